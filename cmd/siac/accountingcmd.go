@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+)
+
+var (
+	accountingCmd = &cobra.Command{
+		Use:   "accounting",
+		Short: "Print the accounting information",
+		Long: `Print the accounting information of the node. By default the current
+accounting information is printed. If --start or --end are provided, the
+persisted accounting information within the range of unix timestamps is printed
+instead.`,
+		Run: wrap(accountingcmd),
+	}
+)
+
+// accountingcmd is the handler for the command `siac accounting`.
+// Prints the accounting information of the node.
+func accountingcmd() {
+	var ag api.AccountingGET
+	var err error
+	if accountingStart == 0 && accountingEnd == 0 {
+		ag, err = httpClient.AccountingGet()
+	} else {
+		end := accountingEnd
+		if end == 0 {
+			end = math.MaxInt64
+		}
+		ag, err = httpClient.AccountingRangeGet(accountingStart, end)
+	}
+	if errors.Contains(err, api.ErrAPICallNotRecognized) {
+		// Assume module is not loaded if status command is not recognized.
+		fmt.Printf("Accounting:\n  Status: %s\n\n", moduleNotReadyStatus)
+		return
+	} else if err != nil {
+		die("Could not get accounting information:", err)
+	}
+
+	if accountingCSV {
+		err = writeAccountingCSV(os.Stdout, ag)
+	} else {
+		err = writeAccountingTable(os.Stdout, ag)
+	}
+	if err != nil {
+		die("Could not write accounting information:", err)
+	}
+}
+
+// writeAccountingCSV writes the accounting information to the writer in CSV
+// format. Currency values are written in hastings.
+func writeAccountingCSV(w io.Writer, ais []modules.AccountingInfo) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"timestamp",
+		"wallet_siacoins", "wallet_siafunds",
+		"renter_unspent_unallocated", "renter_withheld_funds",
+		"host_locked_collateral", "host_risked_collateral", "host_lost_collateral",
+		"host_potential_storage_revenue", "host_storage_revenue",
+		"host_potential_upload_revenue", "host_upload_revenue",
+		"host_potential_download_revenue", "host_download_revenue", "host_lost_revenue",
+		"miner_immature_block_rewards", "miner_immature_fees",
+		"miner_mature_block_rewards", "miner_mature_fees",
+	})
+	if err != nil {
+		return err
+	}
+	for _, ai := range ais {
+		err = cw.Write([]string{
+			fmt.Sprint(ai.Timestamp),
+			ai.Wallet.ConfirmedSiacoinBalance.String(),
+			ai.Wallet.ConfirmedSiafundBalance.String(),
+			ai.Renter.UnspentUnallocated.String(),
+			ai.Renter.WithheldFunds.String(),
+			ai.Host.LockedCollateral.String(),
+			ai.Host.RiskedCollateral.String(),
+			ai.Host.LostCollateral.String(),
+			ai.Host.PotentialStorageRevenue.String(),
+			ai.Host.StorageRevenue.String(),
+			ai.Host.PotentialUploadRevenue.String(),
+			ai.Host.UploadRevenue.String(),
+			ai.Host.PotentialDownloadRevenue.String(),
+			ai.Host.DownloadRevenue.String(),
+			ai.Host.LostRevenue.String(),
+			ai.Miner.ImmatureBlockRewards.String(),
+			ai.Miner.ImmatureFees.String(),
+			ai.Miner.MatureBlockRewards.String(),
+			ai.Miner.MatureFees.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeAccountingTable writes the accounting information to the writer as one
+// table per module.
+func writeAccountingTable(w io.Writer, ais []modules.AccountingInfo) error {
+	if len(ais) == 0 {
+		_, err := fmt.Fprintln(w, "No accounting information found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 4, ' ', 0)
+	timeStr := func(ai modules.AccountingInfo) string {
+		return time.Unix(ai.Timestamp, 0).Format(time.RFC3339)
+	}
+
+	fmt.Fprintf(tw, "Wallet:\n")
+	fmt.Fprintf(tw, "  Time\tSiacoins\tSiafunds\n")
+	for _, ai := range ais {
+		fmt.Fprintf(tw, "  %v\t%v\t%v\n", timeStr(ai),
+			currencyUnits(ai.Wallet.ConfirmedSiacoinBalance), ai.Wallet.ConfirmedSiafundBalance)
+	}
+
+	fmt.Fprintf(tw, "\nRenter:\n")
+	fmt.Fprintf(tw, "  Time\tUnspent Unallocated\tWithheld Funds\n")
+	for _, ai := range ais {
+		fmt.Fprintf(tw, "  %v\t%v\t%v\n", timeStr(ai),
+			currencyUnits(ai.Renter.UnspentUnallocated), currencyUnits(ai.Renter.WithheldFunds))
+	}
+
+	fmt.Fprintf(tw, "\nHost:\n")
+	fmt.Fprintf(tw, "  Time\tLocked Collateral\tRisked Collateral\tLost Collateral\tPotential Revenue\tRevenue\tLost Revenue\n")
+	for _, ai := range ais {
+		h := ai.Host
+		potentialRevenue := h.PotentialStorageRevenue.Add(h.PotentialUploadRevenue).Add(h.PotentialDownloadRevenue)
+		revenue := h.StorageRevenue.Add(h.UploadRevenue).Add(h.DownloadRevenue)
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\t%v\n", timeStr(ai),
+			currencyUnits(h.LockedCollateral), currencyUnits(h.RiskedCollateral), currencyUnits(h.LostCollateral),
+			currencyUnits(potentialRevenue), currencyUnits(revenue), currencyUnits(h.LostRevenue))
+	}
+
+	fmt.Fprintf(tw, "\nMiner:\n")
+	fmt.Fprintf(tw, "  Time\tImmature Rewards\tImmature Fees\tMature Rewards\tMature Fees\n")
+	for _, ai := range ais {
+		m := ai.Miner
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\n", timeStr(ai),
+			currencyUnits(m.ImmatureBlockRewards), currencyUnits(m.ImmatureFees),
+			currencyUnits(m.MatureBlockRewards), currencyUnits(m.MatureFees))
+	}
+	return tw.Flush()
+}
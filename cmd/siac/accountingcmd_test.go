@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestWriteAccountingCSV probes the writeAccountingCSV function
+func TestWriteAccountingCSV(t *testing.T) {
+	var ai modules.AccountingInfo
+	ai.Timestamp = 1234
+	ai.Wallet.ConfirmedSiacoinBalance = types.NewCurrency64(1)
+	ai.Wallet.ConfirmedSiafundBalance = types.NewCurrency64(2)
+	ai.Renter.UnspentUnallocated = types.NewCurrency64(3)
+	ai.Renter.WithheldFunds = types.NewCurrency64(4)
+	ai.Host.LockedCollateral = types.NewCurrency64(5)
+	ai.Miner.MatureFees = types.NewCurrency64(6)
+
+	var buf bytes.Buffer
+	err := writeAccountingCSV(&buf, []modules.AccountingInfo{ai, ai})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %v", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "timestamp,wallet_siacoins,wallet_siafunds,renter_unspent_unallocated,renter_withheld_funds,host_locked_collateral,") {
+		t.Error("unexpected header", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if line != "1234,1,2,3,4,5,0,0,0,0,0,0,0,0,0,0,0,0,6" {
+			t.Error("unexpected line", line)
+		}
+	}
+}
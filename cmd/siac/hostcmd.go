@@ -5,8 +5,10 @@ import (
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -70,6 +72,26 @@ Available settings:
      registrysize:       filesize
      customregistrypath: string
 
+     maxrenteraccounts:        int
+     maxrenterbandwidth:       filesize
+     maxrenterregistryentries: int
+     maxrentersectors:         int
+
+     pricingenabled:                      boolean
+     pricingexchangerate:                 string
+     pricingtargetdownloadbandwidthprice: float
+     pricingtargetstorageprice:           float
+     pricingtargetuploadbandwidthprice:   float
+     pricingbandwidthcapacity:            filesize
+     pricingmaxpricemultiplier:           float
+     pricingsmoothing:                    float
+
+     scrubrate: filesize
+
+     maintenancemode:        boolean
+     maintenancewindowstart: block height
+     maintenancewindowend:   block height
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
 Durations (maxduration and windowsize) must be specified in either blocks (b),
@@ -80,6 +102,25 @@ Timeouts (ephemeralaccountexpiry) must be specified in either seconds (s),
 hours (h), days (d), or weeks (w). One hour is 3600 seconds, a day is 86400
 seconds, and a week is 604800 seconds.
 
+The maxrenter settings limit the resources used by a single renter. A value of
+0 means that there is no limit.
+
+The pricing settings configure the host's dynamic pricing, which raises the
+storage and bandwidth prices as the host's storage, collateral budget and
+bandwidth (pricingbandwidthcapacity is per second) fill up. The target prices
+are per TB (per month for storage) in the currency of the exchange rate, e.g.
+"0.004usd". Run 'siac host pricing' to see the prices it would set.
+
+The scrubrate is the number of bytes per second the host reads to verify the
+integrity of its sectors. A value of 0 disables the sector scrubber. Run
+'siac host scrub' to see its progress and the contracts at risk.
+
+Enabling maintenancemode drains the host before it is taken offline. The host
+keeps serving its existing contracts but stops accepting new contracts and
+renewals. The host refuses contracts whose proof window overlaps the
+maintenance window. Run 'siac host maintenance' to see which contracts still
+need a storage proof before the host can be shut down.
+
 For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
@@ -96,6 +137,7 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 Available output types:
      value:  show financial information
      status: show status information
+     risk:   show whether the storage proofs are at risk
 `,
 		Run: wrap(hostcontractcmd),
 	}
@@ -109,8 +151,17 @@ Available output types:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or migrate a storage folder",
+		Long:  "Add, remove, resize, or migrate a storage folder.",
+	}
+
+	hostFolderMigrateCmd = &cobra.Command{
+		Use:   "migrate [path] [destination]",
+		Short: "Migrate a storage folder to another storage folder",
+		Long: `Move all of the data of a storage folder into another storage folder and
+remove the storage folder afterwards. The migration runs in the background and
+is resumed if the host restarts. The progress is shown by 'siac host'.`,
+		Run: wrap(hostfoldermigratecmd),
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -131,6 +182,94 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostFolderTierCmd = &cobra.Command{
+		Use:   "tier [path] [hdd|ssd]",
+		Short: "Set the tier of a storage folder",
+		Long: `Set the storage tier of a storage folder. Sectors which are read often or which
+belong to short or registry-heavy contracts are moved to the folders of the ssd
+tier in the background, while sectors which are rarely read are moved back to
+the folders of the hdd tier. New folders belong to the hdd tier.`,
+		Run: wrap(hostfoldertiercmd),
+	}
+
+	hostRegistryCmd = &cobra.Command{
+		Use:   "registry",
+		Short: "View and manage the host's registry",
+		Long: `View the usage of the host's registry. This shows the number of entries, the
+distribution of their expiry heights and the public keys using the most
+entries.`,
+		Run: wrap(hostregistrycmd),
+	}
+
+	hostRegistryDeleteCmd = &cobra.Command{
+		Use:   "delete [entryid]",
+		Short: "Delete a registry entry",
+		Long: `Delete the registry entry with the given id from the host's registry. Note that
+the entry might have been paid for by a renter.`,
+		Run: wrap(hostregistrydeletecmd),
+	}
+
+	hostRegistryEntryCmd = &cobra.Command{
+		Use:   "entry [entryid]",
+		Short: "View a registry entry",
+		Long:  "View the registry entry with the given id.",
+		Run:   wrap(hostregistryentrycmd),
+	}
+
+	hostRegistryTruncateCmd = &cobra.Command{
+		Use:   "truncate [size]",
+		Short: "Prune expired entries and resize the registry",
+		Long: `Prune the expired entries of the host's registry and resize it to [size]. The
+registry won't be shrunk below the size required by the remaining entries.`,
+		Run: wrap(hostregistrytruncatecmd),
+	}
+
+	hostBandwidthCmd = &cobra.Command{
+		Use:   "bandwidth",
+		Short: "Show the bandwidth used by the host",
+		Long: `Show the bandwidth used by the host broken down by RPC, MDM instruction and
+renter. The range defaults to all of the retained bandwidth records and can be
+restricted with the --start and --end flags. Download is the data received by
+the host and upload is the data sent by the host.`,
+		Run: wrap(hostbandwidthcmd),
+	}
+
+	hostRentersCmd = &cobra.Command{
+		Use:   "renters",
+		Short: "Show the resources used by each renter",
+		Long: `Show the contracts, sectors, ephemeral accounts, registry entries and
+bandwidth used by each renter of the host. Renters are identified by the public
+key they use in their contracts.`,
+		Run: wrap(hostrenterscmd),
+	}
+
+	hostPricingCmd = &cobra.Command{
+		Use:   "pricing",
+		Short: "Show the state of the host's dynamic pricing",
+		Long: `Show the load of the host, the prices it is currently using and the prices its
+dynamic pricing would set right now. The latter are shown even if dynamic
+pricing is disabled, which allows for trying out a pricing policy before
+enabling it.`,
+		Run: wrap(hostpricingcmd),
+	}
+
+	hostMaintenanceCmd = &cobra.Command{
+		Use:   "maintenance",
+		Short: "Show the maintenance status of the host",
+		Long: `Show whether the host is in maintenance mode and the contracts which still
+need a storage proof before it is safe to shut down the host.`,
+		Run: wrap(hostmaintenancecmd),
+	}
+
+	hostScrubCmd = &cobra.Command{
+		Use:   "scrub",
+		Short: "Show the status of the sector scrubber",
+		Long: `Show the progress of the host's sector scrubber, which periodically re-reads
+all sectors to verify their integrity, and the contracts whose storage proofs are
+at risk because they contain corrupted sectors.`,
+		Run: wrap(hostscrubcmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -196,7 +335,11 @@ func hostcmd() {
 	}
 
 	var connectabilityString string
-	if hg.WorkingStatus == "working" {
+	if hg.WorkingStatus == "draining" {
+		connectabilityString = "Host is in maintenance mode and waiting for its storage proofs."
+	} else if hg.WorkingStatus == "drained" {
+		connectabilityString = "Host is in maintenance mode and safe to shut down."
+	} else if hg.WorkingStatus == "working" {
 		connectabilityString = "Host appears to be working."
 	} else if hg.WorkingStatus == "not working" && hg.ConnectabilityStatus == "connectable" {
 		connectabilityString = "Nobody is connecting to host. Try re-announcing."
@@ -238,6 +381,26 @@ Host Internal Settings:
 	registrysize:       %v
 	customregistrypath: %v
 
+	maxrenteraccounts:        %v
+	maxrenterbandwidth:       %v
+	maxrenterregistryentries: %v
+	maxrentersectors:         %v
+
+	pricingenabled:                      %v
+	pricingexchangerate:                 %v
+	pricingtargetdownloadbandwidthprice: %v / TB
+	pricingtargetstorageprice:           %v / TB / Month
+	pricingtargetuploadbandwidthprice:   %v / TB
+	pricingbandwidthcapacity:            %v/s
+	pricingmaxpricemultiplier:           %v
+	pricingsmoothing:                    %v
+
+	scrubrate: %v/s
+
+	maintenancemode:        %v
+	maintenancewindowstart: %v
+	maintenancewindowend:   %v
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
@@ -292,6 +455,26 @@ RPC Stats:
 			modules.FilesizeUnits(is.RegistrySize),
 			is.CustomRegistryPath,
 
+			is.MaxRenterAccounts,
+			modules.FilesizeUnits(is.MaxRenterBandwidth),
+			is.MaxRenterRegistryEntries,
+			is.MaxRenterSectors,
+
+			yesNo(is.PricingPolicy.Enabled),
+			is.PricingPolicy.ExchangeRate,
+			is.PricingPolicy.TargetDownloadBandwidthPrice,
+			is.PricingPolicy.TargetStoragePrice,
+			is.PricingPolicy.TargetUploadBandwidthPrice,
+			modules.FilesizeUnits(is.PricingPolicy.BandwidthCapacity),
+			is.PricingPolicy.MaxPriceMultiplier,
+			is.PricingPolicy.Smoothing,
+
+			modules.FilesizeUnits(is.ScrubRate),
+
+			yesNo(is.MaintenanceMode),
+			is.MaintenanceWindowStart,
+			is.MaintenanceWindowEnd,
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
@@ -355,11 +538,41 @@ RPC Stats:
 		return
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tPath\n")
+	fmt.Fprintf(w, "\tUsed\tCapacity\t%% Used\tTier\tPath\n")
+	var hasFastTier bool
 	for _, folder := range sg.Folders {
 		curSize := int64(folder.Capacity - folder.CapacityRemaining)
 		pctUsed := 100 * (float64(curSize) / float64(folder.Capacity))
-		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.Path)
+		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\t%s\n", modules.FilesizeUnits(uint64(curSize)), modules.FilesizeUnits(folder.Capacity), pctUsed, folder.Tier, folder.Path)
+		hasFastTier = hasFastTier || folder.Tier == modules.StorageTierSSD
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+
+	// display tiering info
+	if hasFastTier {
+		fmt.Printf(`
+Storage Tiering:
+	Hot Sectors:      %v
+	Promoted Sectors: %v
+	Demoted Sectors:  %v
+`, sg.Tiering.HotSectors, sg.Tiering.PromotedSectors, sg.Tiering.DemotedSectors)
+	}
+
+	// display migration info
+	if len(sg.Migrations) == 0 {
+		return
+	}
+	folderPaths := make(map[uint16]string)
+	for _, folder := range sg.Folders {
+		folderPaths[folder.Index] = folder.Path
+	}
+	fmt.Println("\nStorage Folder Migrations:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "\tMigrated\tFailed\tTotal\tSource\tDestination\n")
+	for _, m := range sg.Migrations {
+		fmt.Fprintf(w, "\t%v\t%v\t%v\t%s\t%s\n", m.SectorsMigrated, m.SectorsFailed, m.SectorsTotal, folderPaths[m.Source], folderPaths[m.Destination])
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
@@ -399,7 +612,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "pricingenabled", "maintenancemode":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -415,7 +628,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// filesize (convert to bytes)
-	case "registrysize":
+	case "registrysize", "maxrenterbandwidth", "pricingbandwidthcapacity", "scrubrate":
 		value, err = parseFilesize(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -429,7 +642,11 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath",
+		"maxrenteraccounts", "maxrenterregistryentries", "maxrentersectors",
+		"pricingexchangerate", "pricingtargetdownloadbandwidthprice", "pricingtargetstorageprice",
+		"pricingtargetuploadbandwidthprice", "pricingmaxpricemultiplier", "pricingsmoothing",
+		"maintenancewindowstart", "maintenancewindowend":
 
 	// invalid settings
 	default:
@@ -475,6 +692,12 @@ func hostcontractcmd() {
 			fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%t\t%t\t%t\t%t\n", so.ObligationId, strings.TrimPrefix(so.ObligationStatus, "obligation"), so.ExpirationHeight, so.OriginConfirmed,
 				so.RevisionConstructed, so.RevisionConfirmed, so.ProofConstructed, so.ProofConfirmed)
 		}
+	case "risk":
+		fmt.Fprintf(w, "Obligation ID\tExpiration Height\tAt Risk\tMissing Sectors\tCorrupted Sectors\tInsufficient Funds\tProof Window Approaching\tEstimated Proof Fee\n")
+		for _, so := range cg.Contracts {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%s\t%s\t%s\n", so.ObligationId, so.ExpirationHeight, yesNo(so.Risk.AtRisk), so.Risk.MissingSectors, so.Risk.CorruptedSectors,
+				yesNo(so.Risk.InsufficientFunds), yesNo(so.Risk.ProofWindowApproaching), currencyUnits(so.Risk.EstimatedProofFee))
+		}
 	default:
 		die("\"" + hostContractOutputType + "\" is not a format")
 	}
@@ -531,6 +754,19 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldermigratecmd migrates a folder of the host to another folder.
+func hostfoldermigratecmd(path, destination string) {
+	maxBandwidth, err := parseRatelimit(hostFolderMigrateMaxBandwidth)
+	if err != nil {
+		die("Could not parse max bandwidth:", err)
+	}
+	err = httpClient.HostStorageFoldersMigratePost(abs(path), abs(destination), uint64(maxBandwidth), hostFolderMigrateMaxIOPS)
+	if err != nil {
+		die("Could not migrate folder:", err)
+	}
+	fmt.Printf("Migrating folder %v to %v\n", path, destination)
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	// Ask for confirm for dangerous --force flag
@@ -550,6 +786,15 @@ func hostfolderremovecmd(path string) {
 	fmt.Println("Removed folder", path)
 }
 
+// hostfoldertiercmd sets the tier of a folder of the host.
+func hostfoldertiercmd(path, tier string) {
+	err := httpClient.HostStorageFoldersTierPost(abs(path), modules.StorageTier(strings.ToLower(tier)))
+	if err != nil {
+		die("Could not set folder tier:", err)
+	}
+	fmt.Printf("Set tier of folder %v to %v\n", path, tier)
+}
+
 // hostfolderresizecmd resizes a folder in the host.
 func hostfolderresizecmd(path, newsize string) {
 	newsize, err := parseFilesize(newsize)
@@ -582,3 +827,282 @@ func hostsectordeletecmd(root string) {
 	}
 	fmt.Println("Deleted sector", root)
 }
+
+// parseRegistryEntryID parses the hex-encoded id of a registry entry.
+func parseRegistryEntryID(entryID string) modules.RegistryEntryID {
+	var hash crypto.Hash
+	err := hash.LoadString(entryID)
+	if err != nil {
+		die("Could not parse entry id:", err)
+	}
+	return modules.RegistryEntryID(hash)
+}
+
+// hostregistrycmd is the handler for the command `siac host registry`. It
+// prints the usage of the host's registry.
+func hostregistrycmd() {
+	hrg, err := httpClient.HostRegistryGet()
+	if err != nil {
+		die("Could not fetch registry stats:", err)
+	}
+	hg, err := httpClient.HostGet()
+	if err != nil {
+		die("Could not fetch host info:", err)
+	}
+	bh := hg.PriceTable.HostBlockHeight
+
+	fmt.Printf(`Registry:
+  Entries:  %v / %v
+  Expired:  %v
+
+Expiry Distribution:
+`, hrg.Entries, hrg.Capacity, hrg.Expired)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, bucket := range hrg.ExpiryDistribution {
+		if i == len(hrg.ExpiryDistribution)-1 {
+			fmt.Fprintf(w, "  later\t%v\n", bucket.Entries)
+			continue
+		}
+		fmt.Fprintf(w, "  within %v\t%v\n", periodUnits(bucket.ExpiresBefore-bh), bucket.Entries)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+
+	if len(hrg.Keys) == 0 {
+		return
+	}
+	keys := hrg.Keys
+	if hostRegistryNumKeys > 0 && len(keys) > hostRegistryNumKeys {
+		keys = keys[:hostRegistryNumKeys]
+	}
+	fmt.Printf("\nUsage by Public Key (%v of %v):\n", len(keys), len(hrg.Keys))
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Public Key\tEntries")
+	for _, ku := range keys {
+		fmt.Fprintf(w, "  %v\t%v\n", ku.PublicKey, ku.Entries)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostregistryentrycmd is the handler for the command `siac host registry
+// entry [entryid]`.
+func hostregistryentrycmd(entryID string) {
+	hreg, err := httpClient.HostRegistryEntryGet(parseRegistryEntryID(entryID))
+	if err != nil {
+		die("Could not fetch registry entry:", err)
+	}
+	entry := hreg.Entry
+	fmt.Printf(`Entry ID:    %v
+Public Key:  %v
+Tweak:       %v
+Type:        %v
+Revision:    %v
+Expiry:      %v
+Data:        %x
+`, entry.EntryID, entry.PublicKey, entry.Tweak, entry.Type, entry.Revision, entry.Expiry, entry.Data)
+}
+
+// hostregistrydeletecmd is the handler for the command `siac host registry
+// delete [entryid]`.
+func hostregistrydeletecmd(entryID string) {
+	err := httpClient.HostRegistryDeletePost(parseRegistryEntryID(entryID))
+	if err != nil {
+		die("Could not delete registry entry:", err)
+	}
+	fmt.Println("Deleted registry entry", entryID)
+}
+
+// hostregistrytruncatecmd is the handler for the command `siac host registry
+// truncate [size]`.
+func hostregistrytruncatecmd(size string) {
+	sizeStr, err := parseFilesize(size)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	sizeBytes, err := strconv.ParseUint(sizeStr, 10, 64)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	err = httpClient.HostRegistryTruncatePost(sizeBytes)
+	if err != nil {
+		die("Could not truncate registry:", err)
+	}
+	fmt.Println("Truncated registry to", modules.FilesizeUnits(sizeBytes))
+}
+
+// hostbandwidthcmd is the handler for the command `siac host bandwidth`.
+func hostbandwidthcmd() {
+	end := hostBandwidthEnd
+	if end == 0 {
+		end = time.Now().Unix()
+	}
+	hbg, err := httpClient.HostBandwidthRangeGet(hostBandwidthStart, end)
+	if err != nil {
+		die("Could not fetch bandwidth usage:", err)
+	}
+	fmt.Printf(`Since Startup (%v):
+	Download: %v
+	Upload:   %v
+
+Range: %v - %v
+`, hbg.StartTime.Format(time.RFC1123), modules.FilesizeUnits(hbg.Download), modules.FilesizeUnits(hbg.Upload),
+		hbg.Start.Format(time.RFC1123), hbg.End.Format(time.RFC1123))
+
+	printUsages := func(title string, usages map[string]modules.HostBandwidthUsage) {
+		fmt.Printf("\n%v:\n", title)
+		if len(usages) == 0 {
+			fmt.Println("  None")
+			return
+		}
+		keys := make([]string, 0, len(usages))
+		for key := range usages {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Name\tCalls\tDownload\tUpload")
+		for _, key := range keys {
+			u := usages[key]
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", key, u.Calls, modules.FilesizeUnits(u.Download), modules.FilesizeUnits(u.Upload))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+	printUsages("RPCs", hbg.RPCs)
+	printUsages("Instructions", hbg.Instructions)
+	printUsages("Renters", hbg.Renters)
+}
+
+// hostrenterscmd is the handler for the command `siac host renters`.
+func hostrenterscmd() {
+	hrg, err := httpClient.HostRentersGet()
+	if err != nil {
+		die("Could not fetch renter usage:", err)
+	}
+	if len(hrg.Renters) == 0 {
+		fmt.Println("No renters are using the host.")
+		return
+	}
+	fmt.Printf("Bandwidth period started: %v\n\n", hrg.Renters[0].BandwidthPeriodStart.Format(time.RFC1123))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Public Key\tContracts\tSectors\tAccounts\tRegistry Entries\tDownload\tUpload")
+	for _, ru := range hrg.Renters {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", ru.PublicKey, ru.Contracts, ru.Sectors, ru.Accounts, ru.RegistryEntries, modules.FilesizeUnits(ru.DownloadBandwidth), modules.FilesizeUnits(ru.UploadBandwidth))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostpricingcmd is the handler for the command `siac host pricing`.
+func hostpricingcmd() {
+	hpg, err := httpClient.HostPricingGet()
+	if err != nil {
+		die("Could not fetch pricing:", err)
+	}
+	lastUpdate := "never"
+	if !hpg.LastUpdate.IsZero() {
+		lastUpdate = hpg.LastUpdate.Format(time.RFC1123)
+	}
+	fmt.Printf(`Dynamic Pricing:
+	Enabled:     %v
+	Last Update: %v
+
+Load:
+	Storage Utilization:           %.2f%%
+	Collateral Budget Utilization: %.2f%%
+	Download Bandwidth Load:       %.2f%%
+	Upload Bandwidth Load:         %.2f%%
+
+`, yesNo(hpg.Enabled), lastUpdate,
+		hpg.StorageUtilization*100, hpg.CollateralBudgetUtilization*100,
+		hpg.DownloadBandwidthLoad*100, hpg.UploadBandwidthLoad*100)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tCurrent\tDry Run")
+	fmt.Fprintf(w, "Storage Price\t%v / TB / Month\t%v / TB / Month\n", currencyUnits(hpg.Current.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)), currencyUnits(hpg.DryRun.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)))
+	fmt.Fprintf(w, "Download Price\t%v / TB\t%v / TB\n", currencyUnits(hpg.Current.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)), currencyUnits(hpg.DryRun.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+	fmt.Fprintf(w, "Upload Price\t%v / TB\t%v / TB\n", currencyUnits(hpg.Current.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)), currencyUnits(hpg.DryRun.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostscrubcmd is the handler for the command `siac host scrub`.
+func hostscrubcmd() {
+	hsg, err := httpClient.HostScrubGet()
+	if err != nil {
+		die("Could not fetch scrub report:", err)
+	}
+	rate := "disabled"
+	if hsg.Rate > 0 {
+		rate = modules.FilesizeUnits(hsg.Rate) + "/s"
+	}
+	cycleStart := "never"
+	if !hsg.CycleStart.IsZero() {
+		cycleStart = hsg.CycleStart.Format(time.RFC1123)
+	}
+	lastCycleEnd := "never"
+	if !hsg.LastCycleEnd.IsZero() {
+		lastCycleEnd = hsg.LastCycleEnd.Format(time.RFC1123)
+	}
+	fmt.Printf(`Sector Scrubber:
+	Rate:              %v
+	Cycle Started:     %v
+	Last Cycle Ended:  %v
+	Sectors Scrubbed:  %v / %v
+	Corrupted Sectors: %v
+`, rate, cycleStart, lastCycleEnd, hsg.SectorsScrubbed, hsg.SectorsTotal, hsg.CorruptedSectors)
+
+	if len(hsg.ObligationsAtRisk) == 0 {
+		fmt.Println("\nNo contracts are at risk.")
+		return
+	}
+	fmt.Println("\nContracts At Risk:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Obligation ID\tProof Deadline\tCorrupted Sectors")
+	for _, oar := range hsg.ObligationsAtRisk {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", oar.ObligationID, oar.ProofDeadline, len(oar.CorruptedSectors))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostmaintenancecmd is the handler for the command `siac host maintenance`.
+// It prints the maintenance status of the host and the contracts which still
+// need a storage proof.
+func hostmaintenancecmd() {
+	hmg, err := httpClient.HostMaintenanceGet()
+	if err != nil {
+		die("Could not fetch maintenance status:", err)
+	}
+	window := "none"
+	if hmg.WindowEnd != 0 {
+		window = fmt.Sprintf("blocks %v - %v", hmg.WindowStart, hmg.WindowEnd)
+	}
+	fmt.Printf(`Maintenance:
+	Maintenance Mode:   %v
+	Maintenance Window: %v
+	Pending Contracts:  %v
+	Drain Height:       %v
+	Safe To Shut Down:  %v
+`, yesNo(hmg.MaintenanceMode), window, len(hmg.PendingObligations), hmg.DrainHeight, yesNo(hmg.SafeToShutDown))
+
+	if len(hmg.PendingObligations) == 0 {
+		return
+	}
+	fmt.Println("\nContracts Awaiting Storage Proof:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Obligation ID\tProof Window Start\tProof Window End\tProof Constructed")
+	for _, po := range hmg.PendingObligations {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", po.ObligationID, po.ProofWindowStart, po.ProofWindowEnd, yesNo(po.ProofConstructed))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/node/api/client"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -23,6 +24,11 @@ var (
 
 	// Module Specific Flags
 	//
+	// Accounting Flags
+	accountingCSV   bool  // Print the accounting information in CSV format
+	accountingEnd   int64 // End of the accounting information range
+	accountingStart int64 // Start of the accounting information range
+
 	// Daemon Flags
 	daemonStackOutputFile  string // The file that the stack trace will be written to
 	daemonCPUProfile       bool   // Indicates that the CPU profile should be started
@@ -31,8 +37,13 @@ var (
 	daemonTraceProfile     bool   // Indicates that the Trace profile should be started
 
 	// Host Flags
-	hostContractOutputType string // output type for host contracts
-	hostFolderRemoveForce  bool   // force folder remove
+	hostBandwidthEnd              int64  // end of the host bandwidth range
+	hostBandwidthStart            int64  // start of the host bandwidth range
+	hostContractOutputType        string // output type for host contracts
+	hostFolderMigrateMaxBandwidth string // max bandwidth of a folder migration
+	hostFolderMigrateMaxIOPS      uint64 // max iops of a folder migration
+	hostFolderRemoveForce         bool   // force folder remove
+	hostRegistryNumKeys           int    // number of public keys to show the registry usage of
 
 	// Renter Flags
 	dataPieces                string // the number of data pieces a file should be uploaded with
@@ -44,10 +55,17 @@ var (
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
+	renterFuseMountReadOnly   bool   // Mount fuse with 'ReadOnly' set to true.
 	renterListRecursive       bool   // List files of folder recursively.
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
+	renterRegistryRevision    uint64 // Revision of a registry entry to set or wait for.
+	renterRegistryTimeout     uint64 // Timeout in seconds of a registry watch request.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
 	renterShowHistory         bool   // Show download history in addition to download queue.
+	renterUploadDedup         bool   // Upload files in dedup mode.
+	renterUploadPack          bool   // Pack small files of a folder into shared chunks.
+	renterUploadErasureCoder  string // The erasure coder a file should be uploaded with.
+	renterCipherType          string // The cipher type a file should be encrypted with.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -80,10 +98,22 @@ var (
 	// Wallet Flags
 	initForce            bool   // destroy and re-encrypt the wallet on init if it already exists
 	initPassword         bool   // supply a custom password when creating a wallet
+	walletBumpFee        string // Fee of a bumped transaction.
+	walletCoinSelection  string // Strategy used to pick the outputs which fund a transaction.
+	walletInputs         string // Comma-separated outputs which fund a transaction.
+	walletBroadcast      bool   // Broadcast a finalized multisig transaction.
+	walletConfirmations  uint64 // Confirmations after which a payment is reported.
+	walletOfflineHeight  uint64 // Height for which offline signatures are created.
+	walletOutput         string // File which the output of offline commands is written to.
+	walletBumpStrategy   string // Strategy used to bump the fee of a transaction.
 	walletRawTxn         bool   // Encode/decode transactions in base64-encoded binary.
+	walletReplaceable    bool   // Mark a transaction as replaceable.
 	walletStartHeight    uint64 // Start height for transaction search.
 	walletEndHeight      uint64 // End height for transaction search.
 	walletTxnFeeIncluded bool   // include the fee in the balance being sent
+	walletWatched        bool   // Subscribe to the payments of all watched addresses.
+	walletWatchUnused    bool   // Watch addresses without rescanning the blockchain.
+	walletWebhook        string // Url which payment events are POSTed to.
 	insecureInput        bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
 )
 
@@ -289,6 +319,11 @@ func initCmds() *cobra.Command {
 	}
 
 	// create command tree (alphabetized by root command)
+	root.AddCommand(accountingCmd)
+	accountingCmd.Flags().BoolVar(&accountingCSV, "csv", false, "Print the accounting information in CSV format")
+	accountingCmd.Flags().Int64Var(&accountingEnd, "end", 0, "Unix timestamp of the end of the range of persisted accounting information")
+	accountingCmd.Flags().Int64Var(&accountingStart, "start", 0, "Unix timestamp of the start of the range of persisted accounting information")
+
 	root.AddCommand(consensusCmd)
 	root.AddCommand(jsonCmd)
 
@@ -297,10 +332,16 @@ func initCmds() *cobra.Command {
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostBandwidthCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostMaintenanceCmd, hostPricingCmd, hostRegistryCmd, hostRentersCmd, hostScrubCmd, hostSectorCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderMigrateCmd, hostFolderRemoveCmd, hostFolderResizeCmd, hostFolderTierCmd)
+	hostRegistryCmd.AddCommand(hostRegistryDeleteCmd, hostRegistryEntryCmd, hostRegistryTruncateCmd)
+	hostRegistryCmd.Flags().IntVarP(&hostRegistryNumKeys, "numkeys", "n", 10, "Number of public keys to show the registry usage of, 0 shows all keys")
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostBandwidthCmd.Flags().Int64Var(&hostBandwidthEnd, "end", 0, "Unix timestamp of the end of the range, defaults to now")
+	hostBandwidthCmd.Flags().Int64Var(&hostBandwidthStart, "start", 0, "Unix timestamp of the start of the range")
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostFolderMigrateCmd.Flags().StringVar(&hostFolderMigrateMaxBandwidth, "max-bandwidth", "0", "Max bandwidth of the migration, e.g. 50MB/s, 0 means no limit")
+	hostFolderMigrateCmd.Flags().Uint64Var(&hostFolderMigrateMaxIOPS, "max-iops", 0, "Max disk operations per second of the migration, 0 means no limit")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
 
 	root.AddCommand(hostdbCmd)
@@ -316,7 +357,7 @@ func initCmds() *cobra.Command {
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
 		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
+		renterRegistryCmd, renterRekeyCmd, renterRekeysCmd, renterSetDefaultCipherCmd, renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
 		renterHealthSummaryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
@@ -335,6 +376,11 @@ func initCmds() *cobra.Command {
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().StringVar(&renterUploadErasureCoder, "erasure-coder", "", "the erasure coder a file should be uploaded with, either 'reedsolomon' or 'leopard' for wide stripes of more than 256 pieces")
+	renterFilesUploadCmd.Flags().StringVar(&renterCipherType, "cipher", "", "the cipher a file should be encrypted with, either 'threefish512' or 'XChaCha20'")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadPack, "pack", false, "pack the small files of a folder into shared chunks")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadDedup, "dedup", false, "upload the files in dedup mode, skipping chunks which were already uploaded by other files in dedup mode")
+	renterRekeyCmd.Flags().StringVar(&renterCipherType, "cipher", "", "the cipher the file should be re-encrypted with, either 'threefish512' or 'XChaCha20'")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
 
@@ -355,6 +401,12 @@ func initCmds() *cobra.Command {
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
+	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountReadOnly, "read-only", "", false, "Mount the fuse directory read-only")
+
+	renterRegistryCmd.AddCommand(renterRegistryGetCmd, renterRegistrySetCmd, renterRegistryWatchCmd)
+	renterRegistrySetCmd.Flags().Uint64Var(&renterRegistryRevision, "revision", 0, "the revision number of the new value, defaults to the current revision plus one")
+	renterRegistryWatchCmd.Flags().Uint64Var(&renterRegistryRevision, "revision", 0, "the minimum revision number of the first printed value")
+	renterRegistryWatchCmd.Flags().Uint64Var(&renterRegistryTimeout, "timeout", 0, "the timeout in seconds of a single watch request, defaults to the maximum allowed by the renter")
 
 	// Daemon Commands
 	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
@@ -374,20 +426,36 @@ func initCmds() *cobra.Command {
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
-		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
-		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletBumpCmd, walletChangepasswordCmd,
+		walletFreezeCmd, walletFrozenCmd, walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletMultisigCmd, walletOfflineCmd, walletSeedsCmd, walletSendCmd,
+		walletSignCmd, walletSubscriptionsCmd, walletSweepCmd, walletTransactionsCmd, walletUnfreezeCmd, walletUnlockCmd, walletWatchCmd)
+	walletBumpCmd.Flags().StringVarP(&walletBumpStrategy, "strategy", "s", string(modules.FeeBumpRBF), "Strategy used to bump the fee, either rbf or cpfp")
+	walletBumpCmd.Flags().StringVarP(&walletBumpFee, "fee", "f", "", "New miner fee, picked by the wallet if not supplied")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
+	walletMultisigCmd.AddCommand(walletMultisigCombineCmd, walletMultisigCreateCmd, walletMultisigFinalizeCmd, walletMultisigInspectCmd, walletMultisigSignCmd)
+	walletMultisigFinalizeCmd.Flags().BoolVarP(&walletBroadcast, "broadcast", "", false, "Broadcast the finalized transaction")
+	walletOfflineCmd.AddCommand(walletOfflineAddressesCmd, walletOfflineSignCmd)
+	walletOfflineCmd.PersistentFlags().StringVarP(&walletOutput, "output", "o", "", "Write the output to a file instead of stdout")
+	walletOfflineSignCmd.Flags().Uint64Var(&walletOfflineHeight, "height", uint64(types.FoundationHardforkHeight), "Height for which the signatures are created")
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletCoinSelection, "strategy", "", "", "Coin selection strategy: largestfirst, smallestfirst, privacy or branchandbound")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletInputs, "inputs", "", "", "Comma-separated ids of the outputs to spend")
+	walletSendSiacoinsCmd.Flags().BoolVarP(&walletReplaceable, "replaceable", "", false, "Mark the transaction as replaceable so that its fee can be bumped with rbf")
 	walletSendSiacoinsCmd.Flags().BoolVarP(&walletTxnFeeIncluded, "fee-included", "", false, "Take the transaction fee out of the balance being submitted instead of the fee being additional")
 	walletUnlockCmd.Flags().BoolVarP(&insecureInput, "insecure-input", "", false, "Disable shoulder-surf protection (echoing passwords and seeds)")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
 	walletBroadcastCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Decode transaction as base64 instead of JSON")
 	walletSignCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode signed transaction as base64 instead of JSON")
+	walletSignCmd.Flags().Uint64Var(&walletOfflineHeight, "height", uint64(types.FoundationHardforkHeight), "Height for which the signatures are created when signing without siad")
+	walletSubscriptionsCmd.AddCommand(walletSubscriptionsAddCmd, walletSubscriptionsEventsCmd, walletSubscriptionsRemoveCmd)
+	walletSubscriptionsAddCmd.Flags().Uint64Var(&walletConfirmations, "confirmations", 1, "Confirmations after which a payment is reported as confirmed")
+	walletSubscriptionsAddCmd.Flags().BoolVarP(&walletWatched, "watched", "", false, "Also subscribe to all addresses watched by the wallet")
+	walletSubscriptionsAddCmd.Flags().StringVarP(&walletWebhook, "webhook", "", "", "Url the payment events are POSTed to")
 	walletTransactionsCmd.Flags().Uint64Var(&walletStartHeight, "startheight", 0, " Height of the block where transaction history should begin.")
+	walletWatchCmd.Flags().BoolVarP(&walletWatchUnused, "unused", "", false, "Don't rescan the blockchain because the addresses are unused")
 	walletTransactionsCmd.Flags().Uint64Var(&walletEndHeight, "endheight", math.MaxUint64, " Height of the block where transaction history should end.")
 
 	return root
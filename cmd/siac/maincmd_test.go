@@ -57,6 +57,7 @@ Wallet:
 Renter:
   Files:                   \d+
   Total Stored:            \d+(\.\d+|) ( B|kB|MB|GB|TB)
+  Dedup Savings:           \d+(\.\d+|) ( B|kB|MB|GB|TB)
   Total Renewing Data:     \d+(\.\d+|) ( B|kB|MB|GB|TB)
   Repair Data Remaining:   \d+(\.\d+|) ( B|kB|MB|GB|TB)
   Stuck Repair Remaining:  \d+(\.\d+|) ( B|kB|MB|GB|TB)
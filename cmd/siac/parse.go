@@ -16,6 +16,7 @@ import (
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
@@ -352,6 +353,23 @@ func parseTxn(s string) (types.Transaction, error) {
 	return txn, nil
 }
 
+// parsePartiallySignedTxn decodes a partially signed transaction from a JSON
+// string or a file containing JSON.
+func parsePartiallySignedTxn(s string) (modules.PartiallySignedTransaction, error) {
+	pstBytes, err := ioutil.ReadFile(s)
+	if os.IsNotExist(err) {
+		// assume s is a literal encoding
+		pstBytes = []byte(s)
+	} else if err != nil {
+		return modules.PartiallySignedTransaction{}, errors.New("could not read partially signed transaction file: " + err.Error())
+	}
+	var pst modules.PartiallySignedTransaction
+	if err := json.Unmarshal(pstBytes, &pst); err != nil {
+		return modules.PartiallySignedTransaction{}, errors.New("could not decode JSON partially signed transaction: " + err.Error())
+	}
+	return pst, nil
+}
+
 // fmtDuration converts a time.Duration into a days,hours,minutes string
 func fmtDuration(dur time.Duration) string {
 	dur = dur.Round(time.Minute)
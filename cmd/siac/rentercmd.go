@@ -5,6 +5,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -22,7 +23,9 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/node/api/client"
@@ -179,10 +182,9 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Use:   "mount [path] [siapath]",
 		Short: "Mount a Sia folder to your disk",
 		Long: `Mount a Sia folder to your disk. Applications will be able to see this folder
-as though it is a normal part of your filesystem.  Currently experimental, and
-read-only. When Sia is ready to support read-write fuse mounting, siac will be
-updated to mount in read-write mode as the default. If you must guarantee that
-read-only mode is used, you must use the API.`,
+as though it is a normal part of your filesystem.  Currently experimental.
+The folder is mounted read-write unless --read-only is passed. Files written to
+the folder are spooled to disk and uploaded when they are closed.`,
 		Run: wrap(renterfusemountcmd),
 	}
 
@@ -194,6 +196,67 @@ local path where the Sia folder is mounted.`,
 		Run: wrap(renterfuseunmountcmd),
 	}
 
+	renterRegistryCmd = &cobra.Command{
+		Use:   "registry",
+		Short: "Read, update and watch registry entries",
+		Long: `Read, update and watch the registry entries stored on the hosts the renter has
+contracts with.`,
+	}
+
+	renterRegistryGetCmd = &cobra.Command{
+		Use:   "get [publickey] [datakey]",
+		Short: "Read a registry entry",
+		Long: `Read the registry entry with the given public key and data key from the hosts.
+The public key is expected in the format 'ed25519:<hex>' and the data key as a
+hex-encoded hash.`,
+		Run: wrap(renterregistrygetcmd),
+	}
+
+	renterRegistrySetCmd = &cobra.Command{
+		Use:   "set [datakey] [data]",
+		Short: "Update a registry entry",
+		Long: `Update the registry entry with the given data key to contain [data]. The
+hex-encoded ed25519 secret key which signs the entry is read from stdin. The
+revision number of the entry is incremented automatically unless --revision is
+set.`,
+		Run: wrap(renterregistrysetcmd),
+	}
+
+	renterRegistryWatchCmd = &cobra.Command{
+		Use:   "watch [publickey] [datakey]",
+		Short: "Watch a registry entry for updates",
+		Long: `Watch the registry entry with the given public key and data key and print every
+update of the entry until the command is interrupted. Only hosts which support
+registry subscriptions are used to watch the entry.`,
+		Run: wrap(renterregistrywatchcmd),
+	}
+
+	renterRekeyCmd = &cobra.Command{
+		Use:   "rekey [path]",
+		Short: "Re-encrypt a file under a new key",
+		Long: `Re-encrypt the file at [path] under a newly generated key. The file is
+downloaded, encrypted with the new key and uploaded again in the background
+before it replaces the original file. The --cipher flag can be used to change
+the cipher of the file, otherwise the file's current cipher is used. Run
+'siac renter rekeys' to check the progress.`,
+		Run: wrap(renterrekeycmd),
+	}
+
+	renterRekeysCmd = &cobra.Command{
+		Use:   "rekeys",
+		Short: "View the files being re-encrypted",
+		Long:  "View the progress of the files which are being re-encrypted and the result of the files re-encrypted since siad started.",
+		Run:   wrap(renterrekeyscmd),
+	}
+
+	renterSetDefaultCipherCmd = &cobra.Command{
+		Use:   "setdefaultcipher [cipher]",
+		Short: "Set the default cipher for uploads",
+		Long: `Set the cipher which is used to encrypt uploaded files if no cipher was
+specified for the upload. Available ciphers are 'threefish512' and 'XChaCha20'.`,
+		Run: wrap(rentersetdefaultciphercmd),
+	}
+
 	renterSetLocalPathCmd = &cobra.Command{
 		Use:   "setlocalpath [siapath] [newlocalpath]",
 		Short: "Changes the local path of the file",
@@ -212,7 +275,11 @@ local path where the Sia folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the Sia network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. The --erasure-coder flag can be
+set to 'leopard' to use wide stripes of more than 256 pieces. The --cipher flag can be set
+to 'threefish512' or 'XChaCha20' to override the renter's default cipher. When uploading a
+folder, the --pack flag can be set to pack all files which fit within a single sector into
+shared chunks.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
@@ -480,6 +547,10 @@ func rentercmd() {
 		die(err)
 	}
 
+	// Print out the default cipher of the renter
+	fmt.Println()
+	fmt.Println("Default Cipher:", rg.Settings.DefaultCipherType)
+
 	// Print out ratelimit info about the renter
 	fmt.Println()
 	rateLimitSummary(rg.Settings.MaxDownloadSpeed, rg.Settings.MaxUploadSpeed)
@@ -1893,10 +1964,6 @@ func renterfusecmd() {
 
 // renterfusemountcmd is the handler for the command `siac renter fuse mount [path] [siapath]`.
 func renterfusemountcmd(path, siaPathStr string) {
-	// TODO: Once read-write is supported on the backend, the 'true' flag can be
-	// set to 'false' - siac will support mounting read-write by default. Need
-	// to update the help string of the command to indicate that mounting will
-	// mount in read-write mode.
 	path = abs(path)
 	var siaPath modules.SiaPath
 	var err error
@@ -1909,7 +1976,7 @@ func renterfusemountcmd(path, siaPathStr string) {
 		}
 	}
 	opts := modules.MountOptions{
-		ReadOnly:   true,
+		ReadOnly:   renterFuseMountReadOnly,
 		AllowOther: renterFuseMountAllowOther,
 	}
 	err = httpClient.RenterFuseMount(path, siaPath, opts)
@@ -1946,6 +2013,172 @@ func rentersetlocalpathcmd(siapath, newlocalpath string) {
 	fmt.Printf("Updated %s localpath to %s\n", siapath, newlocalpath)
 }
 
+// parseRegistryEntryArgs parses the public key and data key of a registry
+// entry.
+func parseRegistryEntryArgs(pubKeyStr, dataKeyStr string) (types.SiaPublicKey, crypto.Hash) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(pubKeyStr); err != nil {
+		die("Couldn't parse public key:", err)
+	}
+	var dataKey crypto.Hash
+	if err := dataKey.LoadString(dataKeyStr); err != nil {
+		die("Couldn't parse data key:", err)
+	}
+	return spk, dataKey
+}
+
+// isRegistryEntryNotFoundErr returns true if the error returned by the API
+// indicates that the registry entry couldn't be found on any host.
+func isRegistryEntryNotFoundErr(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), renter.ErrRegistryEntryNotFound.Error()) ||
+		strings.Contains(err.Error(), renter.ErrRegistryLookupTimeout.Error()))
+}
+
+// printRegistryValue prints a registry value.
+func printRegistryValue(srv modules.SignedRegistryValue) {
+	fmt.Printf(`Revision:  %v
+Data:      %x
+Type:      %v
+Signature: %x
+`, srv.Revision, srv.Data, srv.Type, srv.Signature[:])
+}
+
+// renterregistrygetcmd is the handler for the command `siac renter registry
+// get [publickey] [datakey]`.
+func renterregistrygetcmd(pubKeyStr, dataKeyStr string) {
+	spk, dataKey := parseRegistryEntryArgs(pubKeyStr, dataKeyStr)
+	srv, err := httpClient.RenterRegistryGet(spk, dataKey, 0)
+	if isRegistryEntryNotFoundErr(err) {
+		fmt.Println("Registry entry not found.")
+		return
+	}
+	if err != nil {
+		die("Could not read registry entry:", err)
+	}
+	printRegistryValue(srv)
+}
+
+// renterregistrysetcmd is the handler for the command `siac renter registry
+// set [datakey] [data]`. It signs and publishes a new value for the registry
+// entry.
+func renterregistrysetcmd(dataKeyStr, data string) {
+	var dataKey crypto.Hash
+	if err := dataKey.LoadString(dataKeyStr); err != nil {
+		die("Couldn't parse data key:", err)
+	}
+	if len(data) > modules.RegistryDataSize {
+		die(fmt.Sprintf("Data is too large, the maximum size is %v bytes", modules.RegistryDataSize))
+	}
+
+	// Read the secret key.
+	skStr, err := passwordPrompt("Secret key: ")
+	if err != nil {
+		die("Reading secret key failed:", err)
+	}
+	var sk crypto.SecretKey
+	skBytes, err := hex.DecodeString(skStr)
+	if err != nil || len(skBytes) != len(sk) {
+		die("Secret key must be a hex-encoded ed25519 secret key")
+	}
+	copy(sk[:], skBytes)
+	spk := types.Ed25519PublicKey(sk.PublicKey())
+
+	// Determine the revision number.
+	revision := renterRegistryRevision
+	if revision == 0 {
+		srv, err := httpClient.RenterRegistryGet(spk, dataKey, 0)
+		if err == nil {
+			revision = srv.Revision + 1
+		} else if !isRegistryEntryNotFoundErr(err) {
+			die("Could not read current revision of registry entry:", err)
+		}
+	}
+
+	// Sign and publish the value.
+	srv := modules.NewRegistryValue(dataKey, []byte(data), revision, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	err = httpClient.RenterRegistryPost(spk, srv)
+	if err != nil {
+		die("Could not update registry entry:", err)
+	}
+	fmt.Printf("Updated registry entry of %v to revision %v\n", spk, revision)
+}
+
+// renterregistrywatchcmd is the handler for the command `siac renter registry
+// watch [publickey] [datakey]`. It prints updates of the registry entry until
+// the command is interrupted.
+func renterregistrywatchcmd(pubKeyStr, dataKeyStr string) {
+	spk, dataKey := parseRegistryEntryArgs(pubKeyStr, dataKeyStr)
+	revision := renterRegistryRevision
+	timeout := time.Duration(renterRegistryTimeout) * time.Second
+	for {
+		srv, err := httpClient.RenterRegistryWatchGet(spk, dataKey, revision, timeout)
+		if err != nil && strings.Contains(err.Error(), renter.ErrRegistryWatchTimeout.Error()) {
+			continue
+		}
+		if err != nil {
+			die("Could not watch registry entry:", err)
+		}
+		printRegistryValue(srv)
+		fmt.Println()
+		revision = srv.Revision + 1
+	}
+}
+
+// renterrekeycmd is the handler for the command `siac renter rekey [path]`.
+// It re-encrypts a file under a new key.
+func renterrekeycmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	err = httpClient.RenterFileRekeyPost(siaPath, renterCipherType)
+	if err != nil {
+		die("Could not rekey file:", err)
+	}
+	fmt.Printf("Re-encrypting '%s' under a new key in the background.\n", path)
+}
+
+// renterrekeyscmd is the handler for the command `siac renter rekeys`. It lists
+// the files which are being re-encrypted.
+func renterrekeyscmd() {
+	rrg, err := httpClient.RenterRekeyGet()
+	if err != nil {
+		die("Could not get re-encrypted files:", err)
+	}
+	if len(rrg.Rekeys) == 0 {
+		fmt.Println("No files are being re-encrypted.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Path\tCipher\tSize\tProgress\tStatus")
+	for _, info := range rrg.Rekeys {
+		progress := 100.0
+		if info.Size > 0 {
+			progress = 100 * float64(info.Processed) / float64(info.Size)
+		}
+		status := "re-encrypting"
+		if info.Error != "" {
+			status = "failed: " + info.Error
+		} else if info.Finished {
+			status = "finished"
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%.2f%%\t%v\n", info.SiaPath, info.CipherType, modules.FilesizeUnits(info.Size), progress, status)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentersetdefaultciphercmd is the handler for the command `siac renter
+// setdefaultcipher [cipher]`. It sets the renter's default upload cipher.
+func rentersetdefaultciphercmd(cipher string) {
+	err := httpClient.RenterSetDefaultCipherTypePost(cipher)
+	if err != nil {
+		die("Could not set default cipher:", err)
+	}
+	fmt.Printf("Set default cipher to %s\n", cipher)
+}
+
 // renterfilesunstuckcmd is the handler for the command `siac renter
 // unstuckall`. Sets all files to unstuck.
 func renterfilesunstuckcmd() {
@@ -2017,9 +2250,19 @@ func renterfilesuploadcmd(source, path string) {
 		die("Could not parse data and parity pieces:", err)
 	}
 
+	// Determine how the files should be uploaded.
+	if renterUploadDedup && renterUploadPack {
+		die("Can't combine --dedup and --pack")
+	}
+	uploadFile := httpClient.RenterUploadCustomPost
+	if renterUploadDedup {
+		uploadFile = httpClient.RenterUploadDedupPost
+	}
+
 	if stat.IsDir() {
 		// folder
 		var files []string
+		var packedFiles []string
 		err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				fmt.Println("Warning: skipping file:", err)
@@ -2028,16 +2271,21 @@ func renterfilesuploadcmd(source, path string) {
 			if info.IsDir() {
 				return nil
 			}
+			// Files which fit within a single sector are packed if requested.
+			if renterUploadPack && info.Size() > 0 && uint64(info.Size()) <= modules.SectorSize {
+				packedFiles = append(packedFiles, path)
+				return nil
+			}
 			files = append(files, path)
 			return nil
 		})
 		if err != nil {
 			die("Could not read folder:", err)
-		} else if len(files) == 0 {
+		} else if len(files)+len(packedFiles) == 0 {
 			die("Nothing to upload.")
 		}
-		failed := 0
-		for _, file := range files {
+		// fileSiaPath returns the SiaPath of a file within the folder.
+		fileSiaPath := func(file string) modules.SiaPath {
 			fpath, _ := filepath.Rel(source, file)
 			fpath = filepath.Join(path, fpath)
 			fpath = filepath.ToSlash(fpath)
@@ -2046,13 +2294,33 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse SiaPath:", err)
 			}
-			err = httpClient.RenterUploadPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
+			return fSiaPath
+		}
+		failed := 0
+		if len(packedFiles) > 0 {
+			packed := make([]modules.PackedUploadFile, 0, len(packedFiles))
+			for _, file := range packedFiles {
+				packed = append(packed, modules.PackedUploadFile{
+					Source:  abs(file),
+					SiaPath: fileSiaPath(file),
+				})
+			}
+			err = httpClient.RenterUploadPackedPost(packed, uint64(numDataPieces), uint64(numParityPieces), renterUploadErasureCoder, renterCipherType, false)
+			if err != nil {
+				failed += len(packedFiles)
+				fmt.Printf("Could not upload packed files: %v\n", err)
+			}
+		}
+		for _, file := range files {
+			fSiaPath := fileSiaPath(file)
+			err = uploadFile(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces), renterUploadErasureCoder, renterCipherType, false)
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
 			}
 		}
-		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", len(files)-failed, len(files), path)
+		total := len(files) + len(packedFiles)
+		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", total-failed, total, path)
 	} else {
 		// single file
 		// Parse SiaPath.
@@ -2060,7 +2328,7 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse SiaPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		err = uploadFile(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces), renterUploadErasureCoder, renterCipherType, false)
 		if err != nil {
 			die("Could not upload file:", err)
 		}
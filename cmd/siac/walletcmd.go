@@ -5,11 +5,13 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -56,6 +58,20 @@ be valid. txn may be either JSON, base64, or a file containing either.`,
 		Run: wrap(walletbroadcastcmd),
 	}
 
+	walletBumpCmd = &cobra.Command{
+		Use:   "bump [txid]",
+		Short: "Bump the fee of an unconfirmed transaction",
+		Long: `Bump the fee of a transaction which is stuck in the transaction pool.
+
+The rbf strategy replaces the transaction set with a copy that spends the same
+inputs but pays a higher fee. It only works for transactions which were sent
+with --replaceable. The cpfp strategy attaches a child transaction
+which pays the fee for the whole set. The fee can be specified in units, e.g.
+1.23mS. For rbf it is the new fee of the whole set, for cpfp the fee of the
+child. If no fee is supplied, the wallet picks one.`,
+		Run: wrap(walletbumpcmd),
+	}
+
 	walletChangepasswordCmd = &cobra.Command{
 		Use:   "change-password",
 		Short: "Change the wallet password",
@@ -82,6 +98,21 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletFreezeCmd = &cobra.Command{
+		Use:   "freeze [outputids]",
+		Short: "Freeze outputs of the wallet",
+		Long: `Freeze a comma-separated list of outputs. The wallet doesn't spend frozen
+outputs, which allows other tools to reserve them.`,
+		Run: wrap(walletfreezecmd),
+	}
+
+	walletFrozenCmd = &cobra.Command{
+		Use:   "frozen",
+		Short: "List frozen outputs",
+		Long:  "List the outputs which are frozen in the wallet.",
+		Run:   wrap(walletfrozencmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -133,6 +164,105 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletlockcmd),
 	}
 
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig",
+		Short: "Build transactions with co-signers",
+		Long: `Pass a partially signed transaction between the co-signers of multisig
+inputs. The partially signed transaction carries the transaction, the unlock
+conditions and values of its inputs, and the signatures collected so far.
+
+A partially signed transaction may be either JSON or a file containing JSON.`,
+		// Run field is not set, as the multisig command itself is not a valid
+		// command. A subcommand must be provided.
+	}
+
+	walletMultisigCombineCmd = &cobra.Command{
+		Use:   "combine [pst] [pst]...",
+		Short: "Combine copies of a partially signed transaction",
+		Long: `Merge the signatures of copies of the same partially signed transaction which
+were signed by different co-signers. This doesn't require siad.`,
+		Run: walletmultisigcombinecmd,
+	}
+
+	walletMultisigCreateCmd = &cobra.Command{
+		Use:   "create [txn]",
+		Short: "Create a partially signed transaction",
+		Long: `Wrap a transaction into a partially signed transaction. The inputs of the
+transaction must spend outputs tracked by the wallet, e.g. outputs of a watched
+multisig address whose unlock conditions were added to the wallet.
+
+txn may be either JSON, base64, or a file containing either.`,
+		Run: wrap(walletmultisigcreatecmd),
+	}
+
+	walletMultisigFinalizeCmd = &cobra.Command{
+		Use:   "finalize [pst]",
+		Short: "Finalize a partially signed transaction",
+		Long: `Extract the signed transaction from a partially signed transaction which has
+enough signatures. The transaction is printed, or broadcast if --broadcast is
+set.`,
+		Run: wrap(walletmultisigfinalizecmd),
+	}
+
+	walletMultisigInspectCmd = &cobra.Command{
+		Use:   "inspect [pst]",
+		Short: "Inspect a partially signed transaction",
+		Long:  "Verify the signatures of a partially signed transaction and summarize it.",
+		Run:   wrap(walletmultisiginspectcmd),
+	}
+
+	walletMultisigSignCmd = &cobra.Command{
+		Use:   "sign [pst]",
+		Short: "Sign a partially signed transaction",
+		Long:  "Add the signatures of the wallet to a partially signed transaction.",
+		Run:   wrap(walletmultisigsigncmd),
+	}
+
+	walletOfflineCmd = &cobra.Command{
+		Use:   "offline",
+		Short: "Sign transactions on an air-gapped machine",
+		Long: `Derive addresses and sign transactions with a seed, without contacting siad.
+This allows keeping the seed on a machine which is never connected to a network:
+
+1. On the offline machine, export the addresses of the seed with
+   'wallet offline addresses [n] -o addresses.json'.
+2. On a networked node, watch them with 'wallet watch addresses.json'. The
+   outputs of the addresses show up in 'wallet unspent'.
+3. Build a transaction spending the outputs and wrap it with
+   'wallet multisig create [txn]'.
+4. On the offline machine, sign it with 'wallet offline sign [pst] -o signed.json'.
+5. On the networked node, broadcast it with
+   'wallet multisig finalize --broadcast signed.json'.`,
+		// Run field is not set, as the offline command itself is not a valid
+		// command. A subcommand must be provided.
+	}
+
+	walletOfflineAddressesCmd = &cobra.Command{
+		Use:   "addresses [n]",
+		Short: "Export the addresses of a seed",
+		Long: `Prompt for a seed and export the unlock conditions of its first n addresses as
+JSON, so that a networked node can watch them using 'wallet watch'.`,
+		Run: wrap(walletofflineaddressescmd),
+	}
+
+	walletOfflineSignCmd = &cobra.Command{
+		Use:   "sign [txn|pst]",
+		Short: "Sign a transaction with a seed",
+		Long: `Prompt for a seed and sign a transaction or a partially signed transaction
+without contacting siad. Transactions are signed by filling in every
+TransactionSignature without a signature. Partially signed transactions are
+signed with every key of the seed which is needed by an input.
+
+The input may be either JSON, base64, or a file containing either. The
+signatures are created for --height, which needs to be past the latest
+hardfork changing the replay protection of signatures.
+
+Before signing, the outputs and miner fees of the transaction are printed and
+need to be confirmed. The values of the inputs of a partially signed
+transaction are reported by the networked node, so they can't be trusted.`,
+		Run: wrap(walletofflinesigncmd),
+	}
+
 	walletSeedsCmd = &cobra.Command{
 		Use:   "seeds",
 		Short: "View information about your seeds",
@@ -155,7 +285,12 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+
+--strategy picks how the outputs funding the transaction are selected, --inputs
+spends exactly the provided comma-separated outputs. --replaceable marks the
+transaction as replaceable, which allows 'wallet bump' to replace it with a copy
+paying a higher fee. Nodes which don't know the marker won't relay it.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -172,7 +307,8 @@ Run 'wallet send --help' to see a list of available units.`,
 		Short: "Sign a transaction",
 		Long: `Sign a transaction. If siad is running with an unlocked wallet, the
 /wallet/sign API call will be used. Otherwise, sign will prompt for the wallet
-seed, and the signing key(s) will be regenerated.
+seed, and the signing key(s) will be regenerated. The signatures are then
+created for --height.
 
 txn may be either JSON, base64, or a file containing either.
 
@@ -182,6 +318,42 @@ provided, the wallet will fill in every TransactionSignature it has keys for.`,
 		Run: walletsigncmd,
 	}
 
+	walletSubscriptionsCmd = &cobra.Command{
+		Use:   "subscriptions",
+		Short: "List payment subscriptions",
+		Long: `List the payment subscriptions of the wallet. A payment subscription reports
+siacoins which are sent to a set of addresses once they appear in the
+transaction pool, once they are confirmed and if they are reverted by a reorg.`,
+		Run: wrap(walletsubscriptionscmd),
+	}
+
+	walletSubscriptionsAddCmd = &cobra.Command{
+		Use:   "add [addresses]",
+		Short: "Add a payment subscription",
+		Long: `Subscribe to payments to a comma-separated list of addresses. With --watched,
+the subscription also covers all addresses watched by the wallet and the list
+of addresses may be omitted.
+
+--webhook sets a url the events are POSTed to, signed with the secret of the
+subscription. --confirmations sets the number of confirmations after which a
+payment is reported as confirmed.`,
+		Run: walletsubscriptionsaddcmd,
+	}
+
+	walletSubscriptionsEventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "Stream payment events",
+		Long:  "Print the events of all payment subscriptions as they occur.",
+		Run:   wrap(walletsubscriptionseventscmd),
+	}
+
+	walletSubscriptionsRemoveCmd = &cobra.Command{
+		Use:   "remove [id]",
+		Short: "Remove a payment subscription",
+		Long:  "Remove a payment subscription from the wallet.",
+		Run:   wrap(walletsubscriptionsremovecmd),
+	}
+
 	walletSweepCmd = &cobra.Command{
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
@@ -197,6 +369,13 @@ will be sent to your wallet.`,
 		Run:   wrap(wallettransactionscmd),
 	}
 
+	walletUnfreezeCmd = &cobra.Command{
+		Use:   "unfreeze [outputids]",
+		Short: "Unfreeze outputs of the wallet",
+		Long:  "Unfreeze a comma-separated list of outputs so that the wallet can spend them again.",
+		Run:   wrap(walletunfreezecmd),
+	}
+
 	walletUnlockCmd = &cobra.Command{
 		Use:   `unlock`,
 		Short: "Unlock the wallet",
@@ -206,6 +385,16 @@ SIA_WALLET_PASSWORD environment variable is set, the unlock command will
 use it instead of displaying the typical interactive prompt.`,
 		Run: wrap(walletunlockcmd),
 	}
+
+	walletWatchCmd = &cobra.Command{
+		Use:   "watch [file]",
+		Short: "Watch the addresses of an offline seed",
+		Long: `Watch the addresses exported by 'wallet offline addresses'. Their unlock
+conditions are added to the wallet, so that it can create partially signed
+transactions spending their outputs. The wallet rescans the blockchain unless
+--unused is set.`,
+		Run: wrap(walletwatchcmd),
+	}
 )
 
 const askPasswordText = "We need to encrypt the new data using the current wallet password, please provide: "
@@ -426,7 +615,21 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	if walletCoinSelection != "" || walletInputs != "" || walletReplaceable {
+		if walletTxnFeeIncluded {
+			die("--fee-included can't be combined with --strategy, --inputs or --replaceable")
+		}
+		selection := modules.CoinSelection{
+			Strategy:    modules.CoinSelectionStrategy(walletCoinSelection),
+			Replaceable: walletReplaceable,
+		}
+		if walletInputs != "" {
+			selection.Outputs = parseOutputIDs(walletInputs)
+		}
+		_, err = httpClient.WalletSiacoinsWithSelectionPost([]types.SiacoinOutput{{Value: value, UnlockHash: hash}}, selection)
+	} else {
+		_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	}
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
@@ -500,6 +703,199 @@ Estimated Fee:       %v / KB
 		fees.Maximum.Mul64(1e3).HumanString())
 }
 
+// walletbumpcmd bumps the fee of an unconfirmed transaction.
+func walletbumpcmd(txidStr string) {
+	var hash crypto.Hash
+	if err := hash.LoadString(txidStr); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	txid := types.TransactionID(hash)
+	var fee types.Currency
+	if walletBumpFee != "" {
+		hastings, err := types.ParseCurrency(walletBumpFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		if _, err := fmt.Sscan(hastings, &fee); err != nil {
+			die("Failed to parse fee", err)
+		}
+	}
+	wtbp, err := httpClient.WalletTransactionBumpPost(txid, modules.FeeBumpStrategy(walletBumpStrategy), fee)
+	if err != nil {
+		die("Could not bump transaction fee:", err)
+	}
+	fmt.Println("Submitted transaction set:")
+	for _, id := range wtbp.TransactionIDs {
+		fmt.Println(" ", id)
+	}
+}
+
+// parseOutputIDs parses a comma-separated list of output ids.
+func parseOutputIDs(idsStr string) []types.OutputID {
+	var ids []types.OutputID
+	for _, idStr := range strings.Split(idsStr, ",") {
+		var hash crypto.Hash
+		if err := hash.LoadString(strings.TrimSpace(idStr)); err != nil {
+			die("Could not parse output id:", err)
+		}
+		ids = append(ids, types.OutputID(hash))
+	}
+	return ids
+}
+
+// walletfreezecmd freezes outputs of the wallet.
+func walletfreezecmd(idsStr string) {
+	ids := parseOutputIDs(idsStr)
+	if err := httpClient.WalletFreezePost(ids); err != nil {
+		die("Could not freeze outputs:", err)
+	}
+	fmt.Printf("Froze %v outputs\n", len(ids))
+}
+
+// walletfrozencmd lists the frozen outputs of the wallet.
+func walletfrozencmd() {
+	wfg, err := httpClient.WalletFrozenGet()
+	if err != nil {
+		die("Could not get frozen outputs:", err)
+	}
+	if len(wfg.Outputs) == 0 {
+		fmt.Println("No frozen outputs.")
+		return
+	}
+	for _, id := range wfg.Outputs {
+		fmt.Println(crypto.Hash(id))
+	}
+}
+
+// walletunfreezecmd unfreezes outputs of the wallet.
+func walletunfreezecmd(idsStr string) {
+	ids := parseOutputIDs(idsStr)
+	if err := httpClient.WalletUnfreezePost(ids); err != nil {
+		die("Could not unfreeze outputs:", err)
+	}
+	fmt.Printf("Unfroze %v outputs\n", len(ids))
+}
+
+// printPartiallySignedTxn prints a partially signed transaction as JSON.
+func printPartiallySignedTxn(pst modules.PartiallySignedTransaction) {
+	if err := json.NewEncoder(os.Stdout).Encode(pst); err != nil {
+		die("failed to encode partially signed transaction", err)
+	}
+}
+
+// walletmultisigcombinecmd combines copies of a partially signed transaction.
+func walletmultisigcombinecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var psts []modules.PartiallySignedTransaction
+	for _, arg := range args {
+		pst, err := parsePartiallySignedTxn(arg)
+		if err != nil {
+			die("Could not decode partially signed transaction:", err)
+		}
+		psts = append(psts, pst)
+	}
+	pst, err := modules.CombinePartiallySignedTransactions(psts...)
+	if err != nil {
+		die("Could not combine partially signed transactions:", err)
+	}
+	printPartiallySignedTxn(pst)
+}
+
+// walletmultisigcreatecmd wraps a transaction into a partially signed
+// transaction.
+func walletmultisigcreatecmd(txnStr string) {
+	txn, err := parseTxn(txnStr)
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	wmp, err := httpClient.WalletMultisigCreatePost(txn)
+	if err != nil {
+		die("Could not create partially signed transaction:", err)
+	}
+	printPartiallySignedTxn(wmp.PartiallySignedTransaction)
+}
+
+// walletmultisigfinalizecmd finalizes a partially signed transaction and
+// either prints or broadcasts the signed transaction.
+func walletmultisigfinalizecmd(pstStr string) {
+	pst, err := parsePartiallySignedTxn(pstStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	wmfp, err := httpClient.WalletMultisigFinalizePost(pst)
+	if err != nil {
+		die("Could not finalize partially signed transaction:", err)
+	}
+	if walletBroadcast {
+		err = httpClient.TransactionPoolRawPost(wmfp.Transaction, nil)
+		if err != nil {
+			die("Could not broadcast transaction:", err)
+		}
+		fmt.Println("Transaction", wmfp.Transaction.ID(), "has been broadcast successfully")
+		return
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(wmfp.Transaction); err != nil {
+		die("failed to encode txn", err)
+	}
+}
+
+// walletmultisiginspectcmd summarizes a partially signed transaction.
+func walletmultisiginspectcmd(pstStr string) {
+	pst, err := parsePartiallySignedTxn(pstStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	summary, err := httpClient.WalletMultisigInspectPost(pst)
+	if err != nil {
+		die("Could not inspect partially signed transaction:", err)
+	}
+	fmt.Println("Transaction ID:", summary.TransactionID)
+	fmt.Printf("Siacoins:        %v in, %v out, %v fees\n", currencyUnits(summary.SiacoinInputs), currencyUnits(summary.SiacoinOutputs), currencyUnits(summary.MinerFees))
+	if !summary.SiafundInputs.IsZero() || !summary.SiafundOutputs.IsZero() {
+		fmt.Printf("Siafunds:        %v SF in, %v SF out\n", summary.SiafundInputs, summary.SiafundOutputs)
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Input\tAddress\tValue\tSignatures\tSigned By")
+	for _, input := range summary.Inputs {
+		value := currencyUnits(input.Value)
+		if input.FundType == types.SpecifierSiafundInput {
+			value = input.Value.String() + " SF"
+		}
+		signedBy := make([]string, 0, len(input.SignedBy))
+		for _, index := range input.SignedBy {
+			signedBy = append(signedBy, strconv.FormatUint(index, 10))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v/%v\t%v\n", input.ParentID, input.UnlockConditions.UnlockHash(), value, len(input.SignedBy), input.SignaturesRequired, strings.Join(signedBy, ","))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	fmt.Println()
+	if summary.Complete {
+		fmt.Println("The transaction has enough signatures and can be finalized.")
+	} else {
+		fmt.Println("The transaction needs more signatures.")
+	}
+}
+
+// walletmultisigsigncmd adds the signatures of the wallet to a partially
+// signed transaction.
+func walletmultisigsigncmd(pstStr string) {
+	pst, err := parsePartiallySignedTxn(pstStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	wmp, err := httpClient.WalletMultisigSignPost(pst)
+	if err != nil {
+		die("Could not sign partially signed transaction:", err)
+	}
+	printPartiallySignedTxn(wmp.PartiallySignedTransaction)
+}
+
 // walletbroadcastcmd broadcasts a transaction.
 func walletbroadcastcmd(txnStr string) {
 	txn, err := parseTxn(txnStr)
@@ -513,6 +909,84 @@ func walletbroadcastcmd(txnStr string) {
 	fmt.Println("Transaction has been broadcast successfully")
 }
 
+// walletsubscriptionscmd lists the payment subscriptions of the wallet.
+func walletsubscriptionscmd() {
+	wsg, err := httpClient.WalletSubscriptionsGet()
+	if err != nil {
+		die("Could not get payment subscriptions:", err)
+	}
+	if len(wsg.Subscriptions) == 0 {
+		fmt.Println("No payment subscriptions.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAddresses\tWatched\tConfirmations\tWebhook")
+	for _, sub := range wsg.Subscriptions {
+		webhook := sub.WebhookURL
+		if webhook == "" {
+			webhook = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", sub.ID, len(sub.Addresses), sub.WatchedAddresses, sub.Confirmations, webhook)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer", err)
+	}
+}
+
+// walletsubscriptionsaddcmd adds a payment subscription to the wallet.
+func walletsubscriptionsaddcmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 || (len(args) == 0 && !walletWatched) {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	sub := modules.PaymentSubscription{
+		WatchedAddresses: walletWatched,
+		Confirmations:    walletConfirmations,
+		WebhookURL:       walletWebhook,
+	}
+	if len(args) == 1 {
+		for _, addrStr := range strings.Split(args[0], ",") {
+			var addr types.UnlockHash
+			if err := addr.LoadString(strings.TrimSpace(addrStr)); err != nil {
+				die("Could not parse address:", err)
+			}
+			sub.Addresses = append(sub.Addresses, addr)
+		}
+	}
+	wsp, err := httpClient.WalletSubscriptionsAddPost(sub)
+	if err != nil {
+		die("Could not add payment subscription:", err)
+	}
+	fmt.Println("Added payment subscription", wsp.Subscription.ID)
+	if wsp.Subscription.WebhookURL != "" {
+		fmt.Println("Webhook secret:", wsp.Subscription.Secret)
+	}
+}
+
+// walletsubscriptionseventscmd prints the payment events of the wallet as
+// they occur.
+func walletsubscriptionseventscmd() {
+	events, closeStream, err := httpClient.WalletSubscriptionsEventsGet("")
+	if err != nil {
+		die("Could not stream payment events:", err)
+	}
+	defer func() {
+		_ = closeStream()
+	}()
+	for event := range events {
+		fmt.Printf("%-11v %v %v %v %v\n", event.Type, event.SubscriptionID, event.TransactionID, event.Address, currencyUnits(event.Value))
+	}
+}
+
+// walletsubscriptionsremovecmd removes a payment subscription from the
+// wallet.
+func walletsubscriptionsremovecmd(id string) {
+	if err := httpClient.WalletSubscriptionsRemovePost(id); err != nil {
+		die("Could not remove payment subscription:", err)
+	}
+	fmt.Println("Removed payment subscription", id)
+}
+
 // walletsweepcmd sweeps coins and funds from a seed.
 func walletsweepcmd() {
 	seed, err := passwordPrompt("Seed: ")
@@ -580,6 +1054,17 @@ func walletsigncmd(cmd *cobra.Command, args []string) {
 // transactions without siad.
 func walletsigncmdoffline(txn *types.Transaction, toSign []crypto.Hash) {
 	fmt.Println("Enter your wallet seed to generate the signing key(s) now and sign without siad.")
+	seed := promptSeed()
+	done := keygenNotice()
+	err := wallet.SignTransaction(txn, seed, toSign, types.BlockHeight(walletOfflineHeight))
+	if err != nil {
+		die("Failed to sign transaction:", err)
+	}
+	close(done)
+}
+
+// promptSeed prompts the user for a wallet seed.
+func promptSeed() modules.Seed {
 	seedString, err := passwordPrompt("Seed: ")
 	if err != nil {
 		die("Reading seed failed:", err)
@@ -588,9 +1073,13 @@ func walletsigncmdoffline(txn *types.Transaction, toSign []crypto.Hash) {
 	if err != nil {
 		die("Invalid seed:", err)
 	}
-	// signing via seed may take a while, since we need to regenerate
-	// keys. If it takes longer than a second, print a message to assure
-	// the user that this is normal.
+	return seed
+}
+
+// keygenNotice prints a message if deriving keys from a seed takes longer
+// than a second, to assure the user that this is normal. The returned channel
+// must be closed once the keys are derived.
+func keygenNotice() chan struct{} {
 	done := make(chan struct{})
 	go func() {
 		select {
@@ -599,11 +1088,159 @@ func walletsigncmdoffline(txn *types.Transaction, toSign []crypto.Hash) {
 		case <-done:
 		}
 	}()
-	err = wallet.SignTransaction(txn, seed, toSign, 180e3)
+	return done
+}
+
+// writeOutput encodes v as JSON and writes it to the file specified by
+// --output, or to stdout if no file was specified.
+func writeOutput(v interface{}) {
+	if walletOutput == "" {
+		if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+			die("Failed to encode output:", err)
+		}
+		return
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		die("Failed to encode output:", err)
+	}
+	if err := ioutil.WriteFile(walletOutput, b, 0600); err != nil {
+		die("Failed to write output:", err)
+	}
+	fmt.Println("Wrote", walletOutput)
+}
+
+// walletofflineaddressescmd derives the first n addresses of a seed and
+// exports their unlock conditions.
+func walletofflineaddressescmd(nStr string) {
+	n, err := strconv.ParseUint(nStr, 10, 64)
+	if err != nil || n == 0 {
+		die("Invalid number of addresses", nStr)
+	}
+	seed := promptSeed()
+	done := keygenNotice()
+	ucs := wallet.SeedUnlockConditions(seed, 0, n)
+	close(done)
+	writeOutput(ucs)
+}
+
+// walletofflinesigncmd signs a transaction or a partially signed transaction
+// with keys derived from a seed, without contacting siad.
+func walletofflinesigncmd(s string) {
+	height := types.BlockHeight(walletOfflineHeight)
+
+	// A partially signed transaction is recognized by its inputs.
+	if pst, err := parsePartiallySignedTxn(s); err == nil && len(pst.Inputs) > 0 {
+		if !confirmOfflineSigning(pst.Transaction, pst.Inputs) {
+			return
+		}
+		seed := promptSeed()
+		done := keygenNotice()
+		signed, err := wallet.SignPartiallySignedTransactionWithSeed(pst, seed, height)
+		if err != nil {
+			die("Failed to sign partially signed transaction:", err)
+		}
+		close(done)
+		writeOutput(signed)
+		return
+	}
+
+	txn, err := parseTxn(s)
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	var toSign []crypto.Hash
+	for _, sig := range txn.TransactionSignatures {
+		if len(sig.Signature) == 0 {
+			toSign = append(toSign, sig.ParentID)
+		}
+	}
+	if len(toSign) == 0 {
+		die("Transaction has no unsigned TransactionSignatures")
+	}
+	if !confirmOfflineSigning(txn, nil) {
+		return
+	}
+	seed := promptSeed()
+	done := keygenNotice()
+	err = wallet.SignTransaction(&txn, seed, toSign, height)
 	if err != nil {
 		die("Failed to sign transaction:", err)
 	}
 	close(done)
+	writeOutput(txn)
+}
+
+// confirmOfflineSigning prints the outputs, miner fees and total spent by a
+// transaction and asks the user to confirm signing it. The offline machine
+// can't look up the inputs, so their values are taken from the partially
+// signed transaction and are only printed for comparison.
+func confirmOfflineSigning(txn types.Transaction, inputs []modules.PartiallySignedInput) bool {
+	var scIn, scOut, fees, sfIn, sfOut types.Currency
+	for _, input := range inputs {
+		if input.FundType == types.SpecifierSiafundInput {
+			sfIn = sfIn.Add(input.Value)
+		} else {
+			scIn = scIn.Add(input.Value)
+		}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Output\tAddress\tValue")
+	for i, sco := range txn.SiacoinOutputs {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", i, sco.UnlockHash, currencyUnits(sco.Value))
+		scOut = scOut.Add(sco.Value)
+	}
+	for i, sfo := range txn.SiafundOutputs {
+		fmt.Fprintf(w, "%v\t%v\t%v SF\n", len(txn.SiacoinOutputs)+i, sfo.UnlockHash, sfo.Value)
+		sfOut = sfOut.Add(sfo.Value)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	for _, fee := range txn.MinerFees {
+		fees = fees.Add(fee)
+	}
+	fmt.Println()
+	fmt.Println("Miner fees:  ", currencyUnits(fees))
+	fmt.Println("Total spent: ", currencyUnits(scOut.Add(fees)))
+	if !sfOut.IsZero() {
+		fmt.Printf("Siafunds:     %v SF\n", sfOut)
+	}
+	if len(inputs) > 0 {
+		fmt.Println()
+		fmt.Println("The values of the inputs are reported by the networked node and can't be")
+		fmt.Println("verified offline.")
+		fmt.Println("Reported inputs:", currencyUnits(scIn))
+		if !scIn.Equals(scOut.Add(fees)) || !sfIn.Equals(sfOut) {
+			fmt.Println("WARNING: the reported inputs don't match the outputs and miner fees.")
+		}
+	}
+	fmt.Println()
+	return askForConfirmation("Sign the transaction?")
+}
+
+// walletwatchcmd makes the wallet watch the addresses exported by 'wallet
+// offline addresses'.
+func walletwatchcmd(path string) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		die("Could not read file:", err)
+	}
+	var ucs []types.UnlockConditions
+	if err := json.Unmarshal(b, &ucs); err != nil {
+		die("Could not decode unlock conditions:", err)
+	}
+	addrs := make([]types.UnlockHash, 0, len(ucs))
+	for _, uc := range ucs {
+		if err := httpClient.WalletUnlockConditionsPost(uc); err != nil {
+			die("Could not add unlock conditions:", err)
+		}
+		addrs = append(addrs, uc.UnlockHash())
+	}
+	if err := httpClient.WalletWatchAddPost(addrs, walletWatchUnused); err != nil {
+		die("Could not watch addresses:", err)
+	}
+	fmt.Printf("Watching %v addresses\n", len(addrs))
 }
 
 // wallettransactionscmd lists all of the transactions related to the wallet,
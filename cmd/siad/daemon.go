@@ -34,12 +34,21 @@ func verifyAPISecurity(config Config) error {
 	// Make sure that only the loopback address is allowed unless the
 	// --disable-api-security flag has been used.
 	if !config.Siad.AllowAPIBind {
-		addr := modules.NetAddress(config.Siad.APIaddr)
-		if !addr.IsLoopback() {
-			if addr.Host() == "" {
-				return fmt.Errorf("a blank host will listen on all interfaces, did you mean localhost:%v?\nyou must pass --disable-api-security to bind Siad to a non-localhost address", addr.Port())
+		addrs := []string{config.Siad.APIaddr}
+		if config.Siad.S3Addr != "" {
+			addrs = append(addrs, config.Siad.S3Addr)
+		}
+		if config.Siad.WebDAVAddr != "" {
+			addrs = append(addrs, config.Siad.WebDAVAddr)
+		}
+		for _, a := range addrs {
+			addr := modules.NetAddress(a)
+			if !addr.IsLoopback() {
+				if addr.Host() == "" {
+					return fmt.Errorf("a blank host will listen on all interfaces, did you mean localhost:%v?\nyou must pass --disable-api-security to bind Siad to a non-localhost address", addr.Port())
+				}
+				return errors.New("you must pass --disable-api-security to bind Siad to a non-localhost address")
 			}
-			return errors.New("you must pass --disable-api-security to bind Siad to a non-localhost address")
 		}
 		return nil
 	}
@@ -112,6 +121,12 @@ func processConfig(config Config) (Config, error) {
 	config.Siad.APIaddr = processNetAddr(config.Siad.APIaddr)
 	config.Siad.RPCaddr = processNetAddr(config.Siad.RPCaddr)
 	config.Siad.HostAddr = processNetAddr(config.Siad.HostAddr)
+	if config.Siad.S3Addr != "" {
+		config.Siad.S3Addr = processNetAddr(config.Siad.S3Addr)
+	}
+	if config.Siad.WebDAVAddr != "" {
+		config.Siad.WebDAVAddr = processNetAddr(config.Siad.WebDAVAddr)
+	}
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
 	if config.Siad.Profile != "" {
 		config.Siad.Profile, err2 = profile.ProcessProfileFlags(config.Siad.Profile)
@@ -217,6 +232,26 @@ func startDaemon(config Config) (err error) {
 		return err
 	}
 
+	// Serve the S3 gateway if it was enabled. The API password is used as the
+	// secret key, so the gateway requires API authentication.
+	if config.Siad.S3Addr != "" {
+		err = srv.ServeS3(config.Siad.S3Addr, config.Siad.S3AccessKey, config.APIPassword)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to start s3 gateway"), srv.Close())
+		}
+		fmt.Println("S3 gateway listening on", srv.S3Address())
+	}
+
+	// Serve the renter's files using WebDAV if it was enabled. Requests are
+	// authenticated using the API password.
+	if config.Siad.WebDAVAddr != "" {
+		err = srv.ServeWebDAV(config.Siad.WebDAVAddr, config.APIPassword)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "unable to start webdav server"), srv.Close())
+		}
+		fmt.Println("WebDAV server listening on", srv.WebDAVAddress())
+	}
+
 	// listen for kill signals
 	sigChan := installKillSignalHandler()
 
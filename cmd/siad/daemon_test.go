@@ -206,4 +206,32 @@ func TestVerifyAPISecurity(t *testing.T) {
 	if err != nil {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
+
+	// Check that the S3 gateway is subject to the same rules.
+	var s3OnPublic Config
+	s3OnPublic.Siad.APIaddr = "127.0.0.1:9980"
+	s3OnPublic.Siad.S3Addr = "sia.tech:9982"
+	err = verifyAPISecurity(s3OnPublic)
+	if err == nil {
+		t.Error("public s3 + securityOn was accepted")
+	}
+	s3OnPublic.Siad.S3Addr = "localhost:9982"
+	err = verifyAPISecurity(s3OnPublic)
+	if err != nil {
+		t.Error("loopback s3 + securityOn was rejected:", err)
+	}
+
+	// Check that the WebDAV server is subject to the same rules.
+	var webdavOnPublic Config
+	webdavOnPublic.Siad.APIaddr = "127.0.0.1:9980"
+	webdavOnPublic.Siad.WebDAVAddr = ":9983"
+	err = verifyAPISecurity(webdavOnPublic)
+	if err == nil {
+		t.Error("blank webdav + securityOn was accepted")
+	}
+	webdavOnPublic.Siad.WebDAVAddr = "localhost:9983"
+	err = verifyAPISecurity(webdavOnPublic)
+	if err != nil {
+		t.Error("loopback webdav + securityOn was rejected:", err)
+	}
 }
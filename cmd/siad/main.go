@@ -37,6 +37,9 @@ type Config struct {
 		HostAddr      string
 		SiaMuxTCPAddr string
 		SiaMuxWSAddr  string
+		S3Addr        string
+		S3AccessKey   string
+		WebDAVAddr    string
 		AllowAPIBind  bool
 
 		Modules           string
@@ -203,6 +206,9 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "gctwrhfa", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
+	root.Flags().StringVarP(&globalConfig.Siad.S3Addr, "s3-addr", "", "", "which host:port the S3 gateway listens on, disabled if empty")
+	root.Flags().StringVarP(&globalConfig.Siad.S3AccessKey, "s3-access-key", "", "sia", "access key of the S3 gateway, the API password is the secret key")
+	root.Flags().StringVarP(&globalConfig.Siad.WebDAVAddr, "webdav-addr", "", "", "which host:port the WebDAV server listens on, disabled if empty")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
@@ -16,11 +16,69 @@ type (
 		// Not implemented yet
 		//
 		// FeeManager FeeManagerAccounting `json:"feemanager"`
-		// Host       HostAccounting       `json:"host"`
-		// Miner      MinerAccounting      `json:"miner"`
 
+		Host   HostAccounting   `json:"host"`
+		Miner  MinerAccounting  `json:"miner"`
 		Renter RenterAccounting `json:"renter"`
 		Wallet WalletAccounting `json:"wallet"`
+
+		// Timestamp is the Unix timestamp at which the accounting information
+		// was collected.
+		Timestamp int64 `json:"timestamp"`
+	}
+
+	// HostAccounting contains the accounting information related to the Host
+	// Module
+	HostAccounting struct {
+		// LockedCollateral is the collateral that the host has locked in
+		// unresolved storage obligations.
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+
+		// RiskedCollateral is the collateral that the host would lose if it
+		// failed to submit storage proofs for its unresolved storage
+		// obligations.
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+
+		// LostCollateral is the collateral that the host has lost due to failed
+		// storage obligations.
+		LostCollateral types.Currency `json:"lostcollateral"`
+
+		// PotentialStorageRevenue is the storage revenue of unresolved storage
+		// obligations, StorageRevenue is the storage revenue of successful
+		// storage obligations.
+		PotentialStorageRevenue types.Currency `json:"potentialstoragerevenue"`
+		StorageRevenue          types.Currency `json:"storagerevenue"`
+
+		// PotentialUploadRevenue is the upload bandwidth revenue of unresolved
+		// storage obligations, UploadRevenue is the upload bandwidth revenue of
+		// successful storage obligations.
+		PotentialUploadRevenue types.Currency `json:"potentialuploadrevenue"`
+		UploadRevenue          types.Currency `json:"uploadrevenue"`
+
+		// PotentialDownloadRevenue is the download bandwidth revenue of
+		// unresolved storage obligations, DownloadRevenue is the download
+		// bandwidth revenue of successful storage obligations.
+		PotentialDownloadRevenue types.Currency `json:"potentialdownloadrevenue"`
+		DownloadRevenue          types.Currency `json:"downloadrevenue"`
+
+		// LostRevenue is the revenue that the host has lost due to failed
+		// storage obligations.
+		LostRevenue types.Currency `json:"lostrevenue"`
+	}
+
+	// MinerAccounting contains the accounting information related to the Miner
+	// Module. Only blocks that are part of the current chain are considered.
+	MinerAccounting struct {
+		// ImmatureBlockRewards and ImmatureFees are the block subsidies and
+		// miner fees of mined blocks that have not reached the maturity delay
+		// yet and therefore can't be spent.
+		ImmatureBlockRewards types.Currency `json:"immatureblockrewards"`
+		ImmatureFees         types.Currency `json:"immaturefees"`
+
+		// MatureBlockRewards and MatureFees are the block subsidies and miner
+		// fees of mined blocks that have reached the maturity delay.
+		MatureBlockRewards types.Currency `json:"matureblockrewards"`
+		MatureFees         types.Currency `json:"maturefees"`
 	}
 
 	// RenterAccounting contains the accounting information related to the Renter
@@ -53,6 +111,10 @@ type Accounting interface {
 	// Accounting returns the current accounting information
 	Accounting() (AccountingInfo, error)
 
+	// AccountingRange returns the persisted accounting information with a
+	// timestamp within the provided range, inclusive.
+	AccountingRange(start, end int64) ([]AccountingInfo, error)
+
 	// Close closes the accounting module
 	Close() error
 }
@@ -19,6 +19,10 @@ var (
 
 	// errNilWallet is the error returned when the wallet is nil
 	errNilWallet = errors.New("wallet cannot be nil")
+
+	// errInvalidRange is the error returned when the start of a requested
+	// range is after its end
+	errInvalidRange = errors.New("start of range cannot be after the end")
 )
 
 // Accounting contains the information needed for providing accounting
@@ -31,6 +35,10 @@ type Accounting struct {
 	staticWallet modules.Wallet
 
 	// Accounting module settings
+	//
+	// history contains all the persisted entries in the order that they were
+	// written to disk, persistence is the most recent accounting information.
+	history          []persistence
 	persistence      persistence
 	staticPersistDir string
 
@@ -101,6 +109,31 @@ func (a *Accounting) Accounting() (modules.AccountingInfo, error) {
 	return ai, nil
 }
 
+// AccountingRange returns the persisted accounting information with a
+// timestamp within the provided range, inclusive.
+func (a *Accounting) AccountingRange(start, end int64) ([]modules.AccountingInfo, error) {
+	err := a.staticTG.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer a.staticTG.Done()
+
+	if start > end {
+		return nil, errInvalidRange
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var ais []modules.AccountingInfo
+	for _, p := range a.history {
+		if p.Timestamp < start || p.Timestamp > end {
+			continue
+		}
+		ais = append(ais, p.accountingInfo())
+	}
+	return ais, nil
+}
+
 // Close closes the accounting module
 //
 // NOTE: It will not call close on any of the modules it is tracking. Those
@@ -113,6 +146,34 @@ func (a *Accounting) Close() error {
 func (a *Accounting) callUpdateAccounting() (modules.AccountingInfo, error) {
 	var ai modules.AccountingInfo
 
+	// Get Host information
+	//
+	// NOTE: host is optional so can be nil
+	if a.staticHost != nil {
+		fm := a.staticHost.FinancialMetrics()
+		ai.Host = modules.HostAccounting{
+			LockedCollateral: fm.LockedStorageCollateral,
+			RiskedCollateral: fm.RiskedStorageCollateral,
+			LostCollateral:   fm.LostStorageCollateral,
+
+			PotentialStorageRevenue:  fm.PotentialStorageRevenue,
+			StorageRevenue:           fm.StorageRevenue,
+			PotentialUploadRevenue:   fm.PotentialUploadBandwidthRevenue,
+			UploadRevenue:            fm.UploadBandwidthRevenue,
+			PotentialDownloadRevenue: fm.PotentialDownloadBandwidthRevenue,
+			DownloadRevenue:          fm.DownloadBandwidthRevenue,
+			LostRevenue:              fm.LostRevenue,
+		}
+	}
+
+	// Get Miner information
+	//
+	// NOTE: miner is optional so can be nil
+	var minerErr error
+	if a.staticMiner != nil {
+		ai.Miner, minerErr = a.staticMiner.BlockRewards()
+	}
+
 	// Get Renter information
 	//
 	// NOTE: renter is optional so can be nil
@@ -135,12 +196,15 @@ func (a *Accounting) callUpdateAccounting() (modules.AccountingInfo, error) {
 	}
 
 	// Update the Accounting state
-	err := errors.Compose(renterErr, walletErr)
+	ai.Timestamp = time.Now().Unix()
+	err := errors.Compose(minerErr, renterErr, walletErr)
 	if err == nil {
 		a.mu.Lock()
+		a.persistence.Host = ai.Host
+		a.persistence.Miner = ai.Miner
 		a.persistence.Renter = ai.Renter
 		a.persistence.Wallet = ai.Wallet
-		a.persistence.Timestamp = time.Now().Unix()
+		a.persistence.Timestamp = ai.Timestamp
 		a.mu.Unlock()
 	}
 	return ai, err
@@ -50,14 +50,50 @@ func randomCurrency() types.Currency {
 // testingParams returns the minimum required parameters for creating an
 // Accounting module for testing.
 func testingParams() (modules.Host, modules.Miner, modules.Renter, modules.Wallet, modules.Dependencies) {
-	h := &host.Host{}
-	m := &miner.Miner{}
+	h := &mockHost{}
+	m := &mockMiner{}
 	r := &mockRenter{}
 	w := &mockWallet{}
 	deps := &modules.ProductionDependencies{}
 	return h, m, r, w, deps
 }
 
+// mockHost is a helper for Accounting unit tests
+type mockHost struct {
+	*host.Host
+}
+
+// FinancialMetrics mocks the Host's FinancialMetrics
+func (mh *mockHost) FinancialMetrics() modules.HostFinancialMetrics {
+	return modules.HostFinancialMetrics{
+		LockedStorageCollateral:           randomCurrency(),
+		LostRevenue:                       randomCurrency(),
+		LostStorageCollateral:             randomCurrency(),
+		PotentialStorageRevenue:           randomCurrency(),
+		RiskedStorageCollateral:           randomCurrency(),
+		StorageRevenue:                    randomCurrency(),
+		DownloadBandwidthRevenue:          randomCurrency(),
+		PotentialDownloadBandwidthRevenue: randomCurrency(),
+		PotentialUploadBandwidthRevenue:   randomCurrency(),
+		UploadBandwidthRevenue:            randomCurrency(),
+	}
+}
+
+// mockMiner is a helper for Accounting unit tests
+type mockMiner struct {
+	*miner.Miner
+}
+
+// BlockRewards mocks the Miner's BlockRewards
+func (mm *mockMiner) BlockRewards() (modules.MinerAccounting, error) {
+	return modules.MinerAccounting{
+		ImmatureBlockRewards: randomCurrency(),
+		ImmatureFees:         randomCurrency(),
+		MatureBlockRewards:   randomCurrency(),
+		MatureFees:           randomCurrency(),
+	}, nil
+}
+
 // mockRenter is a helper for Accounting unit tests
 type mockRenter struct {
 	*renter.Renter
@@ -1,6 +1,7 @@
 package accounting
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -17,6 +18,7 @@ func TestAccounting(t *testing.T) {
 
 	// Specific Methods
 	t.Run("Accounting", testAccounting)
+	t.Run("AccountingRange", testAccountingRange)
 	t.Run("NewCustomAccounting", testNewCustomAccounting)
 }
 
@@ -51,12 +53,24 @@ func testAccounting(t *testing.T) {
 	}
 	// Check for a returned value
 	expected := modules.AccountingInfo{
+		Host:   ai.Host,
+		Miner:  ai.Miner,
 		Renter: ai.Renter,
 		Wallet: ai.Wallet,
+
+		Timestamp: ai.Timestamp,
 	}
 	if !reflect.DeepEqual(ai, expected) {
 		t.Error("accounting information is incorrect")
 	}
+	// Check host explicitly
+	if reflect.DeepEqual(ai.Host, modules.HostAccounting{}) {
+		t.Error("host accounting information is empty")
+	}
+	// Check miner explicitly
+	if reflect.DeepEqual(ai.Miner, modules.MinerAccounting{}) {
+		t.Error("miner accounting information is empty")
+	}
 	// Check renter explicitly
 	if reflect.DeepEqual(ai.Renter, modules.RenterAccounting{}) {
 		t.Error("renter accounting information is empty")
@@ -71,6 +85,8 @@ func testAccounting(t *testing.T) {
 	p = a.persistence
 	a.mu.Unlock()
 	ep := persistence{
+		Host:   p.Host,
+		Miner:  p.Miner,
 		Renter: p.Renter,
 		Wallet: p.Wallet,
 
@@ -79,6 +95,12 @@ func testAccounting(t *testing.T) {
 	if !reflect.DeepEqual(p, ep) {
 		t.Error("persistence information is incorrect")
 	}
+	if !reflect.DeepEqual(p.Host, ai.Host) {
+		t.Error("host accounting persistence not updated")
+	}
+	if !reflect.DeepEqual(p.Miner, ai.Miner) {
+		t.Error("miner accounting persistence not updated")
+	}
 	if !reflect.DeepEqual(p.Renter, ai.Renter) {
 		t.Error("renter accounting persistence not updated")
 	}
@@ -87,6 +109,76 @@ func testAccounting(t *testing.T) {
 	}
 }
 
+// testAccountingRange probes the AccountingRange method
+func testAccountingRange(t *testing.T) {
+	// Create new accounting
+	testDir := accountingTestDir(t.Name())
+	h, m, r, w, _ := testingParams()
+	a, err := NewCustomAccounting(h, m, r, w, testDir, &dependencies.AccountingDisablePersistLoop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = a.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid range should return an error
+	_, err = a.AccountingRange(1, 0)
+	if err != errInvalidRange {
+		t.Fatalf("Expected %v, got %v", errInvalidRange, err)
+	}
+
+	// No entries have been persisted yet
+	ais, err := a.AccountingRange(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ais) != 0 {
+		t.Fatalf("Expected 0 entries, got %v", len(ais))
+	}
+
+	// Persist a few entries with known timestamps
+	numEntries := 3
+	for i := 0; i < numEntries; i++ {
+		err = a.managedUpdateAndPersistAccounting()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.mu.Lock()
+	for i := range a.history {
+		a.history[i].Timestamp = int64(i + 1)
+	}
+	a.mu.Unlock()
+
+	// Full range should return all entries
+	ais, err = a.AccountingRange(0, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ais) != numEntries {
+		t.Fatalf("Expected %v entries, got %v", numEntries, len(ais))
+	}
+
+	// Range should be inclusive
+	ais, err = a.AccountingRange(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ais) != 2 {
+		t.Fatalf("Expected %v entries, got %v", 2, len(ais))
+	}
+	if ais[0].Timestamp != 2 || ais[1].Timestamp != 3 {
+		t.Error("unexpected timestamps", ais[0].Timestamp, ais[1].Timestamp)
+	}
+	if reflect.DeepEqual(ais[0].Wallet, modules.WalletAccounting{}) {
+		t.Error("wallet accounting information is empty")
+	}
+}
+
 // testNewCustomAccounting probes the NewCustomAccounting function
 func testNewCustomAccounting(t *testing.T) {
 	// checkNew is a helper function to check NewCustomAccounting
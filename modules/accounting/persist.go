@@ -50,9 +50,9 @@ type persistence struct {
 	// Not implemented yet
 	//
 	// FeeManager modules.FeeManagerAccounting `json:"feemanager"`
-	// Host       modules.HostAccounting       `json:"host"`
-	// Miner      modules.MinerAccounting      `json:"miner"`
 
+	Host   modules.HostAccounting   `json:"host"`
+	Miner  modules.MinerAccounting  `json:"miner"`
 	Renter modules.RenterAccounting `json:"renter"`
 	Wallet modules.WalletAccounting `json:"wallet"`
 
@@ -139,7 +139,8 @@ func (a *Accounting) initPersist() error {
 		return errors.AddContext(err, "unable to unmarshal persistence")
 	}
 
-	// Keep the last persist entry in memory
+	// Keep the persisted entries in memory
+	a.history = persistence
 	if len(persistence) > 0 {
 		a.persistence = persistence[len(persistence)-1]
 	}
@@ -177,9 +178,26 @@ func (a *Accounting) managedUpdateAndPersistAccounting() error {
 		return err
 	}
 
+	// Add the persisted entry to the history
+	a.mu.Lock()
+	a.history = append(a.history, p)
+	a.mu.Unlock()
 	return nil
 }
 
+// accountingInfo returns the accounting information contained in the
+// persistence.
+func (p persistence) accountingInfo() modules.AccountingInfo {
+	return modules.AccountingInfo{
+		Host:   p.Host,
+		Miner:  p.Miner,
+		Renter: p.Renter,
+		Wallet: p.Wallet,
+
+		Timestamp: p.Timestamp,
+	}
+}
+
 // marshalPersistence marshals the persistence.
 func marshalPersistence(p persistence) ([]byte, error) {
 	// Marshal the persistence
@@ -162,6 +162,24 @@ func testManagedUpdateAndPersistAccounting(t *testing.T) {
 func testMarshal(t *testing.T) {
 	// Create persistence
 	p := persistence{
+		Host: modules.HostAccounting{
+			LockedCollateral:         randomCurrency(),
+			RiskedCollateral:         randomCurrency(),
+			LostCollateral:           randomCurrency(),
+			PotentialStorageRevenue:  randomCurrency(),
+			StorageRevenue:           randomCurrency(),
+			PotentialUploadRevenue:   randomCurrency(),
+			UploadRevenue:            randomCurrency(),
+			PotentialDownloadRevenue: randomCurrency(),
+			DownloadRevenue:          randomCurrency(),
+			LostRevenue:              randomCurrency(),
+		},
+		Miner: modules.MinerAccounting{
+			ImmatureBlockRewards: randomCurrency(),
+			ImmatureFees:         randomCurrency(),
+			MatureBlockRewards:   randomCurrency(),
+			MatureFees:           randomCurrency(),
+		},
 		Renter: modules.RenterAccounting{
 			WithheldFunds:      randomCurrency(),
 			UnspentUnallocated: randomCurrency(),
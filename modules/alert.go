@@ -52,10 +52,17 @@ const (
 	// AlertIDHostDiskTrouble is the id of the alert that is registered when the
 	// host is encountering problems interacting with one or more of his disks
 	AlertIDHostDiskTrouble = "host-disk-trouble"
+	// AlertIDHostCorruptedSectors is the id of the alert that is registered
+	// when the host finds sectors on disk which don't match their merkle root
+	AlertIDHostCorruptedSectors = "host-corrupted-sectors"
 	// AlertIDHostInsufficientCollateral is the id of the alert that is
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDHostObligationsAtRisk is the id of the alert that is registered
+	// if the host has storage obligations which are likely to fail their
+	// storage proof
+	AlertIDHostObligationsAtRisk = "host-obligations-at-risk"
 )
 
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
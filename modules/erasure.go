@@ -45,6 +45,23 @@ var (
 	// ECPassthrough defines the erasure coder type for an erasure coder that
 	// does nothing.
 	ECPassthrough = ErasureCoderType{0, 0, 0, 3}
+
+	// ECLeopardGF16 is the marshaled type of the FFT based reed solomon coder
+	// over GF(2^16). Like ECReedSolomonSubShards64, every 64 bytes of an
+	// encoded piece can be decoded separately.
+	ECLeopardGF16 = ErasureCoderType{0, 0, 0, 4}
+)
+
+var (
+	// maxRSPieces is the maximum number of pieces supported by the classic
+	// reed solomon coders which operate over GF(2^8).
+	maxRSPieces = 256
+
+	// MaxLeopardPieces is the maximum number of pieces supported by the
+	// leopard coder. The coder operates over GF(2^16) but a siafile can only
+	// reserve 255 pages of 4096 bytes for a chunk. Each piece takes up 40
+	// bytes on disk and every chunk has 19 bytes of overhead.
+	MaxLeopardPieces = (255*4096 - 19) / 40
 )
 
 type (
@@ -114,6 +131,20 @@ type (
 		staticType        ErasureCoderType
 	}
 
+	// LeopardCode is a Reed-Solomon encoder/decoder over GF(2^16) which uses
+	// the O(n log n) FFT based algorithm of the leopard library. It implements
+	// the ErasureCoder interface. The encoded data is laid out like the data of
+	// the RSSubCode, which means that every crypto.SegmentSize bytes of encoded
+	// data can be recovered separately. Since the coder operates on every
+	// segment independently, the pieces don't need to be encoded segment by
+	// segment though.
+	LeopardCode struct {
+		enc reedsolomon.Encoder
+
+		numPieces  int
+		dataPieces int
+	}
+
 	// PassthroughErasureCoder is a blank type that signifies no erasure coding.
 	PassthroughErasureCoder struct{}
 )
@@ -167,6 +198,26 @@ func NewRSSubCodeDefault() ErasureCoder {
 	return ec
 }
 
+// NewLeopardCode creates a new leopard encoder/decoder using the supplied
+// parameters.
+func NewLeopardCode(nData, nParity int) (ErasureCoder, error) {
+	if nData <= 0 || nParity <= 0 {
+		return nil, reedsolomon.ErrInvShardNum
+	}
+	if nData+nParity > MaxLeopardPieces {
+		return nil, reedsolomon.ErrMaxShardNum
+	}
+	enc, err := reedsolomon.New(nData, nParity, reedsolomon.WithLeopardGF16(true))
+	if err != nil {
+		return nil, err
+	}
+	return &LeopardCode{
+		enc:        enc,
+		numPieces:  nData + nParity,
+		dataPieces: nData,
+	}, nil
+}
+
 // NewPassthroughErasureCoder will return an erasure coder that does not encode
 // the data. It uses 1-of-1 redundancy and always returns itself or some subset
 // of itself.
@@ -252,6 +303,14 @@ func (rs *RSCode) Type() ErasureCoderType {
 // newRSCode creates a new Reed-Solomon encoder/decoder using the supplied
 // parameters.
 func newRSCode(nData, nParity int) (*RSCode, error) {
+	// The reed solomon library would fall back to a different coder for
+	// unsupported parameters, so they need to be checked here.
+	if nData <= 0 || nParity <= 0 {
+		return nil, reedsolomon.ErrInvShardNum
+	}
+	if nData+nParity > maxRSPieces {
+		return nil, reedsolomon.ErrMaxShardNum
+	}
 	enc, err := reedsolomon.New(nData, nParity)
 	if err != nil {
 		return nil, err
@@ -452,6 +511,154 @@ func (rs *RSSubCode) Type() ErasureCoderType {
 	return rs.staticType
 }
 
+// NumPieces returns the number of pieces returned by Encode.
+func (lc *LeopardCode) NumPieces() int { return lc.numPieces }
+
+// MinPieces return the minimum number of pieces that must be present to
+// recover the original data.
+func (lc *LeopardCode) MinPieces() int { return lc.dataPieces }
+
+// Encode splits data into equal-length pieces, some containing the original
+// data and some containing parity data.
+func (lc *LeopardCode) Encode(data []byte) ([][]byte, error) {
+	if len(data) == 0 {
+		return nil, reedsolomon.ErrShortData
+	}
+	// Pad the data to a multiple of the size of a decoded segment and split
+	// it into pieces.
+	decodedSegmentSize := int(crypto.SegmentSize) * lc.MinPieces()
+	numSegments := (len(data) + decodedSegmentSize - 1) / decodedSegmentSize
+	pieceSize := numSegments * int(crypto.SegmentSize)
+	padded := make([]byte, numSegments*decodedSegmentSize)
+	copy(padded, data)
+	pieces := make([][]byte, lc.MinPieces())
+	for i := range pieces {
+		pieces[i] = padded[i*pieceSize:][:pieceSize]
+	}
+	return lc.EncodeShards(pieces)
+}
+
+// EncodeShards encodes data in a way that every crypto.SegmentSize bytes of
+// the encoded data can be decoded independently.
+func (lc *LeopardCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
+	// Check that there are enough pieces.
+	if len(pieces) != lc.MinPieces() {
+		return nil, fmt.Errorf("not enough segments expected %v but was %v",
+			lc.MinPieces(), len(pieces))
+	}
+	// Since all the pieces should have the same length, get the pieceSize from
+	// the first one.
+	pieceSize := uint64(len(pieces[0]))
+	// pieceSize must be divisible by segmentSize
+	if pieceSize%crypto.SegmentSize != 0 {
+		return nil, errors.New("pieceSize not divisible by segmentSize")
+	}
+	// Each piece should have pieceSize bytes.
+	for _, piece := range pieces {
+		if uint64(len(piece)) != pieceSize {
+			return nil, fmt.Errorf("pieces don't have right size expected %v but was %v",
+				pieceSize, len(piece))
+		}
+	}
+	// Interleave the data so that the n-th segment of every piece contains
+	// the data of the n-th decoded segment.
+	encoded := make([][]byte, lc.NumPieces())
+	for i := range encoded {
+		encoded[i] = make([]byte, pieceSize)
+	}
+	decodedSegmentSize := crypto.SegmentSize * uint64(lc.MinPieces())
+	for i, piece := range pieces {
+		for off := uint64(0); off < pieceSize; off += crypto.SegmentSize {
+			dataOff := uint64(i)*pieceSize + off
+			segmentIndex := dataOff / decodedSegmentSize
+			pieceIndex := (dataOff % decodedSegmentSize) / crypto.SegmentSize
+			copy(encoded[pieceIndex][segmentIndex*crypto.SegmentSize:], piece[off:off+crypto.SegmentSize])
+		}
+	}
+	// Encode the pieces.
+	if err := lc.enc.Encode(encoded); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// Identifier returns an identifier for an erasure coder which can be used to
+// identify erasure coders of the same type, dataPieces and parityPieces.
+func (lc *LeopardCode) Identifier() ErasureCoderIdentifier {
+	t := lc.Type()
+	dataPieces := lc.MinPieces()
+	parityPieces := lc.NumPieces() - dataPieces
+	id := fmt.Sprintf("%v+%v+%v", binary.BigEndian.Uint32(t[:]), dataPieces, parityPieces)
+	return ErasureCoderIdentifier(id)
+}
+
+// Reconstruct recovers the full set of encoded shards from the provided
+// pieces, of which at least MinPieces must be non-nil.
+func (lc *LeopardCode) Reconstruct(pieces [][]byte) error {
+	// Check the length of pieces.
+	if len(pieces) != lc.NumPieces() {
+		return fmt.Errorf("expected pieces to have len %v but was %v",
+			lc.NumPieces(), len(pieces))
+	}
+	return lc.enc.Reconstruct(pieces)
+}
+
+// Recover accepts encoded pieces and decodes them. The pieces might only
+// contain a range of segments as long as that range is the same for all the
+// pieces.
+func (lc *LeopardCode) Recover(pieces [][]byte, n uint64, w io.Writer) error {
+	// Check the length of pieces.
+	if len(pieces) != lc.NumPieces() {
+		return fmt.Errorf("expected pieces to have len %v but was %v",
+			lc.NumPieces(), len(pieces))
+	}
+	// Since all the pieces should have the same length, get the pieceSize from
+	// the first piece that was set.
+	var pieceSize uint64
+	for _, piece := range pieces {
+		if len(piece) > 0 {
+			pieceSize = uint64(len(piece))
+			break
+		}
+	}
+	// pieceSize must be divisible by segmentSize
+	if pieceSize%crypto.SegmentSize != 0 {
+		return errors.New("pieceSize not divisible by segmentSize")
+	}
+	// Reconstruct the data pieces.
+	if err := lc.enc.ReconstructData(pieces); err != nil {
+		return err
+	}
+	// Write the data one segment at a time.
+	for off := uint64(0); off < pieceSize && n > 0; off += crypto.SegmentSize {
+		for _, piece := range pieces[:lc.MinPieces()] {
+			segment := piece[off : off+crypto.SegmentSize]
+			if n < uint64(len(segment)) {
+				segment = segment[:n]
+			}
+			if _, err := w.Write(segment); err != nil {
+				return err
+			}
+			n -= uint64(len(segment))
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// SupportsPartialEncoding returns true for the leopard encoder and returns the
+// segment size.
+func (lc *LeopardCode) SupportsPartialEncoding() (uint64, bool) {
+	return crypto.SegmentSize, true
+}
+
+// Type returns the erasure coders type identifier.
+func (lc *LeopardCode) Type() ErasureCoderType {
+	return ECLeopardGF16
+}
+
 // ExtractSegment is a convenience method that extracts the data of the segment
 // at segmentIndex from pieces.
 func ExtractSegment(pieces [][]byte, segmentIndex int, segmentSize uint64) [][]byte {
@@ -15,6 +15,7 @@ import (
 func TestErasureCode(t *testing.T) {
 	t.Run("RSCode", testRSCode)
 	t.Run("RSSubCode", testRSSubCode)
+	t.Run("LeopardCode", testLeopardCode)
 	t.Run("Passthrough", testPassthrough)
 	t.Run("UniqueIdentifier", testUniqueIdentifier)
 	t.Run("DefaultConstructors", testDefaultConstructors)
@@ -143,6 +144,150 @@ func testRSSubCode(t *testing.T) {
 	}
 }
 
+// testLeopardCode checks that the leopard coder supports wide stripes, that
+// individual segments of an encoded piece can be recovered and that the
+// encoded data is laid out like the data of the RSSubCode.
+func testLeopardCode(t *testing.T) {
+	badParams := []struct {
+		data, parity int
+	}{
+		{-1, -1},
+		{-1, 0},
+		{0, -1},
+		{0, 0},
+		{0, 1},
+		{1, 0},
+		{60000, 6000},
+		{MaxLeopardPieces, 1},
+	}
+	for _, ps := range badParams {
+		if _, err := NewLeopardCode(ps.data, ps.parity); err == nil {
+			t.Error("expected bad parameter error, got nil")
+		}
+	}
+
+	segmentSize := int(crypto.SegmentSize)
+	pieceSize := 4096
+	dataPieces := 100
+	parityPieces := 300
+	data := fastrand.Bytes(pieceSize * dataPieces)
+	originalData := make([]byte, len(data))
+	copy(originalData, data)
+
+	// The classic reed solomon coders don't support this many pieces.
+	if _, err := NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize); err == nil {
+		t.Fatal("expected RSSubCode to fail for a wide stripe")
+	}
+	lc, err := NewLeopardCode(dataPieces, parityPieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.Type() != ECLeopardGF16 {
+		t.Fatal("wrong type", lc.Type())
+	}
+	if size, supported := lc.SupportsPartialEncoding(); !supported || size != crypto.SegmentSize {
+		t.Fatal("leopard coder should support partial encoding")
+	}
+
+	// Encode the data.
+	encodedPieces, err := lc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encodedPieces) != lc.NumPieces() {
+		t.Fatalf("encodedPieces should've length %v but was %v", lc.NumPieces(), len(encodedPieces))
+	}
+	for _, piece := range encodedPieces {
+		if len(piece) != pieceSize {
+			t.Fatalf("expected len(piece) to be %v but was %v", pieceSize, len(piece))
+		}
+	}
+
+	// The data pieces should be laid out like the data pieces of the RSSubCode.
+	rsc, err := NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc10, err := NewLeopardCode(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsData := make([]byte, pieceSize*10)
+	copy(rsData, originalData)
+	rsPieces, err1 := rsc.Encode(rsData)
+	lcPieces, err2 := lc10.Encode(originalData[:pieceSize*10])
+	if err := errors.Compose(err1, err2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(rsPieces[i], lcPieces[i]) {
+			t.Fatal("data pieces don't match the layout of the RSSubCode")
+		}
+	}
+
+	// Delete as many random pieces as possible.
+	for _, i := range fastrand.Perm(len(encodedPieces))[:parityPieces] {
+		encodedPieces[i] = nil
+	}
+	// Recover every segment individually.
+	dataOffset := 0
+	decodedSegmentSize := segmentSize * dataPieces
+	for segmentIndex := 0; segmentIndex < pieceSize/segmentSize; segmentIndex++ {
+		buf := new(bytes.Buffer)
+		segment := ExtractSegment(encodedPieces, segmentIndex, uint64(segmentSize))
+		err = lc.Recover(segment, uint64(segmentSize*lc.MinPieces()), buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), originalData[dataOffset:dataOffset+decodedSegmentSize]) {
+			t.Fatal("decoded bytes don't equal original segment")
+		}
+		dataOffset += decodedSegmentSize
+	}
+	// Recover all segments at once.
+	buf := new(bytes.Buffer)
+	err = lc.Recover(encodedPieces, uint64(len(data)), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), originalData) {
+		t.Fatal("decoded bytes don't equal original data")
+	}
+	// Reconstruct the missing pieces and compare them to a fresh encoding.
+	err = lc.Reconstruct(encodedPieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPieces, err := lc.Encode(originalData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range expectedPieces {
+		if !bytes.Equal(encodedPieces[i], expectedPieces[i]) {
+			t.Fatal("reconstructed piece doesn't match", i)
+		}
+	}
+
+	// Data that isn't a multiple of the segment size should be padded.
+	data = fastrand.Bytes(777)
+	encodedPieces, err = lc10.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf = new(bytes.Buffer)
+	err = lc10.Recover(encodedPieces, 777, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatal("recovered data does not match original")
+	}
+	_, err = lc10.Encode(nil)
+	if err == nil {
+		t.Fatal("expected nil data error, got nil")
+	}
+}
+
 // testPassthrough verifies the functionality of the Passthrough EC.
 func testPassthrough(t *testing.T) {
 	ptec := NewPassthroughErasureCoder()
@@ -211,8 +356,9 @@ func testUniqueIdentifier(t *testing.T) {
 	ec4, err4 := NewRSSubCode(1, 2, 64)
 	ec5, err5 := NewRSCode(1, 1)
 	ec6 := NewPassthroughErasureCoder()
+	ec7, err7 := NewLeopardCode(1, 2)
 
-	if err := errors.Compose(err1, err2, err3, err4, err5); err != nil {
+	if err := errors.Compose(err1, err2, err3, err4, err5, err7); err != nil {
 		t.Fatal(err)
 	}
 	if ec1.Identifier() != "1+1+2" {
@@ -233,12 +379,16 @@ func testUniqueIdentifier(t *testing.T) {
 	if ec6.Identifier() != "ECPassthrough" {
 		t.Error("wrong identifier for ec6")
 	}
+	if ec7.Identifier() != "4+1+2" {
+		t.Error("wrong identifier for ec7")
+	}
 	sp1 := CombinedSiaFilePath(ec1)
 	sp2 := CombinedSiaFilePath(ec2)
 	sp3 := CombinedSiaFilePath(ec3)
 	sp4 := CombinedSiaFilePath(ec4)
 	sp5 := CombinedSiaFilePath(ec5)
 	sp6 := CombinedSiaFilePath(ec6)
+	sp7 := CombinedSiaFilePath(ec7)
 	if !sp1.Equals(sp2) {
 		t.Error("sp1 and sp2 should have the same path")
 	}
@@ -254,6 +404,9 @@ func testUniqueIdentifier(t *testing.T) {
 	if sp1.Equals(sp6) {
 		t.Error("sp1 and sp6 should have different path")
 	}
+	if sp4.Equals(sp7) {
+		t.Error("sp4 and sp7 should have different path")
+	}
 }
 
 // testDefaultConstructors verifies the default constructor create erasure codes
@@ -293,6 +446,22 @@ func BenchmarkRSEncode(b *testing.B) {
 	}
 }
 
+// BenchmarkLeopardEncode benchmarks the 'Encode' function of the LeopardCode
+// EC for a wide stripe.
+func BenchmarkLeopardEncode(b *testing.B) {
+	lc, err := NewLeopardCode(100, 300)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := fastrand.Bytes(1 << 20)
+
+	b.SetBytes(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lc.Encode(data)
+	}
+}
+
 // BenchmarkRSEncode benchmarks the 'Recover' function of the RSCode EC.
 func BenchmarkRSRecover(b *testing.B) {
 	rsc, err := NewRSCode(50, 200)
@@ -83,6 +83,11 @@ var (
 	// with a number like 65 MiB.
 	DefaultMaxReviseBatchSize = 17 * (1 << 20)
 
+	// DefaultScrubRate is the number of bytes per second the host reads by
+	// default to verify the integrity of its stored sectors. One sector per
+	// second reads 1 TiB in about 3 days.
+	DefaultScrubRate = SectorSize
+
 	// DefaultWindowSize is the size of the proof of storage window requested
 	// by the host. The host will not delete any obligations until the window
 	// has closed and buried under several confirmations. For release builds,
@@ -224,6 +229,16 @@ var (
 	// received more than workingThreshold settings calls over the duration of
 	// workingStatusFrequency.
 	HostWorkingStatusWorking = HostWorkingStatus("working")
+
+	// HostWorkingStatusDraining is returned from WorkingStatus() if the host
+	// is in maintenance mode and still has to submit storage proofs for some
+	// of its storage obligations.
+	HostWorkingStatusDraining = HostWorkingStatus("draining")
+
+	// HostWorkingStatusDrained is returned from WorkingStatus() if the host is
+	// in maintenance mode and all of its storage obligations were proven. It
+	// is safe to shut down the host.
+	HostWorkingStatusDrained = HostWorkingStatus("drained")
 )
 
 type (
@@ -290,6 +305,247 @@ type (
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		// The quotas limit the resources a single renter can use. A value of 0
+		// means that there is no limit.
+		MaxRenterAccounts        uint64 `json:"maxrenteraccounts"`
+		MaxRenterBandwidth       uint64 `json:"maxrenterbandwidth"`
+		MaxRenterRegistryEntries uint64 `json:"maxrenterregistryentries"`
+		MaxRenterSectors         uint64 `json:"maxrentersectors"`
+
+		PricingPolicy HostPricingPolicy `json:"pricingpolicy"`
+
+		// ScrubRate is the number of bytes per second the host reads to
+		// verify the integrity of its stored sectors. A value of 0 disables
+		// the scrubber.
+		ScrubRate uint64 `json:"scrubrate"`
+
+		// MaintenanceMode drains the host before it is taken offline. While
+		// enabled, the host keeps serving its existing storage obligations
+		// but doesn't accept any new contracts or renewals.
+		MaintenanceMode bool `json:"maintenancemode"`
+
+		// MaintenanceWindowStart and MaintenanceWindowEnd are the block
+		// heights of a planned maintenance. The host refuses contracts whose
+		// proof window overlaps the maintenance window. A window end of 0
+		// means that no maintenance is planned.
+		MaintenanceWindowStart types.BlockHeight `json:"maintenancewindowstart"`
+		MaintenanceWindowEnd   types.BlockHeight `json:"maintenancewindowend"`
+	}
+
+	// HostPricingPolicy configures the host's dynamic pricing. If enabled, the
+	// host periodically adjusts its storage and bandwidth prices to its
+	// storage utilization, collateral budget usage and bandwidth load. The
+	// prices never drop below the host's minimum prices.
+	HostPricingPolicy struct {
+		Enabled bool `json:"enabled"`
+
+		// ExchangeRate is the value of one siacoin, e.g. "0.004 usd". If it is
+		// set, the target prices are used as the base prices for the dynamic
+		// pricing instead of the minimum prices.
+		ExchangeRate string `json:"exchangerate"`
+
+		// The target prices are denominated in the currency of the exchange
+		// rate. The storage price is per TB per month, the bandwidth prices
+		// are per TB. A value of 0 means that the minimum price is used as
+		// the base price instead.
+		TargetDownloadBandwidthPrice float64 `json:"targetdownloadbandwidthprice"`
+		TargetStoragePrice           float64 `json:"targetstorageprice"`
+		TargetUploadBandwidthPrice   float64 `json:"targetuploadbandwidthprice"`
+
+		// BandwidthCapacity is the bandwidth in bytes per second in either
+		// direction at which the host considers itself fully loaded. A value
+		// of 0 means that bandwidth load doesn't influence the prices.
+		BandwidthCapacity uint64 `json:"bandwidthcapacity"`
+
+		// MaxPriceMultiplier is the factor by which a fully loaded host
+		// multiplies its base prices. It bounds the dynamic prices.
+		MaxPriceMultiplier float64 `json:"maxpricemultiplier"`
+
+		// Smoothing is the weight, between 0 and 1, of the most recent load
+		// when updating the prices. Lower values result in prices that change
+		// more slowly.
+		Smoothing float64 `json:"smoothing"`
+	}
+
+	// HostPrices contains the prices which are set by the host's dynamic
+	// pricing.
+	HostPrices struct {
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	}
+
+	// HostPricing describes the state of the host's dynamic pricing. Current
+	// contains the prices the host is currently using while DryRun contains
+	// the prices the host would set if it updated its prices right now. The
+	// load values are between 0 and 1.
+	HostPricing struct {
+		Enabled    bool      `json:"enabled"`
+		LastUpdate time.Time `json:"lastupdate"`
+
+		CollateralBudgetUtilization float64 `json:"collateralbudgetutilization"`
+		DownloadBandwidthLoad       float64 `json:"downloadbandwidthload"`
+		StorageUtilization          float64 `json:"storageutilization"`
+		UploadBandwidthLoad         float64 `json:"uploadbandwidthload"`
+
+		Current HostPrices `json:"current"`
+		DryRun  HostPrices `json:"dryrun"`
+	}
+
+	// HostRegistryEntry describes an entry of the host's registry.
+	HostRegistryEntry struct {
+		EntryID   crypto.Hash        `json:"entryid"`
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Tweak     crypto.Hash        `json:"tweak"`
+		Expiry    types.BlockHeight  `json:"expiry"`
+		Revision  uint64             `json:"revision"`
+		Type      RegistryEntryType  `json:"type"`
+		DataSize  uint64             `json:"datasize"`
+
+		// Data is only set when a single entry is looked up.
+		Data []byte `json:"data,omitempty"`
+	}
+
+	// HostRegistryExpiryBucket is the number of registry entries which expire
+	// before a certain height but not before the height of the previous
+	// bucket.
+	HostRegistryExpiryBucket struct {
+		ExpiresBefore types.BlockHeight `json:"expiresbefore"`
+		Entries       uint64            `json:"entries"`
+	}
+
+	// HostRegistryKeyUsage is the number of registry entries stored for a
+	// public key.
+	HostRegistryKeyUsage struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Entries   uint64             `json:"entries"`
+	}
+
+	// HostRegistryStats contains information about the usage of the host's
+	// registry.
+	HostRegistryStats struct {
+		Capacity uint64 `json:"capacity"`
+		Entries  uint64 `json:"entries"`
+		Expired  uint64 `json:"expired"`
+
+		// ExpiryDistribution groups the entries that aren't expired yet by
+		// their expiry height.
+		ExpiryDistribution []HostRegistryExpiryBucket `json:"expirydistribution"`
+
+		// Keys contains the usage of each public key, sorted by the number of
+		// entries in descending order.
+		Keys []HostRegistryKeyUsage `json:"keys"`
+	}
+
+	// HostRenterUsage contains the resources used by a single renter. Renters
+	// are identified by the public key they use in their contracts. The
+	// bandwidth is the bandwidth used within the current bandwidth period.
+	HostRenterUsage struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+
+		Accounts        uint64 `json:"accounts"`
+		Contracts       uint64 `json:"contracts"`
+		RegistryEntries uint64 `json:"registryentries"`
+		Sectors         uint64 `json:"sectors"`
+
+		DownloadBandwidth    uint64    `json:"downloadbandwidth"`
+		UploadBandwidth      uint64    `json:"uploadbandwidth"`
+		BandwidthPeriodStart time.Time `json:"bandwidthperiodstart"`
+	}
+
+	// HostBandwidthUsage is the number of calls and the bandwidth used by a
+	// single RPC, MDM instruction or renter. Download is the data received by
+	// the host and Upload is the data sent by the host.
+	HostBandwidthUsage struct {
+		Calls    uint64 `json:"calls"`
+		Download uint64 `json:"download"`
+		Upload   uint64 `json:"upload"`
+	}
+
+	// HostBandwidthReport contains the bandwidth used by the host within a
+	// time range. RPCs are identified by their specifier, instructions by
+	// their instruction specifier and renters by their public key. The range
+	// is extended to the boundaries of the intervals in which the host
+	// aggregates its bandwidth.
+	HostBandwidthReport struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+
+		RPCs         map[string]HostBandwidthUsage `json:"rpcs"`
+		Instructions map[string]HostBandwidthUsage `json:"instructions"`
+		Renters      map[string]HostBandwidthUsage `json:"renters"`
+	}
+
+	// HostScrubReport contains the status of the host's sector scrubber and
+	// the storage obligations which are at risk of failing their storage
+	// proof because some of their sectors are corrupted.
+	HostScrubReport struct {
+		StorageScrubStatus
+		ObligationsAtRisk []HostObligationAtRisk `json:"obligationsatrisk"`
+	}
+
+	// HostObligationAtRisk is a storage obligation containing sectors which
+	// failed the integrity check of the sector scrubber.
+	HostObligationAtRisk struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ProofDeadline    types.BlockHeight    `json:"proofdeadline"`
+		CorruptedSectors []crypto.Hash        `json:"corruptedsectors"`
+	}
+
+	// HostMaintenanceStatus reports whether the host is in maintenance mode and
+	// which storage obligations still need to be proven before it is safe to
+	// shut down the host.
+	HostMaintenanceStatus struct {
+		MaintenanceMode bool              `json:"maintenancemode"`
+		WindowStart     types.BlockHeight `json:"windowstart"`
+		WindowEnd       types.BlockHeight `json:"windowend"`
+
+		// PendingObligations are the storage obligations which still require
+		// a storage proof, sorted by their proof window.
+		PendingObligations []HostPendingObligation `json:"pendingobligations"`
+
+		// DrainHeight is the block height at which the proof window of the
+		// last pending obligation closes.
+		DrainHeight types.BlockHeight `json:"drainheight"`
+
+		// SafeToShutDown indicates that the host is in maintenance mode and
+		// doesn't have any pending obligations.
+		SafeToShutDown bool `json:"safetoshutdown"`
+	}
+
+	// HostPendingObligation is a storage obligation which still requires a
+	// storage proof.
+	HostPendingObligation struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ProofWindowStart types.BlockHeight    `json:"proofwindowstart"`
+		ProofWindowEnd   types.BlockHeight    `json:"proofwindowend"`
+		ProofConstructed bool                 `json:"proofconstructed"`
+	}
+
+	// StorageObligationRisk describes the conditions which put the storage
+	// proof of a storage obligation at risk. Only obligations which still
+	// require a storage proof can be at risk.
+	StorageObligationRisk struct {
+		// AtRisk indicates that the host is unlikely to submit a valid
+		// storage proof for the obligation unless the operator acts.
+		AtRisk bool `json:"atrisk"`
+
+		// MissingSectors and CorruptedSectors are the number of sectors of
+		// the obligation which are not stored by the host or which failed the
+		// integrity check of the sector scrubber.
+		MissingSectors   uint64 `json:"missingsectors"`
+		CorruptedSectors uint64 `json:"corruptedsectors"`
+
+		// InsufficientFunds indicates that the wallet can't pay the fee of
+		// the storage proof transaction after paying the fees of all proofs
+		// which are due earlier. A locked wallet has no funds.
+		InsufficientFunds bool           `json:"insufficientfunds"`
+		EstimatedProofFee types.Currency `json:"estimatedprooffee"`
+
+		// ProofWindowApproaching indicates that the proof window opens soon
+		// or is already open.
+		ProofWindowApproaching bool `json:"proofwindowapproaching"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -342,10 +598,14 @@ type (
 		// or a proof has been confirmed on the blockchain.
 		ValidProofOutputs  []types.SiacoinOutput `json:"validproofoutputs"`
 		MissedProofOutputs []types.SiacoinOutput `json:"missedproofoutputs"`
+
+		// Risk describes whether the storage proof of the obligation is at
+		// risk.
+		Risk StorageObligationRisk `json:"risk"`
 	}
 
 	// HostWorkingStatus reports the working state of a host. Can be one of
-	// "checking", "working", or "not working".
+	// "checking", "working", "not working", "draining" or "drained".
 	HostWorkingStatus string
 
 	// HostConnectabilityStatus reports the connectability state of a host. Can be
@@ -413,6 +673,10 @@ type (
 		// BandwidthCounters returns the Hosts's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
+		// BandwidthUsage returns the bandwidth used by the host between start
+		// and end broken down by RPC, MDM instruction and renter.
+		BandwidthUsage(start, end time.Time) (HostBandwidthReport, error)
+
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
@@ -420,6 +684,11 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// MaintenanceStatus returns whether the host is in maintenance mode
+		// and the storage obligations which still need to be proven before
+		// the host can be shut down.
+		MaintenanceStatus() (HostMaintenanceStatus, error)
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
@@ -429,6 +698,11 @@ type (
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
+		// Pricing returns the state of the host's dynamic pricing. The dry run
+		// prices are computed using the pricing policy of the provided
+		// settings.
+		Pricing(settings HostInternalSettings) (HostPricing, error)
+
 		// PruneStaleStorageObligations will delete storage obligations from the
 		// host that, for whatever reason, did not make it on the block chain.
 		// As these stale storage obligations have an impact on the host
@@ -458,6 +732,33 @@ type (
 		// of all at once.
 		MarkSectorsForRemoval(sectorRoots []crypto.Hash) error
 
+		// MigrateStorageFolder will move all of the sectors of the source
+		// storage folder into the destination storage folder in the
+		// background and remove the source once it is empty. The migration is
+		// throttled to the provided bandwidth in bytes per second and disk
+		// operations per second, with 0 meaning no limit.
+		MigrateStorageFolder(source, destination uint16, maxBandwidth, maxIOPS uint64) error
+
+		// RegistryDelete deletes the entry with the given id from the host's
+		// registry.
+		RegistryDelete(sid RegistryEntryID) error
+
+		// RegistryEntry returns the entry with the given id from the host's
+		// registry.
+		RegistryEntry(sid RegistryEntryID) (HostRegistryEntry, error)
+
+		// RegistryStats returns information about the usage of the host's
+		// registry.
+		RegistryStats() (HostRegistryStats, error)
+
+		// RegistryTruncate removes expired entries from the registry and
+		// resizes it to the given size in bytes. Resizing fails if the
+		// remaining entries don't fit into the new size.
+		RegistryTruncate(size uint64) error
+
+		// RenterUsage returns the resources used by each renter of the host.
+		RenterUsage() ([]HostRenterUsage, error)
+
 		// RemoveStorageFolder will remove a storage folder from the host. All
 		// storage on the folder will be moved to other storage folders, meaning
 		// that no data will be lost. If the host is unable to save data, an
@@ -481,9 +782,17 @@ type (
 		// and the resize operation completed, meaning that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubReport returns the status of the sector scrubber and the
+		// storage obligations with corrupted sectors.
+		ScrubReport() (HostScrubReport, error)
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetStorageFolderTier sets the tier of a storage folder on the host.
+		// Sectors are moved between the tiers in the background.
+		SetStorageFolderTier(index uint16, tier StorageTier) error
+
 		// StorageObligation returns the storage obligation matching the id or
 		// an error if it does not exist
 		StorageObligation(obligationID types.FileContractID) (StorageObligation, error)
@@ -496,6 +805,14 @@ type (
 		// host.
 		StorageFolders() []StorageFolderMetadata
 
+		// StorageFolderMigrations returns the storage folder migrations that
+		// are in progress.
+		StorageFolderMigrations() []StorageFolderMigration
+
+		// TieringStatus returns the status of the tiering of sectors between
+		// the storage folders of the different tiers.
+		TieringStatus() StorageTieringStatus
+
 		// WorkingStatus returns the working state of the host, determined by if
 		// settings calls are increasing.
 		WorkingStatus() HostWorkingStatus
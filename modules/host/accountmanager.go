@@ -866,11 +866,17 @@ func (am *accountManager) threadedPruneExpiredAccounts() {
 
 			// Expire accounts that have been inactive for too long. Keep track
 			// of the indexes that got expired.
-			expired := am.managedExpireAccounts(accountExpiryTimeout)
+			expired, expiredIDs := am.managedExpireAccounts(accountExpiryTimeout)
 			if len(expired) == 0 {
 				return
 			}
 
+			// Expired accounts no longer count towards the quota of their
+			// renters.
+			if err := am.h.managedUnattributeAccounts(expiredIDs); err != nil {
+				am.h.log.Println(errors.AddContext(err, "failed to remove renters of expired accounts"))
+			}
+
 			// Batch delete the expired accounts on disk
 			deleted, err := am.staticAccountsPersister.callBatchDeleteAccount(expired)
 			if err != nil {
@@ -926,8 +932,8 @@ func (am *accountManager) staticWaitForWithdrawalResult(commitResultChan chan er
 }
 
 // managedExpireAccounts will expire accounts where the lastTxnTime exceeds the
-// given threshold.
-func (am *accountManager) managedExpireAccounts(threshold int64) []uint32 {
+// given threshold. It returns the indexes and ids of the expired accounts.
+func (am *accountManager) managedExpireAccounts(threshold int64) ([]uint32, []modules.AccountID) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
@@ -935,6 +941,7 @@ func (am *accountManager) managedExpireAccounts(threshold int64) []uint32 {
 	force := am.h.dependencies.Disrupt("expireEphemeralAccounts")
 
 	var deleted []uint32
+	var deletedIDs []modules.AccountID
 	now := time.Now().Unix()
 	for id, acc := range am.accounts {
 		if force || now-acc.lastTxnTime > threshold {
@@ -945,9 +952,10 @@ func (am *accountManager) managedExpireAccounts(threshold int64) []uint32 {
 			}
 			delete(am.accounts, id)
 			deleted = append(deleted, acc.index)
+			deletedIDs = append(deletedIDs, id)
 		}
 	}
-	return deleted
+	return deleted, deletedIDs
 }
 
 // callAccountBalance will return the balance of an account.
@@ -0,0 +1,286 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+type (
+	// bandwidthRecord is the bandwidth used by the host during a single
+	// interval.
+	bandwidthRecord struct {
+		RPCs         map[string]modules.HostBandwidthUsage `json:"rpcs"`
+		Instructions map[string]modules.HostBandwidthUsage `json:"instructions"`
+		Renters      map[string]modules.HostBandwidthUsage `json:"renters"`
+	}
+
+	// bandwidthAccounting aggregates the bandwidth used by the host in
+	// intervals of bandwidthInterval. The records are saved to the host's
+	// database periodically and on shutdown.
+	bandwidthAccounting struct {
+		// current is the record of the interval starting at currentStart.
+		current      bandwidthRecord
+		currentStart int64
+
+		// unsaved contains the records of finished intervals which haven't
+		// been saved yet, indexed by the start of their interval.
+		unsaved map[int64]bandwidthRecord
+
+		mu sync.Mutex
+	}
+)
+
+// newBandwidthRecord creates an empty bandwidthRecord.
+func newBandwidthRecord() bandwidthRecord {
+	return bandwidthRecord{
+		RPCs:         make(map[string]modules.HostBandwidthUsage),
+		Instructions: make(map[string]modules.HostBandwidthUsage),
+		Renters:      make(map[string]modules.HostBandwidthUsage),
+	}
+}
+
+// newBandwidthAccounting creates a new bandwidthAccounting.
+func newBandwidthAccounting() *bandwidthAccounting {
+	return &bandwidthAccounting{
+		current:      newBandwidthRecord(),
+		currentStart: bandwidthIntervalStart(time.Now()),
+		unsaved:      make(map[int64]bandwidthRecord),
+	}
+}
+
+// bandwidthIntervalStart returns the start of the interval containing t as a
+// unix timestamp.
+func bandwidthIntervalStart(t time.Time) int64 {
+	return t.Truncate(bandwidthInterval).Unix()
+}
+
+// bandwidthRecordKey returns the database key of the record of the interval
+// starting at start. Big endian keys keep the records sorted by time.
+func bandwidthRecordKey(start int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(start))
+	return key
+}
+
+// addUsage adds the bandwidth of a single call to a map of usages.
+func addUsage(usages map[string]modules.HostBandwidthUsage, key string, calls, download, upload uint64) {
+	usage := usages[key]
+	usage.Calls += calls
+	usage.Download += download
+	usage.Upload += upload
+	usages[key] = usage
+}
+
+// merge adds the usage of another record to the record.
+func (br bandwidthRecord) merge(other bandwidthRecord) {
+	for key, usage := range other.RPCs {
+		addUsage(br.RPCs, key, usage.Calls, usage.Download, usage.Upload)
+	}
+	for key, usage := range other.Instructions {
+		addUsage(br.Instructions, key, usage.Calls, usage.Download, usage.Upload)
+	}
+	for key, usage := range other.Renters {
+		addUsage(br.Renters, key, usage.Calls, usage.Download, usage.Upload)
+	}
+}
+
+// rotate starts a new interval if the current one is over.
+func (ba *bandwidthAccounting) rotate(now time.Time) {
+	start := bandwidthIntervalStart(now)
+	if start == ba.currentStart {
+		return
+	}
+	ba.unsaved[ba.currentStart] = ba.current
+	ba.current = newBandwidthRecord()
+	ba.currentStart = start
+}
+
+// managedAddInstruction adds the bandwidth used by an MDM instruction.
+func (ba *bandwidthAccounting) managedAddInstruction(specifier modules.InstructionSpecifier, download, upload uint64) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	ba.rotate(time.Now())
+	addUsage(ba.current.Instructions, types.Specifier(specifier).String(), 1, download, upload)
+}
+
+// managedAddRenter adds the bandwidth used by an RPC of a renter.
+func (ba *bandwidthAccounting) managedAddRenter(spk types.SiaPublicKey, download, upload uint64) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	ba.rotate(time.Now())
+	addUsage(ba.current.Renters, spk.String(), 1, download, upload)
+}
+
+// managedAddRPC adds the bandwidth used by an RPC.
+func (ba *bandwidthAccounting) managedAddRPC(id types.Specifier, download, upload uint64) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	ba.rotate(time.Now())
+	addUsage(ba.current.RPCs, id.String(), 1, download, upload)
+}
+
+// managedRecords returns copies of the records which haven't been saved
+// yet, including the record of the current interval, and the start of the
+// current interval.
+func (ba *bandwidthAccounting) managedRecords() (map[int64]bandwidthRecord, int64) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	ba.rotate(time.Now())
+	records := make(map[int64]bandwidthRecord, len(ba.unsaved)+1)
+	for start, record := range ba.unsaved {
+		records[start] = record
+	}
+	current := newBandwidthRecord()
+	current.merge(ba.current)
+	records[ba.currentStart] = current
+	return records, ba.currentStart
+}
+
+// managedMarkSaved removes the saved records of finished intervals. The
+// record of the interval that was current when the records were copied might
+// have finished in the meantime and received more bandwidth, so it stays
+// unsaved.
+func (ba *bandwidthAccounting) managedMarkSaved(records map[int64]bandwidthRecord, currentStart int64) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	for start := range records {
+		if start != currentStart {
+			delete(ba.unsaved, start)
+		}
+	}
+}
+
+// BandwidthUsage returns the bandwidth used by the host between start and end
+// broken down by RPC, MDM instruction and renter.
+func (h *Host) BandwidthUsage(start, end time.Time) (modules.HostBandwidthReport, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostBandwidthReport{}, err
+	}
+	defer h.tg.Done()
+
+	if end.Before(start) {
+		return modules.HostBandwidthReport{}, errors.New("end of the range is before its start")
+	}
+	from, to := bandwidthIntervalStart(start), bandwidthIntervalStart(end)
+
+	// Load the saved records first and overwrite them with the unsaved ones,
+	// which are more recent.
+	records := make(map[int64]bandwidthRecord)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketBandwidthUsage).Cursor()
+		for k, v := c.Seek(bandwidthRecordKey(from)); k != nil && int64(binary.BigEndian.Uint64(k)) <= to; k, v = c.Next() {
+			var record bandwidthRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return errors.AddContext(err, "failed to decode bandwidth record")
+			}
+			records[int64(binary.BigEndian.Uint64(k))] = record
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.HostBandwidthReport{}, errors.AddContext(err, "failed to load bandwidth records")
+	}
+	unsaved, _ := h.staticBandwidthAccounting.managedRecords()
+	for recordStart, record := range unsaved {
+		if recordStart >= from && recordStart <= to {
+			records[recordStart] = record
+		}
+	}
+
+	total := newBandwidthRecord()
+	for _, record := range records {
+		total.merge(record)
+	}
+	return modules.HostBandwidthReport{
+		Start:        time.Unix(from, 0),
+		End:          time.Unix(to, 0).Add(bandwidthInterval),
+		RPCs:         total.RPCs,
+		Instructions: total.Instructions,
+		Renters:      total.Renters,
+	}, nil
+}
+
+// loadBandwidthUsage loads the record of the current interval from the
+// database to continue it after a restart.
+func (h *Host) loadBandwidthUsage(tx *bolt.Tx) error {
+	ba := h.staticBandwidthAccounting
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	v := tx.Bucket(bucketBandwidthUsage).Get(bandwidthRecordKey(ba.currentStart))
+	if v == nil {
+		return nil
+	}
+	record := newBandwidthRecord()
+	if err := json.Unmarshal(v, &record); err != nil {
+		return errors.AddContext(err, "failed to decode bandwidth record")
+	}
+	record.merge(ba.current)
+	ba.current = record
+	return nil
+}
+
+// managedSaveBandwidthUsage saves the unsaved bandwidth records and removes
+// the records that are older than bandwidthRetention.
+func (h *Host) managedSaveBandwidthUsage() error {
+	records, currentStart := h.staticBandwidthAccounting.managedRecords()
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketBandwidthUsage)
+		for start, record := range records {
+			v, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(bandwidthRecordKey(start), v); err != nil {
+				return err
+			}
+		}
+
+		// Prune the expired records.
+		expiry := bandwidthIntervalStart(time.Now().Add(-bandwidthRetention))
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && int64(binary.BigEndian.Uint64(k)) < expiry; k, _ = c.First() {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to save bandwidth records")
+	}
+	h.staticBandwidthAccounting.managedMarkSaved(records, currentStart)
+	return nil
+}
+
+// threadedSaveBandwidthUsage periodically saves the bandwidth used by the
+// host.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedSaveBandwidthUsage() {
+	for {
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(bandwidthSaveFrequency):
+		}
+
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.managedSaveBandwidthUsage(); err != nil {
+				h.log.Println("ERROR:", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,169 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBandwidthAccounting is a unit test for the bandwidthAccounting.
+func TestBandwidthAccounting(t *testing.T) {
+	t.Parallel()
+
+	ba := newBandwidthAccounting()
+	_, pk := crypto.GenerateKeyPair()
+	renter := types.Ed25519PublicKey(pk)
+
+	// Add some bandwidth to the current interval.
+	ba.managedAddRPC(modules.RPCExecuteProgram, 10, 20)
+	ba.managedAddRPC(modules.RPCExecuteProgram, 1, 2)
+	ba.managedAddInstruction(modules.SpecifierHasSector, 3, 4)
+	ba.managedAddRenter(renter, 5, 6)
+	records, currentStart := ba.managedRecords()
+	if len(records) != 1 {
+		t.Fatal("expected a single record", len(records))
+	}
+	record := records[currentStart]
+	if u := record.RPCs[modules.RPCExecuteProgram.String()]; u.Calls != 2 || u.Download != 11 || u.Upload != 22 {
+		t.Fatal("unexpected rpc usage", u)
+	}
+	if u := record.Instructions[types.Specifier(modules.SpecifierHasSector).String()]; u.Calls != 1 || u.Download != 3 || u.Upload != 4 {
+		t.Fatal("unexpected instruction usage", u)
+	}
+	if u := record.Renters[renter.String()]; u.Calls != 1 || u.Download != 5 || u.Upload != 6 {
+		t.Fatal("unexpected renter usage", u)
+	}
+
+	// The returned record is a copy.
+	ba.managedAddRPC(modules.RPCExecuteProgram, 1, 1)
+	if u := record.RPCs[modules.RPCExecuteProgram.String()]; u.Calls != 2 {
+		t.Fatal("record wasn't copied", u)
+	}
+
+	// Move the current interval into the past. The next call should start a
+	// new interval.
+	ba.mu.Lock()
+	ba.currentStart -= int64(bandwidthInterval.Seconds())
+	oldStart := ba.currentStart
+	ba.mu.Unlock()
+	ba.managedAddRPC(modules.RPCUpdatePriceTable, 1, 1)
+	records, currentStart = ba.managedRecords()
+	if len(records) != 2 || currentStart == oldStart {
+		t.Fatal("expected a new interval", len(records))
+	}
+	if len(records[currentStart].RPCs) != 1 || len(records[oldStart].RPCs) != 1 {
+		t.Fatal("bandwidth was added to the wrong interval", records)
+	}
+
+	// Marking the records as saved should keep the current interval only.
+	ba.managedMarkSaved(records, currentStart)
+	ba.mu.Lock()
+	numUnsaved := len(ba.unsaved)
+	ba.mu.Unlock()
+	if numUnsaved != 0 {
+		t.Fatal("finished interval should have been saved", numUnsaved)
+	}
+	records, _ = ba.managedRecords()
+	if len(records) != 1 {
+		t.Fatal("expected a single record", len(records))
+	}
+}
+
+// TestHostBandwidthUsage verifies the host breaks down its bandwidth by RPC,
+// instruction and renter and persists it across restarts.
+func TestHostBandwidthUsage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := pair.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ht := pair.staticHT
+
+	// Fund the pair's account so the host knows the renter of the account.
+	pt := pair.managedPriceTable()
+	_, err = pair.managedFundEphemeralAccount(pt.FundAccountCost.Add(types.SiacoinPrecision), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Execute a HasSector program.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = ht.host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt = pair.managedPriceTable()
+	pb := modules.NewProgramBuilder(pt, 0)
+	pb.AddHasSectorInstruction(sectorRoot)
+	program, data := pb.Program()
+	programCost, _, _ := pb.Cost(true)
+	epr := modules.RPCExecuteProgramRequest{
+		FileContractID:    pair.staticFCID,
+		Program:           program,
+		ProgramDataLength: uint64(len(data)),
+	}
+	cost := programCost.Add(pt.DownloadBandwidthCost.Add(pt.UploadBandwidthCost).Mul64(10e3))
+	_, _, err = pair.managedExecuteProgram(epr, data, cost, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkUsage is a helper that checks the host's bandwidth usage.
+	checkUsage := func() error {
+		report, err := ht.host.BandwidthUsage(time.Unix(0, 0), time.Now())
+		if err != nil {
+			return err
+		}
+		for _, rpc := range []types.Specifier{modules.RPCFundAccount, modules.RPCUpdatePriceTable, modules.RPCExecuteProgram} {
+			if u := report.RPCs[rpc.String()]; u.Calls == 0 || u.Download == 0 || u.Upload == 0 {
+				return errors.New("missing bandwidth of rpc " + rpc.String())
+			}
+		}
+		if u := report.Instructions[types.Specifier(modules.SpecifierHasSector).String()]; u.Calls != 1 || u.Download == 0 || u.Upload == 0 {
+			return errors.New("missing bandwidth of instruction")
+		}
+		if u := report.Renters[pair.staticRenterPK.String()]; u.Calls == 0 || u.Download == 0 || u.Upload == 0 {
+			return errors.New("missing bandwidth of renter")
+		}
+		return nil
+	}
+	if err := checkUsage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A range in the past shouldn't contain any bandwidth.
+	report, err := ht.host.BandwidthUsage(time.Unix(0, 0), time.Now().Add(-bandwidthRetention))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.RPCs) != 0 || len(report.Instructions) != 0 || len(report.Renters) != 0 {
+		t.Fatal("unexpected bandwidth in the past", report)
+	}
+	if _, err := ht.host.BandwidthUsage(time.Now(), time.Unix(0, 0)); err == nil {
+		t.Fatal("expected an error for an invalid range")
+	}
+
+	// Reload the host. The usage should be the same.
+	err = reloadHost(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkUsage(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -12,6 +12,9 @@ const (
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+	// AlertMSGHostObligationsAtRisk indicates that a host has storage
+	// obligations which are likely to fail their storage proof
+	AlertMSGHostObligationsAtRisk = "storage obligations at risk"
 )
 
 const (
@@ -34,6 +37,14 @@ const (
 	// maxObligationLockTimeout is the maximum amount of time the host will wait
 	// to lock a storage obligation.
 	maxObligationLockTimeout = 10 * time.Minute
+
+	// defaultPricingMaxPriceMultiplier is the default factor by which a fully
+	// loaded host multiplies its base prices when dynamic pricing is enabled.
+	defaultPricingMaxPriceMultiplier = 3
+
+	// defaultPricingSmoothing is the default weight of the most recent load
+	// when the host updates its dynamic prices.
+	defaultPricingSmoothing = 0.25
 )
 
 var (
@@ -77,6 +88,15 @@ var (
 	// prevent the host from having too much money at risk.
 	defaultMaxEphemeralAccountRisk = types.SiacoinPrecision.Mul64(5)
 
+	// pricingUpdateFrequency is the frequency at which the host updates its
+	// prices if dynamic pricing is enabled.
+	pricingUpdateFrequency = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testnet:  time.Minute * 10,
+		Testing:  time.Second * 2,
+	}).(time.Duration)
+
 	// logAllLimit is the number of errors of each type that the host will log
 	// before switching to probabilistic logging. If there are not many errors,
 	// it is reasonable that all errors get logged. If there are lots of
@@ -118,6 +138,101 @@ var (
 		Testing:  types.BlockHeight(4),
 	}).(types.BlockHeight)
 
+	// bandwidthInterval is the length of the intervals in which the host
+	// aggregates its bandwidth usage.
+	bandwidthInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// bandwidthRetention is the duration for which the host keeps the
+	// records of its bandwidth usage.
+	bandwidthRetention = build.Select(build.Var{
+		Dev:      time.Hour * 24,
+		Standard: time.Hour * 24 * 90,
+		Testnet:  time.Hour * 24 * 90,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// bandwidthSaveFrequency is the frequency at which the host saves its
+	// bandwidth usage.
+	bandwidthSaveFrequency = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Minute * 5,
+		Testnet:  time.Minute * 5,
+		Testing:  time.Millisecond * 500,
+	}).(time.Duration)
+
+	// proofWindowRiskThreshold is the number of blocks before the proof
+	// window of a storage obligation opens at which the window is considered
+	// to be approaching. Obligations at risk within this threshold trigger a
+	// critical alert.
+	proofWindowRiskThreshold = build.Select(build.Var{
+		Dev:      types.BlockHeight(40),  // About 8 minutes
+		Standard: types.BlockHeight(432), // 3 days.
+		Testnet:  types.BlockHeight(432), // 3 days.
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// obligationRiskCheckFrequency is the frequency at which the host checks
+	// whether its storage obligations are at risk.
+	obligationRiskCheckFrequency = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testnet:  time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// hotSectorsUpdateFrequency is the frequency at which the host tells the
+	// storage manager which sectors it expects to be read often.
+	hotSectorsUpdateFrequency = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testnet:  time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// hotContractDuration is the maximum duration of a storage obligation for
+	// its sectors to be considered hot. Short contracts are usually formed
+	// for data that is read frequently.
+	hotContractDuration = build.Select(build.Var{
+		Dev:      types.BlockHeight(100),  // About 20 minutes
+		Standard: types.BlockHeight(1008), // 1 week
+		Testnet:  types.BlockHeight(1008), // 1 week
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
+
+	// maxHotSectors is the maximum number of sectors the host marks as hot.
+	// It limits the memory used by the set of hot sectors of the storage
+	// manager.
+	maxHotSectors = build.Select(build.Var{
+		Dev:      1 << 12,
+		Standard: 1 << 18, // 1 TiB
+		Testnet:  1 << 18, // 1 TiB
+		Testing:  1 << 8,
+	}).(int)
+
+	// hotRenterRegistryEntries is the number of registry entries at which a
+	// renter is considered to be registry-heavy. The sectors of the storage
+	// obligations of registry-heavy renters are considered hot.
+	hotRenterRegistryEntries = build.Select(build.Var{
+		Dev:      uint64(100),
+		Standard: uint64(1000),
+		Testnet:  uint64(1000),
+		Testing:  uint64(1),
+	}).(uint64)
+
+	// renterBandwidthPeriod is the length of the period after which the
+	// bandwidth used by each renter is reset.
+	renterBandwidthPeriod = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: time.Hour * 24,
+		Testnet:  time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
 	// rpcRatelimit prevents someone from spamming the host with connections,
 	// causing it to spin up enough goroutines to crash.
 	rpcRatelimit = build.Select(build.Var{
@@ -168,6 +283,19 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketBandwidthUsage maps the start of an interval, stored as a big
+	// endian unix timestamp, to the bandwidth used by the host during that
+	// interval.
+	bucketBandwidthUsage = []byte("BucketBandwidthUsage")
+
+	// bucketRenterAccounts maps the ephemeral accounts to the public key of
+	// the renter that funded them.
+	bucketRenterAccounts = []byte("BucketRenterAccounts")
+
+	// bucketRenterRegistryEntries maps registry entry ids to the public key of
+	// the renter that paid for the creation of the entry.
+	bucketRenterRegistryEntries = []byte("BucketRenterRegistryEntries")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
@@ -12,6 +12,10 @@ const (
 	// AlertMSGHostDiskTrouble indicates that one or multiple of a host's disks
 	// are encountering problems
 	AlertMSGHostDiskTrouble = "disk problem detected"
+
+	// AlertMSGHostCorruptedSectors indicates that the host found sectors on
+	// disk which are corrupted or can't be read
+	AlertMSGHostCorruptedSectors = "corrupted sectors detected"
 )
 
 const (
@@ -53,6 +57,10 @@ const (
 	// sectorRemovalFile is the path to the file used to store the sector removal
 	// queue.
 	sectorRemovalQueueFile = "sector_removal.dat"
+
+	// sectorScrubFile is the name of the file that is used to save the state
+	// of the sector scrubber.
+	sectorScrubFile = "sector_scrub.json"
 )
 
 const (
@@ -89,6 +97,13 @@ var (
 		Version: "1.2.0",
 	}
 
+	// sectorScrubMetadata is the header that is used when writing the state
+	// of the sector scrubber to disk.
+	sectorScrubMetadata = persist.Metadata{
+		Header:  "Sia Contract Manager Sector Scrubber",
+		Version: "1.5.6",
+	}
+
 	// walMetadata is the header that is used when writing the write ahead log
 	// to disk, so that it may be identified at startup.
 	walMetadata = persist.Metadata{
@@ -139,6 +154,55 @@ var (
 		Testing:  time.Second,
 	}).(time.Duration)
 
+	// sectorTieringInterval specifies the amount of time between two cycles
+	// of moving sectors between the storage tiers. The read counts of the
+	// sectors are halved after every cycle.
+	sectorTieringInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testnet:  10 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// sectorTieringMaxMoves is the maximum number of sectors that are promoted
+	// and demoted respectively within a single tiering cycle.
+	sectorTieringMaxMoves = build.Select(build.Var{
+		Dev:      64,
+		Standard: 1024,
+		Testnet:  1024,
+		Testing:  16,
+	}).(int)
+
+	// sectorTieringBatchSize is the number of sectors that are looked up in
+	// the sector locations at once while searching for sectors to move. The
+	// sector lock is released between batches so that other operations don't
+	// have to wait for the whole search.
+	sectorTieringBatchSize = build.Select(build.Var{
+		Dev:      256,
+		Standard: 4096,
+		Testnet:  4096,
+		Testing:  4,
+	}).(int)
+
+	// sectorPromotionReads is the read count at which a sector is promoted to
+	// the fast tier. Sectors are only demoted once their read count has
+	// decayed to 0, to prevent them from being moved back and forth.
+	sectorPromotionReads = build.Select(build.Var{
+		Dev:      uint64(3),
+		Standard: uint64(3),
+		Testnet:  uint64(3),
+		Testing:  uint64(2),
+	}).(uint64)
+
+	// scrubIdleInterval specifies the amount of time that the sector
+	// scrubber waits before starting a new cycle if the host has no sectors.
+	scrubIdleInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// maxFolderRecheckInterval specifies the maximum amount of time that the
 	// contract manager will wait between checking if an unavailable storage
 	// folder has become available.
@@ -114,10 +114,22 @@ type ContractManager struct {
 	sectorLocations map[sectorID]sectorLocation
 	storageFolders  map[uint16]*storageFolder
 
+	// migrations contains the storage folder migrations that are in progress,
+	// indexed by the source storage folder.
+	migrations map[uint16]*storageFolderMigration
+
 	// sectors are removed from the store in a rate-limited queue to work around
 	// lock contention on extra large contracts.
 	sectorRemoval *sectorRemovalMap
 
+	// scrubber verifies the integrity of the stored sectors in the
+	// background.
+	scrubber *sectorScrubber
+
+	// tiering moves sectors between the storage folders of the different
+	// tiers in the background.
+	tiering *sectorTiering
+
 	// Utilities.
 	dependencies  modules.Dependencies
 	staticAlerter *modules.GenericAlerter
@@ -136,6 +148,7 @@ func (cm *ContractManager) Close() error {
 // the provided dependencies.
 func newContractManager(dependencies modules.Dependencies, persistDir string) (_ *ContractManager, err error) {
 	cm := &ContractManager{
+		migrations:      make(map[uint16]*storageFolderMigration),
 		storageFolders:  make(map[uint16]*storageFolder),
 		sectorLocations: make(map[sectorID]sectorLocation),
 
@@ -265,6 +278,19 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		err = errors.Compose(cm.sectorRemoval.Close(), err)
 	})
 
+	// Start the sector scrubber.
+	cm.scrubber, err = newSectorScrubber(filepath.Join(persistDir, sectorScrubFile), cm)
+	if err != nil {
+		return nil, errors.AddContext(err, "error loading the sector scrubber for the contract manager")
+	}
+
+	// Start moving sectors between the storage tiers.
+	cm.tiering = newSectorTiering(cm)
+
+	// Resume any storage folder migrations that were interrupted by the last
+	// shutdown.
+	cm.resumeStorageFolderMigrations()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
@@ -12,6 +12,7 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 )
 
@@ -21,6 +22,7 @@ type (
 	savedStorageFolder struct {
 		Index uint16
 		Path  string
+		Tier  modules.StorageTier
 		Usage []uint64
 	}
 
@@ -29,19 +31,27 @@ type (
 	savedSettings struct {
 		SectorSalt     crypto.Hash
 		StorageFolders []savedStorageFolder
+		Migrations     []storageFolderMigration
 	}
 )
 
 // equals tests if all settings are equal between two savedSettings.
 func (s *savedSettings) equals(sb savedSettings) bool {
-	if s.SectorSalt != sb.SectorSalt || len(s.StorageFolders) != len(sb.StorageFolders) {
+	if s.SectorSalt != sb.SectorSalt || len(s.StorageFolders) != len(sb.StorageFolders) || len(s.Migrations) != len(sb.Migrations) {
 		return false
 	}
 
+	for i, m := range s.Migrations {
+		mb := sb.Migrations[i]
+		if m.Source != mb.Source || m.Destination != mb.Destination || m.MaxBandwidth != mb.MaxBandwidth || m.MaxIOPS != mb.MaxIOPS || m.TotalSectors != mb.TotalSectors {
+			return false
+		}
+	}
+
 	for i, sf := range s.StorageFolders {
 		sfb := sb.StorageFolders[i]
 
-		if sf.Index != sfb.Index || sf.Path != sfb.Path || len(sf.Usage) != len(sfb.Usage) {
+		if sf.Index != sfb.Index || sf.Path != sfb.Path || sf.Tier != sfb.Tier || len(sf.Usage) != len(sfb.Usage) {
 			return false
 		}
 
@@ -60,6 +70,7 @@ func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 	ssf := savedStorageFolder{
 		Index: sf.index,
 		Path:  sf.path,
+		Tier:  sf.tier,
 		Usage: make([]uint64, len(sf.usage)),
 	}
 	copy(ssf.Usage, sf.usage)
@@ -101,6 +112,7 @@ func (cm *ContractManager) loadSettings() error {
 		sf := new(storageFolder)
 		sf.index = ss.StorageFolders[i].Index
 		sf.path = ss.StorageFolders[i].Path
+		sf.tier = savedStorageTier(ss.StorageFolders[i].Tier)
 		sf.usage = ss.StorageFolders[i].Usage
 		sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(ss.StorageFolders[i].Path, metadataFile), os.O_RDWR, 0700)
 		if err != nil {
@@ -122,6 +134,9 @@ func (cm *ContractManager) loadSettings() error {
 		cm.storageFolders[sf.index] = sf
 		cm.sectorMu.Unlock()
 	}
+	for _, m := range ss.Migrations {
+		cm.wal.commitStorageFolderMigration(m)
+	}
 	return nil
 }
 
@@ -189,6 +204,7 @@ func (cm *ContractManager) savedSettings() savedSettings {
 			sf.setUsage(sectorIndex)
 		}
 	}
+	ss.Migrations = cm.savedMigrations()
 	cm.sectorMu.Unlock()
 
 	// canonicalize storage folder ordering; otherwise savedSettings.equals
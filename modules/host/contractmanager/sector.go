@@ -154,6 +154,7 @@ func (cm *ContractManager) ReadPartialSector(root crypto.Hash, offset, length ui
 		return nil, build.ExtendErr("unable to fetch sector", err)
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	cm.tiering.managedAddRead(id)
 	return sectorData, nil
 }
 
@@ -0,0 +1,298 @@
+package contractmanager
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+type (
+	// sectorScrubber periodically re-reads all of the sectors stored by the
+	// contract manager and verifies that their data still matches their
+	// merkle root. Sectors that don't match or can't be read are flagged as
+	// corrupted.
+	sectorScrubber struct {
+		// rate is the number of bytes per second that the scrubber reads. A
+		// rate of 0 disables the scrubber.
+		rate uint64
+
+		// Information about the current and the previous scrubbing cycle.
+		cycleStart      time.Time
+		lastCycleEnd    time.Time
+		sectorsScrubbed uint64
+		sectorsTotal    uint64
+
+		// corrupted contains the sectors which failed the integrity check.
+		corrupted map[sectorID]struct{}
+
+		// rateChanged is used to wake up the scrubber when the rate changes.
+		rateChanged chan struct{}
+
+		cm   *ContractManager
+		wal  *writeAheadLog
+		path string
+		mu   sync.Mutex
+	}
+
+	// savedSectorScrubber contains the fields of the scrubber that are saved
+	// to disk.
+	savedSectorScrubber struct {
+		Corrupted    []sectorID
+		LastCycleEnd time.Time
+	}
+)
+
+// newSectorScrubber initializes the sector scrubber and starts scrubbing in
+// the background. The scrubber is disabled until a rate is set.
+func newSectorScrubber(path string, cm *ContractManager) (*sectorScrubber, error) {
+	ss := &sectorScrubber{
+		corrupted:   make(map[sectorID]struct{}),
+		rateChanged: make(chan struct{}, 1),
+		cm:          cm,
+		wal:         &cm.wal,
+		path:        path,
+	}
+
+	// Load the state of the previous run.
+	var saved savedSectorScrubber
+	err := persist.LoadJSON(sectorScrubMetadata, &saved, path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.AddContext(err, "unable to load the sector scrubber")
+	}
+	ss.lastCycleEnd = saved.LastCycleEnd
+	for _, id := range saved.Corrupted {
+		ss.corrupted[id] = struct{}{}
+	}
+	ss.managedUpdateAlert()
+
+	go ss.threadedScrub()
+	return ss, nil
+}
+
+// saveSync saves the state of the scrubber. The caller must hold the lock.
+func (ss *sectorScrubber) saveSync() error {
+	saved := savedSectorScrubber{
+		LastCycleEnd: ss.lastCycleEnd,
+	}
+	for id := range ss.corrupted {
+		saved.Corrupted = append(saved.Corrupted, id)
+	}
+	return persist.SaveJSON(sectorScrubMetadata, saved, ss.path)
+}
+
+// managedUpdateAlert registers or unregisters the alert for corrupted
+// sectors depending on whether there are any.
+func (ss *sectorScrubber) managedUpdateAlert() {
+	ss.mu.Lock()
+	numCorrupted := len(ss.corrupted)
+	ss.mu.Unlock()
+	if numCorrupted == 0 {
+		ss.cm.staticAlerter.UnregisterAlert(modules.AlertIDHostCorruptedSectors)
+		return
+	}
+	ss.cm.staticAlerter.RegisterAlert(modules.AlertIDHostCorruptedSectors, AlertMSGHostCorruptedSectors,
+		fmt.Sprintf("%v sectors failed the integrity check", numCorrupted), modules.SeverityCritical)
+}
+
+// managedPrune removes the sectors from the set of corrupted sectors which are
+// no longer stored by the contract manager.
+func (ss *sectorScrubber) managedPrune() {
+	ss.mu.Lock()
+	var pruned bool
+	ss.cm.sectorMu.Lock()
+	for id := range ss.corrupted {
+		if _, exists := ss.cm.sectorLocations[id]; !exists {
+			delete(ss.corrupted, id)
+			pruned = true
+		}
+	}
+	ss.cm.sectorMu.Unlock()
+	var err error
+	if pruned {
+		err = ss.saveSync()
+	}
+	ss.mu.Unlock()
+	if err != nil {
+		ss.cm.log.Println("ERROR: unable to save the sector scrubber:", err)
+	}
+	ss.managedUpdateAlert()
+}
+
+// managedScrubSector verifies the integrity of a single sector. True is
+// returned if the sector was found to be corrupted.
+func (ss *sectorScrubber) managedScrubSector(id sectorID) bool {
+	ss.wal.managedLockSector(id)
+	defer ss.wal.managedUnlockSector(id)
+
+	// Find the sector.
+	ss.cm.sectorMu.Lock()
+	sl, exists1 := ss.cm.sectorLocations[id]
+	sf, exists2 := ss.cm.storageFolders[sl.storageFolder]
+	ss.cm.sectorMu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		// The sector has been removed or its storage folder is unavailable,
+		// which is tracked separately.
+		return false
+	}
+
+	// Read the sector and check whether it still matches its id.
+	data, err := readSector(sf.sectorFile, sl.index)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		ss.cm.log.Printf("ERROR: unable to read sector %x from folder %v: %v\n", id, sf.path, err)
+		return true
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+	if ss.cm.managedSectorID(crypto.MerkleRoot(data)) != id {
+		ss.cm.log.Printf("ERROR: sector %x in folder %v doesn't match its merkle root\n", id, sf.path)
+		return true
+	}
+	return false
+}
+
+// managedWait blocks until the scrubber's rate allows for reading the next
+// sector. False is returned if the contract manager is shutting down.
+func (ss *sectorScrubber) managedWait() bool {
+	for {
+		ss.mu.Lock()
+		rate := ss.rate
+		ss.mu.Unlock()
+
+		// Wait for the rate to be set if the scrubber is disabled.
+		var wait <-chan time.Time
+		if rate > 0 {
+			wait = time.After(time.Duration(float64(modules.SectorSize) / float64(rate) * float64(time.Second)))
+		}
+		select {
+		case <-ss.cm.tg.StopChan():
+			return false
+		case <-ss.rateChanged:
+			continue
+		case <-wait:
+			return true
+		}
+	}
+}
+
+// threadedScrub continuously scrubs all of the sectors of the contract
+// manager.
+func (ss *sectorScrubber) threadedScrub() {
+	for {
+		// Start a new cycle.
+		ss.managedPrune()
+		ss.cm.sectorMu.Lock()
+		ids := make([]sectorID, 0, len(ss.cm.sectorLocations))
+		for id := range ss.cm.sectorLocations {
+			ids = append(ids, id)
+		}
+		ss.cm.sectorMu.Unlock()
+		ss.mu.Lock()
+		ss.cycleStart = time.Now()
+		ss.sectorsScrubbed = 0
+		ss.sectorsTotal = uint64(len(ids))
+		ss.mu.Unlock()
+
+		for _, id := range ids {
+			if !ss.managedWait() {
+				return
+			}
+			err := ss.cm.tg.Add()
+			if err != nil {
+				return
+			}
+			corrupted := ss.managedScrubSector(id)
+			ss.cm.tg.Done()
+
+			// Update the set of corrupted sectors. A sector that was
+			// corrupted before might be readable again, e.g. after a disk
+			// was remounted.
+			ss.mu.Lock()
+			ss.sectorsScrubbed++
+			_, known := ss.corrupted[id]
+			changed := corrupted != known
+			if corrupted {
+				ss.corrupted[id] = struct{}{}
+			} else {
+				delete(ss.corrupted, id)
+			}
+			if changed {
+				err = ss.saveSync()
+			}
+			ss.mu.Unlock()
+			if err != nil {
+				ss.cm.log.Println("ERROR: unable to save the sector scrubber:", err)
+			}
+			if changed {
+				ss.managedUpdateAlert()
+			}
+		}
+
+		// Finish the cycle.
+		ss.mu.Lock()
+		ss.lastCycleEnd = time.Now()
+		err := ss.saveSync()
+		ss.mu.Unlock()
+		if err != nil {
+			ss.cm.log.Println("ERROR: unable to save the sector scrubber:", err)
+		}
+
+		// Don't spin if there are no sectors.
+		if len(ids) == 0 {
+			select {
+			case <-ss.cm.tg.StopChan():
+				return
+			case <-time.After(scrubIdleInterval):
+			}
+		}
+	}
+}
+
+// CorruptedSectors returns the roots of the provided sectors which failed the
+// integrity check of the sector scrubber.
+func (cm *ContractManager) CorruptedSectors(sectorRoots []crypto.Hash) []crypto.Hash {
+	cm.scrubber.mu.Lock()
+	defer cm.scrubber.mu.Unlock()
+	if len(cm.scrubber.corrupted) == 0 {
+		return nil
+	}
+	var corrupted []crypto.Hash
+	for _, root := range sectorRoots {
+		if _, exists := cm.scrubber.corrupted[cm.managedSectorID(root)]; exists {
+			corrupted = append(corrupted, root)
+		}
+	}
+	return corrupted
+}
+
+// ScrubStatus returns the status of the sector scrubber.
+func (cm *ContractManager) ScrubStatus() modules.StorageScrubStatus {
+	cm.scrubber.mu.Lock()
+	defer cm.scrubber.mu.Unlock()
+	return modules.StorageScrubStatus{
+		Rate:             cm.scrubber.rate,
+		CycleStart:       cm.scrubber.cycleStart,
+		LastCycleEnd:     cm.scrubber.lastCycleEnd,
+		SectorsScrubbed:  cm.scrubber.sectorsScrubbed,
+		SectorsTotal:     cm.scrubber.sectorsTotal,
+		CorruptedSectors: uint64(len(cm.scrubber.corrupted)),
+	}
+}
+
+// SetScrubRate sets the number of bytes per second that the sector scrubber
+// reads. A rate of 0 disables the scrubber.
+func (cm *ContractManager) SetScrubRate(rate uint64) {
+	cm.scrubber.mu.Lock()
+	cm.scrubber.rate = rate
+	cm.scrubber.mu.Unlock()
+	select {
+	case cm.scrubber.rateChanged <- struct{}{}:
+	default:
+	}
+}
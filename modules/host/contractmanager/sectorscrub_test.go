@@ -0,0 +1,130 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorScrubber corrupts a sector on disk and checks that the scrubber
+// detects it, alerts about it and remembers it across restarts.
+func TestSectorScrubber(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder with a few sectors.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := make([]crypto.Hash, 5)
+	for i := range roots {
+		var data []byte
+		roots[i], data = randSector()
+		err = cmt.cm.AddSector(roots[i], data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The scrubber is disabled by default.
+	if status := cmt.cm.ScrubStatus(); status.Rate != 0 || status.CorruptedSectors != 0 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Overwrite the first sector with garbage.
+	cmt.cm.sectorMu.Lock()
+	sl := cmt.cm.sectorLocations[cmt.cm.managedSectorID(roots[0])]
+	cmt.cm.sectorMu.Unlock()
+	f, err := os.OpenFile(filepath.Join(storageFolderDir, sectorFile), os.O_RDWR, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt(fastrand.Bytes(int(modules.SectorSize)), int64(uint64(sl.index)*modules.SectorSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Enable the scrubber and wait for it to find the corrupted sector.
+	cmt.cm.SetScrubRate(100 * modules.SectorSize)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status := cmt.cm.ScrubStatus()
+		if status.LastCycleEnd.IsZero() {
+			return errors.New("scrubber hasn't finished a cycle")
+		}
+		if status.CorruptedSectors != 1 {
+			return errors.New("corrupted sector wasn't found")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := cmt.cm.CorruptedSectors(roots)
+	if len(corrupted) != 1 || corrupted[0] != roots[0] {
+		t.Fatal("unexpected corrupted sectors", corrupted)
+	}
+	crit, _, _, _ := cmt.cm.Alerts()
+	if len(crit) != 1 || crit[0].Msg != AlertMSGHostCorruptedSectors {
+		t.Fatal("expected corrupted sectors alert", crit)
+	}
+
+	// The corrupted sector should be remembered after a restart.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted = cmt.cm.CorruptedSectors(roots)
+	if len(corrupted) != 1 || corrupted[0] != roots[0] {
+		t.Fatal("corrupted sectors weren't persisted", corrupted)
+	}
+	crit, _, _, _ = cmt.cm.Alerts()
+	if len(crit) != 1 {
+		t.Fatal("expected corrupted sectors alert after restart", crit)
+	}
+
+	// Removing the corrupted sector should clear the alert.
+	err = cmt.cm.RemoveSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.SetScrubRate(100 * modules.SectorSize)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if n := cmt.cm.ScrubStatus().CorruptedSectors; n != 0 {
+			return errors.New("corrupted sector wasn't pruned")
+		}
+		if crit, _, _, _ := cmt.cm.Alerts(); len(crit) != 0 {
+			return errors.New("alert wasn't unregistered")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,370 @@
+package contractmanager
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errUnknownStorageTier is returned if a storage folder is assigned to a
+	// tier that doesn't exist.
+	errUnknownStorageTier = errors.New("unknown storage tier")
+)
+
+type (
+	// sectorTiering moves sectors between the storage folders of the fast and
+	// the regular tier in the background. Sectors which are read often or
+	// which the host marked as hot are promoted to the fast tier and sectors
+	// which haven't been read for a while are demoted to the regular tier.
+	sectorTiering struct {
+		// reads counts the reads of each sector. The counts are halved after
+		// every tiering cycle so that old reads matter less than new ones.
+		reads map[sectorID]uint64
+
+		// hot contains the sectors which the host expects to be read often.
+		hot map[sectorID]struct{}
+
+		// Statistics about the tiering since startup.
+		lastCycle time.Time
+		promoted  uint64
+		demoted   uint64
+
+		cm  *ContractManager
+		wal *writeAheadLog
+		mu  sync.Mutex
+	}
+
+	// storageFolderTier is a change of the tier of a storage folder.
+	storageFolderTier struct {
+		Index uint16
+		Tier  modules.StorageTier
+	}
+)
+
+// newSectorTiering initializes the sector tiering and starts moving sectors
+// between the tiers in the background.
+func newSectorTiering(cm *ContractManager) *sectorTiering {
+	st := &sectorTiering{
+		reads: make(map[sectorID]uint64),
+		hot:   make(map[sectorID]struct{}),
+		cm:    cm,
+		wal:   &cm.wal,
+	}
+	go st.threadedTierSectors()
+	return st
+}
+
+// savedStorageTier returns the tier of a storage folder that was loaded from
+// disk. Storage folders which were saved before tiers were introduced belong
+// to the regular tier.
+func savedStorageTier(tier modules.StorageTier) modules.StorageTier {
+	if tier == "" {
+		return modules.StorageTierHDD
+	}
+	return tier
+}
+
+// commitStorageFolderTier will apply a change of the tier of a storage folder
+// to the contract manager.
+func (wal *writeAheadLog) commitStorageFolderTier(sft storageFolderTier) {
+	wal.cm.sectorMu.Lock()
+	defer wal.cm.sectorMu.Unlock()
+	sf, exists := wal.cm.storageFolders[sft.Index]
+	if !exists {
+		wal.cm.log.Println("ERROR: unable to change the tier of a storage folder that doesn't exist:", sft.Index)
+		return
+	}
+	sf.tier = sft.Tier
+}
+
+// managedAddRead increments the read count of a sector.
+func (st *sectorTiering) managedAddRead(id sectorID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.reads[id]++
+}
+
+// managedDecay halves the read counts of all sectors and forgets the sectors
+// which haven't been read for a while.
+func (st *sectorTiering) managedDecay() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for id, reads := range st.reads {
+		if reads <= 1 {
+			delete(st.reads, id)
+			continue
+		}
+		st.reads[id] = reads / 2
+	}
+}
+
+// managedMoveSectors moves the provided sectors into the provided storage
+// folders one at a time. The number of moved sectors is returned. Moving
+// stops early if the storage folders are full or the contract manager is
+// shutting down.
+func (st *sectorTiering) managedMoveSectors(ids []sectorID, destinations []*storageFolder) uint64 {
+	var moved uint64
+	for _, id := range ids {
+		select {
+		case <-st.cm.tg.StopChan():
+			return moved
+		default:
+		}
+		err := st.wal.managedMoveSectorToFolders(id, destinations)
+		if err != nil && err.Error() == modules.V1420HostOutOfStorageErrString {
+			return moved
+		} else if errors.Contains(err, errDiskTrouble) {
+			st.cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
+		}
+		if err != nil {
+			st.cm.log.Println("Unable to move sector between storage tiers:", err)
+			continue
+		}
+		moved++
+	}
+	return moved
+}
+
+// managedPromotionCandidates returns the hot sectors which are stored in the
+// regular tier. The sector locations are looked up in batches.
+func (st *sectorTiering) managedPromotionCandidates(hot map[sectorID]struct{}) []sectorID {
+	ids := make([]sectorID, 0, len(hot))
+	for id := range hot {
+		ids = append(ids, id)
+	}
+	var promote []sectorID
+	for len(ids) > 0 {
+		n := sectorTieringBatchSize
+		if n > len(ids) {
+			n = len(ids)
+		}
+		st.cm.sectorMu.Lock()
+		for _, id := range ids[:n] {
+			sl, exists := st.cm.sectorLocations[id]
+			if !exists {
+				continue
+			}
+			if sf, exists := st.cm.storageFolders[sl.storageFolder]; exists && sf.tier != modules.StorageTierSSD {
+				promote = append(promote, id)
+			}
+		}
+		st.cm.sectorMu.Unlock()
+		ids = ids[n:]
+	}
+	return promote
+}
+
+// managedDemotionCandidates returns up to sectorTieringMaxMoves cold sectors
+// of the fast tier. Sectors which have been read recently aren't cold yet,
+// even if they aren't hot. Only the sectors of the fast storage folders are
+// considered. They are found through the metadata of the folders and their
+// locations are verified in batches.
+func (st *sectorTiering) managedDemotionCandidates(fast []*storageFolder, hot map[sectorID]struct{}, reads map[sectorID]uint64) []sectorID {
+	var demote []sectorID
+	for _, sf := range fast {
+		if len(demote) >= sectorTieringMaxMoves {
+			break
+		}
+		st.cm.sectorMu.Lock()
+		numSectors := len(sf.usage) * storageFolderGranularity
+		sectorIndices := usageSectors(sf.usage)
+		st.cm.sectorMu.Unlock()
+
+		sf.mu.RLock()
+		sectorLookupBytes, err := readFullMetadata(sf.metadataFile, numSectors)
+		sf.mu.RUnlock()
+		if err != nil {
+			atomic.AddUint64(&sf.atomicFailedReads, 1)
+			st.cm.log.Printf("Unable to read the metadata of storage folder %v for tiering: %v", sf.index, err)
+			continue
+		}
+		atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+		// Skip the sectors which are hot or were read recently before looking
+		// up the remaining ones.
+		ids := make([]sectorID, 0, len(sectorIndices))
+		for _, sectorIndex := range sectorIndices {
+			readHead := sectorMetadataDiskSize * sectorIndex
+			var id sectorID
+			copy(id[:], sectorLookupBytes[readHead:readHead+12])
+			if _, isHot := hot[id]; isHot || reads[id] > 0 {
+				continue
+			}
+			ids = append(ids, id)
+		}
+		for len(ids) > 0 && len(demote) < sectorTieringMaxMoves {
+			n := sectorTieringBatchSize
+			if n > len(ids) {
+				n = len(ids)
+			}
+			st.cm.sectorMu.Lock()
+			for _, id := range ids[:n] {
+				if len(demote) >= sectorTieringMaxMoves {
+					break
+				}
+				// The metadata might be outdated. Only sectors which are still
+				// stored in the folder are demoted.
+				if sl, exists := st.cm.sectorLocations[id]; exists && sl.storageFolder == sf.index {
+					demote = append(demote, id)
+				}
+			}
+			st.cm.sectorMu.Unlock()
+			ids = ids[n:]
+		}
+	}
+	return demote
+}
+
+// managedTierSectors performs a single tiering cycle. First the cold sectors
+// of the fast tier are demoted to make room for the hot sectors of the
+// regular tier, which are promoted afterwards.
+func (st *sectorTiering) managedTierSectors() {
+	defer st.managedDecay()
+
+	// Sort the available storage folders by tier. Nothing needs to be moved
+	// unless both tiers exist.
+	var fast, regular []*storageFolder
+	sfs := st.cm.availableStorageFolders()
+	st.cm.sectorMu.Lock()
+	for _, sf := range sfs {
+		if sf.tier == modules.StorageTierSSD {
+			fast = append(fast, sf)
+		} else {
+			regular = append(regular, sf)
+		}
+	}
+	st.cm.sectorMu.Unlock()
+	if len(fast) == 0 || len(regular) == 0 {
+		return
+	}
+
+	// Grab a snapshot of the hot sectors. A sector is hot if it was read
+	// often or if the host marked it as hot.
+	st.mu.Lock()
+	reads := make(map[sectorID]uint64, len(st.reads))
+	for id, n := range st.reads {
+		reads[id] = n
+	}
+	hot := make(map[sectorID]struct{}, len(st.hot))
+	for id := range st.hot {
+		hot[id] = struct{}{}
+	}
+	st.mu.Unlock()
+	for id, n := range reads {
+		if n >= sectorPromotionReads {
+			hot[id] = struct{}{}
+		}
+	}
+
+	// Find the hot sectors in the regular tier and the cold sectors in the
+	// fast tier.
+	promote := st.managedPromotionCandidates(hot)
+	demote := st.managedDemotionCandidates(fast, hot, reads)
+
+	// Promote the most read sectors first.
+	sort.Slice(promote, func(i, j int) bool {
+		return reads[promote[i]] > reads[promote[j]]
+	})
+	if len(promote) > sectorTieringMaxMoves {
+		promote = promote[:sectorTieringMaxMoves]
+	}
+
+	demoted := st.managedMoveSectors(demote, regular)
+	promoted := st.managedMoveSectors(promote, fast)
+	st.mu.Lock()
+	st.demoted += demoted
+	st.promoted += promoted
+	st.lastCycle = time.Now()
+	st.mu.Unlock()
+}
+
+// threadedTierSectors periodically moves sectors between the storage tiers.
+func (st *sectorTiering) threadedTierSectors() {
+	for {
+		select {
+		case <-st.cm.tg.StopChan():
+			return
+		case <-time.After(sectorTieringInterval):
+		}
+		func() {
+			if err := st.cm.tg.Add(); err != nil {
+				return
+			}
+			defer st.cm.tg.Done()
+			st.managedTierSectors()
+		}()
+	}
+}
+
+// SetHotSectors replaces the set of sectors which are kept in the fast tier
+// regardless of how often they are read.
+func (cm *ContractManager) SetHotSectors(sectorRoots []crypto.Hash) {
+	hot := make(map[sectorID]struct{}, len(sectorRoots))
+	for _, root := range sectorRoots {
+		hot[cm.managedSectorID(root)] = struct{}{}
+	}
+	cm.tiering.mu.Lock()
+	cm.tiering.hot = hot
+	cm.tiering.mu.Unlock()
+}
+
+// SetStorageFolderTier sets the tier of a storage folder. The sectors of the
+// storage folder are moved between the tiers in the background.
+func (cm *ContractManager) SetStorageFolderTier(index uint16, tier modules.StorageTier) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	if tier != modules.StorageTierHDD && tier != modules.StorageTierSSD {
+		return errUnknownStorageTier
+	}
+
+	cm.wal.mu.Lock()
+	cm.sectorMu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		cm.sectorMu.Unlock()
+		cm.wal.mu.Unlock()
+		return errStorageFolderNotFound
+	}
+	sf.tier = tier
+	cm.sectorMu.Unlock()
+
+	// Add the change to the WAL and wait until it is synced.
+	cm.wal.appendChange(stateChange{
+		StorageFolderTiers: []storageFolderTier{{
+			Index: index,
+			Tier:  tier,
+		}},
+	})
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+	return nil
+}
+
+// TieringStatus returns the status of the tiering of sectors between the
+// storage folders of the different tiers.
+func (cm *ContractManager) TieringStatus() modules.StorageTieringStatus {
+	cm.tiering.mu.Lock()
+	defer cm.tiering.mu.Unlock()
+	hot := uint64(len(cm.tiering.hot))
+	for id, reads := range cm.tiering.reads {
+		if _, exists := cm.tiering.hot[id]; !exists && reads >= sectorPromotionReads {
+			hot++
+		}
+	}
+	return modules.StorageTieringStatus{
+		HotSectors:      hot,
+		LastCycle:       cm.tiering.lastCycle,
+		PromotedSectors: cm.tiering.promoted,
+		DemotedSectors:  cm.tiering.demoted,
+	}
+}
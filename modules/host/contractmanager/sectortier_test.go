@@ -0,0 +1,140 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorTiering checks that sectors which are read often or marked as hot
+// are promoted to the fast tier and demoted again once they are cold.
+func TestSectorTiering(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a regular and a fast storage folder.
+	hddDir := filepath.Join(cmt.persistDir, "hdd")
+	ssdDir := filepath.Join(cmt.persistDir, "ssd")
+	for _, dir := range []string{hddDir, ssdDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ssdIndex uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Tier != modules.StorageTierHDD {
+			t.Fatal("new storage folder should belong to the hdd tier", sf.Tier)
+		}
+		if sf.Path == ssdDir {
+			ssdIndex = sf.Index
+		}
+	}
+	if err := cmt.cm.SetStorageFolderTier(ssdIndex, "tape"); !errors.Contains(err, errUnknownStorageTier) {
+		t.Fatal("expected unknown tier error", err)
+	}
+	err = cmt.cm.SetStorageFolderTier(ssdIndex, modules.StorageTierSSD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New sectors should be stored in the regular tier.
+	roots := make([]crypto.Hash, 3)
+	for i := range roots {
+		var data []byte
+		roots[i], data = randSector()
+		err = cmt.cm.AddSector(roots[i], data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// inFastTier is a helper that returns whether a sector is stored in the
+	// fast tier.
+	inFastTier := func(root crypto.Hash) bool {
+		cmt.cm.sectorMu.Lock()
+		defer cmt.cm.sectorMu.Unlock()
+		sl := cmt.cm.sectorLocations[cmt.cm.managedSectorID(root)]
+		return sl.storageFolder == ssdIndex
+	}
+	for _, root := range roots {
+		if inFastTier(root) {
+			t.Fatal("new sector was stored in the fast tier")
+		}
+	}
+
+	// Read the first sector a few times and mark the second one as hot. Both
+	// should be promoted.
+	for i := 0; i < 4; i++ {
+		if _, err := cmt.cm.ReadSector(roots[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmt.cm.SetHotSectors([]crypto.Hash{roots[1]})
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !inFastTier(roots[0]) || !inFastTier(roots[1]) {
+			return errors.New("hot sectors weren't promoted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inFastTier(roots[2]) {
+		t.Fatal("cold sector was promoted")
+	}
+	if status := cmt.cm.TieringStatus(); status.PromotedSectors != 2 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// The promoted sectors should still be readable.
+	for _, root := range roots[:2] {
+		if _, err := cmt.cm.ReadSector(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Once the sectors aren't hot anymore they should be demoted.
+	cmt.cm.SetHotSectors(nil)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if inFastTier(roots[0]) || inFastTier(roots[1]) {
+			return errors.New("cold sectors weren't demoted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The tier should be remembered after a restart.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == ssdIndex && sf.Tier != modules.StorageTierSSD {
+			t.Fatal("tier wasn't persisted", sf.Tier)
+		}
+	}
+}
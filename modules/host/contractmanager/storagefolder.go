@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -95,9 +96,11 @@ type storageFolder struct {
 	// an error if it is queried.
 	atomicUnavailable uint64 // uint64 for alignment
 
-	// The index, path, and usage are all saved directly to disk.
+	// The index, path, tier and usage are all saved directly to disk. The
+	// tier is protected by the sectorMu.
 	index uint16
 	path  string
+	tier  modules.StorageTier
 	usage []uint64
 
 	// availableSectors indicates sectors which are marked as consumed in the
@@ -200,13 +203,21 @@ func usageSectors(usage []uint64) (usageSectors []uint32) {
 // vacancyStorageFolder takes a set of storage folders and returns a storage
 // folder with vacancy for a sector along with its index. 'nil' and '-1' are
 // returned if none of the storage folders are available to accept a sector.
-// The returned storage folder will be holding an RLock on its mutex.
+// The returned storage folder will be holding an RLock on its mutex. Storage
+// folders of the fast tier are only returned if none of the other storage
+// folders have vacancy, which keeps the fast tier available for the sectors
+// that are promoted to it. The caller must hold the sectorMu.
 func vacancyStorageFolder(sfs []*storageFolder) (*storageFolder, int) {
 	enoughRoom := false
 	var winningIndex int
 
-	// Go through the folders in random order.
-	for _, index := range fastrand.Perm(len(sfs)) {
+	// Go through the folders in random order, starting with the folders that
+	// aren't part of the fast tier.
+	perm := fastrand.Perm(len(sfs))
+	sort.SliceStable(perm, func(i, j int) bool {
+		return sfs[perm[i]].tier != modules.StorageTierSSD && sfs[perm[j]].tier == modules.StorageTierSSD
+	})
+	for _, index := range perm {
 		sf := sfs[index]
 
 		// Skip past this storage folder if there is not enough room for at
@@ -393,6 +404,9 @@ func (cm *ContractManager) ResizeStorageFolder(index uint16, newSize uint64, for
 	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 		return errStorageFolderNotFound
 	}
+	if cm.managedMigrationInProgress(index) {
+		return errMigrationInProgress
+	}
 
 	if newSize/modules.SectorSize < MinimumSectorsPerStorageFolder {
 		return ErrSmallStorageFolder
@@ -454,6 +468,7 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
 			Index:             sf.index,
 			Path:              sf.path,
+			Tier:              sf.tier,
 		}
 
 		// Set some of the values to extreme numbers if the storage folder is
@@ -329,6 +329,7 @@ func (wal *writeAheadLog) commitAddStorageFolder(ssf savedStorageFolder) {
 	sf = &storageFolder{
 		index: ssf.Index,
 		path:  ssf.Path,
+		tier:  savedStorageTier(ssf.Tier),
 		usage: ssf.Usage,
 
 		availableSectors: make(map[sectorID]uint32),
@@ -385,6 +386,7 @@ func (cm *ContractManager) AddStorageFolder(path string, size uint64) error {
 	// Create a storage folder object and add it to the WAL.
 	newSF := &storageFolder{
 		path:  path,
+		tier:  modules.StorageTierHDD,
 		usage: make([]uint64, sectors/64),
 
 		availableSectors: make(map[sectorID]uint32),
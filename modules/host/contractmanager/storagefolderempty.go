@@ -22,6 +22,13 @@ var (
 // managedMoveSector will move a sector from its current storage folder to
 // another.
 func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+	return wal.managedMoveSectorToFolders(id, nil)
+}
+
+// managedMoveSectorToFolders will move a sector from its current storage
+// folder to one of the provided storage folders. If no storage folders are
+// provided, the sector will be moved to any of the available storage folders.
+func (wal *writeAheadLog) managedMoveSectorToFolders(id sectorID, destinations []*storageFolder) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -52,9 +59,15 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	}
 
 	// Place the sector into its new folder and add the atomic move to the WAL.
-	wal.mu.Lock()
-	storageFolders := wal.cm.availableStorageFolders()
-	wal.mu.Unlock()
+	var storageFolders []*storageFolder
+	if len(destinations) == 0 {
+		wal.mu.Lock()
+		storageFolders = wal.cm.availableStorageFolders()
+		wal.mu.Unlock()
+	} else {
+		// Copy the destinations since the slice is modified below.
+		storageFolders = append(storageFolders, destinations...)
+	}
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
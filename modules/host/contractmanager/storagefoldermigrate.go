@@ -0,0 +1,432 @@
+package contractmanager
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// migrationOpsPerSector is the number of disk operations that are counted
+	// towards the IOPS limit of a migration for every sector that is moved.
+	// Moving a sector requires reading the sector, writing the sector and
+	// writing the sector metadata.
+	migrationOpsPerSector = 3
+)
+
+var (
+	// errMigrationConcurrent is returned if a migration is started while
+	// another migration is still in progress. Only one migration can run at a
+	// time to make sure that the room of the destination which was checked
+	// when the migration was started isn't used up by another migration.
+	errMigrationConcurrent = errors.New("another storage folder migration is already in progress")
+
+	// errMigrationInProgress is returned if a migration is started for a
+	// storage folder that is already part of another migration.
+	errMigrationInProgress = errors.New("storage folder is already part of a migration")
+
+	// errMigrationInsufficientSpace is returned if the destination of a
+	// migration doesn't have enough room for the sectors of the source.
+	errMigrationInsufficientSpace = errors.New("destination storage folder doesn't have enough room for the sectors of the source")
+
+	// errMigrationSameFolder is returned if the source and destination of a
+	// migration are the same storage folder.
+	errMigrationSameFolder = errors.New("cannot migrate a storage folder to itself")
+)
+
+type (
+	// storageFolderMigration is a long running operation that moves all of the
+	// sectors of the source storage folder into the destination storage folder
+	// and removes the source once it is empty. Migrations are persisted in the
+	// settings so that they can be resumed after a restart.
+	storageFolderMigration struct {
+		// atomicFailedSectors is the number of sectors that failed to be
+		// moved during this boot cycle. It's not persisted.
+		//
+		// NOTE: this field must come first in the struct to ensure proper
+		// alignment.
+		atomicFailedSectors uint64
+
+		Source       uint16
+		Destination  uint16
+		MaxBandwidth uint64
+		MaxIOPS      uint64
+		TotalSectors uint64
+	}
+)
+
+// commitStorageFolderMigration will add a migration to the contract manager's
+// set of migrations.
+func (wal *writeAheadLog) commitStorageFolderMigration(sfm storageFolderMigration) {
+	wal.cm.sectorMu.Lock()
+	defer wal.cm.sectorMu.Unlock()
+	wal.cm.migrations[sfm.Source] = &storageFolderMigration{
+		Source:       sfm.Source,
+		Destination:  sfm.Destination,
+		MaxBandwidth: sfm.MaxBandwidth,
+		MaxIOPS:      sfm.MaxIOPS,
+		TotalSectors: sfm.TotalSectors,
+	}
+}
+
+// commitFinishedStorageFolderMigration will remove a migration from the
+// contract manager's set of migrations.
+func (wal *writeAheadLog) commitFinishedStorageFolderMigration(source uint16) {
+	wal.cm.sectorMu.Lock()
+	defer wal.cm.sectorMu.Unlock()
+	delete(wal.cm.migrations, source)
+}
+
+// savedMigrations returns the persistent version of the contract manager's
+// migrations sorted by their source. The caller must hold the sectorMu.
+func (cm *ContractManager) savedMigrations() []storageFolderMigration {
+	var sfms []storageFolderMigration
+	for _, m := range cm.migrations {
+		sfms = append(sfms, storageFolderMigration{
+			Source:       m.Source,
+			Destination:  m.Destination,
+			MaxBandwidth: m.MaxBandwidth,
+			MaxIOPS:      m.MaxIOPS,
+			TotalSectors: m.TotalSectors,
+		})
+	}
+	sort.Slice(sfms, func(i, j int) bool {
+		return sfms[i].Source < sfms[j].Source
+	})
+	return sfms
+}
+
+// managedFinishMigration will remove a migration from the WAL and the
+// contract manager's set of migrations. If 'removal' is not nil, the source
+// storage folder of the migration is removed as well. The call blocks until
+// the change has been synced.
+func (cm *ContractManager) managedFinishMigration(m *storageFolderMigration, removal *storageFolderRemoval) {
+	cm.wal.mu.Lock()
+	sc := stateChange{
+		FinishedStorageFolderMigrations: []uint16{m.Source},
+	}
+	if removal != nil {
+		sc.StorageFolderRemovals = []storageFolderRemoval{*removal}
+	}
+	cm.wal.appendChange(sc)
+	cm.sectorMu.Lock()
+	delete(cm.migrations, m.Source)
+	cm.sectorMu.Unlock()
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+}
+
+// managedMigrationSleep will block until the throttle of the migration allows
+// for the next sector to be moved. False is returned if the contract manager
+// is shutting down.
+func (cm *ContractManager) managedMigrationSleep(m *storageFolderMigration, start time.Time, moved uint64) bool {
+	var target time.Duration
+	if m.MaxBandwidth > 0 {
+		bandwidthTarget := time.Duration(float64(moved*modules.SectorSize) / float64(m.MaxBandwidth) * float64(time.Second))
+		if bandwidthTarget > target {
+			target = bandwidthTarget
+		}
+	}
+	if m.MaxIOPS > 0 {
+		iopsTarget := time.Duration(float64(moved*migrationOpsPerSector) / float64(m.MaxIOPS) * float64(time.Second))
+		if iopsTarget > target {
+			target = iopsTarget
+		}
+	}
+	elapsed := time.Since(start)
+	if target <= elapsed {
+		select {
+		case <-cm.tg.StopChan():
+			return false
+		default:
+			return true
+		}
+	}
+	select {
+	case <-cm.tg.StopChan():
+		return false
+	case <-time.After(target - elapsed):
+		return true
+	}
+}
+
+// threadedMigrateStorageFolder will move all of the sectors of the migration's
+// source storage folder into the destination storage folder. Once all sectors
+// have been moved, the source storage folder is removed. If the contract
+// manager shuts down during the migration, the migration is resumed upon the
+// next startup.
+func (cm *ContractManager) threadedMigrateStorageFolder(m *storageFolderMigration) {
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	// Grab the storage folders.
+	cm.sectorMu.Lock()
+	source, exists1 := cm.storageFolders[m.Source]
+	destination, exists2 := cm.storageFolders[m.Destination]
+	cm.sectorMu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&source.atomicUnavailable) == 1 || atomic.LoadUint64(&destination.atomicUnavailable) == 1 {
+		cm.log.Printf("ERROR: unable to migrate storage folder %v to %v: %v\n", m.Source, m.Destination, errStorageFolderNotFound)
+		cm.staticAlerter.RegisterAlert(migrationAlertID(m.Source),
+			fmt.Sprintf("Migration of storage folder %v to %v failed: %v", m.Source, m.Destination, errStorageFolderNotFound),
+			"folder op", modules.SeverityError)
+		cm.managedFinishMigration(m, nil)
+		return
+	}
+
+	// Lock the source for the duration of the migration. This prevents new
+	// sectors from being added to the source and other storage folder
+	// operations from interfering with the migration.
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	defer atomic.StoreUint64(&source.atomicProgressNumerator, 0)
+	defer atomic.StoreUint64(&source.atomicProgressDenominator, 0)
+
+	// Register an alert that tracks the progress of the migration.
+	alertID := modules.AlertID(fmt.Sprintf("cm-migrate-folder-%v", m.Source))
+	defer cm.staticAlerter.UnregisterAlert(alertID)
+	updateProgress := func() {
+		cm.sectorMu.Lock()
+		remaining := source.sectors
+		cm.sectorMu.Unlock()
+		migrated := uint64(0)
+		if remaining < m.TotalSectors {
+			migrated = m.TotalSectors - remaining
+		}
+		atomic.StoreUint64(&source.atomicProgressNumerator, migrated*modules.SectorSize)
+		atomic.StoreUint64(&source.atomicProgressDenominator, m.TotalSectors*modules.SectorSize)
+		cm.staticAlerter.RegisterAlert(alertID,
+			fmt.Sprintf("Migrating %d sectors from %s to %s: %d migrated, %d errored",
+				m.TotalSectors,
+				source.path,
+				destination.path,
+				migrated,
+				atomic.LoadUint64(&m.atomicFailedSectors)),
+			"folder op", modules.SeverityInfo)
+	}
+	updateProgress()
+
+	// Read the sector lookup bytes into memory to figure out which sectors
+	// are stored in the source.
+	sectorLookupBytes, err := readFullMetadata(source.metadataFile, len(source.usage)*storageFolderGranularity)
+	if err != nil {
+		atomic.AddUint64(&source.atomicFailedReads, 1)
+		cm.managedFailMigration(m, build.ExtendErr("unable to read sector metadata", err))
+		return
+	}
+	atomic.AddUint64(&source.atomicSuccessfulReads, 1)
+	cm.sectorMu.Lock()
+	sectorIndices := usageSectors(source.usage)
+	cm.sectorMu.Unlock()
+
+	// Move the sectors one at a time to be able to throttle the migration.
+	start := time.Now()
+	var moved uint64
+	for _, sectorIndex := range sectorIndices {
+		readHead := sectorMetadataDiskSize * sectorIndex
+		var id sectorID
+		copy(id[:], sectorLookupBytes[readHead:readHead+12])
+
+		// Reference the sector locations map to get the most up-to-date
+		// status for the sector.
+		cm.sectorMu.Lock()
+		sl, exists := cm.sectorLocations[id]
+		cm.sectorMu.Unlock()
+		if !exists || sl.storageFolder != source.index {
+			// The sector has been deleted, but the usage has not been updated
+			// yet. Safe to ignore.
+			continue
+		}
+
+		// Wait for the throttle.
+		if !cm.managedMigrationSleep(m, start, moved) {
+			// The contract manager is shutting down, the migration will be
+			// resumed upon the next startup.
+			return
+		}
+
+		err := cm.wal.managedMoveSectorToFolders(id, []*storageFolder{destination})
+		if err != nil && err.Error() == modules.V1420HostOutOfStorageErrString {
+			cm.managedFailMigration(m, errMigrationInsufficientSpace)
+			return
+		} else if errors.Contains(err, errDiskTrouble) {
+			cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
+		}
+		if err != nil {
+			atomic.AddUint64(&m.atomicFailedSectors, 1)
+			cm.log.Println("Unable to migrate sector:", err)
+		} else {
+			moved++
+		}
+		updateProgress()
+	}
+
+	// Don't remove the source if not every sector was migrated.
+	if atomic.LoadUint64(&m.atomicFailedSectors) > 0 {
+		cm.managedFailMigration(m, ErrPartialRelocation)
+		return
+	}
+
+	// Wait for a synchronize to confirm that all of the moves have succeeded
+	// in full before removing the source.
+	cm.wal.mu.Lock()
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+	cm.managedFinishMigration(m, &storageFolderRemoval{
+		Index: source.index,
+		Path:  source.path,
+	})
+}
+
+// managedFailMigration will log and register an alert for a failed migration
+// before removing it. The source storage folder is kept.
+func (cm *ContractManager) managedFailMigration(m *storageFolderMigration, err error) {
+	cm.log.Printf("ERROR: unable to migrate storage folder %v to %v: %v\n", m.Source, m.Destination, err)
+	cm.staticAlerter.RegisterAlert(migrationAlertID(m.Source),
+		fmt.Sprintf("Migration of storage folder %v to %v failed: %v", m.Source, m.Destination, err),
+		"folder op", modules.SeverityError)
+	cm.managedFinishMigration(m, nil)
+}
+
+// managedMigrationInProgress returns true if the storage folder with the
+// provided index is the source or destination of a migration.
+func (cm *ContractManager) managedMigrationInProgress(index uint16) bool {
+	cm.sectorMu.Lock()
+	defer cm.sectorMu.Unlock()
+	for _, m := range cm.migrations {
+		if m.Source == index || m.Destination == index {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationAlertID returns the id of the alert that is registered when the
+// migration of the storage folder with the provided index fails.
+func migrationAlertID(source uint16) modules.AlertID {
+	return modules.AlertID(fmt.Sprintf("cm-migrate-folder-failed-%v", source))
+}
+
+// resumeStorageFolderMigrations will resume all of the migrations that were
+// interrupted by a shutdown.
+func (cm *ContractManager) resumeStorageFolderMigrations() {
+	cm.sectorMu.Lock()
+	defer cm.sectorMu.Unlock()
+	for _, m := range cm.migrations {
+		go cm.threadedMigrateStorageFolder(m)
+	}
+}
+
+// MigrateStorageFolder will move all of the sectors of the source storage
+// folder into the destination storage folder and remove the source afterwards.
+// The migration is performed in the background and is resumed after a
+// restart. 'maxBandwidth' limits the number of bytes moved per second and
+// 'maxIOPS' limits the number of disk operations per second. A limit of 0
+// means no limit. Only one migration can be in progress at a time.
+func (cm *ContractManager) MigrateStorageFolder(source, destination uint16, maxBandwidth, maxIOPS uint64) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	if source == destination {
+		return errMigrationSameFolder
+	}
+
+	// Check that the storage folders exist and that no other migration is in
+	// progress.
+	cm.wal.mu.Lock()
+	cm.sectorMu.Lock()
+	sf, exists1 := cm.storageFolders[source]
+	df, exists2 := cm.storageFolders[destination]
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 || atomic.LoadUint64(&df.atomicUnavailable) == 1 {
+		cm.sectorMu.Unlock()
+		cm.wal.mu.Unlock()
+		return errStorageFolderNotFound
+	}
+	for _, m := range cm.migrations {
+		if m.Source == source || m.Destination == source || m.Source == destination || m.Destination == destination {
+			cm.sectorMu.Unlock()
+			cm.wal.mu.Unlock()
+			return errMigrationInProgress
+		}
+	}
+	if len(cm.migrations) > 0 {
+		cm.sectorMu.Unlock()
+		cm.wal.mu.Unlock()
+		return errMigrationConcurrent
+	}
+	if uint64(len(df.usage))*storageFolderGranularity-df.sectors < sf.sectors {
+		cm.sectorMu.Unlock()
+		cm.wal.mu.Unlock()
+		return errMigrationInsufficientSpace
+	}
+	m := &storageFolderMigration{
+		Source:       source,
+		Destination:  destination,
+		MaxBandwidth: maxBandwidth,
+		MaxIOPS:      maxIOPS,
+		TotalSectors: sf.sectors,
+	}
+	cm.migrations[source] = m
+	cm.sectorMu.Unlock()
+
+	// Add the migration to the WAL and wait until it is synced.
+	cm.wal.appendChange(stateChange{
+		StorageFolderMigrations: []storageFolderMigration{{
+			Source:       m.Source,
+			Destination:  m.Destination,
+			MaxBandwidth: m.MaxBandwidth,
+			MaxIOPS:      m.MaxIOPS,
+			TotalSectors: m.TotalSectors,
+		}},
+	})
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+
+	cm.staticAlerter.UnregisterAlert(migrationAlertID(source))
+	go cm.threadedMigrateStorageFolder(m)
+	return nil
+}
+
+// StorageFolderMigrations returns the migrations that are currently in
+// progress.
+func (cm *ContractManager) StorageFolderMigrations() []modules.StorageFolderMigration {
+	err := cm.tg.Add()
+	if err != nil {
+		return nil
+	}
+	defer cm.tg.Done()
+	cm.sectorMu.Lock()
+	defer cm.sectorMu.Unlock()
+
+	var sfms []modules.StorageFolderMigration
+	for _, m := range cm.migrations {
+		sfm := modules.StorageFolderMigration{
+			Source:        m.Source,
+			Destination:   m.Destination,
+			MaxBandwidth:  m.MaxBandwidth,
+			MaxIOPS:       m.MaxIOPS,
+			SectorsTotal:  m.TotalSectors,
+			SectorsFailed: atomic.LoadUint64(&m.atomicFailedSectors),
+		}
+		if sf, exists := cm.storageFolders[m.Source]; exists && sf.sectors < m.TotalSectors {
+			sfm.SectorsMigrated = m.TotalSectors - sf.sectors
+		}
+		sfms = append(sfms, sfm)
+	}
+	sort.Slice(sfms, func(i, j int) bool {
+		return sfms[i].Source < sfms[j].Source
+	})
+	return sfms
+}
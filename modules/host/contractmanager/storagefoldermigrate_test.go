@@ -0,0 +1,289 @@
+package contractmanager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestMigrateStorageFolder migrates a storage folder into another storage
+// folder, restarting the contract manager in the middle of the migration.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add the source storage folder and fill it with sectors.
+	sourceDir := filepath.Join(cmt.persistDir, "source")
+	destinationDir := filepath.Join(cmt.persistDir, "destination")
+	for _, dir := range []string{sourceDir, destinationDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(sourceDir, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numSectors := 20
+	roots := make([]crypto.Hash, numSectors)
+	datas := make([][]byte, numSectors)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+		err = cmt.cm.AddSector(roots[i], datas[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Add the destination after the sectors to make sure all sectors are in
+	// the source.
+	err = cmt.cm.AddStorageFolder(destinationDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source, destination uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == sourceDir {
+			source = sf.Index
+		} else {
+			destination = sf.Index
+		}
+	}
+
+	// Check the invalid migrations.
+	if err := cmt.cm.MigrateStorageFolder(source, source, 0, 0); !errors.Contains(err, errMigrationSameFolder) {
+		t.Fatal("expected errMigrationSameFolder", err)
+	}
+	if err := cmt.cm.MigrateStorageFolder(source, source+destination+1, 0, 0); !errors.Contains(err, errStorageFolderNotFound) {
+		t.Fatal("expected errStorageFolderNotFound", err)
+	}
+
+	// Start a throttled migration which moves 10 sectors per second.
+	err = cmt.cm.MigrateStorageFolder(source, destination, 0, 10*migrationOpsPerSector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations := cmt.cm.StorageFolderMigrations()
+	if len(migrations) != 1 || migrations[0].Source != source || migrations[0].Destination != destination || migrations[0].SectorsTotal != uint64(numSectors) {
+		t.Fatal("unexpected migrations", migrations)
+	}
+
+	// The storage folders of the migration can't be part of another migration
+	// or be removed.
+	if err := cmt.cm.MigrateStorageFolder(destination, source, 0, 0); !errors.Contains(err, errMigrationInProgress) {
+		t.Fatal("expected errMigrationInProgress", err)
+	}
+	if err := cmt.cm.RemoveStorageFolder(destination, false); !errors.Contains(err, errMigrationInProgress) {
+		t.Fatal("expected errMigrationInProgress", err)
+	}
+
+	// Restart the contract manager in the middle of the migration.
+	time.Sleep(500 * time.Millisecond)
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations = cmt.cm.StorageFolderMigrations()
+	if len(migrations) != 1 || migrations[0].MaxIOPS != 10*migrationOpsPerSector {
+		t.Fatal("migration wasn't resumed", migrations)
+	}
+	if migrations[0].SectorsMigrated == 0 || migrations[0].SectorsMigrated == uint64(numSectors) {
+		t.Fatal("unexpected progress", migrations[0].SectorsMigrated)
+	}
+
+	// Wait for the migration to finish. The source should be removed.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if len(cmt.cm.StorageFolderMigrations()) != 0 {
+			return errors.New("migration is still in progress")
+		}
+		sfs := cmt.cm.StorageFolders()
+		if len(sfs) != 1 || sfs[0].Index != destination {
+			return fmt.Errorf("unexpected storage folders %v", sfs)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(sourceDir, sectorFile))
+	if !os.IsNotExist(err) {
+		t.Fatal("sector file should have been removed")
+	}
+	sf := cmt.cm.StorageFolders()[0]
+	if sf.CapacityRemaining != sf.Capacity-uint64(numSectors)*modules.SectorSize {
+		t.Fatal("unexpected remaining capacity", sf.CapacityRemaining)
+	}
+
+	// All of the sectors should still be available after another restart.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmt.cm.StorageFolders()) != 1 || len(cmt.cm.StorageFolderMigrations()) != 0 {
+		t.Fatal("unexpected state after restart")
+	}
+	for i, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector data mismatch")
+		}
+	}
+}
+
+// TestMigrateStorageFolderInsufficientSpace checks that a migration is
+// rejected if the destination doesn't have enough room.
+func TestMigrateStorageFolderInsufficientSpace(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Fill a storage folder completely and then add a second folder.
+	sourceDir := filepath.Join(cmt.persistDir, "source")
+	destinationDir := filepath.Join(cmt.persistDir, "destination")
+	for _, dir := range []string{sourceDir, destinationDir} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(sourceDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < storageFolderGranularity; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(destinationDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add one more sector which has to go to the destination.
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source, destination uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == sourceDir {
+			source = sf.Index
+		} else {
+			destination = sf.Index
+		}
+	}
+	err = cmt.cm.MigrateStorageFolder(source, destination, 0, 0)
+	if !errors.Contains(err, errMigrationInsufficientSpace) {
+		t.Fatal("expected errMigrationInsufficientSpace", err)
+	}
+	if len(cmt.cm.StorageFolderMigrations()) != 0 {
+		t.Fatal("migration shouldn't have been added")
+	}
+}
+
+// TestMigrateStorageFolderConcurrent checks that a migration can't be started
+// while another migration is in progress.
+func TestMigrateStorageFolderConcurrent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add two storage folders with sectors and two destinations.
+	var dirs []string
+	for _, name := range []string{"source1", "source2", "destination1", "destination2"} {
+		dir := filepath.Join(cmt.persistDir, name)
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs[:2] {
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range dirs[2:] {
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity*2)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	indices := make(map[string]uint16)
+	for _, sf := range cmt.cm.StorageFolders() {
+		indices[sf.Path] = sf.Index
+	}
+	source1, source2, destination, destination2 := indices[dirs[0]], indices[dirs[1]], indices[dirs[2]], indices[dirs[3]]
+
+	// Start a slow migration of the first source.
+	err = cmt.cm.MigrateStorageFolder(source1, destination, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither a migration involving the folders of the migration nor a
+	// migration between other folders can be started.
+	if err := cmt.cm.MigrateStorageFolder(source2, destination, 0, 0); !errors.Contains(err, errMigrationInProgress) {
+		t.Fatal("expected errMigrationInProgress", err)
+	}
+	if err := cmt.cm.MigrateStorageFolder(source2, source1, 0, 0); !errors.Contains(err, errMigrationInProgress) {
+		t.Fatal("expected errMigrationInProgress", err)
+	}
+	if err := cmt.cm.MigrateStorageFolder(source2, destination2, 0, 0); !errors.Contains(err, errMigrationConcurrent) {
+		t.Fatal("expected errMigrationConcurrent", err)
+	}
+	if len(cmt.cm.StorageFolderMigrations()) != 1 {
+		t.Fatal("expected a single migration", cmt.cm.StorageFolderMigrations())
+	}
+}
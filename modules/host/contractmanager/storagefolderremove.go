@@ -69,6 +69,9 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 		return errStorageFolderNotFound
 	}
 	cm.sectorMu.Unlock()
+	if cm.managedMigrationInProgress(index) {
+		return errMigrationInProgress
+	}
 
 	// Lock the storage folder for the duration of the operation.
 	sf.mu.Lock()
@@ -57,6 +57,16 @@ type (
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
+		// Storage folder migrations are long running operations which are
+		// persisted in the settings so that they can be resumed after a
+		// restart. A migration is removed again by adding its source to the
+		// FinishedStorageFolderMigrations.
+		FinishedStorageFolderMigrations []uint16
+		StorageFolderMigrations         []storageFolderMigration
+
+		// Changes to the tier of storage folders.
+		StorageFolderTiers []storageFolderTier
+
 		// Updates to the sector metadata. Careful ordering of events ensures
 		// that a sector update will not make it into the synced WAL unless the
 		// sector data is already on-disk and synced.
@@ -192,6 +202,21 @@ func (wal *writeAheadLog) commitChange(sc stateChange) {
 			wal.commitUpdateSector(su)
 		}
 	}
+	for _, sfm := range sc.StorageFolderMigrations {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitStorageFolderMigration(sfm)
+		}
+	}
+	for _, source := range sc.FinishedStorageFolderMigrations {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitFinishedStorageFolderMigration(source)
+		}
+	}
+	for _, sft := range sc.StorageFolderTiers {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitStorageFolderTier(sft)
+		}
+	}
 }
 
 // createWALTmp will open up the temporary WAL file.
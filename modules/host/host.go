@@ -69,7 +69,6 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -164,6 +163,8 @@ type Host struct {
 	staticMDM                   *mdm.MDM
 	staticRegistry              *registry.Registry
 	staticRegistrySubscriptions *registrySubscriptions
+	staticRenterUsage           *renterUsage
+	staticBandwidthAccounting   *bandwidthAccounting
 
 	// Host ACID fields - these fields need to be updated in serial, ACID
 	// transactions.
@@ -183,6 +184,11 @@ type Host struct {
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// obligationRisks are the risks of the storage obligations which still
+	// require a storage proof, as of the last time they were checked by
+	// threadedCheckObligationRisks.
+	obligationRisks map[types.FileContractID]obligationRisk
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
@@ -196,6 +202,10 @@ type Host struct {
 	// of such conditions are congestion, load, liquidity, etc.
 	staticPriceTables *hostPrices
 
+	// The pricing engine keeps track of the multipliers which are applied to
+	// the host's prices if dynamic pricing is enabled.
+	staticPricing *pricingEngine
+
 	// Fields related to RHP3 bandwidhth.
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
@@ -458,6 +468,9 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 			},
 		},
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
+		staticRenterUsage:           newRenterUsage(),
+		staticBandwidthAccounting:   newBandwidthAccounting(),
+		staticPricing:               newPricingEngine(),
 		persistDir:                  persistDir,
 	}
 
@@ -518,14 +531,27 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		if err != nil {
 			h.log.Println("Could not save host upon shutdown:", err)
 		}
+		err = h.managedSaveBandwidthUsage()
+		if err != nil {
+			h.log.Println("Could not save bandwidth usage upon shutdown:", err)
+		}
 	})
 
+	// Start the sector scrubber.
+	h.StorageManager.SetScrubRate(h.managedInternalSettings().ScrubRate)
+
 	// Load the registry.
 	err = h.managedInitRegistry()
 	if err != nil {
 		return nil, err
 	}
 
+	// Forget about the renters of registry entries which no longer exist.
+	err = h.managedPruneRenterRegistryEntries()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to prune renters of registry entries")
+	}
+
 	// Add the account manager subsystem
 	h.staticAccountManager, err = h.newAccountManager()
 	if err != nil {
@@ -563,6 +589,16 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Periodically update the dynamic prices
+	go h.threadedUpdatePricing()
+
+	// Periodically check whether storage obligations are at risk
+	go h.threadedCheckObligationRisks()
+
+	// Periodically save the bandwidth usage
+	go h.threadedSaveBandwidthUsage()
+	go h.threadedUpdateHotSectors()
+
 	return h, nil
 }
 
@@ -607,18 +643,7 @@ func (h *Host) BandwidthCounters() (uint64, uint64, time.Time, error) {
 	}
 	defer h.tg.Done()
 
-	// Get the bandwidth usage for RHP1 & RHP2 connections.
-	readBytes, writeBytes := h.staticMonitor.Counts()
-
-	// Get the bandwidth usage for RHP3 connections. Unfortunately we can't just
-	// wrap the siamux streams since that wouldn't give us the raw data sent over
-	// the TCP connection. Since we want this to be as accurate as possible, we
-	// use the `Limit` method on the streams before closing them to get the
-	// accurate amount of data sent and received. This includes overhead such as
-	// frame headers and encryption.
-	readBytes += atomic.LoadUint64(&h.atomicStreamDownload)
-	writeBytes += atomic.LoadUint64(&h.atomicStreamUpload)
-
+	readBytes, writeBytes := h.bandwidthCounts()
 	startTime := h.staticMonitor.StartTime()
 	return writeBytes, readBytes, startTime, nil
 }
@@ -632,11 +657,26 @@ func (h *Host) PriceTable() modules.RPCPriceTable {
 
 // WorkingStatus returns the working state of the host, where working is
 // defined as having received more than workingStatusThreshold settings calls
-// over the period of workingStatusFrequency.
+// over the period of workingStatusFrequency. A host in maintenance mode is
+// either draining or drained, depending on whether it still has to submit
+// storage proofs.
 func (h *Host) WorkingStatus() modules.HostWorkingStatus {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-	return h.workingStatus
+	workingStatus := h.workingStatus
+	maintenanceMode := h.settings.MaintenanceMode
+	h.mu.RUnlock()
+	if !maintenanceMode {
+		return workingStatus
+	}
+	status, err := h.MaintenanceStatus()
+	if err != nil {
+		h.log.Println("WARN: unable to get the maintenance status:", err)
+		return modules.HostWorkingStatusDraining
+	}
+	if status.SafeToShutDown {
+		return modules.HostWorkingStatusDrained
+	}
+	return modules.HostWorkingStatusDraining
 }
 
 // ConnectabilityStatus returns the connectability state of the host, whether
@@ -701,6 +741,14 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		h.announced = false
 	}
 
+	err = staticValidatePricingPolicy(settings.PricingPolicy)
+	if err != nil {
+		return errors.New("internal settings not updated, invalid pricing policy: " + err.Error())
+	}
+	if settings.MaintenanceWindowEnd != 0 && settings.MaintenanceWindowEnd < settings.MaintenanceWindowStart {
+		return errors.New("internal settings not updated, the maintenance window ends before it starts")
+	}
+
 	// Translate the size of the registry in bytes to the number of entries. Adjust
 	// the input in case it's not a multiple of 64 times the size of a persisted
 	// entry.
@@ -725,6 +773,10 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if h.settings.ScrubRate != settings.ScrubRate {
+		h.StorageManager.SetScrubRate(settings.ScrubRate)
+	}
+
 	h.settings = settings
 	h.revisionNumber++
 
@@ -0,0 +1,87 @@
+package host
+
+import (
+	"time"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// hotSectorRoots returns the roots of up to max sectors of the provided
+// storage obligations which the host expects to be read often. These are the
+// sectors of short obligations, followed by the sectors of obligations of
+// registry-heavy renters. The renters are identified by the string
+// representation of their public key.
+func hotSectorRoots(sos []storageObligation, heavyRenters map[string]struct{}, max int) []crypto.Hash {
+	var short, heavy []storageObligation
+	for _, so := range sos {
+		if so.ObligationStatus != obligationUnresolved {
+			continue
+		}
+		if so.expiration() <= so.NegotiationHeight+hotContractDuration {
+			short = append(short, so)
+		} else if renter, ok := so.renterPublicKey(); ok {
+			if _, isHeavy := heavyRenters[renter.String()]; isHeavy {
+				heavy = append(heavy, so)
+			}
+		}
+	}
+
+	var roots []crypto.Hash
+	seen := make(map[crypto.Hash]struct{})
+	for _, so := range append(short, heavy...) {
+		for _, root := range so.SectorRoots {
+			if len(roots) >= max {
+				return roots
+			}
+			if _, exists := seen[root]; exists {
+				continue
+			}
+			seen[root] = struct{}{}
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// managedUpdateHotSectors tells the storage manager which sectors should be
+// kept in the fast storage tier. At most maxHotSectors sectors are marked as
+// hot.
+func (h *Host) managedUpdateHotSectors() {
+	sos, err := h.managedStorageObligations()
+	if err != nil {
+		h.log.Println("ERROR: unable to update the hot sectors:", err)
+		return
+	}
+	heavyRenters := make(map[string]struct{})
+	for _, usage := range h.staticRenterUsage.managedUsage() {
+		if usage.RegistryEntries >= hotRenterRegistryEntries {
+			heavyRenters[usage.PublicKey.String()] = struct{}{}
+		}
+	}
+	h.StorageManager.SetHotSectors(hotSectorRoots(sos, heavyRenters, maxHotSectors))
+}
+
+// threadedUpdateHotSectors periodically updates the sectors which should be
+// kept in the fast storage tier.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedUpdateHotSectors() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedUpdateHotSectors()
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(hotSectorsUpdateFrequency):
+			continue
+		}
+	}
+}
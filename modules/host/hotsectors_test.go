@@ -0,0 +1,58 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestHotSectorRoots is a unit test for hotSectorRoots.
+func TestHotSectorRoots(t *testing.T) {
+	t.Parallel()
+
+	_, pk1 := crypto.GenerateKeyPair()
+	_, pk2 := crypto.GenerateKeyPair()
+	renter1 := types.Ed25519PublicKey(pk1)
+	renter2 := types.Ed25519PublicKey(pk2)
+
+	// newObligation is a helper to create an obligation of a renter with a
+	// single sector and the given duration.
+	newObligation := func(spk types.SiaPublicKey, duration types.BlockHeight) storageObligation {
+		so := newRenterUsageTestObligation(spk, 1)
+		so.SectorRoots[0] = crypto.Hash{byte(duration)}
+		so.NegotiationHeight = 10
+		so.RevisionTransactionSet[0].FileContractRevisions[0].NewWindowStart = so.NegotiationHeight + duration
+		so.ObligationStatus = obligationUnresolved
+		return so
+	}
+	short := newObligation(renter1, hotContractDuration)
+	long := newObligation(renter1, hotContractDuration+1)
+	heavy := newObligation(renter2, hotContractDuration+2)
+	resolved := newObligation(renter1, 1)
+	resolved.ObligationStatus = obligationSucceeded
+
+	// Only the short obligation is hot.
+	roots := hotSectorRoots([]storageObligation{short, long, heavy, resolved}, nil, maxHotSectors)
+	if len(roots) != 1 || roots[0] != short.SectorRoots[0] {
+		t.Fatal("unexpected hot sectors", roots)
+	}
+
+	// The obligations of registry-heavy renters are hot as well.
+	heavyRenters := map[string]struct{}{renter2.String(): {}}
+	roots = hotSectorRoots([]storageObligation{heavy, short, long, resolved}, heavyRenters, maxHotSectors)
+	if len(roots) != 2 || roots[0] != short.SectorRoots[0] || roots[1] != heavy.SectorRoots[0] {
+		t.Fatal("unexpected hot sectors", roots)
+	}
+
+	// The number of hot sectors is limited. The sectors of short obligations
+	// are preferred and duplicate roots are only counted once.
+	roots = hotSectorRoots([]storageObligation{heavy, short, short, long, resolved}, heavyRenters, 1)
+	if len(roots) != 1 || roots[0] != short.SectorRoots[0] {
+		t.Fatal("unexpected hot sectors", roots)
+	}
+	roots = hotSectorRoots([]storageObligation{heavy, short, short, long, resolved}, heavyRenters, 2)
+	if len(roots) != 2 || roots[1] != heavy.SectorRoots[0] {
+		t.Fatal("unexpected hot sectors", roots)
+	}
+}
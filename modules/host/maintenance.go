@@ -0,0 +1,76 @@
+package host
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// acceptingContracts returns whether a host with the provided settings
+// accepts new contracts and renewals. A host in maintenance mode doesn't.
+func acceptingContracts(settings modules.HostInternalSettings) bool {
+	return settings.AcceptingContracts && !settings.MaintenanceMode
+}
+
+// verifyMaintenanceWindow checks that a proof window between windowStart and
+// windowEnd doesn't overlap the maintenance window of the provided settings.
+func verifyMaintenanceWindow(settings modules.HostInternalSettings, windowStart, windowEnd types.BlockHeight) error {
+	if settings.MaintenanceWindowEnd == 0 {
+		return nil
+	}
+	if windowStart <= settings.MaintenanceWindowEnd && windowEnd >= settings.MaintenanceWindowStart {
+		return ErrMaintenanceWindow
+	}
+	return nil
+}
+
+// maintenanceStatus computes the maintenance status of a host with the
+// provided settings and storage obligations.
+func maintenanceStatus(settings modules.HostInternalSettings, sos []storageObligation) modules.HostMaintenanceStatus {
+	status := modules.HostMaintenanceStatus{
+		MaintenanceMode:    settings.MaintenanceMode,
+		WindowStart:        settings.MaintenanceWindowStart,
+		WindowEnd:          settings.MaintenanceWindowEnd,
+		PendingObligations: make([]modules.HostPendingObligation, 0),
+	}
+	for _, so := range sos {
+		if !so.pendingProof() {
+			continue
+		}
+		status.PendingObligations = append(status.PendingObligations, modules.HostPendingObligation{
+			ObligationID:     so.id(),
+			ProofWindowStart: so.expiration(),
+			ProofWindowEnd:   so.proofDeadline(),
+			ProofConstructed: so.ProofConstructed,
+		})
+		if so.proofDeadline() > status.DrainHeight {
+			status.DrainHeight = so.proofDeadline()
+		}
+	}
+	sort.Slice(status.PendingObligations, func(i, j int) bool {
+		return status.PendingObligations[i].ProofWindowStart < status.PendingObligations[j].ProofWindowStart
+	})
+	status.SafeToShutDown = status.MaintenanceMode && len(status.PendingObligations) == 0
+	return status
+}
+
+// MaintenanceStatus returns whether the host is in maintenance mode and the
+// storage obligations which still need to be proven before the host can be
+// shut down.
+func (h *Host) MaintenanceStatus() (modules.HostMaintenanceStatus, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostMaintenanceStatus{}, err
+	}
+	defer h.tg.Done()
+
+	sos, err := h.managedStorageObligations()
+	if err != nil {
+		return modules.HostMaintenanceStatus{}, err
+	}
+	h.mu.RLock()
+	settings := h.settings
+	h.mu.RUnlock()
+	return maintenanceStatus(settings, sos), nil
+}
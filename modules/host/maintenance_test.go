@@ -0,0 +1,142 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestVerifyMaintenanceWindow is a unit test for verifyMaintenanceWindow.
+func TestVerifyMaintenanceWindow(t *testing.T) {
+	t.Parallel()
+
+	// Without a maintenance window every proof window is fine.
+	var settings modules.HostInternalSettings
+	if err := verifyMaintenanceWindow(settings, 0, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	settings.MaintenanceWindowStart = 100
+	settings.MaintenanceWindowEnd = 200
+	tests := []struct {
+		windowStart types.BlockHeight
+		windowEnd   types.BlockHeight
+		err         error
+	}{
+		{50, 99, nil},
+		{201, 250, nil},
+		{50, 100, ErrMaintenanceWindow},
+		{150, 160, ErrMaintenanceWindow},
+		{200, 250, ErrMaintenanceWindow},
+		{50, 250, ErrMaintenanceWindow},
+	}
+	for _, test := range tests {
+		if err := verifyMaintenanceWindow(settings, test.windowStart, test.windowEnd); err != test.err {
+			t.Fatalf("window [%v, %v]: expected %v but got %v", test.windowStart, test.windowEnd, test.err, err)
+		}
+	}
+}
+
+// TestMaintenanceMode checks that a host in maintenance mode stops accepting
+// contracts and reports the obligations it still has to prove.
+func TestMaintenanceMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a revised storage obligation which requires a proof.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			UnlockConditions:      types.UnlockConditions{},
+			NewRevisionNumber:     1,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host isn't in maintenance mode yet.
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws := ht.host.WorkingStatus(); ws == modules.HostWorkingStatusDraining || ws == modules.HostWorkingStatusDrained {
+		t.Fatal("unexpected working status", ws)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should accept contracts")
+	}
+
+	// A maintenance window can't end before it starts.
+	settings.MaintenanceWindowStart = 10
+	settings.MaintenanceWindowEnd = 5
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Fatal("expected an error for an invalid maintenance window")
+	}
+
+	// Enable maintenance mode.
+	settings.MaintenanceMode = true
+	settings.MaintenanceWindowEnd = 20
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host in maintenance mode shouldn't accept contracts")
+	}
+	if !ht.host.InternalSettings().AcceptingContracts {
+		t.Fatal("maintenance mode shouldn't change the internal settings")
+	}
+	if ws := ht.host.WorkingStatus(); ws != modules.HostWorkingStatusDraining {
+		t.Fatal("expected the host to be draining", ws)
+	}
+	status, err := ht.host.MaintenanceStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.MaintenanceMode || status.WindowStart != 10 || status.WindowEnd != 20 || status.SafeToShutDown {
+		t.Fatal("unexpected maintenance status", status)
+	}
+	if len(status.PendingObligations) != 1 || status.PendingObligations[0].ObligationID != so.id() {
+		t.Fatal("unexpected pending obligations", status.PendingObligations)
+	}
+	pending := status.PendingObligations[0]
+	if pending.ProofWindowStart != so.expiration() || pending.ProofWindowEnd != so.proofDeadline() || status.DrainHeight != so.proofDeadline() {
+		t.Fatal("unexpected proof window", pending, status.DrainHeight)
+	}
+
+	// Once the proof is confirmed the host is drained.
+	so.ProofConfirmed = true
+	status = maintenanceStatus(ht.host.InternalSettings(), []storageObligation{so})
+	if len(status.PendingObligations) != 0 || !status.SafeToShutDown {
+		t.Fatal("host should be safe to shut down", status)
+	}
+}
@@ -115,6 +115,12 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 // ExecuteProgram initializes a new program from a set of instructions and a
 // reader which can be used to fetch the program's data and executes it.
 func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p modules.Program, budget *modules.RPCBudget, collateralBudget types.Currency, sos StorageObligationSnapshot, duration types.BlockHeight, programDataLen uint64, data io.Reader) (_ FnFinalize, _ <-chan Output, err error) {
+	return mdm.ExecuteProgramWithHost(ctx, mdm.host, pt, p, budget, collateralBudget, sos, duration, programDataLen, data)
+}
+
+// ExecuteProgramWithHost is the same as ExecuteProgram but the program
+// interacts with the provided host instead of the host of the MDM.
+func (mdm *MDM) ExecuteProgramWithHost(ctx context.Context, host Host, pt *modules.RPCPriceTable, p modules.Program, budget *modules.RPCBudget, collateralBudget types.Currency, sos StorageObligationSnapshot, duration types.BlockHeight, programDataLen uint64, data io.Reader) (_ FnFinalize, _ <-chan Output, err error) {
 	// Sanity check program length.
 	if len(p) == 0 {
 		return nil, nil, ErrEmptyProgram
@@ -131,7 +137,7 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 		outputChan: make(chan Output),
 		staticProgramState: &programState{
 			staticRemainingDuration: duration,
-			host:                    mdm.host,
+			host:                    host,
 			priceTable:              pt,
 			sectors:                 newSectors(sos.SectorRoots()),
 			staticRevisionTxn:       sos.RevisionTxn(),
@@ -234,6 +240,8 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 			return ErrInterrupted
 		default:
 		}
+		// Remember how much program data was read before the instruction.
+		dataRead := p.staticData.managedBytesRead()
 		// Increment collateral first.
 		collateral := i.Collateral()
 		err := p.addCollateral(collateral)
@@ -280,6 +288,7 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 			ExecutionCost:        p.executionCost,
 			AdditionalCollateral: p.additionalCollateral,
 			FailureRefund:        p.failureRefund,
+			ProgramDataRead:      p.staticData.managedBytesRead() - dataRead,
 		}
 		// Abort if the last output contained an error.
 		if output.Error != nil {
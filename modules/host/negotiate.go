@@ -124,6 +124,10 @@ var (
 	// funds to the void output.
 	ErrLowVoidOutput = ErrorCommunication("rejected for low value void output")
 
+	// ErrMaintenanceWindow is returned if the renter proposes a file contract
+	// with a proof window which overlaps the host's maintenance window.
+	ErrMaintenanceWindow = ErrorCommunication("rejected because the proof window overlaps the host's maintenance window")
+
 	// ErrMismatchedHostPayouts is returned if the renter incorrectly sets the
 	// host valid and missed payouts to different values during contract
 	// formation.
@@ -269,6 +269,10 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 	if fc.WindowStart > blockHeight+eSettings.MaxDuration {
 		return ErrLongDuration
 	}
+	// The proof window must not overlap the host's maintenance window.
+	if err := verifyMaintenanceWindow(iSettings, fc.WindowStart, fc.WindowEnd); err != nil {
+		return err
+	}
 
 	// ValidProofOutputs should have 2 outputs (renter + host) and missed
 	// outputs should have 3 (renter + host + void)
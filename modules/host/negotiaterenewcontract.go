@@ -149,6 +149,10 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 	if fc.WindowStart > blockHeight+externalSettings.MaxDuration {
 		return types.Currency{}, ErrLongDuration
 	}
+	// The proof window must not overlap the host's maintenance window.
+	if err := verifyMaintenanceWindow(internalSettings, fc.WindowStart, fc.WindowEnd); err != nil {
+		return types.Currency{}, err
+	}
 
 	// ValidProofOutputs shoud have 2 outputs (renter + host) and missed
 	// outputs should have 3 (renter + host + void)
@@ -43,8 +43,9 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		contractPrice = h.settings.MinContractPrice
 	}
 
-	// If the host's wallet is locked report that it is not accepting contracts.
-	acceptingContracts := h.settings.AcceptingContracts
+	// If the host's wallet is locked or the host is in maintenance mode report
+	// that it is not accepting contracts.
+	acceptingContracts := acceptingContracts(h.settings)
 	if unlocked, err := h.wallet.Unlocked(); err != nil || !unlocked {
 		acceptingContracts = false
 	}
@@ -64,6 +65,9 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
 
+	// Use the dynamic prices if enabled.
+	prices := h.prices()
+
 	// Extract the port from the SiaMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
 	if err != nil {
@@ -87,10 +91,10 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 
 		BaseRPCPrice:           h.settings.MinBaseRPCPrice,
 		ContractPrice:          contractPrice,
-		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		DownloadBandwidthPrice: prices.DownloadBandwidthPrice,
 		SectorAccessPrice:      h.settings.MinSectorAccessPrice,
-		StoragePrice:           h.settings.MinStoragePrice,
-		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		StoragePrice:           prices.StoragePrice,
+		UploadBandwidthPrice:   prices.UploadBandwidthPrice,
 
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
@@ -315,6 +315,13 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		}
 	}
 
+	// Monitor the bandwidth of the old RPCs. The RPCs of the new protocol are
+	// monitored by the RPC loop.
+	monitor := connmonitor.NewMonitor()
+	if id != modules.RPCLoopEnter {
+		conn = connmonitor.NewMonitoredConn(conn, monitor)
+	}
+
 	switch id {
 	// new RPCs: enter an infinite request/response loop
 	case modules.RPCLoopEnter:
@@ -338,6 +345,11 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		h.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RemoteAddr(), id)
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
 	}
+	switch id {
+	case modules.RPCDownload, modules.RPCFormContract, modules.RPCReviseContract, modules.RPCSettings:
+		read, write := monitor.Counts()
+		h.staticBandwidthAccounting.managedAddRPC(id, read, write)
+	}
 	if err != nil {
 		atomic.AddUint64(&h.atomicErroredCalls, 1)
 		err = extendErr("error with "+conn.RemoteAddr().String()+": ", err)
@@ -349,6 +361,8 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 func (h *Host) threadedHandleStream(stream siamux.Stream) {
 	// close the stream when the method terminates
 	var cleanup afterCloseFn
+	var rpcID types.Specifier
+	var rpcRecognized bool
 	defer func() {
 		if h.dependencies.Disrupt("DisableStreamClose") {
 			return
@@ -362,6 +376,9 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		l := stream.Limit()
 		atomic.AddUint64(&h.atomicStreamUpload, l.Uploaded())
 		atomic.AddUint64(&h.atomicStreamDownload, l.Downloaded())
+		if rpcRecognized {
+			h.staticBandwidthAccounting.managedAddRPC(rpcID, l.Downloaded(), l.Uploaded())
+		}
 
 		// Call rpc specific cleanup if necessary.
 		if cleanup != nil {
@@ -395,7 +412,6 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 	}
 
 	// read the RPC id
-	var rpcID types.Specifier
 	err = modules.RPCRead(stream, &rpcID)
 	if err != nil {
 		err = errors.AddContext(err, "Failed to read RPC id")
@@ -406,6 +422,7 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		return
 	}
 
+	rpcRecognized = true
 	switch rpcID {
 	case modules.RPCAccountBalance:
 		err = h.managedRPCAccountBalance(stream)
@@ -425,6 +442,7 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		h.log.Debugf("WARN: incoming stream %v requested unknown RPC \"%v\"", stream.RemoteAddr().String(), rpcID)
 		err = errors.New(fmt.Sprintf("Unrecognized RPC id %v", rpcID))
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
+		rpcRecognized = false
 	}
 
 	if err != nil {
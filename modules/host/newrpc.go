@@ -185,6 +185,14 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 		return err
 	}
 
+	// Check that the renter didn't use up its bandwidth quota.
+	if renter, ok := s.so.renterPublicKey(); ok {
+		if err := h.managedCheckRenterBandwidth(renter); err != nil {
+			err = errors.Compose(err, s.writeError(err))
+			return err
+		}
+	}
+
 	// Read some internal fields for later.
 	_, maxFee := h.tpool.FeeEstimation()
 	h.mu.Lock()
@@ -440,6 +448,15 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 		return err
 	}
 
+	// Check that the renter didn't use up its bandwidth quota.
+	if renter, ok := s.so.renterPublicKey(); ok {
+		if err := h.managedCheckRenterBandwidth(renter); err != nil {
+			err = errors.Compose(err, s.writeError(err))
+			<-stopSignal
+			return err
+		}
+	}
+
 	// Read some internal fields for later.
 	_, maxFee := h.tpool.FeeEstimation()
 	h.mu.Lock()
@@ -0,0 +1,189 @@
+package host
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// estimatedStorageProofFee estimates the fee of the transaction which submits
+// the storage proof of an obligation. The size of the transaction is
+// estimated the same way as when the proof is submitted.
+func estimatedStorageProofFee(so storageObligation, feeRecommendation types.Currency) types.Currency {
+	numLeaves := crypto.CalculateLeaves(so.fileSize())
+	sp := types.StorageProof{
+		HashSet: make([]crypto.Hash, bits.Len64(numLeaves)),
+	}
+	txnSize := uint64(len(encoding.Marshal(sp)) + txnFeeSizeBuffer)
+	return feeRecommendation.Mul64(txnSize)
+}
+
+// pendingProof returns whether the host still has to submit a storage proof
+// for the obligation.
+func (so storageObligation) pendingProof() bool {
+	return so.ObligationStatus == obligationUnresolved && !so.ProofConfirmed && so.requiresProof()
+}
+
+// obligationRisk is the risk of a storage obligation together with the roots
+// of its corrupted sectors.
+type obligationRisk struct {
+	modules.StorageObligationRisk
+	corruptedSectors []crypto.Hash
+	proofDeadline    types.BlockHeight
+}
+
+// managedComputeObligationRisks computes the risk of the provided storage
+// obligations which still require a storage proof. The wallet is assumed to
+// fund the storage proofs in the order of their proof windows.
+func (h *Host) managedComputeObligationRisks(sos []storageObligation) map[types.FileContractID]obligationRisk {
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	h.mu.RUnlock()
+	_, feeRecommendation := h.tpool.FeeEstimation()
+
+	// A locked wallet can't fund any storage proofs.
+	var balance types.Currency
+	unlocked, err := h.wallet.Unlocked()
+	if err != nil {
+		h.log.Println("WARN: unable to check whether the wallet is unlocked:", err)
+	} else if unlocked {
+		balance, _, _, err = h.wallet.ConfirmedBalance()
+		if err != nil {
+			h.log.Println("WARN: unable to get the wallet balance:", err)
+		}
+	}
+
+	// Sort the obligations which require a proof by their proof window.
+	pending := make([]storageObligation, 0, len(sos))
+	for _, so := range sos {
+		if so.pendingProof() {
+			pending = append(pending, so)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].expiration() < pending[j].expiration()
+	})
+
+	risks := make(map[types.FileContractID]obligationRisk, len(pending))
+	var totalFees types.Currency
+	for _, so := range pending {
+		corrupted := h.StorageManager.CorruptedSectors(so.SectorRoots)
+		risk := obligationRisk{
+			StorageObligationRisk: modules.StorageObligationRisk{
+				CorruptedSectors:       uint64(len(corrupted)),
+				EstimatedProofFee:      estimatedStorageProofFee(so, feeRecommendation),
+				ProofWindowApproaching: blockHeight+proofWindowRiskThreshold >= so.expiration(),
+			},
+			corruptedSectors: corrupted,
+			proofDeadline:    so.proofDeadline(),
+		}
+		for _, root := range so.SectorRoots {
+			if !h.StorageManager.HasSector(root) {
+				risk.MissingSectors++
+			}
+		}
+		totalFees = totalFees.Add(risk.EstimatedProofFee)
+		risk.InsufficientFunds = totalFees.Cmp(balance) > 0
+		risk.AtRisk = risk.MissingSectors > 0 || risk.CorruptedSectors > 0 || risk.InsufficientFunds
+		risks[so.id()] = risk
+	}
+	return risks
+}
+
+// managedObligationRisk returns the risk of the storage obligation with the
+// provided id as of the last check. Obligations which haven't been checked yet
+// or don't require a proof aren't at risk.
+func (h *Host) managedObligationRisk(id types.FileContractID) modules.StorageObligationRisk {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.obligationRisks[id].StorageObligationRisk
+}
+
+// managedObligationsAtRisk returns the storage obligations which contain
+// corrupted sectors as of the last check, sorted by their proof deadline.
+func (h *Host) managedObligationsAtRisk() []modules.HostObligationAtRisk {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var oars []modules.HostObligationAtRisk
+	for id, risk := range h.obligationRisks {
+		if len(risk.corruptedSectors) == 0 {
+			continue
+		}
+		oars = append(oars, modules.HostObligationAtRisk{
+			ObligationID:     id,
+			ProofDeadline:    risk.proofDeadline,
+			CorruptedSectors: append([]crypto.Hash(nil), risk.corruptedSectors...),
+		})
+	}
+	sort.Slice(oars, func(i, j int) bool {
+		return oars[i].ProofDeadline < oars[j].ProofDeadline
+	})
+	return oars
+}
+
+// managedUpdateObligationRisks computes the risks of the host's storage
+// obligations and registers an alert if any of them are at risk. The alert is
+// critical if the proof window of any of them is approaching.
+func (h *Host) managedUpdateObligationRisks() {
+	sos, err := h.managedStorageObligations()
+	if err != nil {
+		h.log.Println("ERROR: unable to check the storage obligations at risk:", err)
+		return
+	}
+	risks := h.managedComputeObligationRisks(sos)
+	h.mu.Lock()
+	h.obligationRisks = risks
+	h.mu.Unlock()
+
+	var atRisk, approaching int
+	for _, risk := range risks {
+		if !risk.AtRisk {
+			continue
+		}
+		atRisk++
+		if risk.ProofWindowApproaching {
+			approaching++
+		}
+	}
+	if atRisk == 0 {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostObligationsAtRisk)
+		return
+	}
+	var severity modules.AlertSeverity = modules.SeverityWarning
+	if approaching > 0 {
+		severity = modules.SeverityCritical
+	}
+	cause := fmt.Sprintf("%v storage obligations are at risk of failing their storage proof, the proof window of %v of them opens within %v blocks", atRisk, approaching, proofWindowRiskThreshold)
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostObligationsAtRisk, AlertMSGHostObligationsAtRisk, cause, severity)
+}
+
+// threadedCheckObligationRisks periodically checks whether the host's storage
+// obligations are at risk. The result is cached to be reported by the API.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedCheckObligationRisks() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedUpdateObligationRisks()
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(obligationRiskCheckFrequency):
+			continue
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newTesterRevisedStorageObligation creates a storage obligation with a
+// revision of a single sector which requires a storage proof.
+func (ht *hostTester) newTesterRevisedStorageObligation() (storageObligation, error) {
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		return storageObligation{}, err
+	}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:              so.id(),
+			UnlockConditions:      types.UnlockConditions{},
+			NewRevisionNumber:     1,
+			NewFileSize:           modules.SectorSize,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	return so, nil
+}
+
+// TestObligationRisk checks that the host flags storage obligations with
+// missing sectors and insufficient funds and alerts about them.
+func TestObligationRisk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a revised storage obligation with a sector.
+	so, err := ht.newTesterRevisedStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The obligation isn't at risk but its proof window is approaching.
+	ht.host.managedUpdateObligationRisks()
+	mso, err := ht.host.StorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mso.Risk.AtRisk || mso.Risk.MissingSectors != 0 || mso.Risk.InsufficientFunds || !mso.Risk.ProofWindowApproaching {
+		t.Fatal("unexpected risk", mso.Risk)
+	}
+
+	// Remove the sector from the storage manager.
+	err = ht.host.StorageManager.RemoveSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUpdateObligationRisks()
+	sos := ht.host.StorageObligations()
+	if len(sos) != 1 || !sos[0].Risk.AtRisk || sos[0].Risk.MissingSectors != 1 {
+		t.Fatal("obligation should be at risk", sos)
+	}
+
+	// The host should register a critical alert.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		crit, _, _, _ := ht.host.Alerts()
+		for _, alert := range crit {
+			if alert.Msg == AlertMSGHostObligationsAtRisk {
+				return nil
+			}
+		}
+		return errors.New("alert wasn't registered")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A locked wallet can't fund the storage proof.
+	err = ht.wallet.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUpdateObligationRisks()
+	mso, err = ht.host.StorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mso.Risk.InsufficientFunds {
+		t.Fatal("expected insufficient funds", mso.Risk)
+	}
+}
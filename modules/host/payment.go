@@ -25,7 +25,7 @@ func (h *Host) ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (modul
 
 	// process payment depending on the payment method
 	if pr.Type == modules.PayByEphemeralAccount {
-		return h.staticPayByEphemeralAccount(stream, bh)
+		return h.managedPayByEphemeralAccount(stream, bh)
 	}
 	if pr.Type == modules.PayByContract {
 		return h.managedPayByContract(stream, bh)
@@ -34,15 +34,22 @@ func (h *Host) ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (modul
 	return nil, errors.Compose(fmt.Errorf("Could not handle payment method %v", pr.Type), modules.ErrUnknownPaymentMethod)
 }
 
-// staticPayByEphemeralAccount processes a PayByEphemeralAccountRequest coming
+// managedPayByEphemeralAccount processes a PayByEphemeralAccountRequest coming
 // in over the given stream.
-func (h *Host) staticPayByEphemeralAccount(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, error) {
+func (h *Host) managedPayByEphemeralAccount(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, error) {
 	// read the PayByEphemeralAccountRequest
 	var req modules.PayByEphemeralAccountRequest
 	if err := modules.RPCRead(stream, &req); err != nil {
 		return nil, errors.AddContext(err, "Could not read PayByEphemeralAccountRequest")
 	}
 
+	// check the bandwidth quota of the renter that funded the account
+	if renter, known := h.staticRenterUsage.managedAccountRenter(req.Message.Account); known {
+		if err := h.managedCheckRenterBandwidth(renter); err != nil {
+			return nil, err
+		}
+	}
+
 	// process the request
 	if err := h.staticAccountManager.callWithdraw(&req.Message, req.Signature, req.Priority, bh); err != nil {
 		return nil, errors.AddContext(err, "Withdraw failed")
@@ -95,6 +102,14 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 	}
 	paymentRevision := revisionFromRequest(currentRevision, pbcr)
 
+	// check the bandwidth quota of the renter
+	renter, knownRenter := so.renterPublicKey()
+	if knownRenter {
+		if err := h.managedCheckRenterBandwidth(renter); err != nil {
+			return nil, err
+		}
+	}
+
 	// verify the payment revision
 	amount, err := verifyPayByContractRevision(currentRevision, paymentRevision, bh)
 	if err != nil {
@@ -108,6 +123,14 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 		return nil, errors.AddContext(err, "Could not create revision signature")
 	}
 
+	// attribute the refund account to the renter now that the renter's
+	// signature was verified
+	if knownRenter {
+		if err := h.managedAttributeAccount(accountID, renter); err != nil {
+			return nil, errors.AddContext(err, "Could not attribute refund account")
+		}
+	}
+
 	// extract the payment output & update the storage obligation with the
 	// host's signature
 	so.RevisionTransactionSet = []types.Transaction{{
@@ -185,6 +208,14 @@ func (h *Host) managedFundAccount(stream siamux.Stream, request modules.FundAcco
 		return types.ZeroCurrency, errors.AddContext(err, "Could not create revision signature")
 	}
 
+	// attribute the account to the renter of the contract now that the
+	// renter's signature was verified
+	if renter, ok := so.renterPublicKey(); ok {
+		if err := h.managedAttributeAccount(request.Account, renter); err != nil {
+			return types.ZeroCurrency, errors.AddContext(err, "Could not attribute account")
+		}
+	}
+
 	// copy the transaction signature
 	var sig crypto.Signature
 	if len(txn.HostSignature().Signature) != len(sig) {
@@ -30,6 +30,9 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Renter Usage.
+	RenterBandwidth renterBandwidthPersist `json:"renterbandwidth"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +51,9 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Renter Usage.
+		RenterBandwidth: h.staticRenterUsage.managedBandwidthPersist(),
 	}
 }
 
@@ -75,6 +81,13 @@ func (h *Host) establishDefaults() error {
 		EphemeralAccountExpiry:     modules.DefaultEphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
+
+		PricingPolicy: modules.HostPricingPolicy{
+			MaxPriceMultiplier: defaultPricingMaxPriceMultiplier,
+			Smoothing:          defaultPricingSmoothing,
+		},
+
+		ScrubRate: modules.DefaultScrubRate,
 	}
 
 	// Load the host's key pair, use the same keys as the SiaMux.
@@ -121,6 +134,9 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+
+	// Copy over the renter usage.
+	h.staticRenterUsage.managedLoadBandwidth(p.RenterBandwidth)
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -143,6 +159,9 @@ func (h *Host) initDB() (err error) {
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
 			bucketActionItems,
+			bucketBandwidthUsage,
+			bucketRenterAccounts,
+			bucketRenterRegistryEntries,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
@@ -230,7 +249,8 @@ func (h *Host) load() error {
 	}
 
 	// Get the contract count and locked collateral by observing all of the incomplete
-	// storage obligations in the database.
+	// storage obligations in the database. The usage of the renters is also
+	// restored.
 	// TODO: both contract count and locked collateral are not correctly updated during
 	// contract renewals. This leads to an offset to the real value over time.
 	h.financialMetrics.ContractCount = 0
@@ -246,9 +266,14 @@ func (h *Host) load() error {
 			if so.ObligationStatus == obligationUnresolved {
 				h.financialMetrics.ContractCount++
 				h.financialMetrics.LockedStorageCollateral = h.financialMetrics.LockedStorageCollateral.Add(so.LockedCollateral)
+				h.staticRenterUsage.managedUpdateContract(so)
 			}
 		}
-		return nil
+		err = h.loadRenterUsage(tx)
+		if err != nil {
+			return err
+		}
+		return h.loadBandwidthUsage(tx)
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,369 @@
+package host
+
+// pricing.go contains the host's dynamic pricing. If it is enabled, the host
+// periodically samples its storage utilization, collateral budget usage and
+// bandwidth load and derives a multiplier for each of its storage and
+// bandwidth prices from them. The multipliers are smoothed to avoid sudden
+// price jumps and applied to the base prices which are either the host's
+// minimum prices or the target prices of the pricing policy converted to
+// siacoins.
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errPricingInvalidMultiplier is returned if the pricing policy's max
+	// price multiplier is smaller than 1.
+	errPricingInvalidMultiplier = errors.New("max price multiplier must be 0 or at least 1")
+
+	// errPricingInvalidSmoothing is returned if the pricing policy's smoothing
+	// is not between 0 and 1.
+	errPricingInvalidSmoothing = errors.New("smoothing must be between 0 and 1")
+
+	// errPricingInvalidTarget is returned if one of the pricing policy's
+	// target prices is negative.
+	errPricingInvalidTarget = errors.New("target prices can't be negative")
+
+	// errPricingNoExchangeRate is returned if the pricing policy specifies
+	// target prices without an exchange rate.
+	errPricingNoExchangeRate = errors.New("target prices require an exchange rate")
+)
+
+type (
+	// pricingEngine keeps track of the state of the host's dynamic pricing.
+	pricingEngine struct {
+		// multipliers are the smoothed multipliers which are applied to the
+		// base prices.
+		multipliers pricingMultipliers
+
+		// lastDownload and lastUpload are the host's bandwidth counters at
+		// the time of the last update. They are used to compute the
+		// bandwidth load.
+		lastDownload uint64
+		lastUpload   uint64
+		lastUpdate   time.Time
+
+		mu sync.Mutex
+	}
+
+	// pricingLoad is a sample of the host's load. All values are between 0
+	// and 1.
+	pricingLoad struct {
+		collateral float64
+		download   float64
+		storage    float64
+		upload     float64
+	}
+
+	// pricingMultipliers are the multipliers which are applied to the base
+	// prices.
+	pricingMultipliers struct {
+		download float64
+		storage  float64
+		upload   float64
+	}
+)
+
+// newPricingEngine creates a new pricingEngine.
+func newPricingEngine() *pricingEngine {
+	return &pricingEngine{
+		multipliers: pricingMultipliers{
+			download: 1,
+			storage:  1,
+			upload:   1,
+		},
+	}
+}
+
+// bandwidthLoad returns the load caused by transferring the given number of
+// bytes since the last update.
+func (pe *pricingEngine) bandwidthLoad(bytes, capacity uint64, now time.Time) float64 {
+	if capacity == 0 || pe.lastUpdate.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(pe.lastUpdate).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return clampPricingLoad(float64(bytes) / elapsed / float64(capacity))
+}
+
+// managedMultipliers returns the current multipliers.
+func (pe *pricingEngine) managedMultipliers() pricingMultipliers {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.multipliers
+}
+
+// managedUpdate computes the load and the multipliers from a sample of the
+// host's state. If commit is false, the state of the engine is not changed
+// which allows for dry runs.
+func (pe *pricingEngine) managedUpdate(policy modules.HostPricingPolicy, storage, collateral float64, download, upload uint64, now time.Time, commit bool) (pricingLoad, pricingMultipliers) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	// Compute the load. The counters should only ever increase but we avoid
+	// underflows just in case.
+	var downloadDelta, uploadDelta uint64
+	if download > pe.lastDownload {
+		downloadDelta = download - pe.lastDownload
+	}
+	if upload > pe.lastUpload {
+		uploadDelta = upload - pe.lastUpload
+	}
+	load := pricingLoad{
+		collateral: clampPricingLoad(collateral),
+		download:   pe.bandwidthLoad(downloadDelta, policy.BandwidthCapacity, now),
+		storage:    clampPricingLoad(storage),
+		upload:     pe.bandwidthLoad(uploadDelta, policy.BandwidthCapacity, now),
+	}
+
+	// Compute the new multipliers. The storage price depends on both the
+	// storage and the collateral since the host runs out of either when
+	// storing more data.
+	maxMultiplier, smoothing := pricingPolicyParams(policy)
+	smooth := func(old, load float64) float64 {
+		target := 1 + (maxMultiplier-1)*load
+		return old + smoothing*(target-old)
+	}
+	multipliers := pricingMultipliers{
+		download: smooth(pe.multipliers.download, load.download),
+		storage:  smooth(pe.multipliers.storage, math.Max(load.storage, load.collateral)),
+		upload:   smooth(pe.multipliers.upload, load.upload),
+	}
+
+	if commit {
+		pe.multipliers = multipliers
+		pe.lastDownload = download
+		pe.lastUpload = upload
+		pe.lastUpdate = now
+	}
+	return load, multipliers
+}
+
+// clampPricingLoad clamps a load to the range between 0 and 1.
+func clampPricingLoad(load float64) float64 {
+	if math.IsNaN(load) || load < 0 {
+		return 0
+	}
+	return math.Min(load, 1)
+}
+
+// pricingBasePrices returns the prices the multipliers are applied to.
+func pricingBasePrices(settings modules.HostInternalSettings) (modules.HostPrices, error) {
+	prices := modules.HostPrices{
+		DownloadBandwidthPrice: settings.MinDownloadBandwidthPrice,
+		StoragePrice:           settings.MinStoragePrice,
+		UploadBandwidthPrice:   settings.MinUploadBandwidthPrice,
+	}
+	policy := settings.PricingPolicy
+	rate, err := types.ParseExchangeRate(policy.ExchangeRate)
+	if err != nil {
+		return modules.HostPrices{}, errors.AddContext(err, "invalid exchange rate")
+	}
+	if rate == nil {
+		return prices, nil
+	}
+	if policy.TargetDownloadBandwidthPrice > 0 {
+		prices.DownloadBandwidthPrice = rate.Unapply(policy.TargetDownloadBandwidthPrice).Div(modules.BytesPerTerabyte)
+	}
+	if policy.TargetStoragePrice > 0 {
+		prices.StoragePrice = rate.Unapply(policy.TargetStoragePrice).Div(modules.BlockBytesPerMonthTerabyte)
+	}
+	if policy.TargetUploadBandwidthPrice > 0 {
+		prices.UploadBandwidthPrice = rate.Unapply(policy.TargetUploadBandwidthPrice).Div(modules.BytesPerTerabyte)
+	}
+	return prices, nil
+}
+
+// pricingPolicyParams returns the max price multiplier and smoothing of a
+// policy. Unset values are replaced with their defaults.
+func pricingPolicyParams(policy modules.HostPricingPolicy) (maxMultiplier, smoothing float64) {
+	maxMultiplier, smoothing = policy.MaxPriceMultiplier, policy.Smoothing
+	if maxMultiplier == 0 {
+		maxMultiplier = defaultPricingMaxPriceMultiplier
+	}
+	if smoothing == 0 {
+		smoothing = defaultPricingSmoothing
+	}
+	return math.Max(maxMultiplier, 1), math.Min(smoothing, 1)
+}
+
+// pricingPrices applies the multipliers to the base prices of the settings.
+// The prices never drop below the host's minimum prices.
+func pricingPrices(settings modules.HostInternalSettings, multipliers pricingMultipliers) modules.HostPrices {
+	minPrices := modules.HostPrices{
+		DownloadBandwidthPrice: settings.MinDownloadBandwidthPrice,
+		StoragePrice:           settings.MinStoragePrice,
+		UploadBandwidthPrice:   settings.MinUploadBandwidthPrice,
+	}
+	base, err := pricingBasePrices(settings)
+	if err != nil {
+		return minPrices
+	}
+	apply := func(base, min types.Currency, multiplier float64) types.Currency {
+		price := base.MulFloat(multiplier)
+		if price.Cmp(min) < 0 {
+			return min
+		}
+		return price
+	}
+	return modules.HostPrices{
+		DownloadBandwidthPrice: apply(base.DownloadBandwidthPrice, minPrices.DownloadBandwidthPrice, multipliers.download),
+		StoragePrice:           apply(base.StoragePrice, minPrices.StoragePrice, multipliers.storage),
+		UploadBandwidthPrice:   apply(base.UploadBandwidthPrice, minPrices.UploadBandwidthPrice, multipliers.upload),
+	}
+}
+
+// staticValidatePricingPolicy checks that a pricing policy is valid.
+func staticValidatePricingPolicy(policy modules.HostPricingPolicy) error {
+	rate, err := types.ParseExchangeRate(policy.ExchangeRate)
+	if err != nil {
+		return errors.AddContext(err, "invalid exchange rate")
+	}
+	targets := []float64{policy.TargetDownloadBandwidthPrice, policy.TargetStoragePrice, policy.TargetUploadBandwidthPrice}
+	for _, target := range targets {
+		if math.IsNaN(target) || math.IsInf(target, 0) || target < 0 {
+			return errPricingInvalidTarget
+		}
+		if target > 0 && rate == nil {
+			return errPricingNoExchangeRate
+		}
+	}
+	if math.IsNaN(policy.MaxPriceMultiplier) || math.IsInf(policy.MaxPriceMultiplier, 0) || (policy.MaxPriceMultiplier != 0 && policy.MaxPriceMultiplier < 1) {
+		return errPricingInvalidMultiplier
+	}
+	if math.IsNaN(policy.Smoothing) || policy.Smoothing < 0 || policy.Smoothing > 1 {
+		return errPricingInvalidSmoothing
+	}
+	return nil
+}
+
+// bandwidthCounts returns the number of bytes the host has received and sent.
+func (h *Host) bandwidthCounts() (download, upload uint64) {
+	// Get the bandwidth usage for RHP1 & RHP2 connections.
+	readBytes, writeBytes := h.staticMonitor.Counts()
+
+	// Get the bandwidth usage for RHP3 connections. Unfortunately we can't just
+	// wrap the siamux streams since that wouldn't give us the raw data sent over
+	// the TCP connection. Since we want this to be as accurate as possible, we
+	// use the `Limit` method on the streams before closing them to get the
+	// accurate amount of data sent and received. This includes overhead such as
+	// frame headers and encryption.
+	readBytes += atomic.LoadUint64(&h.atomicStreamDownload)
+	writeBytes += atomic.LoadUint64(&h.atomicStreamUpload)
+	return readBytes, writeBytes
+}
+
+// managedPricingUpdate samples the host's state and updates the pricing
+// engine. The host's bandwidth is from the host's point of view while the
+// prices are from the renter's point of view, which means that the bandwidth
+// uploaded by the host affects the download price.
+func (h *Host) managedPricingUpdate(settings modules.HostInternalSettings, commit bool) (pricingLoad, pricingMultipliers) {
+	h.mu.RLock()
+	total, remaining := h.capacity()
+	locked := h.financialMetrics.LockedStorageCollateral
+	budget := h.settings.CollateralBudget
+	h.mu.RUnlock()
+
+	var storage, collateral float64
+	if total > 0 {
+		storage = float64(total-remaining) / float64(total)
+	}
+	if budget.IsZero() {
+		collateral = 1
+	} else {
+		lockedFloat, _ := locked.Float64()
+		budgetFloat, _ := budget.Float64()
+		collateral = lockedFloat / budgetFloat
+	}
+	hostDownload, hostUpload := h.bandwidthCounts()
+	return h.staticPricing.managedUpdate(settings.PricingPolicy, storage, collateral, hostUpload, hostDownload, time.Now(), commit)
+}
+
+// threadedUpdatePricing periodically updates the host's dynamic prices.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedUpdatePricing() {
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+
+			// The engine is updated even if dynamic pricing is disabled to
+			// have an accurate bandwidth load once it is enabled.
+			settings := h.managedInternalSettings()
+			h.managedPricingUpdate(settings, true)
+			if settings.PricingPolicy.Enabled {
+				h.managedUpdatePriceTable()
+			}
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(pricingUpdateFrequency):
+			continue
+		}
+	}
+}
+
+// prices returns the storage and bandwidth prices the host is currently
+// using.
+func (h *Host) prices() modules.HostPrices {
+	if !h.settings.PricingPolicy.Enabled {
+		return modules.HostPrices{
+			DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+			StoragePrice:           h.settings.MinStoragePrice,
+			UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		}
+	}
+	return pricingPrices(h.settings, h.staticPricing.managedMultipliers())
+}
+
+// Pricing returns the state of the host's dynamic pricing. The dry run prices
+// are computed using the pricing policy of the provided settings.
+func (h *Host) Pricing(settings modules.HostInternalSettings) (modules.HostPricing, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostPricing{}, err
+	}
+	defer h.tg.Done()
+
+	if err := staticValidatePricingPolicy(settings.PricingPolicy); err != nil {
+		return modules.HostPricing{}, errors.AddContext(err, "invalid pricing policy")
+	}
+	load, multipliers := h.managedPricingUpdate(settings, false)
+
+	h.mu.RLock()
+	current := h.prices()
+	enabled := h.settings.PricingPolicy.Enabled
+	h.mu.RUnlock()
+	h.staticPricing.mu.Lock()
+	lastUpdate := h.staticPricing.lastUpdate
+	h.staticPricing.mu.Unlock()
+
+	return modules.HostPricing{
+		Enabled:    enabled,
+		LastUpdate: lastUpdate,
+
+		CollateralBudgetUtilization: load.collateral,
+		DownloadBandwidthLoad:       load.download,
+		StorageUtilization:          load.storage,
+		UploadBandwidthLoad:         load.upload,
+
+		Current: current,
+		DryRun:  pricingPrices(settings, multipliers),
+	}, nil
+}
@@ -0,0 +1,212 @@
+package host
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPricingEngine is a unit test for the pricingEngine.
+func TestPricingEngine(t *testing.T) {
+	t.Parallel()
+
+	policy := modules.HostPricingPolicy{
+		Enabled:            true,
+		BandwidthCapacity:  100,
+		MaxPriceMultiplier: 3,
+		Smoothing:          0.5,
+	}
+	pe := newPricingEngine()
+	start := time.Now()
+
+	// The first update has no bandwidth load since there is no previous
+	// sample. The storage multiplier should move halfway towards 2 since the
+	// host is half full.
+	load, m := pe.managedUpdate(policy, 0.5, 0.1, 1000, 1000, start, true)
+	if load.download != 0 || load.upload != 0 {
+		t.Fatal("unexpected bandwidth load", load)
+	}
+	if m.storage != 1.5 || m.download != 1 || m.upload != 1 {
+		t.Fatal("unexpected multipliers", m)
+	}
+
+	// A dry run shouldn't change the engine.
+	_, m = pe.managedUpdate(policy, 1, 1, 1000, 1000, start.Add(time.Second), false)
+	if m.storage != 2.25 {
+		t.Fatal("unexpected multiplier", m.storage)
+	}
+	if pe.managedMultipliers().storage != 1.5 {
+		t.Fatal("dry run changed the multipliers")
+	}
+
+	// Transfer 50 bytes per second for 10 seconds in one direction and more
+	// than the capacity in the other. The collateral determines the storage
+	// load if it's higher than the storage utilization.
+	load, m = pe.managedUpdate(policy, 0, 1, 1500, 1e6, start.Add(10*time.Second), true)
+	if load.download != 0.5 || load.upload != 1 {
+		t.Fatal("unexpected bandwidth load", load)
+	}
+	if m.download != 1.5 || m.upload != 2 || m.storage != 2.25 {
+		t.Fatal("unexpected multipliers", m)
+	}
+
+	// Repeated updates with a full host should converge towards the max
+	// multiplier without exceeding it.
+	for i := 0; i < 100; i++ {
+		_, m = pe.managedUpdate(policy, 1, 1, 1500, 1e6, start.Add(time.Duration(11+i)*time.Second), true)
+	}
+	if m.storage > 3 || math.Abs(m.storage-3) > 1e-9 {
+		t.Fatal("multiplier didn't converge", m.storage)
+	}
+}
+
+// TestPricingPrices tests computing the prices from the settings and the
+// multipliers.
+func TestPricingPrices(t *testing.T) {
+	t.Parallel()
+
+	settings := modules.HostInternalSettings{
+		MinDownloadBandwidthPrice: types.NewCurrency64(100),
+		MinStoragePrice:           types.NewCurrency64(100),
+		MinUploadBandwidthPrice:   types.NewCurrency64(100),
+	}
+	multipliers := pricingMultipliers{download: 2, storage: 1.5, upload: 1}
+
+	// Without an exchange rate the minimum prices are the base prices.
+	prices := pricingPrices(settings, multipliers)
+	if !prices.DownloadBandwidthPrice.Equals64(200) || !prices.StoragePrice.Equals64(150) || !prices.UploadBandwidthPrice.Equals64(100) {
+		t.Fatal("unexpected prices", prices)
+	}
+
+	// Set target prices. 1 SC is worth 0.5 usd which means a target of 1 usd
+	// per TB is 2 SC per TB.
+	settings.PricingPolicy.ExchangeRate = "0.5 usd"
+	settings.PricingPolicy.TargetDownloadBandwidthPrice = 1
+	settings.PricingPolicy.TargetStoragePrice = 1
+	base, err := pricingBasePrices(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDownload := types.SiacoinPrecision.Mul64(2).Div(modules.BytesPerTerabyte)
+	expectedStorage := types.SiacoinPrecision.Mul64(2).Div(modules.BlockBytesPerMonthTerabyte)
+	if !base.DownloadBandwidthPrice.Equals(expectedDownload) || !base.StoragePrice.Equals(expectedStorage) {
+		t.Fatal("unexpected base prices", base)
+	}
+	if !base.UploadBandwidthPrice.Equals64(100) {
+		t.Fatal("upload price without target should be the min price", base.UploadBandwidthPrice)
+	}
+	prices = pricingPrices(settings, multipliers)
+	if !prices.DownloadBandwidthPrice.Equals(expectedDownload.Mul64(2)) {
+		t.Fatal("unexpected download price", prices.DownloadBandwidthPrice)
+	}
+
+	// The prices never drop below the min prices.
+	settings.MinStoragePrice = expectedStorage.Mul64(10)
+	prices = pricingPrices(settings, multipliers)
+	if !prices.StoragePrice.Equals(settings.MinStoragePrice) {
+		t.Fatal("price dropped below min price", prices.StoragePrice)
+	}
+}
+
+// TestValidatePricingPolicy tests staticValidatePricingPolicy.
+func TestValidatePricingPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy modules.HostPricingPolicy
+		err    error
+	}{
+		{modules.HostPricingPolicy{}, nil},
+		{modules.HostPricingPolicy{ExchangeRate: "0.004 usd", TargetStoragePrice: 1}, nil},
+		{modules.HostPricingPolicy{MaxPriceMultiplier: 1, Smoothing: 1}, nil},
+		{modules.HostPricingPolicy{ExchangeRate: "usd"}, types.ErrUnexpectedFormat},
+		{modules.HostPricingPolicy{TargetStoragePrice: 1}, errPricingNoExchangeRate},
+		{modules.HostPricingPolicy{ExchangeRate: "1 usd", TargetUploadBandwidthPrice: -1}, errPricingInvalidTarget},
+		{modules.HostPricingPolicy{MaxPriceMultiplier: 0.5}, errPricingInvalidMultiplier},
+		{modules.HostPricingPolicy{MaxPriceMultiplier: math.NaN()}, errPricingInvalidMultiplier},
+		{modules.HostPricingPolicy{Smoothing: 1.5}, errPricingInvalidSmoothing},
+		{modules.HostPricingPolicy{Smoothing: -0.5}, errPricingInvalidSmoothing},
+	}
+	for i, test := range tests {
+		err := staticValidatePricingPolicy(test.policy)
+		if test.err == nil && err != nil || test.err != nil && !errors.Contains(err, test.err) {
+			t.Errorf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+}
+
+// TestHostPricing verifies that the host uses its dynamic prices once dynamic
+// pricing is enabled.
+func TestHostPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Set a target storage price.
+	is := ht.host.InternalSettings()
+	is.PricingPolicy.ExchangeRate = "1 usd"
+	is.PricingPolicy.TargetStoragePrice = 1e6
+	expected := types.SiacoinPrecision.Mul64(1e6).Div(modules.BlockBytesPerMonthTerabyte)
+
+	// A dry run should show the target price while the host still uses the
+	// min price.
+	hp, err := ht.host.Pricing(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hp.Enabled || !hp.Current.StoragePrice.Equals(is.MinStoragePrice) {
+		t.Fatal("unexpected current pricing", hp.Enabled, hp.Current)
+	}
+	if hp.DryRun.StoragePrice.Cmp(expected) < 0 {
+		t.Fatal("unexpected dry run storage price", hp.DryRun.StoragePrice, expected)
+	}
+
+	// An invalid policy is rejected.
+	invalid := is
+	invalid.PricingPolicy.Smoothing = 2
+	if _, err := ht.host.Pricing(invalid); !errors.Contains(err, errPricingInvalidSmoothing) {
+		t.Fatal("expected invalid policy", err)
+	}
+	if err := ht.host.SetInternalSettings(invalid); err == nil {
+		t.Fatal("invalid policy should be rejected")
+	}
+
+	// Enable dynamic pricing. The external settings and the price table
+	// should use the dynamic price.
+	is.PricingPolicy.Enabled = true
+	err = ht.host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.ExternalSettings().StoragePrice.Cmp(expected) < 0 {
+		t.Fatal("external settings don't use the dynamic price", ht.host.ExternalSettings().StoragePrice)
+	}
+	if ht.host.PriceTable().WriteStoreCost.Cmp(expected) < 0 {
+		t.Fatal("price table doesn't use the dynamic price", ht.host.PriceTable().WriteStoreCost)
+	}
+
+	// Disable it again.
+	is.PricingPolicy.Enabled = false
+	err = ht.host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().StoragePrice.Equals(is.MinStoragePrice) {
+		t.Fatal("external settings should use the min price", ht.host.ExternalSettings().StoragePrice)
+	}
+}
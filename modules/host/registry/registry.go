@@ -42,6 +42,9 @@ var (
 	// errSamePath is returned if the registry is about to be migrated to its
 	// current path.
 	errSamePath = errors.New("registry can't be migrated to its current path")
+	// ErrEntryNotFound is returned if an entry that doesn't exist is looked up
+	// or deleted.
+	ErrEntryNotFound = errors.New("registry entry not found")
 )
 
 type (
@@ -83,6 +86,20 @@ func (v *value) mapKey() modules.RegistryEntryID {
 	return modules.DeriveRegistryEntryID(v.key, v.tweak)
 }
 
+// hostRegistryEntry returns the metadata of the value. The value's lock needs
+// to be held.
+func (v *value) hostRegistryEntry() modules.HostRegistryEntry {
+	return modules.HostRegistryEntry{
+		EntryID:   crypto.Hash(v.mapKey()),
+		PublicKey: v.key,
+		Tweak:     v.tweak,
+		Expiry:    v.expiry,
+		Revision:  v.revision,
+		Type:      v.entryType,
+		DataSize:  uint64(len(v.data)),
+	}
+}
+
 // update updates a value with a new revision, expiry and data.
 func (v *value) update(rv modules.SignedRegistryValue, newExpiry types.BlockHeight, init bool, hpk types.SiaPublicKey) error {
 	// Check if the entry has been invalidated. This should only ever be the
@@ -139,6 +156,73 @@ func (r *Registry) Get(sid modules.RegistryEntryID) (types.SiaPublicKey, modules
 	return v.key, modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature, v.entryType), true
 }
 
+// Entry returns the entry with the given id including its metadata.
+func (r *Registry) Entry(sid modules.RegistryEntryID) (modules.HostRegistryEntry, error) {
+	r.mu.Lock()
+	v, ok := r.entries[sid]
+	r.mu.Unlock()
+	if !ok {
+		return modules.HostRegistryEntry{}, ErrEntryNotFound
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.invalid {
+		return modules.HostRegistryEntry{}, ErrEntryNotFound
+	}
+	entry := v.hostRegistryEntry()
+	entry.Data = append([]byte{}, v.data...)
+	return entry, nil
+}
+
+// Entries returns the metadata of all the entries in the registry. The data of
+// the entries is omitted.
+func (r *Registry) Entries() []modules.HostRegistryEntry {
+	// Get a slice of values. We only hold the lock during the map access.
+	r.mu.Lock()
+	values := make([]*value, 0, len(r.entries))
+	for _, v := range r.entries {
+		values = append(values, v)
+	}
+	r.mu.Unlock()
+
+	entries := make([]modules.HostRegistryEntry, 0, len(values))
+	for _, v := range values {
+		v.mu.Lock()
+		if !v.invalid {
+			entries = append(entries, v.hostRegistryEntry())
+		}
+		v.mu.Unlock()
+	}
+	return entries
+}
+
+// Delete removes the entry with the given id from the registry.
+func (r *Registry) Delete(sid modules.RegistryEntryID) error {
+	r.mu.Lock()
+	entry, ok := r.entries[sid]
+	r.mu.Unlock()
+	if !ok {
+		return ErrEntryNotFound
+	}
+
+	entry.mu.Lock()
+	if entry.invalid {
+		entry.mu.Unlock()
+		return ErrEntryNotFound // already deleted
+	}
+	// Delete the entry from disk.
+	if err := r.staticSaveEntry(entry, false); err != nil {
+		entry.mu.Unlock()
+		return errors.AddContext(err, "failed to delete entry from disk")
+	}
+	// Invalidate the entry.
+	entry.invalid = true
+	entry.mu.Unlock()
+	// Delete the entry from the registry.
+	r.managedDeleteFromMemory(entry)
+	return nil
+}
+
 // Len returns the length of the registry.
 func (r *Registry) Len() uint64 {
 	r.mu.Lock()
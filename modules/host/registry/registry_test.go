@@ -1431,3 +1431,94 @@ func TestFailedLoadLargeRegistry(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestEntriesAndDelete tests looking up the metadata of registry entries and
+// deleting single entries.
+func TestEntriesAndDelete(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+
+	// Create a new registry.
+	registryPath := filepath.Join(dir, "registry")
+	r, err := New(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add 2 entries.
+	rv1, v1, _ := randomValue(0)
+	_, err = r.Update(rv1, v1.key, v1.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv2, v2, _ := randomValue(0)
+	_, err = r.Update(rv2, v2.key, v2.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid1 := modules.DeriveRegistryEntryID(v1.key, rv1.Tweak)
+	sid2 := modules.DeriveRegistryEntryID(v2.key, rv2.Tweak)
+
+	// Both entries should be listed without their data.
+	entries := r.Entries()
+	if len(entries) != 2 {
+		t.Fatal("wrong number of entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Data != nil {
+			t.Fatal("data shouldn't be set")
+		}
+	}
+
+	// Look up the first entry.
+	entry, err := r.Entry(sid1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.EntryID != crypto.Hash(sid1) || !entry.PublicKey.Equals(v1.key) || entry.Tweak != rv1.Tweak {
+		t.Fatal("wrong key", entry)
+	}
+	if entry.Expiry != v1.expiry || entry.Revision != rv1.Revision || entry.Type != rv1.Type {
+		t.Fatal("wrong metadata", entry)
+	}
+	if !bytes.Equal(entry.Data, rv1.Data) || entry.DataSize != uint64(len(rv1.Data)) {
+		t.Fatal("wrong data", entry)
+	}
+
+	// Delete it.
+	err = r.Delete(sid1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Entry(sid1); !errors.Contains(err, ErrEntryNotFound) {
+		t.Fatal("expected entry to be gone", err)
+	}
+	if err := r.Delete(sid1); !errors.Contains(err, ErrEntryNotFound) {
+		t.Fatal("expected entry to be gone", err)
+	}
+	if r.Len() != 1 {
+		t.Fatal("wrong number of entries", r.Len())
+	}
+
+	// Reload the registry. Only the second entry should remain.
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = New(registryPath, testingDefaultMaxEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c io.Closer) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(r)
+	entries = r.Entries()
+	if len(entries) != 1 || entries[0].EntryID != crypto.Hash(sid2) {
+		t.Fatal("wrong entries after reload", entries)
+	}
+}
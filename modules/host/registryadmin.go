@@ -0,0 +1,137 @@
+package host
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// registryExpiryBuckets are the widths of the buckets used for the expiry
+// distribution of the registry stats. The last bucket contains all the entries
+// that expire after the second to last bucket.
+var registryExpiryBuckets = []types.BlockHeight{
+	types.BlocksPerDay,
+	types.BlocksPerWeek,
+	types.BlocksPerMonth,
+	types.BlocksPerYear,
+}
+
+// RegistryDelete deletes the entry with the given id from the registry.
+func (h *Host) RegistryDelete(sid modules.RegistryEntryID) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	err = h.staticRegistry.Delete(sid)
+	if err != nil {
+		return err
+	}
+	return h.managedUnattributeRegistryEntries([]modules.RegistryEntryID{sid})
+}
+
+// RegistryEntry returns the entry with the given id from the registry.
+func (h *Host) RegistryEntry(sid modules.RegistryEntryID) (modules.HostRegistryEntry, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostRegistryEntry{}, err
+	}
+	defer h.tg.Done()
+	return h.staticRegistry.Entry(sid)
+}
+
+// RegistryStats returns information about the usage of the registry.
+func (h *Host) RegistryStats() (modules.HostRegistryStats, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostRegistryStats{}, err
+	}
+	defer h.tg.Done()
+	return staticRegistryStats(h.staticRegistry.Entries(), h.staticRegistry.Cap(), h.BlockHeight()), nil
+}
+
+// RegistryTruncate prunes the expired entries from the registry and resizes
+// it to the given size in bytes. The registry is never shrunk below the
+// number of entries it still contains after pruning.
+func (h *Host) RegistryTruncate(size uint64) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	// Prune the expired entries first to make room.
+	pruned, err := h.staticRegistry.Prune(h.BlockHeight())
+	if err != nil {
+		return errors.AddContext(err, "failed to prune expired registry entries")
+	}
+	h.log.Printf("Pruned %v expired registry entries", pruned)
+	err = h.managedPruneRenterRegistryEntries()
+	if err != nil {
+		return errors.AddContext(err, "failed to prune renters of registry entries")
+	}
+
+	// Update the size in the settings which truncates the registry without
+	// dropping any entries.
+	settings := h.InternalSettings()
+	settings.RegistrySize = size
+	return h.SetInternalSettings(settings)
+}
+
+// staticRegistryStats computes the registry stats from the entries of the
+// registry.
+func staticRegistryStats(entries []modules.HostRegistryEntry, capacity uint64, bh types.BlockHeight) modules.HostRegistryStats {
+	stats := modules.HostRegistryStats{
+		Capacity: capacity,
+		Entries:  uint64(len(entries)),
+	}
+
+	// Prepare the buckets of the expiry distribution.
+	for _, width := range registryExpiryBuckets {
+		stats.ExpiryDistribution = append(stats.ExpiryDistribution, modules.HostRegistryExpiryBucket{
+			ExpiresBefore: bh + width,
+		})
+	}
+	stats.ExpiryDistribution = append(stats.ExpiryDistribution, modules.HostRegistryExpiryBucket{
+		ExpiresBefore: types.BlockHeight(^uint64(0)),
+	})
+
+	usage := make(map[string]*modules.HostRegistryKeyUsage)
+	for _, entry := range entries {
+		// Count the entry towards the usage of its key.
+		key := entry.PublicKey.String()
+		ku, exists := usage[key]
+		if !exists {
+			ku = &modules.HostRegistryKeyUsage{PublicKey: entry.PublicKey}
+			usage[key] = ku
+		}
+		ku.Entries++
+
+		// Add the entry to the expiry distribution.
+		if entry.Expiry <= bh {
+			stats.Expired++
+			continue
+		}
+		for i := range stats.ExpiryDistribution {
+			if entry.Expiry < stats.ExpiryDistribution[i].ExpiresBefore {
+				stats.ExpiryDistribution[i].Entries++
+				break
+			}
+		}
+	}
+
+	// Sort the keys by usage.
+	stats.Keys = make([]modules.HostRegistryKeyUsage, 0, len(usage))
+	for _, ku := range usage {
+		stats.Keys = append(stats.Keys, *ku)
+	}
+	sort.Slice(stats.Keys, func(i, j int) bool {
+		if stats.Keys[i].Entries != stats.Keys[j].Entries {
+			return stats.Keys[i].Entries > stats.Keys[j].Entries
+		}
+		return stats.Keys[i].PublicKey.String() < stats.Keys[j].PublicKey.String()
+	})
+	return stats
+}
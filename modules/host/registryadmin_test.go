@@ -0,0 +1,123 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostRegistryAdmin tests the methods for administrating the host's
+// registry.
+func TestHostRegistryAdmin(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+	bh := h.BlockHeight()
+
+	// Make room for 192 entries.
+	is := h.managedInternalSettings()
+	is.RegistrySize = 192 * modules.RegistryEntrySize
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add 64 entries for the same key that expire within a day and 32 expired
+	// entries for different keys.
+	addEntry := func(sk crypto.SecretKey, pk crypto.PublicKey, expiry types.BlockHeight) modules.RegistryEntryID {
+		spk := types.Ed25519PublicKey(pk)
+		var tweak crypto.Hash
+		fastrand.Read(tweak[:])
+		rv := modules.NewRegistryValue(tweak, fastrand.Bytes(modules.RegistryDataSize), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+		_, err := h.RegistryUpdate(rv, spk, expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return modules.DeriveRegistryEntryID(spk, tweak)
+	}
+	sk, pk := crypto.GenerateKeyPair()
+	var sids []modules.RegistryEntryID
+	for i := 0; i < 64; i++ {
+		sids = append(sids, addEntry(sk, pk, bh+10))
+	}
+	for i := 0; i < 32; i++ {
+		sk, pk := crypto.GenerateKeyPair()
+		addEntry(sk, pk, bh)
+	}
+
+	// Check the stats.
+	stats, err := h.RegistryStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Capacity != 192 || stats.Entries != 96 || stats.Expired != 32 {
+		t.Fatal("wrong stats", stats.Capacity, stats.Entries, stats.Expired)
+	}
+	if len(stats.ExpiryDistribution) != len(registryExpiryBuckets)+1 {
+		t.Fatal("wrong number of buckets", len(stats.ExpiryDistribution))
+	}
+	if stats.ExpiryDistribution[0].Entries != 64 || stats.ExpiryDistribution[0].ExpiresBefore != bh+types.BlocksPerDay {
+		t.Fatal("wrong first bucket", stats.ExpiryDistribution[0])
+	}
+	if len(stats.Keys) != 33 {
+		t.Fatal("wrong number of keys", len(stats.Keys))
+	}
+	if !stats.Keys[0].PublicKey.Equals(types.Ed25519PublicKey(pk)) || stats.Keys[0].Entries != 64 {
+		t.Fatal("wrong top key", stats.Keys[0])
+	}
+
+	// Look up and delete an entry.
+	entry, err := h.RegistryEntry(sids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.EntryID != crypto.Hash(sids[0]) || len(entry.Data) != modules.RegistryDataSize {
+		t.Fatal("wrong entry", entry)
+	}
+	err = h.RegistryDelete(sids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RegistryEntry(sids[0]); !errors.Contains(err, registry.ErrEntryNotFound) {
+		t.Fatal("expected entry to be deleted", err)
+	}
+
+	// Truncating to 64 entries only works after pruning the expired entries.
+	err = h.RegistryTruncate(64 * modules.RegistryEntrySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err = h.RegistryStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Capacity != 64 || stats.Entries != 63 || stats.Expired != 0 {
+		t.Fatal("wrong stats after truncate", stats.Capacity, stats.Entries, stats.Expired)
+	}
+	if h.InternalSettings().RegistrySize != 64*modules.RegistryEntrySize {
+		t.Fatal("registry size wasn't updated")
+	}
+
+	// Adding the deleted entry back and truncating further should fail.
+	addEntry(sk, pk, bh+10)
+	err = h.RegistryTruncate(0)
+	if !errors.Contains(err, registry.ErrInvalidTruncate) {
+		t.Fatal("expected truncate to fail", err)
+	}
+}
@@ -0,0 +1,541 @@
+package host
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrRenterAccountQuotaExceeded is returned when a renter tries to fund a
+	// new ephemeral account after reaching the host's MaxRenterAccounts.
+	ErrRenterAccountQuotaExceeded = errors.New("renter has reached the host's limit of ephemeral accounts")
+
+	// ErrRenterBandwidthQuotaExceeded is returned when a renter tries to pay
+	// for an RPC or transfer data after using up the host's
+	// MaxRenterBandwidth for the current bandwidth period.
+	ErrRenterBandwidthQuotaExceeded = errors.New("renter has reached the host's bandwidth limit for the current period")
+
+	// ErrRenterRegistryQuotaExceeded is returned when a renter tries to create
+	// a new registry entry after reaching the host's MaxRenterRegistryEntries.
+	ErrRenterRegistryQuotaExceeded = errors.New("renter has reached the host's limit of registry entries")
+
+	// ErrRenterSectorQuotaExceeded is returned when a renter tries to store
+	// more sectors than the host's MaxRenterSectors allows.
+	ErrRenterSectorQuotaExceeded = errors.New("renter has reached the host's limit of stored sectors")
+)
+
+type (
+	// renterUsage keeps track of the resources used by the renters of the
+	// host. A renter is identified by the public key it uses in its contracts.
+	renterUsage struct {
+		// accounts and registryEntries map the accounts and registry entries
+		// to the renter that created them. They are persisted in the host's
+		// database.
+		accounts        map[modules.AccountID]string
+		registryEntries map[modules.RegistryEntryID]string
+
+		// contracts contains the renter and number of sectors of every
+		// unresolved storage obligation. It is rebuilt from the storage
+		// obligations on startup.
+		contracts map[types.FileContractID]contractUsage
+
+		// renters contains the aggregated usage of every renter, indexed by the
+		// string representation of its public key.
+		renters map[string]*modules.HostRenterUsage
+
+		// bandwidthPeriodStart is the time at which the bandwidth of all
+		// renters was last reset. The bandwidth is persisted with the host's
+		// settings.
+		bandwidthPeriodStart time.Time
+
+		mu sync.Mutex
+	}
+
+	// renterBandwidthPersist is the persisted bandwidth usage of the renters
+	// in the current bandwidth period.
+	renterBandwidthPersist struct {
+		PeriodStart time.Time              `json:"periodstart"`
+		Renters     []renterBandwidthUsage `json:"renters"`
+	}
+
+	// renterBandwidthUsage is the persisted bandwidth usage of a single
+	// renter.
+	renterBandwidthUsage struct {
+		PublicKey         types.SiaPublicKey `json:"publickey"`
+		DownloadBandwidth uint64             `json:"downloadbandwidth"`
+		UploadBandwidth   uint64             `json:"uploadbandwidth"`
+	}
+
+	// contractUsage is the usage of a single contract.
+	contractUsage struct {
+		renter  string
+		sectors uint64
+	}
+
+	// renterUsageHost wraps the host that is passed to the MDM to attribute
+	// the registry entries created by a program to the renter paying for it.
+	renterUsageHost struct {
+		*Host
+		staticRenter types.SiaPublicKey
+	}
+)
+
+// newRenterUsage creates a new renterUsage.
+func newRenterUsage() *renterUsage {
+	return &renterUsage{
+		accounts:             make(map[modules.AccountID]string),
+		registryEntries:      make(map[modules.RegistryEntryID]string),
+		contracts:            make(map[types.FileContractID]contractUsage),
+		renters:              make(map[string]*modules.HostRenterUsage),
+		bandwidthPeriodStart: time.Now(),
+	}
+}
+
+// renter returns the usage of a renter, creating it if necessary.
+func (ru *renterUsage) renter(spk types.SiaPublicKey) *modules.HostRenterUsage {
+	key := spk.String()
+	usage, exists := ru.renters[key]
+	if !exists {
+		usage = &modules.HostRenterUsage{PublicKey: spk}
+		ru.renters[key] = usage
+	}
+	return usage
+}
+
+// pruneRenter removes the usage of a renter if it doesn't use any resources
+// anymore.
+func (ru *renterUsage) pruneRenter(key string) {
+	usage, exists := ru.renters[key]
+	if !exists {
+		return
+	}
+	if usage.Accounts == 0 && usage.Contracts == 0 && usage.RegistryEntries == 0 && usage.Sectors == 0 && usage.DownloadBandwidth == 0 && usage.UploadBandwidth == 0 {
+		delete(ru.renters, key)
+	}
+}
+
+// resetBandwidth starts a new bandwidth period if the current one is over.
+func (ru *renterUsage) resetBandwidth() {
+	if time.Since(ru.bandwidthPeriodStart) < renterBandwidthPeriod {
+		return
+	}
+	ru.bandwidthPeriodStart = time.Now()
+	for key, usage := range ru.renters {
+		usage.DownloadBandwidth = 0
+		usage.UploadBandwidth = 0
+		ru.pruneRenter(key)
+	}
+}
+
+// removeContract removes a contract from the usage of its renter.
+func (ru *renterUsage) removeContract(id types.FileContractID) {
+	cu, exists := ru.contracts[id]
+	if !exists {
+		return
+	}
+	delete(ru.contracts, id)
+	if usage, exists := ru.renters[cu.renter]; exists {
+		usage.Contracts--
+		usage.Sectors -= cu.sectors
+		ru.pruneRenter(cu.renter)
+	}
+}
+
+// managedAccountRenter returns the renter that funded the account.
+func (ru *renterUsage) managedAccountRenter(id modules.AccountID) (types.SiaPublicKey, bool) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	key, exists := ru.accounts[id]
+	if !exists {
+		return types.SiaPublicKey{}, false
+	}
+	return ru.renters[key].PublicKey, true
+}
+
+// managedAddAccount attributes an account to a renter. It returns true if the
+// account wasn't attributed to a renter yet. Accounts can't be added if the
+// renter already has max accounts, unless max is 0.
+func (ru *renterUsage) managedAddAccount(id modules.AccountID, spk types.SiaPublicKey, max uint64) (bool, error) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	if _, exists := ru.accounts[id]; exists {
+		return false, nil
+	}
+	usage := ru.renter(spk)
+	if max > 0 && usage.Accounts >= max {
+		ru.pruneRenter(spk.String())
+		return false, ErrRenterAccountQuotaExceeded
+	}
+	ru.accounts[id] = spk.String()
+	usage.Accounts++
+	return true, nil
+}
+
+// managedAddBandwidth adds bandwidth to the usage of a renter.
+func (ru *renterUsage) managedAddBandwidth(spk types.SiaPublicKey, download, upload uint64) {
+	if download == 0 && upload == 0 {
+		return
+	}
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.resetBandwidth()
+	usage := ru.renter(spk)
+	usage.DownloadBandwidth += download
+	usage.UploadBandwidth += upload
+}
+
+// managedAddRegistryEntry attributes a registry entry to a renter. It returns
+// true if the entry wasn't attributed to a renter yet. Entries can't be added
+// if the renter already has max entries, unless max is 0.
+func (ru *renterUsage) managedAddRegistryEntry(sid modules.RegistryEntryID, spk types.SiaPublicKey, max uint64) (bool, error) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	if _, exists := ru.registryEntries[sid]; exists {
+		return false, nil
+	}
+	usage := ru.renter(spk)
+	if max > 0 && usage.RegistryEntries >= max {
+		ru.pruneRenter(spk.String())
+		return false, ErrRenterRegistryQuotaExceeded
+	}
+	ru.registryEntries[sid] = spk.String()
+	usage.RegistryEntries++
+	return true, nil
+}
+
+// managedBandwidthPersist returns the bandwidth usage of the current period to
+// be persisted.
+func (ru *renterUsage) managedBandwidthPersist() renterBandwidthPersist {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.resetBandwidth()
+	p := renterBandwidthPersist{
+		PeriodStart: ru.bandwidthPeriodStart,
+	}
+	for _, usage := range ru.renters {
+		if usage.DownloadBandwidth == 0 && usage.UploadBandwidth == 0 {
+			continue
+		}
+		p.Renters = append(p.Renters, renterBandwidthUsage{
+			PublicKey:         usage.PublicKey,
+			DownloadBandwidth: usage.DownloadBandwidth,
+			UploadBandwidth:   usage.UploadBandwidth,
+		})
+	}
+	return p
+}
+
+// managedLoadBandwidth restores the persisted bandwidth usage. Persist objects
+// without a period start leave the current period untouched.
+func (ru *renterUsage) managedLoadBandwidth(p renterBandwidthPersist) {
+	if p.PeriodStart.IsZero() {
+		return
+	}
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.bandwidthPeriodStart = p.PeriodStart
+	for _, rb := range p.Renters {
+		usage := ru.renter(rb.PublicKey)
+		usage.DownloadBandwidth = rb.DownloadBandwidth
+		usage.UploadBandwidth = rb.UploadBandwidth
+	}
+	ru.resetBandwidth()
+}
+
+// managedCheckBandwidthQuota returns an error if the renter used more than max
+// bytes of bandwidth in the current period. A max of 0 means no limit.
+func (ru *renterUsage) managedCheckBandwidthQuota(spk types.SiaPublicKey, max uint64) error {
+	if max == 0 {
+		return nil
+	}
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.resetBandwidth()
+	usage, exists := ru.renters[spk.String()]
+	if exists && usage.DownloadBandwidth+usage.UploadBandwidth >= max {
+		return ErrRenterBandwidthQuotaExceeded
+	}
+	return nil
+}
+
+// managedCheckSectorQuota returns an error if updating the storage obligation
+// would increase the number of sectors stored by its renter above max. A max
+// of 0 means no limit.
+func (ru *renterUsage) managedCheckSectorQuota(so storageObligation, max uint64) error {
+	if max == 0 {
+		return nil
+	}
+	spk, ok := so.renterPublicKey()
+	if !ok {
+		return nil
+	}
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	newSectors := uint64(len(so.SectorRoots))
+	oldSectors := ru.contracts[so.id()].sectors
+	if newSectors <= oldSectors {
+		return nil // removing sectors is always allowed
+	}
+	var sectors uint64
+	if usage, exists := ru.renters[spk.String()]; exists {
+		sectors = usage.Sectors
+	}
+	if sectors-oldSectors+newSectors > max {
+		return ErrRenterSectorQuotaExceeded
+	}
+	return nil
+}
+
+// managedRegistryEntries returns the ids of all the registry entries that are
+// attributed to a renter.
+func (ru *renterUsage) managedRegistryEntries() []modules.RegistryEntryID {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	sids := make([]modules.RegistryEntryID, 0, len(ru.registryEntries))
+	for sid := range ru.registryEntries {
+		sids = append(sids, sid)
+	}
+	return sids
+}
+
+// managedRemoveAccount removes an account from the usage of its renter. It
+// returns true if the account was attributed to a renter.
+func (ru *renterUsage) managedRemoveAccount(id modules.AccountID) bool {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	key, exists := ru.accounts[id]
+	if !exists {
+		return false
+	}
+	delete(ru.accounts, id)
+	ru.renters[key].Accounts--
+	ru.pruneRenter(key)
+	return true
+}
+
+// managedRemoveContract removes a contract from the usage of its renter.
+func (ru *renterUsage) managedRemoveContract(id types.FileContractID) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.removeContract(id)
+}
+
+// managedRemoveRegistryEntry removes a registry entry from the usage of its
+// renter. It returns true if the entry was attributed to a renter.
+func (ru *renterUsage) managedRemoveRegistryEntry(sid modules.RegistryEntryID) bool {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	key, exists := ru.registryEntries[sid]
+	if !exists {
+		return false
+	}
+	delete(ru.registryEntries, sid)
+	ru.renters[key].RegistryEntries--
+	ru.pruneRenter(key)
+	return true
+}
+
+// managedUpdateContract updates the usage of the renter of a storage
+// obligation after the obligation changed. Renewed obligations are removed
+// from the usage since their sectors are counted through the obligation they
+// were renewed to.
+func (ru *renterUsage) managedUpdateContract(so storageObligation) {
+	spk, ok := so.renterPublicKey()
+	if !ok {
+		return
+	}
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.removeContract(so.id())
+	if so.RenewedTo != (types.FileContractID{}) {
+		return
+	}
+	sectors := uint64(len(so.SectorRoots))
+	ru.contracts[so.id()] = contractUsage{
+		renter:  spk.String(),
+		sectors: sectors,
+	}
+	usage := ru.renter(spk)
+	usage.Contracts++
+	usage.Sectors += sectors
+}
+
+// managedUsage returns the usage of all renters sorted by their public key.
+func (ru *renterUsage) managedUsage() []modules.HostRenterUsage {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	ru.resetBandwidth()
+	usages := make([]modules.HostRenterUsage, 0, len(ru.renters))
+	for _, usage := range ru.renters {
+		u := *usage
+		u.BandwidthPeriodStart = ru.bandwidthPeriodStart
+		usages = append(usages, u)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].PublicKey.String() < usages[j].PublicKey.String()
+	})
+	return usages
+}
+
+// RegistryUpdate updates a value in the registry. Entries that don't exist
+// yet are attributed to the renter and count towards its quota.
+func (h renterUsageHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
+	sid := modules.DeriveRegistryEntryID(pubKey, rv.Tweak)
+	var added bool
+	if _, _, exists := h.staticRegistry.Get(sid); !exists {
+		var err error
+		added, err = h.managedAttributeRegistryEntry(sid, h.staticRenter)
+		if err != nil {
+			return modules.SignedRegistryValue{}, err
+		}
+	}
+	existingRV, err := h.Host.RegistryUpdate(rv, pubKey, expiry)
+	if err != nil && added {
+		err = errors.Compose(err, h.managedUnattributeRegistryEntries([]modules.RegistryEntryID{sid}))
+	}
+	return existingRV, err
+}
+
+// RenterUsage returns the resources used by each renter of the host.
+func (h *Host) RenterUsage() ([]modules.HostRenterUsage, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	return h.staticRenterUsage.managedUsage(), nil
+}
+
+// loadRenterUsage loads the attributed accounts and registry entries from the
+// database.
+func (h *Host) loadRenterUsage(tx *bolt.Tx) error {
+	err := tx.Bucket(bucketRenterAccounts).ForEach(func(k, v []byte) error {
+		var id modules.AccountID
+		var spk types.SiaPublicKey
+		if err := encoding.Unmarshal(k, &id); err != nil {
+			return errors.AddContext(err, "failed to decode account id")
+		}
+		if err := encoding.Unmarshal(v, &spk); err != nil {
+			return errors.AddContext(err, "failed to decode renter of account")
+		}
+		_, err := h.staticRenterUsage.managedAddAccount(id, spk, 0)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRenterRegistryEntries).ForEach(func(k, v []byte) error {
+		var sid modules.RegistryEntryID
+		var spk types.SiaPublicKey
+		copy(sid[:], k)
+		if err := encoding.Unmarshal(v, &spk); err != nil {
+			return errors.AddContext(err, "failed to decode renter of registry entry")
+		}
+		_, err := h.staticRenterUsage.managedAddRegistryEntry(sid, spk, 0)
+		return err
+	})
+}
+
+// managedAttributeAccount attributes an account to the renter funding it and
+// persists the attribution. It fails if the renter has reached its quota of
+// accounts.
+func (h *Host) managedAttributeAccount(id modules.AccountID, spk types.SiaPublicKey) error {
+	max := h.managedInternalSettings().MaxRenterAccounts
+	added, err := h.staticRenterUsage.managedAddAccount(id, spk, max)
+	if err != nil || !added {
+		return err
+	}
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRenterAccounts).Put(encoding.Marshal(id), encoding.Marshal(spk))
+	})
+	if err != nil {
+		h.staticRenterUsage.managedRemoveAccount(id)
+		return errors.AddContext(err, "failed to persist renter of account")
+	}
+	return nil
+}
+
+// managedAttributeRegistryEntry attributes a new registry entry to the renter
+// creating it and persists the attribution. It returns true if the entry
+// wasn't attributed yet and fails if the renter has reached its quota of
+// registry entries.
+func (h *Host) managedAttributeRegistryEntry(sid modules.RegistryEntryID, spk types.SiaPublicKey) (bool, error) {
+	max := h.managedInternalSettings().MaxRenterRegistryEntries
+	added, err := h.staticRenterUsage.managedAddRegistryEntry(sid, spk, max)
+	if err != nil || !added {
+		return false, err
+	}
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRenterRegistryEntries).Put(sid[:], encoding.Marshal(spk))
+	})
+	if err != nil {
+		h.staticRenterUsage.managedRemoveRegistryEntry(sid)
+		return false, errors.AddContext(err, "failed to persist renter of registry entry")
+	}
+	return true, nil
+}
+
+// managedCheckRenterBandwidth returns an error if the renter has used up its
+// bandwidth quota for the current period.
+func (h *Host) managedCheckRenterBandwidth(spk types.SiaPublicKey) error {
+	max := h.managedInternalSettings().MaxRenterBandwidth
+	return h.staticRenterUsage.managedCheckBandwidthQuota(spk, max)
+}
+
+// managedPruneRenterRegistryEntries removes the attributions of registry
+// entries which were removed from the registry.
+func (h *Host) managedPruneRenterRegistryEntries() error {
+	var removed []modules.RegistryEntryID
+	for _, sid := range h.staticRenterUsage.managedRegistryEntries() {
+		if _, _, exists := h.staticRegistry.Get(sid); !exists {
+			removed = append(removed, sid)
+		}
+	}
+	return h.managedUnattributeRegistryEntries(removed)
+}
+
+// managedUnattributeAccounts removes the attributions of the given accounts.
+func (h *Host) managedUnattributeAccounts(ids []modules.AccountID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, id := range ids {
+		h.staticRenterUsage.managedRemoveAccount(id)
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketRenterAccounts)
+		for _, id := range ids {
+			if err := b.Delete(encoding.Marshal(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// managedUnattributeRegistryEntries removes the attributions of the given
+// registry entries.
+func (h *Host) managedUnattributeRegistryEntries(sids []modules.RegistryEntryID) error {
+	if len(sids) == 0 {
+		return nil
+	}
+	for _, sid := range sids {
+		h.staticRenterUsage.managedRemoveRegistryEntry(sid)
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketRenterRegistryEntries)
+		for _, sid := range sids {
+			if err := b.Delete(sid[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,268 @@
+package host
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newRenterUsageTestObligation is a helper that creates a storage obligation
+// with the given number of sectors which belongs to the given renter.
+func newRenterUsageTestObligation(spk types.SiaPublicKey, sectors int) storageObligation {
+	var so storageObligation
+	so.OriginTransactionSet = []types.Transaction{{
+		FileContracts: []types.FileContract{{}},
+		ArbitraryData: [][]byte{fastrand.Bytes(16)},
+	}}
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID: so.OriginTransactionSet[0].FileContractID(0),
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{spk, {}},
+			},
+		}},
+	}}
+	so.SectorRoots = make([]crypto.Hash, sectors)
+	return so
+}
+
+// TestRenterUsage is a unit test for the renterUsage.
+func TestRenterUsage(t *testing.T) {
+	t.Parallel()
+
+	ru := newRenterUsage()
+	_, pk1 := crypto.GenerateKeyPair()
+	_, pk2 := crypto.GenerateKeyPair()
+	renter1 := types.Ed25519PublicKey(pk1)
+	renter2 := types.Ed25519PublicKey(pk2)
+
+	// usage is a helper to get the usage of a renter.
+	usage := func(spk types.SiaPublicKey) (modules.HostRenterUsage, bool) {
+		for _, u := range ru.managedUsage() {
+			if u.PublicKey.Equals(spk) {
+				return u, true
+			}
+		}
+		return modules.HostRenterUsage{}, false
+	}
+
+	// Add two contracts for the first renter and one for the second one.
+	so1 := newRenterUsageTestObligation(renter1, 2)
+	so2 := newRenterUsageTestObligation(renter1, 3)
+	so3 := newRenterUsageTestObligation(renter2, 1)
+	ru.managedUpdateContract(so1)
+	ru.managedUpdateContract(so2)
+	ru.managedUpdateContract(so3)
+	if len(ru.managedUsage()) != 2 {
+		t.Fatal("expected usage of 2 renters", len(ru.managedUsage()))
+	}
+	u, _ := usage(renter1)
+	if u.Contracts != 2 || u.Sectors != 5 {
+		t.Fatal("unexpected usage", u.Contracts, u.Sectors)
+	}
+
+	// Updating a contract shouldn't count it twice.
+	so1.SectorRoots = append(so1.SectorRoots, crypto.Hash{})
+	ru.managedUpdateContract(so1)
+	u, _ = usage(renter1)
+	if u.Contracts != 2 || u.Sectors != 6 {
+		t.Fatal("unexpected usage", u.Contracts, u.Sectors)
+	}
+
+	// Check the sector quota. Adding sectors beyond the quota should fail
+	// while removing them is always fine.
+	so1.SectorRoots = append(so1.SectorRoots, crypto.Hash{})
+	if err := ru.managedCheckSectorQuota(so1, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := ru.managedCheckSectorQuota(so1, 6); !errors.Contains(err, ErrRenterSectorQuotaExceeded) {
+		t.Fatal("expected quota error", err)
+	}
+	if err := ru.managedCheckSectorQuota(so1, 0); err != nil {
+		t.Fatal(err)
+	}
+	so1.SectorRoots = so1.SectorRoots[:1]
+	if err := ru.managedCheckSectorQuota(so1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewing a contract should only count the sectors of the renewed
+	// contract.
+	so4 := newRenterUsageTestObligation(renter1, 6)
+	so1.RenewedTo = so4.id()
+	ru.managedUpdateContract(so1)
+	ru.managedUpdateContract(so4)
+	u, _ = usage(renter1)
+	if u.Contracts != 2 || u.Sectors != 9 {
+		t.Fatal("unexpected usage", u.Contracts, u.Sectors)
+	}
+
+	// Remove the second renter's contract. The renter should be gone.
+	ru.managedRemoveContract(so3.id())
+	if _, exists := usage(renter2); exists {
+		t.Fatal("renter without usage should have been pruned")
+	}
+
+	// Check the account quota.
+	var aid1, aid2, aid3 modules.AccountID
+	aid1.FromSPK(types.Ed25519PublicKey(crypto.PublicKey{1}))
+	aid2.FromSPK(types.Ed25519PublicKey(crypto.PublicKey{2}))
+	aid3.FromSPK(types.Ed25519PublicKey(crypto.PublicKey{3}))
+	if added, err := ru.managedAddAccount(aid1, renter2, 1); err != nil || !added {
+		t.Fatal("failed to add account", added, err)
+	}
+	if added, err := ru.managedAddAccount(aid1, renter2, 1); err != nil || added {
+		t.Fatal("account shouldn't be added twice", added, err)
+	}
+	if _, err := ru.managedAddAccount(aid2, renter2, 1); !errors.Contains(err, ErrRenterAccountQuotaExceeded) {
+		t.Fatal("expected quota error", err)
+	}
+	if _, err := ru.managedAddAccount(aid3, renter2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if spk, ok := ru.managedAccountRenter(aid1); !ok || !spk.Equals(renter2) {
+		t.Fatal("wrong account renter", spk, ok)
+	}
+	if !ru.managedRemoveAccount(aid1) || !ru.managedRemoveAccount(aid3) {
+		t.Fatal("failed to remove accounts")
+	}
+	if ru.managedRemoveAccount(aid2) {
+		t.Fatal("account should not exist")
+	}
+	if _, exists := usage(renter2); exists {
+		t.Fatal("renter without usage should have been pruned")
+	}
+
+	// Check the registry quota.
+	sid1 := modules.DeriveRegistryEntryID(renter1, crypto.Hash{1})
+	sid2 := modules.DeriveRegistryEntryID(renter1, crypto.Hash{2})
+	if added, err := ru.managedAddRegistryEntry(sid1, renter1, 1); err != nil || !added {
+		t.Fatal("failed to add entry", added, err)
+	}
+	if _, err := ru.managedAddRegistryEntry(sid2, renter1, 1); !errors.Contains(err, ErrRenterRegistryQuotaExceeded) {
+		t.Fatal("expected quota error", err)
+	}
+	if len(ru.managedRegistryEntries()) != 1 {
+		t.Fatal("expected 1 entry", len(ru.managedRegistryEntries()))
+	}
+	u, _ = usage(renter1)
+	if u.RegistryEntries != 1 {
+		t.Fatal("unexpected usage", u.RegistryEntries)
+	}
+	if !ru.managedRemoveRegistryEntry(sid1) {
+		t.Fatal("failed to remove entry")
+	}
+
+	// Check the bandwidth quota.
+	ru.managedAddBandwidth(renter1, 10, 20)
+	if err := ru.managedCheckBandwidthQuota(renter1, 31); err != nil {
+		t.Fatal(err)
+	}
+	if err := ru.managedCheckBandwidthQuota(renter1, 30); !errors.Contains(err, ErrRenterBandwidthQuotaExceeded) {
+		t.Fatal("expected quota error", err)
+	}
+	if err := ru.managedCheckBandwidthQuota(renter2, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bandwidth should survive persisting and loading it.
+	p := ru.managedBandwidthPersist()
+	ru2 := newRenterUsage()
+	ru2.managedLoadBandwidth(p)
+	if !ru2.bandwidthPeriodStart.Equal(ru.bandwidthPeriodStart) {
+		t.Fatal("period start wasn't loaded")
+	}
+	if err := ru2.managedCheckBandwidthQuota(renter1, 30); !errors.Contains(err, ErrRenterBandwidthQuotaExceeded) {
+		t.Fatal("expected quota error after loading", err)
+	}
+
+	// Move the start of the period back in time. The bandwidth should be
+	// reset.
+	ru.mu.Lock()
+	ru.bandwidthPeriodStart = time.Now().Add(-renterBandwidthPeriod)
+	ru.mu.Unlock()
+	if err := ru.managedCheckBandwidthQuota(renter1, 30); err != nil {
+		t.Fatal(err)
+	}
+	u, _ = usage(renter1)
+	if u.DownloadBandwidth != 0 || u.UploadBandwidth != 0 {
+		t.Fatal("bandwidth wasn't reset", u.DownloadBandwidth, u.UploadBandwidth)
+	}
+}
+
+// TestHostRenterUsage verifies the host attributes funded ephemeral accounts
+// to the renter of the contract and enforces the account quota.
+func TestHostRenterUsage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := pair.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ht := pair.staticHT
+
+	// Limit the renter to a single account.
+	is := ht.host.InternalSettings()
+	is.MaxRenterAccounts = 1
+	err = ht.host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the pair's account.
+	_, err = pair.managedFundEphemeralAccount(pair.pt.FundAccountCost.Add64(1), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkUsage is a helper that checks the renter's usage.
+	checkUsage := func() error {
+		usage, err := ht.host.RenterUsage()
+		if err != nil {
+			return err
+		}
+		if len(usage) != 1 {
+			return errors.New("expected usage of a single renter")
+		}
+		if !usage[0].PublicKey.Equals(pair.staticRenterPK) {
+			return errors.New("wrong renter")
+		}
+		if usage[0].Accounts != 1 || usage[0].Contracts != 1 {
+			return errors.New("unexpected usage")
+		}
+		return nil
+	}
+	if err := checkUsage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Funding a second account should fail.
+	_, pair.staticAccountID = prepareAccount()
+	_, err = pair.managedFundEphemeralAccount(pair.pt.FundAccountCost.Add64(1), true)
+	if err == nil || !strings.Contains(err.Error(), ErrRenterAccountQuotaExceeded.Error()) {
+		t.Fatal("expected quota error", err)
+	}
+
+	// Reload the host. The usage should be the same.
+	err = reloadHost(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkUsage(); err != nil {
+		t.Fatal(err)
+	}
+}
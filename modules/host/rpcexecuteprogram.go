@@ -51,6 +51,20 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		return errors.AddContext(err, "failed to set budget limit on stream")
 	}
 
+	// If we know the renter that funded the account, the program's bandwidth
+	// and registry entries are attributed to it. Reading from the stream means
+	// uploading from the renter's perspective.
+	var mdmHost mdm.Host = h
+	renter, knownRenter := h.staticRenterUsage.managedAccountRenter(pd.AccountID())
+	if knownRenter {
+		mdmHost = renterUsageHost{Host: h, staticRenter: renter}
+		defer func() {
+			l := stream.Limit()
+			h.staticRenterUsage.managedAddBandwidth(renter, l.Uploaded(), l.Downloaded())
+			h.staticBandwidthAccounting.managedAddRenter(renter, l.Downloaded(), l.Uploaded())
+		}()
+	}
+
 	// Refund all the money we didn't use at the end of the RPC.
 	refundAccount := pd.AccountID()
 	programRefund := pd.Amount()
@@ -117,7 +131,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	}()
 
 	// Execute the program.
-	finalize, outputs, err := h.staticMDM.ExecuteProgram(ctx, pt, program, budget, collateralBudget, sos, duration, dataLength, stream)
+	finalize, outputs, err := h.staticMDM.ExecuteProgramWithHost(ctx, mdmHost, pt, program, budget, collateralBudget, sos, duration, dataLength, stream)
 	if err != nil {
 		return errors.AddContext(err, "Failed to start execution of the program")
 	}
@@ -187,7 +201,9 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		// Remember that the execution wasn't successful.
 		executionFailed = output.Error != nil
 
-		// Send the response to the peer.
+		// Send the response to the peer. The response and the output are
+		// attributed to the instruction.
+		bufferLen := buffer.Len()
 		err = modules.RPCWrite(buffer, resp)
 		if err != nil {
 			return errors.AddContext(err, "failed to send output to peer")
@@ -206,6 +222,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		if err != nil {
 			return errors.AddContext(err, "failed to send output data to peer")
 		}
+		h.staticBandwidthAccounting.managedAddInstruction(instructionSpecifier, output.ProgramDataRead, uint64(buffer.Len()-bufferLen))
 
 		// Increase the write deadline just before writing to it.
 		err = stream.SetWriteDeadline(time.Now().Add(modules.MDMProgramWriteResponseTime))
@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	connmonitor "gitlab.com/NebulousLabs/monitor"
 	"golang.org/x/crypto/chacha20poly1305"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -54,6 +55,11 @@ func (s *rpcSession) writeError(err error) error {
 // request and response. The loop terminates when the an RPC encounters an
 // error or the renter sends modules.RPCLoopExit.
 func (h *Host) managedRPCLoop(conn net.Conn) error {
+	// Monitor the session's bandwidth to attribute it to the renter of the
+	// locked contract.
+	monitor := connmonitor.NewMonitor()
+	conn = connmonitor.NewMonitoredConn(conn, monitor)
+
 	// read renter's half of key exchange
 	conn.SetDeadline(time.Now().Add(rpcRequestInterval))
 	var req modules.LoopKeyExchangeRequest
@@ -131,6 +137,7 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		modules.RPCLoopSectorRoots:        h.managedRPCLoopSectorRoots,
 	}
 	for {
+		readBefore, writeBefore := monitor.Counts()
 		conn.SetDeadline(time.Now().Add(rpcRequestInterval))
 		id, err := modules.ReadRPCID(conn, aead)
 		if err != nil {
@@ -140,9 +147,22 @@ func (h *Host) managedRPCLoop(conn net.Conn) error {
 		} else if id == modules.RPCLoopExit {
 			return nil
 		}
-		if rpcFn, ok := rpcs[id]; !ok {
+		rpcFn, ok := rpcs[id]
+		if !ok {
 			return errors.New("invalid or unknown RPC ID: " + id.String())
-		} else if err := rpcFn(s); err != nil {
+		}
+		err = rpcFn(s)
+
+		// Attribute the bandwidth of the RPC to the renter of the locked
+		// contract. Reading from the connection means uploading from the
+		// renter's perspective.
+		read, write := monitor.Counts()
+		h.staticBandwidthAccounting.managedAddRPC(id, read-readBefore, write-writeBefore)
+		if renter, ok := s.so.renterPublicKey(); ok {
+			h.staticRenterUsage.managedAddBandwidth(renter, write-writeBefore, read-readBefore)
+			h.staticBandwidthAccounting.managedAddRenter(renter, read-readBefore, write-writeBefore)
+		}
+		if err != nil {
 			return extendErr("incoming RPC"+id.String()+" failed: ", err)
 		}
 	}
@@ -57,7 +57,7 @@ func (h *Host) managedRPCRenewContract(stream siamux.Stream) error {
 	hsk := h.secretKey
 	contractPrice := pt.ContractPrice
 	is := h.settings // internal settings
-	ac := is.AcceptingContracts
+	ac := acceptingContracts(is)
 	lockedCollateral := h.financialMetrics.LockedStorageCollateral
 	unlockHash := h.unlockHash
 	h.mu.RUnlock()
@@ -334,6 +334,10 @@ func verifyRenewedContract(so storageObligation, newContract types.FileContract,
 	if newContract.WindowStart > blockHeight+pt.MaxDuration {
 		return types.Currency{}, ErrLongDuration
 	}
+	// The proof window must not overlap the host's maintenance window.
+	if err := verifyMaintenanceWindow(internalSettings, newContract.WindowStart, newContract.WindowEnd); err != nil {
+		return types.Currency{}, err
+	}
 
 	// ValidProofOutputs should have 2 outputs (renter + host) and missed
 	// outputs should have 3 (renter + host + void)
@@ -0,0 +1,20 @@
+package host
+
+import (
+	"go.sia.tech/siad/modules"
+)
+
+// ScrubReport returns the status of the storage manager's sector scrubber and
+// the storage obligations which contained corrupted sectors during the last
+// check of the obligation risks, sorted by their proof deadline.
+func (h *Host) ScrubReport() (modules.HostScrubReport, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostScrubReport{}, err
+	}
+	defer h.tg.Done()
+	return modules.HostScrubReport{
+		StorageScrubStatus: h.StorageManager.ScrubStatus(),
+		ObligationsAtRisk:  h.managedObligationsAtRisk(),
+	}, nil
+}
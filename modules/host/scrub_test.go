@@ -0,0 +1,104 @@
+package host
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestScrubReport corrupts the sectors of a storage obligation on disk and
+// checks that the obligation is reported as being at risk.
+func TestScrubReport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The scrubber uses the default rate.
+	if rate := ht.host.StorageManager.ScrubStatus().Rate; rate != modules.DefaultScrubRate {
+		t.Fatal("unexpected scrub rate", rate)
+	}
+
+	// Add a storage obligation with a sector which requires a proof.
+	so, err := ht.newTesterRevisedStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No obligations should be at risk yet.
+	report, err := ht.host.ScrubReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.ObligationsAtRisk) != 0 {
+		t.Fatal("unexpected obligations at risk", report.ObligationsAtRisk)
+	}
+
+	// Overwrite all of the host's sector files with garbage.
+	for _, sf := range ht.host.StorageFolders() {
+		path := filepath.Join(sf.Path, "siahostdata.dat")
+		err = ioutil.WriteFile(path, fastrand.Bytes(int(sf.Capacity)), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Speed up the scrubber and wait for the obligation to be at risk.
+	is := ht.host.InternalSettings()
+	is.ScrubRate = 100 * modules.SectorSize
+	err = ht.host.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		report, err = ht.host.ScrubReport()
+		if err != nil {
+			return err
+		}
+		if len(report.ObligationsAtRisk) != 1 {
+			return errors.New("obligation isn't at risk")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oar := report.ObligationsAtRisk[0]
+	if oar.ObligationID != so.id() || oar.ProofDeadline != so.proofDeadline() {
+		t.Fatal("unexpected obligation at risk", oar)
+	}
+	if len(oar.CorruptedSectors) != 1 || oar.CorruptedSectors[0] != sectorRoot {
+		t.Fatal("unexpected corrupted sectors", oar.CorruptedSectors)
+	}
+}
@@ -167,6 +167,11 @@ type storageObligation struct {
 	OriginTransactionSet   []types.Transaction
 	RevisionTransactionSet []types.Transaction
 
+	// RenewedTo is the id of the obligation the obligation was renewed to.
+	// The sectors of a renewed obligation are only attributed to its renter
+	// through the obligation it was renewed to.
+	RenewedTo types.FileContractID
+
 	// Variables indicating whether the critical transactions in a storage
 	// obligation have been confirmed on the blockchain.
 	ObligationStatus    storageObligationStatus
@@ -530,6 +535,17 @@ func (so storageObligation) recentRevision() (types.FileContractRevision, error)
 	return revisionTxn.FileContractRevisions[0], nil
 }
 
+// renterPublicKey returns the public key of the renter of the storage
+// obligation. The second return value is false if the obligation doesn't
+// contain a revision yet.
+func (so storageObligation) renterPublicKey() (types.SiaPublicKey, bool) {
+	rev, err := so.recentRevision()
+	if err != nil || len(rev.UnlockConditions.PublicKeys) == 0 {
+		return types.SiaPublicKey{}, false
+	}
+	return rev.UnlockConditions.PublicKeys[0], true
+}
+
 // managedGetStorageObligation fetches a storage obligation from the database.
 func (h *Host) managedGetStorageObligation(fcid types.FileContractID) (so storageObligation, err error) {
 	h.mu.RLock()
@@ -563,6 +579,9 @@ func (h *Host) deleteStorageObligations(soids []types.FileContractID) error {
 		h.log.Println(build.ExtendErr("database failed to delete storage obligations:", err))
 		return err
 	}
+	for _, soid := range soids {
+		h.staticRenterUsage.managedRemoveContract(soid)
+	}
 	return nil
 }
 
@@ -652,9 +671,10 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 			return err
 		}
 
-		// Update the host financial metrics with regards to this storage
-		// obligation.
+		// Update the host financial metrics and the usage of the renter with
+		// regards to this storage obligation.
 		h.updateFinancialMetricsAddSO(so)
+		h.staticRenterUsage.managedUpdateContract(so)
 		return nil
 	}()
 	if err != nil {
@@ -697,6 +717,10 @@ func (h *Host) managedAddRenewedStorageObligation(oldSO, newSO storageObligation
 		return err
 	}
 
+	// Remember the renewal to not count the sectors of the old obligation
+	// towards the usage of the renter twice.
+	oldSO.RenewedTo = newSO.id()
+
 	// Update the database to contain the new storage obligation.
 	var err error
 	var oldSOBefore storageObligation
@@ -729,6 +753,8 @@ func (h *Host) managedAddRenewedStorageObligation(oldSO, newSO storageObligation
 	// Update the metrics.
 	h.updateFinancialMetricsUpdateSO(oldSOBefore, oldSO)
 	h.updateFinancialMetricsAddSO(newSO)
+	h.staticRenterUsage.managedUpdateContract(oldSO)
+	h.staticRenterUsage.managedUpdateContract(newSO)
 	h.mu.Unlock()
 
 	// Check that the transaction is fully valid and submit it to the
@@ -748,6 +774,7 @@ func (h *Host) managedAddRenewedStorageObligation(oldSO, newSO storageObligation
 			return putStorageObligation(tx, oldSOBefore)
 		})
 		h.updateFinancialMetricsUpdateSO(oldSO, oldSOBefore)
+		h.staticRenterUsage.managedUpdateContract(oldSOBefore)
 		err3 := h.removeStorageObligation(newSO, obligationRejected)
 		h.log.Println("Failed to add storage obligation, transaction set was not accepted:", errors.Compose(err, err2, err3))
 		return err
@@ -875,6 +902,11 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 	if so.expiration()-revisionSubmissionBuffer <= hostHeight {
 		return errNoBuffer
 	}
+	// Check that the renter doesn't exceed its sector quota.
+	maxSectors := h.managedInternalSettings().MaxRenterSectors
+	if err := h.staticRenterUsage.managedCheckSectorQuota(so, maxSectors); err != nil {
+		return err
+	}
 
 	// Note, for safe error handling, the operation order should be: add
 	// sectors, update database, remove sectors. If the adding or update fails,
@@ -938,8 +970,10 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 		_ = h.RemoveSector(sectorsRemoved[k])
 	}
 
-	// Update the financial information for the storage obligation
+	// Update the financial information for the storage obligation and the
+	// usage of its renter.
 	h.updateFinancialMetricsUpdateSO(oldSO, so)
+	h.staticRenterUsage.managedUpdateContract(so)
 	return nil
 }
 
@@ -1067,6 +1101,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
+	h.staticRenterUsage.managedRemoveContract(so.id())
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
@@ -1456,13 +1491,12 @@ func (h *Host) managedBuildStorageProof(so storageObligation, segmentIndex uint6
 	return sp, nil
 }
 
-// StorageObligations fetches the set of storage obligations in the host and
-// returns metadata on them.
-func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
+// managedStorageObligations returns all of the host's storage obligations.
+func (h *Host) managedStorageObligations() (sos []storageObligation, err error) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	err := h.db.View(func(tx *bolt.Tx) error {
+	err = h.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketStorageObligations)
 		err := b.ForEach(func(idBytes, soBytes []byte) error {
 			var so storageObligation
@@ -1470,8 +1504,7 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 			if err != nil {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
-
-			sos = append(sos, so.StorageObligation())
+			sos = append(sos, so)
 			return nil
 		})
 		if err != nil {
@@ -1479,10 +1512,22 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 		}
 		return nil
 	})
+	return
+}
+
+// StorageObligations fetches the set of storage obligations in the host and
+// returns metadata on them, including whether their storage proofs were at
+// risk during the last check.
+func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
+	obligations, err := h.managedStorageObligations()
 	if err != nil {
 		h.log.Println(build.ExtendErr("database failed to provide storage obligations:", err))
 	}
-
+	for _, so := range obligations {
+		mso := so.StorageObligation()
+		mso.Risk = h.managedObligationRisk(so.id())
+		sos = append(sos, mso)
+	}
 	return sos
 }
 
@@ -1493,6 +1538,7 @@ func (h *Host) StorageObligation(obligationID types.FileContractID) (modules.Sto
 	if err != nil {
 		return modules.StorageObligation{}, errors.AddContext(err, "failed to fetch storage obligation")
 	}
-
-	return so.StorageObligation(), nil
+	mso := so.StorageObligation()
+	mso.Risk = h.managedObligationRisk(obligationID)
+	return mso, nil
 }
@@ -30,6 +30,11 @@ type BlockManager interface {
 	// BlocksMined returns the number of blocks and stale blocks that have been
 	// mined using this miner.
 	BlocksMined() (goodBlocks, staleBlocks int)
+
+	// BlockRewards returns the block rewards and miner fees of the blocks that
+	// have been mined using this miner and are part of the current chain,
+	// split by maturity state.
+	BlockRewards() (MinerAccounting, error)
 }
 
 // CPUMiner provides access to a single-threaded cpu miner.
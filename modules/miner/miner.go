@@ -284,3 +284,35 @@ func (m *Miner) BlocksMined() (goodBlocks, staleBlocks int) {
 	}
 	return
 }
+
+// BlockRewards returns the block rewards and miner fees of the blocks that have
+// been mined by the miner and are part of the current chain, split by maturity
+// state. Stale blocks are ignored since their payouts will never be spendable.
+func (m *Miner) BlockRewards() (ma modules.MinerAccounting, err error) {
+	if err = m.tg.Add(); err != nil {
+		return
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	blocksFound := append([]types.BlockID(nil), m.persist.BlocksFound...)
+	m.mu.Unlock()
+
+	currentHeight := m.cs.Height()
+	for _, blockID := range blocksFound {
+		b, height, exists := m.cs.BlockByID(blockID)
+		if !exists || !m.cs.InCurrentPath(blockID) {
+			continue
+		}
+		coinbase := types.CalculateCoinbase(height)
+		fees := b.CalculateSubsidy(height).Sub(coinbase)
+		if currentHeight >= height+types.MaturityDelay {
+			ma.MatureBlockRewards = ma.MatureBlockRewards.Add(coinbase)
+			ma.MatureFees = ma.MatureFees.Add(fees)
+		} else {
+			ma.ImmatureBlockRewards = ma.ImmatureBlockRewards.Add(coinbase)
+			ma.ImmatureFees = ma.ImmatureFees.Add(fees)
+		}
+	}
+	return
+}
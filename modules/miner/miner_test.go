@@ -3,6 +3,7 @@ package miner
 import (
 	"bytes"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 	"unsafe"
@@ -235,6 +236,69 @@ func TestIntegrationBlocksMined(t *testing.T) {
 	}
 }
 
+// TestIntegrationBlockRewards checks that the BlockRewards function correctly
+// reports the rewards of mined blocks by maturity state.
+func TestIntegrationBlockRewards(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks added through AddBlock are not tracked by the miner.
+	ma, err := mt.miner.BlockRewards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ma, modules.MinerAccounting{}) {
+		t.Fatal("expected empty rewards", ma)
+	}
+
+	// Submit a solved header.
+	header, target, err := mt.miner.HeaderForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header = solveHeader(header, target)
+	err = mt.miner.SubmitHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coinbase := types.CalculateCoinbase(mt.cs.Height())
+
+	// The block reward should be immature.
+	ma, err = mt.miner.BlockRewards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ma.ImmatureBlockRewards.Equals(coinbase) {
+		t.Fatalf("expected immature rewards to be %v, got %v", coinbase, ma.ImmatureBlockRewards)
+	}
+	if !ma.MatureBlockRewards.IsZero() {
+		t.Fatal("expected no mature rewards", ma.MatureBlockRewards)
+	}
+
+	// Mine until the block is mature.
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		_, err = mt.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ma, err = mt.miner.BlockRewards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ma.MatureBlockRewards.Equals(coinbase) {
+		t.Fatalf("expected mature rewards to be %v, got %v", coinbase, ma.MatureBlockRewards)
+	}
+	if !ma.ImmatureBlockRewards.IsZero() {
+		t.Fatal("expected no immature rewards", ma.ImmatureBlockRewards)
+	}
+}
+
 // TestIntegrationAutoRescan triggers a rescan during a call to New and
 // verifies that the rescanning happens correctly. The rescan is triggered by
 // a call to New, instead of getting called directly.
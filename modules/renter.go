@@ -346,38 +346,42 @@ type DirectoryInfo struct {
 	// The following fields are aggregate values of the siadir. These values are
 	// the totals of the siadir and any sub siadirs, or are calculated based on
 	// all the values in the subtree
-	AggregateHealth              float64   `json:"aggregatehealth"`
-	AggregateLastHealthCheckTime time.Time `json:"aggregatelasthealthchecktime"`
-	AggregateMaxHealth           float64   `json:"aggregatemaxhealth"`
-	AggregateMaxHealthPercentage float64   `json:"aggregatemaxhealthpercentage"`
-	AggregateMinRedundancy       float64   `json:"aggregateminredundancy"`
-	AggregateMostRecentModTime   time.Time `json:"aggregatemostrecentmodtime"`
-	AggregateNumFiles            uint64    `json:"aggregatenumfiles"`
-	AggregateNumStuckChunks      uint64    `json:"aggregatenumstuckchunks"`
-	AggregateNumSubDirs          uint64    `json:"aggregatenumsubdirs"`
-	AggregateRepairSize          uint64    `json:"aggregaterepairsize"`
-	AggregateSize                uint64    `json:"aggregatesize"`
-	AggregateStuckHealth         float64   `json:"aggregatestuckhealth"`
-	AggregateStuckSize           uint64    `json:"aggregatestucksize"`
+	AggregateDedupSavings        uint64       `json:"aggregatededupsavings"`
+	AggregateHealth              float64      `json:"aggregatehealth"`
+	AggregateLastHealthCheckTime time.Time    `json:"aggregatelasthealthchecktime"`
+	AggregateMaxHealth           float64      `json:"aggregatemaxhealth"`
+	AggregateMaxHealthPercentage float64      `json:"aggregatemaxhealthpercentage"`
+	AggregateMinRedundancy       float64      `json:"aggregateminredundancy"`
+	AggregateMostRecentModTime   time.Time    `json:"aggregatemostrecentmodtime"`
+	AggregateNumFiles            uint64       `json:"aggregatenumfiles"`
+	AggregateNumStuckChunks      uint64       `json:"aggregatenumstuckchunks"`
+	AggregateNumSubDirs          uint64       `json:"aggregatenumsubdirs"`
+	AggregateRepairSize          uint64       `json:"aggregaterepairsize"`
+	AggregateSize                uint64       `json:"aggregatesize"`
+	AggregateSpending            FileSpending `json:"aggregatespending"`
+	AggregateStuckHealth         float64      `json:"aggregatestuckhealth"`
+	AggregateStuckSize           uint64       `json:"aggregatestucksize"`
 
 	// The following fields are information specific to the siadir that is not
 	// an aggregate of the entire sub directory tree
-	Health              float64     `json:"health"`
-	LastHealthCheckTime time.Time   `json:"lasthealthchecktime"`
-	MaxHealthPercentage float64     `json:"maxhealthpercentage"`
-	MaxHealth           float64     `json:"maxhealth"`
-	MinRedundancy       float64     `json:"minredundancy"`
-	DirMode             os.FileMode `json:"mode,siamismatch"` // Field is called DirMode for fuse compatibility
-	MostRecentModTime   time.Time   `json:"mostrecentmodtime"`
-	NumFiles            uint64      `json:"numfiles"`
-	NumStuckChunks      uint64      `json:"numstuckchunks"`
-	NumSubDirs          uint64      `json:"numsubdirs"`
-	RepairSize          uint64      `json:"repairsize"`
-	SiaPath             SiaPath     `json:"siapath"`
-	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
-	StuckHealth         float64     `json:"stuckhealth"`
-	StuckSize           uint64      `json:"stucksize"`
-	UID                 uint64      `json:"uid"`
+	DedupSavings        uint64       `json:"dedupsavings"`
+	Health              float64      `json:"health"`
+	LastHealthCheckTime time.Time    `json:"lasthealthchecktime"`
+	MaxHealthPercentage float64      `json:"maxhealthpercentage"`
+	MaxHealth           float64      `json:"maxhealth"`
+	MinRedundancy       float64      `json:"minredundancy"`
+	DirMode             os.FileMode  `json:"mode,siamismatch"` // Field is called DirMode for fuse compatibility
+	MostRecentModTime   time.Time    `json:"mostrecentmodtime"`
+	NumFiles            uint64       `json:"numfiles"`
+	NumStuckChunks      uint64       `json:"numstuckchunks"`
+	NumSubDirs          uint64       `json:"numsubdirs"`
+	RepairSize          uint64       `json:"repairsize"`
+	SiaPath             SiaPath      `json:"siapath"`
+	DirSize             uint64       `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
+	Spending            FileSpending `json:"spending"`
+	StuckHealth         float64      `json:"stuckhealth"`
+	StuckSize           uint64       `json:"stucksize"`
+	UID                 uint64       `json:"uid"`
 }
 
 // Name implements os.FileInfo.
@@ -416,6 +420,30 @@ type DownloadInfo struct {
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
 }
 
+// FileSpending contains the money that has been spent on behalf of a file, or
+// on behalf of all the files within a directory.
+type FileSpending struct {
+	DownloadSpending types.Currency `json:"downloadspending"` // Spent on downloads of the file.
+	RepairSpending   types.Currency `json:"repairspending"`   // Spent on downloads that were necessary to repair the file.
+	StorageSpending  types.Currency `json:"storagespending"`  // Spent on storing the file's sectors.
+	UploadSpending   types.Currency `json:"uploadspending"`   // Spent on upload bandwidth for the file's sectors.
+}
+
+// Add returns the sum of two FileSpendings.
+func (fs FileSpending) Add(other FileSpending) FileSpending {
+	return FileSpending{
+		DownloadSpending: fs.DownloadSpending.Add(other.DownloadSpending),
+		RepairSpending:   fs.RepairSpending.Add(other.RepairSpending),
+		StorageSpending:  fs.StorageSpending.Add(other.StorageSpending),
+		UploadSpending:   fs.UploadSpending.Add(other.UploadSpending),
+	}
+}
+
+// Total returns the total amount of money spent.
+func (fs FileSpending) Total() types.Currency {
+	return fs.DownloadSpending.Add(fs.RepairSpending).Add(fs.StorageSpending).Add(fs.UploadSpending)
+}
+
 // FileUploadParams contains the information used by the Renter to upload a
 // file.
 type FileUploadParams struct {
@@ -427,13 +455,38 @@ type FileUploadParams struct {
 	Repair              bool
 
 	// CipherType was added later. If it is left blank, the renter will use the
-	// default encryption method (as of writing, Threefish)
+	// default cipher type of the renter's settings (Threefish unless changed)
 	CipherType crypto.CipherType
 
 	// CipherKey was added in v1.5.0. If it is left blank, the renter will use it
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// Dedup enables dedup mode for the upload. Chunks of a file in dedup mode
+	// reference the pieces of previously uploaded chunks with the same
+	// content instead of uploading them again. It can't be combined with
+	// CipherKey.
+	Dedup bool
+}
+
+// PackedUploadFile is a single file of a packed upload.
+type PackedUploadFile struct {
+	Source  string  `json:"source"`
+	SiaPath SiaPath `json:"siapath"`
+}
+
+// PackedUploadParams contains the information used by the Renter to upload
+// multiple small files which are packed into shared chunks.
+type PackedUploadParams struct {
+	Files       []PackedUploadFile
+	ErasureCode ErasureCoder
+	Force       bool
+
+	// CipherType is the cipher type used to encrypt the shared chunks. If it
+	// is left blank, the renter will use the default cipher type of the
+	// renter's settings.
+	CipherType crypto.CipherType
 }
 
 // FileInfo provides information about a file.
@@ -443,6 +496,7 @@ type FileInfo struct {
 	ChangeTime       time.Time         `json:"changetime"`
 	CipherType       string            `json:"ciphertype"`
 	CreateTime       time.Time         `json:"createtime"`
+	DedupSavings     uint64            `json:"dedupsavings"`
 	Expiration       types.BlockHeight `json:"expiration"`
 	Filesize         uint64            `json:"filesize"`
 	Health           float64           `json:"health"`
@@ -459,6 +513,7 @@ type FileInfo struct {
 	RepairBytes      uint64            `json:"repairbytes"`
 	Skylinks         []string          `json:"skylinks"`
 	SiaPath          SiaPath           `json:"siapath"`
+	Spending         FileSpending      `json:"spending"`
 	Stuck            bool              `json:"stuck"`
 	StuckBytes       uint64            `json:"stuckbytes"`
 	StuckHealth      float64           `json:"stuckhealth"`
@@ -595,6 +650,19 @@ type MountInfo struct {
 	MountOptions MountOptions `json:"mountoptions"`
 }
 
+// RekeyInfo contains information about re-encrypting a file under a new
+// master key. Error is set if the re-encryption failed.
+type RekeyInfo struct {
+	SiaPath    SiaPath   `json:"siapath"`
+	CipherType string    `json:"ciphertype"`
+	Size       uint64    `json:"size"`
+	Processed  uint64    `json:"processed"`
+	StartTime  time.Time `json:"starttime"`
+	EndTime    time.Time `json:"endtime"`
+	Finished   bool      `json:"finished"`
+	Error      string    `json:"error"`
+}
+
 // RenterPriceEstimation contains a bunch of files estimating the costs of
 // various operations on the network.
 type RenterPriceEstimation struct {
@@ -614,11 +682,12 @@ type RenterPriceEstimation struct {
 
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
-	Allowance        Allowance     `json:"allowance"`
-	IPViolationCheck bool          `json:"ipviolationcheck"`
-	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
-	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
-	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+	Allowance         Allowance     `json:"allowance"`
+	DefaultCipherType string        `json:"defaultciphertype"`
+	IPViolationCheck  bool          `json:"ipviolationcheck"`
+	MaxUploadSpeed    int64         `json:"maxuploadspeed"`
+	MaxDownloadSpeed  int64         `json:"maxdownloadspeed"`
+	UploadsStatus     UploadsStatus `json:"uploadsstatus"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -1083,6 +1152,15 @@ type Renter interface {
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// RekeyFile starts re-encrypting the file at siaPath under a new master
+	// key of the provided cipher type in the background. If the cipher type
+	// is left blank, the file's current cipher type is used.
+	RekeyFile(siaPath SiaPath, ct crypto.CipherType) error
+
+	// RekeyInfo returns the status of the files which are being re-encrypted
+	// and of the files which were re-encrypted since the renter started.
+	RekeyInfo() []RekeyInfo
+
 	// SetFileStuck sets the 'stuck' status of a file.
 	SetFileStuck(siaPath SiaPath, stuck bool) error
 
@@ -1156,6 +1234,10 @@ type Renter interface {
 	// RenameFile changes the path of a file.
 	RenameFile(siaPath, newSiaPath SiaPath) error
 
+	// ReplaceFile moves the file at srcSiaPath to siaPath. An existing file
+	// at siaPath is atomically replaced and deleted.
+	ReplaceFile(siaPath, srcSiaPath SiaPath) error
+
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath SiaPath) error
 
@@ -1169,6 +1251,11 @@ type Renter interface {
 	// used.
 	ReadRegistry(spk types.SiaPublicKey, tweak crypto.Hash, timeout time.Duration) (SignedRegistryValue, error)
 
+	// WatchRegistry blocks until a value with a revision number of at least
+	// minRevision is known for a registry entry and returns it. The hosts
+	// notify the renter about updates of the entry while it is watched.
+	WatchRegistry(spk types.SiaPublicKey, tweak crypto.Hash, minRevision uint64, timeout time.Duration) (SignedRegistryValue, error)
+
 	// ScoreBreakdown will return the score for a host db entry using the
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) (HostScoreBreakdown, error)
@@ -1202,6 +1289,10 @@ type Renter interface {
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
 
+	// UploadPacked uploads multiple small files by packing them into shared
+	// chunks.
+	UploadPacked(PackedUploadParams) error
+
 	// UploadStreamFromReader reads from the provided reader until io.EOF is
 	// reached and upload the data to the Sia network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
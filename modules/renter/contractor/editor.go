@@ -19,8 +19,9 @@ var errInvalidEditor = errors.New("editor has been invalidated because its contr
 // Editors are the means by which the renter uploads data to hosts.
 type Editor interface {
 	// Upload revises the underlying contract to store the new data. It
-	// returns the Merkle root of the data.
-	Upload(data []byte) (root crypto.Hash, err error)
+	// returns the Merkle root of the data and the storage and upload spending
+	// that was added to the contract by the revision.
+	Upload(data []byte) (root crypto.Hash, spending modules.FileSpending, err error)
 
 	// Address returns the address of the host.
 	Address() modules.NetAddress
@@ -105,19 +106,21 @@ func (he *hostEditor) HostSettings() modules.HostExternalSettings {
 }
 
 // Upload negotiates a revision that adds a sector to a file contract.
-func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
+func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, _ modules.FileSpending, err error) {
 	he.mu.Lock()
 	defer he.mu.Unlock()
 	if he.invalid {
-		return crypto.Hash{}, errInvalidEditor
+		return crypto.Hash{}, modules.FileSpending{}, errInvalidEditor
 	}
 
-	// Perform the upload.
-	_, sectorRoot, err := he.editor.Upload(data)
+	// Perform the upload. The spending is taken from the revision itself so
+	// that concurrent uploads to the same contract aren't charged to this
+	// upload.
+	_, sectorRoot, spending, err := he.editor.Upload(data)
 	if err != nil {
-		return crypto.Hash{}, err
+		return crypto.Hash{}, modules.FileSpending{}, err
 	}
-	return sectorRoot, nil
+	return sectorRoot, spending, nil
 }
 
 // Editor returns a Editor object that can be used to upload, modify, and
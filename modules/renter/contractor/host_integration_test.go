@@ -379,7 +379,7 @@ func TestIntegrationReviseContract(t *testing.T) {
 		t.Fatal(err)
 	}
 	data := fastrand.Bytes(int(modules.SectorSize))
-	_, err = editor.Upload(data)
+	_, spending, err := editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -387,6 +387,18 @@ func TestIntegrationReviseContract(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// the returned spending should match the spending of the contract
+	contract, ok = c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if spending.StorageSpending.IsZero() || !spending.StorageSpending.Equals(contract.StorageSpending) {
+		t.Fatalf("storage spending mismatch: %v != %v", spending.StorageSpending, contract.StorageSpending)
+	}
+	if spending.UploadSpending.IsZero() || !spending.UploadSpending.Equals(contract.UploadSpending) {
+		t.Fatalf("upload spending mismatch: %v != %v", spending.UploadSpending, contract.UploadSpending)
+	}
 }
 
 // TestIntegrationUploadDownload tests that the contractor can upload data to
@@ -430,7 +442,7 @@ func TestIntegrationUploadDownload(t *testing.T) {
 		t.Fatal(err)
 	}
 	data := fastrand.Bytes(int(modules.SectorSize))
-	root, err := editor.Upload(data)
+	root, _, err := editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -513,7 +525,7 @@ func TestIntegrationRenew(t *testing.T) {
 	}
 	data := fastrand.Bytes(int(modules.SectorSize))
 	// insert the sector
-	root, err := editor.Upload(data)
+	root, _, err := editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -577,7 +589,7 @@ func TestIntegrationRenew(t *testing.T) {
 	}
 	data = fastrand.Bytes(int(modules.SectorSize))
 	// insert the sector
-	_, err = editor.Upload(data)
+	_, _, err = editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -51,8 +51,9 @@ type Session interface {
 	Settings() (modules.HostExternalSettings, error)
 
 	// Upload revises the underlying contract to store the new data. It
-	// returns the Merkle root of the data.
-	Upload(data []byte) (crypto.Hash, error)
+	// returns the Merkle root of the data and the storage and upload spending
+	// that was added to the contract by the revision.
+	Upload(data []byte) (crypto.Hash, modules.FileSpending, error)
 }
 
 // A hostSession modifies a Contract via the renter-host RPC loop. It
@@ -160,21 +161,23 @@ func (hs *hostSession) DownloadIndex(index uint64, offset, length uint32) ([]byt
 func (hs *hostSession) EndHeight() types.BlockHeight { return hs.endHeight }
 
 // Upload negotiates a revision that adds a sector to a file contract.
-func (hs *hostSession) Upload(data []byte) (crypto.Hash, error) {
+func (hs *hostSession) Upload(data []byte) (crypto.Hash, modules.FileSpending, error) {
 	hs.mu.Lock()
 	defer hs.mu.Unlock()
 	if hs.invalid {
-		return crypto.Hash{}, errInvalidSession
+		return crypto.Hash{}, modules.FileSpending{}, errInvalidSession
 	}
 
-	// Perform the upload.
-	_, sectorRoot, err := hs.session.Append(data)
+	// Perform the upload. The spending is taken from the revision itself so
+	// that concurrent uploads to the same contract aren't charged to this
+	// upload.
+	_, sectorRoot, spending, err := hs.session.Append(data)
 	if err != nil {
 		// Return the sector root so that it can be logged and used for
 		// debugging in the event of an error.
-		return sectorRoot, err
+		return sectorRoot, modules.FileSpending{}, err
 	}
-	return sectorRoot, nil
+	return sectorRoot, spending, nil
 }
 
 // Replace replaces the sector at the specified index with data.
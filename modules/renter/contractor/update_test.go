@@ -80,7 +80,7 @@ func TestIntegrationAutoRenew(t *testing.T) {
 
 	data := fastrand.Bytes(int(modules.SectorSize))
 	// insert the sector
-	_, err = editor.Upload(data)
+	_, _, err = editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -175,7 +175,7 @@ func TestIntegrationRenewInvalidate(t *testing.T) {
 	}
 	data := fastrand.Bytes(int(modules.SectorSize))
 	// insert the sector
-	_, err = editor.Upload(data)
+	_, _, err = editor.Upload(data)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -203,7 +203,7 @@ func TestIntegrationRenewInvalidate(t *testing.T) {
 	c.mu.Unlock()
 
 	// editor should have been invalidated
-	_, err = editor.Upload(make([]byte, modules.SectorSize))
+	_, _, err = editor.Upload(make([]byte, modules.SectorSize))
 	if !errors.Contains(err, errInvalidEditor) && !errors.Contains(err, errInvalidSession) {
 		t.Error("expected invalid editor error; got", err)
 	}
@@ -281,7 +281,7 @@ func TestWatchdogRevisionCheck(t *testing.T) {
 			t.Fatal(err)
 		}
 		data := fastrand.Bytes(int(modules.SectorSize))
-		_, err = editor.Upload(data)
+		_, _, err = editor.Upload(data)
 		if err != nil {
 			t.Fatal(err)
 		}
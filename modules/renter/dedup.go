@@ -0,0 +1,379 @@
+package renter
+
+// dedup.go implements the dedup mode of uploads. The chunks of a file in dedup
+// mode are identified by a DedupID which is a keyed hash of their plaintext
+// data. The pieces of a chunk are encrypted using a key derived from the
+// DedupID and the master key of the encryption domain, which means that two
+// chunks with the same content produce the same pieces. The renter keeps an
+// index of the pieces of all deduplicated chunks and a chunk that is already
+// stored on the network references the existing pieces instead of uploading
+// them again.
+//
+// An encryption domain consists of all the files in dedup mode which use the
+// same cipher type. The files share the master key of the domain, which is
+// derived from the wallet seed, so only chunks of files of the same domain and
+// erasure code settings are deduplicated.
+//
+// The index keeps track of how many chunks reference each entry. The count is
+// incremented whenever a chunk is assigned a DedupID and decremented when the
+// chunk's file is deleted. Entries that are no longer referenced are removed.
+// Sectors aren't deleted from hosts when files are deleted, so the count only
+// determines how long the renter offers an entry's pieces to new uploads.
+//
+// NOTE: deduplication happens at the granularity of whole chunks. Chunks only
+// match if their data is aligned the same way within the files.
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// dedupFilename is the name of the database which persists the dedup
+	// index.
+	dedupFilename = "dedup.db"
+)
+
+var (
+	// dedupMetadata is the header of the dedup index's database.
+	dedupMetadata = persist.Metadata{
+		Header:  "Renter Dedup Index",
+		Version: "1.0",
+	}
+
+	// bucketDedupChunks maps the DedupIDs of deduplicated chunks to their
+	// entries.
+	bucketDedupChunks = []byte("chunks")
+
+	// dedupKeySpecifier is the specifier used for deriving the master keys of
+	// the encryption domains from the RenterSeed.
+	dedupKeySpecifier = types.NewSpecifier("dedup")
+)
+
+type (
+	// dedupIndex keeps track of the pieces of the deduplicated chunks. Every
+	// change only updates the affected entry and concurrent changes are
+	// batched into a single transaction.
+	dedupIndex struct {
+		staticDB *persist.BoltDatabase
+	}
+
+	// dedupChunk is an entry of the dedup index.
+	dedupChunk struct {
+		Pieces []dedupPiece
+		Refs   uint64
+	}
+
+	// dedupPiece is a piece of a deduplicated chunk.
+	dedupPiece struct {
+		HostPubKey types.SiaPublicKey
+		MerkleRoot crypto.Hash
+		PieceIndex uint64
+	}
+)
+
+// newDedupIndex opens the dedup index in the persist dir or creates a new
+// one.
+func newDedupIndex(persistDir string) (*dedupIndex, error) {
+	db, err := persist.OpenDatabase(dedupMetadata, filepath.Join(persistDir, dedupFilename))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open dedup index")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketDedupChunks)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "failed to initialize dedup index"), db.Close())
+	}
+	return &dedupIndex{staticDB: db}, nil
+}
+
+// Close closes the dedup index.
+func (di *dedupIndex) Close() error {
+	return di.staticDB.Close()
+}
+
+// getChunk returns the entry with the given id.
+func getChunk(tx *bolt.Tx, id siafile.DedupID) (entry dedupChunk, exists bool, err error) {
+	b := tx.Bucket(bucketDedupChunks).Get(id[:])
+	if b == nil {
+		return dedupChunk{}, false, nil
+	}
+	err = encoding.Unmarshal(b, &entry)
+	return entry, true, errors.AddContext(err, "failed to unmarshal dedup entry")
+}
+
+// putChunk stores the entry with the given id. Entries without references are
+// removed.
+func putChunk(tx *bolt.Tx, id siafile.DedupID, entry dedupChunk) error {
+	if entry.Refs == 0 {
+		return tx.Bucket(bucketDedupChunks).Delete(id[:])
+	}
+	return tx.Bucket(bucketDedupChunks).Put(id[:], encoding.Marshal(entry))
+}
+
+// managedAcquire adds a reference to the entry with the given id and returns
+// the pieces that are known for it. The entry is created if it doesn't exist.
+func (di *dedupIndex) managedAcquire(id siafile.DedupID) (pieces []dedupPiece, err error) {
+	err = di.staticDB.Batch(func(tx *bolt.Tx) error {
+		entry, _, err := getChunk(tx, id)
+		if err != nil {
+			return err
+		}
+		entry.Refs++
+		pieces = entry.Pieces
+		return putChunk(tx, id, entry)
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to update dedup index")
+	}
+	return pieces, nil
+}
+
+// managedAddPieces adds the pieces of a chunk to the entry with the given id.
+// Pieces which are already known are ignored.
+func (di *dedupIndex) managedAddPieces(id siafile.DedupID, pieces [][]siafile.Piece) error {
+	err := di.staticDB.Batch(func(tx *bolt.Tx) error {
+		entry, exists, err := getChunk(tx, id)
+		if err != nil || !exists {
+			// The entry is no longer referenced.
+			return err
+		}
+		known := make(map[string]struct{}, len(entry.Pieces))
+		for _, p := range entry.Pieces {
+			known[p.String()] = struct{}{}
+		}
+		n := len(entry.Pieces)
+		for pieceIndex, pieceSet := range pieces {
+			for _, piece := range pieceSet {
+				p := dedupPiece{
+					HostPubKey: piece.HostPubKey,
+					MerkleRoot: piece.MerkleRoot,
+					PieceIndex: uint64(pieceIndex),
+				}
+				if _, exists := known[p.String()]; exists {
+					continue
+				}
+				known[p.String()] = struct{}{}
+				entry.Pieces = append(entry.Pieces, p)
+			}
+		}
+		if len(entry.Pieces) == n {
+			return nil
+		}
+		return putChunk(tx, id, entry)
+	})
+	return errors.AddContext(err, "failed to update dedup index")
+}
+
+// managedRelease removes a reference from the entries with the given ids.
+// Entries without references are removed from the index.
+func (di *dedupIndex) managedRelease(ids []siafile.DedupID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := di.staticDB.Batch(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			entry, exists, err := getChunk(tx, id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			entry.Refs--
+			if err := putChunk(tx, id, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.AddContext(err, "failed to update dedup index")
+}
+
+// managedRefs returns the number of references of the entry with the given
+// id.
+func (di *dedupIndex) managedRefs(id siafile.DedupID) (refs uint64, err error) {
+	err = di.staticDB.View(func(tx *bolt.Tx) error {
+		entry, _, err := getChunk(tx, id)
+		refs = entry.Refs
+		return err
+	})
+	return
+}
+
+// String returns a string which uniquely identifies the piece.
+func (p dedupPiece) String() string {
+	return fmt.Sprintf("%v:%v:%v", p.HostPubKey, p.MerkleRoot, p.PieceIndex)
+}
+
+// staticDedupDomainKey derives the master key of the encryption domain of the
+// given cipher type from the renter seed.
+func staticDedupDomainKey(rs modules.RenterSeed, ct crypto.CipherType) (crypto.CipherKey, error) {
+	if !crypto.IsValidCipherType(ct) {
+		return nil, crypto.ErrInvalidCipherType
+	}
+	// Expand the seed into as much entropy as a key of the cipher type
+	// requires.
+	entropySize := len(crypto.GenerateSiaKey(ct).Key())
+	var entropy []byte
+	for i := uint64(0); len(entropy) < entropySize; i++ {
+		h := crypto.HashAll(rs, dedupKeySpecifier, ct, i)
+		entropy = append(entropy, h[:]...)
+		fastrand.Read(h[:])
+	}
+	defer fastrand.Read(entropy)
+	return crypto.NewSiaKey(ct, entropy[:entropySize])
+}
+
+// managedDedupDomainKey returns the master key of the encryption domain of the
+// given cipher type. The key is derived from the wallet seed and never
+// persisted.
+func (r *Renter) managedDedupDomainKey(ct crypto.CipherType) (crypto.CipherKey, error) {
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	return staticDedupDomainKey(rs, ct)
+}
+
+// staticDedupID computes the DedupID of a chunk from the master key of its
+// encryption domain, its erasure code and its data pieces.
+func staticDedupID(masterKey crypto.CipherKey, ec modules.ErasureCoder, dataPieces [][]byte) (id siafile.DedupID) {
+	h := crypto.NewHash()
+	_, _ = h.Write(masterKey.Key())
+	_, _ = h.Write([]byte(ec.Identifier()))
+	for _, piece := range dataPieces {
+		_, _ = h.Write(piece)
+	}
+	copy(id[:], h.Sum(nil))
+	return
+}
+
+// managedDedupChunk assigns a DedupID to a chunk of a file in dedup mode
+// after its logical data was fetched and before it is encrypted. If the index
+// knows pieces of a chunk with the same id, they are added to the file and
+// marked as completed instead of being uploaded again. Chunks which already
+// have a DedupID are left untouched.
+func (r *Renter) managedDedupChunk(uc *unfinishedUploadChunk) error {
+	if !uc.fileEntry.Dedup() {
+		return nil
+	}
+	id, err := uc.fileEntry.DedupID(uc.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "failed to get dedup id")
+	}
+	if !id.IsZero() {
+		return nil
+	}
+
+	// Compute the id and reference the chunk in the index before setting the
+	// id to make sure the reference is released when the file is deleted.
+	ec := uc.fileEntry.ErasureCode()
+	id = staticDedupID(uc.fileEntry.MasterKey(), ec, uc.logicalChunkData[:ec.MinPieces()])
+	pieces, err := r.staticDedupIndex.managedAcquire(id)
+	if err != nil {
+		return err
+	}
+	if err := uc.fileEntry.SetDedupID(uc.staticIndex, id); err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to set dedup id"), r.staticDedupIndex.managedRelease([]siafile.DedupID{id}))
+	}
+
+	// Reference the known pieces. Only pieces on hosts that can still be used
+	// for the chunk are considered, and a host never stores more than one
+	// piece of a chunk.
+	uc.mu.Lock()
+	var added int
+	for _, p := range pieces {
+		hpk := p.HostPubKey.String()
+		if p.PieceIndex >= uint64(len(uc.pieceUsage)) || uc.pieceUsage[p.PieceIndex] {
+			continue
+		}
+		if _, unused := uc.unusedHosts[hpk]; !unused {
+			continue
+		}
+		if err := uc.fileEntry.AddPiece(p.HostPubKey, uc.staticIndex, p.PieceIndex, p.MerkleRoot); err != nil {
+			r.log.Printf("WARN: failed to add deduplicated piece %v of chunk %v of %v: %v", p.PieceIndex, uc.staticIndex, uc.staticSiaPath, err)
+			continue
+		}
+		delete(uc.unusedHosts, hpk)
+		uc.pieceUsage[p.PieceIndex] = true
+		uc.piecesCompleted++
+		uc.logicalChunkData[p.PieceIndex] = nil
+		added++
+	}
+	memory := uint64(added) * modules.SectorSize
+	uc.memoryReleased += memory
+	uc.mu.Unlock()
+
+	if added > 0 {
+		uc.staticMemoryManager.Return(memory)
+		if err := uc.fileEntry.AddDedupSavings(memory); err != nil {
+			r.log.Printf("WARN: failed to add dedup savings to %v: %v", uc.staticSiaPath, err)
+		}
+		r.repairLog.Printf("Deduplicated %v pieces of chunk %v of %s", added, uc.staticIndex, uc.staticSiaPath)
+	}
+	return nil
+}
+
+// managedUpdateDedupIndex adds the pieces of a deduplicated chunk to the dedup
+// index after the chunk was uploaded or repaired.
+func (r *Renter) managedUpdateDedupIndex(uc *unfinishedUploadChunk) error {
+	if !uc.fileEntry.Dedup() {
+		return nil
+	}
+	id, err := uc.fileEntry.DedupID(uc.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "failed to get dedup id")
+	}
+	if id.IsZero() {
+		return nil
+	}
+	pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "failed to get pieces")
+	}
+	return r.staticDedupIndex.managedAddPieces(id, pieces)
+}
+
+// managedReleaseDedupIDs releases the references of deduplicated chunks of
+// deleted files. The ids have to be collected before the deletion using
+// FileSystem.DedupIDs.
+func (r *Renter) managedReleaseDedupIDs(ids []siafile.DedupID) {
+	if err := r.staticDedupIndex.managedRelease(ids); err != nil {
+		r.log.Println("WARN: failed to release deduplicated chunks:", err)
+	}
+}
+
+// managedOpenNewSiaFile opens a newly created file and puts it into dedup mode
+// if necessary. The file is deleted again if that fails.
+func (r *Renter) managedOpenNewSiaFile(siaPath modules.SiaPath, dedup bool) (*filesystem.FileNode, error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, errors.AddContext(err, "could not open the new sia file")
+	}
+	if !dedup {
+		return entry, nil
+	}
+	if err := entry.SetDedup(); err != nil {
+		err = errors.AddContext(err, "could not enable dedup mode")
+		return nil, errors.Compose(err, entry.Close(), r.staticFileSystem.DeleteFile(siaPath))
+	}
+	return entry, nil
+}
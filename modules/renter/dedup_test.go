@@ -0,0 +1,165 @@
+package renter
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestDedupIndex tests the reference counting and persistence of the dedup
+// index and the derivation of the domain keys.
+func TestDedupIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(testdir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	di, err := newDedupIndex(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := di.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The domain key should be derived from the renter seed.
+	var rs modules.RenterSeed
+	fastrand.Read(rs[:])
+	key, err := staticDedupDomainKey(rs, crypto.TypeThreefish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := staticDedupDomainKey(rs, crypto.TypeThreefish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Key(), key2.Key()) {
+		t.Fatal("domain key changed")
+	}
+	var otherSeed modules.RenterSeed
+	fastrand.Read(otherSeed[:])
+	otherSeedKey, err := staticDedupDomainKey(otherSeed, crypto.TypeThreefish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key.Key(), otherSeedKey.Key()) {
+		t.Fatal("different seeds should have different domain keys")
+	}
+	for _, ct := range []crypto.CipherType{crypto.TypePlain, crypto.TypeTwofish, crypto.TypeXChaCha20} {
+		k, err := staticDedupDomainKey(rs, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Type() != ct {
+			t.Fatal("wrong cipher type", k.Type())
+		}
+	}
+	otherKey, err := staticDedupDomainKey(rs, crypto.TypeXChaCha20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staticDedupDomainKey(rs, crypto.CipherType{}); err == nil {
+		t.Fatal("invalid cipher type should be rejected")
+	}
+
+	// The id of a chunk depends on its data and the domain.
+	ec := modules.NewRSSubCodeDefault()
+	data := make([][]byte, ec.MinPieces())
+	for i := range data {
+		data[i] = fastrand.Bytes(64)
+	}
+	id := staticDedupID(key, ec, data)
+	if id != staticDedupID(key2, ec, data) {
+		t.Fatal("same data should have the same id")
+	}
+	if id == staticDedupID(otherKey, ec, data) {
+		t.Fatal("different domains should have different ids")
+	}
+
+	// Acquire the id for the first time. There are no pieces yet.
+	pieces, err := di.managedAcquire(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 0 {
+		t.Fatal("new entry shouldn't have pieces", len(pieces))
+	}
+
+	// Add some pieces twice. They should only be added once.
+	hpk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(crypto.PublicKeySize)}
+	chunkPieces := make([][]siafile.Piece, ec.NumPieces())
+	chunkPieces[1] = []siafile.Piece{{HostPubKey: hpk, MerkleRoot: crypto.Hash{1}}}
+	for i := 0; i < 2; i++ {
+		if err := di.managedAddPieces(id, chunkPieces); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Acquire the id again. The pieces should be returned.
+	pieces, err = di.managedAcquire(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 1 || pieces[0].PieceIndex != 1 || !pieces[0].HostPubKey.Equals(hpk) {
+		t.Fatal("wrong pieces", pieces)
+	}
+	if refs, err := di.managedRefs(id); err != nil || refs != 2 {
+		t.Fatal("wrong number of refs", refs, err)
+	}
+
+	// Reload the index. The entries should be persisted.
+	if err := di.Close(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := di.managedRefs(id); err != nil || refs != 2 {
+		t.Fatal("refs weren't persisted", refs, err)
+	}
+
+	// Release the references. The entry should be removed after the last one.
+	if err := di.managedRelease([]siafile.DedupID{id}); err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := di.managedRefs(id); err != nil || refs != 1 {
+		t.Fatal("wrong number of refs", refs, err)
+	}
+	if err := di.managedRelease([]siafile.DedupID{id}); err != nil {
+		t.Fatal(err)
+	}
+	if refs, err := di.managedRefs(id); err != nil || refs != 0 {
+		t.Fatal("unreferenced entry should be removed", refs, err)
+	}
+
+	// Adding pieces to a removed entry should be a no-op.
+	if err := di.managedAddPieces(id, chunkPieces); err != nil {
+		t.Fatal(err)
+	}
+	err = di.staticDB.View(func(tx *bolt.Tx) error {
+		if _, exists, err := getChunk(tx, id); err != nil || exists {
+			return errors.Compose(err, errors.New("pieces shouldn't recreate entry"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -26,7 +26,18 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+
+	// Collect the references of the deduplicated chunks within the dir.
+	dedupIDs, err := r.staticFileSystem.DedupIDs(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get dedup ids of dir")
+	}
+	err = r.staticFileSystem.DeleteDir(siaPath)
+	if err != nil {
+		return err
+	}
+	r.managedReleaseDedupIDs(dedupIDs)
+	return nil
 }
 
 // DirList lists the directories in a siadir
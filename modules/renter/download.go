@@ -313,7 +313,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	}
 
 	// Prepare snapshot.
-	snap, err := entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
+	snap, err := r.managedFileSnapshotRange(entry, p.SiaPath, p.Offset, p.Length)
 	if err != nil {
 		return nil, err
 	}
@@ -475,7 +475,7 @@ func (d *download) Start() error {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
 			erasureCode: params.file.ErasureCode(),
-			masterKey:   params.file.MasterKey(),
+			masterKey:   params.file.ChunkMasterKey(i),
 
 			staticChunkIndex: i,
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticSiaPath, i),
@@ -11,6 +11,7 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
 )
 
 // downloadPieceInfo contains all the information required to download and
@@ -72,6 +73,13 @@ type unfinishedDownloadChunk struct {
 	workersRemaining  int       // Number of workers still able to fetch the chunk.
 	workersStandby    []*worker // Set of workers that are able to work on this download, but are not needed unless other workers fail.
 
+	// Spending state - need mutex to access. The cost of the downloaded
+	// pieces is attributed to the file once no worker is fetching a piece
+	// anymore, so that the file's metadata is usually only written once per
+	// chunk.
+	pendingSpending types.Currency // Cost of the downloaded pieces which isn't attributed to the file yet.
+	workersFetching int            // Number of workers that are fetching a piece.
+
 	// Memory management variables.
 	memoryAllocated uint64
 
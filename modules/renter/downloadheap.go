@@ -241,7 +241,9 @@ func (r *Renter) managedTryFetchChunkFromDisk(chunk *unfinishedDownloadChunk) bo
 		default:
 		}
 		// Fetch the chunk from disk.
-		sr := io.NewSectionReader(file, int64(chunk.staticChunkIndex*chunk.staticChunkSize)+int64(chunk.staticFetchOffset), int64(chunk.staticFetchLength))
+		// The fetch offset of a packed file is relative to its pack.
+		offset := chunk.staticChunkIndex*chunk.staticChunkSize + chunk.staticFetchOffset - chunk.renterFile.PackedOffset()
+		sr := io.NewSectionReader(file, int64(offset), int64(chunk.staticFetchLength))
 		pieces, _, err := readDataPieces(sr, chunk.renterFile.ErasureCode(), chunk.renterFile.PieceSize())
 		if err != nil {
 			r.log.Debugf("managedTryFetchChunkFromDisk failed to read data pieces from %v for %v: %v\n",
@@ -488,7 +488,7 @@ func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (_ st
 	}()
 
 	// Create the streamer
-	snap, err := node.Snapshot(siaPath)
+	snap, err := r.managedFileSnapshot(node, siaPath)
 	if err != nil {
 		return "", nil, err
 	}
@@ -507,7 +507,7 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 
 	// Grab the current SiaPath of the FileNode and then create a snapshot.
 	sp := r.staticFileSystem.FileSiaPath(node)
-	snap, err := node.Snapshot(sp)
+	snap, err := r.managedFileSnapshot(node, sp)
 	if err != nil {
 		return nil, err
 	}
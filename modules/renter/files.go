@@ -5,6 +5,8 @@ import (
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -18,11 +20,18 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// Collect the references of the file's deduplicated chunks.
+	dedupIDs, err := r.staticFileSystem.DedupIDs(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get dedup ids of siafile")
+	}
+
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
 	if err != nil {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
+	r.managedReleaseDedupIDs(dedupIDs)
 
 	// Update the filesystem metadata.
 	//
@@ -125,6 +134,52 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	return bubblePaths.callRefreshAll()
 }
 
+// ReplaceFile moves the file at srcSiaPath to siaPath. An existing file at
+// siaPath is atomically replaced and deleted.
+func (r *Renter) ReplaceFile(siaPath, srcSiaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedReplaceFile(siaPath, srcSiaPath)
+}
+
+// managedReplaceFile moves the file at srcSiaPath to siaPath. An existing file
+// at siaPath is atomically replaced and deleted. That way there is no point in
+// time at which neither the old nor the new file is available at siaPath.
+func (r *Renter) managedReplaceFile(siaPath, srcSiaPath modules.SiaPath) error {
+	// Try to move the file first. This only fails with ErrExists if there is
+	// a file or dir to replace.
+	err := r.staticFileSystem.RenameFile(srcSiaPath, siaPath)
+	if errors.Contains(err, filesystem.ErrExists) {
+		// Collect the references of the replaced file's deduplicated chunks.
+		var dedupIDs []siafile.DedupID
+		dedupIDs, err = r.staticFileSystem.DedupIDs(siaPath)
+		if err != nil {
+			return errors.AddContext(err, "unable to get dedup ids of replaced siafile")
+		}
+		err = r.staticFileSystem.ReplaceFile(siaPath, srcSiaPath)
+		if err == nil {
+			r.managedReleaseDedupIDs(dedupIDs)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Queue bubbles for the old and new directories, ignore the return
+	// channels as we do not want to block on these updates.
+	for _, sp := range []modules.SiaPath{srcSiaPath, siaPath} {
+		dirSiaPath, err := sp.Dir()
+		if err != nil {
+			r.log.Printf("Unable to fetch the directory from a siaPath %v for replaced siafile: %v", sp, err)
+			continue
+		}
+		_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	}
+	return nil
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
@@ -150,7 +205,7 @@ func (r *Renter) FileHosts(sp modules.SiaPath) (hosts []modules.HostDBEntry, _ e
 		return nil, build.ExtendErr(fmt.Sprintf("failed to open file %s", sp), err)
 	}
 	// take a snapshot of the file
-	snap, err := entry.Snapshot(sp)
+	snap, err := r.managedFileSnapshot(entry, sp)
 	if err != nil {
 		return nil, build.ExtendErr("failed to get snapshot", err)
 	}
@@ -578,6 +578,7 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 	maxHealth := math.Max(metadata.Health, metadata.StuckHealth)
 	return modules.DirectoryInfo{
 		// Aggregate Fields
+		AggregateDedupSavings:        metadata.AggregateDedupSavings,
 		AggregateHealth:              metadata.AggregateHealth,
 		AggregateLastHealthCheckTime: metadata.AggregateLastHealthCheckTime,
 		AggregateMaxHealth:           aggregateMaxHealth,
@@ -589,10 +590,12 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		AggregateNumSubDirs:          metadata.AggregateNumSubDirs,
 		AggregateRepairSize:          metadata.AggregateRepairSize,
 		AggregateSize:                metadata.AggregateSize,
+		AggregateSpending:            metadata.AggregateSpending,
 		AggregateStuckHealth:         metadata.AggregateStuckHealth,
 		AggregateStuckSize:           metadata.AggregateStuckSize,
 
 		// SiaDir Fields
+		DedupSavings:        metadata.DedupSavings,
 		Health:              metadata.Health,
 		LastHealthCheckTime: metadata.LastHealthCheckTime,
 		MaxHealth:           maxHealth,
@@ -605,6 +608,7 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		NumSubDirs:          metadata.NumSubDirs,
 		RepairSize:          metadata.RepairSize,
 		DirSize:             metadata.Size,
+		Spending:            metadata.Spending,
 		StuckHealth:         metadata.StuckHealth,
 		StuckSize:           metadata.StuckSize,
 		SiaPath:             siaPath,
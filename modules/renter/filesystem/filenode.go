@@ -120,6 +120,11 @@ func (n *FileNode) managedMode() os.FileMode {
 
 // managedFileInfo returns the FileInfo of the file node.
 func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]bool, goodForRenew map[string]bool, contracts map[string]modules.RenterContract) (modules.FileInfo, error) {
+	// The health of a packed file depends on its pack. It is only known from
+	// the cached values set by the repair code.
+	if n.IsPacked() {
+		return n.staticCachedInfo(siaPath)
+	}
 	// Build the FileInfo
 	var onDisk bool
 	localPath := n.LocalPath()
@@ -143,6 +148,7 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		ChangeTime:       n.ChangeTime(),
 		CipherType:       n.MasterKey().Type().String(),
 		CreateTime:       n.CreateTime(),
+		DedupSavings:     n.DedupSavings(),
 		Expiration:       n.Expiration(contracts),
 		Filesize:         n.Size(),
 		Health:           health,
@@ -157,6 +163,7 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		Renewing:         true,
 		RepairBytes:      repairBytes,
 		SiaPath:          siaPath,
+		Spending:         n.Spending(),
 		Stuck:            numStuckChunks > 0,
 		StuckHealth:      stuckHealth,
 		StuckBytes:       stuckBytes,
@@ -207,6 +214,38 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 	return err
 }
 
+// managedReplace atomically replaces the file dst with n. n is moved to the
+// location of dst and dst is deleted.
+func (n *FileNode) managedReplace(dst *FileNode, oldParent, newParent *DirNode) error {
+	// Lock the parents. If they are the same, only lock one.
+	if oldParent.staticUID == newParent.staticUID {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+	} else {
+		oldParent.node.mu.Lock()
+		defer oldParent.node.mu.Unlock()
+		newParent.node.mu.Lock()
+		defer newParent.node.mu.Unlock()
+	}
+	n.node.mu.Lock()
+	defer n.node.mu.Unlock()
+	dst.node.mu.Lock()
+	defer dst.node.mu.Unlock()
+	// Replace the file.
+	err := n.SiaFile.Replace(dst.SiaFile)
+	if err != nil {
+		return err
+	}
+	// Remove both files from their parents and add n to the new parent.
+	oldParent.removeFile(n)
+	newParent.removeFile(dst)
+	n.parent = newParent
+	*n.name = *dst.name
+	*n.path = *dst.path
+	n.parent.files[*n.name] = n
+	return nil
+}
+
 // cachedFileInfo returns information on a siafile. As a performance
 // optimization, the fileInfo takes the maps returned by
 // renter.managedContractUtilityMaps for many files at once.
@@ -227,6 +266,7 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		ChangeTime:       md.ChangeTime,
 		CipherType:       md.StaticMasterKeyType.String(),
 		CreateTime:       md.CreateTime,
+		DedupSavings:     md.DedupSavings,
 		Expiration:       md.CachedExpiration,
 		Filesize:         uint64(md.FileSize),
 		Health:           md.CachedHealth,
@@ -241,6 +281,7 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		Renewing:         true,
 		RepairBytes:      md.CachedRepairBytes,
 		SiaPath:          siaPath,
+		Spending:         md.Spending,
 		Stuck:            md.NumStuckChunks > 0,
 		StuckBytes:       md.CachedStuckBytes,
 		StuckHealth:      md.CachedStuckHealth,
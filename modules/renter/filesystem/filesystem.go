@@ -239,9 +239,23 @@ func (fs *FileSystem) CachedListOnNode(d *DirNode) (fis []modules.FileInfo, dis
 // shortly. Only when all instances of the dir are closed it will be removed
 // from the tree. This means that as long as the deletion is in progress, no new
 // file of the same path can be created and the existing file can't be opened
-// until all instances of it are closed.
+// until all instances of it are closed. Packs which are no longer referenced by
+// any packed files after the deletion are deleted as well.
 func (fs *FileSystem) DeleteDir(siaPath modules.SiaPath) error {
-	return fs.managedDeleteDir(siaPath.String())
+	// Remember the packs of the packed files within the dir before deleting
+	// it.
+	packs, err := fs.managedPackedSiaPaths(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to find packed files in dir")
+	}
+	if err := fs.managedDeleteDir(siaPath.String()); err != nil {
+		return err
+	}
+	var errs error
+	for _, packSiaPath := range packs {
+		errs = errors.Compose(errs, fs.managedRemovePackedFile(packSiaPath))
+	}
+	return errs
 }
 
 // DeleteFile deletes a file from the filesystem. The file will be marked as
@@ -249,9 +263,23 @@ func (fs *FileSystem) DeleteDir(siaPath modules.SiaPath) error {
 // shortly. Only when all instances of the file are closed it will be removed
 // from the tree. This means that as long as the deletion is in progress, no new
 // file of the same path can be created and the existing file can't be opened
-// until all instances of it are closed.
+// until all instances of it are closed. If the file was packed and its pack is
+// no longer referenced by any other packed file, the pack is deleted as well.
 func (fs *FileSystem) DeleteFile(siaPath modules.SiaPath) error {
-	return fs.managedDeleteFile(siaPath.String())
+	var packSiaPath modules.SiaPath
+	if sf, err := fs.managedOpenFile(siaPath.String()); err == nil {
+		packSiaPath = sf.PackedSiaPath()
+		if err := sf.Close(); err != nil {
+			return err
+		}
+	}
+	if err := fs.managedDeleteFile(siaPath.String()); err != nil {
+		return err
+	}
+	if packSiaPath.IsEmpty() {
+		return nil
+	}
+	return fs.managedRemovePackedFile(packSiaPath)
 }
 
 // DirInfo returns the Directory Information of the siadir
@@ -519,6 +547,68 @@ func (fs *FileSystem) RenameFile(oldSiaPath, newSiaPath modules.SiaPath) (err er
 	return sf.managedRename(newSiaPath.Name(), oldDir, newDir)
 }
 
+// ReplaceFile atomically replaces the file at siaPath with the file at
+// srcSiaPath. Afterwards the file at srcSiaPath is located at siaPath and the
+// replaced file is deleted.
+func (fs *FileSystem) ReplaceFile(siaPath, srcSiaPath modules.SiaPath) (err error) {
+	if siaPath.Equals(srcSiaPath) {
+		return errors.New("can't replace a file with itself")
+	}
+	// Open the SiaDirs and the files.
+	srcDirSiaPath, err := srcSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	srcDir, err := fs.managedOpenSiaDir(srcDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, srcDir.Close())
+	}()
+	src, err := srcDir.managedOpenFile(srcSiaPath.Name())
+	if errors.Contains(err, ErrNotExist) {
+		return ErrNotExist
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open source file")
+	}
+	defer func() {
+		err = errors.Compose(err, src.Close())
+	}()
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	dir, err := fs.managedOpenSiaDir(dirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	dst, err := dir.managedOpenFile(siaPath.Name())
+	if errors.Contains(err, ErrNotExist) {
+		return ErrNotExist
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open file to replace")
+	}
+	defer func() {
+		err = errors.Compose(err, dst.Close())
+	}()
+	// Replace the file. If the replaced file was packed, it no longer
+	// references its pack afterwards.
+	packSiaPath := dst.PackedSiaPath()
+	if err := src.managedReplace(dst, srcDir, dir); err != nil {
+		return err
+	}
+	if packSiaPath.IsEmpty() {
+		return nil
+	}
+	return fs.managedRemovePackedFile(packSiaPath)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
@@ -610,6 +700,108 @@ func (fs *FileSystem) managedDeleteDir(path string) (err error) {
 	return dir.managedDelete()
 }
 
+// managedPackedSiaPaths returns the siapaths of the packs referenced by the
+// packed files within the dir at siaPath and its subdirs. A pack is returned
+// once for every packed file that references it.
+func (fs *FileSystem) managedPackedSiaPaths(siaPath modules.SiaPath) ([]modules.SiaPath, error) {
+	var packs []modules.SiaPath
+	err := fs.Walk(siaPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != modules.SiaFileExtension {
+			return nil
+		}
+		md, err := siafile.LoadSiaFileMetadata(path)
+		if err != nil {
+			return errors.AddContext(err, "failed to load siafile metadata")
+		}
+		if !md.PackedSiaPath.IsEmpty() {
+			packs = append(packs, md.PackedSiaPath)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		// Let the caller deal with the missing dir.
+		return nil, nil
+	}
+	return packs, err
+}
+
+// DedupIDs returns the DedupIDs of the deduplicated chunks of the file at
+// siaPath or of all the files within the dir at siaPath and its subdirs. A
+// DedupID is returned once for every chunk that references it.
+func (fs *FileSystem) DedupIDs(siaPath modules.SiaPath) (_ []siafile.DedupID, err error) {
+	sf, err := fs.managedOpenFile(siaPath.String())
+	if err == nil {
+		defer func() {
+			err = errors.Compose(err, sf.Close())
+		}()
+		return sf.DedupIDs()
+	} else if !errors.Contains(err, ErrNotExist) {
+		return nil, err
+	}
+	var ids []siafile.DedupID
+	root := fs.Root()
+	err = fs.Walk(siaPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != modules.SiaFileExtension {
+			return nil
+		}
+		md, err := siafile.LoadSiaFileMetadata(path)
+		if err != nil {
+			return errors.AddContext(err, "failed to load siafile metadata")
+		}
+		if !md.Dedup {
+			return nil
+		}
+		var sp modules.SiaPath
+		if err := sp.FromSysPath(path, root); err != nil {
+			return err
+		}
+		sf, err := fs.managedOpenFile(sp.String())
+		if err != nil {
+			return errors.AddContext(err, "failed to open deduplicated file")
+		}
+		fileIDs, err := sf.DedupIDs()
+		err = errors.Compose(err, sf.Close())
+		if err != nil {
+			return errors.AddContext(err, "failed to get dedup ids")
+		}
+		ids = append(ids, fileIDs...)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		// Let the caller deal with the missing dir.
+		return nil, nil
+	}
+	return ids, err
+}
+
+// managedRemovePackedFile removes a packed file's reference to the pack at
+// packSiaPath. The pack is deleted once no packed file references it anymore.
+func (fs *FileSystem) managedRemovePackedFile(packSiaPath modules.SiaPath) (err error) {
+	pack, err := fs.managedOpenFile(packSiaPath.String())
+	if errors.Contains(err, ErrNotExist) {
+		// The pack was already deleted.
+		return nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open pack")
+	}
+	remaining, err := pack.RemovePackedFile()
+	err = errors.Compose(err, pack.Close())
+	if err != nil {
+		return errors.AddContext(err, "failed to remove packed file from pack")
+	}
+	if remaining > 0 {
+		return nil
+	}
+	return fs.managedDeleteFile(packSiaPath.String())
+}
+
 // managedFileInfo returns the FileInfo of the siafile.
 func (fs *FileSystem) managedFileInfo(siaPath modules.SiaPath, cached bool, offline map[string]bool, goodForRenew map[string]bool, contracts map[string]modules.RenterContract) (_ modules.FileInfo, err error) {
 	// Open the file.
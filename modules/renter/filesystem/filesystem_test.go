@@ -23,6 +23,7 @@ import (
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 
 	"go.sia.tech/siad/build"
 )
@@ -2319,3 +2320,245 @@ func TestList(t *testing.T) {
 		t.Fatal("wrong number of dirs", len(dis), len(dirStructure))
 	}
 }
+
+// TestReplaceFile tests replacing a file with another one.
+func TestReplaceFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a filesystem with two files in different dirs.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	sp := newSiaPath("dir/file")
+	srcSP := newSiaPath("tmp/file")
+	fs.addTestSiaFile(sp)
+	fs.addTestSiaFile(srcSP)
+
+	// Replacing a file that doesn't exist should fail.
+	if err := fs.ReplaceFile(newSiaPath("dir/notexist"), srcSP); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+	// Replacing a file with itself should fail.
+	if err := fs.ReplaceFile(sp, sp); err == nil {
+		t.Fatal("expected replacing a file with itself to fail")
+	}
+
+	// Open both files and set some metadata on the file to replace.
+	dst, err := fs.OpenSiaFile(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := fs.OpenSiaFile(srcSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localPath := filepath.Join(root, "localfile")
+	if err := dst.SetLocalPath(localPath); err != nil {
+		t.Fatal(err)
+	}
+	srcLocalPath := src.LocalPath()
+	spending := modules.FileSpending{UploadSpending: types.NewCurrency64(1)}
+	if err := dst.AddSpending(spending); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.AddSpending(spending); err != nil {
+		t.Fatal(err)
+	}
+	srcUID := src.UID()
+
+	// Replace the file.
+	if err := fs.ReplaceFile(sp, srcSP); err != nil {
+		t.Fatal(err)
+	}
+	// The replaced file should be deleted and the source file should be
+	// gone from its old location.
+	if !dst.Deleted() {
+		t.Fatal("replaced file should be deleted")
+	}
+	if exists, _ := fs.FileExists(srcSP); exists {
+		t.Fatal("source file still exists")
+	}
+	if exists, _ := fs.FileExists(sp); !exists {
+		t.Fatal("file doesn't exist")
+	}
+	if fs.FileSiaPath(src) != sp {
+		t.Fatal("wrong siapath", fs.FileSiaPath(src))
+	}
+	// Close the handles.
+	if err := errors.Compose(dst.Close(), src.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load the file from disk and check the metadata.
+	fs = newTestFileSystem(root)
+	sf, err := fs.OpenSiaFile(sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := sf.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if sf.UID() != srcUID {
+		t.Fatal("file wasn't replaced")
+	}
+	if sf.LocalPath() != srcLocalPath {
+		t.Fatal("local path of the replaced file was carried over", sf.LocalPath())
+	}
+	if !reflect.DeepEqual(sf.Spending(), spending.Add(spending)) {
+		t.Fatal("spending wasn't carried over", sf.Spending())
+	}
+}
+
+// TestDeletePackedFiles tests that a pack is deleted once all the packed files
+// referencing it are deleted.
+func TestDeletePackedFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a filesystem with a pack.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	ec, err := modules.NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+	packSP := newSiaPath("var/packed/pack")
+	err = fs.NewSiaFile(packSP, "", ec, mk, modules.SectorSize, persist.DefaultDiskPermissionsTest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack, err := fs.OpenSiaFile(packSP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := []modules.SiaPath{newSiaPath("dir/a"), newSiaPath("dir/sub/b"), newSiaPath("other/c")}
+	err = errors.Compose(pack.AddPackedFiles(uint64(len(members))), pack.Close())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add the packed files.
+	for i, sp := range members {
+		err = fs.NewSiaFile(sp, "", ec, mk, 10, persist.DefaultDiskPermissionsTest, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf, err := fs.OpenSiaFile(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = errors.Compose(sf.SetPacked(packSP, uint64(i)*10), sf.Close())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// numPackedFiles is a helper to check the number of packed files which
+	// reference the pack.
+	numPackedFiles := func() uint64 {
+		pack, err := fs.OpenSiaFile(packSP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := pack.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		return pack.NumPackedFiles()
+	}
+
+	// Delete a file, then a dir with a file in a subdir.
+	if err := fs.DeleteFile(members[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n := numPackedFiles(); n != 2 {
+		t.Fatal("wrong number of packed files", n)
+	}
+	if err := fs.DeleteDir(newSiaPath("dir")); err != nil {
+		t.Fatal(err)
+	}
+	if n := numPackedFiles(); n != 1 {
+		t.Fatal("wrong number of packed files", n)
+	}
+
+	// Replace the last file. This should delete the pack.
+	srcSP := newSiaPath("tmp/file")
+	fs.addTestSiaFile(srcSP)
+	if err := fs.ReplaceFile(members[2], srcSP); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fs.FileExists(packSP); exists {
+		t.Fatal("pack should have been deleted")
+	}
+}
+
+// TestDedupIDs tests collecting the DedupIDs of files and dirs.
+func TestDedupIDs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	ec, err := modules.NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+
+	// Create a regular file and some deduplicated files with 2 chunks each.
+	// Only the first chunk of each file gets an ID.
+	fs.addTestSiaFile(newSiaPath("dir/regular"))
+	files := []modules.SiaPath{newSiaPath("dir/a"), newSiaPath("dir/sub/b"), newSiaPath("other/c")}
+	ids := make([]siafile.DedupID, len(files))
+	for i, sp := range files {
+		chunkSize := (modules.SectorSize - mk.Type().Overhead()) * uint64(ec.MinPieces())
+		err = fs.NewSiaFile(sp, "", ec, mk, 2*chunkSize, persist.DefaultDiskPermissionsTest, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sf, err := fs.OpenSiaFile(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fastrand.Read(ids[i][:])
+		err = errors.Compose(sf.SetDedup(), sf.SetDedupID(0, ids[i]), sf.Close())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Check the IDs of a single file, a dir and a missing path.
+	fileIDs, err := fs.DedupIDs(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileIDs) != 1 || fileIDs[0] != ids[0] {
+		t.Fatal("wrong file ids", fileIDs)
+	}
+	dirIDs, err := fs.DedupIDs(newSiaPath("dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirIDs) != 2 {
+		t.Fatal("wrong number of dir ids", dirIDs)
+	}
+	for _, id := range dirIDs {
+		if id != ids[0] && id != ids[1] {
+			t.Fatal("unexpected id", id)
+		}
+	}
+	missingIDs, err := fs.DedupIDs(newSiaPath("missing"))
+	if err != nil || len(missingIDs) != 0 {
+		t.Fatal("expected no ids for missing path", missingIDs, err)
+	}
+}
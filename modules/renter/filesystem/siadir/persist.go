@@ -198,6 +198,7 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	}
 
 	// Update metadata
+	sd.metadata.AggregateDedupSavings = metadata.AggregateDedupSavings
 	sd.metadata.AggregateHealth = metadata.AggregateHealth
 	sd.metadata.AggregateLastHealthCheckTime = metadata.AggregateLastHealthCheckTime
 	sd.metadata.AggregateMinRedundancy = metadata.AggregateMinRedundancy
@@ -208,9 +209,11 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.AggregateRemoteHealth = metadata.AggregateRemoteHealth
 	sd.metadata.AggregateRepairSize = metadata.AggregateRepairSize
 	sd.metadata.AggregateSize = metadata.AggregateSize
+	sd.metadata.AggregateSpending = metadata.AggregateSpending
 	sd.metadata.AggregateStuckHealth = metadata.AggregateStuckHealth
 	sd.metadata.AggregateStuckSize = metadata.AggregateStuckSize
 
+	sd.metadata.DedupSavings = metadata.DedupSavings
 	sd.metadata.Health = metadata.Health
 	sd.metadata.LastHealthCheckTime = metadata.LastHealthCheckTime
 	sd.metadata.MinRedundancy = metadata.MinRedundancy
@@ -222,6 +225,7 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.RemoteHealth = metadata.RemoteHealth
 	sd.metadata.RepairSize = metadata.RepairSize
 	sd.metadata.Size = metadata.Size
+	sd.metadata.Spending = metadata.Spending
 	sd.metadata.StuckHealth = metadata.StuckHealth
 	sd.metadata.StuckSize = metadata.StuckSize
 
@@ -31,6 +31,9 @@ type (
 		// sub tree. The definition of aggregate and siadir specific values is
 		// otherwise the same.
 		//
+		// DedupSavings is the number of bytes that didn't need to be uploaded
+		// for the siafiles in the siadir thanks to deduplication
+		//
 		// Health is the health of the most in need siafile that is not stuck
 		//
 		// LastHealthCheckTime is the oldest LastHealthCheckTime of any of the
@@ -52,40 +55,47 @@ type (
 		//
 		// Size is the total amount of data stored in the siafiles of the siadir
 		//
+		// Spending is the sum of the money spent on behalf of the siafiles in
+		// the siadir
+		//
 		// StuckHealth is the health of the most in need siafile in the siadir,
 		// stuck or not stuck
 
 		// The following fields are aggregate values of the siadir. These values are
 		// the totals of the siadir and any sub siadirs, or are calculated based on
 		// all the values in the subtree
-		AggregateHealth              float64   `json:"aggregatehealth"`
-		AggregateLastHealthCheckTime time.Time `json:"aggregatelasthealthchecktime"`
-		AggregateMinRedundancy       float64   `json:"aggregateminredundancy"`
-		AggregateModTime             time.Time `json:"aggregatemodtime"`
-		AggregateNumFiles            uint64    `json:"aggregatenumfiles"`
-		AggregateNumStuckChunks      uint64    `json:"aggregatenumstuckchunks"`
-		AggregateNumSubDirs          uint64    `json:"aggregatenumsubdirs"`
-		AggregateRemoteHealth        float64   `json:"aggregateremotehealth"`
-		AggregateRepairSize          uint64    `json:"aggregaterepairsize"`
-		AggregateSize                uint64    `json:"aggregatesize"`
-		AggregateStuckHealth         float64   `json:"aggregatestuckhealth"`
-		AggregateStuckSize           uint64    `json:"aggregatestucksize"`
+		AggregateDedupSavings        uint64               `json:"aggregatededupsavings"`
+		AggregateHealth              float64              `json:"aggregatehealth"`
+		AggregateLastHealthCheckTime time.Time            `json:"aggregatelasthealthchecktime"`
+		AggregateMinRedundancy       float64              `json:"aggregateminredundancy"`
+		AggregateModTime             time.Time            `json:"aggregatemodtime"`
+		AggregateNumFiles            uint64               `json:"aggregatenumfiles"`
+		AggregateNumStuckChunks      uint64               `json:"aggregatenumstuckchunks"`
+		AggregateNumSubDirs          uint64               `json:"aggregatenumsubdirs"`
+		AggregateRemoteHealth        float64              `json:"aggregateremotehealth"`
+		AggregateRepairSize          uint64               `json:"aggregaterepairsize"`
+		AggregateSize                uint64               `json:"aggregatesize"`
+		AggregateSpending            modules.FileSpending `json:"aggregatespending"`
+		AggregateStuckHealth         float64              `json:"aggregatestuckhealth"`
+		AggregateStuckSize           uint64               `json:"aggregatestucksize"`
 
 		// The following fields are information specific to the siadir that is not
 		// an aggregate of the entire sub directory tree
-		Health              float64     `json:"health"`
-		LastHealthCheckTime time.Time   `json:"lasthealthchecktime"`
-		MinRedundancy       float64     `json:"minredundancy"`
-		Mode                os.FileMode `json:"mode"`
-		ModTime             time.Time   `json:"modtime"`
-		NumFiles            uint64      `json:"numfiles"`
-		NumStuckChunks      uint64      `json:"numstuckchunks"`
-		NumSubDirs          uint64      `json:"numsubdirs"`
-		RemoteHealth        float64     `json:"remotehealth"`
-		RepairSize          uint64      `json:"repairsize"`
-		Size                uint64      `json:"size"`
-		StuckHealth         float64     `json:"stuckhealth"`
-		StuckSize           uint64      `json:"stucksize"`
+		DedupSavings        uint64               `json:"dedupsavings"`
+		Health              float64              `json:"health"`
+		LastHealthCheckTime time.Time            `json:"lasthealthchecktime"`
+		MinRedundancy       float64              `json:"minredundancy"`
+		Mode                os.FileMode          `json:"mode"`
+		ModTime             time.Time            `json:"modtime"`
+		NumFiles            uint64               `json:"numfiles"`
+		NumStuckChunks      uint64               `json:"numstuckchunks"`
+		NumSubDirs          uint64               `json:"numsubdirs"`
+		RemoteHealth        float64              `json:"remotehealth"`
+		RepairSize          uint64               `json:"repairsize"`
+		Size                uint64               `json:"size"`
+		Spending            modules.FileSpending `json:"spending"`
+		StuckHealth         float64              `json:"stuckhealth"`
+		StuckSize           uint64               `json:"stucksize"`
 
 		// Version is the used version of the header file.
 		Version string `json:"version"`
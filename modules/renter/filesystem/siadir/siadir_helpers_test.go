@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 )
 
 // checkMetadataInit is a helper that verifies that the metadata was initialized
@@ -47,6 +49,9 @@ func checkMetadataInit(md Metadata) error {
 // itself as well and reset due to how time is persisted
 func equalMetadatas(md, md2 Metadata) error {
 	// Check Aggregate Fields
+	if md.AggregateDedupSavings != md2.AggregateDedupSavings {
+		return fmt.Errorf("AggregateDedupSavings not equal, %v and %v", md.AggregateDedupSavings, md2.AggregateDedupSavings)
+	}
 	if md.AggregateHealth != md2.AggregateHealth {
 		return fmt.Errorf("AggregateHealth not equal, %v and %v", md.AggregateHealth, md2.AggregateHealth)
 	}
@@ -77,6 +82,9 @@ func equalMetadatas(md, md2 Metadata) error {
 	if md.AggregateSize != md2.AggregateSize {
 		return fmt.Errorf("AggregateSize not equal, %v and %v", md.AggregateSize, md2.AggregateSize)
 	}
+	if !reflect.DeepEqual(md.AggregateSpending, md2.AggregateSpending) {
+		return fmt.Errorf("AggregateSpending not equal, %v and %v", md.AggregateSpending, md2.AggregateSpending)
+	}
 	if md.AggregateStuckHealth != md2.AggregateStuckHealth {
 		return fmt.Errorf("AggregateStuckHealth not equal, %v and %v", md.AggregateStuckHealth, md2.AggregateStuckHealth)
 	}
@@ -85,6 +93,9 @@ func equalMetadatas(md, md2 Metadata) error {
 	}
 
 	// Check SiaDir Fields
+	if md.DedupSavings != md2.DedupSavings {
+		return fmt.Errorf("DedupSavings not equal, %v and %v", md.DedupSavings, md2.DedupSavings)
+	}
 	if md.Health != md2.Health {
 		return fmt.Errorf("Healths not equal, %v and %v", md.Health, md2.Health)
 	}
@@ -115,6 +126,9 @@ func equalMetadatas(md, md2 Metadata) error {
 	if md.Size != md2.Size {
 		return fmt.Errorf("Sizes not equal, %v and %v", md.Size, md2.Size)
 	}
+	if !reflect.DeepEqual(md.Spending, md2.Spending) {
+		return fmt.Errorf("Spending not equal, %v and %v", md.Spending, md2.Spending)
+	}
 	if md.StuckHealth != md2.StuckHealth {
 		return fmt.Errorf("StuckHealth not equal, %v and %v", md.StuckHealth, md2.StuckHealth)
 	}
@@ -138,6 +152,7 @@ func newRootDir(t *testing.T) (string, error) {
 // randomMetadata returns a siadir Metadata struct with random values set
 func randomMetadata() Metadata {
 	md := Metadata{
+		AggregateDedupSavings:        fastrand.Uint64n(100),
 		AggregateHealth:              float64(fastrand.Intn(100)),
 		AggregateLastHealthCheckTime: time.Now(),
 		AggregateMinRedundancy:       float64(fastrand.Intn(100)),
@@ -148,9 +163,11 @@ func randomMetadata() Metadata {
 		AggregateRemoteHealth:        float64(fastrand.Intn(100)),
 		AggregateRepairSize:          fastrand.Uint64n(100),
 		AggregateSize:                fastrand.Uint64n(100),
+		AggregateSpending:            randomSpending(),
 		AggregateStuckHealth:         float64(fastrand.Intn(100)),
 		AggregateStuckSize:           fastrand.Uint64n(100),
 
+		DedupSavings:        fastrand.Uint64n(100),
 		Health:              float64(fastrand.Intn(100)),
 		LastHealthCheckTime: time.Now(),
 		MinRedundancy:       float64(fastrand.Intn(100)),
@@ -161,12 +178,23 @@ func randomMetadata() Metadata {
 		RemoteHealth:        float64(fastrand.Intn(100)),
 		RepairSize:          fastrand.Uint64n(100),
 		Size:                fastrand.Uint64n(100),
+		Spending:            randomSpending(),
 		StuckHealth:         float64(fastrand.Intn(100)),
 		StuckSize:           fastrand.Uint64n(100),
 	}
 	return md
 }
 
+// randomSpending returns a FileSpending struct with random values set
+func randomSpending() modules.FileSpending {
+	return modules.FileSpending{
+		DownloadSpending: types.NewCurrency64(fastrand.Uint64n(100)),
+		RepairSpending:   types.NewCurrency64(fastrand.Uint64n(100)),
+		StorageSpending:  types.NewCurrency64(fastrand.Uint64n(100)),
+		UploadSpending:   types.NewCurrency64(fastrand.Uint64n(100)),
+	}
+}
+
 // newSiaDirTestDir creates a test directory for a siadir test
 func newSiaDirTestDir(testDir string) (string, error) {
 	rootPath := filepath.Join(os.TempDir(), "siadirs", testDir)
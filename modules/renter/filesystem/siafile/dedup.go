@@ -0,0 +1,209 @@
+package siafile
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+)
+
+var (
+	// ErrMissingDedupID is returned when the key of a chunk of a file in
+	// dedup mode is requested before the chunk's DedupID was set.
+	ErrMissingDedupID = errors.New("chunk of deduplicated file has no dedup id")
+)
+
+type (
+	// DedupID identifies the content of a chunk of a file in dedup mode. It
+	// is stored in the ExtensionInfo of the chunk. A zero DedupID indicates
+	// that the chunk isn't deduplicated.
+	DedupID [16]byte
+
+	// dedupKey is the master key of a deduplicated chunk. Since the pieces of
+	// the chunk might be shared with chunks at different indices, the keys of
+	// the pieces don't depend on the index of the chunk.
+	dedupKey struct {
+		crypto.CipherKey
+	}
+)
+
+// Derive derives the key of a piece of a deduplicated chunk. The chunk index
+// is ignored.
+func (key dedupKey) Derive(_, pieceIndex uint64) crypto.CipherKey {
+	return key.CipherKey.Derive(0, pieceIndex)
+}
+
+// IsZero returns true if the DedupID is the zero DedupID.
+func (id DedupID) IsZero() bool {
+	return id == DedupID{}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id DedupID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// String returns the hex representation of the DedupID.
+func (id DedupID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *DedupID) UnmarshalText(b []byte) error {
+	if hex.DecodedLen(len(b)) != len(id) {
+		return errors.New("dedup id has wrong length")
+	}
+	_, err := hex.Decode(id[:], b)
+	return err
+}
+
+// chunkMasterKey returns the key used to derive the keys of the pieces of a
+// chunk. Deduplicated chunks use a key which is derived from their DedupID.
+func chunkMasterKey(masterKey crypto.CipherKey, id DedupID) crypto.CipherKey {
+	if id.IsZero() {
+		return masterKey
+	}
+	return dedupKey{masterKey.Derive(binary.LittleEndian.Uint64(id[:8]), binary.LittleEndian.Uint64(id[8:]))}
+}
+
+// ChunkMasterKey returns the key used to derive the keys of the pieces of the
+// chunk at chunkIndex.
+func (sf *SiaFile) ChunkMasterKey(chunkIndex uint64) (crypto.CipherKey, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if !sf.staticMetadata.Dedup {
+		return sf.staticMasterKey(), nil
+	}
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return nil, err
+	}
+	id := DedupID(chunk.ExtensionInfo)
+	if id.IsZero() {
+		return nil, ErrMissingDedupID
+	}
+	return chunkMasterKey(sf.staticMasterKey(), id), nil
+}
+
+// Dedup returns true if the file was uploaded in dedup mode.
+func (sf *SiaFile) Dedup() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Dedup
+}
+
+// DedupSavings returns the number of bytes that didn't need to be uploaded
+// thanks to deduplication.
+func (sf *SiaFile) DedupSavings() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.DedupSavings
+}
+
+// AddDedupSavings adds n bytes to the dedup savings of the file and saves the
+// metadata to disk.
+func (sf *SiaFile) AddDedupSavings(n uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't add dedup savings to deleted file")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.DedupSavings += n
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDedup puts the file into dedup mode. This needs to happen before any of
+// its chunks are uploaded.
+func (sf *SiaFile) SetDedup() (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't enable dedup for deleted file")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Dedup = true
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// DedupID returns the DedupID of the chunk at chunkIndex.
+func (sf *SiaFile) DedupID(chunkIndex uint64) (DedupID, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return DedupID{}, err
+	}
+	return DedupID(chunk.ExtensionInfo), nil
+}
+
+// DedupIDs returns the DedupIDs of all the deduplicated chunks of the file.
+func (sf *SiaFile) DedupIDs() ([]DedupID, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if !sf.staticMetadata.Dedup {
+		return nil, nil
+	}
+	var ids []DedupID
+	err := sf.iterateChunksReadonly(func(chunk chunk) error {
+		if id := DedupID(chunk.ExtensionInfo); !id.IsZero() {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// SetDedupID sets the DedupID of the chunk at chunkIndex. The ID can only be
+// set once, before the chunk's pieces are encrypted.
+func (sf *SiaFile) SetDedupID(chunkIndex uint64, id DedupID) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't set dedup id of deleted file")
+	}
+	if !sf.staticMetadata.Dedup {
+		return errors.New("file is not in dedup mode")
+	}
+	if id.IsZero() {
+		return errors.New("can't set zero dedup id")
+	}
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return err
+	}
+	if current := DedupID(chunk.ExtensionInfo); current == id {
+		return nil
+	} else if !current.IsZero() {
+		return errors.New("chunk already has a different dedup id")
+	}
+	chunk.ExtensionInfo = id
+	return sf.createAndApplyTransaction(sf.saveChunkUpdate(chunk))
+}
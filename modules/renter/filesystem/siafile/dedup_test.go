@@ -0,0 +1,120 @@
+package siafile
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDedupFile tests setting the DedupIDs of a file's chunks and deriving the
+// keys of the chunks from them.
+func TestDedupFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a file with 2 chunks.
+	siaFilePath, _, _, rc, sk, _, _, fileMode := newTestFileParams(1, false)
+	chunkSize := (modules.SectorSize - sk.Type().Overhead()) * uint64(rc.MinPieces())
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, "", rc, sk, 2*chunkSize, 2, fileMode)
+
+	// Regular files use the master key for every chunk and can't have
+	// DedupIDs.
+	var id DedupID
+	fastrand.Read(id[:])
+	if err := sf.SetDedupID(0, id); err == nil {
+		t.Fatal("shouldn't be able to set dedup id of regular file")
+	}
+	if key, err := sf.ChunkMasterKey(1); err != nil || !bytes.Equal(key.Key(), sk.Key()) {
+		t.Fatal("regular file should use its master key", err)
+	}
+
+	// Enable dedup mode. The keys of the chunks are unknown until their IDs
+	// are set.
+	if err := sf.SetDedup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sf.ChunkMasterKey(0); !errors.Contains(err, ErrMissingDedupID) {
+		t.Fatal("expected ErrMissingDedupID but got", err)
+	}
+	if err := sf.SetDedupID(0, DedupID{}); err == nil {
+		t.Fatal("shouldn't be able to set zero dedup id")
+	}
+
+	// Set the same ID for both chunks. They should share the same keys even
+	// though their indices differ.
+	for chunkIndex := uint64(0); chunkIndex < 2; chunkIndex++ {
+		if err := sf.SetDedupID(chunkIndex, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var other DedupID
+	fastrand.Read(other[:])
+	if err := sf.SetDedupID(0, other); err == nil {
+		t.Fatal("shouldn't be able to change the dedup id")
+	}
+	key0, err := sf.ChunkMasterKey(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1, err := sf.ChunkMasterKey(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key0.Derive(0, 1).Key(), key1.Derive(1, 1).Key()) {
+		t.Fatal("pieces of chunks with the same id should share their keys")
+	}
+	if bytes.Equal(key0.Derive(0, 1).Key(), sk.Derive(0, 1).Key()) {
+		t.Fatal("deduplicated chunk shouldn't use the master key")
+	}
+	if bytes.Equal(key0.Derive(0, 1).Key(), chunkMasterKey(sk, other).Derive(0, 1).Key()) {
+		t.Fatal("chunks with different ids should use different keys")
+	}
+
+	// The IDs and savings should be persisted.
+	if err := sf.AddDedupSavings(modules.SectorSize); err != nil {
+		t.Fatal(err)
+	}
+	sf, err = LoadSiaFile(siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Dedup() || sf.DedupSavings() != modules.SectorSize {
+		t.Fatal("dedup metadata wasn't persisted", sf.Dedup(), sf.DedupSavings())
+	}
+	ids, err := sf.DedupIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != id || ids[1] != id {
+		t.Fatal("wrong dedup ids", ids)
+	}
+
+	// Snapshots should derive the same keys.
+	snap, err := sf.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snap.ChunkMasterKey(1).Derive(1, 2).Key(), key1.Derive(1, 2).Key()) {
+		t.Fatal("snapshot derived the wrong key")
+	}
+
+	// DedupIDs should survive a JSON round trip.
+	b, err := json.Marshal(map[DedupID]uint64{id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[DedupID]uint64
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m[id] != 1 {
+		t.Fatal("dedup id didn't survive round trip", string(b))
+	}
+}
@@ -148,6 +148,8 @@ func unmarshalErasureCoder(ecType [4]byte, ecParams [8]byte) (modules.ErasureCod
 		return modules.NewRSCode(dataPieces, parityPieces)
 	case modules.ECReedSolomonSubShards64:
 		return modules.NewRSSubCode(dataPieces, parityPieces, 64)
+	case modules.ECLeopardGF16:
+		return modules.NewLeopardCode(dataPieces, parityPieces)
 	default:
 		return nil, errors.New("unknown erasure code type")
 	}
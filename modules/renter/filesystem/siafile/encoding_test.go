@@ -53,6 +53,44 @@ func TestMarshalUnmarshalChunk(t *testing.T) {
 	}
 }
 
+// TestMarshalUnmarshalChunkMaxPieces tests marshaling and unmarshaling a chunk
+// with the maximum number of pieces supported by the leopard coder.
+func TestMarshalUnmarshalChunkMaxPieces(t *testing.T) {
+	numPieces := modules.MaxLeopardPieces
+
+	// The chunk needs to fit into the max number of pages and one more piece
+	// shouldn't.
+	maxChunkSize := int64(pageSize) * int64(^uint8(0))
+	if marshaledChunkSize(numPieces) > maxChunkSize {
+		t.Fatal("max number of pieces doesn't fit into a chunk")
+	}
+	if marshaledChunkSize(numPieces+1) <= maxChunkSize {
+		t.Fatal("max number of pieces is lower than necessary")
+	}
+	if numChunkPagesRequired(numPieces) != ^uint8(0) {
+		t.Fatal("wrong number of pages", numChunkPagesRequired(numPieces))
+	}
+
+	// Create a chunk with one piece per index.
+	chunk := chunk{}
+	chunk.Pieces = make([][]piece, numPieces)
+	fastrand.Read(chunk.ExtensionInfo[:])
+	for pieceIndex := range chunk.Pieces {
+		chunk.Pieces[pieceIndex] = []piece{randomPiece()}
+	}
+
+	// Marshal the chunk into the reserved pages and unmarshal it again.
+	chunkBytes := make([]byte, int64(numChunkPagesRequired(numPieces))*pageSize)
+	copy(chunkBytes, marshalChunk(chunk))
+	unmarshaledChunk, err := unmarshalChunk(uint32(numPieces), chunkBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(chunk, unmarshaledChunk) {
+		t.Fatal("Unmarshaled chunk doesn't equal marshaled chunk")
+	}
+}
+
 // TestMarshalUnmarshalErasureCoder tests marshaling and unmarshaling an
 // ErasureCoder.
 func TestMarshalUnmarshalErasureCoder(t *testing.T) {
@@ -80,6 +118,24 @@ func TestMarshalUnmarshalErasureCoder(t *testing.T) {
 			}
 		}
 	}
+
+	// Wide stripes using the leopard coder should survive the round trip as
+	// well.
+	lc, err := modules.NewLeopardCode(100, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecType, ecParams := marshalErasureCoder(lc)
+	lc2, err := unmarshalErasureCoder(ecType, ecParams)
+	if err != nil {
+		t.Fatal("failed to unmarshal leopard coder", err)
+	}
+	if lc2.Type() != modules.ECLeopardGF16 {
+		t.Fatal("wrong type", lc2.Type())
+	}
+	if lc.Identifier() != lc2.Identifier() {
+		t.Fatalf("expected identifier %v but was %v", lc.Identifier(), lc2.Identifier())
+	}
 }
 
 // TestMarshalUnmarshalMetadata tests marshaling and unmarshaling the metadata
@@ -130,6 +186,12 @@ func TestMarshalUnmarshalMetadata(t *testing.T) {
 	md.CreateTime = time.Time{}
 	md.ModTime = time.Time{}
 	md.LastHealthCheckTime = time.Time{}
+	// Compare the erasure coders by their identifiers since the encoders
+	// contain pools which can't be compared with DeepEqual.
+	if sf.staticMetadata.staticErasureCode.Identifier() != md.staticErasureCode.Identifier() {
+		t.Fatal("erasure coders don't match")
+	}
+	md.staticErasureCode = sf.staticMetadata.staticErasureCode
 	// Compare result to original
 	if !reflect.DeepEqual(md, sf.staticMetadata) {
 		t.Fatal("Unmarshaled metadata not equal to marshaled metadata:", err)
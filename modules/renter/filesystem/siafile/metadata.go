@@ -60,6 +60,22 @@ type (
 		PartialChunks       []PartialChunkInfo `json:"partialchunks"`       // information about the partial chunk.
 		HasPartialChunk     bool               `json:"haspartialchunk"`     // indicates whether this file is supposed to have a partial chunk or not
 
+		// Fields for packed files. A packed file doesn't store any data
+		// itself. Instead its data is stored at PackedOffset within the first
+		// chunk of the pack at PackedSiaPath. A pack keeps track of the number
+		// of packed files that still reference it in NumPackedFiles.
+		PackedSiaPath  modules.SiaPath `json:"packedsiapath"`
+		PackedOffset   uint64          `json:"packedoffset"`
+		NumPackedFiles uint64          `json:"numpackedfiles"`
+
+		// Fields for deduplicated files. The chunks of a file in dedup mode
+		// are encrypted with keys derived from their content which allows
+		// for sharing their pieces with other chunks of the same content.
+		// DedupSavings is the number of bytes that didn't need to be uploaded
+		// because the pieces of a chunk were already stored on the network.
+		Dedup        bool   `json:"dedup"`
+		DedupSavings uint64 `json:"dedupsavings"`
+
 		// The following fields are the usual unix timestamps of files.
 		ModTime    time.Time `json:"modtime"`    // time of last content modification
 		ChangeTime time.Time `json:"changetime"` // time of last metadata modification
@@ -131,6 +147,12 @@ type (
 		StuckHealth         float64   `json:"stuckhealth"`
 		StuckBytes          uint64    `json:"stuckbytes"`
 
+		// Spending is the money that has been spent on behalf of the file. It
+		// is persisted by AddSpending, which the renter calls after uploading
+		// a piece of the file and after downloading the pieces of a chunk of
+		// the file.
+		Spending modules.FileSpending `json:"spending"`
+
 		// File ownership/permission fields.
 		Mode    os.FileMode `json:"mode"`    // unix filemode of the sia file - uint32
 		UserID  int32       `json:"userid"`  // id of the user who owns the file
@@ -168,6 +190,7 @@ type (
 
 	// BubbledMetadata is the metadata of a siafile that gets bubbled
 	BubbledMetadata struct {
+		DedupSavings        uint64
 		Health              float64
 		LastHealthCheckTime time.Time
 		ModTime             time.Time
@@ -176,6 +199,7 @@ type (
 		Redundancy          float64
 		RepairBytes         uint64
 		Size                uint64
+		Spending            modules.FileSpending
 		StuckBytes          uint64
 		StuckHealth         float64
 		UID                 SiafileUID
@@ -196,6 +220,13 @@ func (sf *SiaFile) ChangeTime() time.Time {
 	return sf.staticMetadata.ChangeTime
 }
 
+// Spending returns the money that has been spent on behalf of the file.
+func (sf *SiaFile) Spending() modules.FileSpending {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Spending
+}
+
 // PartialChunks returns the partial chunk infos of the siafile.
 func (sf *SiaFile) PartialChunks() []PartialChunkInfo {
 	sf.mu.RLock()
@@ -332,6 +363,12 @@ func (md Metadata) backup() (b Metadata) {
 	b.StuckBytes = md.StuckBytes
 	b.Redundancy = md.Redundancy
 	b.StuckHealth = md.StuckHealth
+	b.Spending = md.Spending
+	b.PackedSiaPath = md.PackedSiaPath
+	b.PackedOffset = md.PackedOffset
+	b.NumPackedFiles = md.NumPackedFiles
+	b.Dedup = md.Dedup
+	b.DedupSavings = md.DedupSavings
 	b.Mode = md.Mode
 	b.UserID = md.UserID
 	b.GroupID = md.GroupID
@@ -384,6 +421,12 @@ func (md *Metadata) restore(b Metadata) {
 	md.StuckBytes = b.StuckBytes
 	md.Redundancy = b.Redundancy
 	md.StuckHealth = b.StuckHealth
+	md.Spending = b.Spending
+	md.PackedSiaPath = b.PackedSiaPath
+	md.PackedOffset = b.PackedOffset
+	md.NumPackedFiles = b.NumPackedFiles
+	md.Dedup = b.Dedup
+	md.DedupSavings = b.DedupSavings
 	md.Mode = b.Mode
 	md.UserID = b.UserID
 	md.GroupID = b.GroupID
@@ -474,6 +517,73 @@ func (sf *SiaFile) rename(newSiaFilePath string) (err error) {
 	return createAndApplyTransaction(sf.wal, updates...)
 }
 
+// Replace atomically replaces dst with sf. Afterwards sf is located at the
+// path of dst and dst is deleted. The metadata of dst which isn't related to
+// the uploaded data, like the file mode, is carried over to sf. The local path
+// of dst is not carried over since sf usually contains different data.
+func (sf *SiaFile) Replace(dst *SiaFile) (err error) {
+	if sf == dst {
+		return errors.New("can't replace a siafile with itself")
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	if sf.deleted || dst.deleted {
+		return errors.AddContext(ErrDeleted, "can't replace deleted siafile")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPath := sf.siaFilePath
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.siaFilePath = oldPath
+		}
+	}(sf.staticMetadata.backup())
+	// Create the delete updates before changing the path.
+	updates := []writeaheadlog.Update{sf.createDeleteUpdate(), dst.createDeleteUpdate()}
+	// Load all the chunks.
+	chunks := make([]chunk, 0, sf.numChunks)
+	err = sf.iterateChunksReadonly(func(chunk chunk) error {
+		if _, ok := sf.isIncludedPartialChunk(uint64(chunk.Index)); ok {
+			return nil // Ignore partial chunk
+		}
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Carry over the metadata of dst.
+	sf.staticMetadata.AccessTime = dst.staticMetadata.AccessTime
+	sf.staticMetadata.CreateTime = dst.staticMetadata.CreateTime
+	sf.staticMetadata.Spending = sf.staticMetadata.Spending.Add(dst.staticMetadata.Spending)
+	sf.staticMetadata.Mode = dst.staticMetadata.Mode
+	sf.staticMetadata.UserID = dst.staticMetadata.UserID
+	sf.staticMetadata.GroupID = dst.staticMetadata.GroupID
+	sf.staticMetadata.ChangeTime = time.Now()
+	// Move sf in memory.
+	sf.siaFilePath = dst.siaFilePath
+	// Write the header to the new location.
+	headerUpdate, err := sf.saveHeaderUpdates()
+	if err != nil {
+		return err
+	}
+	updates = append(updates, headerUpdate...)
+	// Write the chunks to the new location.
+	for _, chunk := range chunks {
+		updates = append(updates, sf.saveChunkUpdate(chunk))
+	}
+	// Apply updates.
+	err = createAndApplyTransaction(sf.wal, updates...)
+	if err != nil {
+		return err
+	}
+	dst.deleted = true
+	return nil
+}
+
 // SetMode sets the filemode of the sia file.
 func (sf *SiaFile) SetMode(mode os.FileMode) (err error) {
 	sf.mu.Lock()
@@ -507,6 +617,28 @@ func (sf *SiaFile) SetLastHealthCheckTime() {
 	sf.staticMetadata.LastHealthCheckTime = time.Now()
 }
 
+// AddSpending adds the provided spending to the money that has been spent on
+// behalf of the file and saves the metadata to disk.
+func (sf *SiaFile) AddSpending(spending modules.FileSpending) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Spending = sf.staticMetadata.Spending.Add(spending)
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLocalPath changes the local path of the file which is used to repair
 // the file from disk.
 func (sf *SiaFile) SetLocalPath(path string) (err error) {
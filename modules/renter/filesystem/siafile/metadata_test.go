@@ -136,6 +136,12 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		sf.staticMetadata.RepairBytes = fastrand.Uint64n(100)
 		sf.staticMetadata.StuckBytes = fastrand.Uint64n(100)
 		sf.staticMetadata.StuckHealth = float64(fastrand.Intn(100))
+		sf.staticMetadata.Spending = modules.FileSpending{
+			DownloadSpending: types.NewCurrency64(fastrand.Uint64n(100)),
+			RepairSpending:   types.NewCurrency64(fastrand.Uint64n(100)),
+			StorageSpending:  types.NewCurrency64(fastrand.Uint64n(100)),
+			UploadSpending:   types.NewCurrency64(fastrand.Uint64n(100)),
+		}
 		sf.staticMetadata.Mode = os.FileMode(fastrand.Intn(100))
 		sf.staticMetadata.UserID = int32(fastrand.Intn(100))
 		sf.staticMetadata.GroupID = int32(fastrand.Intn(100))
@@ -150,3 +156,47 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		t.Fatalf("metadata wasn't restored successfully %v %v", mdBefore, sf.staticMetadata)
 	}
 }
+
+// TestAddSpending checks that spending added to a SiaFile is summed up and
+// persisted to disk.
+func TestAddSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newBlankTestFile()
+	if !reflect.DeepEqual(sf.Spending(), modules.FileSpending{}) {
+		t.Fatal("new file should have no spending", sf.Spending())
+	}
+
+	// Add some spending twice.
+	spending := modules.FileSpending{
+		DownloadSpending: types.NewCurrency64(1),
+		RepairSpending:   types.NewCurrency64(2),
+		StorageSpending:  types.NewCurrency64(3),
+		UploadSpending:   types.NewCurrency64(4),
+	}
+	if err := sf.AddSpending(spending); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.AddSpending(spending); err != nil {
+		t.Fatal(err)
+	}
+	expected := spending.Add(spending)
+	if !reflect.DeepEqual(sf.Spending(), expected) {
+		t.Fatalf("spending mismatch: %v != %v", sf.Spending(), expected)
+	}
+	if !sf.Spending().Total().Equals(types.NewCurrency64(20)) {
+		t.Fatal("wrong total", sf.Spending().Total())
+	}
+
+	// Reload the file.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf2.Spending(), expected) {
+		t.Fatalf("spending mismatch after reload: %v != %v", sf2.Spending(), expected)
+	}
+}
@@ -0,0 +1,200 @@
+package siafile
+
+import (
+	"math"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// ErrNotPacked is returned when a method that requires a packed file is
+	// called on a regular file.
+	ErrNotPacked = errors.New("file is not packed")
+)
+
+// IsPacked returns true if the file's data is stored within a pack.
+func (sf *SiaFile) IsPacked() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return !sf.staticMetadata.PackedSiaPath.IsEmpty()
+}
+
+// PackedSiaPath returns the siapath of the pack which stores the file's data.
+func (sf *SiaFile) PackedSiaPath() modules.SiaPath {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.PackedSiaPath
+}
+
+// PackedOffset returns the offset of the file's data within the first chunk of
+// its pack.
+func (sf *SiaFile) PackedOffset() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.PackedOffset
+}
+
+// NumPackedFiles returns the number of packed files which still reference the
+// file as their pack.
+func (sf *SiaFile) NumPackedFiles() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.NumPackedFiles
+}
+
+// SetPacked marks the file as packed. Its data is then expected to be found
+// at the given offset within the first chunk of the pack at packSiaPath.
+func (sf *SiaFile) SetPacked(packSiaPath modules.SiaPath, offset uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't pack deleted file")
+	}
+	if packSiaPath.IsEmpty() {
+		return errors.New("can't pack file into a pack with an empty siapath")
+	}
+	if sf.numChunks != 1 {
+		return errors.New("only files which consist of a single chunk can be packed")
+	}
+	if offset+uint64(sf.staticMetadata.FileSize) > sf.staticChunkSize() {
+		return errors.New("packed file exceeds the boundary of the pack's chunk")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.PackedSiaPath = packSiaPath
+	sf.staticMetadata.PackedOffset = offset
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// AddPackedFiles increments the number of packed files which reference the
+// file as their pack by n.
+func (sf *SiaFile) AddPackedFiles(n uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't add packed files to deleted file")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.NumPackedFiles += n
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// RemovePackedFile decrements the number of packed files which reference the
+// file as their pack and returns the remaining number of references.
+func (sf *SiaFile) RemovePackedFile() (_ uint64, err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return 0, errors.AddContext(ErrDeleted, "can't remove packed file from deleted file")
+	}
+	if sf.staticMetadata.NumPackedFiles == 0 {
+		return 0, errors.New("file isn't referenced by any packed files")
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.NumPackedFiles--
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return 0, err
+	}
+	return sf.staticMetadata.NumPackedFiles, sf.createAndApplyTransaction(updates...)
+}
+
+// UpdatePackedMetadata sets the cached health, redundancy and upload progress
+// of a packed file to the ones of its pack. Since the pack's chunk can't be
+// stuck without the packed file's data being unavailable, the stuck fields
+// are left untouched. The change is persisted with the next metadata update.
+func (sf *SiaFile) UpdatePackedMetadata(pack Metadata) error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.staticMetadata.PackedSiaPath.IsEmpty() {
+		return ErrNotPacked
+	}
+	// The repair and uploaded bytes are attributed to the packed file
+	// proportionally to its share of the pack.
+	share := 1.0
+	if pack.FileSize > 0 {
+		share = math.Min(1, float64(sf.staticMetadata.FileSize)/float64(pack.FileSize))
+	}
+	sf.staticMetadata.CachedHealth = pack.CachedHealth
+	sf.staticMetadata.CachedRedundancy = pack.CachedRedundancy
+	sf.staticMetadata.CachedUserRedundancy = pack.CachedUserRedundancy
+	sf.staticMetadata.CachedExpiration = pack.CachedExpiration
+	sf.staticMetadata.CachedUploadProgress = pack.CachedUploadProgress
+	sf.staticMetadata.CachedRepairBytes = uint64(share * float64(pack.CachedRepairBytes))
+	sf.staticMetadata.CachedUploadedBytes = uint64(share * float64(pack.CachedUploadedBytes))
+	sf.staticMetadata.Health = pack.Health
+	sf.staticMetadata.Redundancy = pack.Redundancy
+	sf.staticMetadata.RepairBytes = uint64(share * float64(pack.RepairBytes))
+	return nil
+}
+
+// SnapshotPacked creates a snapshot of a packed file using the pack that
+// stores its data. The snapshot behaves like a snapshot of a regular file
+// whose data starts at the packed file's offset within the pack.
+func (sf *SiaFile) SnapshotPacked(sp modules.SiaPath, pack *SiaFile) (*Snapshot, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.readlockSnapshotPacked(sp, pack, 0, uint64(sf.staticMetadata.FileSize))
+}
+
+// SnapshotPackedRange creates a snapshot of a packed file like SnapshotPacked
+// but only includes the chunks of the pack that are needed for the range of
+// the file specified by offset and length.
+func (sf *SiaFile) SnapshotPackedRange(sp modules.SiaPath, pack *SiaFile, offset, length uint64) (*Snapshot, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.readlockSnapshotPacked(sp, pack, offset, length)
+}
+
+// readlockSnapshotPacked creates a snapshot of the range of a packed file
+// specified by offset and length.
+func (sf *SiaFile) readlockSnapshotPacked(sp modules.SiaPath, pack *SiaFile, offset, length uint64) (*Snapshot, error) {
+	if sf.staticMetadata.PackedSiaPath.IsEmpty() {
+		return nil, ErrNotPacked
+	}
+	snap, err := pack.SnapshotRange(sp, sf.staticMetadata.PackedOffset+offset, length)
+	if err != nil {
+		return nil, err
+	}
+	snap.staticFileSize = sf.staticMetadata.FileSize
+	snap.staticLocalPath = sf.staticMetadata.LocalPath
+	snap.staticMode = sf.staticMetadata.Mode
+	snap.staticUID = sf.staticMetadata.UniqueID
+	snap.staticPackedOffset = sf.staticMetadata.PackedOffset
+	return snap, nil
+}
@@ -0,0 +1,124 @@
+package siafile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPackedFile tests packing a file into a pack and creating snapshots of it.
+func TestPackedFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a pack with a single chunk and some pieces.
+	siaFilePath, _, _, rc, sk, _, _, fileMode := newTestFileParams(1, false)
+	chunkSize := (modules.SectorSize - sk.Type().Overhead()) * uint64(rc.MinPieces())
+	pack, wal, _ := customTestFileAndWAL(siaFilePath, "", rc, sk, chunkSize, 1, fileMode)
+	for pieceIndex := 0; pieceIndex < rc.NumPieces(); pieceIndex++ {
+		pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+		if err := pack.AddPiece(pk, 0, uint64(pieceIndex), crypto.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pack.AddPackedFiles(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a small file which shares the pack's key.
+	fileSize := uint64(fastrand.Intn(int(modules.SectorSize))) + 1
+	memberPath := filepath.Join(filepath.Dir(siaFilePath), "member"+modules.SiaFileExtension)
+	sf, err := New(memberPath, "", wal, rc, pack.MasterKey(), fileSize, fileMode, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.IsPacked() {
+		t.Fatal("file shouldn't be packed yet")
+	}
+
+	// Packing the file beyond the boundary of the pack's chunk should fail.
+	packSiaPath := modules.RandomSiaPath()
+	if err := sf.SetPacked(packSiaPath, chunkSize-fileSize+1); err == nil {
+		t.Fatal("file shouldn't fit into the chunk")
+	}
+	offset := chunkSize - fileSize
+	if err := sf.SetPacked(packSiaPath, offset); err != nil {
+		t.Fatal(err)
+	}
+
+	// The packing information should be persisted.
+	sf, err = LoadSiaFile(memberPath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.IsPacked() || !sf.PackedSiaPath().Equals(packSiaPath) || sf.PackedOffset() != offset {
+		t.Fatal("packing information wasn't persisted", sf.PackedSiaPath(), sf.PackedOffset())
+	}
+
+	// Create a snapshot of the packed file. It should have the pack's pieces
+	// but the file's size and offset.
+	sp := modules.RandomSiaPath()
+	snap, err := sf.SnapshotPacked(sp, pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Size() != fileSize || snap.PackedOffset() != offset || !snap.SiaPath().Equals(sp) {
+		t.Fatal("snapshot has wrong fields", snap.Size(), snap.PackedOffset())
+	}
+	pieces := snap.Pieces(0)
+	for pieceIndex := range pieces {
+		if len(pieces[pieceIndex]) != 1 {
+			t.Fatal("snapshot should contain the pack's pieces")
+		}
+	}
+	chunkIndex, off := snap.ChunkIndexByOffset(0)
+	if chunkIndex != 0 || off != offset {
+		t.Fatal("wrong chunk offset", chunkIndex, off)
+	}
+	chunkIndex, off = snap.ChunkIndexByOffset(fileSize)
+	if chunkIndex != 1 || off != 0 {
+		t.Fatal("wrong chunk offset", chunkIndex, off)
+	}
+
+	// A snapshot of a range of the file should map offsets the same way.
+	snap, err = sf.SnapshotPackedRange(sp, pack, fileSize-1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Size() != fileSize || len(snap.Pieces(0)[0]) != 1 {
+		t.Fatal("ranged snapshot has wrong fields", snap.Size())
+	}
+	chunkIndex, off = snap.ChunkIndexByOffset(fileSize - 1)
+	if chunkIndex != 0 || off != chunkSize-1 {
+		t.Fatal("wrong chunk offset", chunkIndex, off)
+	}
+
+	// A snapshot of a regular file can't be created this way.
+	if _, err := pack.SnapshotPacked(sp, pack); !errors.Contains(err, ErrNotPacked) {
+		t.Fatal("expected ErrNotPacked but got", err)
+	}
+
+	// Remove the references to the pack.
+	for remaining := uint64(1); ; remaining-- {
+		n, err := pack.RemovePackedFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != remaining {
+			t.Fatalf("expected %v remaining packed files but got %v", remaining, n)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if _, err := pack.RemovePackedFile(); err == nil {
+		t.Fatal("shouldn't be able to remove a packed file from an unreferenced pack")
+	}
+}
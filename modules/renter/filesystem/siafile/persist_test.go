@@ -62,6 +62,12 @@ func equalFiles(sf, sf2 *SiaFile) error {
 	sf2.staticMetadata.CreateTime = time.Time{}
 	sf2.staticMetadata.ModTime = time.Time{}
 	sf2.staticMetadata.LastHealthCheckTime = time.Time{}
+	// Compare the erasure coders by their identifiers since the encoders
+	// contain pools which can't be compared with DeepEqual.
+	if sf.staticMetadata.staticErasureCode.Identifier() != sf2.staticMetadata.staticErasureCode.Identifier() {
+		return errors.New("erasure coders don't match")
+	}
+	sf2.staticMetadata.staticErasureCode = sf.staticMetadata.staticErasureCode
 	// Compare the rest of sf and sf2.
 	if !reflect.DeepEqual(sf.staticMetadata, sf2.staticMetadata) {
 		fmt.Println(sf.staticMetadata)
@@ -476,6 +482,101 @@ func TestRename(t *testing.T) {
 	}
 }
 
+// TestReplace tests replacing a SiaFile with another one.
+func TestReplace(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	src := newTestFile()
+	dst := newBlankTestFile()
+	if err := dst.SetLocalPath("localpath"); err != nil {
+		t.Fatal(err)
+	}
+	srcLocalPath := src.LocalPath()
+	srcPath := src.SiaFilePath()
+	dstPath := dst.SiaFilePath()
+	srcUID := src.UID()
+	pieces, err := src.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A file can't replace itself.
+	if err := src.Replace(src); err == nil {
+		t.Fatal("expected error when replacing file with itself")
+	}
+
+	// Replace dst with src.
+	if err := src.Replace(dst); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Deleted() {
+		t.Fatal("dst should be deleted")
+	}
+	if src.SiaFilePath() != dstPath {
+		t.Fatal("src wasn't moved", src.SiaFilePath())
+	}
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Fatal("Expected a file doesn't exist error but got", err)
+	}
+	// Replacing a deleted file should fail.
+	if err := src.Replace(dst); !errors.Contains(err, ErrDeleted) {
+		t.Fatal("expected ErrDeleted but got", err)
+	}
+
+	// Load the file from disk and compare it.
+	sf, err := LoadSiaFile(dstPath, src.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.UID() != srcUID {
+		t.Fatal("file wasn't replaced")
+	}
+	if sf.LocalPath() != srcLocalPath {
+		t.Fatalf("expected local path %v but got %v", srcLocalPath, sf.LocalPath())
+	}
+	loadedPieces, err := sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pieces, loadedPieces) {
+		t.Fatal("pieces don't match")
+	}
+}
+
+// TestReplaceLocalPath checks that replacing a file with a local path doesn't
+// make the new file point at the local copy of the replaced file.
+func TestReplaceLocalPath(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	src := newBlankTestFile()
+	dst := newBlankTestFile()
+	if err := src.SetLocalPath(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.SetLocalPath("localpath"); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Replace(dst); err != nil {
+		t.Fatal(err)
+	}
+	if src.LocalPath() != "" {
+		t.Fatal("local path of replaced file was carried over", src.LocalPath())
+	}
+	sf, err := LoadSiaFile(src.SiaFilePath(), src.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.LocalPath() != "" {
+		t.Fatal("local path of replaced file was persisted", sf.LocalPath())
+	}
+}
+
 // TestApplyUpdates tests a variety of functions that are used to apply
 // updates.
 func TestApplyUpdates(t *testing.T) {
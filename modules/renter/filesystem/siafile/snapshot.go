@@ -19,6 +19,7 @@ type (
 	// representation of a siafile which only exists in memory.
 	Snapshot struct {
 		staticChunks          []Chunk
+		staticDedupIDs        []DedupID
 		staticFileSize        int64
 		staticPieceSize       uint64
 		staticErasureCode     modules.ErasureCoder
@@ -29,6 +30,7 @@ type (
 		staticSiaPath         modules.SiaPath
 		staticLocalPath       string
 		staticPartialChunks   []PartialChunkInfo
+		staticPackedOffset    uint64
 		staticUID             SiafileUID
 	}
 )
@@ -92,6 +94,8 @@ func (sf *SiaFile) SnapshotReader() (*SnapshotReader, error) {
 // offset of a file and also the relative offset within the chunk. If the
 // offset is out of bounds, chunkIndex will be equal to NumChunk().
 func (s *Snapshot) ChunkIndexByOffset(offset uint64) (chunkIndex uint64, off uint64) {
+	// The data of a packed file starts at its offset within the pack.
+	offset += s.staticPackedOffset
 	chunkIndex = offset / s.ChunkSize()
 	off = offset % s.ChunkSize()
 	// If the offset points within a partial chunk, we need to adjust our
@@ -108,6 +112,11 @@ func (s *Snapshot) ChunkIndexByOffset(offset uint64) (chunkIndex uint64, off uin
 	return
 }
 
+// PackedOffset returns the offset of a packed file's data within its pack.
+func (s *Snapshot) PackedOffset() uint64 {
+	return s.staticPackedOffset
+}
+
 // ChunkSize returns the size of a single chunk of the file.
 func (s *Snapshot) ChunkSize() uint64 {
 	return s.staticPieceSize * uint64(s.staticErasureCode.MinPieces())
@@ -154,6 +163,15 @@ func (s *Snapshot) MasterKey() crypto.CipherKey {
 	return s.staticMasterKey
 }
 
+// ChunkMasterKey returns the key used to derive the keys of the pieces of the
+// chunk at chunkIndex.
+func (s *Snapshot) ChunkMasterKey(chunkIndex uint64) crypto.CipherKey {
+	if s.staticDedupIDs == nil {
+		return s.staticMasterKey
+	}
+	return chunkMasterKey(s.staticMasterKey, s.staticDedupIDs[chunkIndex])
+}
+
 // Mode returns the FileMode of the file.
 func (s *Snapshot) Mode() os.FileMode {
 	return s.staticMode
@@ -237,7 +255,14 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 
 	// Copy chunks.
 	exportedChunks := make([]Chunk, 0, len(chunks))
-	for _, chunk := range chunks {
+	var dedupIDs []DedupID
+	if sf.staticMetadata.Dedup {
+		dedupIDs = make([]DedupID, len(chunks))
+	}
+	for i, chunk := range chunks {
+		if dedupIDs != nil {
+			dedupIDs[i] = DedupID(chunk.ExtensionInfo)
+		}
 		// Handle complete partial chunk.
 		if cci, ok := sf.isIncludedPartialChunk(uint64(chunk.Index)); ok {
 			pieces, err := sf.partialsSiaFile.Pieces(cci.Index)
@@ -283,6 +308,7 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 
 	return &Snapshot{
 		staticChunks:          exportedChunks,
+		staticDedupIDs:        dedupIDs,
 		staticPartialChunks:   pcs,
 		staticHasPartialChunk: hasPartial,
 		staticFileSize:        fileSize,
@@ -51,25 +51,39 @@ type fuseDirnode struct {
 //
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the directory.
+//
+// NodeCreater, NodeMkdirer, NodeRenamer, NodeRmdirer and NodeUnlinker are
+// necessary for read-write mounts.
 var _ = (fs.NodeAccesser)((*fuseDirnode)(nil))
+var _ = (fs.NodeCreater)((*fuseDirnode)(nil))
 var _ = (fs.NodeFlusher)((*fuseDirnode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseDirnode)(nil))
 var _ = (fs.NodeLookuper)((*fuseDirnode)(nil))
+var _ = (fs.NodeMkdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeReaddirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRenamer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRmdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeStatfser)((*fuseDirnode)(nil))
+var _ = (fs.NodeUnlinker)((*fuseDirnode)(nil))
 
 // fuseFilenode is a fuse node for the fs package that covers a siafile.
 //
 // Data is fetched using a download streamer. This download streamer needs to be
-// closed when the filehandle is released.
+// closed when the filehandle is released. Files which are opened for writing
+// use a fuseWriteHandle instead.
+//
+// Uploading a file which was opened for writing replaces the siafile, so the
+// file node is swapped for the node of the uploaded file afterwards. The file
+// node is protected by its own mutex to keep Getattr from blocking on reads.
 type fuseFilenode struct {
-	atomicClosed uint32
-
 	fs.Inode
 	staticFilesystem *fuseFS
-	staticFileNode   *filesystem.FileNode
 	stream           modules.Streamer
 	mu               sync.Mutex
+
+	closed   bool
+	fileNode *filesystem.FileNode
+	nodeMu   sync.Mutex
 }
 
 // Ensure the file nodes satisfy the required interfaces.
@@ -77,8 +91,7 @@ type fuseFilenode struct {
 // NodeAccesser is necessary for telling certain programs that it is okay to
 // access the file.
 //
-// NodeFlusher is necessary for cleaning up resources such as the download
-// streamer.
+// NodeFlusher is necessary for uploading files which were opened for writing.
 //
 // NodeGetattrer is necessary for providing the filesize to file browsers.
 //
@@ -86,19 +99,28 @@ type fuseFilenode struct {
 //
 // NodeReader is necessary for reading files.
 //
+// NodeReleaser is necessary for cleaning up resources such as the download
+// streamer.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the file.
+//
+// NodeSetattrer and NodeWriter are necessary for read-write mounts.
 var _ = (fs.NodeAccesser)((*fuseFilenode)(nil))
 var _ = (fs.NodeFlusher)((*fuseFilenode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeOpener)((*fuseFilenode)(nil))
 var _ = (fs.NodeReader)((*fuseFilenode)(nil))
+var _ = (fs.NodeReleaser)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeStatfser)((*fuseFilenode)(nil))
+var _ = (fs.NodeWriter)((*fuseFilenode)(nil))
 
 // fuseRoot is the root directory for a mounted fuse filesystem.
 type fuseFS struct {
-	options modules.MountOptions
-	root    *fuseDirnode
+	options  modules.MountOptions
+	root     *fuseDirnode
+	spoolDir string
 
 	renter *Renter
 	server *fuse.Server
@@ -108,12 +130,40 @@ type fuseFS struct {
 func errToStatus(err error) syscall.Errno {
 	if err == nil {
 		return syscall.F_OK
-	} else if errors.IsOSNotExist(err) {
+	} else if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
 		return syscall.ENOENT
+	} else if errors.Contains(err, filesystem.ErrExists) {
+		return syscall.EEXIST
 	}
 	return syscall.EIO
 }
 
+// managedFileNode returns the file node of the fuse file.
+func (ffn *fuseFilenode) managedFileNode() *filesystem.FileNode {
+	ffn.nodeMu.Lock()
+	defer ffn.nodeMu.Unlock()
+	return ffn.fileNode
+}
+
+// managedReplaceFileNode swaps the file node of the fuse file for the node of
+// the siafile which replaced it and closes the replaced node. If the fuse file
+// was already released, the new node is closed right away instead.
+func (ffn *fuseFilenode) managedReplaceFileNode(siaPath modules.SiaPath) error {
+	fileNode, err := ffn.staticFilesystem.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	ffn.nodeMu.Lock()
+	defer ffn.nodeMu.Unlock()
+	if ffn.closed {
+		ffn.fileNode = fileNode
+		return fileNode.Close()
+	}
+	oldNode := ffn.fileNode
+	ffn.fileNode = fileNode
+	return oldNode.Close()
+}
+
 // Access reports whether a directory can be accessed by the caller.
 func (fdn *fuseDirnode) Access(ctx context.Context, mask uint32) syscall.Errno {
 	// TODO: parse the mask and return a more correct value instead of always
@@ -138,30 +188,11 @@ func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Err
 	return errToStatus(err)
 }
 
-// Flush is called when a file is being closed.
+// Flush is called when a file is being closed. Files which were opened for
+// writing are uploaded.
 func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
-	swapped := atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1)
-	if !swapped {
-		return errToStatus(nil)
-	}
-	ffn.mu.Lock()
-	defer ffn.mu.Unlock()
-
-	// If a stream was opened for the file, the stream must now be closed.
-	var streamErr error
-	if ffn.stream != nil {
-		// Need to 'nil' out the stream once 'Flush' has been called because it
-		// can be called multiple times.
-		streamErr = ffn.stream.Close()
-	}
-
-	// Check all of the errors.
-	closeErr := ffn.staticFileNode.Close()
-	err := errors.Compose(streamErr, closeErr)
-	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
-		ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, err)
-		return errToStatus(err)
+	if wh, ok := fh.(*fuseWriteHandle); ok {
+		return wh.Flush(ctx)
 	}
 	return errToStatus(nil)
 }
@@ -182,7 +213,7 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		// Convert the file to an inode.
 		filenode := &fuseFilenode{
 			staticFilesystem: fdn.staticFilesystem,
-			staticFileNode:   fileNode,
+			fileNode:         fileNode,
 		}
 		attrs := fs.StableAttr{
 			Ino:  fileInfo.UID,
@@ -245,7 +276,10 @@ func (fdn *fuseDirnode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse
 // Getattr should try to minimize lock contention and should run very quickly if
 // possible.
 func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
-	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.staticFileNode)
+	if wh, ok := fh.(*fuseWriteHandle); ok {
+		return wh.Getattr(ctx, out)
+	}
+	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.managedFileNode())
 	if err != nil {
 		ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from file: %v", err)
 	}
@@ -256,18 +290,34 @@ func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fus
 	return errToStatus(nil)
 }
 
-// Open will open a streamer for the file.
+// Open will open a streamer for the file. If the file is opened for writing, a
+// write handle is returned instead.
 //
 // TODO: Currently 'Open' returns '0' for the fuseFlags. I was unable to figure
 // out from the documentation what the flags are supposed to represent. So far,
 // this has not seemed to cause problems.
 func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if isWriteOpen(flags) {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return nil, 0, syscall.EROFS
+		}
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
+		truncate := flags&syscall.O_TRUNC != 0
+		wh, err := ffn.staticFilesystem.managedOpenWriteHandle(siaPath, truncate, ffn)
+		if err != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to open file %v for writing: %v", siaPath, err)
+			return nil, 0, errToStatus(err)
+		}
+		wh.dirty = truncate
+		return wh, 0, errToStatus(nil)
+	}
+
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
-	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.staticFileNode, false)
+	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.managedFileNode(), false)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", siaPath, err)
 		return nil, 0, errToStatus(err)
 	}
@@ -277,6 +327,10 @@ func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle,
 
 // Read will read data from the file and place it in dest.
 func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte, offset int64) (fuse.ReadResult, syscall.Errno) {
+	if wh, ok := f.(*fuseWriteHandle); ok {
+		return wh.Read(ctx, dest, offset)
+	}
+
 	// TODO: Right now only one call to Read from a file can be in effect at
 	// once, based on the way the streamer and the read call has been
 	// implemented. As the streamer gets updated to more readily support
@@ -287,7 +341,7 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 
 	_, err := ffn.stream.Seek(offset, io.SeekStart)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error seeking to offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -298,7 +352,7 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	// often dropping parts of the tail of the file.
 	n, err := io.ReadFull(ffn.stream, dest)
 	if err != nil && !errors.Contains(err, io.EOF) && err != io.ErrUnexpectedEOF {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error reading from offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -339,6 +393,40 @@ func (fdn *fuseDirnode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 	return fs.NewListDirStream(dirEntries), errToStatus(nil)
 }
 
+// Release is called when a file handle is released. The download streamer and
+// the file node are closed after the write handle of files which were opened
+// for writing is released, which swaps in the node of the uploaded file.
+func (ffn *fuseFilenode) Release(ctx context.Context, fh fs.FileHandle) syscall.Errno {
+	var releaseErrno syscall.Errno
+	if wh, ok := fh.(*fuseWriteHandle); ok {
+		releaseErrno = wh.Release(ctx)
+	}
+	ffn.mu.Lock()
+	defer ffn.mu.Unlock()
+	ffn.nodeMu.Lock()
+	defer ffn.nodeMu.Unlock()
+	if ffn.closed {
+		return releaseErrno
+	}
+	ffn.closed = true
+
+	// If a stream was opened for the file, the stream must now be closed.
+	var streamErr error
+	if ffn.stream != nil {
+		streamErr = ffn.stream.Close()
+	}
+
+	// Check all of the errors.
+	closeErr := ffn.fileNode.Close()
+	err := errors.Compose(streamErr, closeErr)
+	if err != nil {
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.fileNode)
+		ffn.staticFilesystem.renter.log.Printf("error when releasing fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return releaseErrno
+}
+
 // setStatfsOut is a method that will set the StatfsOut fields which are
 // consistent across the fuse filesystem.
 func (ffs *fuseFS) setStatfsOut(out *fuse.StatfsOut) error {
@@ -402,7 +490,7 @@ func (fdn *fuseDirnode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall
 func (ffn *fuseFilenode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall.Errno {
 	err := ffn.staticFilesystem.setStatfsOut(out)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
 		ffn.staticFilesystem.renter.log.Printf("Error fetching statfs for fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
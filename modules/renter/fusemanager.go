@@ -5,6 +5,8 @@ package renter
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/hanwen/go-fuse/v2/fs"
@@ -31,6 +33,13 @@ func newFuseManager(r *Renter) renterFuseManager {
 		renter:      r,
 	}
 
+	// Remove spool files which were left behind by a previous run. Nothing is
+	// mounted yet so none of them are in use.
+	err := os.RemoveAll(filepath.Join(r.persistDir, fuseSpoolDir))
+	if err != nil {
+		r.log.Printf("Unable to remove fuse spool dir: %v", err)
+	}
+
 	// Close the fuse manager on shutdown.
 	r.tg.OnStop(func() error {
 		return fm.managedCloseFuseManager()
@@ -99,9 +108,13 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.SiaPath, opts modules
 		}
 	}()
 
-	// Enforce read-only requirements for now.
+	// Create the spool dir for files which are opened for writing.
+	spoolDir := filepath.Join(fm.renter.persistDir, fuseSpoolDir)
 	if !opts.ReadOnly {
-		return errors.New("fuse must be mounted as read-only")
+		err = os.MkdirAll(spoolDir, 0700)
+		if err != nil {
+			return errors.AddContext(err, "unable to create fuse spool dir")
+		}
 	}
 
 	// Get the mountpoint's root from the filesystem.
@@ -111,7 +124,8 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.SiaPath, opts modules
 	}
 	// Create the fuse filesystem object.
 	filesystem := &fuseFS{
-		options: opts,
+		options:  opts,
+		spoolDir: spoolDir,
 
 		renter: fm.renter,
 	}
@@ -0,0 +1,569 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package renter
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+const (
+	// fuseRenameNoReplace is the RENAME_NOREPLACE flag of renameat2(2).
+	fuseRenameNoReplace = 0x1
+
+	// fuseSpoolDir is the directory within the renter's persist dir which
+	// contains the local copies of files which were opened for writing through
+	// a fuse mount.
+	fuseSpoolDir = "fusespool"
+)
+
+var (
+	// fuseTmpFolder is the folder which contains the siafiles uploaded by fuse
+	// mounts. Once an upload is complete, the siafile replaces the file which
+	// was written to.
+	fuseTmpFolder = modules.NewGlobalSiaPath("/var/fuse")
+)
+
+// fuseNewFilenode is a fuse node for a file which was created through a
+// read-write mount. Until the file is flushed for the first time, the file only
+// exists in the local spool. Once the kernel looks up the file again, it is
+// replaced by a regular fuseFilenode.
+type fuseNewFilenode struct {
+	fs.Inode
+	staticFilesystem *fuseFS
+	staticSiaPath    modules.SiaPath
+}
+
+// Ensure the new file nodes satisfy the required interfaces.
+//
+// NodeFlusher, NodeGetattrer, NodeReader, NodeSetattrer and NodeWriter forward
+// to the open file handle.
+//
+// NodeOpener is necessary for opening the file again before the kernel looks
+// it up again.
+var _ = (fs.NodeFlusher)((*fuseNewFilenode)(nil))
+var _ = (fs.NodeGetattrer)((*fuseNewFilenode)(nil))
+var _ = (fs.NodeOpener)((*fuseNewFilenode)(nil))
+var _ = (fs.NodeReader)((*fuseNewFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseNewFilenode)(nil))
+var _ = (fs.NodeWriter)((*fuseNewFilenode)(nil))
+
+// fuseWriteHandle is the file handle of a file which was opened for writing.
+//
+// All writes go to a local spool file. When the handle is flushed, the spool
+// file is uploaded to a temporary siafile which then replaces the file. The
+// spool file is deleted when the handle is released. If the file was opened
+// through a fuseFilenode, the node's file node is swapped for the uploaded file
+// after every upload.
+type fuseWriteHandle struct {
+	dirty bool
+	spool *os.File
+
+	staticFilenode   *fuseFilenode
+	staticFilesystem *fuseFS
+	staticSiaPath    modules.SiaPath
+	mu               sync.Mutex
+}
+
+// Ensure the write handles satisfy the required interfaces.
+var _ = (fs.FileFlusher)((*fuseWriteHandle)(nil))
+var _ = (fs.FileFsyncer)((*fuseWriteHandle)(nil))
+var _ = (fs.FileGetattrer)((*fuseWriteHandle)(nil))
+var _ = (fs.FileReader)((*fuseWriteHandle)(nil))
+var _ = (fs.FileReleaser)((*fuseWriteHandle)(nil))
+var _ = (fs.FileSetattrer)((*fuseWriteHandle)(nil))
+var _ = (fs.FileWriter)((*fuseWriteHandle)(nil))
+
+// isWriteOpen returns true if the open flags allow writing to a file.
+func isWriteOpen(flags uint32) bool {
+	return flags&syscall.O_ACCMODE != syscall.O_RDONLY || flags&syscall.O_TRUNC != 0
+}
+
+// managedOpenWriteHandle opens a write handle for the file at siaPath. Unless
+// truncate is set, the spool is initialized with the current contents of the
+// file which requires downloading the whole file. The filenode is optional and
+// is updated after every upload.
+func (ffs *fuseFS) managedOpenWriteHandle(siaPath modules.SiaPath, truncate bool, filenode *fuseFilenode) (_ *fuseWriteHandle, err error) {
+	spool, err := ioutil.TempFile(ffs.spoolDir, "spool-")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create spool file")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, spool.Close(), os.Remove(spool.Name()))
+		}
+	}()
+	if !truncate {
+		_, stream, err := ffs.renter.Streamer(siaPath, false)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return nil, errors.AddContext(err, "unable to open stream")
+		}
+		if err == nil {
+			_, err = io.Copy(spool, stream)
+			err = errors.Compose(err, stream.Close())
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to copy file to spool")
+			}
+		}
+	}
+	return &fuseWriteHandle{
+		spool:            spool,
+		staticFilenode:   filenode,
+		staticFilesystem: ffs,
+		staticSiaPath:    siaPath,
+	}, nil
+}
+
+// managedTruncate truncates the file at siaPath to the provided size without
+// an open file handle. The filenode is optional and is updated after the
+// truncated file is uploaded.
+func (ffs *fuseFS) managedTruncate(ctx context.Context, siaPath modules.SiaPath, size uint64, filenode *fuseFilenode) syscall.Errno {
+	wh, err := ffs.managedOpenWriteHandle(siaPath, size == 0, filenode)
+	if err != nil {
+		ffs.renter.log.Printf("Unable to open fuse file %v for truncating: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	defer wh.Release(ctx)
+	var out fuse.AttrOut
+	in := &fuse.SetAttrIn{SetAttrInCommon: fuse.SetAttrInCommon{Valid: fuse.FATTR_SIZE, Size: size}}
+	if errno := wh.Setattr(ctx, in, &out); errno != 0 {
+		return errno
+	}
+	return wh.Flush(ctx)
+}
+
+// upload uploads the spool file and replaces the file with the uploaded data.
+func (wh *fuseWriteHandle) upload() error {
+	r := wh.staticFilesystem.renter
+	fi, err := wh.spool.Stat()
+	if err != nil {
+		return errors.AddContext(err, "unable to stat spool file")
+	}
+	tmpSiaPath, err := fuseTmpFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary siapath")
+	}
+	err = r.UploadStreamFromReader(modules.FileUploadParams{
+		SiaPath: tmpSiaPath,
+	}, io.NewSectionReader(wh.spool, 0, fi.Size()))
+	if err != nil {
+		// The upload might have created the file before it failed.
+		deleteErr := r.DeleteFile(tmpSiaPath)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+		return errors.AddContext(err, "unable to upload spool file")
+	}
+	err = r.ReplaceFile(wh.staticSiaPath, tmpSiaPath)
+	if err != nil {
+		err = errors.Compose(err, r.DeleteFile(tmpSiaPath))
+		return errors.AddContext(err, "unable to replace file with uploaded file")
+	}
+	wh.dirty = false
+
+	// The replaced file was deleted, so the fuse file needs to use the node of
+	// the uploaded file from now on.
+	if wh.staticFilenode != nil {
+		err = wh.staticFilenode.managedReplaceFileNode(wh.staticSiaPath)
+		if err != nil {
+			return errors.AddContext(err, "unable to open uploaded file")
+		}
+	}
+	return nil
+}
+
+// Flush uploads the file if it was written to since the last flush. Flush is
+// called for every close(2) of the file, so the error of the upload is
+// returned to the application closing the file.
+func (wh *fuseWriteHandle) Flush(ctx context.Context) syscall.Errno {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if !wh.dirty {
+		return errToStatus(nil)
+	}
+	err := wh.upload()
+	if err != nil {
+		wh.staticFilesystem.renter.log.Printf("Unable to upload fuse file %v: %v", wh.staticSiaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// Fsync uploads the file if it was written to since the last flush.
+func (wh *fuseWriteHandle) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	return wh.Flush(ctx)
+}
+
+// Getattr returns the attributes of the spool file.
+func (wh *fuseWriteHandle) Getattr(ctx context.Context, out *fuse.AttrOut) syscall.Errno {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	fi, err := wh.spool.Stat()
+	if err != nil {
+		return errToStatus(err)
+	}
+	out.Size = uint64(fi.Size())
+	out.Mode = uint32(defaultFilePerm) | syscall.S_IFREG
+	modTime := fi.ModTime()
+	out.SetTimes(nil, &modTime, nil)
+	return errToStatus(nil)
+}
+
+// Read reads from the spool file.
+func (wh *fuseWriteHandle) Read(ctx context.Context, dest []byte, offset int64) (fuse.ReadResult, syscall.Errno) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	n, err := wh.spool.ReadAt(dest, offset)
+	if err != nil && !errors.Contains(err, io.EOF) {
+		return nil, errToStatus(err)
+	}
+	return fuse.ReadResultData(dest[:n]), errToStatus(nil)
+}
+
+// Release deletes the spool file. Files which weren't flushed are uploaded
+// first. The kernel ignores the error of Release.
+func (wh *fuseWriteHandle) Release(ctx context.Context) syscall.Errno {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	var err error
+	if wh.dirty {
+		err = errors.AddContext(wh.upload(), "unable to upload unflushed file")
+	}
+	err = errors.Compose(err, wh.spool.Close(), os.Remove(wh.spool.Name()))
+	if err != nil {
+		wh.staticFilesystem.renter.log.Printf("Error when releasing fuse file %v: %v", wh.staticSiaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// Setattr truncates the spool file if a new size is provided. Changes to the
+// mode, owner and times of the file are ignored.
+func (wh *fuseWriteHandle) Setattr(ctx context.Context, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if size, ok := in.GetSize(); ok {
+		wh.mu.Lock()
+		err := wh.spool.Truncate(int64(size))
+		wh.dirty = true
+		wh.mu.Unlock()
+		if err != nil {
+			return errToStatus(err)
+		}
+	}
+	return wh.Getattr(ctx, out)
+}
+
+// Write writes to the spool file.
+func (wh *fuseWriteHandle) Write(ctx context.Context, data []byte, offset int64) (uint32, syscall.Errno) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	n, err := wh.spool.WriteAt(data, offset)
+	wh.dirty = true
+	return uint32(n), errToStatus(err)
+}
+
+// Create creates a new file in the directory and opens it for writing. The
+// file is uploaded once it is flushed.
+func (fdn *fuseDirnode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, nil, 0, syscall.EROFS
+	}
+	siaPath, errno := fdn.childSiaPath(name)
+	if errno != 0 {
+		return nil, nil, 0, errno
+	}
+	wh, err := fdn.staticFilesystem.managedOpenWriteHandle(siaPath, flags&syscall.O_TRUNC != 0, nil)
+	if err != nil {
+		fdn.staticFilesystem.renter.log.Printf("Unable to create fuse file %v: %v", siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	// Mark the handle as dirty to make sure that empty files are uploaded
+	// too.
+	wh.dirty = true
+
+	filenode := &fuseNewFilenode{
+		staticFilesystem: fdn.staticFilesystem,
+		staticSiaPath:    siaPath,
+	}
+	var attr fuse.AttrOut
+	wh.Getattr(ctx, &attr)
+	out.Attr = attr.Attr
+	inode := fdn.NewInode(ctx, filenode, fs.StableAttr{Mode: fuse.S_IFREG})
+	return inode, wh, 0, errToStatus(nil)
+}
+
+// Mkdir creates a new directory.
+func (fdn *fuseDirnode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, syscall.EROFS
+	}
+	siaPath, errno := fdn.childSiaPath(name)
+	if errno != 0 {
+		return nil, errno
+	}
+	r := fdn.staticFilesystem.renter
+	err := r.CreateDir(siaPath, os.FileMode(mode).Perm())
+	if err != nil {
+		r.log.Printf("Unable to create fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	dirNode, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return nil, errToStatus(err)
+	}
+	dirInfo, err := r.staticFileSystem.DirNodeInfo(dirNode)
+	if err != nil {
+		return nil, errToStatus(errors.Compose(err, dirNode.Close()))
+	}
+	dirnode := &fuseDirnode{
+		staticDirNode:    dirNode,
+		staticFilesystem: fdn.staticFilesystem,
+	}
+	out.Ino = dirInfo.UID
+	out.Mode = uint32(dirInfo.Mode())
+	inode := fdn.NewInode(ctx, dirnode, fs.StableAttr{Ino: dirInfo.UID, Mode: fuse.S_IFDIR})
+	return inode, errToStatus(nil)
+}
+
+// Rename moves a file or directory. Existing files are replaced and existing
+// directories are only replaced if they are empty.
+func (fdn *fuseDirnode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	if flags&fs.RENAME_EXCHANGE != 0 {
+		return syscall.ENOTSUP
+	}
+	newParentDir, ok := newParent.(*fuseDirnode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	oldSiaPath, errno := fdn.childSiaPath(name)
+	if errno != 0 {
+		return errno
+	}
+	newSiaPath, errno := newParentDir.childSiaPath(newName)
+	if errno != 0 {
+		return errno
+	}
+	r := fdn.staticFilesystem.renter
+
+	// Check whether a file or a dir is renamed.
+	isFile, err := r.staticFileSystem.FileExists(oldSiaPath)
+	if err != nil {
+		return errToStatus(err)
+	}
+
+	// Check the destination. Existing files are replaced atomically, empty
+	// dirs are removed before the rename.
+	destIsFile, err := r.staticFileSystem.FileExists(newSiaPath)
+	if err != nil {
+		return errToStatus(err)
+	}
+	destIsDir, err := r.staticFileSystem.DirExists(newSiaPath)
+	if err != nil {
+		return errToStatus(err)
+	}
+	switch {
+	case (destIsFile || destIsDir) && flags&fuseRenameNoReplace != 0:
+		return syscall.EEXIST
+	case destIsFile && !isFile:
+		return syscall.ENOTDIR
+	case destIsDir && isFile:
+		return syscall.EISDIR
+	case destIsDir:
+		if errno := fdn.staticFilesystem.managedCheckEmpty(newSiaPath); errno != 0 {
+			return errno
+		}
+		if err := r.DeleteDir(newSiaPath); err != nil {
+			r.log.Printf("Unable to replace %v with %v: %v", newSiaPath, oldSiaPath, err)
+			return errToStatus(err)
+		}
+	}
+
+	if isFile {
+		err = r.ReplaceFile(newSiaPath, oldSiaPath)
+	} else {
+		err = r.RenameDir(oldSiaPath, newSiaPath)
+	}
+	if err != nil {
+		r.log.Printf("Unable to rename %v to %v: %v", oldSiaPath, newSiaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// Rmdir deletes an empty directory.
+func (fdn *fuseDirnode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	siaPath, errno := fdn.childSiaPath(name)
+	if errno != 0 {
+		return errno
+	}
+	if errno := fdn.staticFilesystem.managedCheckEmpty(siaPath); errno != 0 {
+		return errno
+	}
+	err := fdn.staticFilesystem.renter.DeleteDir(siaPath)
+	if err != nil {
+		fdn.staticFilesystem.renter.log.Printf("Unable to delete fuse dir %v: %v", siaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// Unlink deletes a file.
+func (fdn *fuseDirnode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	siaPath, errno := fdn.childSiaPath(name)
+	if errno != 0 {
+		return errno
+	}
+	err := fdn.staticFilesystem.renter.DeleteFile(siaPath)
+	if err != nil {
+		fdn.staticFilesystem.renter.log.Printf("Unable to delete fuse file %v: %v", siaPath, err)
+	}
+	return errToStatus(err)
+}
+
+// childSiaPath returns the siapath of a child of the directory.
+func (fdn *fuseDirnode) childSiaPath(name string) (modules.SiaPath, syscall.Errno) {
+	dirSiaPath := fdn.staticFilesystem.renter.staticFileSystem.DirSiaPath(fdn.staticDirNode)
+	siaPath, err := dirSiaPath.Join(name)
+	if err != nil {
+		return modules.SiaPath{}, syscall.EINVAL
+	}
+	return siaPath, 0
+}
+
+// managedCheckEmpty returns ENOTEMPTY if the directory at siaPath contains
+// any files or directories. The renter deletes directories recursively but
+// rmdir(2) is only allowed to delete empty directories.
+func (ffs *fuseFS) managedCheckEmpty(siaPath modules.SiaPath) syscall.Errno {
+	var mu sync.Mutex
+	var entries int
+	err := ffs.renter.staticFileSystem.CachedList(siaPath, false, func(modules.FileInfo) {
+		mu.Lock()
+		entries++
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {
+		mu.Lock()
+		entries++
+		mu.Unlock()
+	})
+	if err != nil {
+		return errToStatus(err)
+	}
+	// The listing contains the directory itself.
+	if entries > 1 {
+		return syscall.ENOTEMPTY
+	}
+	return errToStatus(nil)
+}
+
+// Write writes to a file which was opened for writing.
+func (ffn *fuseFilenode) Write(ctx context.Context, f fs.FileHandle, data []byte, offset int64) (uint32, syscall.Errno) {
+	wh, ok := f.(*fuseWriteHandle)
+	if !ok {
+		return 0, syscall.EBADF
+	}
+	return wh.Write(ctx, data, offset)
+}
+
+// Setattr truncates the file if a new size is provided. Changes to the mode,
+// owner and times of the file are ignored.
+func (ffn *fuseFilenode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if wh, ok := f.(*fuseWriteHandle); ok {
+		return wh.Setattr(ctx, in, out)
+	}
+	if size, ok := in.GetSize(); ok {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return syscall.EROFS
+		}
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileSiaPath(ffn.managedFileNode())
+		if errno := ffn.staticFilesystem.managedTruncate(ctx, siaPath, size, ffn); errno != 0 {
+			return errno
+		}
+	}
+	return ffn.Getattr(ctx, f, out)
+}
+
+// Flush uploads the file if it was written to since the last flush.
+func (fnn *fuseNewFilenode) Flush(ctx context.Context, f fs.FileHandle) syscall.Errno {
+	if wh, ok := f.(*fuseWriteHandle); ok {
+		return wh.Flush(ctx)
+	}
+	return errToStatus(nil)
+}
+
+// Getattr returns the attributes of the open file or of the uploaded file.
+func (fnn *fuseNewFilenode) Getattr(ctx context.Context, f fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	if wh, ok := f.(*fuseWriteHandle); ok {
+		return wh.Getattr(ctx, out)
+	}
+	fileInfo, err := fnn.staticFilesystem.renter.staticFileSystem.CachedFileInfo(fnn.staticSiaPath)
+	if err != nil {
+		return errToStatus(err)
+	}
+	out.Size = fileInfo.Filesize
+	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
+	out.SetTimes(nil, &fileInfo.ModificationTime, nil)
+	return errToStatus(nil)
+}
+
+// Open opens the file again. Since the file might not have been uploaded yet,
+// the file is always opened through the spool.
+func (fnn *fuseNewFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if isWriteOpen(flags) && fnn.staticFilesystem.options.ReadOnly {
+		return nil, 0, syscall.EROFS
+	}
+	wh, err := fnn.staticFilesystem.managedOpenWriteHandle(fnn.staticSiaPath, flags&syscall.O_TRUNC != 0, nil)
+	if err != nil {
+		fnn.staticFilesystem.renter.log.Printf("Unable to open fuse file %v: %v", fnn.staticSiaPath, err)
+		return nil, 0, errToStatus(err)
+	}
+	wh.dirty = flags&syscall.O_TRUNC != 0
+	return wh, 0, errToStatus(nil)
+}
+
+// Read reads from the open file.
+func (fnn *fuseNewFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte, offset int64) (fuse.ReadResult, syscall.Errno) {
+	wh, ok := f.(*fuseWriteHandle)
+	if !ok {
+		return nil, syscall.EBADF
+	}
+	return wh.Read(ctx, dest, offset)
+}
+
+// Setattr truncates the file if a new size is provided.
+func (fnn *fuseNewFilenode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if wh, ok := f.(*fuseWriteHandle); ok {
+		return wh.Setattr(ctx, in, out)
+	}
+	if size, ok := in.GetSize(); ok {
+		if errno := fnn.staticFilesystem.managedTruncate(ctx, fnn.staticSiaPath, size, nil); errno != 0 {
+			return errno
+		}
+	}
+	return fnn.Getattr(ctx, f, out)
+}
+
+// Write writes to the open file.
+func (fnn *fuseNewFilenode) Write(ctx context.Context, f fs.FileHandle, data []byte, offset int64) (uint32, syscall.Errno) {
+	wh, ok := f.(*fuseWriteHandle)
+	if !ok {
+		return 0, syscall.EBADF
+	}
+	return wh.Write(ctx, data, offset)
+}
@@ -170,9 +170,11 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			}
 
 			// Update aggregate fields.
+			metadata.AggregateDedupSavings += fileMetadata.DedupSavings
 			metadata.AggregateNumFiles++
 			metadata.AggregateNumStuckChunks += fileMetadata.NumStuckChunks
 			metadata.AggregateSize += fileMetadata.Size
+			metadata.AggregateSpending = metadata.AggregateSpending.Add(fileMetadata.Spending)
 
 			// Update siadir fields.
 			metadata.Health = math.Max(metadata.Health, fileMetadata.Health)
@@ -190,7 +192,9 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			if !fileMetadata.OnDisk {
 				metadata.RemoteHealth = math.Max(metadata.RemoteHealth, fileMetadata.Health)
 			}
+			metadata.DedupSavings += fileMetadata.DedupSavings
 			metadata.Size += fileMetadata.Size
+			metadata.Spending = metadata.Spending.Add(fileMetadata.Spending)
 			metadata.StuckHealth = math.Max(metadata.StuckHealth, fileMetadata.StuckHealth)
 		} else if len(dirMetadatas) > 0 {
 			// Get next dir's metadata.
@@ -226,11 +230,13 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			aggregateRemoteHealth = dirMetadata.AggregateRemoteHealth
 
 			// Update aggregate fields.
+			metadata.AggregateDedupSavings += dirMetadata.AggregateDedupSavings
 			metadata.AggregateNumFiles += dirMetadata.AggregateNumFiles
 			metadata.AggregateNumStuckChunks += dirMetadata.AggregateNumStuckChunks
 			metadata.AggregateNumSubDirs += dirMetadata.AggregateNumSubDirs
 			metadata.AggregateRepairSize += dirMetadata.AggregateRepairSize
 			metadata.AggregateSize += dirMetadata.AggregateSize
+			metadata.AggregateSpending = metadata.AggregateSpending.Add(dirMetadata.AggregateSpending)
 			metadata.AggregateStuckSize += dirMetadata.AggregateStuckSize
 
 			// Add 1 to the AggregateNumSubDirs to account for this subdirectory.
@@ -305,6 +311,7 @@ func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaF
 	return bubbledSiaFileMetadata{
 		sp: siaPath,
 		bm: siafile.BubbledMetadata{
+			DedupSavings:        md.DedupSavings,
 			Health:              md.CachedHealth,
 			LastHealthCheckTime: sf.LastHealthCheckTime(),
 			ModTime:             sf.ModTime(),
@@ -313,6 +320,7 @@ func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaF
 			Redundancy:          md.CachedRedundancy,
 			RepairBytes:         md.CachedRepairBytes,
 			Size:                sf.Size(),
+			Spending:            md.Spending,
 			StuckHealth:         md.CachedStuckHealth,
 			StuckBytes:          md.CachedStuckBytes,
 			UID:                 sf.UID(),
@@ -7,6 +7,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/writeaheadlog"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
@@ -56,10 +57,11 @@ var (
 type (
 	// persist contains all of the persistent renter data.
 	persistence struct {
-		MaxDownloadSpeed int64
-		MaxUploadSpeed   int64
-		UploadedBackups  []modules.UploadedBackup
-		SyncedContracts  []types.FileContractID
+		DefaultCipherType crypto.CipherType
+		MaxDownloadSpeed  int64
+		MaxUploadSpeed    int64
+		UploadedBackups   []modules.UploadedBackup
+		SyncedContracts   []types.FileContractID
 	}
 )
 
@@ -62,12 +62,13 @@ func (he *Editor) HostSettings() modules.HostExternalSettings {
 	return he.host.HostExternalSettings
 }
 
-// Upload negotiates a revision that adds a sector to a file contract.
-func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
+// Upload negotiates a revision that adds a sector to a file contract. It
+// returns the storage and upload spending of that revision.
+func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash, _ modules.FileSpending, err error) {
 	// Acquire the contract.
 	sc, haveContract := he.contractSet.Acquire(he.contractID)
 	if !haveContract {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract not present in contract set")
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, errors.New("contract not present in contract set")
 	}
 	defer he.contractSet.Return(sc)
 	contract := sc.header // for convenience
@@ -90,7 +91,7 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 
 	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has insufficient funds to support upload")
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, errors.New("contract has insufficient funds to support upload")
 	}
 	if contract.LastRevision().MissedHostOutput().Value.Cmp(sectorCollateral) < 0 {
 		sectorCollateral = contract.LastRevision().MissedHostOutput().Value
@@ -108,7 +109,7 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}}
 	rev, err := newUploadRevision(contract.LastRevision(), merkleRoot, sectorPrice, sectorCollateral)
 	if err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, errors.AddContext(err, "Error creating new upload revision")
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, errors.AddContext(err, "Error creating new upload revision")
 	}
 
 	// run the revision iteration
@@ -135,7 +136,7 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	// initiate revision
 	extendDeadline(he.conn, modules.NegotiateSettingsTime)
 	if err := startRevision(he.conn, he.host); err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, err
 	}
 
 	// record the change we are about to make to the contract. If we lose power
@@ -143,18 +144,18 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	// post-revision contract.
 	walTxn, err := sc.managedRecordAppendIntent(rev, sectorRoot, sectorStoragePrice, sectorBandwidthPrice)
 	if err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, err
 	}
 
 	// send actions
 	extendDeadline(he.conn, modules.NegotiateFileContractRevisionTime)
 	if err := encoding.WriteObject(he.conn, actions); err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, err
 	}
 
 	// Disrupt here before sending the signed revision to the host.
 	if he.deps.Disrupt("InterruptUploadBeforeSendingRevision") {
-		return modules.RenterContract{}, crypto.Hash{},
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{},
 			errors.New("InterruptUploadBeforeSendingRevision disrupt")
 	}
 
@@ -166,22 +167,26 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 		// cause the next operation to fail
 		he.conn.Close()
 	} else if err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, err
 	}
 
 	// Disrupt here before updating the contract.
 	if he.deps.Disrupt("InterruptUploadAfterSendingRevision") {
-		return modules.RenterContract{}, crypto.Hash{},
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{},
 			errors.New("InterruptUploadAfterSendingRevision disrupt")
 	}
 
 	// update contract
 	err = sc.managedCommitAppend(walTxn, signedTxn, sectorStoragePrice, sectorBandwidthPrice)
 	if err != nil {
-		return modules.RenterContract{}, crypto.Hash{}, err
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, err
 	}
 
-	return sc.Metadata(), sectorRoot, nil
+	spending := modules.FileSpending{
+		StorageSpending: sectorStoragePrice,
+		UploadSpending:  sectorBandwidthPrice,
+	}
+	return sc.Metadata(), sectorRoot, spending, nil
 }
 
 // NewEditor initiates the contract revision process with a host, and returns
@@ -143,10 +143,16 @@ func (s *Session) Settings() (modules.HostExternalSettings, error) {
 }
 
 // Append calls the Write RPC with a single Append action, returning the
-// updated contract and the Merkle root of the appended sector.
-func (s *Session) Append(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
-	rc, err := s.Write([]modules.LoopWriteAction{{Type: modules.WriteActionAppend, Data: data}})
-	return rc, crypto.MerkleRoot(data), err
+// updated contract, the Merkle root of the appended sector and the money that
+// was spent on the append.
+func (s *Session) Append(data []byte) (_ modules.RenterContract, _ crypto.Hash, _ modules.FileSpending, err error) {
+	sc, haveContract := s.contractSet.Acquire(s.contractID)
+	if !haveContract {
+		return modules.RenterContract{}, crypto.Hash{}, modules.FileSpending{}, errors.New("contract not present in contract set")
+	}
+	defer s.contractSet.Return(sc)
+	rc, spending, err := s.write(sc, []modules.LoopWriteAction{{Type: modules.WriteActionAppend, Data: data}})
+	return rc, crypto.MerkleRoot(data), spending, err
 }
 
 // Replace calls the Write RPC with a series of actions that replace the sector
@@ -171,7 +177,7 @@ func (s *Session) Replace(data []byte, sectorIndex uint64, trim bool) (_ modules
 		actions = append(actions, modules.LoopWriteAction{Type: modules.WriteActionTrim, A: 1})
 	}
 
-	rc, err := s.write(sc, actions)
+	rc, _, err := s.write(sc, actions)
 	return rc, crypto.MerkleRoot(data), errors.AddContext(err, "write to host failed")
 }
 
@@ -183,10 +189,13 @@ func (s *Session) Write(actions []modules.LoopWriteAction) (_ modules.RenterCont
 		return modules.RenterContract{}, errors.New("contract not present in contract set")
 	}
 	defer s.contractSet.Return(sc)
-	return s.write(sc, actions)
+	rc, _, err := s.write(sc, actions)
+	return rc, err
 }
 
-func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_ modules.RenterContract, err error) {
+// write performs the Write RPC using the provided contract, which must already
+// be acquired, and returns the storage and upload spending of the revision.
+func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_ modules.RenterContract, _ modules.FileSpending, err error) {
 	contract := sc.header // for convenience
 
 	// calculate price per sector
@@ -210,7 +219,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 		case modules.WriteActionSwap:
 
 		case modules.WriteActionUpdate:
-			return modules.RenterContract{}, errors.New("update not supported")
+			return modules.RenterContract{}, modules.FileSpending{}, errors.New("update not supported")
 
 		default:
 			build.Critical("unknown action type", action.Type)
@@ -234,7 +243,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 
 	// check that enough funds are available
 	if contract.RenterFunds().Cmp(cost) < 0 {
-		return modules.RenterContract{}, errors.New("contract has insufficient funds to support upload")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.New("contract has insufficient funds to support upload")
 	}
 	if contract.LastRevision().MissedHostOutput().Value.Cmp(collateral) < 0 {
 		// The contract doesn't have enough value in it to supply the
@@ -261,7 +270,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// create the revision; we will update the Merkle root later
 	rev, err := contract.LastRevision().PaymentRevision(cost)
 	if err != nil {
-		return modules.RenterContract{}, errors.AddContext(err, "Error creating new write revision")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.AddContext(err, "Error creating new write revision")
 	}
 
 	rev.SetMissedHostPayout(rev.MissedHostOutput().Value.Sub(collateral))
@@ -291,7 +300,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// TODO: update this for non-local root storage
 	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
 	if err != nil {
-		return modules.RenterContract{}, err
+		return modules.RenterContract{}, modules.FileSpending{}, err
 	}
 
 	defer func() {
@@ -308,19 +317,19 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 
 	// Disrupt here before sending the signed revision to the host.
 	if s.deps.Disrupt("InterruptUploadBeforeSendingRevision") {
-		return modules.RenterContract{}, errors.New("InterruptUploadBeforeSendingRevision disrupt")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.New("InterruptUploadBeforeSendingRevision disrupt")
 	}
 
 	// send Write RPC request
 	extendDeadline(s.conn, modules.NegotiateFileContractRevisionTime)
 	if err := s.writeRequest(modules.RPCLoopWrite, req); err != nil {
-		return modules.RenterContract{}, err
+		return modules.RenterContract{}, modules.FileSpending{}, err
 	}
 
 	// read Merkle proof from host
 	var merkleResp modules.LoopWriteMerkleProof
 	if err := s.readResponse(&merkleResp, modules.RPCMinLen); err != nil {
-		return modules.RenterContract{}, err
+		return modules.RenterContract{}, modules.FileSpending{}, err
 	}
 	// verify the proof, first by verifying the old Merkle root...
 	numSectors := contract.LastRevision().NewFileSize / modules.SectorSize
@@ -329,13 +338,13 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	leafHashes := merkleResp.OldLeafHashes
 	oldRoot, newRoot := contract.LastRevision().NewFileMerkleRoot, merkleResp.NewMerkleRoot
 	if !crypto.VerifyDiffProof(proofRanges, numSectors, proofHashes, leafHashes, oldRoot) {
-		return modules.RenterContract{}, errors.New("invalid Merkle proof for old root")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.New("invalid Merkle proof for old root")
 	}
 	// ...then by modifying the leaves and verifying the new Merkle root
 	leafHashes = modifyLeaves(leafHashes, actions, numSectors)
 	proofRanges = modifyProofRanges(proofRanges, actions, numSectors)
 	if !crypto.VerifyDiffProof(proofRanges, numSectors, proofHashes, leafHashes, newRoot) {
-		return modules.RenterContract{}, errors.New("invalid Merkle proof for new root")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.New("invalid Merkle proof for new root")
 	}
 
 	// update the revision, sign it, and send it
@@ -362,7 +371,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 		Signature: sig[:],
 	}
 	if err := s.writeResponse(renterSig, nil); err != nil {
-		return modules.RenterContract{}, err
+		return modules.RenterContract{}, modules.FileSpending{}, err
 	}
 
 	// read the host's signature
@@ -375,13 +384,13 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 			u.LastOOSErr = s.height
 			err = errors.Compose(err, sc.UpdateUtility(u))
 		}
-		return modules.RenterContract{}, errors.AddContext(err, "marking host as not good for upload because the host is out of storage")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.AddContext(err, "marking host as not good for upload because the host is out of storage")
 	}
 	txn.TransactionSignatures[1].Signature = hostSig.Signature
 
 	// Disrupt here before updating the contract.
 	if s.deps.Disrupt("InterruptUploadAfterSendingRevision") {
-		return modules.RenterContract{}, errors.New("InterruptUploadAfterSendingRevision disrupt")
+		return modules.RenterContract{}, modules.FileSpending{}, errors.New("InterruptUploadAfterSendingRevision disrupt")
 	}
 
 	// update contract
@@ -389,9 +398,13 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// TODO: unnecessary?
 	err = sc.managedCommitAppend(walTxn, txn, storagePrice, bandwidthPrice)
 	if err != nil {
-		return modules.RenterContract{}, err
+		return modules.RenterContract{}, modules.FileSpending{}, err
 	}
-	return sc.Metadata(), nil
+	spending := modules.FileSpending{
+		StorageSpending: storagePrice,
+		UploadSpending:  bandwidthPrice,
+	}
+	return sc.Metadata(), spending, nil
 }
 
 // Read calls the Read RPC, writing the requested data to w. The RPC can be
@@ -0,0 +1,279 @@
+package renter
+
+// registrywatch.go allows for watching registry entries for updates. A watch
+// subscribes to the entry on all workers that support registry subscriptions
+// and blocks until one of the hosts notifies the renter about a value with a
+// high enough revision number. The subscriptions are shared between the
+// watches of the same entry. They live independently of the watch that
+// created them and are only removed from the workers they were created on once
+// the last watch of the entry is done.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// MaxRegistryWatchTimeout is the maximum time a registry watch blocks
+	// waiting for an update.
+	MaxRegistryWatchTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testnet:  5 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// ErrRegistryWatchTimeout is returned if a watched registry entry wasn't
+	// updated within the timeout of the watch.
+	ErrRegistryWatchTimeout = errors.New("registry entry wasn't updated within given time")
+)
+
+type (
+	// registryWatchers keeps track of the registry entries that are currently
+	// being watched.
+	registryWatchers struct {
+		watches map[modules.RegistryEntryID]*registryWatch
+		mu      sync.Mutex
+	}
+
+	// registryWatch is the shared state of all the watches of a registry
+	// entry.
+	registryWatch struct {
+		// latest is the value with the highest revision that the workers
+		// were notified about since the entry is being watched.
+		latest *modules.SignedRegistryValue
+
+		// updated is closed and replaced whenever latest changes.
+		updated chan struct{}
+
+		// refs is the number of active watches of the entry.
+		refs int
+
+		// removed is closed once the subscriptions of the watch were removed
+		// after the last watch of the entry was done. It is nil while the
+		// watch is active.
+		removed chan struct{}
+
+		// staticCtx is the lifetime of the subscriptions. It is cancelled
+		// when the last watch of the entry is done.
+		staticCtx    context.Context
+		staticCancel context.CancelFunc
+
+		// staticWorkers are the workers the entry is subscribed on and
+		// staticWG keeps track of the pending subscriptions.
+		staticWorkers []*worker
+		staticWG      sync.WaitGroup
+	}
+)
+
+// newRegistryWatchers creates a new registryWatchers object.
+func newRegistryWatchers() *registryWatchers {
+	return &registryWatchers{
+		watches: make(map[modules.RegistryEntryID]*registryWatch),
+	}
+}
+
+// managedAdd adds a watch for the entry with the given id. If the entry isn't
+// watched yet, a new shared watch is created for the given workers with a
+// lifetime derived from ctx, and true is returned. If the subscriptions of a
+// previous watch of the entry are still being removed, managedAdd waits for
+// that to finish first.
+func (rw *registryWatchers) managedAdd(ctx context.Context, id modules.RegistryEntryID, workers []*worker) (*registryWatch, bool) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for {
+		watch, exists := rw.watches[id]
+		if !exists {
+			break
+		}
+		if watch.removed == nil {
+			watch.refs++
+			return watch, false
+		}
+		removed := watch.removed
+		rw.mu.Unlock()
+		<-removed
+		rw.mu.Lock()
+	}
+	watch := &registryWatch{
+		updated:       make(chan struct{}),
+		refs:          1,
+		staticWorkers: workers,
+	}
+	watch.staticCtx, watch.staticCancel = context.WithCancel(ctx)
+	rw.watches[id] = watch
+	return watch, true
+}
+
+// managedRemove removes a watch for the entry with the given id. If it was the
+// last watch of the entry, the shared watch is returned and the caller is
+// responsible for removing its subscriptions and calling managedDelete.
+func (rw *registryWatchers) managedRemove(id modules.RegistryEntryID) (*registryWatch, bool) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	watch, exists := rw.watches[id]
+	if !exists || watch.removed != nil {
+		build.Critical("removing watch that doesn't exist")
+		return nil, false
+	}
+	watch.refs--
+	if watch.refs > 0 {
+		return nil, false
+	}
+	watch.removed = make(chan struct{})
+	return watch, true
+}
+
+// managedDelete deletes the watch of the entry with the given id after its
+// subscriptions were removed.
+func (rw *registryWatchers) managedDelete(id modules.RegistryEntryID) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	watch, exists := rw.watches[id]
+	if !exists || watch.removed == nil {
+		build.Critical("deleting watch that is still active")
+		return
+	}
+	delete(rw.watches, id)
+	close(watch.removed)
+}
+
+// managedLatest returns the latest value of the watched entry with the given
+// id and a channel which is closed when the value changes.
+func (rw *registryWatchers) managedLatest(id modules.RegistryEntryID) (*modules.SignedRegistryValue, <-chan struct{}) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	watch, exists := rw.watches[id]
+	if !exists {
+		build.Critical("watch doesn't exist")
+		return nil, nil
+	}
+	return watch.latest, watch.updated
+}
+
+// callNotify is called by the workers whenever they learn about a new value
+// of a subscribed entry. The value is expected to be verified already.
+func (rw *registryWatchers) callNotify(spk types.SiaPublicKey, srv modules.SignedRegistryValue) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	watch, exists := rw.watches[modules.DeriveRegistryEntryID(spk, srv.Tweak)]
+	if !exists {
+		return
+	}
+	if watch.latest != nil && watch.latest.Revision >= srv.Revision {
+		return
+	}
+	watch.latest = &srv
+	close(watch.updated)
+	watch.updated = make(chan struct{})
+}
+
+// staticSubscriptionWorkers returns the workers of hosts which support
+// registry subscriptions.
+func (r *Renter) staticSubscriptionWorkers() []*worker {
+	var workers []*worker
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		cache := w.staticCache()
+		if cache == nil || build.VersionCmp(cache.staticHostVersion, minSubscriptionVersion) < 0 {
+			continue
+		}
+		workers = append(workers, w)
+	}
+	return workers
+}
+
+// managedSubscribeRegistryWatch subscribes to the entry of a new watch on the
+// workers of the watch. The initial values returned by the subscriptions are
+// passed on to the watchers.
+func (r *Renter) managedSubscribeRegistryWatch(watch *registryWatch, req modules.RPCRegistrySubscriptionRequest) {
+	id := modules.DeriveRegistryEntryID(req.PubKey, req.Tweak)
+	for _, w := range watch.staticWorkers {
+		watch.staticWG.Add(1)
+		go func(w *worker) {
+			defer watch.staticWG.Done()
+			updates, err := w.Subscribe(watch.staticCtx, req)
+			if err != nil {
+				r.log.Debugf("Worker %v: failed to subscribe to registry entry %v: %v", w.staticHostPubKeyStr, id, err)
+				return
+			}
+			for _, update := range updates {
+				r.staticRegistryWatchers.callNotify(update.PubKey, update.Entry)
+			}
+		}(w)
+	}
+}
+
+// managedRemoveRegistryWatch removes a watch of the entry. The last watch of
+// the entry unsubscribes from the workers the entry was subscribed on once the
+// pending subscriptions are done.
+func (r *Renter) managedRemoveRegistryWatch(req modules.RPCRegistrySubscriptionRequest) {
+	id := modules.DeriveRegistryEntryID(req.PubKey, req.Tweak)
+	watch, last := r.staticRegistryWatchers.managedRemove(id)
+	if !last {
+		return
+	}
+	watch.staticCancel()
+	watch.staticWG.Wait()
+	for _, w := range watch.staticWorkers {
+		w.Unsubscribe(req)
+	}
+	r.staticRegistryWatchers.managedDelete(id)
+}
+
+// WatchRegistry blocks until a value with a revision number of at least
+// minRevision is known for the registry entry with the given public key and
+// tweak, and returns it. If no such value is found within the timeout,
+// ErrRegistryWatchTimeout is returned.
+func (r *Renter) WatchRegistry(spk types.SiaPublicKey, tweak crypto.Hash, minRevision uint64, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SignedRegistryValue{}, err
+	}
+	defer r.tg.Done()
+
+	// Limit the timeout.
+	if timeout <= 0 || timeout > MaxRegistryWatchTimeout {
+		timeout = MaxRegistryWatchTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), timeout)
+	defer cancel()
+
+	workers := r.staticSubscriptionWorkers()
+	if len(workers) == 0 {
+		return modules.SignedRegistryValue{}, errors.New("no workers support registry subscriptions")
+	}
+
+	// Add the watch and subscribe to the entry if this is the first watch.
+	// The subscriptions outlive this watch if other watches of the entry are
+	// still active.
+	id := modules.DeriveRegistryEntryID(spk, tweak)
+	req := modules.RPCRegistrySubscriptionRequest{
+		PubKey: spk,
+		Tweak:  tweak,
+	}
+	watch, created := r.staticRegistryWatchers.managedAdd(r.tg.StopCtx(), id, workers)
+	if created {
+		r.managedSubscribeRegistryWatch(watch, req)
+	}
+	defer r.managedRemoveRegistryWatch(req)
+
+	// Wait for a value with a high enough revision.
+	for {
+		latest, updated := r.staticRegistryWatchers.managedLatest(id)
+		if latest != nil && latest.Revision >= minRevision {
+			return *latest, nil
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return modules.SignedRegistryValue{}, errors.AddContext(ErrRegistryWatchTimeout, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
+		}
+	}
+}
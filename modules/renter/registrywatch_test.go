@@ -0,0 +1,109 @@
+package renter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestRegistryWatchers tests the bookkeeping of the registryWatchers.
+func TestRegistryWatchers(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	id := modules.DeriveRegistryEntryID(spk, tweak)
+	rw := newRegistryWatchers()
+
+	// Notifying about an entry that isn't watched is a no-op.
+	srv := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 1, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	rw.callNotify(spk, srv)
+	if len(rw.watches) != 0 {
+		t.Fatal("unwatched entry shouldn't be added")
+	}
+
+	// The first watch is reported as such and the second one shares its
+	// state.
+	watch, created := rw.managedAdd(context.Background(), id, nil)
+	if !created {
+		t.Fatal("first watch should return true")
+	}
+	if watch2, created := rw.managedAdd(context.Background(), id, nil); created || watch2 != watch {
+		t.Fatal("second watch should share the first one")
+	}
+	latest, updated := rw.managedLatest(id)
+	if latest != nil {
+		t.Fatal("there shouldn't be a value yet")
+	}
+
+	// A notification updates the latest value and closes the channel.
+	rw.callNotify(spk, srv)
+	select {
+	case <-updated:
+	default:
+		t.Fatal("channel wasn't closed")
+	}
+	latest, updated = rw.managedLatest(id)
+	if latest == nil || latest.Revision != srv.Revision {
+		t.Fatal("wrong latest value", latest)
+	}
+
+	// A notification with the same or a lower revision is ignored.
+	srv0 := modules.NewRegistryValue(tweak, fastrand.Bytes(10), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	rw.callNotify(spk, srv0)
+	rw.callNotify(spk, srv)
+	select {
+	case <-updated:
+		t.Fatal("channel shouldn't be closed")
+	default:
+	}
+	latest, _ = rw.managedLatest(id)
+	if latest.Revision != srv.Revision {
+		t.Fatal("wrong latest value", latest.Revision)
+	}
+
+	// Only the last removal is reported as such.
+	if _, last := rw.managedRemove(id); last {
+		t.Fatal("first removal should return false")
+	}
+	removed, last := rw.managedRemove(id)
+	if !last || removed != watch {
+		t.Fatal("last removal should return the watch")
+	}
+
+	// A new watch waits for the subscriptions of the removed one to be
+	// removed.
+	added := make(chan *registryWatch)
+	go func() {
+		watch, _ := rw.managedAdd(context.Background(), id, nil)
+		added <- watch
+	}()
+	select {
+	case <-added:
+		t.Fatal("new watch shouldn't be added before the old one is deleted")
+	case <-time.After(100 * time.Millisecond):
+	}
+	rw.managedDelete(id)
+	select {
+	case newWatch := <-added:
+		if newWatch == watch || newWatch.refs != 1 {
+			t.Fatal("expected a new watch")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("new watch wasn't added")
+	}
+	if _, last := rw.managedRemove(id); !last {
+		t.Fatal("removal should be the last one")
+	}
+	rw.managedDelete(id)
+	if len(rw.watches) != 0 {
+		t.Fatal("watch wasn't removed")
+	}
+}
@@ -0,0 +1,261 @@
+package renter
+
+// rekey.go re-encrypts files under a new master key. The data of the file is
+// streamed and uploaded to a temporary file which is encrypted with the new
+// key. Once the data of the temporary file is available on the network, it
+// atomically replaces the original file and the repair loop takes care of
+// bringing it back to full redundancy.
+//
+// Re-encrypting a file happens in the background since it requires
+// downloading and uploading the whole file. The chunks are uploaded through
+// the upload heap using the repair memory manager, just like the repair work
+// scheduled by siad.
+
+import (
+	"encoding/hex"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errRekeyInProgress is returned if a file is already being re-encrypted.
+	errRekeyInProgress = errors.New("file is already being re-encrypted")
+
+	// rekeyFolder is the folder which contains the temporary files of files
+	// that are being re-encrypted.
+	rekeyFolder = modules.NewGlobalSiaPath("/var/rekey")
+)
+
+type (
+	// rekeyStatus tracks the status of the files which are being re-encrypted
+	// and of the files which were re-encrypted since the renter started.
+	rekeyStatus struct {
+		rekeys map[modules.SiaPath]*rekey
+		mu     sync.Mutex
+	}
+
+	// rekey is the status of re-encrypting a single file.
+	rekey struct {
+		// atomicProcessed is the number of bytes of the file which were
+		// passed on to the upload.
+		atomicProcessed uint64
+
+		info modules.RekeyInfo
+	}
+
+	// rekeyReader wraps the reader of a file that is being re-encrypted and
+	// counts the bytes read from it.
+	rekeyReader struct {
+		io.Reader
+		staticRekey *rekey
+	}
+)
+
+// newRekeyStatus creates a new rekeyStatus.
+func newRekeyStatus() *rekeyStatus {
+	return &rekeyStatus{
+		rekeys: make(map[modules.SiaPath]*rekey),
+	}
+}
+
+// Read implements io.Reader.
+func (rr *rekeyReader) Read(b []byte) (int, error) {
+	n, err := rr.Reader.Read(b)
+	atomic.AddUint64(&rr.staticRekey.atomicProcessed, uint64(n))
+	return n, err
+}
+
+// managedStart marks a file as being re-encrypted. An error is returned if
+// the file is already being re-encrypted.
+func (rs *rekeyStatus) managedStart(siaPath modules.SiaPath, ct crypto.CipherType, size uint64) (*rekey, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rk, exists := rs.rekeys[siaPath]; exists && !rk.info.Finished {
+		return nil, errRekeyInProgress
+	}
+	rk := &rekey{
+		info: modules.RekeyInfo{
+			SiaPath:    siaPath,
+			CipherType: ct.String(),
+			Size:       size,
+			StartTime:  time.Now(),
+		},
+	}
+	rs.rekeys[siaPath] = rk
+	return rk, nil
+}
+
+// managedFinish marks the re-encryption of a file as finished.
+func (rs *rekeyStatus) managedFinish(rk *rekey, err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rk.info.Finished = true
+	rk.info.EndTime = time.Now()
+	if err != nil {
+		rk.info.Error = err.Error()
+	}
+}
+
+// managedInfo returns the status of all re-encryptions sorted by their start
+// time.
+func (rs *rekeyStatus) managedInfo() []modules.RekeyInfo {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	infos := make([]modules.RekeyInfo, 0, len(rs.rekeys))
+	for _, rk := range rs.rekeys {
+		info := rk.info
+		info.Processed = atomic.LoadUint64(&rk.atomicProcessed)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.Before(infos[j].StartTime)
+	})
+	return infos
+}
+
+// RekeyFile starts re-encrypting the file at siaPath under a new master key of
+// the provided cipher type in the background. If the cipher type is left
+// blank, the file's current cipher type is used. The progress can be checked
+// using RekeyInfo.
+func (r *Renter) RekeyFile(siaPath modules.SiaPath, ct crypto.CipherType) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Open the file to check the cipher type.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if ct == (crypto.CipherType{}) {
+		ct = entry.MasterKey().Type()
+	}
+	if !isValidUploadCipherType(ct) {
+		return ErrInvalidUploadCipherType
+	}
+
+	rk, err := r.staticRekeyStatus.managedStart(siaPath, ct, entry.Size())
+	if err != nil {
+		return err
+	}
+	go r.threadedRekeyFile(siaPath, ct, rk)
+	return nil
+}
+
+// RekeyInfo returns the status of the files which are being re-encrypted and
+// of the files which were re-encrypted since the renter started.
+func (r *Renter) RekeyInfo() []modules.RekeyInfo {
+	return r.staticRekeyStatus.managedInfo()
+}
+
+// threadedRekeyFile re-encrypts the file at siaPath and reports the result to
+// the rekey status.
+func (r *Renter) threadedRekeyFile(siaPath modules.SiaPath, ct crypto.CipherType, rk *rekey) {
+	err := r.tg.Add()
+	if err != nil {
+		r.staticRekeyStatus.managedFinish(rk, err)
+		return
+	}
+	defer r.tg.Done()
+
+	err = r.managedRekeyFile(siaPath, ct, rk)
+	if err != nil {
+		r.log.Printf("Unable to re-encrypt file %v: %v", siaPath, err)
+	}
+	r.staticRekeyStatus.managedFinish(rk, err)
+}
+
+// managedRekeyFile re-encrypts the file at siaPath under a new master key of
+// the provided cipher type.
+func (r *Renter) managedRekeyFile(siaPath modules.SiaPath, ct crypto.CipherType, rk *rekey) (err error) {
+	// Open the file to get the settings for the new upload.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// Create a streamer for the file's data.
+	streamer, err := r.StreamerByNode(entry, false)
+	if err != nil {
+		return errors.AddContext(err, "unable to create streamer")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+
+	// Upload the data to a temporary file using a new key. The temporary
+	// file is removed again if the file can't be replaced.
+	tmpSiaPath, err := rekeyFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		deleteErr := r.staticFileSystem.DeleteFile(tmpSiaPath)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+	}()
+	up := modules.FileUploadParams{
+		SiaPath:             tmpSiaPath,
+		ErasureCode:         entry.ErasureCode(),
+		DisablePartialChunk: true,
+		CipherType:          ct,
+	}
+	reader := &rekeyReader{
+		Reader:      streamer,
+		staticRekey: rk,
+	}
+	tmpNode, err := r.managedUploadStreamFromReader(up, reader, r.repairMemoryManager, memoryPriorityLow)
+	if err != nil {
+		return errors.AddContext(err, "unable to upload re-encrypted file")
+	}
+
+	// The content of the file doesn't change, so the re-encrypted file can
+	// still be repaired from the original file's local copy.
+	if err := tmpNode.SetLocalPath(entry.LocalPath()); err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to set local path"), tmpNode.Close())
+	}
+
+	// Replace the original file. The re-encrypted file isn't deduplicated, so
+	// the references of the original file's chunks are released.
+	dedupIDs, err := entry.DedupIDs()
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to get dedup ids"), tmpNode.Close())
+	}
+	err = r.staticFileSystem.ReplaceFile(siaPath, tmpSiaPath)
+	err = errors.Compose(err, tmpNode.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to replace file with re-encrypted file")
+	}
+	r.managedReleaseDedupIDs(dedupIDs)
+
+	// Queue a bubble for the file's directory, ignore the return channel as we
+	// do not want to block on this update.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		r.log.Printf("Unable to fetch the directory from a siaPath %v for re-encrypted siafile: %v", siaPath, err)
+		return nil
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	return nil
+}
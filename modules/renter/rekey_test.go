@@ -0,0 +1,76 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRekeyStatus is a unit test for tracking the status of re-encrypted files.
+func TestRekeyStatus(t *testing.T) {
+	rs := newRekeyStatus()
+	sp1 := modules.RandomSiaPath()
+	sp2 := modules.RandomSiaPath()
+
+	// Start re-encrypting a file.
+	rk, err := rs.managedStart(sp1, crypto.TypeXChaCha20, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A file can't be re-encrypted twice at the same time.
+	if _, err := rs.managedStart(sp1, crypto.TypeThreefish, 100); !errors.Contains(err, errRekeyInProgress) {
+		t.Fatal("expected errRekeyInProgress but got", err)
+	}
+	// Another file can.
+	rk2, err := rs.managedStart(sp2, crypto.TypeThreefish, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read some data through the reader of the first file.
+	data := fastrand.Bytes(100)
+	rr := &rekeyReader{
+		Reader:      bytes.NewReader(data),
+		staticRekey: rk,
+	}
+	if _, err := ioutil.ReadAll(rr); err != nil {
+		t.Fatal(err)
+	}
+
+	// Finish both files, one of them with an error.
+	rs.managedFinish(rk, nil)
+	rs.managedFinish(rk2, errors.New("failed"))
+	infos := make(map[modules.SiaPath]modules.RekeyInfo)
+	for _, info := range rs.managedInfo() {
+		infos[info.SiaPath] = info
+	}
+	if len(infos) != 2 {
+		t.Fatal("wrong number of infos", len(infos))
+	}
+	info := infos[sp1]
+	if !info.SiaPath.Equals(sp1) || info.CipherType != crypto.TypeXChaCha20.String() || info.Size != 100 {
+		t.Fatal("wrong info", info)
+	}
+	if !info.Finished || info.Error != "" || info.Processed != uint64(len(data)) || info.EndTime.Before(info.StartTime) {
+		t.Fatal("wrong status", info)
+	}
+	if info := infos[sp2]; !info.SiaPath.Equals(sp2) || !info.Finished || info.Error != "failed" {
+		t.Fatal("wrong info", info)
+	}
+
+	// A finished file can be re-encrypted again.
+	if _, err := rs.managedStart(sp1, crypto.TypeThreefish, 100); err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range rs.managedInfo() {
+		if info.SiaPath.Equals(sp1) && info.Finished {
+			t.Fatal("wrong info", info)
+		}
+	}
+}
@@ -255,8 +255,11 @@ type Renter struct {
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
 	staticAlerter                      *modules.GenericAlerter
+	staticDedupIndex                   *dedupIndex
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
+	staticRegistryWatchers             *registryWatchers
+	staticRekeyStatus                  *rekeyStatus
 	staticStreamBufferSet              *streamBufferSet
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
@@ -604,6 +607,15 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	var defaultCipherType crypto.CipherType
+	if s.DefaultCipherType != "" {
+		if err := defaultCipherType.FromString(s.DefaultCipherType); err != nil {
+			return errors.AddContext(err, "unable to parse default cipher type")
+		}
+		if !isValidUploadCipherType(defaultCipherType) {
+			return ErrInvalidUploadCipherType
+		}
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -624,6 +636,9 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	if s.DefaultCipherType != "" {
+		r.persist.DefaultCipherType = defaultCipherType
+	}
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -818,10 +833,11 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
 	return modules.RenterSettings{
-		Allowance:        r.hostContractor.Allowance(),
-		IPViolationCheck: enabled,
-		MaxDownloadSpeed: download,
-		MaxUploadSpeed:   upload,
+		Allowance:         r.hostContractor.Allowance(),
+		DefaultCipherType: r.managedDefaultCipherType().String(),
+		IPViolationCheck:  enabled,
+		MaxDownloadSpeed:  download,
+		MaxUploadSpeed:    upload,
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
@@ -927,6 +943,7 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	}
 	r.staticBubbleScheduler = newBubbleScheduler(r)
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
+	r.staticRekeyStatus = newRekeyStatus()
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
 	r.staticRRS = newReadRegistryStats(ReadRegistryBackgroundTimeout, readRegistryStatsInterval, readRegistryStatsDecay, readRegistryStatsPercentile)
 	close(r.uploadHeap.pauseChan)
@@ -964,6 +981,13 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to create account manager")
 	}
+	r.staticDedupIndex, err = newDedupIndex(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create dedup index")
+	}
+	if err := r.tg.AfterStop(r.staticDedupIndex.Close); err != nil {
+		return nil, err
+	}
 
 	r.registryMemoryManager = newMemoryManager(registryMemoryDefault, registryMemoryPriorityDefault, r.tg.StopChan())
 	r.userUploadMemoryManager = newMemoryManager(userUploadMemoryDefault, userUploadMemoryPriorityDefault, r.tg.StopChan())
@@ -971,6 +995,7 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.repairMemoryManager = newMemoryManager(repairMemoryDefault, repairMemoryPriorityDefault, r.tg.StopChan())
 
 	r.staticFuseManager = newFuseManager(r)
+	r.staticRegistryWatchers = newRegistryWatchers()
 	r.stuckStack = callNewStuckStack()
 
 	// Load all saved data.
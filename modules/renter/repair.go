@@ -774,6 +774,10 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, of
 
 // managedUpdateFileMetadata updates the metadata of a siafile.
 func (r *Renter) managedUpdateFileMetadata(sf *filesystem.FileNode, offlineMap, goodForRenew map[string]bool, contracts map[string]modules.RenterContract, used []types.SiaPublicKey) (err error) {
+	// The metadata of packed files depends on their pack.
+	if sf.IsPacked() {
+		return r.managedUpdatePackedFileMetadata(sf, offlineMap, goodForRenew, contracts)
+	}
 	// Update the siafile's used hosts.
 	if err := sf.UpdateUsedHosts(used); err != nil {
 		return errors.AddContext(err, "WARN: Could not update used hosts")
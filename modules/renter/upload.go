@@ -23,8 +23,33 @@ import (
 var (
 	// ErrUploadDirectory is returned if the user tries to upload a directory.
 	ErrUploadDirectory = errors.New("cannot upload directory")
+
+	// ErrInvalidUploadCipherType is returned if the user tries to encrypt an
+	// upload with a cipher type that isn't supported for uploads.
+	ErrInvalidUploadCipherType = fmt.Errorf("uploads can only be encrypted with %v or %v", crypto.TypeThreefish, crypto.TypeXChaCha20)
+
+	// ErrDedupCipherKey is returned if the user tries to upload a file in
+	// dedup mode using a custom cipher key.
+	ErrDedupCipherKey = errors.New("uploads in dedup mode can't use a custom cipher key")
 )
 
+// isValidUploadCipherType returns true if the cipher type can be used to
+// encrypt the pieces of an upload.
+func isValidUploadCipherType(ct crypto.CipherType) bool {
+	return ct == crypto.TypeThreefish || ct == crypto.TypeXChaCha20
+}
+
+// managedDefaultCipherType returns the cipher type which is used to encrypt
+// uploads that don't specify a cipher type.
+func (r *Renter) managedDefaultCipherType() crypto.CipherType {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	if r.persist.DefaultCipherType == (crypto.CipherType{}) {
+		return crypto.TypeDefaultRenter
+	}
+	return r.persist.DefaultCipherType
+}
+
 // Upload instructs the renter to start tracking a file. The renter will
 // automatically upload and repair tracked files using a background loop.
 func (r *Renter) Upload(up modules.FileUploadParams) error {
@@ -83,22 +108,32 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 
 	// Determine what type of encryption key to use. If no cipher type has been
-	// set, the default renter type will be used.
+	// set, the default cipher type of the renter will be used.
 	var ct crypto.CipherType
 	if up.CipherType == ct {
-		up.CipherType = crypto.TypeDefaultRenter
+		up.CipherType = r.managedDefaultCipherType()
+	} else if !isValidUploadCipherType(up.CipherType) {
+		return ErrInvalidUploadCipherType
 	}
-	// Generate a key using the cipher type.
+	// Generate a key using the cipher type. Files in dedup mode use the key
+	// of their encryption domain instead.
 	cipherKey := crypto.GenerateSiaKey(up.CipherType)
+	if up.Dedup {
+		cipherKey, err = r.managedDedupDomainKey(up.CipherType)
+		if err != nil {
+			return errors.AddContext(err, "could not get the dedup key")
+		}
+		up.DisablePartialChunk = true
+	}
 
 	// Create the Siafile and add to renter
 	err = r.staticFileSystem.NewSiaFile(up.SiaPath, up.Source, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode(), up.DisablePartialChunk)
 	if err != nil {
 		return errors.AddContext(err, "could not create a new sia file")
 	}
-	entry, err := r.staticFileSystem.OpenSiaFile(up.SiaPath)
+	entry, err := r.managedOpenNewSiaFile(up.SiaPath, up.Dedup)
 	if err != nil {
-		return errors.AddContext(err, "could not open the new sia file")
+		return err
 	}
 
 	// No need to upload zero-byte files.
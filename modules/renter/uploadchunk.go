@@ -234,9 +234,9 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 }
 
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
-// index i and then encrypt it.
-func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+// index i and then encrypt it using the chunk's master key.
+func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int, masterKey crypto.CipherKey) {
+	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, masterKey)
 }
 
 // padAndEncryptPiece will add padding to a piece and then encrypt it.
@@ -334,6 +334,15 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 	if err != nil {
 		return errors.AddContext(err, "unable to reconstruct the data downloaded from the network during repair")
 	}
+	// Deduplicate the chunk if necessary and get the key for encrypting it.
+	err = r.managedDedupChunk(chunk)
+	if err != nil {
+		return errors.AddContext(err, "unable to deduplicate chunk")
+	}
+	masterKey, err := chunk.fileEntry.ChunkMasterKey(chunk.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "unable to get the key of the chunk")
+	}
 	// Loop through the pieces and encrypt any that are needed, while dropping
 	// any pieces that are not needed.
 	var wg sync.WaitGroup
@@ -344,7 +353,7 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 		}
 		wg.Add(1)
 		go func(i int) {
-			chunk.padAndEncryptPiece(i)
+			chunk.padAndEncryptPiece(i, masterKey)
 			wg.Done()
 		}(i)
 	}
@@ -435,6 +444,16 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		return
 	}
 
+	// If all the pieces of the chunk were deduplicated, there is nothing left
+	// to distribute.
+	chunk.mu.Lock()
+	deduplicated := chunk.piecesCompleted >= chunk.staticPiecesNeeded
+	chunk.mu.Unlock()
+	if deduplicated {
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Distribute the chunk to the workers.
 	r.staticUploadChunkDistributionQueue.callAddUploadChunk(chunk)
 }
@@ -444,6 +463,12 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 // perform the encryption on the pieces and then ensure that the result matches
 // any known roots for the renter.
 func (uc *unfinishedUploadChunk) staticEncryptAndCheckIntegrity() error {
+	// Get the key for encrypting the chunk.
+	masterKey, err := uc.fileEntry.ChunkMasterKey(uc.staticIndex)
+	if err != nil {
+		return errors.AddContext(err, "unable to get the key of the chunk")
+	}
+
 	// Verify that all of the shards match the piece roots we are expecting. Use
 	// one thread per piece so that the verification is multicore.
 	var zeroHash crypto.Hash
@@ -464,7 +489,7 @@ func (uc *unfinishedUploadChunk) staticEncryptAndCheckIntegrity() error {
 			defer wg.Done()
 
 			// Encrypt and pad the piece with the given index.
-			uc.padAndEncryptPiece(i)
+			uc.padAndEncryptPiece(i, masterKey)
 
 			// Perform the integrity check. Skip the integrity check on this
 			// piece if there is no hash available.
@@ -504,9 +529,9 @@ func (uc *unfinishedUploadChunk) staticReadLogicalData(r io.Reader) (uint64, err
 	return total, nil
 }
 
-// staticFetchLogicalDataFromReader will load the logical data for a chunk from
+// managedFetchLogicalDataFromReader will load the logical data for a chunk from
 // a reader, and perform an integrity check on the chunk to ensure correctness.
-func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (err error) {
+func (r *Renter) managedFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (err error) {
 	defer func() {
 		err = errors.Compose(err, uc.sourceReader.Close())
 	}()
@@ -517,6 +542,12 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 		return errors.AddContext(err, "unable to read the chunk data from the source reader")
 	}
 
+	// Deduplicate the chunk if necessary.
+	err = r.managedDedupChunk(uc)
+	if err != nil {
+		return errors.AddContext(err, "unable to deduplicate chunk")
+	}
+
 	// Perform an integrity check on the data that was pulled from the reader.
 	err = uc.staticEncryptAndCheckIntegrity()
 	if err != nil {
@@ -542,7 +573,7 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 	// Use a sourceReader if one is available.
 	if uc.sourceReader != nil {
-		err := r.staticFetchLogicalDataFromReader(uc)
+		err := r.managedFetchLogicalDataFromReader(uc)
 		if err != nil {
 			return errors.AddContext(err, "unable to load logical data from source reader")
 		}
@@ -580,6 +611,10 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
 		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+		err = r.managedDedupChunk(uc)
+		if err != nil {
+			return errors.AddContext(err, "unable to deduplicate chunk")
+		}
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -691,9 +726,15 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	if chunkComplete && !released {
 		r.managedUpdateUploadChunkStuckStatus(uc)
 
+		// Make the pieces of a deduplicated chunk available to other chunks.
+		err := r.managedUpdateDedupIndex(uc)
+		if err != nil {
+			r.log.Print("managedCleanUpUploadChunk: failed to update dedup index", err)
+		}
+
 		// Update the file's metadata.
 		offlineMap, goodForRenewMap, contracts, used := r.callRenterContractsAndUtilities()
-		err := r.managedUpdateFileMetadata(uc.fileEntry, offlineMap, goodForRenewMap, contracts, used)
+		err = r.managedUpdateFileMetadata(uc.fileEntry, offlineMap, goodForRenewMap, contracts, used)
 		if err != nil {
 			r.log.Print("managedCleanUpUploadChunk: failed to update file metadata", err)
 		}
@@ -626,6 +626,11 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 // finish would then close the Entry and consequentially impact the remaining
 // chunks.
 func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts map[string]struct{}, target repairTarget, offline, goodForRenew map[string]bool, mm *memoryManager) []*unfinishedUploadChunk {
+	// Packed files don't have any chunks of their own. Their pack is repaired
+	// instead.
+	if entry.IsPacked() {
+		return nil
+	}
 	// If we don't have enough workers for the file, don't repair it right now.
 	minPieces := entry.ErasureCode().MinPieces()
 	r.staticWorkerPool.mu.RLock()
@@ -0,0 +1,312 @@
+package renter
+
+// uploadpacked.go uploads many small files at once by packing them into
+// shared chunks. The files are placed within sectors using modules.PackFiles
+// and every MinPieces sectors form the single chunk of a hidden pack. The
+// siafiles of the packed files don't store any pieces themselves. Instead
+// they reference their pack and the offset of their data within the pack's
+// chunk. Downloads and health checks are redirected to the pack and the pack
+// is repaired like any other file. Once all the files of a pack are deleted,
+// the filesystem deletes the pack as well. When existing files are replaced,
+// the packed files are created in a temporary folder first and only replace
+// the existing files once their pack was uploaded.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+var (
+	// packedFolder is the folder which contains the packs of packed files.
+	packedFolder = modules.NewGlobalSiaPath("/var/packed")
+
+	// packedTmpFolder is the folder which contains the packed files that
+	// replace existing files until their pack is uploaded.
+	packedTmpFolder = modules.NewGlobalSiaPath("/var/packed/tmp")
+)
+
+// UploadPacked uploads multiple small files by packing them into shared
+// chunks. Every file needs to fit within a single sector.
+func (r *Renter) UploadPacked(up modules.PackedUploadParams) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if len(up.Files) == 0 {
+		return errors.New("no files to upload")
+	}
+
+	// Fill in any missing upload params with sensible defaults.
+	if up.ErasureCode == nil {
+		up.ErasureCode = modules.NewRSSubCodeDefault()
+	}
+	if up.CipherType == (crypto.CipherType{}) {
+		up.CipherType = r.managedDefaultCipherType()
+	} else if !isValidUploadCipherType(up.CipherType) {
+		return ErrInvalidUploadCipherType
+	}
+
+	// Check the files and collect their sizes.
+	files := make(map[string]modules.PackedUploadFile, len(up.Files))
+	sizes := make(map[string]uint64, len(up.Files))
+	for _, file := range up.Files {
+		id := file.SiaPath.String()
+		if _, exists := files[id]; exists {
+			return fmt.Errorf("siapath %v was provided multiple times", file.SiaPath)
+		}
+		sourceInfo, err := os.Stat(file.Source)
+		if err != nil {
+			return errors.AddContext(err, "unable to stat input file")
+		}
+		if sourceInfo.IsDir() {
+			return ErrUploadDirectory
+		}
+		files[id] = file
+		sizes[id] = uint64(sourceInfo.Size())
+	}
+
+	// Make sure that the files can be created. Existing files are only
+	// replaced after the packs were uploaded.
+	for _, file := range up.Files {
+		if up.Force {
+			continue
+		}
+		exists, err := r.staticFileSystem.FileExists(file.SiaPath)
+		if err != nil {
+			return errors.AddContext(err, "unable to check whether file exists")
+		}
+		if exists {
+			return errors.AddContext(filesystem.ErrExists, file.SiaPath.String())
+		}
+	}
+
+	// Place the files within sectors and group the sectors into packs.
+	placements, numSectors, err := modules.PackFiles(sizes)
+	if err != nil {
+		return errors.AddContext(err, "unable to pack files")
+	}
+	sectorsPerPack := uint64(up.ErasureCode.MinPieces())
+	packs := make([][]modules.FilePlacement, (numSectors+sectorsPerPack-1)/sectorsPerPack)
+	for _, fp := range placements {
+		packIndex := fp.SectorIndex / sectorsPerPack
+		packs[packIndex] = append(packs[packIndex], fp)
+	}
+
+	// Upload the packs one after another.
+	for _, pack := range packs {
+		if err := r.managedUploadPack(up, pack, files); err != nil {
+			return err
+		}
+	}
+
+	// Queue bubbles for the dirs of the packed files, ignore the return
+	// channels as we do not want to block on these updates.
+	bubbled := make(map[modules.SiaPath]struct{})
+	for _, file := range up.Files {
+		dirSiaPath, err := file.SiaPath.Dir()
+		if err != nil {
+			r.log.Printf("Unable to fetch the directory from a siaPath %v for packed siafile: %v", file.SiaPath, err)
+			continue
+		}
+		if _, exists := bubbled[dirSiaPath]; exists {
+			continue
+		}
+		bubbled[dirSiaPath] = struct{}{}
+		_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	}
+	return nil
+}
+
+// managedUploadPack uploads the files placed within a single pack. The pack is
+// available on the network once this method returns.
+func (r *Renter) managedUploadPack(up modules.PackedUploadParams, placements []modules.FilePlacement, files map[string]modules.PackedUploadFile) (err error) {
+	// Determine the offsets of the files within the pack's chunk and the size
+	// of the pack.
+	sectorsPerPack := uint64(up.ErasureCode.MinPieces())
+	offsets := make([]uint64, len(placements))
+	var packSize uint64
+	for i, fp := range placements {
+		offsets[i] = (fp.SectorIndex%sectorsPerPack)*modules.SectorSize + fp.SectorOffset
+		if end := offsets[i] + fp.Size; end > packSize {
+			packSize = end
+		}
+	}
+
+	// Read the files into the pack.
+	data := make([]byte, packSize)
+	for i, fp := range placements {
+		err := func() (err error) {
+			f, err := os.Open(files[fp.FileID].Source)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Compose(err, f.Close())
+			}()
+			_, err = io.ReadFull(f, data[offsets[i]:offsets[i]+fp.Size])
+			return err
+		}()
+		if err != nil {
+			return errors.AddContext(err, "unable to read file into pack")
+		}
+	}
+
+	// Upload the pack.
+	packSiaPath, err := packedFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return err
+	}
+	pack, err := r.callUploadStreamFromReader(modules.FileUploadParams{
+		SiaPath:             packSiaPath,
+		ErasureCode:         up.ErasureCode,
+		DisablePartialChunk: true,
+		CipherType:          up.CipherType,
+	}, bytes.NewReader(data))
+	if err != nil {
+		return errors.AddContext(err, "unable to upload pack")
+	}
+	defer func() {
+		// Delete the pack again if none of the files were packed into it.
+		unused := pack.NumPackedFiles() == 0
+		err = errors.Compose(err, pack.Close())
+		if err != nil && unused {
+			deleteErr := r.staticFileSystem.DeleteFile(packSiaPath)
+			if !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+				err = errors.Compose(err, deleteErr)
+			}
+		}
+	}()
+
+	// Create the siafiles of the packed files. The pack keeps track of the
+	// number of files referencing it before they are created. That way an
+	// interruption can only cause the pack to be kept around for longer than
+	// necessary but never cause it to be deleted while still in use.
+	for i, fp := range placements {
+		file := files[fp.FileID]
+		if err := pack.AddPackedFiles(1); err != nil {
+			return errors.AddContext(err, "unable to add packed file to pack")
+		}
+		// Existing files are replaced by moving a temporary file in their
+		// place.
+		siaPath := file.SiaPath
+		if up.Force {
+			siaPath, err = packedTmpFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+			if err != nil {
+				_, removeErr := pack.RemovePackedFile()
+				return errors.Compose(err, removeErr)
+			}
+		}
+		err := r.managedCreatePackedFile(file, siaPath, fp.Size, pack.MasterKey(), up.ErasureCode, packSiaPath, offsets[i])
+		if err != nil {
+			_, removeErr := pack.RemovePackedFile()
+			return errors.Compose(errors.AddContext(err, "unable to create packed file"), removeErr)
+		}
+		if !up.Force {
+			continue
+		}
+		// Deleting the temporary file also removes its reference to the
+		// pack.
+		if err := r.managedReplaceFile(file.SiaPath, siaPath); err != nil {
+			deleteErr := r.staticFileSystem.DeleteFile(siaPath)
+			return errors.Compose(errors.AddContext(err, "unable to replace existing file"), deleteErr)
+		}
+	}
+	return nil
+}
+
+// managedCreatePackedFile creates the siafile of a file which is packed into
+// the pack at packSiaPath at the given offset. The siafile is created at
+// siaPath instead of the file's siapath.
+func (r *Renter) managedCreatePackedFile(file modules.PackedUploadFile, siaPath modules.SiaPath, size uint64, mk crypto.CipherKey, ec modules.ErasureCoder, packSiaPath modules.SiaPath, offset uint64) error {
+	sourceInfo, err := os.Stat(file.Source)
+	if err != nil {
+		return errors.AddContext(err, "unable to stat input file")
+	}
+	err = r.staticFileSystem.NewSiaFile(siaPath, file.Source, ec, mk, size, sourceInfo.Mode(), true)
+	if err != nil {
+		return err
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.Compose(err, r.staticFileSystem.DeleteFile(siaPath))
+	}
+	err = entry.SetPacked(packSiaPath, offset)
+	if err != nil {
+		err = errors.Compose(err, entry.Close())
+		return errors.Compose(err, r.staticFileSystem.DeleteFile(siaPath))
+	}
+	return entry.Close()
+}
+
+// managedFileSnapshot creates a snapshot of a file. The snapshot of a packed
+// file is created from its pack.
+func (r *Renter) managedFileSnapshot(node *filesystem.FileNode, sp modules.SiaPath) (_ *siafile.Snapshot, err error) {
+	if !node.IsPacked() {
+		return node.Snapshot(sp)
+	}
+	pack, err := r.staticFileSystem.OpenSiaFile(node.PackedSiaPath())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open pack of packed file")
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	return node.SnapshotPacked(sp, pack.SiaFile)
+}
+
+// managedFileSnapshotRange creates a snapshot of a file that only contains the
+// chunks needed to download the range specified by offset and length. The
+// snapshot of a packed file is created from its pack.
+func (r *Renter) managedFileSnapshotRange(node *filesystem.FileNode, sp modules.SiaPath, offset, length uint64) (_ *siafile.Snapshot, err error) {
+	if !node.IsPacked() {
+		return node.SnapshotRange(sp, offset, length)
+	}
+	pack, err := r.staticFileSystem.OpenSiaFile(node.PackedSiaPath())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open pack of packed file")
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	return node.SnapshotPackedRange(sp, pack.SiaFile, offset, length)
+}
+
+// managedUpdatePackedFileMetadata updates the cached metadata of a packed file
+// using the health and redundancy of its pack.
+func (r *Renter) managedUpdatePackedFileMetadata(sf *filesystem.FileNode, offlineMap, goodForRenew map[string]bool, contracts map[string]modules.RenterContract) (err error) {
+	pack, err := r.staticFileSystem.OpenSiaFile(sf.PackedSiaPath())
+	if err != nil {
+		return errors.AddContext(err, "unable to open pack of packed file")
+	}
+	defer func() {
+		err = errors.Compose(err, pack.Close())
+	}()
+	// Update the cached values of the pack without persisting them. That's
+	// up to the repair code when it updates the pack itself.
+	_, _, err = pack.Redundancy(offlineMap, goodForRenew)
+	if err != nil {
+		return errors.AddContext(err, "unable to update cached redundancy of pack")
+	}
+	_, _, _, _, _, _, _ = pack.Health(offlineMap, goodForRenew)
+	_ = pack.Expiration(contracts)
+	if _, _, err = pack.UploadProgressAndBytes(); err != nil {
+		return errors.AddContext(err, "unable to update upload progress of pack")
+	}
+	if err := sf.UpdatePackedMetadata(pack.Metadata()); err != nil {
+		return err
+	}
+	sf.SetLastHealthCheckTime()
+	return sf.SaveMetadata()
+}
@@ -177,18 +177,35 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	}
 
 	// If there's a cipherKey defined already use that, otherwise generate a new
-	// key of the given cipherType.
+	// key of the given cipherType. If no cipherType has been set either, the
+	// default cipher type of the renter will be used.
+	// Files in dedup mode use the key of their encryption domain.
+	if up.Dedup && up.CipherKey != nil {
+		return nil, ErrDedupCipherKey
+	}
 	cipherKey := up.CipherKey
 	if up.CipherKey == nil {
+		if cipherType == (crypto.CipherType{}) {
+			cipherType = r.managedDefaultCipherType()
+		} else if !isValidUploadCipherType(cipherType) {
+			return nil, ErrInvalidUploadCipherType
+		}
 		cipherKey = crypto.GenerateSiaKey(cipherType)
 	}
+	if up.Dedup {
+		cipherKey, err = r.managedDedupDomainKey(cipherType)
+		if err != nil {
+			return nil, errors.AddContext(err, "could not get the dedup key")
+		}
+		up.DisablePartialChunk = true
+	}
 
 	// Create the Siafile and add to renter
 	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenSiaFile(siaPath)
+	return r.managedOpenNewSiaFile(siaPath, up.Dedup)
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -201,6 +218,14 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 // the streamer may continue uploading in the background after returning while
 // it is boosting redundancy.
 func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (fileNode *filesystem.FileNode, err error) {
+	return r.managedUploadStreamFromReader(up, reader, r.userUploadMemoryManager, memoryPriorityHigh)
+}
+
+// managedUploadStreamFromReader is like callUploadStreamFromReader but the
+// memory for the chunks is requested from the provided memory manager with the
+// provided priority. This allows for uploads scheduled by siad to not compete
+// with user-initiated uploads.
+func (r *Renter) managedUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader, mm *memoryManager, priority bool) (fileNode *filesystem.FileNode, err error) {
 	// Check the upload params first.
 	fileNode, err = r.managedInitUploadStream(up)
 	if err != nil {
@@ -266,7 +291,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 
 		// Start the chunk upload.
 		offline, goodForRenew, _ := r.managedContractUtilityMaps()
-		uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.userUploadMemoryManager)
+		uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, priority, offline, goodForRenew, mm)
 		if err != nil {
 			return nil, errors.AddContext(err, "unable to fetch chunk for stream")
 		}
@@ -622,6 +622,11 @@ func (w *worker) managedNeedsToSyncAccountBalanceToHost() bool {
 	if !w.staticPriceTable().staticValid() {
 		return false
 	}
+	// No need to sync while a subscription session is open, its budget is
+	// still pending and the sync would fail.
+	if atomic.LoadUint64(&w.staticSubscriptionInfo.atomicSessionActive) == 1 {
+		return false
+	}
 
 	return w.staticAccount.callNeedsToSync()
 }
@@ -11,6 +11,9 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -120,6 +123,17 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	// whether successful or failed, the worker needs to be removed.
 	defer udc.managedRemoveWorker()
 
+	// Attribute the cost of the download to the file once the worker is done.
+	udc.mu.Lock()
+	udc.workersFetching++
+	udc.mu.Unlock()
+	defer func() {
+		err := w.renter.managedFlushDownloadSpending(udc)
+		if err != nil {
+			w.renter.log.Debugln("worker failed to attribute download spending to file:", err)
+		}
+	}()
+
 	// Before performing the download, check for price gouging.
 	allowance := w.renter.hostContractor.Allowance()
 	err := checkDownloadGouging(allowance, &w.staticPriceTable().staticPriceTable)
@@ -133,13 +147,18 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	// unregistered with the chunk.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
 	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	pieceData, cost, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
 
+	// Remember the cost of the download for attributing it to the file.
+	udc.mu.Lock()
+	udc.pendingSpending = udc.pendingSpending.Add(cost)
+	udc.mu.Unlock()
+
 	// TODO: Instead of adding the whole sector after the download completes,
 	// have the 'd.Sector' call add to this value ongoing as the sector comes
 	// in. Perhaps even include the data from creating the downloader and other
@@ -288,3 +307,50 @@ func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfi
 	udc.workersStandby = append(udc.workersStandby, w)
 	return nil
 }
+
+// managedFlushDownloadSpending is called by a worker once it is done fetching a
+// piece of the chunk. If no other worker is fetching a piece of the chunk
+// anymore, the accumulated cost of the downloaded pieces is attributed to the
+// file.
+func (r *Renter) managedFlushDownloadSpending(udc *unfinishedDownloadChunk) error {
+	udc.mu.Lock()
+	udc.workersFetching--
+	if udc.workersFetching > 0 || udc.pendingSpending.IsZero() {
+		udc.mu.Unlock()
+		return nil
+	}
+	cost := udc.pendingSpending
+	udc.pendingSpending = types.ZeroCurrency
+	udc.mu.Unlock()
+	return r.managedAddDownloadSpending(udc.renterFile, udc.staticSpendingCategory, cost)
+}
+
+// managedAddDownloadSpending attributes the cost of downloading pieces to the
+// file that the pieces belong to. Repair downloads are tracked separately from
+// regular downloads. If the file was deleted or replaced since the download
+// started, the spending is not attributed.
+func (r *Renter) managedAddDownloadSpending(snap *siafile.Snapshot, category spendingCategory, cost types.Currency) (err error) {
+	if snap == nil || cost.IsZero() {
+		return nil
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(snap.SiaPath())
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if entry.UID() != snap.UID() {
+		return nil
+	}
+	var spending modules.FileSpending
+	if category == categoryRepairDownload {
+		spending.RepairSpending = cost
+	} else {
+		spending.DownloadSpending = cost
+	}
+	return entry.AddSpending(spending)
+}
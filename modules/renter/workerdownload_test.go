@@ -457,3 +457,87 @@ func TestProcessDownloadChunk(t *testing.T) {
 		t.Fatalf("expected 0 download chunk but got %v", queue.callLen())
 	}
 }
+
+// TestAddDownloadSpending tests that managedAddDownloadSpending attributes
+// download spending to the right file and spending field.
+func TestAddDownloadSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a file and a snapshot of it.
+	sf, err := r.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := r.staticFileSystem.FileSiaPath(sf)
+	snap, err := sf.Snapshot(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Attribute a regular and a repair download.
+	err = r.managedAddDownloadSpending(snap, categoryDownload, types.NewCurrency64(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.managedAddDownloadSpending(snap, categoryRepairDownload, types.NewCurrency64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spending := sf.Spending()
+	if !spending.DownloadSpending.Equals(types.NewCurrency64(2)) {
+		t.Fatal("wrong download spending", spending.DownloadSpending)
+	}
+	if !spending.RepairSpending.Equals(types.NewCurrency64(3)) {
+		t.Fatal("wrong repair spending", spending.RepairSpending)
+	}
+
+	// The spending of a chunk is only attributed once the last worker is done
+	// fetching a piece.
+	udc := &unfinishedDownloadChunk{
+		renterFile:             snap,
+		staticSpendingCategory: categoryDownload,
+		pendingSpending:        types.NewCurrency64(4),
+		workersFetching:        2,
+	}
+	if err := r.managedFlushDownloadSpending(udc); err != nil {
+		t.Fatal(err)
+	}
+	if spending := sf.Spending(); !spending.DownloadSpending.Equals(types.NewCurrency64(2)) {
+		t.Fatal("spending shouldn't be attributed yet", spending.DownloadSpending)
+	}
+	if err := r.managedFlushDownloadSpending(udc); err != nil {
+		t.Fatal(err)
+	}
+	if spending := sf.Spending(); !spending.DownloadSpending.Equals(types.NewCurrency64(6)) {
+		t.Fatal("wrong download spending", spending.DownloadSpending)
+	}
+	if !udc.pendingSpending.IsZero() || udc.workersFetching != 0 {
+		t.Fatal("chunk spending wasn't reset", udc.pendingSpending, udc.workersFetching)
+	}
+
+	// Delete the file. Attributing spending should be a no-op now.
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.staticFileSystem.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	err = r.managedAddDownloadSpending(snap, categoryDownload, types.NewCurrency64(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
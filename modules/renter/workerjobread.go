@@ -56,6 +56,9 @@ type (
 
 		// The time it took for this job to complete.
 		staticJobTime time.Duration
+
+		// The amount of money that was spent on the job.
+		staticCost types.Currency
 	}
 
 	// jobReadMetadata contains meta information about a read job.
@@ -106,7 +109,7 @@ func (j *jobRead) callDiscard(err error) {
 // managedFinishExecute will execute code that is shared by multiple read jobs
 // after execution. It updates the performance metrics, records whether the
 // execution was successful and returns the response.
-func (j *jobRead) managedFinishExecute(readData []byte, readErr error, readJobTime time.Duration) {
+func (j *jobRead) managedFinishExecute(readData []byte, readCost types.Currency, readErr error, readJobTime time.Duration) {
 	// Send the response in a goroutine so that the worker resources can be
 	// released faster. Need to check if the job was canceled so that the
 	// goroutine will exit.
@@ -116,6 +119,7 @@ func (j *jobRead) managedFinishExecute(readData []byte, readErr error, readJobTi
 
 		staticMetadata: j.staticJobReadMetadata(),
 		staticJobTime:  readJobTime,
+		staticCost:     readCost,
 	}
 	w := j.staticQueue.staticWorker()
 	err := w.renter.tg.Launch(func() {
@@ -8,6 +8,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 type (
@@ -22,15 +23,15 @@ type (
 func (j *jobReadOffset) callExecute() {
 	// Track how long the job takes.
 	start := time.Now()
-	data, err := j.managedReadOffset()
+	data, cost, err := j.managedReadOffset()
 	jobTime := time.Since(start)
 
 	// Finish the execution.
-	j.jobRead.managedFinishExecute(data, err, jobTime)
+	j.jobRead.managedFinishExecute(data, cost, err, jobTime)
 }
 
 // managedReadOffset returns the sector data for given root.
-func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
+func (j *jobReadOffset) managedReadOffset() ([]byte, types.Currency, error) {
 	// create the program
 	w := j.staticQueue.staticWorker()
 	bh := w.staticCache().staticBlockHeight
@@ -49,7 +50,7 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	// Read responses.
 	responses, err := j.jobRead.managedRead(w, program, programData, cost)
 	if err != nil {
-		return nil, errors.AddContext(err, "jobReadOffset: failed to execute managedRead")
+		return nil, types.ZeroCurrency, errors.AddContext(err, "jobReadOffset: failed to execute managedRead")
 	}
 	revResponse := responses[0]
 	downloadResponse := responses[1]
@@ -60,23 +61,23 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	// agreed upon at some point.
 	cpk, ok := w.renter.hostContractor.ContractPublicKey(w.staticHostPubKey)
 	if !ok {
-		return nil, errors.New("jobReadOffset: failed to get public key for contract")
+		return nil, types.ZeroCurrency, errors.New("jobReadOffset: failed to get public key for contract")
 	}
 
 	// Unmarshal the revision response.
 	var revResp modules.MDMInstructionRevisionResponse
 	err = encoding.Unmarshal(revResponse.Output, &revResp)
 	if err != nil {
-		return nil, errors.AddContext(err, "jobReadOffset: failed to unmarshal revision")
+		return nil, types.ZeroCurrency, errors.AddContext(err, "jobReadOffset: failed to unmarshal revision")
 	}
 	// Check that the revision txn contains the right number of signatures
 	revisionTxn := revResp.RevisionTxn
 	if len(revisionTxn.TransactionSignatures) != 2 {
-		return nil, errors.New("jobReadOffset: invalid number of signatures on txn")
+		return nil, types.ZeroCurrency, errors.New("jobReadOffset: invalid number of signatures on txn")
 	}
 	// Check that the revision txn contains the right number of revisions.
 	if len(revisionTxn.FileContractRevisions) != 1 {
-		return nil, errors.New("jobReadOffset: invalid number of revisions in txn")
+		return nil, types.ZeroCurrency, errors.New("jobReadOffset: invalid number of revisions in txn")
 	}
 	rev := revResp.RevisionTxn.FileContractRevisions[0]
 	// Verify the signatures.
@@ -85,16 +86,16 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	hash := revisionTxn.SigHash(0, bh) // this should be the start height but this works too
 	err = crypto.VerifyHash(hash, cpk, signature)
 	if err != nil {
-		return nil, errors.AddContext(err, "jobReadOffset: failed to verify signature on revision")
+		return nil, types.ZeroCurrency, errors.AddContext(err, "jobReadOffset: failed to verify signature on revision")
 	}
 	// Verify proof.
 	proofStart := int(j.staticOffset) / crypto.SegmentSize
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	ok = crypto.VerifyMixedRangeProof(downloadResponse.Output, downloadResponse.Proof, rev.NewFileMerkleRoot, proofStart, proofEnd)
 	if !ok {
-		return nil, errors.New("verifying proof failed")
+		return nil, types.ZeroCurrency, errors.New("verifying proof failed")
 	}
-	return downloadResponse.Output, nil
+	return downloadResponse.Output, cost, nil
 }
 
 // ReadOffset is a helper method to run a ReadOffset job on a worker.
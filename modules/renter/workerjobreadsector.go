@@ -7,6 +7,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 type (
@@ -23,15 +24,15 @@ type (
 func (j *jobReadSector) callExecute() {
 	// Track how long the job takes.
 	start := time.Now()
-	data, err := j.managedReadSector()
+	data, cost, err := j.managedReadSector()
 	jobTime := time.Since(start)
 
 	// Finish the execution.
-	j.jobRead.managedFinishExecute(data, err, jobTime)
+	j.jobRead.managedFinishExecute(data, cost, err, jobTime)
 }
 
 // managedReadSector returns the sector data for given root.
-func (j *jobReadSector) managedReadSector() ([]byte, error) {
+func (j *jobReadSector) managedReadSector() ([]byte, types.Currency, error) {
 	// create the program
 	w := j.staticQueue.staticWorker()
 	pt := w.staticPriceTable().staticPriceTable
@@ -47,7 +48,7 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 
 	responses, err := j.jobRead.managedRead(w, program, programData, cost)
 	if err != nil {
-		return nil, errors.AddContext(err, "jobReadSector: failed to execute managedRead")
+		return nil, types.ZeroCurrency, errors.AddContext(err, "jobReadSector: failed to execute managedRead")
 	}
 	data := responses[0].Output
 	proof := responses[0].Proof
@@ -56,9 +57,9 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 	proofStart := int(j.staticOffset) / crypto.SegmentSize
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	if !crypto.VerifyRangeProof(data, proof, proofStart, proofEnd, j.staticSector) {
-		return nil, errors.New("proof verification failed")
+		return nil, types.ZeroCurrency, errors.New("proof verification failed")
 	}
-	return data, nil
+	return data, cost, nil
 }
 
 // newJobReadSector creates a new read sector job.
@@ -80,24 +81,24 @@ func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, resp
 }
 
 // ReadSector is a helper method to run a ReadSector job with low priority on a
-// worker.
-func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, error) {
+// worker. Apart from the data it also returns the money spent on the read.
+func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, types.Currency, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
 	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, category, root, offset, length)
 
 	// Add the job to the queue.
 	if !w.staticJobReadQueue.callAdd(jro) {
-		return nil, errors.New("worker unavailable")
+		return nil, types.ZeroCurrency, errors.New("worker unavailable")
 	}
 
 	// Wait for the response.
 	var resp *jobReadResponse
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("Read interrupted")
+		return nil, types.ZeroCurrency, errors.New("Read interrupted")
 	case resp = <-readSectorRespChan:
 	}
-	return resp.staticData, resp.staticErr
+	return resp.staticData, resp.staticCost, resp.staticErr
 }
 
 // ReadSector is a helper method to run a ReadSector job on a worker.
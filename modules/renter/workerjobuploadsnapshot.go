@@ -257,7 +257,7 @@ func (r *Renter) managedUploadSnapshotHost(meta modules.UploadedBackup, dotSia [
 		Size:         meta.Size,
 	}
 	for j, piece := range sectors {
-		root, err := host.Upload(piece)
+		root, _, err := host.Upload(piece)
 		if err != nil {
 			return errors.AddContext(err, "could not perform host upload")
 		}
@@ -85,6 +85,12 @@ type (
 		// stats
 		atomicExtensions uint64
 
+		// atomicSessionActive is set to 1 while a subscription session is
+		// open. The budget of an open session is tracked as a pending
+		// withdrawal which prevents the worker from syncing its account
+		// balance.
+		atomicSessionActive uint64
+
 		// cooldown
 		cooldownUntil       time.Time
 		consecutiveFailures uint64
@@ -214,8 +220,9 @@ func (nh *notificationHandler) managedHandleRegistryEntry(stream siamux.Stream,
 		return fmt.Errorf("subscription not found")
 	}
 
-	// Update the subscription.
+	// Update the subscription and notify the watchers.
 	sub.latestRV = &sneu.Entry
+	w.renter.staticRegistryWatchers.callNotify(sneu.PubKey, sneu.Entry)
 	return nil
 }
 
@@ -520,6 +527,7 @@ func (w *worker) managedSubscribeToRVs(stream siamux.Stream, toSubscribe []modul
 	defer subInfo.mu.Unlock()
 	for _, rv := range rvs {
 		subInfo.subscriptions[modules.DeriveRegistryEntryID(rv.PubKey, rv.Entry.Tweak)].latestRV = &rv.Entry
+		w.renter.staticRegistryWatchers.callNotify(rv.PubKey, rv.Entry)
 	}
 	// Close the channels to signal that the subscription is done.
 	for _, c := range subChans {
@@ -758,6 +766,7 @@ func (w *worker) threadedSubscriptionLoop() {
 		budget := modules.NewBudget(initialBudget)
 
 		// Track the withdrawal.
+		atomic.StoreUint64(&subInfo.atomicSessionActive, 1)
 		w.staticAccount.managedTrackWithdrawal(initialBudget)
 
 		// Prepare a unique handler for the host to subscribe to.
@@ -770,6 +779,7 @@ func (w *worker) threadedSubscriptionLoop() {
 		if err != nil {
 			// Mark withdrawal as failed.
 			w.staticAccount.managedCommitWithdrawal(categorySubscription, initialBudget, types.ZeroCurrency, false)
+			atomic.StoreUint64(&subInfo.atomicSessionActive, 0)
 
 			// Log error and increment cooldown.
 			w.renter.log.Printf("Worker %v: failed to begin subscription: %v", w.staticHostPubKeyStr, err)
@@ -785,6 +795,7 @@ func (w *worker) threadedSubscriptionLoop() {
 		refund := budget.Remaining()
 		withdrawal := initialBudget.Sub(refund)
 		w.staticAccount.managedCommitWithdrawal(categorySubscription, withdrawal, refund, true)
+		atomic.StoreUint64(&subInfo.atomicSessionActive, 0)
 
 		// Check the error.
 		if errors.Contains(errSubscription, threadgroup.ErrStopped) {
@@ -839,6 +850,8 @@ func (w *worker) Subscribe(ctx context.Context, requests ...modules.RPCRegistryS
 			sub = newSubscription(&requests[i])
 			subInfo.subscriptions[sid] = sub
 		}
+		// Make sure a subscription that is about to be removed is kept.
+		sub.subscribe = true
 		subs = append(subs, sub)
 		subChans = append(subChans, sub.subscribed)
 	}
@@ -206,7 +206,7 @@ func (w *worker) managedPerformUploadChunkJob() {
 	//
 	// Ignore the error if it's a ErrMaxVirtualSectors coming from a pre-1.5.5
 	// host.
-	root, err := e.Upload(uc.physicalChunkData[pieceIndex])
+	root, spending, err := e.Upload(uc.physicalChunkData[pieceIndex])
 	ignoreErr := build.VersionCmp(hostSettings.Version, "1.5.5") < 0 && err != nil && strings.Contains(err.Error(), modules.ErrMaxVirtualSectors.Error())
 	if err != nil && !ignoreErr {
 		failureErr := fmt.Errorf("Worker failed to upload root %v via the editor: %v", root, err)
@@ -217,6 +217,12 @@ func (w *worker) managedPerformUploadChunkJob() {
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
 
+	// Attribute the cost of the upload to the file. The host has already been
+	// paid, so a failure to record the spending doesn't fail the upload.
+	if err := uc.fileEntry.AddSpending(spending); err != nil {
+		w.renter.log.Printf("Worker failed to add upload spending to SiaFile: %v", err)
+	}
+
 	// Add piece to renterFile
 	err = uc.fileEntry.AddPiece(w.staticHostPubKey, uc.staticIndex, pieceIndex, root)
 	if err != nil {
@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"go.sia.tech/siad/crypto"
 )
 
@@ -14,14 +16,29 @@ const (
 	StorageManagerDir = "storagemanager"
 )
 
+const (
+	// StorageTierHDD is the tier of regular storage folders. New sectors are
+	// stored in this tier and sectors which are rarely read are demoted to it.
+	StorageTierHDD StorageTier = "hdd"
+
+	// StorageTierSSD is the fast tier of storage folders. Sectors which are
+	// read often or which the host expects to be read often are promoted to
+	// it.
+	StorageTierSSD StorageTier = "ssd"
+)
+
 type (
+	// StorageTier is the tier of a storage folder.
+	StorageTier string
+
 	// StorageFolderMetadata contains metadata about a storage folder that is
 	// tracked by the storage folder manager.
 	StorageFolderMetadata struct {
-		Capacity          uint64 `json:"capacity"`          // bytes
-		CapacityRemaining uint64 `json:"capacityremaining"` // bytes
-		Index             uint16 `json:"index"`
-		Path              string `json:"path"`
+		Capacity          uint64      `json:"capacity"`          // bytes
+		CapacityRemaining uint64      `json:"capacityremaining"` // bytes
+		Index             uint16      `json:"index"`
+		Path              string      `json:"path"`
+		Tier              StorageTier `json:"tier"`
 
 		// Below are statistics about the filesystem. FailedReads and
 		// FailedWrites are only incremented if the filesystem is returning
@@ -42,6 +59,43 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// StorageFolderMigration contains information about a storage folder
+	// migration that is in progress. A migration moves all of the sectors of
+	// the source storage folder into the destination storage folder and
+	// removes the source afterwards.
+	StorageFolderMigration struct {
+		Source       uint16 `json:"source"`
+		Destination  uint16 `json:"destination"`
+		MaxBandwidth uint64 `json:"maxbandwidth"` // bytes per second
+		MaxIOPS      uint64 `json:"maxiops"`
+
+		SectorsTotal    uint64 `json:"sectorstotal"`
+		SectorsMigrated uint64 `json:"sectorsmigrated"`
+		SectorsFailed   uint64 `json:"sectorsfailed"`
+	}
+
+	// StorageScrubStatus contains information about the sector scrubber which
+	// periodically verifies that the stored sectors still match their merkle
+	// roots.
+	StorageScrubStatus struct {
+		Rate             uint64    `json:"rate"` // bytes per second
+		CycleStart       time.Time `json:"cyclestart"`
+		LastCycleEnd     time.Time `json:"lastcycleend"`
+		SectorsScrubbed  uint64    `json:"sectorsscrubbed"`
+		SectorsTotal     uint64    `json:"sectorstotal"`
+		CorruptedSectors uint64    `json:"corruptedsectors"`
+	}
+
+	// StorageTieringStatus contains information about the tiering of sectors
+	// between the storage folders of the fast and the regular tier. The
+	// number of promoted and demoted sectors are counted since startup.
+	StorageTieringStatus struct {
+		HotSectors      uint64    `json:"hotsectors"`
+		LastCycle       time.Time `json:"lastcycle"`
+		PromotedSectors uint64    `json:"promotedsectors"`
+		DemotedSectors  uint64    `json:"demotedsectors"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -78,6 +132,10 @@ type (
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CorruptedSectors returns the roots of the provided sectors which
+		// failed the integrity check of the sector scrubber.
+		CorruptedSectors(sectorRoots []crypto.Hash) []crypto.Hash
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -105,6 +163,14 @@ type (
 		// of all at once.
 		MarkSectorsForRemoval(sectorRoots []crypto.Hash) error
 
+		// MigrateStorageFolder will move all of the sectors of the source
+		// storage folder into the destination storage folder in the
+		// background and remove the source once it is empty. The migration
+		// is throttled to the provided bandwidth in bytes per second and disk
+		// operations per second, with 0 meaning no limit. Migrations are
+		// resumed after a restart.
+		MigrateStorageFolder(source, destination uint16, maxBandwidth, maxIOPS uint64) error
+
 		// RemoveStorageFolder will remove a storage folder from the manager.
 		// All storage on the folder will be moved to other storage folders,
 		// meaning that no data will be lost. If the manager is unable to save
@@ -129,8 +195,31 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubStatus returns the status of the sector scrubber.
+		ScrubStatus() StorageScrubStatus
+
+		// SetHotSectors replaces the set of sectors which should be kept in
+		// the fast tier regardless of how often they are read.
+		SetHotSectors(sectorRoots []crypto.Hash)
+
+		// SetScrubRate sets the number of bytes per second that the sector
+		// scrubber reads. A rate of 0 disables the scrubber.
+		SetScrubRate(rate uint64)
+
+		// SetStorageFolderTier sets the tier of a storage folder. Sectors are
+		// moved between the tiers in the background.
+		SetStorageFolderTier(index uint16, tier StorageTier) error
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
+
+		// StorageFolderMigrations returns the storage folder migrations that
+		// are in progress.
+		StorageFolderMigrations() []StorageFolderMigration
+
+		// TieringStatus returns the status of the tiering of sectors between
+		// the storage folders of the different tiers.
+		TieringStatus() StorageTieringStatus
 	}
 )
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"bytes"
 	"errors"
 	"strings"
 
@@ -51,6 +52,12 @@ var (
 	// will never be used within the formal Sia protocol.
 	PrefixNonSia = types.NewSpecifier("NonSia")
 
+	// PrefixReplaceable is the arbitrary data that marks a transaction as
+	// replaceable. The transaction pool only evicts a transaction in favor of
+	// a double-spend paying higher fees if the transaction carries this
+	// marker.
+	PrefixReplaceable = types.NewSpecifier("Replaceable")
+
 	// TransactionPoolDir is the name of the directory that is used to store
 	// the transaction pool's persistent data.
 	TransactionPoolDir = "transactionpool"
@@ -179,6 +186,16 @@ func IsConsensusConflict(err error) bool {
 	return strings.HasPrefix(err.Error(), consensusConflictPrefix)
 }
 
+// IsReplaceable returns true iff the transaction is marked as replaceable.
+func IsReplaceable(t types.Transaction) bool {
+	for _, arb := range t.ArbitraryData {
+		if bytes.Equal(arb, PrefixReplaceable[:]) {
+			return true
+		}
+	}
+	return false
+}
+
 // CalculateFee returns the fee-per-byte of a transaction set.
 func CalculateFee(ts []types.Transaction) types.Currency {
 	var sum types.Currency
@@ -196,14 +196,26 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 		return nil, errLowMinerFees
 	}
 
-	// Check that the transaction set is valid.
+	// Check that the transaction set is valid. If it isn't, the set might
+	// double-spend the inputs of a conflict, in which case it may replace the
+	// conflicting transactions.
 	cc, err := txnFn(superset)
 	if err != nil {
-		return nil, modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
+		replacement, replaceErr := tp.replaceConflicts(dedupSet, supersetMap, txnFn)
+		if errors.Contains(replaceErr, errNoDoubleSpend) {
+			return nil, modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
+		}
+		return replacement, replaceErr
 	}
+	tp.addSuperset(superset, supersetMap, cc)
+	return superset, nil
+}
 
+// addSuperset removes the conflicting transaction sets from the pool and adds
+// the superset which replaces them.
+func (tp *TransactionPool) addSuperset(superset []types.Transaction, conflicts map[modules.TransactionSetID]struct{}, cc modules.ConsensusChange) {
 	// Remove the conflicts from the transaction pool.
-	for conflict := range supersetMap {
+	for conflict := range conflicts {
 		conflictSet := tp.transactionSets[conflict]
 		tp.transactionListSize -= len(encoding.Marshal(conflictSet))
 		delete(tp.transactionSets, conflict)
@@ -239,8 +251,6 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 		}
 		tp.log.Debugf("accepted transaction superset %v, size: %vB\ntpool size is %vB after accpeting transaction superset\ntransactions: \n%v\n", setID, tsetSize, tp.transactionListSize, txLogs)
 	}
-
-	return superset, nil
 }
 
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
@@ -0,0 +1,175 @@
+package transactionpool
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errNoDoubleSpend is returned by replaceConflicts if the transaction set
+	// doesn't double-spend any of the conflicting transactions.
+	errNoDoubleSpend = errors.New("transaction set doesn't double-spend any transactions in the pool")
+
+	// errNotReplaceable is returned if a transaction set double-spends a
+	// transaction in the pool which isn't marked as replaceable.
+	errNotReplaceable = errors.New("transaction set double-spends a transaction which isn't marked as replaceable")
+
+	// errLowReplacementFees is returned if a transaction set double-spends
+	// transactions in the pool without paying enough fees to replace them.
+	errLowReplacementFees = errors.New("transaction set needs more miner fees to replace the transactions it double-spends")
+)
+
+// spentObjectIDs returns the ids of the outputs spent by a transaction. Two
+// transactions spending the same output double-spend each other.
+func spentObjectIDs(t types.Transaction) []ObjectID {
+	oids := make([]ObjectID, 0, len(t.SiacoinInputs)+len(t.SiafundInputs))
+	for _, sci := range t.SiacoinInputs {
+		oids = append(oids, ObjectID(sci.ParentID))
+	}
+	for _, sfi := range t.SiafundInputs {
+		oids = append(oids, ObjectID(sfi.ParentID))
+	}
+	return oids
+}
+
+// dependsOn returns whether a transaction spends or references any of the
+// provided objects.
+func dependsOn(t types.Transaction, oids map[ObjectID]struct{}) bool {
+	for _, oid := range spentObjectIDs(t) {
+		if _, exists := oids[oid]; exists {
+			return true
+		}
+	}
+	for _, fcr := range t.FileContractRevisions {
+		if _, exists := oids[ObjectID(fcr.ParentID)]; exists {
+			return true
+		}
+	}
+	for _, sp := range t.StorageProofs {
+		if _, exists := oids[ObjectID(sp.ParentID)]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// createdObjectIDs returns the ids of the objects created by a transaction.
+func createdObjectIDs(t types.Transaction) []ObjectID {
+	var oids []ObjectID
+	for i := range t.SiacoinOutputs {
+		oids = append(oids, ObjectID(t.SiacoinOutputID(uint64(i))))
+	}
+	for i := range t.FileContracts {
+		oids = append(oids, ObjectID(t.FileContractID(uint64(i))))
+	}
+	for i := range t.SiafundOutputs {
+		oids = append(oids, ObjectID(t.SiafundOutputID(uint64(i))))
+	}
+	return oids
+}
+
+// transactionFees returns the sum of the miner fees of the transactions.
+func transactionFees(txns []types.Transaction) (fees types.Currency) {
+	for _, txn := range txns {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return
+}
+
+// replaceConflicts replaces the transactions of the conflicting sets which
+// are double-spent by the transaction set ts. This allows the fee of a stuck
+// transaction to be bumped by double-spending its inputs. Only transactions
+// marked as replaceable can be double-spent, which keeps unmarked transactions
+// safe to rely on before they are confirmed. The double-spent transactions
+// and their descendants are evicted from the pool, the remaining
+// transactions of the conflicting sets are merged with ts. The new
+// transactions need to pay more fees than the evicted ones, plus the minimum
+// fee for their own size.
+func (tp *TransactionPool) replaceConflicts(ts []types.Transaction, conflicts map[modules.TransactionSetID]struct{}, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) ([]types.Transaction, error) {
+	// Determine the outputs spent by the new transactions.
+	newTxns := make(map[types.TransactionID]struct{}, len(ts))
+	spent := make(map[ObjectID]struct{})
+	for _, t := range ts {
+		newTxns[t.ID()] = struct{}{}
+		for _, oid := range spentObjectIDs(t) {
+			spent[oid] = struct{}{}
+		}
+	}
+
+	// Split the conflicting sets into the transactions which are kept and the
+	// ones which are evicted. The transactions of a set are ordered by their
+	// dependencies, so a single pass finds all descendants of the evicted
+	// transactions.
+	var kept, evicted []types.Transaction
+	evictedObjects := make(map[ObjectID]struct{})
+	for conflict := range conflicts {
+		for _, t := range tp.transactionSets[conflict] {
+			if _, exists := newTxns[t.ID()]; exists {
+				kept = append(kept, t)
+				delete(newTxns, t.ID())
+				continue
+			}
+			doubleSpent := dependsOn(t, spent)
+			if !doubleSpent && !dependsOn(t, evictedObjects) {
+				kept = append(kept, t)
+				continue
+			}
+			if doubleSpent && !modules.IsReplaceable(t) {
+				return nil, errNotReplaceable
+			}
+			evicted = append(evicted, t)
+			for _, oid := range createdObjectIDs(t) {
+				evictedObjects[oid] = struct{}{}
+			}
+		}
+	}
+	if len(evicted) == 0 {
+		return nil, errNoDoubleSpend
+	}
+
+	// Check that the new transactions pay enough fees to replace the evicted
+	// ones.
+	var added []types.Transaction
+	for _, t := range ts {
+		if _, exists := newTxns[t.ID()]; exists {
+			added = append(added, t)
+		}
+	}
+	requiredFees := transactionFees(evicted).Add(minEstimation.Mul64(uint64(len(encoding.Marshal(added)))))
+	if transactionFees(added).Cmp(requiredFees) < 0 {
+		return nil, errLowReplacementFees
+	}
+
+	// Check the composition, fees and validity of the new superset. Kept
+	// transactions go first to preserve dependency ordering.
+	superset := append(kept, added...)
+	setSize, err := tp.checkTransactionSetComposition(superset)
+	if err != nil {
+		return nil, err
+	}
+	if tp.requiredFeesToExtendTpool().Mul64(setSize).Cmp(transactionFees(superset)) > 0 {
+		return nil, errLowMinerFees
+	}
+	cc, err := txnFn(superset)
+	if err != nil {
+		return nil, modules.NewConsensusConflict("provided transaction set double-spends the transaction pool, but is still invalid: " + err.Error())
+	}
+
+	// Forget the evicted transactions and the objects that pointed to the
+	// replaced sets.
+	for _, t := range evicted {
+		delete(tp.transactionHeights, t.ID())
+	}
+	for oid, setID := range tp.knownObjects {
+		if _, exists := conflicts[setID]; exists {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	tp.addSuperset(superset, conflicts, cc)
+	tp.log.Debugf("replaced %v transactions with a transaction set paying %v in fees\n", len(evicted), transactionFees(added).HumanString())
+	return superset, nil
+}
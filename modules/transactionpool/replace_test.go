@@ -0,0 +1,153 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestReplaceByFee checks that a transaction set which double-spends a set in
+// the pool replaces it and its descendants if it pays enough fees.
+func TestReplaceByFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Fund a partial transaction. wholeTransaction is set to false so that
+	// the same signature can be used for the replacements.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lowFeeSet := append([]types.Transaction(nil), txnSet...)
+	replacementSet := append([]types.Transaction(nil), txnSet...)
+	txnIndex := len(txnSet) - 1
+
+	// The original transaction is marked as replaceable and sends the funds
+	// to an output which anyone can spend. A child spends that output.
+	uc := types.UnlockConditions{}
+	txnSet[txnIndex].ArbitraryData = append(txnSet[txnIndex].ArbitraryData, modules.PrefixReplaceable[:])
+	txnSet[txnIndex].SiacoinOutputs = append(txnSet[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund, UnlockHash: uc.UnlockHash()})
+	original := txnSet[txnIndex]
+	child := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: original.SiacoinOutputID(uint64(len(original.SiacoinOutputs) - 1)), UnlockConditions: uc}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: fund, UnlockHash: uc.UnlockHash()}},
+	}
+	err = tpt.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.AcceptTransactionSet(append(txnSet, child))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A double-spend which doesn't pay enough fees is rejected.
+	lowFeeSet[txnIndex].MinerFees = append(lowFeeSet[txnIndex].MinerFees, minEstimation)
+	lowFeeSet[txnIndex].SiacoinOutputs = append(lowFeeSet[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund.Sub(minEstimation)})
+	err = tpt.tpool.AcceptTransactionSet(lowFeeSet)
+	if !errors.Contains(err, errLowReplacementFees) {
+		t.Fatal("expected errLowReplacementFees", err)
+	}
+
+	// A double-spend paying enough fees replaces the original and its child.
+	replacementSet[txnIndex].MinerFees = append(replacementSet[txnIndex].MinerFees, fund)
+	replacement := replacementSet[txnIndex]
+	err = tpt.tpool.AcceptTransactionSet(replacementSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, exists := tpt.tpool.Transaction(original.ID()); exists {
+		t.Fatal("original transaction wasn't evicted")
+	}
+	if _, _, exists := tpt.tpool.Transaction(child.ID()); exists {
+		t.Fatal("child of the original transaction wasn't evicted")
+	}
+	if _, _, exists := tpt.tpool.Transaction(replacement.ID()); !exists {
+		t.Fatal("replacement isn't in the pool")
+	}
+	for _, txn := range tpt.tpool.TransactionList() {
+		if txn.ID() == original.ID() || txn.ID() == child.ID() {
+			t.Fatal("evicted transaction is still in the transaction list")
+		}
+	}
+
+	// The replacement can be mined.
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tpt.tpool.transactionConfirmed(tpt.tpool.dbTx, replacement.ID()) {
+		t.Fatal("replacement wasn't confirmed")
+	}
+}
+
+// TestReplaceByFeeNotReplaceable checks that a transaction which isn't marked
+// as replaceable can't be double-spent.
+func TestReplaceByFeeNotReplaceable(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Fund a partial transaction and submit it without the marker.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacementSet := append([]types.Transaction(nil), txnSet...)
+	txnIndex := len(txnSet) - 1
+	txnSet[txnIndex].SiacoinOutputs = append(txnSet[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	original := txnSet[txnIndex]
+	err = tpt.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A double-spend is rejected no matter how much it pays.
+	replacementSet[txnIndex].MinerFees = append(replacementSet[txnIndex].MinerFees, fund)
+	err = tpt.tpool.AcceptTransactionSet(replacementSet)
+	if !errors.Contains(err, errNotReplaceable) {
+		t.Fatal("expected errNotReplaceable", err)
+	}
+	if _, _, exists := tpt.tpool.Transaction(original.ID()); !exists {
+		t.Fatal("original transaction was evicted")
+	}
+}
@@ -107,6 +107,7 @@ func isStandardTransaction(t types.Transaction) (uint64, error) {
 		if prefix == modules.PrefixHostAnnouncement ||
 			prefix == modules.PrefixNonSia ||
 			prefix == modules.PrefixFileContractIdentifier ||
+			prefix == modules.PrefixReplaceable ||
 			prefix == types.SpecifierFoundation {
 			continue
 		}
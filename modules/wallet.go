@@ -27,6 +27,53 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// FeeBumpRBF bumps the fee of a transaction by replacing it with a copy
+	// which double-spends the same inputs and pays a higher miner fee.
+	FeeBumpRBF = FeeBumpStrategy("rbf")
+
+	// FeeBumpCPFP bumps the fee of a transaction by attaching a child
+	// transaction which spends one of its outputs and pays the miner fee.
+	FeeBumpCPFP = FeeBumpStrategy("cpfp")
+)
+
+const (
+	// CoinSelectionLargestFirst spends the largest outputs first. This is the
+	// default strategy and minimizes the number of inputs.
+	CoinSelectionLargestFirst = CoinSelectionStrategy("largestfirst")
+
+	// CoinSelectionSmallestFirst spends the smallest outputs first, which
+	// consolidates the outputs of the wallet.
+	CoinSelectionSmallestFirst = CoinSelectionStrategy("smallestfirst")
+
+	// CoinSelectionPrivacy spends a single output if possible, so that no
+	// addresses of the wallet are linked, and picks outputs in random order
+	// otherwise.
+	CoinSelectionPrivacy = CoinSelectionStrategy("privacy")
+
+	// CoinSelectionBranchAndBound searches for a combination of outputs which
+	// matches the amount closely enough to not require a refund output.
+	CoinSelectionBranchAndBound = CoinSelectionStrategy("branchandbound")
+)
+
+const (
+	// PaymentEventUnconfirmed is sent when a payment appears in the
+	// transaction pool.
+	PaymentEventUnconfirmed = PaymentEventType("unconfirmed")
+
+	// PaymentEventConfirmed is sent once a payment has as many confirmations
+	// as required by the subscription.
+	PaymentEventConfirmed = PaymentEventType("confirmed")
+
+	// PaymentEventReverted is sent when the block containing a payment is
+	// reverted by a reorg.
+	PaymentEventReverted = PaymentEventType("reverted")
+
+	// PaymentEventDropped is sent when an unconfirmed payment leaves the
+	// transaction pool without being confirmed.
+	PaymentEventDropped = PaymentEventType("dropped")
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -46,6 +93,10 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrPartiallySignedMismatch is returned when combining partially signed
+	// transactions which don't belong to the same transaction.
+	ErrPartiallySignedMismatch = errors.New("partially signed transactions don't belong to the same transaction")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -59,6 +110,99 @@ type (
 	// WalletTransactionID is a unique identifier for a wallet transaction.
 	WalletTransactionID crypto.Hash
 
+	// FeeBumpStrategy is a strategy for raising the miner fee of an
+	// unconfirmed transaction.
+	FeeBumpStrategy string
+
+	// CoinSelectionStrategy is a strategy for picking the outputs which fund
+	// a transaction.
+	CoinSelectionStrategy string
+
+	// CoinSelection controls which outputs the wallet spends to fund a
+	// transaction. If Outputs is set, exactly those outputs are spent.
+	// Otherwise the outputs are picked using the strategy, which defaults to
+	// largest-first. If Replaceable is set, the transaction is marked as
+	// replaceable so that its fee can be bumped by replacing it. Nodes which
+	// don't know the marker won't relay such a transaction.
+	CoinSelection struct {
+		Strategy    CoinSelectionStrategy `json:"strategy"`
+		Outputs     []types.OutputID      `json:"outputs"`
+		Replaceable bool                  `json:"replaceable"`
+	}
+
+	// A PartiallySignedTransaction is a transaction which is passed between
+	// the co-signers of its inputs until enough signatures are collected.
+	// Besides the transaction and the signatures collected so far, it carries
+	// the unlock conditions and values of the inputs, so that co-signers can
+	// review and sign it without tracking the spent outputs themselves.
+	PartiallySignedTransaction struct {
+		Transaction types.Transaction      `json:"transaction"`
+		Inputs      []PartiallySignedInput `json:"inputs"`
+	}
+
+	// A PartiallySignedInput is an input of a partially signed transaction.
+	// The fund type is either 'SiacoinInput' or 'SiafundInput'.
+	PartiallySignedInput struct {
+		ParentID         types.OutputID         `json:"parentid"`
+		FundType         types.Specifier        `json:"fundtype"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		Value            types.Currency         `json:"value"`
+	}
+
+	// PartiallySignedInputSummary reports the signatures which were collected
+	// for an input. SignedBy contains the indices of the public keys with a
+	// valid signature.
+	PartiallySignedInputSummary struct {
+		PartiallySignedInput
+		SignaturesRequired uint64   `json:"signaturesrequired"`
+		SignedBy           []uint64 `json:"signedby"`
+	}
+
+	// PartiallySignedTransactionSummary describes a partially signed
+	// transaction. It is complete once every input has enough valid
+	// signatures.
+	PartiallySignedTransactionSummary struct {
+		TransactionID  types.TransactionID           `json:"transactionid"`
+		Inputs         []PartiallySignedInputSummary `json:"inputs"`
+		SiacoinInputs  types.Currency                `json:"siacoininputs"`
+		SiacoinOutputs types.Currency                `json:"siacoinoutputs"`
+		SiafundInputs  types.Currency                `json:"siafundinputs"`
+		SiafundOutputs types.Currency                `json:"siafundoutputs"`
+		MinerFees      types.Currency                `json:"minerfees"`
+		Complete       bool                          `json:"complete"`
+	}
+
+	// PaymentEventType is the type of a payment event.
+	PaymentEventType string
+
+	// A PaymentSubscription notifies about siacoins which are sent to a set of
+	// addresses. If WatchedAddresses is set, the subscription also covers all
+	// addresses watched by the wallet. Confirmed events are sent after the
+	// payment has the provided number of confirmations. If WebhookURL is set,
+	// events are POSTed to it and signed with the secret.
+	PaymentSubscription struct {
+		ID               string             `json:"id"`
+		Addresses        []types.UnlockHash `json:"addresses"`
+		WatchedAddresses bool               `json:"watchedaddresses"`
+		Confirmations    uint64             `json:"confirmations"`
+		WebhookURL       string             `json:"webhookurl"`
+		Secret           string             `json:"secret"`
+	}
+
+	// A PaymentEvent reports a change of a payment to an address of a
+	// subscription. The value is the sum of the siacoin outputs of the
+	// transaction which are sent to the address. Height is the height of the
+	// block containing the transaction, and zero for unconfirmed payments.
+	PaymentEvent struct {
+		SubscriptionID string              `json:"subscriptionid"`
+		Type           PaymentEventType    `json:"type"`
+		TransactionID  types.TransactionID `json:"transactionid"`
+		Address        types.UnlockHash    `json:"address"`
+		Value          types.Currency      `json:"value"`
+		Height         types.BlockHeight   `json:"height"`
+		Confirmations  uint64              `json:"confirmations"`
+	}
+
 	// A ProcessedInput represents funding to a transaction. The input is
 	// coming from an address and going to the outputs. The fund types are
 	// 'SiacoinInput', 'SiafundInput'.
@@ -126,6 +270,7 @@ type (
 		Value              types.Currency    `json:"value"`
 		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
 		IsWatchOnly        bool              `json:"iswatchonly"`
+		IsFrozen           bool              `json:"isfrozen"`
 	}
 
 	// TransactionBuilder is used to construct custom transactions. A transaction
@@ -148,6 +293,11 @@ type (
 		// transaction failed.
 		FundSiacoins(amount types.Currency) error
 
+		// FundSiacoinsWithSelection is like FundSiacoins, but the outputs
+		// which fund the transaction are picked according to the coin
+		// selection.
+		FundSiacoinsWithSelection(amount types.Currency, selection CoinSelection) error
+
 		// FundSiafunds will add a siafund input of exactly 'amount' to the
 		// transaction. A parent transaction may be needed to achieve an input
 		// with the correct value. The siafund input will not be signed until
@@ -433,9 +583,63 @@ type (
 		// the blockchain to search for transactions containing the addresses.
 		AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
+		// BumpTransaction raises the miner fee of an unconfirmed transaction
+		// in the transaction pool using the provided strategy. The fee is the
+		// miner fee of the replacement or the child transaction. If it is
+		// zero, the wallet picks a fee based on the current fee estimation.
+		// The new transaction set is submitted to the transaction pool and
+		// returned.
+		BumpTransaction(txid types.TransactionID, strategy FeeBumpStrategy, fee types.Currency) ([]types.Transaction, error)
+
 		// Close permits clean shutdown during testing and serving.
 		Close() error
 
+		// CreatePartiallySignedTransaction wraps a transaction spending
+		// outputs tracked by the wallet into a partially signed transaction.
+		// The unlock conditions of the inputs are filled in by the wallet.
+		CreatePartiallySignedTransaction(txn types.Transaction) (PartiallySignedTransaction, error)
+
+		// FinalizePartiallySignedTransaction returns the signed transaction
+		// of a complete partially signed transaction. Signatures beyond the
+		// ones required by each input are dropped.
+		FinalizePartiallySignedTransaction(pst PartiallySignedTransaction) (types.Transaction, error)
+
+		// InspectPartiallySignedTransaction verifies the signatures of a
+		// partially signed transaction and summarizes it.
+		InspectPartiallySignedTransaction(pst PartiallySignedTransaction) (PartiallySignedTransactionSummary, error)
+
+		// SignPartiallySignedTransaction adds the signatures of the wallet to
+		// a partially signed transaction.
+		SignPartiallySignedTransaction(pst PartiallySignedTransaction) (PartiallySignedTransaction, error)
+
+		// FreezeOutputs prevents the wallet from spending the provided
+		// outputs until they are unfrozen. This allows other tools to reserve
+		// outputs of the wallet.
+		FreezeOutputs(ids []types.OutputID) error
+
+		// FrozenOutputs returns the ids of the frozen outputs.
+		FrozenOutputs() ([]types.OutputID, error)
+
+		// UnfreezeOutputs allows the wallet to spend the provided outputs
+		// again.
+		UnfreezeOutputs(ids []types.OutputID) error
+
+		// AddPaymentSubscription registers a subscription for payments to a
+		// set of addresses. The wallet assigns the id of the subscription and
+		// generates a secret if none is provided.
+		AddPaymentSubscription(sub PaymentSubscription) (PaymentSubscription, error)
+
+		// PaymentSubscriptions returns the registered payment subscriptions.
+		PaymentSubscriptions() ([]PaymentSubscription, error)
+
+		// RemovePaymentSubscription removes a payment subscription.
+		RemovePaymentSubscription(id string) error
+
+		// SubscribePaymentEvents returns a channel which receives the events
+		// of all payment subscriptions and a function which closes it. The
+		// channel is closed if the receiver falls too far behind.
+		SubscribePaymentEvents() (<-chan PaymentEvent, func())
+
 		// ConfirmedBalance returns the confirmed balance of the wallet, minus
 		// any outgoing transactions. ConfirmedBalance will include unconfirmed
 		// refund transactions.
@@ -509,6 +713,11 @@ type (
 
 		SiacoinSenderMulti
 
+		// SendSiacoinsWithSelection sends coins to multiple addresses like
+		// SendSiacoinsMulti, but funds the transaction according to the coin
+		// selection.
+		SendSiacoinsWithSelection(outputs []types.SiacoinOutput, selection CoinSelection) ([]types.Transaction, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -543,6 +752,43 @@ func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) W
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// CombinePartiallySignedTransactions merges the signatures of multiple copies
+// of the same partially signed transaction. A signature for an input and
+// public key which appears in multiple copies is only kept once, and empty
+// signatures are dropped.
+func CombinePartiallySignedTransactions(psts ...PartiallySignedTransaction) (PartiallySignedTransaction, error) {
+	if len(psts) == 0 {
+		return PartiallySignedTransaction{}, errors.New("no partially signed transactions to combine")
+	}
+	combined := PartiallySignedTransaction{
+		Transaction: psts[0].Transaction,
+		Inputs:      append([]PartiallySignedInput(nil), psts[0].Inputs...),
+	}
+	combined.Transaction.TransactionSignatures = nil
+	txid := combined.Transaction.ID()
+	inputsHash := crypto.HashObject(combined.Inputs)
+
+	type signer struct {
+		parentID       crypto.Hash
+		publicKeyIndex uint64
+	}
+	seen := make(map[signer]struct{})
+	for _, pst := range psts {
+		if pst.Transaction.ID() != txid || crypto.HashObject(pst.Inputs) != inputsHash {
+			return PartiallySignedTransaction{}, ErrPartiallySignedMismatch
+		}
+		for _, sig := range pst.Transaction.TransactionSignatures {
+			s := signer{sig.ParentID, sig.PublicKeyIndex}
+			if _, exists := seen[s]; exists || len(sig.Signature) == 0 {
+				continue
+			}
+			seen[s] = struct{}{}
+			combined.Transaction.TransactionSignatures = append(combined.Transaction.TransactionSignatures, sig)
+		}
+	}
+	return combined, nil
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
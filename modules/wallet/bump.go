@@ -0,0 +1,342 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errBumpForeignInputs is returned if a transaction can't be replaced
+	// because the wallet can't sign all of its inputs.
+	errBumpForeignInputs = errors.New("the wallet can't sign all inputs of the transaction")
+
+	// errBumpLowFee is returned if the fee of a replacement isn't higher than
+	// the fee of the original transaction.
+	errBumpLowFee = errors.New("the new fee must be higher than the fee of the transaction")
+
+	// errBumpNoChange is returned if a transaction can't be replaced because
+	// none of its change outputs can pay for the higher fee.
+	errBumpNoChange = errors.New("the transaction doesn't have a change output large enough to pay the higher fee, try cpfp instead")
+
+	// errBumpNoOutput is returned if a transaction doesn't have a change
+	// output that a child transaction can spend.
+	errBumpNoOutput = errors.New("the transaction doesn't have a change output large enough to pay the fee of a child transaction")
+
+	// errBumpNotReplaceable is returned if a transaction can't be replaced
+	// because neither it nor its parents are marked as replaceable.
+	errBumpNotReplaceable = errors.New("the transaction isn't marked as replaceable, try cpfp instead")
+
+	// errBumpNotInPool is returned if the transaction to bump is not in the
+	// transaction pool.
+	errBumpNotInPool = errors.New("transaction is not in the transaction pool")
+
+	// errBumpUnreplaceable is returned if a transaction can't be replaced
+	// because other parties rely on its id.
+	errBumpUnreplaceable = errors.New("transactions with file contracts, revisions or storage proofs can't be replaced")
+
+	// errUnknownFeeBumpStrategy is returned for an unknown fee bump strategy.
+	errUnknownFeeBumpStrategy = errors.New("unknown fee bump strategy")
+)
+
+// BumpTransaction raises the miner fee of an unconfirmed transaction in the
+// transaction pool. The new transaction set is submitted to the transaction
+// pool and returned.
+func (w *Wallet) BumpTransaction(txid types.TransactionID, strategy modules.FeeBumpStrategy, fee types.Currency) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	txn, parents, exists := w.tpool.Transaction(txid)
+	if !exists {
+		return nil, errBumpNotInPool
+	}
+	minFeePerByte, feePerByte := w.tpool.FeeEstimation()
+
+	var txnSet []types.Transaction
+	var err error
+	switch strategy {
+	case modules.FeeBumpRBF:
+		txnSet, err = w.managedReplaceByFee(txn, parents, fee, minFeePerByte, feePerByte)
+	case modules.FeeBumpCPFP:
+		txnSet, err = w.managedChildPaysForParent(txn, parents, fee, feePerByte)
+	default:
+		return nil, errors.AddContext(errUnknownFeeBumpStrategy, string(strategy))
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to bump transaction fee")
+	}
+	if w.deps.Disrupt("BumpTransactionInterrupted") {
+		err = errors.New("failed to accept transaction set (BumpTransactionInterrupted)")
+	} else {
+		err = w.tpool.AcceptTransactionSet(txnSet)
+	}
+	if err != nil && strategy == modules.FeeBumpCPFP {
+		// The child won't spend the output, release it again.
+		child := txnSet[len(txnSet)-1]
+		err = errors.Compose(err, w.managedUnmarkSpent(types.OutputID(child.SiacoinInputs[0].ParentID)))
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "transaction pool rejected the bumped transaction set")
+	}
+	w.log.Printf("Bumped the fee of transaction %v using %v, new transaction: %v\n", txid, strategy, txnSet[len(txnSet)-1].ID())
+	return txnSet, nil
+}
+
+// findBumpOutput returns the position of a change output in txnSet which
+// isn't spent within the set and is worth more than value. A change output is
+// an output of the wallet in a transaction that only spends outputs of the
+// wallet. Frozen outputs are skipped. The change of the bumped transaction,
+// which is the last one of the set, is preferred over the change of its
+// parents. If replaceable is true, only transactions marked as replaceable are
+// considered.
+func (w *Wallet) findBumpOutput(txnSet []types.Transaction, value types.Currency, replaceable bool) (txnIndex, outputIndex int, found bool) {
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	for i := len(txnSet) - 1; i >= 0; i-- {
+		if replaceable && !modules.IsReplaceable(txnSet[i]) {
+			continue
+		}
+		if !w.ownsInputs(txnSet[i]) {
+			continue
+		}
+		for j, sco := range txnSet[i].SiacoinOutputs {
+			id := txnSet[i].SiacoinOutputID(uint64(j))
+			if _, ok := spent[id]; ok {
+				continue
+			}
+			if dbGetFrozenOutput(w.dbTx, types.OutputID(id)) {
+				continue
+			}
+			if _, ok := w.keys[sco.UnlockHash]; ok && sco.Value.Cmp(value) > 0 {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// ownsInputs returns whether the wallet can sign all inputs of a transaction.
+func (w *Wallet) ownsInputs(txn types.Transaction) bool {
+	for _, sci := range txn.SiacoinInputs {
+		if _, ok := w.keys[sci.UnlockConditions.UnlockHash()]; !ok {
+			return false
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if _, ok := w.keys[sfi.UnlockConditions.UnlockHash()]; !ok {
+			return false
+		}
+	}
+	return len(txn.SiacoinInputs)+len(txn.SiafundInputs) > 0
+}
+
+// managedReplaceByFee rebuilds the transaction set of txn with a higher miner
+// fee. The additional fee is taken from a change output of txn or one of its
+// parents, which has to be marked as replaceable. That transaction and all
+// transactions of the set which depend on it are signed again, which makes them
+// double-spend the original set in the transaction pool.
+func (w *Wallet) managedReplaceByFee(txn types.Transaction, parents []types.Transaction, fee, minFeePerByte, feePerByte types.Currency) ([]types.Transaction, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	txnSet := append(append([]types.Transaction(nil), parents...), txn)
+	replaceable := false
+	for _, t := range txnSet {
+		replaceable = replaceable || modules.IsReplaceable(t)
+	}
+	if !replaceable {
+		return nil, errBumpNotReplaceable
+	}
+
+	// Pick the fee if none was provided. The transaction pool only accepts a
+	// replacement which pays at least the minimum fee on top of the current
+	// fee of the set.
+	var oldFee types.Currency
+	for _, t := range txnSet {
+		for _, minerFee := range t.MinerFees {
+			oldFee = oldFee.Add(minerFee)
+		}
+	}
+	if fee.IsZero() {
+		setSize := uint64(len(encoding.Marshal(txnSet)))
+		fee = feePerByte.Mul64(setSize)
+		if minReplacementFee := oldFee.Add(minFeePerByte.Mul64(setSize)); fee.Cmp(minReplacementFee) < 0 {
+			fee = minReplacementFee
+		}
+		// The replacement grows by the encoded increase, which is never larger
+		// than the encoded fee.
+		fee = fee.Add(minFeePerByte.Mul64(uint64(len(encoding.Marshal(fee)))))
+	}
+	if fee.Cmp(oldFee) <= 0 {
+		return nil, errBumpLowFee
+	}
+	increase := fee.Sub(oldFee)
+
+	// Find the change output which pays for the increase.
+	source, change, found := w.findBumpOutput(txnSet, increase, true)
+	if !found {
+		return nil, errBumpNoChange
+	}
+
+	// Rebuild the set starting at the source of the fee. Transactions which
+	// spend outputs of rebuilt transactions need to be rebuilt as well.
+	replaced := make(map[types.OutputID]types.OutputID)
+	replacementSet := append([]types.Transaction(nil), txnSet[:source]...)
+	for i, t := range txnSet[source:] {
+		rebuild := i == 0
+		for _, sci := range t.SiacoinInputs {
+			_, ok := replaced[types.OutputID(sci.ParentID)]
+			rebuild = rebuild || ok
+		}
+		for _, sfi := range t.SiafundInputs {
+			_, ok := replaced[types.OutputID(sfi.ParentID)]
+			rebuild = rebuild || ok
+		}
+		if !rebuild {
+			replacementSet = append(replacementSet, t)
+			continue
+		}
+		if len(t.FileContracts) > 0 || len(t.FileContractRevisions) > 0 || len(t.StorageProofs) > 0 {
+			return nil, errBumpUnreplaceable
+		}
+
+		// Copy the transaction and point it to the rebuilt parents.
+		replacement := t
+		replacement.SiacoinInputs = append([]types.SiacoinInput(nil), t.SiacoinInputs...)
+		replacement.SiafundInputs = append([]types.SiafundInput(nil), t.SiafundInputs...)
+		replacement.SiacoinOutputs = append([]types.SiacoinOutput(nil), t.SiacoinOutputs...)
+		replacement.MinerFees = append([]types.Currency(nil), t.MinerFees...)
+		replacement.TransactionSignatures = append([]types.TransactionSignature(nil), t.TransactionSignatures...)
+		if i == 0 {
+			replacement.SiacoinOutputs[change].Value = replacement.SiacoinOutputs[change].Value.Sub(increase)
+			replacement.MinerFees = append(replacement.MinerFees, increase)
+		}
+		var toSign []crypto.Hash
+		for j, sci := range replacement.SiacoinInputs {
+			if _, ok := w.keys[sci.UnlockConditions.UnlockHash()]; !ok {
+				return nil, errBumpForeignInputs
+			}
+			if id, ok := replaced[types.OutputID(sci.ParentID)]; ok {
+				replacement.SiacoinInputs[j].ParentID = types.SiacoinOutputID(id)
+			}
+			toSign = append(toSign, crypto.Hash(replacement.SiacoinInputs[j].ParentID))
+		}
+		for j, sfi := range replacement.SiafundInputs {
+			if _, ok := w.keys[sfi.UnlockConditions.UnlockHash()]; !ok {
+				return nil, errBumpForeignInputs
+			}
+			if id, ok := replaced[types.OutputID(sfi.ParentID)]; ok {
+				replacement.SiafundInputs[j].ParentID = types.SiafundOutputID(id)
+			}
+			toSign = append(toSign, crypto.Hash(replacement.SiafundInputs[j].ParentID))
+		}
+		for j, sig := range replacement.TransactionSignatures {
+			if id, ok := replaced[types.OutputID(sig.ParentID)]; ok {
+				replacement.TransactionSignatures[j].ParentID = crypto.Hash(id)
+			}
+			replacement.TransactionSignatures[j].Signature = nil
+		}
+		err = signTransaction(&replacement, w.keys, toSign, consensusHeight)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to sign the replacement")
+		}
+
+		// Remember the new ids of the outputs.
+		for j := range t.SiacoinOutputs {
+			replaced[types.OutputID(t.SiacoinOutputID(uint64(j)))] = types.OutputID(replacement.SiacoinOutputID(uint64(j)))
+		}
+		for j := range t.SiafundOutputs {
+			replaced[types.OutputID(t.SiafundOutputID(uint64(j)))] = types.OutputID(replacement.SiafundOutputID(uint64(j)))
+		}
+		replacementSet = append(replacementSet, replacement)
+	}
+	return replacementSet, nil
+}
+
+// managedChildPaysForParent creates a child transaction which spends a change
+// output of txn or one of its parents and pays the fee. Miners only get the fee
+// of the child if they also include its parents.
+func (w *Wallet) managedChildPaysForParent(txn types.Transaction, parents []types.Transaction, fee, feePerByte types.Currency) ([]types.Transaction, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	txnSet := append(append([]types.Transaction(nil), parents...), txn)
+
+	// Pick the fee if none was provided. The child should raise the fee of
+	// the whole set to the estimated fee.
+	if fee.IsZero() {
+		var setFee types.Currency
+		for _, t := range txnSet {
+			for _, minerFee := range t.MinerFees {
+				setFee = setFee.Add(minerFee)
+			}
+		}
+		fee = feePerByte.Mul64(estimatedTransactionSize)
+		if setTarget := feePerByte.Mul64(uint64(len(encoding.Marshal(txnSet))) + estimatedTransactionSize); setTarget.Cmp(setFee.Add(fee)) > 0 {
+			fee = setTarget.Sub(setFee)
+		}
+	}
+
+	// Find a change output which can pay for the fee.
+	txnIndex, outputIndex, found := w.findBumpOutput(txnSet, fee, false)
+	if !found {
+		return nil, errBumpNoOutput
+	}
+	output := txnSet[txnIndex].SiacoinOutputs[outputIndex]
+	outputID := txnSet[txnIndex].SiacoinOutputID(uint64(outputIndex))
+	spendKey := w.keys[output.UnlockHash]
+
+	// Build and sign the child.
+	refund, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get a refund address")
+	}
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         outputID,
+			UnlockConditions: spendKey.UnlockConditions,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      output.Value.Sub(fee),
+			UnlockHash: refund.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	addSignatures(&child, types.FullCoveredFields, spendKey.UnlockConditions, crypto.Hash(outputID), spendKey, consensusHeight)
+
+	// Mark the output as spent so that the wallet doesn't spend it again. The
+	// mark is removed by BumpTransaction if the transaction pool rejects the
+	// child.
+	err = dbPutSpentOutput(w.dbTx, types.OutputID(outputID), consensusHeight)
+	if err != nil {
+		return nil, err
+	}
+	return append(txnSet, child), nil
+}
+
+// managedUnmarkSpent removes the spent mark of an output of the wallet.
+func (w *Wallet) managedUnmarkSpent(id types.OutputID) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return dbDeleteSpentOutput(w.dbTx, id)
+}
@@ -0,0 +1,311 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBumpTransaction probes bumping the fee of an unconfirmed transaction
+// using replace-by-fee and child-pays-for-parent.
+func TestBumpTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// setFee is a helper to sum up the fees of a transaction set.
+	setFee := func(txnSet []types.Transaction) (fee types.Currency) {
+		for _, txn := range txnSet {
+			for _, minerFee := range txn.MinerFees {
+				fee = fee.Add(minerFee)
+			}
+		}
+		return
+	}
+	// unconfirmed is a helper to check if the wallet tracks a transaction as
+	// unconfirmed.
+	unconfirmed := func(txid types.TransactionID) bool {
+		utxns, err := wt.wallet.UnconfirmedTransactions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, utxn := range utxns {
+			if utxn.TransactionID == txid {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Unknown transactions and strategies are rejected.
+	outputs := []types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(3)}}
+	txnSet, err := wt.wallet.SendSiacoinsWithSelection(outputs, modules.CoinSelection{Replaceable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txnSet[len(txnSet)-1].ID()
+	_, err = wt.wallet.BumpTransaction(types.TransactionID{}, modules.FeeBumpRBF, types.ZeroCurrency)
+	if !errors.Contains(err, errBumpNotInPool) {
+		t.Fatal("expected errBumpNotInPool", err)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, "foo", types.ZeroCurrency)
+	if !errors.Contains(err, errUnknownFeeBumpStrategy) {
+		t.Fatal("expected errUnknownFeeBumpStrategy", err)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpRBF, setFee(txnSet))
+	if !errors.Contains(err, errBumpLowFee) {
+		t.Fatal("expected errBumpLowFee", err)
+	}
+	if !modules.IsReplaceable(txnSet[len(txnSet)-1]) {
+		t.Fatal("wallet should mark the transaction as replaceable")
+	}
+
+	// Replace the transaction. The original transaction is evicted from the
+	// transaction pool and the wallet.
+	rbfSet, err := wt.wallet.BumpTransaction(txid, modules.FeeBumpRBF, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbfID := rbfSet[len(rbfSet)-1].ID()
+	if rbfID == txid {
+		t.Fatal("replacement should have a new id")
+	}
+	if setFee(rbfSet).Cmp(setFee(txnSet)) <= 0 {
+		t.Fatal("replacement doesn't pay a higher fee", setFee(rbfSet), setFee(txnSet))
+	}
+	if _, _, exists := wt.tpool.Transaction(txid); exists {
+		t.Fatal("original transaction is still in the transaction pool")
+	}
+	if _, _, exists := wt.tpool.Transaction(rbfID); !exists {
+		t.Fatal("replacement isn't in the transaction pool")
+	}
+	if unconfirmed(txid) || !unconfirmed(rbfID) {
+		t.Fatal("wallet doesn't track the replacement")
+	}
+
+	// Attach a child to the replacement.
+	cpfpSet, err := wt.wallet.BumpTransaction(rbfID, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childID := cpfpSet[len(cpfpSet)-1].ID()
+	if len(cpfpSet) != len(rbfSet)+1 || cpfpSet[len(cpfpSet)-2].ID() != rbfID {
+		t.Fatal("child should be appended to the set")
+	}
+	if _, _, exists := wt.tpool.Transaction(childID); !exists {
+		t.Fatal("child isn't in the transaction pool")
+	}
+	if !unconfirmed(rbfID) || !unconfirmed(childID) {
+		t.Fatal("wallet doesn't track the child")
+	}
+
+	// Mine the set.
+	b, _ := wt.miner.FindBlock()
+	err = wt.cs.AcceptBlock(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range cpfpSet {
+		if _, _, exists := wt.tpool.Transaction(txn.ID()); exists {
+			t.Fatal("transaction wasn't mined")
+		}
+	}
+	for _, id := range []types.TransactionID{rbfID, childID} {
+		if _, exists, err := wt.wallet.Transaction(id); err != nil || !exists {
+			t.Fatal("wallet didn't confirm the transaction", err)
+		}
+	}
+}
+
+// TestBumpTransactionNotReplaceable checks that transactions which aren't
+// marked as replaceable can only be bumped using child-pays-for-parent.
+func TestBumpTransactionNotReplaceable(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Build a transaction with a change output without marking it.
+	txnBuilder, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = txnBuilder.FundSiacoins(types.SiacoinPrecision.Mul64(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddMinerFee(types.SiacoinPrecision)
+	txnBuilder.AddSiacoinOutput(types.SiacoinOutput{Value: types.SiacoinPrecision})
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txnSet[len(txnSet)-1].ID()
+
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpRBF, types.ZeroCurrency)
+	if !errors.Contains(err, errBumpNotReplaceable) {
+		t.Fatal("expected errBumpNotReplaceable", err)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plain sends aren't marked as replaceable either.
+	txnSet, err = wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(3), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range txnSet {
+		if modules.IsReplaceable(txn) {
+			t.Fatal("plain send shouldn't be marked as replaceable")
+		}
+	}
+	_, err = wt.wallet.BumpTransaction(txnSet[len(txnSet)-1].ID(), modules.FeeBumpRBF, types.ZeroCurrency)
+	if !errors.Contains(err, errBumpNotReplaceable) {
+		t.Fatal("expected errBumpNotReplaceable", err)
+	}
+}
+
+// TestBumpTransactionCPFPRejected checks that the output spent by a child is
+// released if the transaction pool rejects the child.
+func TestBumpTransactionCPFPRejected(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	deps := &dependencyBumpTransactionInterrupted{}
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// spentOutputs is a helper to count the outputs marked as spent.
+	spentOutputs := func() int {
+		wt.wallet.mu.Lock()
+		defer wt.wallet.mu.Unlock()
+		if err := wt.wallet.syncDB(); err != nil {
+			t.Fatal(err)
+		}
+		return wt.wallet.dbTx.Bucket(bucketSpentOutputs).Stats().KeyN
+	}
+
+	txnSet, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(3), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txnSet[len(txnSet)-1].ID()
+	spent := spentOutputs()
+
+	deps.fail()
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err == nil {
+		t.Fatal("bump should have failed")
+	}
+	if n := spentOutputs(); n != spent {
+		t.Fatalf("expected %v spent outputs, got %v", spent, n)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := spentOutputs(); n != spent+1 {
+		t.Fatalf("expected %v spent outputs, got %v", spent+1, n)
+	}
+}
+
+// TestBumpTransactionFrozenOutput checks that frozen change outputs aren't
+// used to bump the fee of a transaction.
+func TestBumpTransactionFrozenOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	outputs := []types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(3)}}
+	txnSet, err := wt.wallet.SendSiacoinsWithSelection(outputs, modules.CoinSelection{Replaceable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txnSet[len(txnSet)-1].ID()
+
+	// Freeze the change of the set.
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	var change []types.OutputID
+	for _, txn := range txnSet {
+		for i, sco := range txn.SiacoinOutputs {
+			id := txn.SiacoinOutputID(uint64(i))
+			if _, ok := spent[id]; ok || sco.UnlockHash == (types.UnlockHash{}) {
+				continue
+			}
+			change = append(change, types.OutputID(id))
+		}
+	}
+	if len(change) == 0 {
+		t.Fatal("set doesn't have a change output")
+	}
+	err = wt.wallet.FreezeOutputs(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither strategy can use the frozen change.
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpRBF, types.ZeroCurrency)
+	if !errors.Contains(err, errBumpNoChange) {
+		t.Fatal("expected errBumpNoChange", err)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if !errors.Contains(err, errBumpNoOutput) {
+		t.Fatal("expected errBumpNoOutput", err)
+	}
+
+	// After unfreezing the change the transaction can be bumped.
+	err = wt.wallet.UnfreezeOutputs(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.wallet.BumpTransaction(txid, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// branchAndBoundMaxTries is the maximum number of steps the branch and
+	// bound search takes before falling back to largest-first selection.
+	branchAndBoundMaxTries = 100e3
+)
+
+var (
+	// errDuplicateOutput is returned if the same output is selected twice.
+	errDuplicateOutput = errors.New("output was selected more than once")
+
+	// errOutputFrozen indicates an output is not spendable because it was
+	// frozen.
+	errOutputFrozen = errors.New("output is frozen")
+
+	// errUnknownCoinSelection is returned for an unknown coin selection
+	// strategy.
+	errUnknownCoinSelection = errors.New("unknown coin selection strategy")
+
+	// errUnknownOutput is returned if a selected output is not a siacoin
+	// output of the wallet.
+	errUnknownOutput = errors.New("output is not a siacoin output of the wallet")
+)
+
+// takeOutputs returns the shortest prefix of so which is worth at least
+// amount, and its value.
+func takeOutputs(so sortedOutputs, amount types.Currency) (sortedOutputs, types.Currency) {
+	var fund types.Currency
+	for i := range so.ids {
+		fund = fund.Add(so.outputs[i].Value)
+		if fund.Cmp(amount) >= 0 {
+			return sortedOutputs{ids: so.ids[:i+1], outputs: so.outputs[:i+1]}, fund
+		}
+	}
+	return so, fund
+}
+
+// branchAndBound searches for a subset of so, which must be sorted by
+// descending value, that is worth at least amount but no more than amount
+// plus tolerance. Spending such a subset doesn't require a refund output.
+func branchAndBound(so sortedOutputs, amount, tolerance types.Currency) (sortedOutputs, types.Currency, bool) {
+	// remaining[i] is the value of the outputs from i onwards.
+	remaining := make([]types.Currency, len(so.ids)+1)
+	for i := len(so.ids) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1].Add(so.outputs[i].Value)
+	}
+	target := amount.Add(tolerance)
+
+	// Search depth-first, trying to include each output before excluding
+	// it. Branches which overshoot the target or can't reach the amount
+	// anymore are cut.
+	var selected []int
+	var tries int
+	var search func(i int, fund types.Currency) bool
+	search = func(i int, fund types.Currency) bool {
+		tries++
+		if fund.Cmp(amount) >= 0 {
+			return fund.Cmp(target) <= 0
+		}
+		if i == len(so.ids) || tries > branchAndBoundMaxTries || fund.Add(remaining[i]).Cmp(amount) < 0 {
+			return false
+		}
+		selected = append(selected, i)
+		if search(i+1, fund.Add(so.outputs[i].Value)) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+		return search(i+1, fund)
+	}
+	if !search(0, types.ZeroCurrency) {
+		return sortedOutputs{}, types.ZeroCurrency, false
+	}
+
+	var subset sortedOutputs
+	var fund types.Currency
+	for _, i := range selected {
+		subset.ids = append(subset.ids, so.ids[i])
+		subset.outputs = append(subset.outputs, so.outputs[i])
+		fund = fund.Add(so.outputs[i].Value)
+	}
+	return subset, fund, true
+}
+
+// selectSiacoinOutputs picks the outputs of so which are spent to fund
+// amount. If the selection lists outputs, all of them are spent. Otherwise the
+// outputs are picked according to the selection strategy. The selected
+// outputs and their total value are returned.
+func (w *Wallet) selectSiacoinOutputs(tx *bolt.Tx, so sortedOutputs, amount types.Currency, selection modules.CoinSelection, consensusHeight types.BlockHeight, dustThreshold types.Currency) (sortedOutputs, types.Currency, error) {
+	// Spend the outputs chosen by the caller.
+	if len(selection.Outputs) > 0 {
+		candidates := make(map[types.OutputID]int, len(so.ids))
+		for i, scoid := range so.ids {
+			candidates[types.OutputID(scoid)] = i
+		}
+		var selected sortedOutputs
+		var fund types.Currency
+		for _, id := range selection.Outputs {
+			i, exists := candidates[id]
+			if !exists {
+				return sortedOutputs{}, types.ZeroCurrency, errors.AddContext(errUnknownOutput, id.String())
+			}
+			if i == -1 {
+				return sortedOutputs{}, types.ZeroCurrency, errors.AddContext(errDuplicateOutput, id.String())
+			}
+			candidates[id] = -1
+			if err := w.checkOutput(tx, consensusHeight, so.ids[i], so.outputs[i], dustThreshold); err != nil {
+				return sortedOutputs{}, types.ZeroCurrency, errors.AddContext(err, fmt.Sprintf("unable to spend output %v", id))
+			}
+			selected.ids = append(selected.ids, so.ids[i])
+			selected.outputs = append(selected.outputs, so.outputs[i])
+			fund = fund.Add(so.outputs[i].Value)
+		}
+		if fund.Cmp(amount) < 0 {
+			return sortedOutputs{}, types.ZeroCurrency, modules.ErrLowBalance
+		}
+		return selected, fund, nil
+	}
+
+	// Collect the outputs which can be spent. potentialFund tracks the
+	// balance of the wallet including outputs that have been spent in other
+	// unconfirmed transactions recently. This is to provide the user with a
+	// more useful error message in the event that they are overspending.
+	var spendable sortedOutputs
+	var fund, potentialFund types.Currency
+	for i := range so.ids {
+		if err := w.checkOutput(tx, consensusHeight, so.ids[i], so.outputs[i], dustThreshold); err != nil {
+			if errors.Contains(err, errSpendHeightTooHigh) {
+				potentialFund = potentialFund.Add(so.outputs[i].Value)
+			}
+			continue
+		}
+		spendable.ids = append(spendable.ids, so.ids[i])
+		spendable.outputs = append(spendable.outputs, so.outputs[i])
+		fund = fund.Add(so.outputs[i].Value)
+		potentialFund = potentialFund.Add(so.outputs[i].Value)
+	}
+	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
+		return sortedOutputs{}, types.ZeroCurrency, modules.ErrIncompleteTransactions
+	}
+	if fund.Cmp(amount) < 0 {
+		return sortedOutputs{}, types.ZeroCurrency, modules.ErrLowBalance
+	}
+
+	switch selection.Strategy {
+	case "", modules.CoinSelectionLargestFirst:
+		sort.Sort(sort.Reverse(spendable))
+	case modules.CoinSelectionSmallestFirst:
+		// Spending the smallest outputs first consolidates the wallet.
+		sort.Sort(spendable)
+	case modules.CoinSelectionPrivacy:
+		// A single output doesn't link any addresses of the wallet, so
+		// prefer the smallest output which covers the amount. Otherwise the
+		// outputs are spent in random order so that the inputs don't reveal
+		// the sizes of the other outputs of the wallet.
+		sort.Sort(spendable)
+		for i := range spendable.ids {
+			if spendable.outputs[i].Value.Cmp(amount) >= 0 {
+				return sortedOutputs{ids: spendable.ids[i : i+1], outputs: spendable.outputs[i : i+1]}, spendable.outputs[i].Value, nil
+			}
+		}
+		for i := len(spendable.ids) - 1; i > 0; i-- {
+			spendable.Swap(i, fastrand.Intn(i+1))
+		}
+	case modules.CoinSelectionBranchAndBound:
+		// Look for a combination of outputs which matches the amount closely
+		// enough to not require a refund output. Fall back to largest-first
+		// if there is none.
+		sort.Sort(sort.Reverse(spendable))
+		if selected, fund, ok := branchAndBound(spendable, amount, dustThreshold); ok {
+			return selected, fund, nil
+		}
+	default:
+		return sortedOutputs{}, types.ZeroCurrency, errors.AddContext(errUnknownCoinSelection, string(selection.Strategy))
+	}
+	selected, fund := takeOutputs(spendable, amount)
+	return selected, fund, nil
+}
+
+// FreezeOutputs prevents the wallet from spending the provided outputs until
+// they are unfrozen. The outputs don't need to be known to the wallet yet, so
+// that outputs of unconfirmed transactions can be reserved as well.
+func (w *Wallet) FreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range ids {
+		if err := dbPutFrozenOutput(w.dbTx, id); err != nil {
+			return errors.AddContext(err, "failed to freeze output")
+		}
+	}
+	return w.syncDB()
+}
+
+// FrozenOutputs returns the ids of the frozen outputs.
+func (w *Wallet) FrozenOutputs() ([]types.OutputID, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ids := []types.OutputID{}
+	err := dbForEachFrozenOutput(w.dbTx, func(id types.OutputID, _ bool) {
+		ids = append(ids, id)
+	})
+	return ids, err
+}
+
+// UnfreezeOutputs allows the wallet to spend the provided outputs again.
+func (w *Wallet) UnfreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range ids {
+		if err := dbDeleteFrozenOutput(w.dbTx, id); err != nil {
+			return errors.AddContext(err, "failed to unfreeze output")
+		}
+	}
+	return w.syncDB()
+}
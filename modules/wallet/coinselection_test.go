@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBranchAndBound is a unit test for branchAndBound.
+func TestBranchAndBound(t *testing.T) {
+	t.Parallel()
+
+	// outputs is a helper to create sortedOutputs from a list of values.
+	outputs := func(values ...uint64) (so sortedOutputs) {
+		for i, v := range values {
+			so.ids = append(so.ids, types.SiacoinOutputID{byte(i)})
+			so.outputs = append(so.outputs, types.SiacoinOutput{Value: types.NewCurrency64(v)})
+		}
+		return
+	}
+	so := outputs(5, 4, 3, 1)
+	tests := []struct {
+		amount    uint64
+		tolerance uint64
+		found     bool
+		fund      uint64
+	}{
+		{7, 0, true, 7},
+		{6, 0, true, 6},
+		{13, 0, true, 13},
+		{14, 0, false, 0},
+		{2, 0, false, 0},
+		{2, 1, true, 3},
+	}
+	for _, test := range tests {
+		selected, fund, found := branchAndBound(so, types.NewCurrency64(test.amount), types.NewCurrency64(test.tolerance))
+		if found != test.found || !fund.Equals64(test.fund) {
+			t.Fatalf("amount %v: expected %v %v but got %v %v", test.amount, test.found, test.fund, found, fund)
+		}
+		var sum types.Currency
+		for _, sco := range selected.outputs {
+			sum = sum.Add(sco.Value)
+		}
+		if !sum.Equals(fund) {
+			t.Fatal("fund doesn't match the selected outputs", sum, fund)
+		}
+	}
+}
+
+// TestCoinSelection checks that the wallet spends the outputs picked by the
+// coin selection and never spends frozen outputs.
+func TestCoinSelection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Split the balance of the wallet into outputs worth 1, 2, 3 and 4 SC.
+	var splits []types.SiacoinOutput
+	for i := uint64(1); i <= 4; i++ {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		splits = append(splits, types.SiacoinOutput{Value: types.SiacoinPrecision.Mul64(i), UnlockHash: uc.UnlockHash()})
+	}
+	_, err = wt.wallet.SendSiacoinsMulti(splits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	unspent, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[types.OutputID]types.Currency)
+	large := make(map[types.OutputID]struct{})
+	var largeIDs []types.OutputID
+	for _, uo := range unspent {
+		values[uo.ID] = uo.Value
+		if uo.Value.Cmp(types.SiacoinPrecision.Mul64(4)) > 0 {
+			large[uo.ID] = struct{}{}
+			largeIDs = append(largeIDs, uo.ID)
+		}
+	}
+	byValue := func(sc uint64) types.OutputID {
+		for id, v := range values {
+			if v.Equals(types.SiacoinPrecision.Mul64(sc)) {
+				return id
+			}
+		}
+		t.Fatal("no output worth", sc)
+		return types.OutputID{}
+	}
+
+	// send sends 1.5 SC to the void and returns the ids of the spent outputs
+	// of the wallet.
+	send := func(selection modules.CoinSelection) ([]types.OutputID, error) {
+		out := []types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(3).Div64(2)}}
+		txnSet, err := wt.wallet.SendSiacoinsWithSelection(out, selection)
+		if err != nil {
+			return nil, err
+		}
+		var spent []types.OutputID
+		for _, sci := range txnSet[0].SiacoinInputs {
+			spent = append(spent, types.OutputID(sci.ParentID))
+		}
+		return spent, nil
+	}
+	expectSpent := func(spent []types.OutputID, expected ...types.OutputID) {
+		t.Helper()
+		if len(spent) != len(expected) {
+			t.Fatalf("expected %v outputs to be spent but got %v", len(expected), len(spent))
+		}
+		for i := range spent {
+			if spent[i] != expected[i] {
+				t.Fatalf("expected output %v to be spent but got %v", expected[i], spent[i])
+			}
+		}
+	}
+
+	// Unknown strategies are rejected. The errors are extended by
+	// SendSiacoinsWithSelection, so their messages are compared.
+	_, err = send(modules.CoinSelection{Strategy: "foo"})
+	if err == nil || !strings.Contains(err.Error(), errUnknownCoinSelection.Error()) {
+		t.Fatal("expected errUnknownCoinSelection", err)
+	}
+
+	// Freeze the outputs which are larger than the splits. Largest-first
+	// spends the largest split instead.
+	err = wt.wallet.FreezeOutputs(largeIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen, err := wt.wallet.FrozenOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frozen) != len(large) {
+		t.Fatal("unexpected frozen outputs", frozen)
+	}
+	for _, id := range frozen {
+		if _, exists := large[id]; !exists {
+			t.Fatal("unexpected frozen output", id)
+		}
+	}
+	unspent, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range unspent {
+		if _, exists := large[uo.ID]; uo.IsFrozen != exists {
+			t.Fatal("wrong frozen flag for output", uo.ID)
+		}
+	}
+	spent, err := send(modules.CoinSelection{Strategy: modules.CoinSelectionLargestFirst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSpent(spent, byValue(4))
+
+	// A frozen output can't be selected explicitly.
+	_, err = send(modules.CoinSelection{Outputs: []types.OutputID{largeIDs[0]}})
+	if err == nil || !strings.Contains(err.Error(), errOutputFrozen.Error()) {
+		t.Fatal("expected errOutputFrozen", err)
+	}
+
+	// Smallest-first spends the 1 SC and 2 SC outputs.
+	spent, err = send(modules.CoinSelection{Strategy: modules.CoinSelectionSmallestFirst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSpent(spent, byValue(1), byValue(2))
+
+	// The privacy strategy spends the smallest output which covers the
+	// amount on its own.
+	unspent, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var smallest modules.UnspentOutput
+	for _, uo := range unspent {
+		if !uo.IsFrozen && uo.Value.Cmp(types.SiacoinPrecision.Mul64(2)) >= 0 && (smallest.Value.IsZero() || uo.Value.Cmp(smallest.Value) < 0) {
+			smallest = uo
+		}
+	}
+	spent, err = send(modules.CoinSelection{Strategy: modules.CoinSelectionPrivacy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSpent(spent, smallest.ID)
+
+	// Explicitly selected outputs are all spent once they are unfrozen.
+	err = wt.wallet.UnfreezeOutputs(largeIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = send(modules.CoinSelection{Outputs: []types.OutputID{largeIDs[0], largeIDs[0]}})
+	if err == nil || !strings.Contains(err.Error(), errDuplicateOutput.Error()) {
+		t.Fatal("expected errDuplicateOutput", err)
+	}
+	_, err = send(modules.CoinSelection{Outputs: []types.OutputID{{}}})
+	if err == nil || !strings.Contains(err.Error(), errUnknownOutput.Error()) {
+		t.Fatal("expected errUnknownOutput", err)
+	}
+	spent, err = send(modules.CoinSelection{Outputs: []types.OutputID{largeIDs[0]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSpent(spent, largeIDs[0])
+}
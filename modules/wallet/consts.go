@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"time"
+
 	"go.sia.tech/siad/build"
 )
 
@@ -15,6 +17,18 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// paymentListenerBufferSize is the number of payment events which are
+	// buffered for a listener before it is considered too slow and closed.
+	paymentListenerBufferSize = 100
+
+	// paymentMaxConfirmations is the maximum number of confirmations a
+	// payment subscription can wait for, which bounds how long payments are
+	// tracked in the database.
+	paymentMaxConfirmations = 1008
+
+	// paymentWebhookTimeout is the timeout of a single webhook request.
+	paymentWebhookTimeout = 30 * time.Second
 )
 
 var (
@@ -35,6 +49,46 @@ var (
 		Testnet:  uint64(1000),
 		Testing:  uint64(10),
 	}).(uint64)
+
+	// seedSigningMaxKeys is the maximum number of keys which are derived from
+	// a seed to sign a partially signed transaction.
+	seedSigningMaxKeys = build.Select(build.Var{
+		Dev:      uint64(1e6),
+		Standard: uint64(1e6),
+		Testnet:  uint64(1e6),
+		Testing:  uint64(10e3),
+	}).(uint64)
+
+	// paymentWebhookRetryInterval is the time the wallet waits before retrying
+	// a failed webhook request. It is doubled after every attempt, up to
+	// paymentWebhookMaxRetryInterval.
+	paymentWebhookRetryInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// paymentWebhookMaxRetryInterval is the maximum time the wallet waits
+	// before retrying a failed webhook request. Events are retried until they
+	// are delivered, dropped from a full queue or their subscription is
+	// removed.
+	paymentWebhookMaxRetryInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// paymentWebhookMaxQueued is the maximum number of undelivered events
+	// which are queued for the webhook of a subscription. If the webhook
+	// doesn't keep up, the oldest events are dropped.
+	paymentWebhookMaxQueued = build.Select(build.Var{
+		Dev:      uint64(1000),
+		Standard: uint64(10e3),
+		Testnet:  uint64(10e3),
+		Testing:  uint64(5),
+	}).(uint64)
 )
 
 func init() {
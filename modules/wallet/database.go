@@ -26,6 +26,22 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketFrozenOutputs contains the OutputIDs of outputs which were frozen
+	// by the user. The wallet doesn't spend frozen outputs.
+	bucketFrozenOutputs = []byte("bucketFrozenOutputs")
+	// bucketPaymentSubscriptions maps the id of a payment subscription to
+	// the subscription.
+	bucketPaymentSubscriptions = []byte("bucketPaymentSubscriptions")
+	// bucketPayments maps a confirmed payment to a subscribed address to its
+	// value and height. Payments are tracked until they are deep enough in
+	// the blockchain to no longer be reverted by a reorg.
+	bucketPayments = []byte("bucketPayments")
+	// bucketPaymentWebhooks contains the payment events which still need to
+	// be delivered to the webhooks of their subscriptions. It contains a
+	// bucket per subscription which is keyed by an autoincrementing integer,
+	// so that the events of a subscription are delivered in the order they
+	// occurred.
+	bucketPaymentWebhooks = []byte("bucketPaymentWebhooks")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -51,6 +67,10 @@ var (
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketFrozenOutputs,
+		bucketPaymentSubscriptions,
+		bucketPayments,
+		bucketPaymentWebhooks,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
@@ -65,6 +85,8 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyPaymentsChange         = []byte("keyPaymentsChange")
+	keyPaymentsHeight         = []byte("keyPaymentsHeight")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -204,6 +226,10 @@ func dbForEach(b *bolt.Bucket, fn interface{}) error {
 func dbPutSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
 	return dbPut(tx.Bucket(bucketSiacoinOutputs), id, output)
 }
+func dbGetSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (output types.SiacoinOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiacoinOutputs), id, &output)
+	return
+}
 func dbDeleteSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiacoinOutputs), id)
 }
@@ -214,6 +240,10 @@ func dbForEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.Si
 func dbPutSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
 	return dbPut(tx.Bucket(bucketSiafundOutputs), id, output)
 }
+func dbGetSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) (output types.SiafundOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiafundOutputs), id, &output)
+	return
+}
 func dbDeleteSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiafundOutputs), id)
 }
@@ -232,6 +262,126 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutFrozenOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbPut(tx.Bucket(bucketFrozenOutputs), id, true)
+}
+func dbGetFrozenOutput(tx *bolt.Tx, id types.OutputID) bool {
+	var frozen bool
+	return dbGet(tx.Bucket(bucketFrozenOutputs), id, &frozen) == nil && frozen
+}
+func dbDeleteFrozenOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbDelete(tx.Bucket(bucketFrozenOutputs), id)
+}
+func dbForEachFrozenOutput(tx *bolt.Tx, fn func(types.OutputID, bool)) error {
+	return dbForEach(tx.Bucket(bucketFrozenOutputs), fn)
+}
+
+func dbPutPaymentSubscription(tx *bolt.Tx, sub modules.PaymentSubscription) error {
+	return dbPut(tx.Bucket(bucketPaymentSubscriptions), sub.ID, sub)
+}
+func dbGetPaymentSubscription(tx *bolt.Tx, id string) (sub modules.PaymentSubscription, err error) {
+	err = dbGet(tx.Bucket(bucketPaymentSubscriptions), id, &sub)
+	return
+}
+func dbDeletePaymentSubscription(tx *bolt.Tx, id string) error {
+	return dbDelete(tx.Bucket(bucketPaymentSubscriptions), id)
+}
+func dbForEachPaymentSubscription(tx *bolt.Tx, fn func(string, modules.PaymentSubscription)) error {
+	return dbForEach(tx.Bucket(bucketPaymentSubscriptions), fn)
+}
+
+func dbPutPayment(tx *bolt.Tx, key paymentKey, payment trackedPayment) error {
+	return dbPut(tx.Bucket(bucketPayments), key, payment)
+}
+func dbGetPayment(tx *bolt.Tx, key paymentKey) (payment trackedPayment, err error) {
+	err = dbGet(tx.Bucket(bucketPayments), key, &payment)
+	return
+}
+func dbDeletePayment(tx *bolt.Tx, key paymentKey) error {
+	return dbDelete(tx.Bucket(bucketPayments), key)
+}
+func dbForEachPayment(tx *bolt.Tx, fn func(paymentKey, trackedPayment)) error {
+	return dbForEach(tx.Bucket(bucketPayments), fn)
+}
+
+// dbAppendPaymentWebhook queues a payment event for delivery to the webhook
+// of its subscription. Every subscription has its own queue. If the queue
+// holds more than max events afterwards, the oldest events are dropped and
+// their number is returned.
+func dbAppendPaymentWebhook(tx *bolt.Tx, event modules.PaymentEvent, max uint64) (dropped uint64, err error) {
+	b, err := tx.Bucket(bucketPaymentWebhooks).CreateBucketIfNotExists([]byte(event.SubscriptionID))
+	if err != nil {
+		return 0, err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	if err := b.Put(key, encoding.Marshal(event)); err != nil {
+		return 0, err
+	}
+	// Events are only removed from the front of the queue, so the queue
+	// holds all events from the first key up to seq.
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && seq-binary.BigEndian.Uint64(k) >= max; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return dropped, err
+		}
+		dropped++
+	}
+	return dropped, nil
+}
+
+// dbDeletePaymentWebhook removes a delivered payment event from the queue of
+// its subscription. The queue is removed once it is empty.
+func dbDeletePaymentWebhook(tx *bolt.Tx, subID string, seq uint64) error {
+	b := tx.Bucket(bucketPaymentWebhooks).Bucket([]byte(subID))
+	if b == nil {
+		return nil
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	if k, _ := b.Cursor().First(); k != nil {
+		return nil
+	}
+	return tx.Bucket(bucketPaymentWebhooks).DeleteBucket([]byte(subID))
+}
+
+// dbDeletePaymentWebhooks removes the queue of a subscription.
+func dbDeletePaymentWebhooks(tx *bolt.Tx, subID string) error {
+	err := tx.Bucket(bucketPaymentWebhooks).DeleteBucket([]byte(subID))
+	if errors.Contains(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// dbForEachNextPaymentWebhook calls fn on the oldest queued payment event of
+// every subscription.
+func dbForEachNextPaymentWebhook(tx *bolt.Tx, fn func(uint64, modules.PaymentEvent)) error {
+	queues := tx.Bucket(bucketPaymentWebhooks)
+	return queues.ForEach(func(subID, v []byte) error {
+		if v != nil {
+			return nil // not a queue
+		}
+		k, v := queues.Bucket(subID).Cursor().First()
+		if k == nil {
+			return nil
+		}
+		var event modules.PaymentEvent
+		if err := encoding.Unmarshal(v, &event); err != nil {
+			return err
+		}
+		fn(binary.BigEndian.Uint64(k), event)
+		return nil
+	})
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -474,6 +624,28 @@ func dbPutConsensusHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	return tx.Bucket(bucketWallet).Put(keyConsensusHeight, encoding.Marshal(height))
 }
 
+// dbGetPaymentsChangeID returns the id of the last consensus change which was
+// checked for payments.
+func dbGetPaymentsChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
+	copy(cc[:], tx.Bucket(bucketWallet).Get(keyPaymentsChange))
+	return
+}
+func dbPutPaymentsChangeID(tx *bolt.Tx, cc modules.ConsensusChangeID) error {
+	return tx.Bucket(bucketWallet).Put(keyPaymentsChange, cc[:])
+}
+
+// dbGetPaymentsHeight returns the height of the last consensus change which
+// was checked for payments.
+func dbGetPaymentsHeight(tx *bolt.Tx) (height types.BlockHeight) {
+	if b := tx.Bucket(bucketWallet).Get(keyPaymentsHeight); b != nil {
+		_ = encoding.Unmarshal(b, &height)
+	}
+	return
+}
+func dbPutPaymentsHeight(tx *bolt.Tx, height types.BlockHeight) error {
+	return tx.Bucket(bucketWallet).Put(keyPaymentsHeight, encoding.Marshal(height))
+}
+
 // dbGetSiafundPool returns the value of the siafund pool.
 func dbGetSiafundPool(tx *bolt.Tx) (pool types.Currency, err error) {
 	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keySiafundPool), &pool)
@@ -11,6 +11,13 @@ type (
 		f bool // indicates if the next call should fail
 	}
 
+	// dependencyBumpTransactionInterrupted is a dependency used to cause a
+	// call to BumpTransaction to fail instead of calling AcceptTransactionSet
+	dependencyBumpTransactionInterrupted struct {
+		modules.ProductionDependencies
+		f bool // indicates if the next call should fail
+	}
+
 	// dependencyDefragInterrupted is a dependency used to cause a defrag to
 	// fail before AcceptTransactionSet is called
 	dependencyDefragInterrupted struct {
@@ -50,3 +57,19 @@ func (d *dependencyDefragInterrupted) Disrupt(s string) bool {
 func (d *dependencyDefragInterrupted) fail() {
 	d.f = true
 }
+
+// Disrupt will return true if fail was called and the correct string value is
+// provided. It also resets f back to false. This means fail has to be called
+// once for each bump that should fail.
+func (d *dependencyBumpTransactionInterrupted) Disrupt(s string) bool {
+	if d.f && s == "BumpTransactionInterrupted" {
+		d.f = false
+		return true
+	}
+	return false
+}
+
+// fail causes the next BumpTransactionInterrupted disrupt to return true
+func (d *dependencyBumpTransactionInterrupted) fail() {
+	d.f = true
+}
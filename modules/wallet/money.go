@@ -211,7 +211,26 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	}
 	defer w.tg.Done()
 	w.log.Println("Beginning call to SendSiacoinsMulti")
+	return w.managedSendSiacoinsWithSelection(outputs, modules.CoinSelection{})
+}
+
+// SendSiacoinsWithSelection creates a transaction that includes the specified
+// outputs and is funded according to the coin selection. The transaction is
+// submitted to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsWithSelection(outputs []types.SiacoinOutput, selection modules.CoinSelection) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.log.Println("Beginning call to SendSiacoinsWithSelection")
+	return w.managedSendSiacoinsWithSelection(outputs, selection)
+}
 
+// managedSendSiacoinsWithSelection creates a transaction that includes the
+// specified outputs and is funded according to the coin selection. The
+// transaction is submitted to the transaction pool and is also returned.
+func (w *Wallet) managedSendSiacoinsWithSelection(outputs []types.SiacoinOutput, selection modules.CoinSelection) (txns []types.Transaction, err error) {
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
 		return nil, errors.New("cannot send siacoin until fully synced")
@@ -250,7 +269,12 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	for _, sco := range outputs {
 		totalCost = totalCost.Add(sco.Value)
 	}
-	err = txnBuilder.FundSiacoins(totalCost)
+	// Mark the transaction as replaceable before funding it, so that the
+	// parents holding the change are marked as well.
+	if selection.Replaceable {
+		txnBuilder.AddArbitraryData(modules.PrefixReplaceable[:])
+	}
+	err = txnBuilder.FundSiacoinsWithSelection(totalCost, selection)
 	if err != nil {
 		return nil, build.ExtendErr("unable to fund transaction", err)
 	}
@@ -97,10 +97,11 @@ outer:
 		}
 	}
 
-	// mark the watch-only outputs
+	// mark the watch-only and frozen outputs
 	for i, o := range outputs {
 		_, ok := w.watchedAddrs[o.UnlockHash]
 		outputs[i].IsWatchOnly = ok
+		outputs[i].IsFrozen = dbGetFrozenOutput(w.dbTx, o.ID)
 	}
 
 	return outputs, nil
@@ -0,0 +1,466 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInputValueMismatch is returned if the value of an input of a
+	// partially signed transaction doesn't match the output it spends.
+	errInputValueMismatch = errors.New("value of partially signed input doesn't match the spent output")
+
+	// errMissingSignatures is returned when finalizing a partially signed
+	// transaction which doesn't have enough signatures yet.
+	errMissingSignatures = errors.New("partially signed transaction is missing signatures")
+
+	// errNoSigningKeys is returned if no signatures can be added to a
+	// partially signed transaction.
+	errNoSigningKeys = errors.New("no keys were found to sign any of the inputs")
+
+	// errPartiallySignedInputs is returned if the inputs of a partially
+	// signed transaction don't match the inputs of its transaction.
+	errPartiallySignedInputs = errors.New("inputs of the partially signed transaction don't match its transaction")
+
+	// errUnknownUnlockConditions is returned if the wallet doesn't know the
+	// unlock conditions of an address spent by a transaction.
+	errUnknownUnlockConditions = errors.New("no record of UnlockConditions for that UnlockHash")
+)
+
+// checkPartiallySignedInputs checks that every input of the transaction has a
+// matching entry in the inputs of the partially signed transaction and vice
+// versa.
+func checkPartiallySignedInputs(pst modules.PartiallySignedTransaction) error {
+	inputs := make(map[types.OutputID]modules.PartiallySignedInput, len(pst.Inputs))
+	for _, input := range pst.Inputs {
+		if _, exists := inputs[input.ParentID]; exists {
+			return errors.AddContext(errPartiallySignedInputs, "duplicate input "+input.ParentID.String())
+		}
+		inputs[input.ParentID] = input
+	}
+	// check is a helper to compare an input of the transaction with its
+	// entry.
+	check := func(id types.OutputID, fundType types.Specifier, uc types.UnlockConditions) error {
+		input, exists := inputs[id]
+		if !exists {
+			return errors.AddContext(errPartiallySignedInputs, "missing input "+id.String())
+		}
+		if input.FundType != fundType || input.UnlockConditions.UnlockHash() != uc.UnlockHash() {
+			return errors.AddContext(errPartiallySignedInputs, "mismatched input "+id.String())
+		}
+		return nil
+	}
+	for _, sci := range pst.Transaction.SiacoinInputs {
+		if err := check(types.OutputID(sci.ParentID), types.SpecifierSiacoinInput, sci.UnlockConditions); err != nil {
+			return err
+		}
+	}
+	for _, sfi := range pst.Transaction.SiafundInputs {
+		if err := check(types.OutputID(sfi.ParentID), types.SpecifierSiafundInput, sfi.UnlockConditions); err != nil {
+			return err
+		}
+	}
+	if len(inputs) != len(pst.Transaction.SiacoinInputs)+len(pst.Transaction.SiafundInputs) {
+		return errors.AddContext(errPartiallySignedInputs, "partially signed transaction has extra inputs")
+	}
+	return nil
+}
+
+// validPartialSignatures returns the indices of the valid signatures of each
+// input of a partially signed transaction. Only signatures covering the whole
+// transaction are counted, since a signature covering parts of the
+// transaction doesn't protect the rest of it from changes by other
+// co-signers. If a public key signed an input more than once, only its first
+// signature is counted.
+func validPartialSignatures(pst modules.PartiallySignedTransaction, height types.BlockHeight) map[types.OutputID][]int {
+	inputs := make(map[crypto.Hash]types.UnlockConditions, len(pst.Inputs))
+	for _, input := range pst.Inputs {
+		inputs[crypto.Hash(input.ParentID)] = input.UnlockConditions
+	}
+	valid := make(map[types.OutputID][]int)
+	used := make(map[crypto.Hash]map[uint64]struct{})
+	txn := pst.Transaction
+	for i, sig := range txn.TransactionSignatures {
+		uc, exists := inputs[sig.ParentID]
+		if !exists || !sig.CoveredFields.WholeTransaction || sig.Timelock > height {
+			continue
+		}
+		if sig.PublicKeyIndex >= uint64(len(uc.PublicKeys)) || uc.PublicKeys[sig.PublicKeyIndex].Algorithm != types.SignatureEd25519 {
+			continue
+		}
+		if _, exists := used[sig.ParentID][sig.PublicKeyIndex]; exists {
+			continue
+		}
+		var pk crypto.PublicKey
+		copy(pk[:], uc.PublicKeys[sig.PublicKeyIndex].Key)
+		var edSig crypto.Signature
+		if len(sig.Signature) != len(edSig) {
+			continue
+		}
+		copy(edSig[:], sig.Signature)
+		if crypto.VerifyHash(txn.SigHash(i, height), pk, edSig) != nil {
+			continue
+		}
+		if used[sig.ParentID] == nil {
+			used[sig.ParentID] = make(map[uint64]struct{})
+		}
+		used[sig.ParentID][sig.PublicKeyIndex] = struct{}{}
+		valid[types.OutputID(sig.ParentID)] = append(valid[types.OutputID(sig.ParentID)], i)
+	}
+	return valid
+}
+
+// inspectPartiallySigned summarizes a partially signed transaction at the
+// provided height.
+func inspectPartiallySigned(pst modules.PartiallySignedTransaction, height types.BlockHeight) (modules.PartiallySignedTransactionSummary, error) {
+	if err := checkPartiallySignedInputs(pst); err != nil {
+		return modules.PartiallySignedTransactionSummary{}, err
+	}
+	valid := validPartialSignatures(pst, height)
+	summary := modules.PartiallySignedTransactionSummary{
+		TransactionID: pst.Transaction.ID(),
+		Complete:      true,
+	}
+	for _, input := range pst.Inputs {
+		is := modules.PartiallySignedInputSummary{
+			PartiallySignedInput: input,
+			SignaturesRequired:   input.UnlockConditions.SignaturesRequired,
+			SignedBy:             []uint64{},
+		}
+		for _, i := range valid[input.ParentID] {
+			is.SignedBy = append(is.SignedBy, pst.Transaction.TransactionSignatures[i].PublicKeyIndex)
+		}
+		if uint64(len(is.SignedBy)) < is.SignaturesRequired {
+			summary.Complete = false
+		}
+		summary.Inputs = append(summary.Inputs, is)
+
+		if input.FundType == types.SpecifierSiacoinInput {
+			summary.SiacoinInputs = summary.SiacoinInputs.Add(input.Value)
+		} else {
+			summary.SiafundInputs = summary.SiafundInputs.Add(input.Value)
+		}
+	}
+	for _, sco := range pst.Transaction.SiacoinOutputs {
+		summary.SiacoinOutputs = summary.SiacoinOutputs.Add(sco.Value)
+	}
+	for _, sfo := range pst.Transaction.SiafundOutputs {
+		summary.SiafundOutputs = summary.SiafundOutputs.Add(sfo.Value)
+	}
+	for _, fee := range pst.Transaction.MinerFees {
+		summary.MinerFees = summary.MinerFees.Add(fee)
+	}
+	return summary, nil
+}
+
+// signPartiallySigned signs every input of a partially signed transaction
+// with each of the provided keys until the input has enough signatures. It
+// returns an error if no signatures were added.
+func signPartiallySigned(pst modules.PartiallySignedTransaction, keys map[crypto.PublicKey]crypto.SecretKey, height types.BlockHeight) (modules.PartiallySignedTransaction, error) {
+	// Signatures covering the whole transaction don't cover other
+	// signatures, so new signatures can be appended without invalidating the
+	// existing ones.
+	signed := pst.Transaction
+	signed.TransactionSignatures = append([]types.TransactionSignature(nil), pst.Transaction.TransactionSignatures...)
+	valid := validPartialSignatures(pst, height)
+	var added int
+	for _, input := range pst.Inputs {
+		uc := input.UnlockConditions
+		used := make(map[uint64]struct{})
+		for _, i := range valid[input.ParentID] {
+			used[signed.TransactionSignatures[i].PublicKeyIndex] = struct{}{}
+		}
+		for j, spk := range uc.PublicKeys {
+			if uint64(len(used)) >= uc.SignaturesRequired {
+				break
+			}
+			if _, exists := used[uint64(j)]; exists || spk.Algorithm != types.SignatureEd25519 {
+				continue
+			}
+			var pk crypto.PublicKey
+			copy(pk[:], spk.Key)
+			sk, exists := keys[pk]
+			if !exists {
+				continue
+			}
+			signed.TransactionSignatures = append(signed.TransactionSignatures, types.TransactionSignature{
+				ParentID:       crypto.Hash(input.ParentID),
+				PublicKeyIndex: uint64(j),
+				CoveredFields:  types.FullCoveredFields,
+			})
+			sigIndex := len(signed.TransactionSignatures) - 1
+			encodedSig := crypto.SignHash(signed.SigHash(sigIndex, height), sk)
+			signed.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+			used[uint64(j)] = struct{}{}
+			added++
+		}
+	}
+	if added == 0 {
+		return modules.PartiallySignedTransaction{}, errNoSigningKeys
+	}
+	pst.Transaction = signed
+	return pst, nil
+}
+
+// managedConsensusHeight returns the consensus height of the wallet.
+func (w *Wallet) managedConsensusHeight() (types.BlockHeight, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return dbGetConsensusHeight(w.dbTx)
+}
+
+// CreatePartiallySignedTransaction wraps a transaction spending outputs
+// tracked by the wallet into a partially signed transaction. The unlock
+// conditions of the inputs are filled in from the keys of the wallet and the
+// unlock conditions added with AddUnlockConditions. Unsigned signature
+// entries of the transaction are dropped, since the co-signers add their own
+// signatures.
+func (w *Wallet) CreatePartiallySignedTransaction(txn types.Transaction) (modules.PartiallySignedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PartiallySignedTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.PartiallySignedTransaction{}, modules.ErrLockedWallet
+	}
+
+	// unlockConditions is a helper to look up the unlock conditions of an
+	// address.
+	unlockConditions := func(addr types.UnlockHash) (types.UnlockConditions, error) {
+		if sk, ok := w.keys[addr]; ok {
+			return sk.UnlockConditions, nil
+		}
+		uc, err := dbGetUnlockConditions(w.dbTx, addr)
+		if err != nil {
+			return types.UnlockConditions{}, errors.AddContext(errUnknownUnlockConditions, addr.String())
+		}
+		return uc, nil
+	}
+
+	pst := modules.PartiallySignedTransaction{Transaction: txn}
+	pst.Transaction.SiacoinInputs = append([]types.SiacoinInput(nil), txn.SiacoinInputs...)
+	pst.Transaction.SiafundInputs = append([]types.SiafundInput(nil), txn.SiafundInputs...)
+	pst.Transaction.TransactionSignatures = nil
+	for _, sig := range txn.TransactionSignatures {
+		if len(sig.Signature) != 0 {
+			pst.Transaction.TransactionSignatures = append(pst.Transaction.TransactionSignatures, sig)
+		}
+	}
+	for i, sci := range pst.Transaction.SiacoinInputs {
+		sco, err := dbGetSiacoinOutput(w.dbTx, sci.ParentID)
+		if err != nil {
+			return modules.PartiallySignedTransaction{}, errors.AddContext(errUnknownOutput, sci.ParentID.String())
+		}
+		uc, err := unlockConditions(sco.UnlockHash)
+		if err != nil {
+			return modules.PartiallySignedTransaction{}, err
+		}
+		pst.Transaction.SiacoinInputs[i].UnlockConditions = uc
+		pst.Inputs = append(pst.Inputs, modules.PartiallySignedInput{
+			ParentID:         types.OutputID(sci.ParentID),
+			FundType:         types.SpecifierSiacoinInput,
+			UnlockConditions: uc,
+			Value:            sco.Value,
+		})
+	}
+	for i, sfi := range pst.Transaction.SiafundInputs {
+		sfo, err := dbGetSiafundOutput(w.dbTx, sfi.ParentID)
+		if err != nil {
+			return modules.PartiallySignedTransaction{}, errors.AddContext(errUnknownOutput, sfi.ParentID.String())
+		}
+		uc, err := unlockConditions(sfo.UnlockHash)
+		if err != nil {
+			return modules.PartiallySignedTransaction{}, err
+		}
+		pst.Transaction.SiafundInputs[i].UnlockConditions = uc
+		pst.Inputs = append(pst.Inputs, modules.PartiallySignedInput{
+			ParentID:         types.OutputID(sfi.ParentID),
+			FundType:         types.SpecifierSiafundInput,
+			UnlockConditions: uc,
+			Value:            sfo.Value,
+		})
+	}
+	// make a copy of the public key slices; otherwise the caller can modify
+	// the keys of the wallet
+	for i := range pst.Inputs {
+		pst.Inputs[i].UnlockConditions.PublicKeys = append([]types.SiaPublicKey(nil), pst.Inputs[i].UnlockConditions.PublicKeys...)
+	}
+	return pst, nil
+}
+
+// SignPartiallySignedTransaction adds the signatures of the wallet to a
+// partially signed transaction. The wallet signs every input with each of
+// its public keys until the input has enough signatures. The values of inputs
+// spending outputs tracked by the wallet are checked before signing.
+func (w *Wallet) SignPartiallySignedTransaction(pst modules.PartiallySignedTransaction) (modules.PartiallySignedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PartiallySignedTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := checkPartiallySignedInputs(pst); err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.PartiallySignedTransaction{}, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+
+	// Check the values of the inputs the wallet knows about. A co-signer
+	// might rely on them to judge the miner fees of the transaction.
+	for _, input := range pst.Inputs {
+		var value types.Currency
+		if input.FundType == types.SpecifierSiacoinInput {
+			sco, err := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(input.ParentID))
+			if err != nil {
+				continue
+			}
+			value = sco.Value
+		} else {
+			sfo, err := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(input.ParentID))
+			if err != nil {
+				continue
+			}
+			value = sfo.Value
+		}
+		if !value.Equals(input.Value) {
+			return modules.PartiallySignedTransaction{}, errors.AddContext(errInputValueMismatch, input.ParentID.String())
+		}
+	}
+
+	// Index the secret keys of the wallet by their public key.
+	keys := make(map[crypto.PublicKey]crypto.SecretKey)
+	for _, sk := range w.keys {
+		for _, key := range sk.SecretKeys {
+			keys[key.PublicKey()] = key
+		}
+	}
+
+	return signPartiallySigned(pst, keys, height)
+}
+
+// InspectPartiallySignedTransaction verifies the signatures of a partially
+// signed transaction at the current height and summarizes it.
+func (w *Wallet) InspectPartiallySignedTransaction(pst modules.PartiallySignedTransaction) (modules.PartiallySignedTransactionSummary, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PartiallySignedTransactionSummary{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	height, err := w.managedConsensusHeight()
+	if err != nil {
+		return modules.PartiallySignedTransactionSummary{}, err
+	}
+	return inspectPartiallySigned(pst, height)
+}
+
+// FinalizePartiallySignedTransaction returns the signed transaction of a
+// complete partially signed transaction. Invalid signatures and signatures
+// beyond the ones required by each input are dropped, since the consensus
+// rules reject frivolous signatures.
+func (w *Wallet) FinalizePartiallySignedTransaction(pst modules.PartiallySignedTransaction) (types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	height, err := w.managedConsensusHeight()
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	summary, err := inspectPartiallySigned(pst, height)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	if !summary.Complete {
+		return types.Transaction{}, errMissingSignatures
+	}
+
+	// keep the first required signatures of each input in their original
+	// order
+	valid := validPartialSignatures(pst, height)
+	var keep []int
+	for _, input := range pst.Inputs {
+		keep = append(keep, valid[input.ParentID][:input.UnlockConditions.SignaturesRequired]...)
+	}
+	sort.Ints(keep)
+	txn := pst.Transaction
+	txn.TransactionSignatures = make([]types.TransactionSignature, 0, len(keep))
+	for _, i := range keep {
+		txn.TransactionSignatures = append(txn.TransactionSignatures, pst.Transaction.TransactionSignatures[i])
+	}
+	if err := txn.StandaloneValid(height); err != nil {
+		return types.Transaction{}, errors.AddContext(err, "finalized transaction is invalid")
+	}
+	return txn, nil
+}
+
+// SignPartiallySignedTransactionWithSeed adds signatures to a partially signed
+// transaction using keys derived from seed, so that it can be signed on a
+// machine which doesn't run a wallet. The signatures are created for the
+// provided height, which only needs to be past the latest hardfork that
+// changed the replay protection of signatures.
+//
+// Like SignTransaction, SignPartiallySignedTransactionWithSeed must derive the
+// keys from scratch. Keys are derived until every input can be signed
+// completely, but at most the first seedSigningMaxKeys keys are derived.
+func SignPartiallySignedTransactionWithSeed(pst modules.PartiallySignedTransaction, seed modules.Seed, height types.BlockHeight) (modules.PartiallySignedTransaction, error) {
+	if err := checkPartiallySignedInputs(pst); err != nil {
+		return modules.PartiallySignedTransaction{}, err
+	}
+
+	// collect the public keys which can sign the inputs
+	needed := make(map[crypto.PublicKey]struct{})
+	for _, input := range pst.Inputs {
+		for _, spk := range input.UnlockConditions.PublicKeys {
+			if spk.Algorithm == types.SignatureEd25519 {
+				var pk crypto.PublicKey
+				copy(pk[:], spk.Key)
+				needed[pk] = struct{}{}
+			}
+		}
+	}
+	// signable is a helper to check whether every input has enough
+	// signatures once it is signed with the keys derived so far.
+	valid := validPartialSignatures(pst, height)
+	keys := make(map[crypto.PublicKey]crypto.SecretKey)
+	signable := func() bool {
+		for _, input := range pst.Inputs {
+			signers := make(map[uint64]struct{})
+			for _, i := range valid[input.ParentID] {
+				signers[pst.Transaction.TransactionSignatures[i].PublicKeyIndex] = struct{}{}
+			}
+			for j, spk := range input.UnlockConditions.PublicKeys {
+				var pk crypto.PublicKey
+				copy(pk[:], spk.Key)
+				if _, exists := keys[pk]; exists && spk.Algorithm == types.SignatureEd25519 {
+					signers[uint64(j)] = struct{}{}
+				}
+			}
+			if uint64(len(signers)) < input.UnlockConditions.SignaturesRequired {
+				return false
+			}
+		}
+		return true
+	}
+
+	// generate keys in batches before giving up
+	const keysPerBatch = 1000
+	for keyIndex := uint64(0); keyIndex < seedSigningMaxKeys && !signable(); keyIndex += keysPerBatch {
+		for _, sk := range generateKeys(seed, keyIndex, keysPerBatch) {
+			pk := sk.SecretKeys[0].PublicKey()
+			if _, exists := needed[pk]; exists {
+				keys[pk] = sk.SecretKeys[0]
+			}
+		}
+	}
+	return signPartiallySigned(pst, keys, height)
+}
@@ -0,0 +1,250 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPartiallySignedTransaction probes spending a 2-of-3 multisig output by
+// passing a partially signed transaction between its co-signers.
+func TestPartiallySignedTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a multisig address with one key of the wallet and two keys of
+	// other co-signers.
+	walletUC, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk1, pk1 := crypto.GenerateKeyPair()
+	sk2, pk2 := crypto.GenerateKeyPair()
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk1), walletUC.PublicKeys[0], types.Ed25519PublicKey(pk2)},
+		SignaturesRequired: 2,
+	}
+	addr := uc.UnlockHash()
+	if err := wt.wallet.AddUnlockConditions(uc); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{addr}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund the multisig address.
+	value := types.SiacoinPrecision.Mul64(10)
+	_, err = wt.wallet.SendSiacoins(value, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	unspent, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parentID types.SiacoinOutputID
+	for _, uo := range unspent {
+		if uo.UnlockHash == addr {
+			parentID = types.SiacoinOutputID(uo.ID)
+		}
+	}
+	if parentID == (types.SiacoinOutputID{}) {
+		t.Fatal("multisig output not found")
+	}
+
+	// Create the partially signed transaction.
+	fee := types.SiacoinPrecision
+	txn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: parentID}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value.Sub(fee)}},
+		MinerFees:      []types.Currency{fee},
+	}
+	_, err = wt.wallet.CreatePartiallySignedTransaction(types.Transaction{SiacoinInputs: []types.SiacoinInput{{}}})
+	if !errors.Contains(err, errUnknownOutput) {
+		t.Fatal("expected errUnknownOutput", err)
+	}
+	pst, err := wt.wallet.CreatePartiallySignedTransaction(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pst.Inputs) != 1 || pst.Inputs[0].UnlockConditions.UnlockHash() != addr || !pst.Inputs[0].Value.Equals(value) {
+		t.Fatal("wrong inputs", pst.Inputs)
+	}
+	if pst.Transaction.SiacoinInputs[0].UnlockConditions.UnlockHash() != addr {
+		t.Fatal("unlock conditions weren't filled in")
+	}
+	summary, err := wt.wallet.InspectPartiallySignedTransaction(pst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Complete || len(summary.Inputs[0].SignedBy) != 0 || !summary.MinerFees.Equals(fee) || !summary.SiacoinInputs.Equals(value) {
+		t.Fatal("wrong summary", summary)
+	}
+	_, err = wt.wallet.FinalizePartiallySignedTransaction(pst)
+	if !errors.Contains(err, errMissingSignatures) {
+		t.Fatal("expected errMissingSignatures", err)
+	}
+
+	// cosign is a helper to add the signature of another co-signer.
+	height := wt.cs.Height()
+	cosign := func(pst modules.PartiallySignedTransaction, sk crypto.SecretKey, index uint64) modules.PartiallySignedTransaction {
+		pst.Transaction.TransactionSignatures = append(append([]types.TransactionSignature(nil), pst.Transaction.TransactionSignatures...), types.TransactionSignature{
+			ParentID:       crypto.Hash(parentID),
+			PublicKeyIndex: index,
+			CoveredFields:  types.FullCoveredFields,
+		})
+		i := len(pst.Transaction.TransactionSignatures) - 1
+		sig := crypto.SignHash(pst.Transaction.SigHash(i, height), sk)
+		pst.Transaction.TransactionSignatures[i].Signature = sig[:]
+		return pst
+	}
+
+	// The wallet refuses to sign if the value of the input was tampered with.
+	tampered := pst
+	tampered.Inputs = []modules.PartiallySignedInput{pst.Inputs[0]}
+	tampered.Inputs[0].Value = value.Mul64(2)
+	_, err = wt.wallet.SignPartiallySignedTransaction(tampered)
+	if !errors.Contains(err, errInputValueMismatch) {
+		t.Fatal("expected errInputValueMismatch", err)
+	}
+
+	// The wallet adds its signature once.
+	walletSigned, err := wt.wallet.SignPartiallySignedTransaction(pst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err = wt.wallet.InspectPartiallySignedTransaction(walletSigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Complete || len(summary.Inputs[0].SignedBy) != 1 || summary.Inputs[0].SignedBy[0] != 1 {
+		t.Fatal("wrong summary", summary)
+	}
+	_, err = wt.wallet.SignPartiallySignedTransaction(walletSigned)
+	if !errors.Contains(err, errNoSigningKeys) {
+		t.Fatal("expected errNoSigningKeys", err)
+	}
+
+	// Both other co-signers sign the transaction independently. Combining
+	// the copies results in a transaction with more signatures than
+	// required.
+	combined, err := modules.CombinePartiallySignedTransactions(walletSigned, cosign(pst, sk1, 0), cosign(pst, sk2, 2), walletSigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(combined.Transaction.TransactionSignatures) != 3 {
+		t.Fatal("expected 3 signatures but got", len(combined.Transaction.TransactionSignatures))
+	}
+	summary, err = wt.wallet.InspectPartiallySignedTransaction(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Complete || len(summary.Inputs[0].SignedBy) != 3 {
+		t.Fatal("wrong summary", summary)
+	}
+
+	// Copies of a different transaction can't be combined.
+	other := pst
+	other.Transaction.MinerFees = []types.Currency{fee.Mul64(2)}
+	_, err = modules.CombinePartiallySignedTransactions(walletSigned, other)
+	if !errors.Contains(err, modules.ErrPartiallySignedMismatch) {
+		t.Fatal("expected ErrPartiallySignedMismatch", err)
+	}
+
+	// Finalize the transaction. The frivolous signature is dropped and the
+	// transaction is accepted by the transaction pool.
+	final, err := wt.wallet.FinalizePartiallySignedTransaction(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(final.TransactionSignatures) != 2 {
+		t.Fatal("expected 2 signatures but got", len(final.TransactionSignatures))
+	}
+	if final.ID() != summary.TransactionID {
+		t.Fatal("finalized transaction has the wrong id")
+	}
+	err = wt.tpool.AcceptTransactionSet([]types.Transaction{final})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSignPartiallySignedTransactionSeed probes signing a partially signed
+// transaction with keys derived from a seed.
+func TestSignPartiallySignedTransactionSeed(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Spend a standard address and a 2-of-2 multisig address of the seed.
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	ucs := SeedUnlockConditions(seed, 0, 1000)
+	if len(ucs) != 1000 {
+		t.Fatal("expected 1000 unlock conditions but got", len(ucs))
+	}
+	multisig := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{ucs[999].PublicKeys[0], ucs[42].PublicKeys[0]},
+		SignaturesRequired: 2,
+	}
+	value := types.SiacoinPrecision
+	pst := modules.PartiallySignedTransaction{
+		Transaction: types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{
+				{ParentID: types.SiacoinOutputID{1}, UnlockConditions: ucs[7]},
+				{ParentID: types.SiacoinOutputID{2}, UnlockConditions: multisig},
+			},
+			MinerFees: []types.Currency{value.Mul64(2)},
+		},
+		Inputs: []modules.PartiallySignedInput{
+			{ParentID: types.OutputID{1}, FundType: types.SpecifierSiacoinInput, UnlockConditions: ucs[7], Value: value},
+			{ParentID: types.OutputID{2}, FundType: types.SpecifierSiacoinInput, UnlockConditions: multisig, Value: value},
+		},
+	}
+
+	// A seed without any of the keys can't sign.
+	var otherSeed modules.Seed
+	fastrand.Read(otherSeed[:])
+	height := types.FoundationHardforkHeight
+	_, err := SignPartiallySignedTransactionWithSeed(pst, otherSeed, height)
+	if !errors.Contains(err, errNoSigningKeys) {
+		t.Fatal("expected errNoSigningKeys", err)
+	}
+
+	// The seed signs every input.
+	signed, err := SignPartiallySignedTransactionWithSeed(pst, seed, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed.Transaction.TransactionSignatures) != 3 {
+		t.Fatal("expected 3 signatures but got", len(signed.Transaction.TransactionSignatures))
+	}
+	summary, err := inspectPartiallySigned(signed, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Complete {
+		t.Fatal("transaction should be complete", summary)
+	}
+	if err := signed.Transaction.StandaloneValid(height); err != nil {
+		t.Fatal(err)
+	}
+}
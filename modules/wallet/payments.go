@@ -0,0 +1,643 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// PaymentSignatureHeader is the header of a webhook request which contains
+// the hex-encoded HMAC-SHA256 of the request body, keyed with the secret of
+// the payment subscription.
+const PaymentSignatureHeader = "Sia-Payment-Signature"
+
+var (
+	// errEmptySubscription is returned if a payment subscription doesn't
+	// cover any addresses.
+	errEmptySubscription = errors.New("subscription doesn't cover any addresses")
+
+	// errInvalidWebhookURL is returned if the webhook of a payment
+	// subscription is not a http or https url.
+	errInvalidWebhookURL = errors.New("webhook must be a http or https url")
+
+	// errTooManyConfirmations is returned if a payment subscription waits for
+	// more than paymentMaxConfirmations confirmations.
+	errTooManyConfirmations = fmt.Errorf("subscriptions can't wait for more than %v confirmations", paymentMaxConfirmations)
+
+	// errUnknownPaymentSubscription is returned if a payment subscription
+	// doesn't exist.
+	errUnknownPaymentSubscription = errors.New("payment subscription not found")
+)
+
+type (
+	// paymentKey identifies a payment to an address of a subscription.
+	paymentKey struct {
+		SubscriptionID string
+		TransactionID  types.TransactionID
+		Address        types.UnlockHash
+	}
+
+	// trackedPayment is a confirmed payment which might still be reverted by
+	// a reorg. Confirmed indicates whether the confirmed event was sent.
+	trackedPayment struct {
+		Value     types.Currency
+		Height    types.BlockHeight
+		Confirmed bool
+	}
+
+	// payment is the value a transaction sends to an address.
+	payment struct {
+		address types.UnlockHash
+		value   types.Currency
+	}
+
+	// queuedPaymentEvent is a payment event which waits for delivery to the
+	// webhook of its subscription.
+	queuedPaymentEvent struct {
+		seq     uint64
+		event   modules.PaymentEvent
+		webhook string
+		secret  string
+	}
+
+	// activeSubscription is a payment subscription together with the set of
+	// its addresses.
+	activeSubscription struct {
+		modules.PaymentSubscription
+		addrs map[types.UnlockHash]struct{}
+	}
+)
+
+// activePaymentSubscriptions loads the payment subscriptions from the
+// database.
+func activePaymentSubscriptions(tx *bolt.Tx) ([]activeSubscription, error) {
+	var subs []activeSubscription
+	err := dbForEachPaymentSubscription(tx, func(_ string, sub modules.PaymentSubscription) {
+		addrs := make(map[types.UnlockHash]struct{}, len(sub.Addresses))
+		for _, addr := range sub.Addresses {
+			addrs[addr] = struct{}{}
+		}
+		subs = append(subs, activeSubscription{PaymentSubscription: sub, addrs: addrs})
+	})
+	return subs, err
+}
+
+// transactionPayments returns the payments of a transaction to the addresses
+// of a subscription. Outputs to addresses which also fund the transaction are
+// change and not considered payments.
+func (w *Wallet) transactionPayments(sub activeSubscription, txn types.Transaction) []payment {
+	var payments []payment
+	for _, sco := range txn.SiacoinOutputs {
+		_, subscribed := sub.addrs[sco.UnlockHash]
+		if !subscribed && sub.WatchedAddresses {
+			_, subscribed = w.watchedAddrs[sco.UnlockHash]
+		}
+		if !subscribed {
+			continue
+		}
+		isChange := false
+		for _, sci := range txn.SiacoinInputs {
+			isChange = isChange || sci.UnlockConditions.UnlockHash() == sco.UnlockHash
+		}
+		if isChange {
+			continue
+		}
+		found := false
+		for i := range payments {
+			if payments[i].address == sco.UnlockHash {
+				payments[i].value = payments[i].value.Add(sco.Value)
+				found = true
+			}
+		}
+		if !found {
+			payments = append(payments, payment{address: sco.UnlockHash, value: sco.Value})
+		}
+	}
+	return payments
+}
+
+// queuePaymentEvent adds a payment event to the pending events and queues it
+// for delivery to the webhook of its subscription. The event is sent once
+// the database is committed by commitPaymentEvents. If the webhook doesn't
+// keep up, the oldest undelivered events of the subscription are dropped. It
+// must be called while holding w.mu.
+func (w *Wallet) queuePaymentEvent(tx *bolt.Tx, sub activeSubscription, event modules.PaymentEvent) error {
+	event.SubscriptionID = sub.ID
+	if sub.WebhookURL != "" {
+		dropped, err := dbAppendPaymentWebhook(tx, event, paymentWebhookMaxQueued)
+		if err != nil {
+			return errors.AddContext(err, "failed to queue payment webhook")
+		}
+		if dropped > 0 {
+			w.log.Printf("WARN: dropped %v undelivered payment events of subscription %v, the queue is full", dropped, sub.ID)
+		}
+	}
+	w.pendingPaymentEvents = append(w.pendingPaymentEvents, event)
+	return nil
+}
+
+// commitPaymentEvents commits the database and sends the pending payment
+// events to the listeners and webhooks. Events are only sent once the changes
+// which caused them are persisted, so that they aren't sent again after a
+// restart. Listeners which fall too far behind are closed. It must be called
+// while holding w.mu.
+func (w *Wallet) commitPaymentEvents() {
+	if len(w.pendingPaymentEvents) == 0 {
+		return
+	}
+	events := w.pendingPaymentEvents
+	w.pendingPaymentEvents = nil
+	if w.dbRollback {
+		// The changes which caused the events will be rolled back.
+		return
+	}
+	if err := w.syncDB(); err != nil {
+		w.log.Severe("ERROR: failed to commit payment events:", err)
+		return
+	}
+	for _, event := range events {
+		for c := range w.paymentListeners {
+			select {
+			case c <- event:
+			default:
+				delete(w.paymentListeners, c)
+				close(c)
+			}
+		}
+	}
+	select {
+	case w.paymentWebhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// updatePayments queues the payment events of a consensus change and tracks
+// confirmed payments until they can't be reverted anymore. Unconfirmed
+// payments which left the transaction pool without being confirmed by the
+// consensus change are reported as dropped.
+//
+// While the wallet rescans the blockchain, consensus changes which were
+// already checked for payments are skipped so that no events are sent twice.
+// Checking resumes after the last checked consensus change, or once the
+// rescan passes its height if that change was reorged out in the meantime.
+func (w *Wallet) updatePayments(tx *bolt.Tx, cc modules.ConsensusChange) error {
+	lastChange := dbGetPaymentsChangeID(tx)
+	rescanning := lastChange != modules.ConsensusChangeBeginning && lastChange != dbGetConsensusChangeID(tx)
+	if rescanning && cc.BlockHeight <= dbGetPaymentsHeight(tx) {
+		return nil
+	}
+	subs, err := activePaymentSubscriptions(tx)
+	if err != nil {
+		return errors.AddContext(err, "failed to load payment subscriptions")
+	}
+
+	// Send the reverted events of tracked payments.
+	for _, block := range cc.RevertedBlocks {
+		for _, txn := range block.Transactions {
+			txid := txn.ID()
+			for _, sub := range subs {
+				for _, p := range w.transactionPayments(sub, txn) {
+					key := paymentKey{SubscriptionID: sub.ID, TransactionID: txid, Address: p.address}
+					tp, err := dbGetPayment(tx, key)
+					if errors.Contains(err, errNoKey) {
+						continue
+					} else if err != nil {
+						return errors.AddContext(err, "failed to get payment")
+					}
+					if err := dbDeletePayment(tx, key); err != nil {
+						return errors.AddContext(err, "failed to delete payment")
+					}
+					err = w.queuePaymentEvent(tx, sub, modules.PaymentEvent{
+						Type:          modules.PaymentEventReverted,
+						TransactionID: txid,
+						Address:       p.address,
+						Value:         tp.Value,
+						Height:        tp.Height,
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	// Track the payments of the applied blocks.
+	height := cc.InitialHeight()
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			height++
+		}
+		for _, txn := range block.Transactions {
+			txid := txn.ID()
+			delete(w.droppedPayments, txid)
+			for _, sub := range subs {
+				for _, p := range w.transactionPayments(sub, txn) {
+					key := paymentKey{SubscriptionID: sub.ID, TransactionID: txid, Address: p.address}
+					if err := dbPutPayment(tx, key, trackedPayment{Value: p.value, Height: height}); err != nil {
+						return errors.AddContext(err, "failed to put payment")
+					}
+				}
+			}
+		}
+	}
+
+	// The remaining transactions which left the transaction pool weren't
+	// confirmed.
+	if err := w.queueDroppedPayments(tx, subs); err != nil {
+		return err
+	}
+
+	// Send the confirmed events of payments with enough confirmations and
+	// stop tracking payments which are too deep to be reverted.
+	subsByID := make(map[string]activeSubscription, len(subs))
+	for _, sub := range subs {
+		subsByID[sub.ID] = sub
+	}
+	var keys []paymentKey
+	var payments []trackedPayment
+	err = dbForEachPayment(tx, func(key paymentKey, tp trackedPayment) {
+		keys = append(keys, key)
+		payments = append(payments, tp)
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to load payments")
+	}
+	for i, key := range keys {
+		tp := payments[i]
+		sub, exists := subsByID[key.SubscriptionID]
+		if !exists || cc.BlockHeight < tp.Height {
+			continue
+		}
+		confirmations := uint64(cc.BlockHeight-tp.Height) + 1
+		if confirmations > sub.Confirmations+uint64(types.MaturityDelay) {
+			if err := dbDeletePayment(tx, key); err != nil {
+				return errors.AddContext(err, "failed to delete payment")
+			}
+			continue
+		}
+		if tp.Confirmed || confirmations < sub.Confirmations {
+			continue
+		}
+		tp.Confirmed = true
+		if err := dbPutPayment(tx, key, tp); err != nil {
+			return errors.AddContext(err, "failed to put payment")
+		}
+		err = w.queuePaymentEvent(tx, sub, modules.PaymentEvent{
+			Type:          modules.PaymentEventConfirmed,
+			TransactionID: key.TransactionID,
+			Address:       key.Address,
+			Value:         tp.Value,
+			Height:        tp.Height,
+			Confirmations: confirmations,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := dbPutPaymentsChangeID(tx, cc.ID); err != nil {
+		return err
+	}
+	return dbPutPaymentsHeight(tx, cc.BlockHeight)
+}
+
+// queueDroppedPayments queues the dropped events of the unconfirmed payments
+// which left the transaction pool.
+func (w *Wallet) queueDroppedPayments(tx *bolt.Tx, subs []activeSubscription) error {
+	subsByID := make(map[string]activeSubscription, len(subs))
+	for _, sub := range subs {
+		subsByID[sub.ID] = sub
+	}
+	for txid, events := range w.droppedPayments {
+		for _, event := range events {
+			sub, exists := subsByID[event.SubscriptionID]
+			if !exists {
+				continue
+			}
+			event.Type = modules.PaymentEventDropped
+			if err := w.queuePaymentEvent(tx, sub, event); err != nil {
+				return err
+			}
+		}
+		delete(w.droppedPayments, txid)
+	}
+	return nil
+}
+
+// updateUnconfirmedPayments queues the unconfirmed events of the transactions
+// which were added to the transaction pool. Every transaction is only reported
+// once while it stays in the transaction pool. The transactions which left
+// the transaction pool are reported as dropped by the next consensus change
+// which doesn't confirm them.
+func (w *Wallet) updateUnconfirmedPayments(diff *modules.TransactionPoolDiff) error {
+	subs, err := activePaymentSubscriptions(w.dbTx)
+	if err != nil {
+		return errors.AddContext(err, "failed to load payment subscriptions")
+	}
+	for _, uts := range diff.AppliedTransactions {
+		for i, txn := range uts.Transactions {
+			txid := uts.IDs[i]
+			if _, reported := w.unconfirmedPayments[txid]; reported {
+				continue
+			}
+			// A transaction which returns to the transaction pool wasn't
+			// dropped.
+			if events, left := w.droppedPayments[txid]; left {
+				w.unconfirmedPayments[txid] = events
+				delete(w.droppedPayments, txid)
+				continue
+			}
+			for _, sub := range subs {
+				for _, p := range w.transactionPayments(sub, txn) {
+					event := modules.PaymentEvent{
+						Type:           modules.PaymentEventUnconfirmed,
+						SubscriptionID: sub.ID,
+						TransactionID:  txid,
+						Address:        p.address,
+						Value:          p.value,
+					}
+					if err := w.queuePaymentEvent(w.dbTx, sub, event); err != nil {
+						return err
+					}
+					w.unconfirmedPayments[txid] = append(w.unconfirmedPayments[txid], event)
+				}
+			}
+		}
+	}
+
+	// Move the transactions which left the transaction pool to the dropped
+	// payments, unless they were already confirmed.
+	if len(w.unconfirmedPayments) == 0 {
+		return nil
+	}
+	inPool := make(map[types.TransactionID]struct{})
+	for _, txids := range w.unconfirmedSets {
+		for _, txid := range txids {
+			inPool[txid] = struct{}{}
+		}
+	}
+	for txid, events := range w.unconfirmedPayments {
+		if _, exists := inPool[txid]; exists {
+			continue
+		}
+		delete(w.unconfirmedPayments, txid)
+		key := paymentKey{SubscriptionID: events[0].SubscriptionID, TransactionID: txid, Address: events[0].Address}
+		if _, err := dbGetPayment(w.dbTx, key); err == nil {
+			continue
+		} else if !errors.Contains(err, errNoKey) {
+			return errors.AddContext(err, "failed to get payment")
+		}
+		w.droppedPayments[txid] = events
+	}
+	return nil
+}
+
+// managedNextPaymentWebhooks returns the oldest queued payment event of every
+// subscription.
+func (w *Wallet) managedNextPaymentWebhooks() ([]queuedPaymentEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var next []queuedPaymentEvent
+	err := dbForEachNextPaymentWebhook(w.dbTx, func(seq uint64, event modules.PaymentEvent) {
+		sub, err := dbGetPaymentSubscription(w.dbTx, event.SubscriptionID)
+		if err != nil {
+			return
+		}
+		next = append(next, queuedPaymentEvent{seq: seq, event: event, webhook: sub.WebhookURL, secret: sub.Secret})
+	})
+	return next, err
+}
+
+// managedDeletePaymentWebhook removes a delivered payment event from the
+// queue.
+func (w *Wallet) managedDeletePaymentWebhook(subID string, seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbDeletePaymentWebhook(w.dbTx, subID, seq); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// threadedDeliverPaymentWebhooks POSTs the queued payment events to the
+// webhooks of their subscriptions. The events of a subscription are delivered
+// one at a time in the order they occurred. A failed request is retried with
+// exponential backoff and blocks the later events of its subscription, but
+// not the events of other subscriptions. Since events are only removed from
+// the queue after they were delivered, an event might be delivered again if
+// siad stops right after the delivery. Every iteration only reads the oldest
+// event of every subscription.
+func (w *Wallet) threadedDeliverPaymentWebhooks() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	client := &http.Client{Timeout: paymentWebhookTimeout}
+	retryIntervals := make(map[string]time.Duration)
+	retryTimes := make(map[string]time.Time)
+	for {
+		select {
+		case <-w.tg.StopChan():
+			return
+		default:
+		}
+
+		next, err := w.managedNextPaymentWebhooks()
+		if err != nil {
+			w.log.Println("WARN: failed to load queued payment events:", err)
+		}
+		wait := paymentWebhookMaxRetryInterval
+		delivered := false
+		for _, qe := range next {
+			id := qe.event.SubscriptionID
+			if d := time.Until(retryTimes[id]); d > 0 {
+				if d < wait {
+					wait = d
+				}
+				continue
+			}
+			err := w.postPaymentEvent(client, qe.webhook, qe.secret, qe.event)
+			if err != nil {
+				interval := retryIntervals[id] * 2
+				if interval < paymentWebhookRetryInterval {
+					interval = paymentWebhookRetryInterval
+				} else if interval > paymentWebhookMaxRetryInterval {
+					interval = paymentWebhookMaxRetryInterval
+				}
+				retryIntervals[id] = interval
+				retryTimes[id] = time.Now().Add(interval)
+				if interval < wait {
+					wait = interval
+				}
+				w.log.Printf("WARN: failed to deliver %v payment event of transaction %v to %v, retrying in %v: %v", qe.event.Type, qe.event.TransactionID, qe.webhook, interval, err)
+				continue
+			}
+			delete(retryIntervals, id)
+			delete(retryTimes, id)
+			if err := w.managedDeletePaymentWebhook(id, qe.seq); err != nil {
+				w.log.Println("WARN: failed to remove delivered payment event:", err)
+				continue
+			}
+			delivered = true
+		}
+		if delivered {
+			continue
+		}
+
+		select {
+		case <-w.paymentWebhookWake:
+		case <-time.After(wait):
+		case <-w.tg.StopChan():
+			return
+		}
+	}
+}
+
+// postPaymentEvent sends a single webhook request. The body is signed with the
+// secret of the subscription.
+func (w *Wallet) postPaymentEvent(client *http.Client, webhook, secret string, event modules.PaymentEvent) (err error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal payment event")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	req, err := http.NewRequestWithContext(w.tg.StopCtx(), "POST", webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(PaymentSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// AddPaymentSubscription registers a subscription for payments to a set of
+// addresses. The wallet assigns the id of the subscription and generates a
+// secret if none is provided. Only payments which are received after the
+// subscription was added are reported.
+func (w *Wallet) AddPaymentSubscription(sub modules.PaymentSubscription) (modules.PaymentSubscription, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PaymentSubscription{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	if len(sub.Addresses) == 0 && !sub.WatchedAddresses {
+		return modules.PaymentSubscription{}, errEmptySubscription
+	}
+	if sub.WebhookURL != "" {
+		u, err := url.Parse(sub.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return modules.PaymentSubscription{}, errors.AddContext(errInvalidWebhookURL, sub.WebhookURL)
+		}
+	}
+	if sub.Confirmations == 0 {
+		sub.Confirmations = 1
+	} else if sub.Confirmations > paymentMaxConfirmations {
+		return modules.PaymentSubscription{}, errTooManyConfirmations
+	}
+	if sub.Secret == "" {
+		sub.Secret = hex.EncodeToString(fastrand.Bytes(32))
+	}
+	sub.ID = hex.EncodeToString(fastrand.Bytes(16))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutPaymentSubscription(w.dbTx, sub); err != nil {
+		return modules.PaymentSubscription{}, errors.AddContext(err, "failed to add payment subscription")
+	}
+	return sub, w.syncDB()
+}
+
+// PaymentSubscriptions returns the registered payment subscriptions.
+func (w *Wallet) PaymentSubscriptions() ([]modules.PaymentSubscription, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	subs := []modules.PaymentSubscription{}
+	err := dbForEachPaymentSubscription(w.dbTx, func(_ string, sub modules.PaymentSubscription) {
+		subs = append(subs, sub)
+	})
+	return subs, err
+}
+
+// RemovePaymentSubscription removes a payment subscription and stops tracking
+// its payments.
+func (w *Wallet) RemovePaymentSubscription(id string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetPaymentSubscription(w.dbTx, id); errors.Contains(err, errNoKey) {
+		return errors.AddContext(errUnknownPaymentSubscription, id)
+	} else if err != nil {
+		return err
+	}
+	if err := dbDeletePaymentSubscription(w.dbTx, id); err != nil {
+		return errors.AddContext(err, "failed to remove payment subscription")
+	}
+	var keys []paymentKey
+	err := dbForEachPayment(w.dbTx, func(key paymentKey, _ trackedPayment) {
+		if key.SubscriptionID == id {
+			keys = append(keys, key)
+		}
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to load payments")
+	}
+	for _, key := range keys {
+		if err := dbDeletePayment(w.dbTx, key); err != nil {
+			return errors.AddContext(err, "failed to delete payment")
+		}
+	}
+	if err := dbDeletePaymentWebhooks(w.dbTx, id); err != nil {
+		return errors.AddContext(err, "failed to delete queued payment events")
+	}
+	return w.syncDB()
+}
+
+// SubscribePaymentEvents returns a channel which receives the events of all
+// payment subscriptions and a function which closes it. The channel is closed
+// if the receiver falls too far behind.
+func (w *Wallet) SubscribePaymentEvents() (<-chan modules.PaymentEvent, func()) {
+	c := make(chan modules.PaymentEvent, paymentListenerBufferSize)
+	w.mu.Lock()
+	w.paymentListeners[c] = struct{}{}
+	w.mu.Unlock()
+	return c, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, exists := w.paymentListeners[c]; exists {
+			delete(w.paymentListeners, c)
+			close(c)
+		}
+	}
+}
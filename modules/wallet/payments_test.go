@@ -0,0 +1,430 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPaymentSubscription probes the events of a payment subscription.
+func TestPaymentSubscription(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a webhook which fails the first request and records the events
+	// with a valid signature.
+	var mu sync.Mutex
+	var requests int
+	var webhookEvents []modules.PaymentEvent
+	secret := "foo"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if req.Header.Get(PaymentSignatureHeader) != hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("invalid signature")
+			return
+		}
+		var event modules.PaymentEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Error(err)
+			return
+		}
+		webhookEvents = append(webhookEvents, event)
+	}))
+	defer server.Close()
+
+	// Invalid subscriptions are rejected.
+	_, err = wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{})
+	if !errors.Contains(err, errEmptySubscription) {
+		t.Fatal("expected errEmptySubscription", err)
+	}
+	addr := types.UnlockHash{1, 2, 3}
+	_, err = wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{Addresses: []types.UnlockHash{addr}, WebhookURL: "ftp://foo"})
+	if !errors.Contains(err, errInvalidWebhookURL) {
+		t.Fatal("expected errInvalidWebhookURL", err)
+	}
+	_, err = wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{Addresses: []types.UnlockHash{addr}, Confirmations: paymentMaxConfirmations + 1})
+	if !errors.Contains(err, errTooManyConfirmations) {
+		t.Fatal("expected errTooManyConfirmations", err)
+	}
+
+	// Subscribe to payments to an address which doesn't belong to the wallet.
+	sub, err := wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{
+		Addresses:     []types.UnlockHash{addr},
+		Confirmations: 2,
+		WebhookURL:    server.URL,
+		Secret:        secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID == "" {
+		t.Fatal("subscription has no id")
+	}
+	events, unsubscribe := wt.wallet.SubscribePaymentEvents()
+	defer unsubscribe()
+	nextEvent := func() modules.PaymentEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("no payment event received")
+		}
+		return modules.PaymentEvent{}
+	}
+
+	// Send a payment. The payment is reported once it is in the transaction
+	// pool and again once it has two confirmations.
+	value := types.SiacoinPrecision.Mul64(3)
+	txns, err := wt.wallet.SendSiacoins(value, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	event := nextEvent()
+	if event.Type != modules.PaymentEventUnconfirmed || event.SubscriptionID != sub.ID || event.TransactionID != txid || event.Address != addr || !event.Value.Equals(value) {
+		t.Fatal("wrong event", event)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	height := wt.cs.Height()
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	event = nextEvent()
+	if event.Type != modules.PaymentEventConfirmed || event.TransactionID != txid || event.Height != height || event.Confirmations != 2 || !event.Value.Equals(value) {
+		t.Fatal("wrong event", event)
+	}
+
+	// The webhook receives both events after retrying the first one.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(webhookEvents) != 2 {
+			return errors.New("webhook didn't receive both events")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhookEvents[0].Type != modules.PaymentEventUnconfirmed || webhookEvents[1].Type != modules.PaymentEventConfirmed {
+		t.Fatal("webhook events weren't delivered in order", webhookEvents)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		wt.wallet.mu.Lock()
+		defer wt.wallet.mu.Unlock()
+		if n := wt.wallet.dbTx.Bucket(bucketPaymentWebhooks).Stats().KeyN; n != 0 {
+			return fmt.Errorf("%v delivered events are still queued", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revert the block containing the payment.
+	block, exists := wt.cs.BlockAtHeight(height)
+	if !exists {
+		t.Fatal("block not found")
+	}
+	wt.wallet.mu.Lock()
+	err = wt.wallet.updatePayments(wt.wallet.dbTx, modules.ConsensusChange{
+		RevertedBlocks: []types.Block{block},
+		BlockHeight:    height - 1,
+	})
+	wt.wallet.commitPaymentEvents()
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event = nextEvent()
+	if event.Type != modules.PaymentEventReverted || event.TransactionID != txid || event.Height != height {
+		t.Fatal("wrong event", event)
+	}
+
+	// Remove the subscription.
+	if err := wt.wallet.RemovePaymentSubscription(sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.RemovePaymentSubscription(sub.ID); !errors.Contains(err, errUnknownPaymentSubscription) {
+		t.Fatal("expected errUnknownPaymentSubscription", err)
+	}
+	subs, err := wt.wallet.PaymentSubscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 0 {
+		t.Fatal("expected no subscriptions", subs)
+	}
+}
+
+// TestPaymentSubscriptionDropped checks that a payment which leaves the
+// transaction pool without being confirmed is reported as dropped.
+func TestPaymentSubscriptionDropped(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	addr := types.UnlockHash{1, 2, 3}
+	_, err = wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{Addresses: []types.UnlockHash{addr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe := wt.wallet.SubscribePaymentEvents()
+	defer unsubscribe()
+	nextEvent := func() modules.PaymentEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("no payment event received")
+		}
+		return modules.PaymentEvent{}
+	}
+
+	// Send a replaceable payment and replace it.
+	outputs := []types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(3), UnlockHash: addr}}
+	txns, err := wt.wallet.SendSiacoinsWithSelection(outputs, modules.CoinSelection{Replaceable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	if event := nextEvent(); event.Type != modules.PaymentEventUnconfirmed || event.TransactionID != txid {
+		t.Fatal("wrong event", event)
+	}
+	rbfSet, err := wt.wallet.BumpTransaction(txid, modules.FeeBumpRBF, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbfID := rbfSet[len(rbfSet)-1].ID()
+	if event := nextEvent(); event.Type != modules.PaymentEventUnconfirmed || event.TransactionID != rbfID {
+		t.Fatal("wrong event", event)
+	}
+
+	// Once a block is mined, the replaced payment is reported as dropped and
+	// the replacement as confirmed.
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(); event.Type != modules.PaymentEventDropped || event.TransactionID != txid {
+		t.Fatal("wrong event", event)
+	}
+	if event := nextEvent(); event.Type != modules.PaymentEventConfirmed || event.TransactionID != rbfID {
+		t.Fatal("wrong event", event)
+	}
+	select {
+	case event := <-events:
+		t.Fatal("unexpected event", event)
+	default:
+	}
+}
+
+// TestPaymentWebhookQueue probes the per-subscription queues of undelivered
+// webhook events.
+func TestPaymentWebhookQueue(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	tx := wt.wallet.dbTx
+
+	// next is a helper that returns the height of the oldest queued event of
+	// every subscription.
+	next := func() map[string]types.BlockHeight {
+		heights := make(map[string]types.BlockHeight)
+		err := dbForEachNextPaymentWebhook(tx, func(_ uint64, event modules.PaymentEvent) {
+			heights[event.SubscriptionID] = event.Height
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return heights
+	}
+
+	// Queue more events for one subscription than fit into its queue. The
+	// oldest events are dropped.
+	for i := uint64(0); i < paymentWebhookMaxQueued+2; i++ {
+		dropped, err := dbAppendPaymentWebhook(tx, modules.PaymentEvent{SubscriptionID: "a", Height: types.BlockHeight(i)}, paymentWebhookMaxQueued)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if full := i >= paymentWebhookMaxQueued; full != (dropped == 1) || dropped > 1 {
+			t.Fatalf("%v events were dropped after queueing %v events", dropped, i+1)
+		}
+	}
+	if _, err := dbAppendPaymentWebhook(tx, modules.PaymentEvent{SubscriptionID: "b", Height: 100}, paymentWebhookMaxQueued); err != nil {
+		t.Fatal(err)
+	}
+	if heights := next(); len(heights) != 2 || heights["a"] != 2 || heights["b"] != 100 {
+		t.Fatal("wrong next events", heights)
+	}
+
+	// Deliver the event of b. Its queue is removed.
+	var seq uint64
+	err = dbForEachNextPaymentWebhook(tx, func(s uint64, event modules.PaymentEvent) {
+		if event.SubscriptionID == "b" {
+			seq = s
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dbDeletePaymentWebhook(tx, "b", seq); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Bucket(bucketPaymentWebhooks).Bucket([]byte("b")) != nil {
+		t.Fatal("empty queue wasn't removed")
+	}
+	if heights := next(); len(heights) != 1 || heights["a"] != 2 {
+		t.Fatal("wrong next events", heights)
+	}
+
+	// Remove the queue of a.
+	if err := dbDeletePaymentWebhooks(tx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbDeletePaymentWebhooks(tx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if heights := next(); len(heights) != 0 {
+		t.Fatal("wrong next events", heights)
+	}
+}
+
+// TestPaymentsRescanReorg checks that payments are reported again after a
+// rescan if the last consensus change which was checked for payments was
+// reorged out.
+func TestPaymentsRescanReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	addr := types.UnlockHash{1}
+	sub, err := wt.wallet.AddPaymentSubscription(modules.PaymentSubscription{Addresses: []types.UnlockHash{addr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	tx := wt.wallet.dbTx
+
+	// Pretend that payments were checked up to a consensus change which isn't
+	// on the current chain and that the wallet is rescanning.
+	height := dbGetPaymentsHeight(tx)
+	if err := dbPutPaymentsChangeID(tx, modules.ConsensusChangeID{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbPutConsensusChangeID(tx, modules.ConsensusChangeBeginning); err != nil {
+		t.Fatal(err)
+	}
+
+	// paymentChange is a helper that creates a consensus change with a payment
+	// to addr at the given height.
+	paymentChange := func(height types.BlockHeight) modules.ConsensusChange {
+		txn := types.Transaction{
+			SiacoinOutputs: []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: addr}},
+			ArbitraryData:  [][]byte{encoding.Marshal(height)},
+		}
+		return modules.ConsensusChange{
+			ID:            modules.ConsensusChangeID{byte(height)},
+			AppliedBlocks: []types.Block{{Transactions: []types.Transaction{txn}}},
+			BlockHeight:   height,
+		}
+	}
+
+	// Consensus changes up to the last checked height are skipped.
+	if err := wt.wallet.updatePayments(tx, paymentChange(height)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbForEachPayment(tx, func(key paymentKey, _ trackedPayment) {
+		t.Fatal("payment shouldn't be tracked", key)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the rescan passes the last checked height, payments are tracked
+	// again.
+	cc := paymentChange(height + 1)
+	if err := wt.wallet.updatePayments(tx, cc); err != nil {
+		t.Fatal(err)
+	}
+	var tracked []paymentKey
+	if err := dbForEachPayment(tx, func(key paymentKey, _ trackedPayment) {
+		tracked = append(tracked, key)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracked) != 1 || tracked[0].SubscriptionID != sub.ID || tracked[0].Address != addr {
+		t.Fatal("payment wasn't tracked", tracked)
+	}
+	if dbGetPaymentsChangeID(tx) != cc.ID || dbGetPaymentsHeight(tx) != cc.BlockHeight {
+		t.Fatal("last checked consensus change wasn't updated")
+	}
+	wt.wallet.pendingPaymentEvents = nil
+}
@@ -178,6 +178,9 @@ func (w *Wallet) initPersist() error {
 
 	// spawn a goroutine to commit the db transaction at regular intervals
 	go w.threadedDBUpdate()
+
+	// spawn a goroutine to deliver the queued payment events to webhooks
+	go w.threadedDeliverPaymentWebhooks()
 	return nil
 }
 
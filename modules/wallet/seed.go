@@ -66,6 +66,17 @@ func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
 	return keys
 }
 
+// SeedUnlockConditions returns the unlock conditions of the n addresses
+// generated from seed, starting from index start. It allows deriving the
+// addresses of a seed without loading it into a wallet.
+func SeedUnlockConditions(seed modules.Seed, start, n uint64) []types.UnlockConditions {
+	ucs := make([]types.UnlockConditions, 0, n)
+	for _, sk := range generateKeys(seed, start, n) {
+		ucs = append(ucs, sk.UnlockConditions)
+	}
+	return ucs
+}
+
 // createSeedFile creates and encrypts a seedFile.
 func createSeedFile(masterKey crypto.CipherKey, seed modules.Seed) seedFile {
 	var sf seedFile
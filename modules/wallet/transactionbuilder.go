@@ -2,7 +2,6 @@ package wallet
 
 import (
 	"bytes"
-	"sort"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
@@ -108,6 +107,10 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 			return errSpendHeightTooHigh
 		}
 	}
+	// Check that the output is not frozen.
+	if dbGetFrozenOutput(tx, types.OutputID(id)) {
+		return errOutputFrozen
+	}
 	outputUnlockConditions := w.keys[output.UnlockHash].UnlockConditions
 	if currentHeight < outputUnlockConditions.Timelock {
 		return errOutputTimelock
@@ -190,7 +193,14 @@ func (tb *transactionBuilder) MarkWalletInputs() bool {
 // transaction. A parent transaction may be needed to achieve an input with the
 // correct value. The siacoin input will not be signed until 'Sign' is called
 // on the transaction builder.
-func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
+func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
+	return tb.FundSiacoinsWithSelection(amount, modules.CoinSelection{})
+}
+
+// FundSiacoinsWithSelection will add a siacoin input of exactly 'amount' to
+// the transaction, funded by the outputs picked according to the coin
+// selection.
+func (tb *transactionBuilder) FundSiacoinsWithSelection(amount types.Currency, selection modules.CoinSelection) (err error) {
 	if amount.IsZero() {
 		return nil
 	}
@@ -208,7 +218,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		return err
 	}
 
-	// Collect a value-sorted set of siacoin outputs.
+	// Collect the siacoin outputs of the wallet.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
 		so.ids = append(so.ids, scoid)
@@ -229,49 +239,26 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 			so.outputs = append(so.outputs, sco)
 		}
 	}
-	sort.Sort(sort.Reverse(so))
 
 	// Create and fund a parent transaction that will add the correct amount of
 	// siacoins to the transaction.
-	var fund types.Currency
-	// potentialFund tracks the balance of the wallet including outputs that
-	// have been spent in other unconfirmed transactions recently. This is to
-	// provide the user with a more useful error message in the event that they
-	// are overspending.
-	var potentialFund types.Currency
+	selected, fund, err := tb.wallet.selectSiacoinOutputs(tb.wallet.dbTx, so, amount, selection, consensusHeight, dustThreshold)
+	if err != nil {
+		return err
+	}
 	parentTxn := types.Transaction{}
-	var spentScoids []types.SiacoinOutputID
-	for i := range so.ids {
-		scoid := so.ids[i]
-		sco := so.outputs[i]
-		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
-			if errors.Contains(err, errSpendHeightTooHigh) {
-				potentialFund = potentialFund.Add(sco.Value)
-			}
-			continue
-		}
-
-		// Add a siacoin input for this output.
+	if modules.IsReplaceable(tb.transaction) {
+		// The parent holds the change of a replaceable transaction, which
+		// is where a replacement takes its additional fee from.
+		parentTxn.ArbitraryData = [][]byte{modules.PrefixReplaceable[:]}
+	}
+	spentScoids := selected.ids
+	for i, scoid := range selected.ids {
 		sci := types.SiacoinInput{
 			ParentID:         scoid,
-			UnlockConditions: tb.wallet.keys[sco.UnlockHash].UnlockConditions,
+			UnlockConditions: tb.wallet.keys[selected.outputs[i].UnlockHash].UnlockConditions,
 		}
 		parentTxn.SiacoinInputs = append(parentTxn.SiacoinInputs, sci)
-		spentScoids = append(spentScoids, scoid)
-
-		// Add the output to the total fund
-		fund = fund.Add(sco.Value)
-		potentialFund = potentialFund.Add(sco.Value)
-		if fund.Cmp(amount) >= 0 {
-			break
-		}
-	}
-	if potentialFund.Cmp(amount) >= 0 && fund.Cmp(amount) < 0 {
-		return modules.ErrIncompleteTransactions
-	}
-	if fund.Cmp(amount) < 0 {
-		return modules.ErrLowBalance
 	}
 
 	// Create and add the output that will be used to fund the standard
@@ -292,8 +279,15 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 	}
 	parentTxn.SiacoinOutputs = append(parentTxn.SiacoinOutputs, exactOutput)
 
-	// Create a refund output if needed.
-	if !amount.Equals(fund) {
+	// Create a refund output if needed. An excess left by branch and bound
+	// selection would only create a dust output, so it is paid as miner fee
+	// instead.
+	excess := fund.Sub(amount)
+	if selection.Strategy == modules.CoinSelectionBranchAndBound && excess.Cmp(dustThreshold) < 0 {
+		if !excess.IsZero() {
+			parentTxn.MinerFees = append(parentTxn.MinerFees, excess)
+		}
+	} else if !excess.IsZero() {
 		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
 		if err != nil {
 			return err
@@ -389,6 +383,9 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 			potentialFund = potentialFund.Add(sfo.Value)
 			continue
 		}
+		if dbGetFrozenOutput(tb.wallet.dbTx, types.OutputID(sfoid)) {
+			continue
+		}
 		outputUnlockConditions := tb.wallet.keys[sfo.UnlockHash].UnlockConditions
 		if consensusHeight < outputUnlockConditions.Timelock {
 			continue
@@ -523,6 +523,10 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
 	}
+	if err := w.updatePayments(w.dbTx, cc); err != nil {
+		w.log.Severe("ERROR: failed to update payments:", err)
+		w.dbRollback = true
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
@@ -531,6 +535,7 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to update consensus block height:", err)
 		w.dbRollback = true
 	}
+	w.commitPaymentEvents()
 
 	if cc.Synced {
 		go w.threadedDefragWallet()
@@ -649,4 +654,9 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 			w.unconfirmedProcessedTransactions = append(w.unconfirmedProcessedTransactions, pt)
 		}
 	}
+
+	if err := w.updateUnconfirmedPayments(diff); err != nil {
+		w.log.Println("WARN: failed to update unconfirmed payments:", err)
+	}
+	w.commitPaymentEvents()
 }
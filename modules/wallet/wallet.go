@@ -89,6 +89,19 @@ type Wallet struct {
 	unconfirmedSets                  map[modules.TransactionSetID][]types.TransactionID
 	unconfirmedProcessedTransactions []modules.ProcessedTransaction
 
+	// paymentListeners receive the events of the payment subscriptions.
+	// pendingPaymentEvents are sent to the listeners once the changes which
+	// caused them are committed to the database. unconfirmedPayments contains
+	// the unconfirmed events of the transactions in the transaction pool.
+	// droppedPayments contains the unconfirmed events of the transactions
+	// which left the transaction pool and might have been dropped.
+	// paymentWebhookWake wakes up the delivery of the webhooks.
+	paymentListeners     map[chan modules.PaymentEvent]struct{}
+	pendingPaymentEvents []modules.PaymentEvent
+	unconfirmedPayments  map[types.TransactionID][]modules.PaymentEvent
+	droppedPayments      map[types.TransactionID][]modules.PaymentEvent
+	paymentWebhookWake   chan struct{}
+
 	// The wallet's database tracks its seeds, keys, outputs, and
 	// transactions. A global db transaction is maintained in memory to avoid
 	// excessive disk writes. Any operations involving dbTx must hold an
@@ -208,6 +221,11 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
+		paymentListeners:    make(map[chan modules.PaymentEvent]struct{}),
+		unconfirmedPayments: make(map[types.TransactionID][]modules.PaymentEvent),
+		droppedPayments:     make(map[types.TransactionID][]modules.PaymentEvent),
+		paymentWebhookWake:  make(chan struct{}, 1),
+
 		persistDir: persistDir,
 
 		deps: deps,
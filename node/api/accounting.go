@@ -0,0 +1,74 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// AccountingGET contains the information that is returned after a GET
+	// request to /accounting.
+	AccountingGET []modules.AccountingInfo
+)
+
+// RegisterRoutesAccounting is a helper function to register all accounting
+// routes.
+func RegisterRoutesAccounting(router *httprouter.Router, a modules.Accounting) {
+	router.GET("/accounting", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		accountingHandlerGET(a, w, req, ps)
+	})
+}
+
+// accountingHandlerGET handles the API call that queries the accounting
+// information. If no range is provided the current accounting information is
+// returned, otherwise the persisted accounting information within the range is
+// returned.
+func accountingHandlerGET(a modules.Accounting, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	startStr, endStr := req.FormValue("start"), req.FormValue("end")
+
+	// If no range is provided, return the current accounting information.
+	if startStr == "" && endStr == "" {
+		ai, err := a.Accounting()
+		if err != nil {
+			WriteError(w, Error{"unable to get the accounting information: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteJSON(w, AccountingGET{ai})
+		return
+	}
+
+	// Parse the range. A missing start defaults to the beginning of time and a
+	// missing end defaults to the end of time.
+	var start int64
+	var err error
+	if startStr != "" {
+		start, err = strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `start` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	end := int64(math.MaxInt64)
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `end` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	ais, err := a.AccountingRange(start, end)
+	if err != nil {
+		WriteError(w, Error{"unable to get the accounting information: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ais == nil {
+		ais = []modules.AccountingInfo{}
+	}
+	WriteJSON(w, AccountingGET(ais))
+}
@@ -131,6 +131,11 @@ type (
 
 		staticStartTime time.Time
 
+		// staticStreamsClosed is closed when the API shuts down to end
+		// long-lived streaming responses.
+		staticStreamsClosed chan struct{}
+		streamsCloseOnce    sync.Once
+
 		staticDeps modules.Dependencies
 	}
 
@@ -156,6 +161,15 @@ func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	api.routerMu.RUnlock()
 }
 
+// CloseStreams ends the long-lived streaming responses of the API, such as the
+// stream of payment events. It must be called before the http server shuts
+// down, which otherwise waits for the streams to end.
+func (api *API) CloseStreams() {
+	api.streamsCloseOnce.Do(func() {
+		close(api.staticStreamsClosed)
+	})
+}
+
 // SetModules allows for replacing the modules in the API at runtime.
 func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) {
 	if api.modulesSet {
@@ -218,8 +232,9 @@ func NewCustom(cfg *modules.SiadConfig, requiredUserAgent string, requiredPasswo
 		requiredPassword:  requiredPassword,
 		siadConfig:        cfg,
 
-		staticDeps:      deps,
-		staticStartTime: time.Now(),
+		staticDeps:          deps,
+		staticStartTime:     time.Now(),
+		staticStreamsClosed: make(chan struct{}),
 	}
 
 	// Register API handlers
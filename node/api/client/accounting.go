@@ -0,0 +1,25 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"go.sia.tech/siad/node/api"
+)
+
+// AccountingGet requests the /accounting api resource for the current
+// accounting information.
+func (c *Client) AccountingGet() (ag api.AccountingGET, err error) {
+	err = c.get("/accounting", &ag)
+	return
+}
+
+// AccountingRangeGet requests the /accounting api resource for the persisted
+// accounting information with a timestamp within the provided range.
+func (c *Client) AccountingRangeGet(start, end int64) (ag api.AccountingGET, err error) {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(start))
+	values.Set("end", fmt.Sprint(end))
+	err = c.get(fmt.Sprintf("/accounting?%s", values.Encode()), &ag)
+	return
+}
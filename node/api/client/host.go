@@ -68,6 +68,54 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamMaxRenterAccounts is the maximum number of ephemeral accounts
+	// a single renter can fund.
+	HostParamMaxRenterAccounts = HostParam("maxrenteraccounts")
+	// HostParamMaxRenterBandwidth is the maximum bandwidth in bytes a single
+	// renter can use per bandwidth period.
+	HostParamMaxRenterBandwidth = HostParam("maxrenterbandwidth")
+	// HostParamMaxRenterRegistryEntries is the maximum number of registry
+	// entries a single renter can create.
+	HostParamMaxRenterRegistryEntries = HostParam("maxrenterregistryentries")
+	// HostParamMaxRenterSectors is the maximum number of sectors a single
+	// renter can store.
+	HostParamMaxRenterSectors = HostParam("maxrentersectors")
+	// HostParamPricingEnabled indicates whether the host's dynamic pricing is
+	// enabled.
+	HostParamPricingEnabled = HostParam("pricingenabled")
+	// HostParamPricingExchangeRate is the value of one siacoin used by the
+	// dynamic pricing, e.g. "0.004 usd".
+	HostParamPricingExchangeRate = HostParam("pricingexchangerate")
+	// HostParamPricingTargetDownloadBandwidthPrice is the target download
+	// price per TB in the currency of the exchange rate.
+	HostParamPricingTargetDownloadBandwidthPrice = HostParam("pricingtargetdownloadbandwidthprice")
+	// HostParamPricingTargetStoragePrice is the target storage price per TB
+	// per month in the currency of the exchange rate.
+	HostParamPricingTargetStoragePrice = HostParam("pricingtargetstorageprice")
+	// HostParamPricingTargetUploadBandwidthPrice is the target upload price
+	// per TB in the currency of the exchange rate.
+	HostParamPricingTargetUploadBandwidthPrice = HostParam("pricingtargetuploadbandwidthprice")
+	// HostParamPricingBandwidthCapacity is the bandwidth in bytes per second
+	// at which the host considers itself fully loaded.
+	HostParamPricingBandwidthCapacity = HostParam("pricingbandwidthcapacity")
+	// HostParamPricingMaxPriceMultiplier is the factor by which a fully
+	// loaded host multiplies its base prices.
+	HostParamPricingMaxPriceMultiplier = HostParam("pricingmaxpricemultiplier")
+	// HostParamPricingSmoothing is the weight of the most recent load when
+	// updating the dynamic prices.
+	HostParamPricingSmoothing = HostParam("pricingsmoothing")
+	// HostParamScrubRate is the number of bytes per second read by the
+	// host's sector scrubber.
+	HostParamScrubRate = HostParam("scrubrate")
+	// HostParamMaintenanceMode drains the host by no longer accepting new
+	// contracts and renewals.
+	HostParamMaintenanceMode = HostParam("maintenancemode")
+	// HostParamMaintenanceWindowStart is the block height at which a planned
+	// maintenance of the host starts.
+	HostParamMaintenanceWindowStart = HostParam("maintenancewindowstart")
+	// HostParamMaintenanceWindowEnd is the block height at which a planned
+	// maintenance of the host ends.
+	HostParamMaintenanceWindowEnd = HostParam("maintenancewindowend")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -118,8 +166,79 @@ func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err
 }
 
 // HostBandwidthGet requests the /host/bandwidth api resource
-func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
-	err = c.get("/host/bandwidth", &gbg)
+func (c *Client) HostBandwidthGet() (hbg api.HostBandwidthGET, err error) {
+	err = c.get("/host/bandwidth", &hbg)
+	return
+}
+
+// HostBandwidthRangeGet requests the /host/bandwidth api resource for the
+// bandwidth used by the host within the provided range of unix timestamps.
+func (c *Client) HostBandwidthRangeGet(start, end int64) (hbg api.HostBandwidthGET, err error) {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(start))
+	values.Set("end", fmt.Sprint(end))
+	err = c.get(fmt.Sprintf("/host/bandwidth?%s", values.Encode()), &hbg)
+	return
+}
+
+// HostPricingGet requests the /host/pricing endpoint.
+func (c *Client) HostPricingGet() (hpg api.HostPricingGET, err error) {
+	err = c.get("/host/pricing", &hpg)
+	return
+}
+
+// HostPricingDryRunGet requests the /host/pricing endpoint with a pricing
+// policy param set to a certain value for a dry run.
+func (c *Client) HostPricingDryRunGet(param HostParam, value interface{}) (hpg api.HostPricingGET, err error) {
+	values := url.Values{}
+	values.Set(string(param), fmt.Sprint(value))
+	err = c.get("/host/pricing?"+values.Encode(), &hpg)
+	return
+}
+
+// HostRentersGet requests the /host/renters endpoint.
+func (c *Client) HostRentersGet() (hrg api.HostRentersGET, err error) {
+	err = c.get("/host/renters", &hrg)
+	return
+}
+
+// HostMaintenanceGet requests the /host/maintenance endpoint.
+func (c *Client) HostMaintenanceGet() (hmg api.HostMaintenanceGET, err error) {
+	err = c.get("/host/maintenance", &hmg)
+	return
+}
+
+// HostScrubGet requests the /host/scrub endpoint.
+func (c *Client) HostScrubGet() (hsg api.HostScrubGET, err error) {
+	err = c.get("/host/scrub", &hsg)
+	return
+}
+
+// HostRegistryGet requests the /host/registry endpoint.
+func (c *Client) HostRegistryGet() (hrg api.HostRegistryGET, err error) {
+	err = c.get("/host/registry", &hrg)
+	return
+}
+
+// HostRegistryEntryGet requests the /host/registry/entry/:entryid endpoint.
+func (c *Client) HostRegistryEntryGet(sid modules.RegistryEntryID) (hreg api.HostRegistryEntryGET, err error) {
+	err = c.get("/host/registry/entry/"+crypto.Hash(sid).String(), &hreg)
+	return
+}
+
+// HostRegistryDeletePost uses the /host/registry/delete/:entryid endpoint to
+// delete an entry from the host's registry.
+func (c *Client) HostRegistryDeletePost(sid modules.RegistryEntryID) (err error) {
+	err = c.post("/host/registry/delete/"+crypto.Hash(sid).String(), "", nil)
+	return
+}
+
+// HostRegistryTruncatePost uses the /host/registry/truncate endpoint to prune
+// the expired entries of the host's registry and resize it to size bytes.
+func (c *Client) HostRegistryTruncatePost(size uint64) (err error) {
+	values := url.Values{}
+	values.Set("size", strconv.FormatUint(size, 10))
+	err = c.post("/host/registry/truncate", values.Encode(), nil)
 	return
 }
 
@@ -133,6 +252,19 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersMigratePost uses the /host/storage/folders/migrate api
+// endpoint to migrate the sectors of a storage folder into another storage
+// folder. A limit of 0 means no limit.
+func (c *Client) HostStorageFoldersMigratePost(path, destination string, maxBandwidth, maxIOPS uint64) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("destination", destination)
+	values.Set("maxbandwidth", strconv.FormatUint(maxBandwidth, 10))
+	values.Set("maxiops", strconv.FormatUint(maxIOPS, 10))
+	err = c.post("/host/storage/folders/migrate", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string, force bool) (err error) {
@@ -153,6 +285,16 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostStorageFoldersTierPost uses the /host/storage/folders/tier api endpoint
+// to set the tier of a storage folder.
+func (c *Client) HostStorageFoldersTierPost(path string, tier modules.StorageTier) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("tier", string(tier))
+	err = c.post("/host/storage/folders/tier", values.Encode(), nil)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
@@ -1,9 +1,13 @@
 package client
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -12,6 +16,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -525,6 +530,15 @@ func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
 	return
 }
 
+// RenterSetDefaultCipherTypePost uses the /renter endpoint to set the cipher
+// type which is used for uploads that don't specify one.
+func (c *Client) RenterSetDefaultCipherTypePost(cipherType string) (err error) {
+	values := url.Values{}
+	values.Set("defaultciphertype", cipherType)
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterStreamGet uses the /renter/stream endpoint to download data as a
 // stream.
 func (c *Client) RenterStreamGet(siaPath modules.SiaPath, disableLocalFetch, root bool) (resp []byte, err error) {
@@ -568,6 +582,27 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck boo
 	return
 }
 
+// RenterFileRekeyPost uses the /renter/file endpoint to start re-encrypting the
+// siafile at siaPath under a new master key of the given cipher type. An empty
+// cipherType will keep the file's current cipher type.
+func (c *Client) RenterFileRekeyPost(siaPath modules.SiaPath, cipherType string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("rekey", fmt.Sprint(true))
+	if cipherType != "" {
+		values.Set("ciphertype", cipherType)
+	}
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterRekeyGet uses the /renter/rekey endpoint to get the status of the files
+// which are being re-encrypted.
+func (c *Client) RenterRekeyGet() (rrg api.RenterRekeyGET, err error) {
+	err = c.get("/renter/rekey", &rrg)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -576,16 +611,75 @@ func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPiec
 // RenterUploadForcePost uses the /renter/upload endpoint to upload a file
 // and to overwrite if the file already exists
 func (c *Client) RenterUploadForcePost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, force bool) (err error) {
+	return c.RenterUploadErasureCoderPost(path, siaPath, dataPieces, parityPieces, "", force)
+}
+
+// RenterUploadErasureCoderPost uses the /renter/upload endpoint to upload a
+// file using the specified erasure coder. An empty erasureCoder will use the
+// default one.
+func (c *Client) RenterUploadErasureCoderPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder string, force bool) (err error) {
+	return c.RenterUploadCustomPost(path, siaPath, dataPieces, parityPieces, erasureCoder, "", force)
+}
+
+// RenterUploadCustomPost uses the /renter/upload endpoint to upload a file
+// using the specified erasure coder and cipher type. An empty erasureCoder or
+// cipherType will use the renter's default.
+func (c *Client) RenterUploadCustomPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) (err error) {
+	return c.renterUploadPost(path, siaPath, dataPieces, parityPieces, erasureCoder, cipherType, force, false)
+}
+
+// RenterUploadDedupPost uses the /renter/upload endpoint to upload a file in
+// dedup mode. Chunks which were already uploaded by other files in dedup mode
+// won't be uploaded again. An empty erasureCoder or cipherType will use the
+// renter's default.
+func (c *Client) RenterUploadDedupPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) (err error) {
+	return c.renterUploadPost(path, siaPath, dataPieces, parityPieces, erasureCoder, cipherType, force, true)
+}
+
+// renterUploadPost uses the /renter/upload endpoint to upload a file.
+func (c *Client) renterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force, dedup bool) (err error) {
 	sp := escapeSiaPath(siaPath)
 	values := url.Values{}
 	values.Set("source", path)
 	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
 	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	if erasureCoder != "" {
+		values.Set("erasurecoder", erasureCoder)
+	}
+	if cipherType != "" {
+		values.Set("ciphertype", cipherType)
+	}
 	values.Set("force", strconv.FormatBool(force))
+	if dedup {
+		values.Set("dedup", strconv.FormatBool(dedup))
+	}
 	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
 	return
 }
 
+// RenterUploadPackedPost uses the /renter/uploadpacked endpoint to upload
+// multiple small files which are packed into shared chunks. An empty
+// erasureCoder or cipherType will use the renter's default.
+func (c *Client) RenterUploadPackedPost(files []modules.PackedUploadFile, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) (err error) {
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	if erasureCoder != "" {
+		values.Set("erasurecoder", erasureCoder)
+	}
+	if cipherType != "" {
+		values.Set("ciphertype", cipherType)
+	}
+	values.Set("force", strconv.FormatBool(force))
+	data, err := json.Marshal(api.RenterUploadPackedPOST{Files: files})
+	if err != nil {
+		return err
+	}
+	headers := http.Header{"Content-Type": []string{"application/json"}}
+	_, _, err = c.postRawResponseWithHeaders(fmt.Sprintf("/renter/uploadpacked?%s", values.Encode()), bytes.NewReader(data), headers)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (err error) {
@@ -598,11 +692,44 @@ func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (
 
 // RenterUploadStreamPost uploads data using a stream.
 func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, force bool) error {
+	return c.RenterUploadStreamErasureCoderPost(r, siaPath, dataPieces, parityPieces, "", force)
+}
+
+// RenterUploadStreamErasureCoderPost uploads data using a stream and the
+// specified erasure coder. An empty erasureCoder will use the default one.
+func (c *Client) RenterUploadStreamErasureCoderPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder string, force bool) error {
+	return c.RenterUploadStreamCustomPost(r, siaPath, dataPieces, parityPieces, erasureCoder, "", force)
+}
+
+// RenterUploadStreamCustomPost uploads data using a stream, the specified
+// erasure coder and the specified cipher type. An empty erasureCoder or
+// cipherType will use the renter's default.
+func (c *Client) RenterUploadStreamCustomPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) error {
+	return c.renterUploadStreamPost(r, siaPath, dataPieces, parityPieces, erasureCoder, cipherType, force, false)
+}
+
+// RenterUploadStreamDedupPost uploads data using a stream in dedup mode. An
+// empty erasureCoder or cipherType will use the renter's default.
+func (c *Client) RenterUploadStreamDedupPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) error {
+	return c.renterUploadStreamPost(r, siaPath, dataPieces, parityPieces, erasureCoder, cipherType, force, true)
+}
+
+// renterUploadStreamPost uploads data using a stream.
+func (c *Client) renterUploadStreamPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force, dedup bool) error {
 	sp := escapeSiaPath(siaPath)
 	values := url.Values{}
 	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
 	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	if erasureCoder != "" {
+		values.Set("erasurecoder", erasureCoder)
+	}
+	if cipherType != "" {
+		values.Set("ciphertype", cipherType)
+	}
 	values.Set("force", strconv.FormatBool(force))
+	if dedup {
+		values.Set("dedup", strconv.FormatBool(dedup))
+	}
 	values.Set("stream", strconv.FormatBool(true))
 	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
 	return err
@@ -782,3 +909,77 @@ func (c *Client) RenterFileHosts(siaPath modules.SiaPath) (hosts []modules.HostD
 	err = c.get("/renter/hosts/"+sp, &hosts)
 	return
 }
+
+// RenterRegistryGet uses the /renter/registry endpoint to read a registry
+// entry. A timeout of 0 uses the renter's default timeout.
+func (c *Client) RenterRegistryGet(spk types.SiaPublicKey, dataKey crypto.Hash, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+	if timeout > 0 {
+		values.Set("timeout", fmt.Sprint(uint64(timeout.Seconds())))
+	}
+	var rrg api.RenterRegistryGET
+	err := c.get(fmt.Sprintf("/renter/registry?%s", values.Encode()), &rrg)
+	if err != nil {
+		return modules.SignedRegistryValue{}, err
+	}
+	return parseRenterRegistryGET(dataKey, rrg)
+}
+
+// RenterRegistryPost uses the /renter/registry endpoint to update a registry
+// entry.
+func (c *Client) RenterRegistryPost(spk types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+	data, err := json.Marshal(api.RenterRegistryPOST{
+		PublicKey: spk,
+		DataKey:   srv.Tweak,
+		Revision:  srv.Revision,
+		Data:      hex.EncodeToString(srv.Data),
+		Signature: hex.EncodeToString(srv.Signature[:]),
+		Type:      srv.Type,
+	})
+	if err != nil {
+		return err
+	}
+	headers := http.Header{"Content-Type": []string{"application/json"}}
+	_, _, err = c.postRawResponseWithHeaders("/renter/registry", bytes.NewReader(data), headers)
+	return err
+}
+
+// RenterRegistryWatchGet uses the /renter/registry/watch endpoint to wait for
+// a value of a registry entry with a revision number of at least revision. A
+// timeout of 0 uses the renter's default timeout.
+func (c *Client) RenterRegistryWatchGet(spk types.SiaPublicKey, dataKey crypto.Hash, revision uint64, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+	values.Set("revision", fmt.Sprint(revision))
+	if timeout > 0 {
+		values.Set("timeout", fmt.Sprint(uint64(timeout.Seconds())))
+	}
+	var rrg api.RenterRegistryGET
+	err := c.get(fmt.Sprintf("/renter/registry/watch?%s", values.Encode()), &rrg)
+	if err != nil {
+		return modules.SignedRegistryValue{}, err
+	}
+	return parseRenterRegistryGET(dataKey, rrg)
+}
+
+// parseRenterRegistryGET converts the response of the registry endpoints into
+// a registry value.
+func parseRenterRegistryGET(dataKey crypto.Hash, rrg api.RenterRegistryGET) (modules.SignedRegistryValue, error) {
+	data, err := hex.DecodeString(rrg.Data)
+	if err != nil {
+		return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode data")
+	}
+	var sig crypto.Signature
+	sigBytes, err := hex.DecodeString(rrg.Signature)
+	if err != nil {
+		return modules.SignedRegistryValue{}, errors.AddContext(err, "failed to decode signature")
+	}
+	if len(sigBytes) != len(sig) {
+		return modules.SignedRegistryValue{}, errors.New("signature has wrong length")
+	}
+	copy(sig[:], sigBytes)
+	return modules.NewSignedRegistryValue(dataKey, data, rrg.Revision, sig, rrg.Type), nil
+}
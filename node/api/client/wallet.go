@@ -1,10 +1,13 @@
 package client
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 	"gitlab.com/NebulousLabs/errors"
@@ -141,6 +144,29 @@ func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api
 	return
 }
 
+// WalletSiacoinsWithSelectionPost uses the /wallet/siacoins api endpoint to
+// send money to multiple addresses, funding the transaction according to the
+// coin selection.
+func (c *Client) WalletSiacoinsWithSelectionPost(outputs []types.SiacoinOutput, selection modules.CoinSelection) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("outputs", string(marshaledOutputs))
+	values.Set("strategy", string(selection.Strategy))
+	if len(selection.Outputs) > 0 {
+		marshaledInputs, err := json.Marshal(selection.Outputs)
+		if err != nil {
+			return api.WalletSiacoinsPOST{}, err
+		}
+		values.Set("inputs", string(marshaledInputs))
+	}
+	values.Set("replaceable", strconv.FormatBool(selection.Replaceable))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletSiacoinsPost uses the /wallet/siacoins api endpoint to send money to a
 // single address
 func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool) (wsp api.WalletSiacoinsPOST, err error) {
@@ -165,6 +191,71 @@ func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (ws
 	return
 }
 
+// WalletMultisigCombinePost uses the /wallet/multisig/combine endpoint to
+// merge the signatures of multiple copies of a partially signed transaction.
+func (c *Client) WalletMultisigCombinePost(psts []modules.PartiallySignedTransaction) (wmp api.WalletMultisigPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigCombinePOSTParams{
+		PartiallySignedTransactions: psts,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/combine", string(json), &wmp)
+	return
+}
+
+// WalletMultisigCreatePost uses the /wallet/multisig/create endpoint to wrap
+// a transaction into a partially signed transaction.
+func (c *Client) WalletMultisigCreatePost(txn types.Transaction) (wmp api.WalletMultisigPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigCreatePOSTParams{
+		Transaction: txn,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/create", string(json), &wmp)
+	return
+}
+
+// WalletMultisigFinalizePost uses the /wallet/multisig/finalize endpoint to
+// turn a complete partially signed transaction into a signed transaction.
+func (c *Client) WalletMultisigFinalizePost(pst modules.PartiallySignedTransaction) (wmfp api.WalletMultisigFinalizePOSTResp, err error) {
+	json, err := json.Marshal(api.WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/finalize", string(json), &wmfp)
+	return
+}
+
+// WalletMultisigInspectPost uses the /wallet/multisig/inspect endpoint to
+// summarize a partially signed transaction.
+func (c *Client) WalletMultisigInspectPost(pst modules.PartiallySignedTransaction) (summary modules.PartiallySignedTransactionSummary, err error) {
+	json, err := json.Marshal(api.WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/inspect", string(json), &summary)
+	return
+}
+
+// WalletMultisigSignPost uses the /wallet/multisig/sign endpoint to add the
+// signatures of the wallet to a partially signed transaction.
+func (c *Client) WalletMultisigSignPost(pst modules.PartiallySignedTransaction) (wmp api.WalletMultisigPOST, err error) {
+	json, err := json.Marshal(api.WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/sign", string(json), &wmp)
+	return
+}
+
 // WalletSiafundsPost uses the /wallet/siafunds api endpoint to send siafunds
 // to a single address.
 func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiafundsPOST, err error) {
@@ -185,6 +276,78 @@ func (c *Client) WalletSiagKeyPost(keyfiles, password string) (err error) {
 	return
 }
 
+// WalletSubscriptionsGet uses the /wallet/subscriptions endpoint to get the
+// payment subscriptions of the wallet.
+func (c *Client) WalletSubscriptionsGet() (wsg api.WalletSubscriptionsGET, err error) {
+	err = c.get("/wallet/subscriptions", &wsg)
+	return
+}
+
+// WalletSubscriptionsAddPost uses the /wallet/subscriptions/add endpoint to
+// add a payment subscription to the wallet.
+func (c *Client) WalletSubscriptionsAddPost(sub modules.PaymentSubscription) (wsp api.WalletSubscriptionsPOST, err error) {
+	json, err := json.Marshal(sub)
+	if err != nil {
+		return api.WalletSubscriptionsPOST{}, err
+	}
+	err = c.post("/wallet/subscriptions/add", string(json), &wsp)
+	return
+}
+
+// WalletSubscriptionsEventsGet uses the /wallet/subscriptions/events endpoint
+// to stream the payment events of the wallet. If id is set, only the events
+// of that subscription are streamed. The returned channel is closed once the
+// stream ends, and the returned function closes the stream.
+func (c *Client) WalletSubscriptionsEventsGet(id string) (<-chan modules.PaymentEvent, func() error, error) {
+	values := url.Values{}
+	values.Set("id", id)
+	_, body, err := c.getReaderResponse("/wallet/subscriptions/events?" + values.Encode())
+	if err != nil {
+		return nil, nil, err
+	}
+	if body == nil {
+		return nil, nil, errors.New("no stream returned")
+	}
+	events := make(chan modules.PaymentEvent)
+	stop := make(chan struct{})
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event modules.PaymentEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	closeStream := func() (err error) {
+		once.Do(func() {
+			close(stop)
+			err = body.Close()
+		})
+		return
+	}
+	return events, closeStream, nil
+}
+
+// WalletSubscriptionsRemovePost uses the /wallet/subscriptions/remove
+// endpoint to remove a payment subscription from the wallet.
+func (c *Client) WalletSubscriptionsRemovePost(id string) error {
+	values := url.Values{}
+	values.Set("id", id)
+	return c.post("/wallet/subscriptions/remove", values.Encode(), nil)
+}
+
 // WalletSweepPost uses the /wallet/sweep/seed endpoint to sweep a seed into
 // the current wallet.
 func (c *Client) WalletSweepPost(seed string) (wsp api.WalletSweepPOST, err error) {
@@ -209,6 +372,19 @@ func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTra
 	return
 }
 
+// WalletTransactionBumpPost uses the /wallet/transaction/:id/bump endpoint to
+// bump the fee of an unconfirmed transaction. A zero fee lets the wallet pick
+// the fee.
+func (c *Client) WalletTransactionBumpPost(id types.TransactionID, strategy modules.FeeBumpStrategy, fee types.Currency) (wtbp api.WalletTransactionBumpPOST, err error) {
+	values := url.Values{}
+	values.Set("strategy", string(strategy))
+	if !fee.IsZero() {
+		values.Set("fee", fee.String())
+	}
+	err = c.post("/wallet/transaction/"+id.String()+"/bump", values.Encode(), &wtbp)
+	return
+}
+
 // WalletUnlockPost uses the /wallet/unlock endpoint to unlock the wallet with
 // a given encryption key. Per default this key is the seed.
 func (c *Client) WalletUnlockPost(password string) (err error) {
@@ -225,6 +401,18 @@ func (c *Client) WalletUnlockConditionsGet(addr types.UnlockHash) (wucg api.Wall
 	return
 }
 
+// WalletUnlockConditionsPost uses the /wallet/unlockconditions endpoint to add
+// a set of UnlockConditions to the wallet.
+func (c *Client) WalletUnlockConditionsPost(uc types.UnlockConditions) error {
+	json, err := json.Marshal(api.WalletUnlockConditionsPOSTParams{
+		UnlockConditions: uc,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/unlockconditions", string(json), nil)
+}
+
 // WalletUnspentGet requests the /wallet/unspent endpoint and returns all of
 // the unspent outputs related to the wallet.
 func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
@@ -232,6 +420,39 @@ func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
 	return
 }
 
+// WalletFrozenGet requests the /wallet/frozen endpoint and returns the frozen
+// outputs of the wallet.
+func (c *Client) WalletFrozenGet() (wfg api.WalletFrozenGET, err error) {
+	err = c.get("/wallet/frozen", &wfg)
+	return
+}
+
+// WalletFreezePost uses the /wallet/frozen endpoint to freeze a set of outputs
+// so that the wallet doesn't spend them.
+func (c *Client) WalletFreezePost(ids []types.OutputID) error {
+	json, err := json.Marshal(api.WalletFrozenPOST{
+		Outputs: ids,
+		Remove:  false,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/frozen", string(json), nil)
+}
+
+// WalletUnfreezePost uses the /wallet/frozen endpoint to unfreeze a set of
+// outputs.
+func (c *Client) WalletUnfreezePost(ids []types.OutputID) error {
+	json, err := json.Marshal(api.WalletFrozenPOST{
+		Outputs: ids,
+		Remove:  true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/frozen", string(json), nil)
+}
+
 // WalletWatchGet requests the /wallet/watch endpoint and returns the set of
 // currently watched addresses.
 func (c *Client) WalletWatchGet() (wwg api.WalletWatchGET, err error) {
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
 	"go.sia.tech/siad/types"
 )
 
@@ -60,6 +62,15 @@ type (
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
 	}
 
+	// HostBandwidthGET contains the information that is returned after a GET
+	// request to /host/bandwidth. The download, upload and start time are the
+	// host's total bandwidth usage since startup while the breakdown by RPC,
+	// instruction and renter covers the requested range.
+	HostBandwidthGET struct {
+		GatewayBandwidthGET
+		modules.HostBandwidthReport
+	}
+
 	// HostEstimateScoreGET contains the information that is returned from a
 	// /host/estimatescore call.
 	HostEstimateScoreGET struct {
@@ -67,11 +78,49 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostMaintenanceGET contains the information that is returned after a GET
+	// request to /host/maintenance.
+	HostMaintenanceGET struct {
+		modules.HostMaintenanceStatus
+	}
+
+	// HostPricingGET contains the information that is returned after a GET
+	// request to /host/pricing.
+	HostPricingGET struct {
+		modules.HostPricing
+	}
+
+	// HostRegistryGET contains the information that is returned after a GET
+	// request to /host/registry.
+	HostRegistryGET struct {
+		modules.HostRegistryStats
+	}
+
+	// HostRegistryEntryGET contains the information that is returned after a
+	// GET request to /host/registry/entry/:entryid.
+	HostRegistryEntryGET struct {
+		Entry modules.HostRegistryEntry `json:"entry"`
+	}
+
+	// HostRentersGET contains the information that is returned after a GET
+	// request to /host/renters.
+	HostRentersGET struct {
+		Renters []modules.HostRenterUsage `json:"renters"`
+	}
+
+	// HostScrubGET contains the information that is returned after a GET
+	// request to /host/scrub.
+	HostScrubGET struct {
+		modules.HostScrubReport
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
-		Folders []modules.StorageFolderMetadata `json:"folders"`
+		Folders    []modules.StorageFolderMetadata  `json:"folders"`
+		Migrations []modules.StorageFolderMigration `json:"migrations"`
+		Tiering    modules.StorageTieringStatus     `json:"tiering"`
 	}
 )
 
@@ -96,6 +145,32 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/renters", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRentersHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/maintenance", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMaintenanceHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/pricing", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/scrub", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostScrubHandlerGET(h, w, req, ps)
+	})
+
+	// Calls pertaining to the registry of the host.
+	router.GET("/host/registry", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/registry/entry/:entryid", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryEntryHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/registry/delete/:entryid", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryDeleteHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/registry/truncate", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRegistryTruncateHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 
 	// Calls pertaining to the storage manager that the host uses.
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -104,12 +179,18 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/storage/folders/add", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersAddHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/migrate", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersMigrateHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/folders/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersRemoveHandler(h, w, req, ps)
 	}, requiredPassword))
 	router.POST("/host/storage/folders/resize", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersResizeHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/tier", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersTierHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageSectorsDeleteHandler(h, w, req, ps)
 	}, requiredPassword))
@@ -189,20 +270,168 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 }
 
 // hostsBandwidthHandlerGET handles GET requests to the /host/bandwidth API endpoint,
-// returning bandwidth usage data from the host module
-func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+// returning bandwidth usage data from the host module. The optional 'start' and
+// 'end' unix timestamps restrict the breakdown of the bandwidth to a range and
+// default to the beginning of time and the current time.
+func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	start, end := time.Unix(0, 0), time.Now()
+	if startStr := req.FormValue("start"); startStr != "" {
+		timestamp, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `start` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(timestamp, 0)
+	}
+	if endStr := req.FormValue("end"); endStr != "" {
+		timestamp, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `end` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(timestamp, 0)
+	}
+
 	sent, receive, startTime, err := host.BandwidthCounters()
 	if err != nil {
 		WriteError(w, Error{"failed to get hosts's bandwidth usage: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-	WriteJSON(w, GatewayBandwidthGET{
-		Download:  receive,
-		Upload:    sent,
-		StartTime: startTime,
+	report, err := host.BandwidthUsage(start, end)
+	if err != nil {
+		WriteError(w, Error{"failed to get hosts's bandwidth usage: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostBandwidthGET{
+		GatewayBandwidthGET: GatewayBandwidthGET{
+			Download:  receive,
+			Upload:    sent,
+			StartTime: startTime,
+		},
+		HostBandwidthReport: report,
 	})
 }
 
+// hostRentersHandlerGET handles GET requests to the /host/renters API
+// endpoint, returning the resources used by each renter of the host.
+func hostRentersHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	renters, err := host.RenterUsage()
+	if err != nil {
+		WriteError(w, Error{"failed to get renter usage: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostRentersGET{renters})
+}
+
+// hostPricingHandlerGET handles GET requests to the /host/pricing API endpoint,
+// returning the state of the host's dynamic pricing. The settings in the query
+// string are used for a dry run without changing the host's settings.
+func hostPricingHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := parseHostSettings(host, req)
+	if err != nil {
+		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pricing, err := host.Pricing(settings)
+	if err != nil {
+		WriteError(w, Error{"failed to get pricing: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostPricingGET{pricing})
+}
+
+// hostMaintenanceHandlerGET handles GET requests to the /host/maintenance API
+// endpoint, returning whether the host is in maintenance mode and which
+// storage obligations still need to be proven before it can be shut down.
+func hostMaintenanceHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := host.MaintenanceStatus()
+	if err != nil {
+		WriteError(w, Error{"failed to get maintenance status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostMaintenanceGET{status})
+}
+
+// hostScrubHandlerGET handles GET requests to the /host/scrub API endpoint,
+// returning the status of the sector scrubber and the storage obligations at
+// risk due to corrupted sectors.
+func hostScrubHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := host.ScrubReport()
+	if err != nil {
+		WriteError(w, Error{"failed to get scrub report: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostScrubGET{report})
+}
+
+// hostRegistryHandlerGET handles GET requests to the /host/registry API
+// endpoint, returning information about the usage of the host's registry.
+func hostRegistryHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	stats, err := host.RegistryStats()
+	if err != nil {
+		WriteError(w, Error{"failed to get registry stats: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostRegistryGET{stats})
+}
+
+// hostRegistryEntryHandlerGET handles GET requests to the
+// /host/registry/entry/:entryid API endpoint, returning a single entry of the
+// host's registry.
+func hostRegistryEntryHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	entryID, err := scanHash(ps.ByName("entryid"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	entry, err := host.RegistryEntry(modules.RegistryEntryID(entryID))
+	if errors.Is(err, registry.ErrEntryNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to get registry entry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostRegistryEntryGET{Entry: entry})
+}
+
+// hostRegistryDeleteHandlerPOST handles the call to delete an entry from the
+// host's registry.
+func hostRegistryDeleteHandlerPOST(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	entryID, err := scanHash(ps.ByName("entryid"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = host.RegistryDelete(modules.RegistryEntryID(entryID))
+	if errors.Is(err, registry.ErrEntryNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to delete registry entry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRegistryTruncateHandlerPOST handles the call to prune the expired entries
+// of the host's registry and resize it.
+func hostRegistryTruncateHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	size, err := strconv.ParseUint(req.FormValue("size"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'size' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = host.RegistryTruncate(size)
+	if err != nil {
+		WriteError(w, Error{"failed to truncate registry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -366,6 +595,129 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	if req.FormValue("maxrenteraccounts") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrenteraccounts"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterAccounts = x
+	}
+	if req.FormValue("maxrenterbandwidth") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrenterbandwidth"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterBandwidth = x
+	}
+	if req.FormValue("maxrenterregistryentries") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrenterregistryentries"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterRegistryEntries = x
+	}
+	if req.FormValue("maxrentersectors") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrentersectors"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterSectors = x
+	}
+	if req.FormValue("pricingenabled") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("pricingenabled"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.Enabled = x
+	}
+	if req.FormValue("pricingexchangerate") != "" {
+		settings.PricingPolicy.ExchangeRate = req.FormValue("pricingexchangerate")
+	}
+	if req.FormValue("pricingtargetdownloadbandwidthprice") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingtargetdownloadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.TargetDownloadBandwidthPrice = x
+	}
+	if req.FormValue("pricingtargetstorageprice") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingtargetstorageprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.TargetStoragePrice = x
+	}
+	if req.FormValue("pricingtargetuploadbandwidthprice") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingtargetuploadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.TargetUploadBandwidthPrice = x
+	}
+	if req.FormValue("pricingbandwidthcapacity") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("pricingbandwidthcapacity"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.BandwidthCapacity = x
+	}
+	if req.FormValue("pricingmaxpricemultiplier") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingmaxpricemultiplier"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.MaxPriceMultiplier = x
+	}
+	if req.FormValue("pricingsmoothing") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("pricingsmoothing"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PricingPolicy.Smoothing = x
+	}
+	if req.FormValue("scrubrate") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("scrubrate"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ScrubRate = x
+	}
+	if req.FormValue("maintenancemode") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("maintenancemode"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceMode = x
+	}
+	if req.FormValue("maintenancewindowstart") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("maintenancewindowstart"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceWindowStart = x
+	}
+	if req.FormValue("maintenancewindowend") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("maintenancewindowend"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceWindowEnd = x
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -478,7 +830,9 @@ func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Req
 // the host.
 func storageHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	WriteJSON(w, StorageGET{
-		Folders: host.StorageFolders(),
+		Folders:    host.StorageFolders(),
+		Migrations: host.StorageFolderMigrations(),
+		Tiering:    host.TieringStatus(),
 	})
 }
 
@@ -499,6 +853,55 @@ func storageFoldersAddHandler(host modules.Host, w http.ResponseWriter, req *htt
 	WriteSuccess(w)
 }
 
+// storageFoldersMigrateHandler migrates the sectors of a storage folder into
+// another storage folder in the storage manager.
+func storageFoldersMigrateHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	destinationPath := req.FormValue("destination")
+	if destinationPath == "" {
+		WriteError(w, Error{"destination parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := host.StorageFolders()
+	source, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	destination, err := folderIndex(destinationPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	var maxBandwidth, maxIOPS uint64
+	if req.FormValue("maxbandwidth") != "" {
+		_, err = fmt.Sscan(req.FormValue("maxbandwidth"), &maxBandwidth)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'maxbandwidth' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("maxiops") != "" {
+		_, err = fmt.Sscan(req.FormValue("maxiops"), &maxIOPS)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'maxiops' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err = host.MigrateStorageFolder(uint16(source), uint16(destination), maxBandwidth, maxIOPS)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersResizeHandler resizes a storage folder in the storage manager.
 func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -528,6 +931,34 @@ func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *
 	WriteSuccess(w)
 }
 
+// storageFoldersTierHandler sets the tier of a storage folder in the storage
+// manager.
+func storageFoldersTierHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	tier := modules.StorageTier(req.FormValue("tier"))
+	if tier == "" {
+		WriteError(w, Error{"tier parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = host.SetStorageFolderTier(uint16(folderIndex), tier)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -27,6 +29,18 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// ErasureCoderReedSolomon is the value of the 'erasurecoder' parameter
+	// which selects the classic reed solomon erasure coder. It is the default
+	// and supports up to 256 pieces per chunk.
+	ErasureCoderReedSolomon = "reedsolomon"
+
+	// ErasureCoderLeopard is the value of the 'erasurecoder' parameter which
+	// selects the leopard erasure coder. It supports wide stripes of up to
+	// 65536 pieces per chunk.
+	ErasureCoderLeopard = "leopard"
+)
+
 var (
 	// requiredHosts specifies the minimum number of hosts that must be set in
 	// the renter settings for the renter settings to be valid. This minimum is
@@ -72,6 +86,10 @@ var (
 	// erasure coding parameters is set
 	errNeedBothDataAndParityPieces = errors.New("must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters")
 
+	// errUnknownErasureCoder is the error returned when the erasurecoder
+	// parameter is set to an unknown value
+	errUnknownErasureCoder = fmt.Errorf("erasurecoder must be either '%v' or '%v'", ErasureCoderReedSolomon, ErasureCoderLeopard)
+
 	// ErrFundsNeedToBeSet is the error returned when the funds are not set for
 	// the allowance
 	ErrFundsNeedToBeSet = errors.New("funds needs to be set if it hasn't been set before")
@@ -188,6 +206,13 @@ type (
 		MountPoints []modules.MountInfo `json:"mountpoints"`
 	}
 
+	// RenterRekeyGET contains the status of the files which are being
+	// re-encrypted and of the files which were re-encrypted since the renter
+	// started.
+	RenterRekeyGET struct {
+		Rekeys []modules.RekeyInfo `json:"rekeys"`
+	}
+
 	// RenterLoad lists files that were loaded into the renter.
 	RenterLoad struct {
 		FilesAdded []string `json:"filesadded"`
@@ -205,6 +230,25 @@ type (
 		ScanInProgress bool              `json:"scaninprogress"`
 		ScannedHeight  types.BlockHeight `json:"scannedheight"`
 	}
+	// RenterRegistryGET is the response returned by the /renter/registry and
+	// /renter/registry/watch GET endpoints. The data and signature are hex
+	// encoded.
+	RenterRegistryGET struct {
+		Data      string                    `json:"data"`
+		Revision  uint64                    `json:"revision"`
+		Signature string                    `json:"signature"`
+		Type      modules.RegistryEntryType `json:"type"`
+	}
+	// RenterRegistryPOST is the request body of the /renter/registry POST
+	// endpoint. The data and signature are hex encoded.
+	RenterRegistryPOST struct {
+		PublicKey types.SiaPublicKey        `json:"publickey"`
+		DataKey   crypto.Hash               `json:"datakey"`
+		Revision  uint64                    `json:"revision"`
+		Data      string                    `json:"data"`
+		Signature string                    `json:"signature"`
+		Type      modules.RegistryEntryType `json:"type"`
+	}
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -226,6 +270,11 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterUploadPackedPOST contains the files of a packed upload.
+	RenterUploadPackedPOST struct {
+		Files []modules.PackedUploadFile `json:"files"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -595,15 +644,23 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 // parseErasureCodingParameters parses the supplied string values and creates
 // an erasure coder. If values haven't been supplied it will fill in sane
 // defaults.
-func parseErasureCodingParameters(strDataPieces, strParityPieces string) (modules.ErasureCoder, error) {
+func parseErasureCodingParameters(strDataPieces, strParityPieces, strErasureCoder string) (modules.ErasureCoder, error) {
 	// Parse data and parity pieces
 	dataPieces, parityPieces, err := ParseDataAndParityPieces(strDataPieces, strParityPieces)
 	if err != nil {
 		return nil, err
 	}
 
+	// Check that the erasure coder is known.
+	if strErasureCoder != "" && strErasureCoder != ErasureCoderReedSolomon && strErasureCoder != ErasureCoderLeopard {
+		return nil, errUnknownErasureCoder
+	}
+
 	// Check if data and parity pieces were set
 	if dataPieces == 0 && parityPieces == 0 {
+		if strErasureCoder != "" {
+			return nil, errors.New("must provide the datapieces and paritypieces parameters when specifying an erasure coder")
+		}
 		return nil, nil
 	}
 
@@ -620,9 +677,29 @@ func parseErasureCodingParameters(strDataPieces, strParityPieces string) (module
 	}
 
 	// Create the erasure coder.
+	if strErasureCoder == ErasureCoderLeopard {
+		if dataPieces+parityPieces > modules.MaxLeopardPieces {
+			return nil, fmt.Errorf("the leopard erasure coder supports at most %v pieces, but %v pieces requested", modules.MaxLeopardPieces, dataPieces+parityPieces)
+		}
+		return modules.NewLeopardCode(dataPieces, parityPieces)
+	}
 	return modules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
 }
 
+// parseCipherType parses the supplied cipher type. If no cipher type was
+// supplied, the zero value is returned which tells the renter to use its
+// default cipher type.
+func parseCipherType(strCipherType string) (crypto.CipherType, error) {
+	var ct crypto.CipherType
+	if strCipherType == "" {
+		return ct, nil
+	}
+	if err := ct.FromString(strCipherType); err != nil {
+		return crypto.CipherType{}, err
+	}
+	return ct, nil
+}
+
 // ParseDataAndParityPieces parse the numeric values for dataPieces and
 // parityPieces from the input strings
 func ParseDataAndParityPieces(strDataPieces, strParityPieces string) (dataPieces, parityPieces int, err error) {
@@ -959,6 +1036,16 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.IPViolationCheck = ipviolationcheck
 	}
 
+	// Scan the default cipher type. (optional parameter)
+	if dct := req.FormValue("defaultciphertype"); dct != "" {
+		var ct crypto.CipherType
+		if err := ct.FromString(dct); err != nil {
+			WriteError(w, Error{"unable to parse defaultciphertype: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.DefaultCipherType = ct.String()
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
@@ -1340,6 +1427,148 @@ func (api *API) renterDownloadByUIDHandlerGET(w http.ResponseWriter, _ *http.Req
 	})
 }
 
+// parseRegistryEntryQuery parses the public key and data key of a registry
+// entry from the query string of a request.
+func parseRegistryEntryQuery(req *http.Request) (types.SiaPublicKey, crypto.Hash, error) {
+	var spk types.SiaPublicKey
+	err := spk.LoadString(req.FormValue("publickey"))
+	if err != nil {
+		return types.SiaPublicKey{}, crypto.Hash{}, errors.AddContext(err, "unable to parse 'publickey' parameter")
+	}
+	var dataKey crypto.Hash
+	err = dataKey.LoadString(req.FormValue("datakey"))
+	if err != nil {
+		return types.SiaPublicKey{}, crypto.Hash{}, errors.AddContext(err, "unable to parse 'datakey' parameter")
+	}
+	return spk, dataKey, nil
+}
+
+// parseRegistryTimeout parses the optional timeout in seconds of a registry
+// request. If no timeout is provided, the default is returned.
+func parseRegistryTimeout(req *http.Request, defaultTimeout time.Duration) (time.Duration, error) {
+	timeoutStr := req.FormValue("timeout")
+	if timeoutStr == "" {
+		return defaultTimeout, nil
+	}
+	timeoutInt, err := strconv.ParseUint(timeoutStr, 10, 32)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to parse 'timeout' parameter")
+	}
+	timeout := time.Duration(timeoutInt) * time.Second
+	if timeout == 0 || timeout > defaultTimeout {
+		return 0, fmt.Errorf("'timeout' parameter has to be between 1 and %v seconds", uint64(defaultTimeout.Seconds()))
+	}
+	return timeout, nil
+}
+
+// newRenterRegistryGET creates the response of the registry GET endpoints
+// from a registry value.
+func newRenterRegistryGET(srv modules.SignedRegistryValue) RenterRegistryGET {
+	return RenterRegistryGET{
+		Data:      hex.EncodeToString(srv.Data),
+		Revision:  srv.Revision,
+		Signature: hex.EncodeToString(srv.Signature[:]),
+		Type:      srv.Type,
+	}
+}
+
+// renterRegistryHandlerGET handles the API call to read a registry entry.
+func (api *API) renterRegistryHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	spk, dataKey, err := parseRegistryEntryQuery(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	timeout, err := parseRegistryTimeout(req, renter.MaxRegistryReadTimeout)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	srv, err := api.renter.ReadRegistry(spk, dataKey, timeout)
+	if errors.Contains(err, renter.ErrRegistryEntryNotFound) || errors.Contains(err, renter.ErrRegistryLookupTimeout) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to read registry entry: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, newRenterRegistryGET(srv))
+}
+
+// renterRegistryHandlerPOST handles the API call to update a registry entry.
+func (api *API) renterRegistryHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var rrp RenterRegistryPOST
+	err := json.NewDecoder(req.Body).Decode(&rrp)
+	if err != nil {
+		WriteError(w, Error{"unable to decode request body: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	data, err := hex.DecodeString(rrp.Data)
+	if err != nil {
+		WriteError(w, Error{"unable to decode data: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var sig crypto.Signature
+	sigBytes, err := hex.DecodeString(rrp.Signature)
+	if err != nil || len(sigBytes) != len(sig) {
+		WriteError(w, Error{"signature has to be a hex encoded ed25519 signature"}, http.StatusBadRequest)
+		return
+	}
+	copy(sig[:], sigBytes)
+	if rrp.Type == modules.RegistryTypeInvalid {
+		rrp.Type = modules.RegistryTypeWithoutPubkey
+	}
+	srv := modules.NewSignedRegistryValue(rrp.DataKey, data, rrp.Revision, sig, rrp.Type)
+	if err := srv.Verify(rrp.PublicKey.ToPublicKey()); err != nil {
+		WriteError(w, Error{"invalid registry entry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.UpdateRegistry(rrp.PublicKey, srv, renter.DefaultRegistryUpdateTimeout)
+	if modules.IsRegistryEntryExistErr(err) {
+		WriteError(w, Error{"failed to update registry entry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to update registry entry: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRegistryWatchHandlerGET handles the API call to wait for an update of
+// a registry entry.
+func (api *API) renterRegistryWatchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	spk, dataKey, err := parseRegistryEntryQuery(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var revision uint64
+	if revisionStr := req.FormValue("revision"); revisionStr != "" {
+		revision, err = strconv.ParseUint(revisionStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'revision' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	timeout, err := parseRegistryTimeout(req, renter.MaxRegistryWatchTimeout)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	srv, err := api.renter.WatchRegistry(spk, dataKey, revision, timeout)
+	if errors.Contains(err, renter.ErrRegistryWatchTimeout) {
+		WriteError(w, Error{err.Error()}, http.StatusRequestTimeout)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to watch registry entry: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, newRenterRegistryGET(srv))
+}
+
 // renterFuseHandlerGET handles the API call to /renter/fuse.
 func (api *API) renterFuseHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	rfi := RenterFuseInfo{
@@ -1357,6 +1586,30 @@ func (api *API) renterFuseHandlerGET(w http.ResponseWriter, _ *http.Request, _ h
 	WriteJSON(w, rfi)
 }
 
+// renterRekeyHandlerGET handles the API call to /renter/rekey. Unless root is
+// set, only files in the user folder are returned and their siapaths are
+// relative to the user folder.
+func (api *API) renterRekeyHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	rrg := RenterRekeyGET{
+		Rekeys: []modules.RekeyInfo{},
+	}
+	for _, info := range api.renter.RekeyInfo() {
+		if !root {
+			info.SiaPath, err = info.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+			if err != nil {
+				continue
+			}
+		}
+		rrg.Rekeys = append(rrg.Rekeys, info)
+	}
+	WriteJSON(w, rrg)
+}
+
 // renterFuseMountHandlerPOST handles the API call to /renter/fuse/mount.
 func (api *API) renterFuseMountHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var siaPath modules.SiaPath
@@ -1523,6 +1776,20 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
 	stuck := req.FormValue("stuck")
+	rekey, err := scanBool(req.FormValue("rekey"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse rekey flag: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	ct, err := parseCipherType(req.FormValue("ciphertype"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'ciphertype' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !rekey && ct != (crypto.CipherType{}) {
+		WriteError(w, Error{"'ciphertype' can only be provided together with 'rekey'"}, http.StatusBadRequest)
+		return
+	}
 	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
 		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
@@ -1559,6 +1826,15 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle re-encrypting a file under a new master key. The file is
+	// re-encrypted in the background, the progress is reported by
+	// /renter/rekey.
+	if rekey {
+		if err := api.renter.RekeyFile(siaPath, ct); err != nil {
+			WriteError(w, Error{"failed to rekey file: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1943,12 +2219,27 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Check whether the file should be uploaded in dedup mode.
+	dedup := false
+	if d := req.FormValue("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Parse the erasure coder.
-	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"), req.FormValue("erasurecoder"))
 	if err != nil {
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// Parse the cipher type.
+	ct, err := parseCipherType(req.FormValue("ciphertype"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'ciphertype' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -1967,9 +2258,75 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		CipherType:          ct,
+		Dedup:               dedup,
+	})
+	if err != nil {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterUploadPackedHandler handles the API call to upload multiple small
+// files which are packed into shared chunks.
+func (api *API) renterUploadPackedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the files. The body is decoded before the query string is parsed
+	// to make sure that it isn't mistaken for form values.
+	var params RenterUploadPackedPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing files should be overwritten
+	force := false
+	if f := req.FormValue("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"), req.FormValue("erasurecoder"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the cipher type.
+	ct, err := parseCipherType(req.FormValue("ciphertype"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'ciphertype' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if len(params.Files) == 0 {
+		WriteError(w, Error{"no files provided"}, http.StatusBadRequest)
+		return
+	}
+	for i, file := range params.Files {
+		// Source must be absolute path.
+		if !filepath.IsAbs(file.Source) {
+			WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+			return
+		}
+		if err := file.SiaPath.Validate(false); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		params.Files[i].SiaPath, err = rebaseInputSiaPath(file.SiaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+	// Call the renter to upload the files.
+	err = api.renter.UploadPacked(modules.PackedUploadParams{
+		Files:       params.Files,
+		ErasureCode: ec,
+		Force:       force,
+		CipherType:  ct,
 	})
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
@@ -2071,7 +2428,7 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		}
 	}
 	// Parse the erasure coder.
-	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
+	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"), queryForm.Get("erasurecoder"))
 	if err != nil && !repair {
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -2080,6 +2437,29 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	// Parse the cipher type.
+	ct, err := parseCipherType(queryForm.Get("ciphertype"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'ciphertype' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if repair && ct != (crypto.CipherType{}) {
+		WriteError(w, Error{"can't provide a cipher type when doing a repair"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether the file should be uploaded in dedup mode.
+	dedup := false
+	if d := queryForm.Get("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if repair && dedup {
+		WriteError(w, Error{"can't enable dedup mode when doing a repair"}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -2097,9 +2477,8 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
-
-		// NOTE: can make this an optional param.
-		CipherType: crypto.TypeDefaultRenter,
+		CipherType:  ct,
+		Dedup:       dedup,
 	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
 	if err != nil {
@@ -1672,3 +1672,60 @@ func TestHealthLoop(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestParseErasureCodingParameters tests parsing the erasure coding
+// parameters of the upload endpoints.
+func TestParseErasureCodingParameters(t *testing.T) {
+	// No parameters should result in the default erasure coder.
+	ec, err := parseErasureCodingParameters("", "", "")
+	if err != nil || ec != nil {
+		t.Fatal("expected default erasure coder", ec, err)
+	}
+	// Specifying only the erasure coder should fail.
+	_, err = parseErasureCodingParameters("", "", ErasureCoderLeopard)
+	if err == nil {
+		t.Fatal("expected error when only the erasure coder is set")
+	}
+	// An unknown erasure coder should fail.
+	_, err = parseErasureCodingParameters("10", "20", "unknown")
+	if !errors.Contains(err, errUnknownErasureCoder) {
+		t.Fatal("expected errUnknownErasureCoder but got", err)
+	}
+	// The reed solomon coder is the default.
+	for _, coder := range []string{"", ErasureCoderReedSolomon} {
+		ec, err = parseErasureCodingParameters("10", "20", coder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ec.Type() != modules.ECReedSolomonSubShards64 {
+			t.Fatal("wrong erasure coder type", ec.Type())
+		}
+	}
+	// The reed solomon coder doesn't support wide stripes.
+	_, err = parseErasureCodingParameters("100", "300", ErasureCoderReedSolomon)
+	if err == nil {
+		t.Fatal("expected reed solomon coder to fail for wide stripes")
+	}
+	// The leopard coder does.
+	ec, err = parseErasureCodingParameters("100", "300", ErasureCoderLeopard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ec.Type() != modules.ECLeopardGF16 {
+		t.Fatal("wrong erasure coder type", ec.Type())
+	}
+	if ec.MinPieces() != 100 || ec.NumPieces() != 400 {
+		t.Fatal("wrong number of pieces", ec.MinPieces(), ec.NumPieces())
+	}
+	// The leopard coder is limited to the number of pieces a siafile can
+	// store.
+	parity := modules.MaxLeopardPieces - 1000
+	_, err = parseErasureCodingParameters("1000", fmt.Sprint(parity), ErasureCoderLeopard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseErasureCodingParameters("1000", fmt.Sprint(parity+1), ErasureCoderLeopard)
+	if err == nil {
+		t.Fatal("expected leopard coder to fail for too many pieces")
+	}
+}
@@ -20,6 +20,15 @@ var (
 		Dev:      1 * time.Hour,
 		Testing:  5 * time.Minute,
 	}).(time.Duration)
+
+	// paymentEventsKeepAlive is the interval at which a comment is sent on
+	// an idle stream of payment events.
+	paymentEventsKeepAlive = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
 )
 
 // buildHttpRoutes sets up and returns an * httprouter.Router.
@@ -46,6 +55,11 @@ func (api *API) buildHTTPRoutes() {
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
 
+	// Accounting API Calls
+	if api.accounting != nil {
+		RegisterRoutesAccounting(router, api.accounting)
+	}
+
 	// Consensus API Calls
 	if api.cs != nil {
 		RegisterRoutesConsensus(router, api.cs)
@@ -97,7 +111,11 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
+		router.GET("/renter/registry", api.renterRegistryHandlerGET)
+		router.POST("/renter/registry", RequirePassword(api.renterRegistryHandlerPOST, requiredPassword))
+		router.GET("/renter/registry/watch", api.renterRegistryWatchHandlerGET)
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.GET("/renter/rekey", api.renterRekeyHandlerGET)
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
 		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
 		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
@@ -109,6 +127,7 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/uploadpacked", RequirePassword(api.renterUploadPackedHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
@@ -144,7 +163,7 @@ func (api *API) buildHTTPRoutes() {
 
 	// Wallet API Calls
 	if api.wallet != nil {
-		RegisterRoutesWallet(router, api.wallet, requiredPassword)
+		RegisterRoutesWallet(router, api.wallet, requiredPassword, api.staticStreamsClosed)
 	}
 
 	// Apply UserAgent middleware and return the Router
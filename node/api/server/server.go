@@ -22,6 +22,8 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/node/s3"
+	"go.sia.tech/siad/node/webdav"
 	"go.sia.tech/siad/types"
 )
 
@@ -35,6 +37,12 @@ type Server struct {
 	requiredUserAgent string
 	Dir               string
 
+	s3Listener net.Listener
+	s3Server   *http.Server
+
+	webdavListener net.Listener
+	webdavServer   *http.Server
+
 	serveChan chan struct{}
 	serveErr  error
 
@@ -61,7 +69,16 @@ func (srv *Server) Close() error {
 	srv.closeMu.Lock()
 	defer srv.closeMu.Unlock()
 	// Stop accepting API requests.
+	srv.api.CloseStreams()
 	err := srv.apiServer.Shutdown(context.Background())
+	// Stop accepting S3 requests.
+	if srv.s3Server != nil {
+		err = errors.Compose(err, srv.s3Server.Shutdown(context.Background()))
+	}
+	// Stop accepting WebDAV requests.
+	if srv.webdavServer != nil {
+		err = errors.Compose(err, srv.webdavServer.Shutdown(context.Background()))
+	}
 	// Wait for serve() to return and capture its error.
 	<-srv.serveChan
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
@@ -116,6 +133,82 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// S3Address returns the address of the S3 gateway or an empty string if the
+// gateway isn't served.
+func (srv *Server) S3Address() string {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.s3Listener == nil {
+		return ""
+	}
+	return srv.s3Listener.Addr().String()
+}
+
+// ServeS3 starts serving an S3 compatible gateway for the node's renter on the
+// provided address. Requests need to be signed using the provided keys. The
+// gateway is shut down together with the server.
+func (srv *Server) ServeS3(addr, accessKey, secretKey string) error {
+	if srv.node.Renter == nil {
+		return errors.New("can't serve the s3 gateway for a non-renter node")
+	}
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.s3Server != nil {
+		return errors.New("s3 gateway is already being served")
+	}
+	gateway, err := s3.New(srv.node.Renter, accessKey, secretKey, filepath.Join(srv.node.Dir, modules.RenterDir, "s3"))
+	if err != nil {
+		return errors.AddContext(err, "unable to create s3 gateway")
+	}
+	srv.s3Listener, srv.s3Server, err = serveHandler(addr, gateway, "s3 gateway")
+	return err
+}
+
+// WebDAVAddress returns the address of the WebDAV server or an empty string if
+// the renter's files aren't served using WebDAV.
+func (srv *Server) WebDAVAddress() string {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.webdavListener == nil {
+		return ""
+	}
+	return srv.webdavListener.Addr().String()
+}
+
+// ServeWebDAV starts serving the files of the node's renter using WebDAV on the
+// provided address. Requests need to provide the password using HTTP basic
+// auth. The WebDAV server is shut down together with the server.
+func (srv *Server) ServeWebDAV(addr, password string) error {
+	if srv.node.Renter == nil {
+		return errors.New("can't serve webdav for a non-renter node")
+	}
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.webdavServer != nil {
+		return errors.New("webdav is already being served")
+	}
+	var err error
+	srv.webdavListener, srv.webdavServer, err = serveHandler(addr, webdav.New(srv.node.Renter, password), "webdav server")
+	return err
+}
+
+// serveHandler starts serving the handler on the provided address in a
+// separate goroutine.
+func serveHandler(addr string, handler http.Handler, name string) (net.Listener, *http.Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, fmt.Sprintf("unable to listen for %v requests", name))
+	}
+	hs := &http.Server{Handler: handler}
+	go func() {
+		err := hs.Serve(l)
+		if err != nil && !errors.Contains(err, http.ErrServerClosed) {
+			fmt.Printf("%v stopped unexpectedly: %v\n", name, err)
+		}
+	}()
+	return l, hs, nil
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
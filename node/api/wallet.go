@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
@@ -80,6 +81,29 @@ type (
 		Transaction types.Transaction `json:"transaction"`
 	}
 
+	// WalletMultisigCreatePOSTParams contains the transaction to wrap into a
+	// partially signed transaction.
+	WalletMultisigCreatePOSTParams struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
+	// WalletMultisigCombinePOSTParams contains the copies of a partially
+	// signed transaction to combine.
+	WalletMultisigCombinePOSTParams struct {
+		PartiallySignedTransactions []modules.PartiallySignedTransaction `json:"partiallysignedtransactions"`
+	}
+
+	// WalletMultisigPOST contains a partially signed transaction. It is used
+	// as the parameters and response of the /wallet/multisig endpoints.
+	WalletMultisigPOST struct {
+		PartiallySignedTransaction modules.PartiallySignedTransaction `json:"partiallysignedtransaction"`
+	}
+
+	// WalletMultisigFinalizePOSTResp contains the finalized transaction.
+	WalletMultisigFinalizePOSTResp struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -94,6 +118,13 @@ type (
 		Funds types.Currency `json:"funds"`
 	}
 
+	// WalletTransactionBumpPOST contains the transaction set created by a
+	// call to /wallet/transaction/:id/bump.
+	WalletTransactionBumpPOST struct {
+		Transactions   []types.Transaction   `json:"transactions"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
 	// WalletTransactionGETid contains the transaction returned by a call to
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
@@ -145,6 +176,29 @@ type (
 		Valid bool `json:"valid"`
 	}
 
+	// WalletFrozenGET contains the outputs which are frozen in the wallet.
+	WalletFrozenGET struct {
+		Outputs []types.OutputID `json:"outputs"`
+	}
+
+	// WalletFrozenPOST contains the set of outputs to freeze or unfreeze.
+	WalletFrozenPOST struct {
+		Outputs []types.OutputID `json:"outputs"`
+		Remove  bool             `json:"remove"`
+	}
+
+	// WalletSubscriptionsGET contains the payment subscriptions of the
+	// wallet.
+	WalletSubscriptionsGET struct {
+		Subscriptions []modules.PaymentSubscription `json:"subscriptions"`
+	}
+
+	// WalletSubscriptionsPOST contains a payment subscription which was added
+	// to the wallet, including its id and secret.
+	WalletSubscriptionsPOST struct {
+		Subscription modules.PaymentSubscription `json:"subscription"`
+	}
+
 	// WalletWatchPOST contains the set of addresses to add or remove from the
 	// watch set.
 	WalletWatchPOST struct {
@@ -161,7 +215,7 @@ type (
 )
 
 // RegisterRoutesWallet is a helper function to register all wallet routes.
-func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requiredPassword string) {
+func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requiredPassword string, stop <-chan struct{}) {
 	router.GET("/wallet", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletHandler(wallet, w, req, ps)
 	})
@@ -180,6 +234,12 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletBackupHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/frozen", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletFrozenHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/frozen", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletFrozenHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -189,6 +249,21 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/lock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletLockHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/multisig/combine", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigCombineHandler(w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/create", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigCreateHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/finalize", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigFinalizeHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/inspect", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigInspectHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/multisig/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletMultisigSignHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -204,12 +279,27 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/siagkey", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSiagkeyHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/subscriptions", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSubscriptionsHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/subscriptions/add", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSubscriptionsAddHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/subscriptions/events", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSubscriptionsEventsHandler(wallet, stop, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/subscriptions/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletSubscriptionsRemoveHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/sweep/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSweepSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
 	router.GET("/wallet/transaction/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionHandler(wallet, w, req, ps)
 	})
+	router.POST("/wallet/transaction/:id/bump", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletTransactionBumpHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletTransactionsHandler(wallet, w, req, ps)
 	})
@@ -581,6 +671,31 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional coin selection.
+	selection := modules.CoinSelection{
+		Strategy: modules.CoinSelectionStrategy(req.FormValue("strategy")),
+	}
+	switch selection.Strategy {
+	case "", modules.CoinSelectionLargestFirst, modules.CoinSelectionSmallestFirst, modules.CoinSelectionPrivacy, modules.CoinSelectionBranchAndBound:
+	default:
+		WriteError(w, Error{"unknown coin selection strategy: " + string(selection.Strategy)}, http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("inputs") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("inputs")), &selection.Outputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode inputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	replaceable, err := scanBool(req.FormValue("replaceable"))
+	if err != nil {
+		WriteError(w, Error{"could not read replaceable from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+		return
+	}
+	selection.Replaceable = replaceable
+	coinControl := selection.Strategy != "" || len(selection.Outputs) > 0 || selection.Replaceable
+
 	var txns []types.Transaction
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
@@ -595,7 +710,11 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
+		if coinControl {
+			txns, err = wallet.SendSiacoinsWithSelection(outputs, selection)
+		} else {
+			txns, err = wallet.SendSiacoinsMulti(outputs)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -618,7 +737,13 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			return
 		}
 
-		if feeIncluded {
+		if feeIncluded && coinControl {
+			WriteError(w, Error{"cannot combine feeIncluded with 'strategy', 'inputs' or 'replaceable'"}, http.StatusBadRequest)
+			return
+		}
+		if coinControl {
+			txns, err = wallet.SendSiacoinsWithSelection([]types.SiacoinOutput{{Value: amount, UnlockHash: dest}}, selection)
+		} else if feeIncluded {
 			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
 		} else {
 			txns, err = wallet.SendSiacoins(amount, dest)
@@ -716,6 +841,52 @@ func walletTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, _ *h
 	})
 }
 
+// walletTransactionBumpHandler handles API calls to
+// /wallet/transaction/:id/bump.
+func walletTransactionBumpHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the id from the url.
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id/bump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the strategy and the optional fee.
+	strategy := modules.FeeBumpStrategy(req.FormValue("strategy"))
+	if strategy == "" {
+		strategy = modules.FeeBumpRBF
+	}
+	if strategy != modules.FeeBumpRBF && strategy != modules.FeeBumpCPFP {
+		WriteError(w, Error{fmt.Sprintf("strategy must be %v or %v", modules.FeeBumpRBF, modules.FeeBumpCPFP)}, http.StatusBadRequest)
+		return
+	}
+	var fee types.Currency
+	if f := req.FormValue("fee"); f != "" {
+		var ok bool
+		fee, ok = scanAmount(f)
+		if !ok {
+			WriteError(w, Error{"could not read fee from POST call to /wallet/transaction/id/bump"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	txns, err := wallet.BumpTransaction(id, strategy, fee)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id/bump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletTransactionBumpPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
 // walletTransactionsHandler handles API calls to /wallet/transactions.
 func walletTransactionsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
@@ -927,6 +1098,234 @@ func walletSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	})
 }
 
+// walletMultisigCombineHandler handles API calls to /wallet/multisig/combine.
+func walletMultisigCombineHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigCombinePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := modules.CombinePartiallySignedTransactions(params.PartiallySignedTransactions...)
+	if err != nil {
+		WriteError(w, Error{"failed to combine partially signed transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+}
+
+// walletMultisigCreateHandler handles API calls to /wallet/multisig/create.
+func walletMultisigCreateHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigCreatePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := wallet.CreatePartiallySignedTransaction(params.Transaction)
+	if err != nil {
+		WriteError(w, Error{"failed to create partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+}
+
+// walletMultisigFinalizeHandler handles API calls to
+// /wallet/multisig/finalize.
+func walletMultisigFinalizeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, err := wallet.FinalizePartiallySignedTransaction(params.PartiallySignedTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to finalize partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigFinalizePOSTResp{
+		Transaction: txn,
+	})
+}
+
+// walletMultisigInspectHandler handles API calls to /wallet/multisig/inspect.
+func walletMultisigInspectHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	summary, err := wallet.InspectPartiallySignedTransaction(params.PartiallySignedTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to inspect partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, summary)
+}
+
+// walletMultisigSignHandler handles API calls to /wallet/multisig/sign.
+func walletMultisigSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pst, err := wallet.SignPartiallySignedTransaction(params.PartiallySignedTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to sign partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		PartiallySignedTransaction: pst,
+	})
+}
+
+// walletFrozenHandlerGET handles GET calls to /wallet/frozen.
+func walletFrozenHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	outputs, err := wallet.FrozenOutputs()
+	if err != nil {
+		WriteError(w, Error{"failed to get frozen outputs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletFrozenGET{
+		Outputs: outputs,
+	})
+}
+
+// walletFrozenHandlerPOST handles POST calls to /wallet/frozen.
+func walletFrozenHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var wfp WalletFrozenPOST
+	err := json.NewDecoder(req.Body).Decode(&wfp)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if wfp.Remove {
+		err = wallet.UnfreezeOutputs(wfp.Outputs)
+	} else {
+		err = wallet.FreezeOutputs(wfp.Outputs)
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to update frozen outputs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletSubscriptionsHandlerGET handles GET calls to /wallet/subscriptions.
+func walletSubscriptionsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	subs, err := wallet.PaymentSubscriptions()
+	if err != nil {
+		WriteError(w, Error{"failed to get payment subscriptions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSubscriptionsGET{
+		Subscriptions: subs,
+	})
+}
+
+// walletSubscriptionsAddHandler handles POST calls to
+// /wallet/subscriptions/add.
+func walletSubscriptionsAddHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var sub modules.PaymentSubscription
+	err := json.NewDecoder(req.Body).Decode(&sub)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sub, err = wallet.AddPaymentSubscription(sub)
+	if err != nil {
+		WriteError(w, Error{"failed to add payment subscription: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSubscriptionsPOST{
+		Subscription: sub,
+	})
+}
+
+// walletSubscriptionsEventsHandler handles GET calls to
+// /wallet/subscriptions/events. The payment events are streamed as
+// server-sent events until the client disconnects or the API shuts down.
+func walletSubscriptionsEventsHandler(wallet modules.Wallet, stop <-chan struct{}, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, Error{"streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+	id := req.FormValue("id")
+	if id != "" {
+		subs, err := wallet.PaymentSubscriptions()
+		if err != nil {
+			WriteError(w, Error{"failed to get payment subscriptions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		found := false
+		for _, sub := range subs {
+			found = found || sub.ID == id
+		}
+		if !found {
+			WriteError(w, Error{"unknown payment subscription " + id}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, unsubscribe := wallet.SubscribePaymentEvents()
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Send a comment from time to time so that dead connections are noticed.
+	keepAlive := time.NewTicker(paymentEventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if id != "" && event.SubscriptionID != id {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %v\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-req.Context().Done():
+			return
+		case <-stop:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// walletSubscriptionsRemoveHandler handles POST calls to
+// /wallet/subscriptions/remove.
+func walletSubscriptionsRemoveHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := wallet.RemovePaymentSubscription(req.FormValue("id"))
+	if err != nil {
+		WriteError(w, Error{"failed to remove payment subscription: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletWatchHandlerGET handles GET calls to /wallet/watch.
 func walletWatchHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	addrs, err := wallet.WatchAddresses()
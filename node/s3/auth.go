@@ -0,0 +1,486 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// signV4Algorithm is the only supported signing algorithm.
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// signV4ChunkAlgorithm is the algorithm used for the signatures of the
+	// chunks of a streaming payload.
+	signV4ChunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// unsignedPayload is the payload hash of requests which don't sign their
+	// payload.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// streamingPayload is the payload hash of requests which sign every chunk
+	// of their aws-chunked encoded payload.
+	streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+	// amzDateFormat is the format of the X-Amz-Date header.
+	amzDateFormat = "20060102T150405Z"
+
+	// maxClockSkew is the maximum difference between the time of a request
+	// and the local time.
+	maxClockSkew = 15 * time.Minute
+
+	// maxPresignedExpiry is the maximum lifetime of a presigned request.
+	maxPresignedExpiry = 7 * 24 * time.Hour
+
+	// maxChunkSize is the maximum size of a single chunk of an aws-chunked
+	// encoded payload.
+	maxChunkSize = 1 << 26 // 64 MiB
+)
+
+var (
+	// emptySHA256 is the hex encoded sha256 hash of an empty payload.
+	emptySHA256 = hex.EncodeToString(sha256Sum(nil))
+)
+
+type (
+	// signature contains the fields of a signed request which are required to
+	// verify it.
+	signature struct {
+		accessKey     string
+		amzDate       string
+		scope         string
+		signedHeaders []string
+		signature     string
+		signingKey    []byte
+	}
+
+	// chunkedReader decodes an aws-chunked encoded payload and verifies the
+	// signature of every chunk if a signing key is provided.
+	chunkedReader struct {
+		closer       io.Closer
+		r            *bufio.Reader
+		decodedLen   int64
+		read         int64
+		buf          []byte
+		done         bool
+		err          error
+		amzDate      string
+		scope        string
+		prevSig      string
+		key          []byte
+		verifyChunks bool
+	}
+
+	// sha256Reader verifies that the sha256 hash of the data read from the
+	// underlying reader matches the expected hash.
+	sha256Reader struct {
+		io.ReadCloser
+		expected []byte
+		h        hash.Hash
+	}
+)
+
+// staticAuthenticate verifies the signature of a request and returns the body
+// of the request which needs to be read instead of req.Body. The returned body
+// returns an error if the payload doesn't match its signature.
+func (g *Gateway) staticAuthenticate(req *http.Request) (io.ReadCloser, error) {
+	contentSHA := req.Header.Get("X-Amz-Content-Sha256")
+	if g.staticSecretKey == "" {
+		// Authentication is disabled, the payload still needs to be decoded.
+		if contentSHA == streamingPayload {
+			return newChunkedReader(req, signature{}, false)
+		}
+		return req.Body, nil
+	}
+
+	// Parse the signature.
+	var sig signature
+	var err error
+	var payloadHash string
+	query := req.URL.Query()
+	switch auth := req.Header.Get("Authorization"); {
+	case strings.HasPrefix(auth, signV4Algorithm+" "):
+		sig, err = parseAuthorizationHeader(req, auth)
+		payloadHash = contentSHA
+		if payloadHash == "" {
+			return nil, errMissingContentSHA256
+		}
+	case auth != "":
+		return nil, errUnsupportedSignatureVersion
+	case query.Get("X-Amz-Algorithm") == signV4Algorithm:
+		sig, err = parsePresignedQuery(query)
+		payloadHash = unsignedPayload
+		if contentSHA != "" {
+			payloadHash = contentSHA
+		}
+	case query.Get("X-Amz-Algorithm") != "" || query.Get("AWSAccessKeyId") != "":
+		return nil, errUnsupportedSignatureVersion
+	default:
+		return nil, errAccessDenied
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sig.accessKey != g.staticAccessKey {
+		return nil, errInvalidAccessKeyID
+	}
+
+	// Verify the signature.
+	scopeParts := strings.Split(sig.scope, "/")
+	sig.signingKey = signingKey(g.staticSecretKey, scopeParts[0], scopeParts[1], scopeParts[2])
+	cr := canonicalRequest(req, sig.signedHeaders, payloadHash)
+	expected := hex.EncodeToString(hmacSHA256(sig.signingKey, stringToSign(sig.amzDate, sig.scope, cr)))
+	if !hmac.Equal([]byte(expected), []byte(sig.signature)) {
+		return nil, errSignatureDoesNotMatch
+	}
+
+	// Verify the payload while it is read.
+	switch payloadHash {
+	case unsignedPayload:
+		return req.Body, nil
+	case streamingPayload:
+		return newChunkedReader(req, sig, true)
+	}
+	expectedHash, err := hex.DecodeString(payloadHash)
+	if err != nil || len(expectedHash) != sha256.Size {
+		return nil, errUnsupportedContentEncoding
+	}
+	return &sha256Reader{
+		ReadCloser: req.Body,
+		expected:   expectedHash,
+		h:          sha256.New(),
+	}, nil
+}
+
+// parseAuthorizationHeader parses the Authorization header of a request which
+// was signed using AWS Signature Version 4.
+func parseAuthorizationHeader(req *http.Request, auth string) (signature, error) {
+	var sig signature
+	var credential, signedHeaders string
+	for _, field := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			return signature{}, errAuthorizationHeaderInvalid
+		}
+		switch kv[0] {
+		case "Credential":
+			credential = kv[1]
+		case "SignedHeaders":
+			signedHeaders = kv[1]
+		case "Signature":
+			sig.signature = kv[1]
+		}
+	}
+	if credential == "" || signedHeaders == "" || sig.signature == "" {
+		return signature{}, errAuthorizationHeaderInvalid
+	}
+	var err error
+	sig.accessKey, sig.scope, err = parseCredential(credential)
+	if err != nil {
+		return signature{}, err
+	}
+	sig.signedHeaders = strings.Split(signedHeaders, ";")
+
+	// Check the time of the request.
+	sig.amzDate = req.Header.Get("X-Amz-Date")
+	t, err := time.Parse(amzDateFormat, sig.amzDate)
+	if err != nil {
+		// Fall back to the Date header.
+		t, err = http.ParseTime(req.Header.Get("Date"))
+		if err != nil {
+			return signature{}, errAccessDenied
+		}
+		sig.amzDate = t.UTC().Format(amzDateFormat)
+	}
+	if !strings.HasPrefix(sig.scope, sig.amzDate[:8]+"/") {
+		return signature{}, errAuthorizationHeaderInvalid
+	}
+	if skew := time.Since(t); skew > maxClockSkew || skew < -maxClockSkew {
+		return signature{}, errRequestTimeTooSkewed
+	}
+	return sig, nil
+}
+
+// parsePresignedQuery parses the query of a presigned request which was signed
+// using AWS Signature Version 4.
+func parsePresignedQuery(query map[string][]string) (signature, error) {
+	get := func(key string) string {
+		if values := query[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	var sig signature
+	var err error
+	sig.accessKey, sig.scope, err = parseCredential(get("X-Amz-Credential"))
+	if err != nil {
+		return signature{}, err
+	}
+	sig.signature = get("X-Amz-Signature")
+	sig.signedHeaders = strings.Split(get("X-Amz-SignedHeaders"), ";")
+	sig.amzDate = get("X-Amz-Date")
+	if sig.signature == "" || len(sig.signedHeaders) == 0 {
+		return signature{}, errAuthorizationHeaderInvalid
+	}
+
+	// Check that the request didn't expire yet.
+	t, err := time.Parse(amzDateFormat, sig.amzDate)
+	if err != nil || !strings.HasPrefix(sig.scope, sig.amzDate[:8]+"/") {
+		return signature{}, errAuthorizationHeaderInvalid
+	}
+	expires, err := strconv.ParseUint(get("X-Amz-Expires"), 10, 64)
+	if err != nil || time.Duration(expires)*time.Second > maxPresignedExpiry {
+		return signature{}, errAuthorizationHeaderInvalid
+	}
+	if time.Until(t) > maxClockSkew {
+		return signature{}, errRequestTimeTooSkewed
+	}
+	if time.Since(t) > time.Duration(expires)*time.Second {
+		return signature{}, errExpiredRequest
+	}
+	return sig, nil
+}
+
+// parseCredential splits the credential of a signature into the access key and
+// the scope of the signature.
+func parseCredential(credential string) (accessKey, scope string, _ error) {
+	parts := strings.Split(credential, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || len(parts[1]) != 8 {
+		return "", "", errAuthorizationHeaderInvalid
+	}
+	return parts[0], strings.Join(parts[1:], "/"), nil
+}
+
+// canonicalRequest returns the canonical form of a request which is signed by
+// the client.
+func canonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	// Collect and sort the query parameters. The signature of a presigned
+	// request is not part of the canonical request.
+	type param struct{ key, value string }
+	var params []param
+	for key, values := range req.URL.Query() {
+		if key == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, param{uriEncode(key, true), uriEncode(value, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].key != params[j].key {
+			return params[i].key < params[j].key
+		}
+		return params[i].value < params[j].value
+	})
+	query := make([]string, 0, len(params))
+	for _, p := range params {
+		query = append(query, p.key+"="+p.value)
+	}
+
+	// Collect the signed headers.
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		var value string
+		switch name {
+		case "host":
+			value = req.Host
+		case "content-length":
+			value = req.Header.Get("Content-Length")
+			if value == "" && req.ContentLength >= 0 {
+				value = strconv.FormatInt(req.ContentLength, 10)
+			}
+		default:
+			values := req.Header.Values(name)
+			for i := range values {
+				values[i] = strings.Join(strings.Fields(values[i]), " ")
+			}
+			value = strings.Join(values, ",")
+		}
+		headers.WriteString(name + ":" + value + "\n")
+	}
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		strings.Join(query, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// stringToSign returns the string which is signed by the client for a
+// canonical request.
+func stringToSign(amzDate, scope, canonicalRequest string) string {
+	return strings.Join([]string{
+		signV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(sha256Sum([]byte(canonicalRequest))),
+	}, "\n")
+}
+
+// signingKey derives the key which is used to sign requests within the scope
+// of the provided date, region and service.
+func signingKey(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data using the provided key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sha256Sum returns the sha256 hash of data.
+func sha256Sum(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+// uriEncode encodes s as described by the AWS Signature Version 4
+// specification. All characters except for unreserved ones are percent
+// encoded. Slashes are only encoded if encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexChars = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexChars[c>>4])
+			b.WriteByte(hexChars[c&15])
+		}
+	}
+	return b.String()
+}
+
+// newChunkedReader creates a reader which decodes the aws-chunked encoded body
+// of a request. If verify is true, the signature of every chunk is verified
+// using the request's signature as the seed.
+func newChunkedReader(req *http.Request, sig signature, verify bool) (*chunkedReader, error) {
+	decodedLen, err := strconv.ParseInt(req.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+	if err != nil || decodedLen < 0 {
+		return nil, errMissingContentLength
+	}
+	return &chunkedReader{
+		closer:       req.Body,
+		r:            bufio.NewReader(req.Body),
+		decodedLen:   decodedLen,
+		amzDate:      sig.amzDate,
+		scope:        sig.scope,
+		prevSig:      sig.signature,
+		key:          sig.signingKey,
+		verifyChunks: verify,
+	}, nil
+}
+
+// Read implements io.Reader.
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	for len(cr.buf) == 0 && !cr.done && cr.err == nil {
+		cr.err = cr.readChunk()
+	}
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	if len(cr.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, cr.buf)
+	cr.buf = cr.buf[n:]
+	return n, nil
+}
+
+// Close implements io.Closer.
+func (cr *chunkedReader) Close() error {
+	return cr.closer.Close()
+}
+
+// readChunk reads and verifies the next chunk of the payload.
+func (cr *chunkedReader) readChunk() error {
+	// Parse the chunk's header.
+	line, err := cr.r.ReadString('\n')
+	if err != nil {
+		return errIncompleteBody
+	}
+	header := strings.SplitN(strings.TrimSuffix(line, "\r\n"), ";", 2)
+	size, err := strconv.ParseInt(header[0], 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errIncompleteBody
+	}
+	var chunkSig string
+	if len(header) == 2 {
+		chunkSig = strings.TrimPrefix(header[1], "chunk-signature=")
+	}
+
+	// Read the chunk's data followed by its CRLF.
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return errIncompleteBody
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		return errIncompleteBody
+	}
+	data = data[:size]
+
+	// Verify the chunk's signature.
+	if cr.verifyChunks {
+		sts := strings.Join([]string{
+			signV4ChunkAlgorithm,
+			cr.amzDate,
+			cr.scope,
+			cr.prevSig,
+			emptySHA256,
+			hex.EncodeToString(sha256Sum(data)),
+		}, "\n")
+		expected := hex.EncodeToString(hmacSHA256(cr.key, sts))
+		if !hmac.Equal([]byte(expected), []byte(chunkSig)) {
+			return errSignatureDoesNotMatch
+		}
+		cr.prevSig = chunkSig
+	}
+
+	cr.read += size
+	if cr.read > cr.decodedLen {
+		return errIncompleteBody
+	}
+	if size == 0 {
+		if cr.read != cr.decodedLen {
+			return errIncompleteBody
+		}
+		cr.done = true
+	}
+	cr.buf = data
+	return nil
+}
+
+// Read implements io.Reader.
+func (sr *sha256Reader) Read(p []byte) (int, error) {
+	n, err := sr.ReadCloser.Read(p)
+	sr.h.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(sr.h.Sum(nil), sr.expected) {
+		return n, errContentSHA256Mismatch
+	}
+	return n, err
+}
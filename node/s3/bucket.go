@@ -0,0 +1,154 @@
+package s3
+
+import (
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// timeFormat is the format of the timestamps within XML responses.
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+var (
+	// validBucketName matches the names of buckets which can be created. It
+	// follows the naming rules of S3.
+	validBucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+	// gatewayOwner is the owner reported for all buckets and objects.
+	gatewayOwner = owner{ID: "sia", DisplayName: "sia"}
+)
+
+type (
+	// owner is the owner of a bucket or object.
+	owner struct {
+		ID          string `xml:"ID"`
+		DisplayName string `xml:"DisplayName"`
+	}
+
+	// bucketInfo describes a single bucket.
+	bucketInfo struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+
+	// listAllMyBucketsResult is the response of a ListBuckets request.
+	listAllMyBucketsResult struct {
+		XMLName xml.Name     `xml:"ListAllMyBucketsResult"`
+		Xmlns   string       `xml:"xmlns,attr"`
+		Owner   owner        `xml:"Owner"`
+		Buckets []bucketInfo `xml:"Buckets>Bucket"`
+	}
+
+	// locationConstraint is the response of a GetBucketLocation request.
+	locationConstraint struct {
+		XMLName  xml.Name `xml:"LocationConstraint"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string   `xml:",chardata"`
+	}
+)
+
+// listBucketsHandler handles ListBuckets requests.
+func (g *Gateway) listBucketsHandler(w http.ResponseWriter, req *http.Request) {
+	dirs, err := g.staticRenter.DirList(g.staticRoot)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	resp := listAllMyBucketsResult{
+		Xmlns:   s3Namespace,
+		Owner:   gatewayOwner,
+		Buckets: []bucketInfo{},
+	}
+	for _, dir := range dirs {
+		// The first entry is the user folder itself.
+		if dir.SiaPath.Equals(g.staticRoot) {
+			continue
+		}
+		resp.Buckets = append(resp.Buckets, bucketInfo{
+			Name:         dir.SiaPath.Name(),
+			CreationDate: formatTime(dir.MostRecentModTime),
+		})
+	}
+	sort.Slice(resp.Buckets, func(i, j int) bool {
+		return resp.Buckets[i].Name < resp.Buckets[j].Name
+	})
+	writeXML(w, http.StatusOK, resp)
+}
+
+// getBucketLocationHandler handles GetBucketLocation requests. The gateway
+// reports the default location for all buckets.
+func (g *Gateway) getBucketLocationHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	writeXML(w, http.StatusOK, locationConstraint{Xmlns: s3Namespace})
+}
+
+// headBucketHandler handles HeadBucket requests.
+func (g *Gateway) headBucketHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// createBucketHandler handles CreateBucket requests.
+func (g *Gateway) createBucketHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	if !validBucketName.MatchString(bucket) || strings.Contains(bucket, "..") {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	sp, err := g.staticBucketSiaPath(bucket)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	err = g.staticRenter.CreateDir(sp, defaultDirMode)
+	if errors.Contains(err, filesystem.ErrExists) {
+		writeError(w, req, errBucketAlreadyOwnedByYou)
+		return
+	} else if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBucketHandler handles DeleteBucket requests. Only empty buckets can be
+// deleted.
+func (g *Gateway) deleteBucketHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	sp, err := g.staticBucketSiaPath(bucket)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	objects, err := g.staticListObjects(sp)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if len(objects) > 0 {
+		writeError(w, req, errBucketNotEmpty)
+		return
+	}
+	if err := g.staticRenter.DeleteDir(sp); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// formatTime formats a timestamp of an XML response.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
@@ -0,0 +1,116 @@
+package s3
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+type (
+	// apiError is an error which is returned to the client using the error
+	// codes of the S3 API.
+	apiError struct {
+		Code       string
+		Message    string
+		StatusCode int
+	}
+
+	// errorResponse is the XML body of an error response.
+	errorResponse struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		Resource  string   `xml:"Resource"`
+		RequestID string   `xml:"RequestId"`
+	}
+)
+
+var (
+	errAccessDenied                = apiError{"AccessDenied", "Access Denied", http.StatusForbidden}
+	errAuthorizationHeaderInvalid  = apiError{"AuthorizationHeaderMalformed", "The authorization header is malformed", http.StatusBadRequest}
+	errBadDigest                   = apiError{"BadDigest", "The Content-MD5 you specified did not match what we received", http.StatusBadRequest}
+	errBucketAlreadyOwnedByYou     = apiError{"BucketAlreadyOwnedByYou", "The bucket already exists", http.StatusConflict}
+	errBucketNotEmpty              = apiError{"BucketNotEmpty", "The bucket you tried to delete is not empty", http.StatusConflict}
+	errContentSHA256Mismatch       = apiError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed", http.StatusBadRequest}
+	errExpiredRequest              = apiError{"AccessDenied", "Request has expired", http.StatusForbidden}
+	errIncompleteBody              = apiError{"IncompleteBody", "The request body is incomplete", http.StatusBadRequest}
+	errInvalidAccessKeyID          = apiError{"InvalidAccessKeyId", "The access key ID you provided does not exist in our records", http.StatusForbidden}
+	errInvalidArgument             = apiError{"InvalidArgument", "Invalid argument", http.StatusBadRequest}
+	errInvalidBucketName           = apiError{"InvalidBucketName", "The specified bucket is not valid", http.StatusBadRequest}
+	errInvalidDigest               = apiError{"InvalidDigest", "The Content-MD5 you specified is not valid", http.StatusBadRequest}
+	errInvalidKey                  = apiError{"InvalidArgument", "The specified key is not valid", http.StatusBadRequest}
+	errInvalidPart                 = apiError{"InvalidPart", "One or more of the specified parts could not be found", http.StatusBadRequest}
+	errInvalidPartOrder            = apiError{"InvalidPartOrder", "The list of parts was not in ascending order", http.StatusBadRequest}
+	errMalformedXML                = apiError{"MalformedXML", "The XML you provided was not well-formed", http.StatusBadRequest}
+	errMethodNotAllowed            = apiError{"MethodNotAllowed", "The specified method is not allowed against this resource", http.StatusMethodNotAllowed}
+	errMissingContentLength        = apiError{"MissingContentLength", "You must provide the Content-Length HTTP header", http.StatusLengthRequired}
+	errMissingContentSHA256        = apiError{"InvalidRequest", "Missing required header for this request: x-amz-content-sha256", http.StatusBadRequest}
+	errNoSuchBucket                = apiError{"NoSuchBucket", "The specified bucket does not exist", http.StatusNotFound}
+	errNoSuchKey                   = apiError{"NoSuchKey", "The specified key does not exist", http.StatusNotFound}
+	errNoSuchUpload                = apiError{"NoSuchUpload", "The specified multipart upload does not exist", http.StatusNotFound}
+	errNotImplemented              = apiError{"NotImplemented", "A header or query you provided implies functionality that is not implemented", http.StatusNotImplemented}
+	errRequestTimeTooSkewed        = apiError{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large", http.StatusForbidden}
+	errSignatureDoesNotMatch       = apiError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided", http.StatusForbidden}
+	errUnsupportedContentEncoding  = apiError{"NotImplemented", "The provided 'x-amz-content-sha256' header is not supported", http.StatusNotImplemented}
+	errUnsupportedSignatureVersion = apiError{"InvalidRequest", "Only AWS Signature Version 4 is supported", http.StatusBadRequest}
+)
+
+// bodyErrors are the errors returned while reading the body of a request. They
+// might be wrapped by the renter when they are encountered during an upload.
+var bodyErrors = []apiError{
+	errBadDigest,
+	errContentSHA256Mismatch,
+	errIncompleteBody,
+	errSignatureDoesNotMatch,
+}
+
+// Error implements the error interface.
+func (e apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// randomID returns a random hex encoded identifier.
+func randomID() string {
+	return hex.EncodeToString(fastrand.Bytes(16))
+}
+
+// writeError writes an error response. Errors which aren't S3 API errors are
+// returned as internal errors.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	e, ok := err.(apiError)
+	for _, bodyErr := range bodyErrors {
+		if !ok && errors.Contains(err, bodyErr) {
+			e, ok = bodyErr, true
+		}
+	}
+	if !ok {
+		e = apiError{"InternalError", err.Error(), http.StatusInternalServerError}
+	}
+	if req.Method == http.MethodHead {
+		// Responses to HEAD requests don't have a body.
+		w.WriteHeader(e.StatusCode)
+		return
+	}
+	writeXML(w, e.StatusCode, errorResponse{
+		Code:      e.Code,
+		Message:   e.Message,
+		Resource:  req.URL.Path,
+		RequestID: randomID(),
+	})
+}
+
+// writeXML writes an XML response with the given status code.
+func writeXML(w http.ResponseWriter, statusCode int, v interface{}) {
+	encoded, err := xml.Marshal(v)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		encoded, _ = xml.Marshal(errorResponse{Code: "InternalError", Message: err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(encoded)
+}
@@ -0,0 +1,199 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultMaxKeys is the maximum number of keys returned by a single
+	// ListObjects request.
+	defaultMaxKeys = 1000
+)
+
+type (
+	// objectContents describes an object within the response of a ListObjects
+	// request.
+	objectContents struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         uint64 `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+		Owner        *owner `xml:"Owner,omitempty"`
+	}
+
+	// commonPrefix is a prefix shared by multiple keys which were rolled up
+	// into a single result.
+	commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+
+	// listBucketResult is the response of a ListObjects and ListObjectsV2
+	// request. The fields which are specific to either version are omitted
+	// if they are empty.
+	listBucketResult struct {
+		XMLName               xml.Name         `xml:"ListBucketResult"`
+		Xmlns                 string           `xml:"xmlns,attr"`
+		Name                  string           `xml:"Name"`
+		Prefix                string           `xml:"Prefix"`
+		Marker                *string          `xml:"Marker,omitempty"`
+		NextMarker            string           `xml:"NextMarker,omitempty"`
+		StartAfter            string           `xml:"StartAfter,omitempty"`
+		ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+		KeyCount              *int             `xml:"KeyCount,omitempty"`
+		MaxKeys               int              `xml:"MaxKeys"`
+		Delimiter             string           `xml:"Delimiter,omitempty"`
+		EncodingType          string           `xml:"EncodingType,omitempty"`
+		IsTruncated           bool             `xml:"IsTruncated"`
+		Contents              []objectContents `xml:"Contents"`
+		CommonPrefixes        []commonPrefix   `xml:"CommonPrefixes"`
+	}
+)
+
+// listObjectsHandler handles ListObjects and ListObjectsV2 requests.
+func (g *Gateway) listObjectsHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	query := req.URL.Query()
+	v2 := query.Get("list-type") == "2"
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	maxKeys := defaultMaxKeys
+	if mk := query.Get("max-keys"); mk != "" {
+		var err error
+		maxKeys, err = strconv.Atoi(mk)
+		if err != nil || maxKeys < 0 {
+			writeError(w, req, errInvalidArgument)
+			return
+		}
+		if maxKeys > defaultMaxKeys {
+			maxKeys = defaultMaxKeys
+		}
+	}
+
+	// Determine the key after which the listing starts.
+	var marker, continuationToken string
+	if v2 {
+		marker = query.Get("start-after")
+		continuationToken = query.Get("continuation-token")
+		if continuationToken != "" {
+			decoded, err := base64.StdEncoding.DecodeString(continuationToken)
+			if err != nil {
+				writeError(w, req, errInvalidArgument)
+				return
+			}
+			marker = string(decoded)
+		}
+	} else {
+		marker = query.Get("marker")
+	}
+
+	sp, err := g.staticBucketSiaPath(bucket)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	objects, err := g.staticListObjects(sp)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	contents, prefixes, truncated, next := listObjects(objects, prefix, delimiter, marker, maxKeys)
+
+	// Build the response.
+	encode := func(s string) string {
+		if encodingType == "url" {
+			return url.QueryEscape(s)
+		}
+		return s
+	}
+	resp := listBucketResult{
+		Xmlns:        s3Namespace,
+		Name:         bucket,
+		Prefix:       encode(prefix),
+		MaxKeys:      maxKeys,
+		Delimiter:    encode(delimiter),
+		EncodingType: encodingType,
+		IsTruncated:  truncated,
+	}
+	if v2 {
+		keyCount := len(contents) + len(prefixes)
+		resp.KeyCount = &keyCount
+		resp.StartAfter = encode(query.Get("start-after"))
+		resp.ContinuationToken = continuationToken
+		if next != "" {
+			resp.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(next))
+		}
+	} else {
+		resp.Marker = &marker
+		resp.NextMarker = encode(next)
+	}
+	for _, obj := range contents {
+		oc := objectContents{
+			Key:          encode(obj.Key),
+			LastModified: formatTime(obj.ModTime),
+			ETag:         obj.ETag,
+			Size:         obj.Size,
+			StorageClass: "STANDARD",
+		}
+		if !v2 || query.Get("fetch-owner") == "true" {
+			oc.Owner = &gatewayOwner
+		}
+		resp.Contents = append(resp.Contents, oc)
+	}
+	for _, p := range prefixes {
+		resp.CommonPrefixes = append(resp.CommonPrefixes, commonPrefix{Prefix: encode(p)})
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+// listObjects returns up to maxKeys objects and common prefixes of the sorted
+// objects which have the provided prefix and come after the marker. Keys which
+// contain the delimiter after the prefix are rolled up into a common prefix.
+// If the listing is truncated, the last returned key or common prefix is
+// returned as the marker of the next page.
+func listObjects(objects []object, prefix, delimiter, marker string, maxKeys int) (contents []object, prefixes []string, truncated bool, next string) {
+	// If the marker is a common prefix, all the keys starting with it were
+	// already rolled up.
+	skipPrefix := delimiter != "" && strings.HasPrefix(marker, prefix) && strings.HasSuffix(marker, delimiter)
+
+	var last string
+	for _, obj := range objects {
+		if obj.Key <= marker || !strings.HasPrefix(obj.Key, prefix) {
+			continue
+		}
+		if skipPrefix && strings.HasPrefix(obj.Key, marker) {
+			continue
+		}
+		// Check whether the key is rolled up into a common prefix.
+		var cp string
+		if delimiter != "" {
+			if i := strings.Index(obj.Key[len(prefix):], delimiter); i >= 0 {
+				cp = obj.Key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if cp != "" && len(prefixes) > 0 && prefixes[len(prefixes)-1] == cp {
+			continue
+		}
+		if len(contents)+len(prefixes) == maxKeys {
+			return contents, prefixes, true, last
+		}
+		if cp != "" {
+			prefixes = append(prefixes, cp)
+			last = cp
+		} else {
+			contents = append(contents, obj)
+			last = obj.Key
+		}
+	}
+	return contents, prefixes, false, ""
+}
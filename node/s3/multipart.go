@@ -0,0 +1,406 @@
+package s3
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxPartNumber is the highest part number of a multipart upload.
+	maxPartNumber = 10000
+
+	// uploadFile is the name of the file within the dir of a multipart upload
+	// which describes the upload.
+	uploadFile = "upload.json"
+)
+
+type (
+	// multipartUpload describes an upload which was initiated but not yet
+	// completed or aborted. It is stored on disk next to the upload's parts.
+	multipartUpload struct {
+		Bucket    string    `json:"bucket"`
+		Key       string    `json:"key"`
+		Initiated time.Time `json:"initiated"`
+	}
+
+	// uploadPart is a part of a multipart upload which was stored on disk.
+	uploadPart struct {
+		number  int
+		md5     string
+		modTime time.Time
+		path    string
+		size    int64
+	}
+
+	// initiateMultipartUploadResult is the response of a
+	// CreateMultipartUpload request.
+	initiateMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}
+
+	// completeMultipartUpload is the body of a CompleteMultipartUpload
+	// request.
+	completeMultipartUpload struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+
+	// completeMultipartUploadResult is the response of a
+	// CompleteMultipartUpload request.
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}
+
+	// partInfo describes a part within the response of a ListParts request.
+	partInfo struct {
+		PartNumber   int    `xml:"PartNumber"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+	}
+
+	// listPartsResult is the response of a ListParts request.
+	listPartsResult struct {
+		XMLName     xml.Name   `xml:"ListPartsResult"`
+		Xmlns       string     `xml:"xmlns,attr"`
+		Bucket      string     `xml:"Bucket"`
+		Key         string     `xml:"Key"`
+		UploadID    string     `xml:"UploadId"`
+		MaxParts    int        `xml:"MaxParts"`
+		IsTruncated bool       `xml:"IsTruncated"`
+		Parts       []partInfo `xml:"Part"`
+	}
+)
+
+// partFileName returns the name of the file which stores a part with the given
+// number and hex encoded md5 hash.
+func partFileName(number int, partMD5 string) string {
+	return fmt.Sprintf("part-%05d-%s", number, partMD5)
+}
+
+// parsePartFileName parses the name of a file which stores a part.
+func parsePartFileName(name string) (number int, partMD5 string, ok bool) {
+	fields := strings.Split(name, "-")
+	if len(fields) != 3 || fields[0] != "part" {
+		return 0, "", false
+	}
+	number, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return number, fields[2], true
+}
+
+// staticUploadDir returns the dir of a multipart upload after verifying that
+// the upload exists and belongs to the object.
+func (g *Gateway) staticUploadDir(bucket, key, uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || len(uploadID) != 32 {
+		return "", errNoSuchUpload
+	}
+	dir := filepath.Join(g.staticMultipartDir, uploadID)
+	b, err := ioutil.ReadFile(filepath.Join(dir, uploadFile))
+	if os.IsNotExist(err) {
+		return "", errNoSuchUpload
+	} else if err != nil {
+		return "", errors.AddContext(err, "unable to read multipart upload")
+	}
+	var mu multipartUpload
+	if err := json.Unmarshal(b, &mu); err != nil {
+		return "", errors.AddContext(err, "unable to decode multipart upload")
+	}
+	if mu.Bucket != bucket || mu.Key != key {
+		return "", errNoSuchUpload
+	}
+	return dir, nil
+}
+
+// staticUploadParts returns the parts of the multipart upload stored in dir,
+// sorted by their part numbers.
+func staticUploadParts(dir string) ([]uploadPart, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, errNoSuchUpload
+	} else if err != nil {
+		return nil, errors.AddContext(err, "unable to read parts of multipart upload")
+	}
+	var parts []uploadPart
+	for _, fi := range fis {
+		number, partMD5, ok := parsePartFileName(fi.Name())
+		if !ok {
+			continue
+		}
+		parts = append(parts, uploadPart{
+			number:  number,
+			md5:     partMD5,
+			modTime: fi.ModTime(),
+			path:    filepath.Join(dir, fi.Name()),
+			size:    fi.Size(),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].number < parts[j].number
+	})
+	return parts, nil
+}
+
+// createMultipartUploadHandler handles CreateMultipartUpload requests.
+func (g *Gateway) createMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if _, err := g.staticObjectSiaPath(bucket, key); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	b, err := json.Marshal(multipartUpload{
+		Bucket:    bucket,
+		Key:       key,
+		Initiated: time.Now(),
+	})
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	// Prepare the upload within a temporary dir which is moved into place
+	// once it is complete.
+	uploadID := randomID()
+	tmpDir := filepath.Join(g.staticMultipartDir, uploadID+".tmp")
+	if err := os.Mkdir(tmpDir, 0700); err != nil {
+		writeError(w, req, errors.AddContext(err, "unable to create multipart upload"))
+		return
+	}
+	err = ioutil.WriteFile(filepath.Join(tmpDir, uploadFile), b, 0600)
+	if err == nil {
+		err = os.Rename(tmpDir, filepath.Join(g.staticMultipartDir, uploadID))
+	}
+	if err != nil {
+		err = errors.Compose(err, os.RemoveAll(tmpDir))
+		writeError(w, req, errors.AddContext(err, "unable to create multipart upload"))
+		return
+	}
+	writeXML(w, http.StatusOK, initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+	})
+}
+
+// uploadPartHandler handles UploadPart requests. The part is stored on disk
+// until the upload is completed.
+func (g *Gateway) uploadPartHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	query := req.URL.Query()
+	number, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || number < 1 || number > maxPartNumber {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	dir, err := g.staticUploadDir(bucket, key, query.Get("uploadId"))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	mr, err := newMD5Reader(req.Body, req.Header.Get("Content-MD5"))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	// Write the part to a temporary file first.
+	tmpPath := filepath.Join(dir, "tmp-"+randomID())
+	err = func() (err error) {
+		f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if os.IsNotExist(err) {
+			// The upload was completed or aborted in the meantime.
+			return errNoSuchUpload
+		} else if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Compose(err, f.Close())
+		}()
+		if _, err := io.Copy(f, mr); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		writeError(w, req, err)
+		return
+	}
+
+	// Move the part into place and remove previous uploads of the same part.
+	partMD5 := hex.EncodeToString(mr.h.Sum(nil))
+	name := partFileName(number, partMD5)
+	if err := os.Rename(tmpPath, filepath.Join(dir, name)); err != nil {
+		_ = os.Remove(tmpPath)
+		writeError(w, req, errors.AddContext(err, "unable to store part"))
+		return
+	}
+	parts, err := staticUploadParts(dir)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	for _, part := range parts {
+		if part.number == number && part.md5 != partMD5 {
+			_ = os.Remove(part.path)
+		}
+	}
+	w.Header().Set("ETag", mr.ETag())
+	w.WriteHeader(http.StatusOK)
+}
+
+// completeMultipartUploadHandler handles CompleteMultipartUpload requests. The
+// selected parts are uploaded to the renter as a single file.
+func (g *Gateway) completeMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	sp, err := g.staticObjectSiaPath(bucket, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	dir, err := g.staticUploadDir(bucket, key, req.URL.Query().Get("uploadId"))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	var cmu completeMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(req.Body, maxXMLBodySize)).Decode(&cmu); err != nil || len(cmu.Parts) == 0 {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	parts, err := staticUploadParts(dir)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	// Find the selected parts.
+	stored := make(map[int]uploadPart, len(parts))
+	for _, part := range parts {
+		stored[part.number] = part
+	}
+	selected := make([]uploadPart, 0, len(cmu.Parts))
+	for i, p := range cmu.Parts {
+		if i > 0 && p.PartNumber <= cmu.Parts[i-1].PartNumber {
+			writeError(w, req, errInvalidPartOrder)
+			return
+		}
+		part, exists := stored[p.PartNumber]
+		if !exists || strings.Trim(p.ETag, `"`) != part.md5 {
+			writeError(w, req, errInvalidPart)
+			return
+		}
+		selected = append(selected, part)
+	}
+
+	// Upload the concatenated parts.
+	var fi modules.FileInfo
+	err = func() (err error) {
+		readers := make([]io.Reader, 0, len(selected))
+		for _, part := range selected {
+			f, err := os.Open(part.path)
+			if os.IsNotExist(err) {
+				return errInvalidPart
+			} else if err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Compose(err, f.Close())
+			}()
+			readers = append(readers, f)
+		}
+		fi, err = g.staticReplaceFile(sp, io.MultiReader(readers...))
+		return err
+	}()
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		writeError(w, req, errors.AddContext(err, "unable to remove parts of completed upload"))
+		return
+	}
+	writeXML(w, http.StatusOK, completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     objectETag(fi),
+	})
+}
+
+// abortMultipartUploadHandler handles AbortMultipartUpload requests.
+func (g *Gateway) abortMultipartUploadHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	dir, err := g.staticUploadDir(bucket, key, req.URL.Query().Get("uploadId"))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		writeError(w, req, errors.AddContext(err, "unable to remove multipart upload"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listPartsHandler handles ListParts requests.
+func (g *Gateway) listPartsHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	uploadID := req.URL.Query().Get("uploadId")
+	dir, err := g.staticUploadDir(bucket, key, uploadID)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	parts, err := staticUploadParts(dir)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	resp := listPartsResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+		MaxParts: maxPartNumber,
+	}
+	for _, part := range parts {
+		resp.Parts = append(resp.Parts, partInfo{
+			PartNumber:   part.number,
+			LastModified: formatTime(part.modTime),
+			ETag:         `"` + part.md5 + `"`,
+			Size:         part.size,
+		})
+	}
+	writeXML(w, http.StatusOK, resp)
+}
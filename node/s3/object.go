@@ -0,0 +1,299 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+const (
+	// defaultContentType is the content type of objects whose type can't be
+	// derived from their key.
+	defaultContentType = "binary/octet-stream"
+
+	// maxDeleteObjects is the maximum number of objects which can be deleted
+	// with a single DeleteObjects request.
+	maxDeleteObjects = 1000
+
+	// maxXMLBodySize is the maximum size of the XML body of a request.
+	maxXMLBodySize = 1 << 20 // 1 MiB
+)
+
+type (
+	// object describes a single object of a bucket.
+	object struct {
+		Key     string
+		ETag    string
+		ModTime time.Time
+		Size    uint64
+	}
+
+	// md5Reader computes the md5 hash of the data read from the underlying
+	// reader. If an expected hash is set, it verifies that the hash matches
+	// once the reader is drained.
+	md5Reader struct {
+		r        io.Reader
+		expected []byte
+		h        hash.Hash
+	}
+
+	// deleteRequest is the body of a DeleteObjects request.
+	deleteRequest struct {
+		XMLName xml.Name `xml:"Delete"`
+		Quiet   bool     `xml:"Quiet"`
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+
+	// deletedObject is an object which was deleted by a DeleteObjects
+	// request.
+	deletedObject struct {
+		Key string `xml:"Key"`
+	}
+
+	// deleteError is an object which couldn't be deleted by a DeleteObjects
+	// request.
+	deleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	// deleteResult is the response of a DeleteObjects request.
+	deleteResult struct {
+		XMLName xml.Name        `xml:"DeleteResult"`
+		Xmlns   string          `xml:"xmlns,attr"`
+		Deleted []deletedObject `xml:"Deleted"`
+		Errors  []deleteError   `xml:"Error"`
+	}
+)
+
+// newMD5Reader creates a new md5Reader. If contentMD5 is not empty, it needs
+// to be the base64 encoded md5 hash of the data.
+func newMD5Reader(r io.Reader, contentMD5 string) (*md5Reader, error) {
+	mr := &md5Reader{r: r, h: md5.New()}
+	if contentMD5 != "" {
+		expected, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || len(expected) != md5.Size {
+			return nil, errInvalidDigest
+		}
+		mr.expected = expected
+	}
+	return mr, nil
+}
+
+// Read implements io.Reader.
+func (mr *md5Reader) Read(p []byte) (int, error) {
+	n, err := mr.r.Read(p)
+	mr.h.Write(p[:n])
+	if err == io.EOF && mr.expected != nil && !bytes.Equal(mr.h.Sum(nil), mr.expected) {
+		return n, errBadDigest
+	}
+	return n, err
+}
+
+// ETag returns the quoted hex encoded md5 hash of the data read so far.
+func (mr *md5Reader) ETag() string {
+	return `"` + hex.EncodeToString(mr.h.Sum(nil)) + `"`
+}
+
+// objectETag returns the ETag of an object. It is the only ETag of objects
+// that is returned by the gateway. The renter doesn't keep track of the md5
+// hashes of files so the ETag only identifies the version of the object. The
+// suffix makes sure that clients don't mistake it for an md5 hash.
+func objectETag(fi modules.FileInfo) string {
+	h := crypto.HashAll(fi.UID, fi.Filesize, fi.ModificationTime.UnixNano())
+	return `"` + hex.EncodeToString(h[:16]) + `-1"`
+}
+
+// staticListObjects returns all the objects of the bucket at bucketSiaPath
+// sorted by their keys.
+func (g *Gateway) staticListObjects(bucketSiaPath modules.SiaPath) ([]object, error) {
+	var mu sync.Mutex
+	var objects []object
+	err := g.staticRenter.FileList(bucketSiaPath, true, true, func(fi modules.FileInfo) {
+		key := strings.TrimPrefix(fi.SiaPath.String(), bucketSiaPath.String()+"/")
+		mu.Lock()
+		defer mu.Unlock()
+		objects = append(objects, object{
+			Key:     key,
+			ETag:    objectETag(fi),
+			ModTime: fi.ModificationTime,
+			Size:    fi.Filesize,
+		})
+	})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, errNoSuchBucket
+	} else if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// staticObjectInfo returns the siapath and file info of an object.
+func (g *Gateway) staticObjectInfo(bucket, key string) (modules.SiaPath, modules.FileInfo, error) {
+	sp, err := g.staticObjectSiaPath(bucket, key)
+	if err != nil {
+		return modules.SiaPath{}, modules.FileInfo{}, err
+	}
+	fi, err := g.staticRenter.File(sp)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		if err := g.staticBucketExists(bucket); err != nil {
+			return modules.SiaPath{}, modules.FileInfo{}, err
+		}
+		return modules.SiaPath{}, modules.FileInfo{}, errNoSuchKey
+	} else if err != nil {
+		return modules.SiaPath{}, modules.FileInfo{}, err
+	}
+	return sp, fi, nil
+}
+
+// setObjectHeaders sets the headers which describe an object.
+func setObjectHeaders(w http.ResponseWriter, key string, fi modules.FileInfo) {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", objectETag(fi))
+	w.Header().Set("Last-Modified", fi.ModificationTime.UTC().Format(http.TimeFormat))
+}
+
+// putObjectHandler handles PutObject requests.
+func (g *Gateway) putObjectHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	sp, err := g.staticObjectSiaPath(bucket, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	mr, err := newMD5Reader(req.Body, req.Header.Get("Content-MD5"))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	fi, err := g.staticReplaceFile(sp, mr)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("ETag", objectETag(fi))
+	w.WriteHeader(http.StatusOK)
+}
+
+// getObjectHandler handles GetObject requests. Range requests are supported.
+func (g *Gateway) getObjectHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	sp, fi, err := g.staticObjectInfo(bucket, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	_, streamer, err := g.staticRenter.Streamer(sp, false)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+	setObjectHeaders(w, key, fi)
+	http.ServeContent(w, req, "", fi.ModificationTime, streamer)
+}
+
+// headObjectHandler handles HeadObject requests.
+func (g *Gateway) headObjectHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	_, fi, err := g.staticObjectInfo(bucket, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	setObjectHeaders(w, key, fi)
+	w.Header().Set("Content-Length", strconv.FormatUint(fi.Filesize, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteObjectHandler handles DeleteObject requests. Deleting an object which
+// doesn't exist succeeds.
+func (g *Gateway) deleteObjectHandler(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	sp, err := g.staticObjectSiaPath(bucket, key)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	err = g.staticRenter.DeleteFile(sp)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		writeError(w, req, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteObjectsHandler handles DeleteObjects requests.
+func (g *Gateway) deleteObjectsHandler(w http.ResponseWriter, req *http.Request, bucket string) {
+	if err := g.staticBucketExists(bucket); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	var dr deleteRequest
+	if err := xml.NewDecoder(io.LimitReader(req.Body, maxXMLBodySize)).Decode(&dr); err != nil {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	if len(dr.Objects) == 0 || len(dr.Objects) > maxDeleteObjects {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	resp := deleteResult{Xmlns: s3Namespace}
+	for _, obj := range dr.Objects {
+		sp, err := g.staticObjectSiaPath(bucket, obj.Key)
+		if err == nil {
+			err = g.staticRenter.DeleteFile(sp)
+		}
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			e, ok := err.(apiError)
+			if !ok {
+				e = apiError{Code: "InternalError", Message: err.Error()}
+			}
+			resp.Errors = append(resp.Errors, deleteError{
+				Key:     obj.Key,
+				Code:    e.Code,
+				Message: e.Message,
+			})
+			continue
+		}
+		if !dr.Quiet {
+			resp.Deleted = append(resp.Deleted, deletedObject{Key: obj.Key})
+		}
+	}
+	writeXML(w, http.StatusOK, resp)
+}
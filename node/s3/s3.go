@@ -0,0 +1,239 @@
+// Package s3 provides a gateway which exposes the renter's files through a
+// subset of the Amazon S3 API. Buckets are directories within the renter's
+// user folder and the keys of objects are the paths of files relative to their
+// bucket. Requests are authenticated using AWS Signature Version 4 with the
+// API password acting as the secret key.
+//
+// Only path-style requests are supported, e.g. http://localhost:9982/bucket/key.
+package s3
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+const (
+	// defaultDirMode is the mode of the directories created by the gateway.
+	defaultDirMode = modules.DefaultDirPerm
+
+	// s3Namespace is the XML namespace of the responses.
+	s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+)
+
+var (
+	// tmpFolder is the folder which contains the files of objects that are
+	// still being uploaded. Once an upload is complete, its file is moved to
+	// the location of the object.
+	tmpFolder = modules.NewGlobalSiaPath("/var/s3")
+
+	// errNoSecretKey is returned when a gateway is created without a secret
+	// key.
+	errNoSecretKey = errors.New("the s3 gateway requires a secret key to authenticate requests")
+)
+
+type (
+	// Renter is the subset of the renter's methods used by the gateway.
+	Renter interface {
+		CreateDir(siaPath modules.SiaPath, mode os.FileMode) error
+		DeleteDir(siaPath modules.SiaPath) error
+		DeleteFile(siaPath modules.SiaPath) error
+		DirList(siaPath modules.SiaPath) ([]modules.DirectoryInfo, error)
+		File(siaPath modules.SiaPath) (modules.FileInfo, error)
+		FileList(siaPath modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error
+		ReplaceFile(siaPath, srcSiaPath modules.SiaPath) error
+		Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (string, modules.Streamer, error)
+		UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error
+	}
+
+	// Gateway serves the S3 API for a renter.
+	Gateway struct {
+		staticAccessKey    string
+		staticSecretKey    string
+		staticMultipartDir string
+		staticRenter       Renter
+		staticRoot         modules.SiaPath
+	}
+)
+
+// New creates a new gateway for the renter. Requests need to be signed with
+// the provided access and secret key. The parts of multipart uploads are
+// stored within multipartDir until the upload is completed.
+func New(renter Renter, accessKey, secretKey, multipartDir string) (*Gateway, error) {
+	if secretKey == "" {
+		return nil, errNoSecretKey
+	}
+	return newGateway(renter, accessKey, secretKey, multipartDir)
+}
+
+// newGateway creates a new gateway without checking the keys. If the secret
+// key is empty, requests are not authenticated.
+func newGateway(renter Renter, accessKey, secretKey, multipartDir string) (*Gateway, error) {
+	if err := os.MkdirAll(multipartDir, 0700); err != nil {
+		return nil, errors.AddContext(err, "unable to create multipart dir")
+	}
+	return &Gateway{
+		staticAccessKey:    accessKey,
+		staticSecretKey:    secretKey,
+		staticMultipartDir: multipartDir,
+		staticRenter:       renter,
+		staticRoot:         modules.UserFolder,
+	}, nil
+}
+
+// ServeHTTP authenticates a request and routes it to its handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := g.staticAuthenticate(req)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	req.Body = body
+
+	// Split the path into bucket and key.
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	bucket, key := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		bucket, key = path[:i], path[i+1:]
+	}
+	query := req.URL.Query()
+
+	switch {
+	case bucket == "":
+		if req.Method != http.MethodGet {
+			writeError(w, req, errMethodNotAllowed)
+			return
+		}
+		g.listBucketsHandler(w, req)
+	case key == "":
+		g.serveBucket(w, req, bucket, query)
+	default:
+		g.serveObject(w, req, bucket, key, query)
+	}
+}
+
+// serveBucket routes a request which targets a bucket.
+func (g *Gateway) serveBucket(w http.ResponseWriter, req *http.Request, bucket string, query map[string][]string) {
+	_, location := query["location"]
+	_, deleteObjects := query["delete"]
+	_, uploads := query["uploads"]
+	switch {
+	case req.Method == http.MethodGet && location:
+		g.getBucketLocationHandler(w, req, bucket)
+	case req.Method == http.MethodGet && uploads:
+		writeError(w, req, errNotImplemented)
+	case req.Method == http.MethodGet:
+		g.listObjectsHandler(w, req, bucket)
+	case req.Method == http.MethodHead:
+		g.headBucketHandler(w, req, bucket)
+	case req.Method == http.MethodPut:
+		g.createBucketHandler(w, req, bucket)
+	case req.Method == http.MethodDelete:
+		g.deleteBucketHandler(w, req, bucket)
+	case req.Method == http.MethodPost && deleteObjects:
+		g.deleteObjectsHandler(w, req, bucket)
+	default:
+		writeError(w, req, errMethodNotAllowed)
+	}
+}
+
+// serveObject routes a request which targets an object.
+func (g *Gateway) serveObject(w http.ResponseWriter, req *http.Request, bucket, key string, query map[string][]string) {
+	_, uploads := query["uploads"]
+	_, uploadID := query["uploadId"]
+	switch {
+	case req.Method == http.MethodPost && uploads:
+		g.createMultipartUploadHandler(w, req, bucket, key)
+	case req.Method == http.MethodPost && uploadID:
+		g.completeMultipartUploadHandler(w, req, bucket, key)
+	case req.Method == http.MethodPut && uploadID:
+		g.uploadPartHandler(w, req, bucket, key)
+	case req.Method == http.MethodDelete && uploadID:
+		g.abortMultipartUploadHandler(w, req, bucket, key)
+	case req.Method == http.MethodGet && uploadID:
+		g.listPartsHandler(w, req, bucket, key)
+	case req.Method == http.MethodPut && req.Header.Get("X-Amz-Copy-Source") != "":
+		writeError(w, req, errNotImplemented)
+	case req.Method == http.MethodPut:
+		g.putObjectHandler(w, req, bucket, key)
+	case req.Method == http.MethodGet:
+		g.getObjectHandler(w, req, bucket, key)
+	case req.Method == http.MethodHead:
+		g.headObjectHandler(w, req, bucket, key)
+	case req.Method == http.MethodDelete:
+		g.deleteObjectHandler(w, req, bucket, key)
+	default:
+		writeError(w, req, errMethodNotAllowed)
+	}
+}
+
+// staticBucketSiaPath returns the siapath of a bucket.
+func (g *Gateway) staticBucketSiaPath(bucket string) (modules.SiaPath, error) {
+	if bucket == "" || strings.ContainsAny(bucket, "/\\") || bucket == "." || bucket == ".." {
+		return modules.SiaPath{}, errInvalidBucketName
+	}
+	sp, err := g.staticRoot.Join(bucket)
+	if err != nil {
+		return modules.SiaPath{}, errInvalidBucketName
+	}
+	return sp, nil
+}
+
+// staticObjectSiaPath returns the siapath of an object.
+func (g *Gateway) staticObjectSiaPath(bucket, key string) (modules.SiaPath, error) {
+	bucketSiaPath, err := g.staticBucketSiaPath(bucket)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	sp, err := bucketSiaPath.Join(key)
+	if err != nil || strings.HasSuffix(key, "/") {
+		return modules.SiaPath{}, errInvalidKey
+	}
+	return sp, nil
+}
+
+// staticBucketExists returns an error if the bucket doesn't exist.
+func (g *Gateway) staticBucketExists(bucket string) error {
+	sp, err := g.staticBucketSiaPath(bucket)
+	if err != nil {
+		return err
+	}
+	_, err = g.staticRenter.DirList(sp)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return errNoSuchBucket
+	}
+	return err
+}
+
+// staticReplaceFile uploads the data of reader as the object at siaPath and
+// returns the info of the new object. The data is uploaded to a temporary file
+// first which atomically replaces any existing object once the upload
+// succeeded.
+func (g *Gateway) staticReplaceFile(siaPath modules.SiaPath, reader io.Reader) (_ modules.FileInfo, err error) {
+	tmpSiaPath, err := tmpFolder.Join(randomID())
+	if err != nil {
+		return modules.FileInfo{}, err
+	}
+	err = g.staticRenter.UploadStreamFromReader(modules.FileUploadParams{
+		SiaPath: tmpSiaPath,
+	}, reader)
+	if err != nil {
+		// The upload might have created the file before it failed.
+		deleteErr := g.staticRenter.DeleteFile(tmpSiaPath)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+		return modules.FileInfo{}, err
+	}
+	err = g.staticRenter.ReplaceFile(siaPath, tmpSiaPath)
+	if err != nil {
+		return modules.FileInfo{}, errors.Compose(err, g.staticRenter.DeleteFile(tmpSiaPath))
+	}
+	return g.staticRenter.File(siaPath)
+}
@@ -0,0 +1,602 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+type (
+	// fakeRenter is an in-memory implementation of the Renter interface.
+	fakeRenter struct {
+		dirs  map[modules.SiaPath]time.Time
+		files map[modules.SiaPath]fakeFile
+		mu    sync.Mutex
+	}
+
+	// fakeFile is a file of the fakeRenter.
+	fakeFile struct {
+		data    []byte
+		modTime time.Time
+		uid     uint64
+	}
+
+	// nopCloser adds a no-op Close method to a bytes.Reader.
+	nopCloser struct {
+		*bytes.Reader
+	}
+
+	// gatewayTester is a helper for sending requests to a gateway.
+	gatewayTester struct {
+		staticGateway *Gateway
+		staticRenter  *fakeRenter
+		t             *testing.T
+	}
+)
+
+// newFakeRenter creates an empty fakeRenter with a user folder.
+func newFakeRenter() *fakeRenter {
+	return &fakeRenter{
+		dirs: map[modules.SiaPath]time.Time{
+			modules.RootSiaPath(): time.Now(),
+			modules.UserFolder:    time.Now(),
+		},
+		files: make(map[modules.SiaPath]fakeFile),
+	}
+}
+
+// Close implements io.Closer.
+func (nopCloser) Close() error { return nil }
+
+// isChild returns true if sp is a child of dir.
+func isChild(sp, dir modules.SiaPath, recursive bool) bool {
+	parent, err := sp.Dir()
+	if err != nil {
+		return false
+	}
+	if parent.Equals(dir) {
+		return true
+	}
+	return recursive && strings.HasPrefix(sp.String(), dir.String()+"/")
+}
+
+// createDirs creates all the parent dirs of sp.
+func (r *fakeRenter) createDirs(sp modules.SiaPath) {
+	for dir, err := sp.Dir(); err == nil && !dir.IsRoot(); dir, err = dir.Dir() {
+		if _, exists := r.dirs[dir]; !exists {
+			r.dirs[dir] = time.Now()
+		}
+	}
+}
+
+func (r *fakeRenter) CreateDir(sp modules.SiaPath, _ os.FileMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(sp)
+	r.dirs[sp] = time.Now()
+	return nil
+}
+
+func (r *fakeRenter) DeleteDir(sp modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(r.dirs, sp)
+	for dir := range r.dirs {
+		if isChild(dir, sp, true) {
+			delete(r.dirs, dir)
+		}
+	}
+	for file := range r.files {
+		if isChild(file, sp, true) {
+			delete(r.files, file)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRenter) DeleteFile(sp modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.files[sp]; !exists {
+		return errors.AddContext(filesystem.ErrNotExist, "failed to delete file")
+	}
+	delete(r.files, sp)
+	return nil
+}
+
+func (r *fakeRenter) DirList(sp modules.SiaPath) ([]modules.DirectoryInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, exists := r.dirs[sp]
+	if !exists {
+		return nil, filesystem.ErrNotExist
+	}
+	dis := []modules.DirectoryInfo{{SiaPath: sp, MostRecentModTime: modTime}}
+	for dir, modTime := range r.dirs {
+		if isChild(dir, sp, false) {
+			dis = append(dis, modules.DirectoryInfo{SiaPath: dir, MostRecentModTime: modTime})
+		}
+	}
+	return dis, nil
+}
+
+func (r *fakeRenter) File(sp modules.SiaPath) (modules.FileInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	if !exists {
+		return modules.FileInfo{}, errors.AddContext(filesystem.ErrNotExist, "unable to get the fileinfo from the filesystem")
+	}
+	return f.info(sp), nil
+}
+
+func (r *fakeRenter) FileList(sp modules.SiaPath, recursive, _ bool, flf modules.FileListFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	for file, f := range r.files {
+		if isChild(file, sp, recursive) {
+			flf(f.info(file))
+		}
+	}
+	return nil
+}
+
+func (r *fakeRenter) ReplaceFile(sp, srcSiaPath modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[srcSiaPath]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	r.createDirs(sp)
+	delete(r.files, srcSiaPath)
+	r.files[sp] = f
+	return nil
+}
+
+func (r *fakeRenter) Streamer(sp modules.SiaPath, _ bool) (string, modules.Streamer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	if !exists {
+		return "", nil, filesystem.ErrNotExist
+	}
+	return sp.Name(), nopCloser{bytes.NewReader(f.data)}, nil
+}
+
+func (r *fakeRenter) UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.AddContext(err, "unable to stream an upload from a reader")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.files[up.SiaPath]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(up.SiaPath)
+	r.files[up.SiaPath] = fakeFile{
+		data:    data,
+		modTime: time.Now(),
+		uid:     fastrand.Uint64n(1 << 62),
+	}
+	return nil
+}
+
+// info returns the file info of the file.
+func (f fakeFile) info(sp modules.SiaPath) modules.FileInfo {
+	return modules.FileInfo{
+		Filesize:         uint64(len(f.data)),
+		ModificationTime: f.modTime,
+		SiaPath:          sp,
+		UID:              f.uid,
+	}
+}
+
+// newGatewayTester creates a gateway for a fakeRenter which doesn't require
+// authentication.
+func newGatewayTester(t *testing.T) *gatewayTester {
+	return newGatewayTesterWithKeys(t, "", "")
+}
+
+// newGatewayTesterWithKeys creates a gateway for a fakeRenter using the
+// provided keys.
+func newGatewayTesterWithKeys(t *testing.T, accessKey, secretKey string) *gatewayTester {
+	r := newFakeRenter()
+	g, err := newGateway(r, accessKey, secretKey, filepath.Join(build.TempDir("s3", t.Name()), "multipart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &gatewayTester{
+		staticGateway: g,
+		staticRenter:  r,
+		t:             t,
+	}
+}
+
+// request sends a request to the gateway and returns the response.
+func (gt *gatewayTester) request(method, target string, body []byte, header http.Header) *http.Response {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	gt.staticGateway.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+// checkStatus sends a request and checks the status code of its response.
+func (gt *gatewayTester) checkStatus(method, target string, body []byte, header http.Header, status int) []byte {
+	gt.t.Helper()
+	resp := gt.request(method, target, body, header)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		gt.t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		gt.t.Fatalf("%v %v: expected status %v but got %v: %s", method, target, status, resp.StatusCode, respBody)
+	}
+	return respBody
+}
+
+// checkError sends a request and checks the S3 error code of the response.
+func (gt *gatewayTester) checkError(method, target string, body []byte, header http.Header, apiErr apiError) {
+	gt.t.Helper()
+	respBody := gt.checkStatus(method, target, body, header, apiErr.StatusCode)
+	if method == http.MethodHead {
+		return
+	}
+	var resp errorResponse
+	if err := xml.Unmarshal(respBody, &resp); err != nil {
+		gt.t.Fatal(err)
+	}
+	if resp.Code != apiErr.Code {
+		gt.t.Fatalf("%v %v: expected error code %v but got %v", method, target, apiErr.Code, resp.Code)
+	}
+}
+
+// TestBuckets tests creating, listing and deleting buckets.
+func TestBuckets(t *testing.T) {
+	t.Parallel()
+	gt := newGatewayTester(t)
+
+	// Create some buckets.
+	for _, bucket := range []string{"foo", "bar.baz"} {
+		gt.checkStatus(http.MethodPut, "/"+bucket, nil, nil, http.StatusOK)
+		gt.checkStatus(http.MethodHead, "/"+bucket, nil, nil, http.StatusOK)
+	}
+	gt.checkError(http.MethodPut, "/foo", nil, nil, errBucketAlreadyOwnedByYou)
+	for _, bucket := range []string{"ab", "UPPER", "-foo", "foo_bar", "foo..bar", strings.Repeat("a", 64)} {
+		gt.checkError(http.MethodPut, "/"+bucket, nil, nil, errInvalidBucketName)
+	}
+	gt.checkError(http.MethodHead, "/missing", nil, nil, errNoSuchBucket)
+	gt.checkError(http.MethodGet, "/missing", nil, nil, errNoSuchBucket)
+
+	// List the buckets.
+	var lr listAllMyBucketsResult
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodGet, "/", nil, nil, http.StatusOK), &lr); err != nil {
+		t.Fatal(err)
+	}
+	if len(lr.Buckets) != 2 || lr.Buckets[0].Name != "bar.baz" || lr.Buckets[1].Name != "foo" {
+		t.Fatal("wrong buckets", lr.Buckets)
+	}
+
+	// Non-empty buckets can't be deleted.
+	gt.checkStatus(http.MethodPut, "/foo/dir/file", []byte("data"), nil, http.StatusOK)
+	gt.checkError(http.MethodDelete, "/foo", nil, nil, errBucketNotEmpty)
+	gt.checkStatus(http.MethodDelete, "/foo/dir/file", nil, nil, http.StatusNoContent)
+	gt.checkStatus(http.MethodDelete, "/foo", nil, nil, http.StatusNoContent)
+	gt.checkError(http.MethodDelete, "/foo", nil, nil, errNoSuchBucket)
+	if _, exists := gt.staticRenter.dirs[modules.UserFolder]; !exists {
+		t.Fatal("user folder was deleted")
+	}
+}
+
+// TestObjects tests uploading, downloading and deleting objects.
+func TestObjects(t *testing.T) {
+	t.Parallel()
+	gt := newGatewayTester(t)
+	gt.checkStatus(http.MethodPut, "/bucket", nil, nil, http.StatusOK)
+
+	// Upload an object.
+	data := fastrand.Bytes(1000)
+	sum := md5.Sum(data)
+	header := http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(sum[:])}}
+	resp := gt.request(http.MethodPut, "/bucket/dir/file.txt", data, header)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("upload failed", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || etag == `"`+hex.EncodeToString(sum[:])+`"` {
+		t.Fatal("wrong etag", etag)
+	}
+	sp, err := modules.UserFolder.Join("bucket/dir/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, exists := gt.staticRenter.files[sp]; !exists || !bytes.Equal(f.data, data) {
+		t.Fatal("object wasn't uploaded to the expected siapath")
+	}
+
+	// Download the object and a range of it.
+	resp = gt.request(http.MethodGet, "/bucket/dir/file.txt", nil, nil)
+	downloaded, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(downloaded, data) {
+		t.Fatal("download failed", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatal("wrong content type", ct)
+	}
+	if resp.Header.Get("ETag") != etag {
+		t.Fatal("etag doesn't match etag of upload", resp.Header.Get("ETag"), etag)
+	}
+	resp = gt.request(http.MethodGet, "/bucket/dir/file.txt", nil, http.Header{"Range": []string{"bytes=100-199"}})
+	downloaded, _ = ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(downloaded, data[100:200]) {
+		t.Fatal("range download failed", resp.StatusCode)
+	}
+	if cr := resp.Header.Get("Content-Range"); cr != "bytes 100-199/1000" {
+		t.Fatal("wrong content range", cr)
+	}
+
+	// Fetch the object's metadata.
+	resp = gt.request(http.MethodHead, "/bucket/dir/file.txt", nil, nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Length") != "1000" || resp.Header.Get("ETag") != etag {
+		t.Fatal("wrong metadata", resp.StatusCode, resp.Header)
+	}
+
+	// Replace the object. The ETag should change.
+	resp = gt.request(http.MethodPut, "/bucket/dir/file.txt", []byte("new data"), nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Fatal("etag didn't change", resp.StatusCode)
+	}
+	etag = resp.Header.Get("ETag")
+	if resp = gt.request(http.MethodHead, "/bucket/dir/file.txt", nil, nil); resp.Header.Get("ETag") != etag {
+		t.Fatal("etag doesn't match etag of upload")
+	}
+	if downloaded = gt.checkStatus(http.MethodGet, "/bucket/dir/file.txt", nil, nil, http.StatusOK); string(downloaded) != "new data" {
+		t.Fatal("object wasn't replaced", string(downloaded))
+	}
+
+	// An upload with the wrong digest should fail without replacing the
+	// object.
+	gt.checkError(http.MethodPut, "/bucket/dir/file.txt", []byte("other data"), header, errBadDigest)
+	if downloaded = gt.checkStatus(http.MethodGet, "/bucket/dir/file.txt", nil, nil, http.StatusOK); string(downloaded) != "new data" {
+		t.Fatal("object was replaced", string(downloaded))
+	}
+	if len(gt.staticRenter.files) != 1 {
+		t.Fatal("temporary file wasn't deleted", len(gt.staticRenter.files))
+	}
+
+	// Check errors for missing buckets and objects.
+	gt.checkError(http.MethodPut, "/missing/file", data, nil, errNoSuchBucket)
+	gt.checkError(http.MethodGet, "/missing/file", nil, nil, errNoSuchBucket)
+	gt.checkError(http.MethodGet, "/bucket/missing", nil, nil, errNoSuchKey)
+	gt.checkError(http.MethodHead, "/bucket/missing", nil, nil, errNoSuchKey)
+	gt.checkError(http.MethodPut, "/bucket/dir/", nil, nil, errInvalidKey)
+
+	// Delete the object. Deleting it again should succeed as well.
+	gt.checkStatus(http.MethodDelete, "/bucket/dir/file.txt", nil, nil, http.StatusNoContent)
+	gt.checkStatus(http.MethodDelete, "/bucket/dir/file.txt", nil, nil, http.StatusNoContent)
+	gt.checkError(http.MethodGet, "/bucket/dir/file.txt", nil, nil, errNoSuchKey)
+}
+
+// TestDeleteObjects tests deleting multiple objects with a single request.
+func TestDeleteObjects(t *testing.T) {
+	t.Parallel()
+	gt := newGatewayTester(t)
+	gt.checkStatus(http.MethodPut, "/bucket", nil, nil, http.StatusOK)
+	gt.checkStatus(http.MethodPut, "/bucket/a", []byte("a"), nil, http.StatusOK)
+	gt.checkStatus(http.MethodPut, "/bucket/b/c", []byte("c"), nil, http.StatusOK)
+
+	body := []byte(`<Delete><Object><Key>a</Key></Object><Object><Key>b/c</Key></Object><Object><Key>missing</Key></Object></Delete>`)
+	var dr deleteResult
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodPost, "/bucket?delete", body, nil, http.StatusOK), &dr); err != nil {
+		t.Fatal(err)
+	}
+	if len(dr.Deleted) != 3 || len(dr.Errors) != 0 {
+		t.Fatal("wrong result", dr)
+	}
+	if len(gt.staticRenter.files) != 0 {
+		t.Fatal("objects weren't deleted")
+	}
+	gt.checkError(http.MethodPost, "/bucket?delete", []byte("<Delete>"), nil, errMalformedXML)
+}
+
+// TestListObjectsPagination tests listing objects with prefixes, delimiters
+// and markers.
+func TestListObjectsPagination(t *testing.T) {
+	t.Parallel()
+	var objects []object
+	for _, key := range []string{"a", "b/1", "b/2", "b/3", "c/1", "c/d/1", "d"} {
+		objects = append(objects, object{Key: key})
+	}
+	keys := func(contents []object) []string {
+		var keys []string
+		for _, obj := range contents {
+			keys = append(keys, obj.Key)
+		}
+		return keys
+	}
+	tests := []struct {
+		prefix, delimiter, marker string
+		maxKeys                   int
+		contents, prefixes        string
+		truncated                 bool
+		next                      string
+	}{
+		{"", "", "", 1000, "a,b/1,b/2,b/3,c/1,c/d/1,d", "", false, ""},
+		{"", "/", "", 1000, "a,d", "b/,c/", false, ""},
+		{"", "/", "", 2, "a", "b/", true, "b/"},
+		{"", "/", "b/", 2, "d", "c/", false, ""},
+		{"c/", "/", "", 1000, "c/1", "c/d/", false, ""},
+		{"b/", "", "b/1", 1, "b/2", "", true, "b/2"},
+		{"", "", "", 0, "", "", true, ""},
+		{"x", "", "", 1000, "", "", false, ""},
+	}
+	for _, test := range tests {
+		contents, prefixes, truncated, next := listObjects(objects, test.prefix, test.delimiter, test.marker, test.maxKeys)
+		if truncated != test.truncated {
+			t.Errorf("%+v: wrong truncated %v", test, truncated)
+		}
+		if c := strings.Join(keys(contents), ","); c != test.contents {
+			t.Errorf("%+v: wrong contents %v", test, c)
+		}
+		if p := strings.Join(prefixes, ","); p != test.prefixes {
+			t.Errorf("%+v: wrong prefixes %v", test, p)
+		}
+		if next != test.next {
+			t.Errorf("%+v: wrong next marker %v", test, next)
+		}
+	}
+}
+
+// TestListObjectsV2 tests paging through the objects of a bucket using
+// ListObjectsV2 requests.
+func TestListObjectsV2(t *testing.T) {
+	t.Parallel()
+	gt := newGatewayTester(t)
+	gt.checkStatus(http.MethodPut, "/bucket", nil, nil, http.StatusOK)
+	for _, key := range []string{"a b", "dir/1", "dir/2", "z+"} {
+		gt.checkStatus(http.MethodPut, "/bucket/"+strings.Replace(key, " ", "%20", -1), []byte(key), nil, http.StatusOK)
+	}
+
+	var keys, prefixes []string
+	target := "/bucket?list-type=2&max-keys=1&delimiter=/&encoding-type=url"
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("listing doesn't terminate")
+		}
+		var lr listBucketResult
+		if err := xml.Unmarshal(gt.checkStatus(http.MethodGet, target, nil, nil, http.StatusOK), &lr); err != nil {
+			t.Fatal(err)
+		}
+		if lr.KeyCount == nil || *lr.KeyCount != 1 {
+			t.Fatal("wrong key count", lr.KeyCount)
+		}
+		for _, c := range lr.Contents {
+			keys = append(keys, c.Key)
+		}
+		for _, cp := range lr.CommonPrefixes {
+			prefixes = append(prefixes, cp.Prefix)
+		}
+		if !lr.IsTruncated {
+			break
+		}
+		target = "/bucket?list-type=2&max-keys=1&delimiter=/&encoding-type=url&continuation-token=" + url.QueryEscape(lr.NextContinuationToken)
+	}
+	if strings.Join(keys, ",") != "a+b,z%2B" || strings.Join(prefixes, ",") != "dir%2F" {
+		t.Fatal("wrong listing", keys, prefixes)
+	}
+}
+
+// TestMultipartUpload tests uploading an object in multiple parts.
+func TestMultipartUpload(t *testing.T) {
+	t.Parallel()
+	gt := newGatewayTester(t)
+	gt.checkStatus(http.MethodPut, "/bucket", nil, nil, http.StatusOK)
+
+	// Create an upload.
+	var imu initiateMultipartUploadResult
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodPost, "/bucket/object?uploads", nil, nil, http.StatusOK), &imu); err != nil {
+		t.Fatal(err)
+	}
+	uploadID := imu.UploadID
+	target := "/bucket/object?uploadId=" + uploadID
+
+	// Upload the parts. The second part is uploaded twice.
+	parts := [][]byte{fastrand.Bytes(100), fastrand.Bytes(200), fastrand.Bytes(50)}
+	gt.checkStatus(http.MethodPut, target+"&partNumber=2", fastrand.Bytes(10), nil, http.StatusOK)
+	etags := make([]string, len(parts))
+	for i, part := range parts {
+		resp := gt.request(http.MethodPut, target+"&partNumber="+string(rune('1'+i)), part, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal("part upload failed", resp.StatusCode)
+		}
+		etags[i] = resp.Header.Get("ETag")
+	}
+	gt.checkError(http.MethodPut, target+"&partNumber=0", nil, nil, errInvalidArgument)
+	gt.checkError(http.MethodPut, "/bucket/other?partNumber=1&uploadId="+uploadID, nil, nil, errNoSuchUpload)
+
+	// List the parts.
+	var lp listPartsResult
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodGet, target, nil, nil, http.StatusOK), &lp); err != nil {
+		t.Fatal(err)
+	}
+	if len(lp.Parts) != len(parts) {
+		t.Fatal("wrong number of parts", len(lp.Parts))
+	}
+	for i, p := range lp.Parts {
+		if p.PartNumber != i+1 || p.ETag != etags[i] || p.Size != int64(len(parts[i])) {
+			t.Fatal("wrong part", p)
+		}
+	}
+
+	// Complete the upload with invalid part lists.
+	completeBody := func(numbers ...int) []byte {
+		body := "<CompleteMultipartUpload>"
+		for _, n := range numbers {
+			body += "<Part><PartNumber>" + string(rune('0'+n)) + "</PartNumber><ETag>" + etags[(n-1)%len(etags)] + "</ETag></Part>"
+		}
+		return []byte(body + "</CompleteMultipartUpload>")
+	}
+	gt.checkError(http.MethodPost, target, completeBody(2, 1), nil, errInvalidPartOrder)
+	gt.checkError(http.MethodPost, target, completeBody(1, 4), nil, errInvalidPart)
+
+	// Complete the upload using the first two parts.
+	var cmu completeMultipartUploadResult
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodPost, target, completeBody(1, 2), nil, http.StatusOK), &cmu); err != nil {
+		t.Fatal(err)
+	}
+	if resp := gt.request(http.MethodHead, "/bucket/object", nil, nil); resp.Header.Get("ETag") != cmu.ETag {
+		t.Fatal("etag doesn't match etag of object", cmu.ETag)
+	}
+	downloaded := gt.checkStatus(http.MethodGet, "/bucket/object", nil, nil, http.StatusOK)
+	if !bytes.Equal(downloaded, append(append([]byte{}, parts[0]...), parts[1]...)) {
+		t.Fatal("wrong data")
+	}
+	gt.checkError(http.MethodPost, target, completeBody(1, 2), nil, errNoSuchUpload)
+	if fis, err := ioutil.ReadDir(gt.staticGateway.staticMultipartDir); err != nil || len(fis) != 0 {
+		t.Fatal("parts weren't removed", err, len(fis))
+	}
+
+	// Abort an upload.
+	if err := xml.Unmarshal(gt.checkStatus(http.MethodPost, "/bucket/object?uploads", nil, nil, http.StatusOK), &imu); err != nil {
+		t.Fatal(err)
+	}
+	target = "/bucket/object?uploadId=" + imu.UploadID
+	gt.checkStatus(http.MethodPut, target+"&partNumber=1", parts[0], nil, http.StatusOK)
+	gt.checkStatus(http.MethodDelete, target, nil, nil, http.StatusNoContent)
+	gt.checkError(http.MethodPut, target+"&partNumber=1", parts[0], nil, errNoSuchUpload)
+	gt.checkError(http.MethodDelete, target, nil, nil, errNoSuchUpload)
+	gt.checkError(http.MethodDelete, "/bucket/object?uploadId=../..", nil, nil, errNoSuchUpload)
+}
@@ -0,0 +1,349 @@
+package webdav
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errReadOnly is returned when writing to a file which was opened for
+	// reading.
+	errReadOnly = errors.New("file was opened for reading")
+
+	// errWriteOnly is returned when reading from a file which was opened for
+	// writing.
+	errWriteOnly = errors.New("file was opened for writing")
+)
+
+type (
+	// fileInfo wraps the info of a file to provide its content type. Without
+	// it, the WebDAV handler would download the beginning of each file to
+	// detect its content type.
+	fileInfo struct {
+		modules.FileInfo
+	}
+
+	// readFile is a file which was opened for reading. The file's streamer is
+	// only created once the file is read from.
+	readFile struct {
+		offset   int64
+		streamer modules.Streamer
+
+		staticFileInfo os.FileInfo
+		staticRenter   Renter
+		staticSiaPath  modules.SiaPath
+		staticState    *requestState
+	}
+
+	// dirFile is a dir which was opened for reading.
+	dirFile struct {
+		entries []os.FileInfo
+		listed  bool
+
+		staticFileInfo   os.FileInfo
+		staticFileSystem *fileSystem
+		staticSiaPath    modules.SiaPath
+	}
+
+	// uploadFile is a file which was opened for writing. The data written to
+	// the file is streamed to a temporary siafile which replaces the file once
+	// the upload is complete.
+	uploadFile struct {
+		written int64
+
+		staticDone       chan struct{}
+		staticPipeWriter *io.PipeWriter
+		staticRenter     Renter
+		staticSiaPath    modules.SiaPath
+		staticState      *requestState
+		staticTmpSiaPath modules.SiaPath
+
+		// uploadErr is set by the upload's goroutine before staticDone is
+		// closed.
+		uploadErr error
+
+		mu sync.Mutex
+	}
+)
+
+// ContentType implements webdav.ContentTyper.
+func (fi fileInfo) ContentType(_ context.Context) (string, error) {
+	if ctype := mime.TypeByExtension(filepath.Ext(fi.Name())); ctype != "" {
+		return ctype, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// newReadFile creates a file which reads the siafile at siaPath.
+func newReadFile(ctx context.Context, renter Renter, siaPath modules.SiaPath, fi os.FileInfo) *readFile {
+	return &readFile{
+		staticFileInfo: fi,
+		staticRenter:   renter,
+		staticSiaPath:  siaPath,
+		staticState:    stateFromContext(ctx),
+	}
+}
+
+// Close implements io.Closer.
+func (f *readFile) Close() error {
+	if f.streamer == nil {
+		return nil
+	}
+	return f.streamer.Close()
+}
+
+// Read implements io.Reader.
+func (f *readFile) Read(p []byte) (int, error) {
+	if f.streamer == nil {
+		_, streamer, err := f.staticRenter.Streamer(f.staticSiaPath, false)
+		if err != nil {
+			err = errors.AddContext(convertErr(err), "failed to create streamer")
+			f.staticState.managedSetErr(err)
+			return 0, err
+		}
+		f.streamer = streamer
+		if _, err := f.streamer.Seek(f.offset, io.SeekStart); err != nil {
+			f.staticState.managedSetErr(err)
+			return 0, err
+		}
+	}
+	n, err := f.streamer.Read(p)
+	f.offset += int64(n)
+	f.staticState.managedSetErr(err)
+	return n, err
+}
+
+// Readdir implements http.File.
+func (f *readFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+// Seek implements io.Seeker. Seeking doesn't create the file's streamer.
+func (f *readFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.staticFileInfo.Size()
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	if f.streamer != nil {
+		if _, err := f.streamer.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Stat implements http.File.
+func (f *readFile) Stat() (os.FileInfo, error) {
+	return f.staticFileInfo, nil
+}
+
+// Write implements io.Writer.
+func (f *readFile) Write([]byte) (int, error) {
+	return 0, errReadOnly
+}
+
+// newDirFile creates a file which lists the dir at siaPath.
+func newDirFile(fs *fileSystem, siaPath modules.SiaPath, fi os.FileInfo) *dirFile {
+	return &dirFile{
+		staticFileInfo:   fi,
+		staticFileSystem: fs,
+		staticSiaPath:    siaPath,
+	}
+}
+
+// Close implements io.Closer.
+func (f *dirFile) Close() error {
+	return nil
+}
+
+// Read implements io.Reader.
+func (f *dirFile) Read([]byte) (int, error) {
+	return 0, os.ErrInvalid
+}
+
+// Readdir implements http.File. It follows the semantics of os.File.Readdir.
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.listed {
+		entries, err := f.staticFileSystem.staticDirEntries(f.staticSiaPath)
+		if err != nil {
+			return nil, err
+		}
+		f.entries = entries
+		f.listed = true
+	}
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+// Seek implements io.Seeker.
+func (f *dirFile) Seek(int64, int) (int64, error) {
+	return 0, os.ErrInvalid
+}
+
+// Stat implements http.File.
+func (f *dirFile) Stat() (os.FileInfo, error) {
+	return f.staticFileInfo, nil
+}
+
+// Write implements io.Writer.
+func (f *dirFile) Write([]byte) (int, error) {
+	return 0, os.ErrInvalid
+}
+
+// staticDirEntries returns the infos of the dirs and files within the dir at
+// siaPath.
+func (fs *fileSystem) staticDirEntries(siaPath modules.SiaPath) ([]os.FileInfo, error) {
+	dis, err := fs.staticRenter.DirList(siaPath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	var entries []os.FileInfo
+	for _, di := range dis[1:] {
+		entries = append(entries, di)
+	}
+	var mu sync.Mutex
+	err = fs.staticRenter.FileList(siaPath, false, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, fileInfo{fi})
+	})
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return entries, nil
+}
+
+// newUploadFile creates a file which uploads the data written to it to the
+// renter. The upload replaces the file at siaPath once the file is closed.
+func newUploadFile(ctx context.Context, renter Renter, siaPath modules.SiaPath) (*uploadFile, error) {
+	tmpSiaPath, err := tmpFolder.Join(hex.EncodeToString(fastrand.Bytes(16)))
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	f := &uploadFile{
+		staticDone:       make(chan struct{}),
+		staticPipeWriter: pw,
+		staticRenter:     renter,
+		staticSiaPath:    siaPath,
+		staticState:      stateFromContext(ctx),
+		staticTmpSiaPath: tmpSiaPath,
+	}
+	go f.threadedUpload(pr)
+	return f, nil
+}
+
+// threadedUpload uploads the data read from the pipe to the temporary
+// siafile.
+func (f *uploadFile) threadedUpload(pr *io.PipeReader) {
+	err := f.staticRenter.UploadStreamFromReader(modules.FileUploadParams{
+		SiaPath: f.staticTmpSiaPath,
+	}, pr)
+	// Unblock the writer if the upload stopped reading early.
+	if err != nil {
+		_ = pr.CloseWithError(err)
+	} else {
+		_ = pr.Close()
+	}
+	f.uploadErr = err
+	close(f.staticDone)
+}
+
+// Close implements io.Closer. It waits for the upload to finish and replaces
+// the file with the uploaded data. If the upload or the request failed, the
+// uploaded data is discarded instead.
+func (f *uploadFile) Close() error {
+	_ = f.staticPipeWriter.Close()
+	<-f.staticDone
+	err := errors.Compose(f.uploadErr, f.staticState.managedErr())
+	if err != nil {
+		// The upload might have created the file before it failed.
+		deleteErr := f.staticRenter.DeleteFile(f.staticTmpSiaPath)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+		return errors.AddContext(err, "upload failed")
+	}
+	err = f.staticRenter.ReplaceFile(f.staticSiaPath, f.staticTmpSiaPath)
+	if err != nil {
+		err = errors.Compose(err, f.staticRenter.DeleteFile(f.staticTmpSiaPath))
+		return errors.AddContext(err, "failed to replace file with uploaded file")
+	}
+	return nil
+}
+
+// Read implements io.Reader.
+func (f *uploadFile) Read([]byte) (int, error) {
+	return 0, errWriteOnly
+}
+
+// Readdir implements http.File.
+func (f *uploadFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+// Seek implements io.Seeker. Uploads can't seek but the WebDAV handler might
+// ask for the current offset.
+func (f *uploadFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if offset == 0 && whence == io.SeekCurrent {
+		return f.written, nil
+	}
+	return 0, errWriteOnly
+}
+
+// Stat implements http.File. The returned size is the number of bytes written
+// so far.
+func (f *uploadFile) Stat() (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fileInfo{modules.FileInfo{
+		Filesize:         uint64(f.written),
+		FileMode:         modules.DefaultFilePerm,
+		ModificationTime: time.Now(),
+		SiaPath:          f.staticSiaPath,
+	}}, nil
+}
+
+// Write implements io.Writer.
+func (f *uploadFile) Write(p []byte) (int, error) {
+	n, err := f.staticPipeWriter.Write(p)
+	f.mu.Lock()
+	f.written += int64(n)
+	f.mu.Unlock()
+	return n, err
+}
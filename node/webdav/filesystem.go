@@ -0,0 +1,201 @@
+package webdav
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/webdav"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// tmpFolder is the folder which contains the files which are still being
+	// uploaded. Once an upload is complete, its file is moved to its
+	// destination.
+	tmpFolder = modules.NewGlobalSiaPath("/var/webdav")
+)
+
+// fileSystem implements webdav.FileSystem on top of the renter.
+type fileSystem struct {
+	staticRenter Renter
+	staticRoot   modules.SiaPath
+}
+
+// newFileSystem creates a new fileSystem which serves the dir at root.
+func newFileSystem(renter Renter, root modules.SiaPath) *fileSystem {
+	return &fileSystem{
+		staticRenter: renter,
+		staticRoot:   root,
+	}
+}
+
+// convertErr converts errors of the renter into the errors of the os package
+// which are expected by the WebDAV handler.
+func convertErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Contains(err, filesystem.ErrNotExist):
+		return os.ErrNotExist
+	case errors.Contains(err, filesystem.ErrExists):
+		return os.ErrExist
+	}
+	return err
+}
+
+// staticSiaPath returns the siapath of a file or dir.
+func (fs *fileSystem) staticSiaPath(name string) (modules.SiaPath, error) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return fs.staticRoot, nil
+	}
+	sp, err := fs.staticRoot.Join(name)
+	if err != nil {
+		return modules.SiaPath{}, os.ErrInvalid
+	}
+	return sp, nil
+}
+
+// staticCheckParent returns os.ErrNotExist if the parent dir of siaPath
+// doesn't exist. The renter would create missing parent dirs which isn't
+// expected by WebDAV clients.
+func (fs *fileSystem) staticCheckParent(siaPath modules.SiaPath) error {
+	parent, err := siaPath.Dir()
+	if err != nil {
+		return os.ErrInvalid
+	}
+	_, err = fs.staticRenter.DirList(parent)
+	return convertErr(err)
+}
+
+// staticStat returns the info of the file or dir at siaPath.
+func (fs *fileSystem) staticStat(siaPath modules.SiaPath) (os.FileInfo, error) {
+	fi, err := fs.staticRenter.File(siaPath)
+	if err == nil {
+		return fileInfo{fi}, nil
+	} else if !errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, err
+	}
+	dis, err := fs.staticRenter.DirList(siaPath)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return dis[0], nil
+}
+
+// Mkdir implements webdav.FileSystem. The parent dir needs to exist.
+func (fs *fileSystem) Mkdir(_ context.Context, name string, perm os.FileMode) error {
+	sp, err := fs.staticSiaPath(name)
+	if err != nil {
+		return err
+	}
+	if err := fs.staticCheckParent(sp); err != nil {
+		return err
+	}
+	if _, err := fs.staticRenter.File(sp); err == nil {
+		return os.ErrExist
+	}
+	return convertErr(fs.staticRenter.CreateDir(sp, perm.Perm()))
+}
+
+// OpenFile implements webdav.FileSystem. Files can either be opened for
+// reading or for writing. Opening a file for writing always replaces its
+// contents once the file is closed.
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	sp, err := fs.staticSiaPath(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fs.staticStat(sp)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	exists := err == nil
+
+	// Open the file for reading.
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		if !exists {
+			return nil, os.ErrNotExist
+		}
+		if fi.IsDir() {
+			return newDirFile(fs, sp, fi), nil
+		}
+		return newReadFile(ctx, fs.staticRenter, sp, fi), nil
+	}
+
+	// Open the file for writing.
+	switch {
+	case exists && fi.IsDir():
+		return nil, os.ErrExist
+	case exists && flag&os.O_EXCL != 0:
+		return nil, os.ErrExist
+	case exists && flag&os.O_TRUNC == 0:
+		return nil, errors.New("files can only be replaced as a whole")
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, os.ErrNotExist
+	}
+	if err := fs.staticCheckParent(sp); err != nil {
+		return nil, err
+	}
+	return newUploadFile(ctx, fs.staticRenter, sp)
+}
+
+// RemoveAll implements webdav.FileSystem.
+func (fs *fileSystem) RemoveAll(_ context.Context, name string) error {
+	sp, err := fs.staticSiaPath(name)
+	if err != nil {
+		return err
+	}
+	if sp.Equals(fs.staticRoot) {
+		return os.ErrPermission
+	}
+	err = fs.staticRenter.DeleteFile(sp)
+	if !errors.Contains(err, filesystem.ErrNotExist) {
+		return err
+	}
+	err = fs.staticRenter.DeleteDir(sp)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Rename implements webdav.FileSystem.
+func (fs *fileSystem) Rename(_ context.Context, oldName, newName string) error {
+	oldSiaPath, err := fs.staticSiaPath(oldName)
+	if err != nil {
+		return err
+	}
+	newSiaPath, err := fs.staticSiaPath(newName)
+	if err != nil {
+		return err
+	}
+	if oldSiaPath.Equals(fs.staticRoot) || newSiaPath.Equals(fs.staticRoot) {
+		return os.ErrPermission
+	}
+	if err := fs.staticCheckParent(newSiaPath); err != nil {
+		return err
+	}
+	fi, err := fs.staticStat(oldSiaPath)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return convertErr(fs.staticRenter.RenameDir(oldSiaPath, newSiaPath))
+	}
+	return convertErr(fs.staticRenter.RenameFile(oldSiaPath, newSiaPath))
+}
+
+// Stat implements webdav.FileSystem.
+func (fs *fileSystem) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	sp, err := fs.staticSiaPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.staticStat(sp)
+}
@@ -0,0 +1,125 @@
+// Package webdav provides a WebDAV server for the renter's filesystem. The
+// root of the server is the renter's user folder. Files are downloaded using
+// the renter's streamer and uploaded using streaming uploads. Requests are
+// authenticated using HTTP basic auth with the API password.
+package webdav
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"golang.org/x/net/webdav"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// Renter is the subset of the renter's methods used by the server.
+	Renter interface {
+		CreateDir(siaPath modules.SiaPath, mode os.FileMode) error
+		DeleteDir(siaPath modules.SiaPath) error
+		DeleteFile(siaPath modules.SiaPath) error
+		DirList(siaPath modules.SiaPath) ([]modules.DirectoryInfo, error)
+		File(siaPath modules.SiaPath) (modules.FileInfo, error)
+		FileList(siaPath modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error
+		RenameDir(oldPath, newPath modules.SiaPath) error
+		RenameFile(siaPath, newSiaPath modules.SiaPath) error
+		ReplaceFile(siaPath, srcSiaPath modules.SiaPath) error
+		Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (string, modules.Streamer, error)
+		UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error
+	}
+
+	// Server serves the renter's filesystem using WebDAV.
+	Server struct {
+		staticHandler  *webdav.Handler
+		staticPassword string
+	}
+
+	// requestState keeps track of errors encountered while reading data
+	// during a request. Uploads which were fed by a failed reader are
+	// aborted instead of being completed with partial data.
+	requestState struct {
+		err error
+		mu  sync.Mutex
+	}
+
+	// stateReader is a reader which records read errors in the request's
+	// state.
+	stateReader struct {
+		io.ReadCloser
+		staticState *requestState
+	}
+
+	// requestStateKey is the context key of a request's state.
+	requestStateKey struct{}
+)
+
+// New creates a new WebDAV server for the renter. If the password is not
+// empty, requests need to provide it using HTTP basic auth. The username is
+// ignored.
+func New(renter Renter, password string) *Server {
+	return &Server{
+		staticHandler: &webdav.Handler{
+			FileSystem: newFileSystem(renter, modules.UserFolder),
+			LockSystem: webdav.NewMemLS(),
+		},
+		staticPassword: password,
+	}
+}
+
+// ServeHTTP authenticates a request and passes it on to the WebDAV handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if s.staticPassword != "" {
+		_, password, ok := req.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(s.staticPassword)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Sia WebDAV"`)
+			http.Error(w, "API authentication failed.", http.StatusUnauthorized)
+			return
+		}
+	}
+	state := new(requestState)
+	req = req.WithContext(context.WithValue(req.Context(), requestStateKey{}, state))
+	if req.Body != nil {
+		req.Body = &stateReader{ReadCloser: req.Body, staticState: state}
+	}
+	s.staticHandler.ServeHTTP(w, req)
+}
+
+// stateFromContext returns the state of the request a context belongs to.
+func stateFromContext(ctx context.Context) *requestState {
+	state, ok := ctx.Value(requestStateKey{}).(*requestState)
+	if !ok {
+		return new(requestState)
+	}
+	return state
+}
+
+// managedErr returns the first read error of the request.
+func (rs *requestState) managedErr() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.err
+}
+
+// managedSetErr records a read error unless it is io.EOF.
+func (rs *requestState) managedSetErr(err error) {
+	if err == nil || err == io.EOF {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.err == nil {
+		rs.err = err
+	}
+}
+
+// Read implements io.Reader.
+func (sr *stateReader) Read(p []byte) (int, error) {
+	n, err := sr.ReadCloser.Read(p)
+	sr.staticState.managedSetErr(err)
+	return n, err
+}
@@ -0,0 +1,466 @@
+package webdav
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+type (
+	// fakeRenter is an in-memory implementation of the Renter interface.
+	fakeRenter struct {
+		dirs  map[modules.SiaPath]time.Time
+		files map[modules.SiaPath]fakeFile
+		mu    sync.Mutex
+	}
+
+	// fakeFile is a file of the fakeRenter.
+	fakeFile struct {
+		data    []byte
+		modTime time.Time
+	}
+
+	// nopCloser adds a no-op Close method to a bytes.Reader.
+	nopCloser struct {
+		*bytes.Reader
+	}
+
+	// failingReader returns its data followed by an error.
+	failingReader struct {
+		io.Reader
+	}
+
+	// serverTester is a helper for sending requests to a server.
+	serverTester struct {
+		staticRenter *fakeRenter
+		staticServer *Server
+		t            *testing.T
+	}
+)
+
+// errFailingReader is returned by failingReader.
+var errFailingReader = errors.New("connection reset")
+
+// newFakeRenter creates an empty fakeRenter with a user folder.
+func newFakeRenter() *fakeRenter {
+	return &fakeRenter{
+		dirs: map[modules.SiaPath]time.Time{
+			modules.RootSiaPath(): time.Now(),
+			modules.UserFolder:    time.Now(),
+		},
+		files: make(map[modules.SiaPath]fakeFile),
+	}
+}
+
+// Close implements io.Closer.
+func (nopCloser) Close() error { return nil }
+
+// Read implements io.Reader.
+func (fr failingReader) Read(p []byte) (int, error) {
+	n, err := fr.Reader.Read(p)
+	if err == io.EOF {
+		err = errFailingReader
+	}
+	return n, err
+}
+
+// isChild returns true if sp is a child of dir.
+func isChild(sp, dir modules.SiaPath, recursive bool) bool {
+	parent, err := sp.Dir()
+	if err != nil {
+		return false
+	}
+	if parent.Equals(dir) {
+		return true
+	}
+	return recursive && strings.HasPrefix(sp.String(), dir.String()+"/")
+}
+
+// createDirs creates all the parent dirs of sp.
+func (r *fakeRenter) createDirs(sp modules.SiaPath) {
+	for dir, err := sp.Dir(); err == nil && !dir.IsRoot(); dir, err = dir.Dir() {
+		if _, exists := r.dirs[dir]; !exists {
+			r.dirs[dir] = time.Now()
+		}
+	}
+}
+
+func (r *fakeRenter) CreateDir(sp modules.SiaPath, _ os.FileMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(sp)
+	r.dirs[sp] = time.Now()
+	return nil
+}
+
+func (r *fakeRenter) DeleteDir(sp modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	delete(r.dirs, sp)
+	for dir := range r.dirs {
+		if isChild(dir, sp, true) {
+			delete(r.dirs, dir)
+		}
+	}
+	for file := range r.files {
+		if isChild(file, sp, true) {
+			delete(r.files, file)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRenter) DeleteFile(sp modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.files[sp]; !exists {
+		return errors.AddContext(filesystem.ErrNotExist, "failed to delete file")
+	}
+	delete(r.files, sp)
+	return nil
+}
+
+func (r *fakeRenter) DirList(sp modules.SiaPath) ([]modules.DirectoryInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, exists := r.dirs[sp]
+	if !exists {
+		return nil, filesystem.ErrNotExist
+	}
+	dis := []modules.DirectoryInfo{{SiaPath: sp, MostRecentModTime: modTime, DirMode: os.ModeDir | modules.DefaultDirPerm}}
+	for dir, modTime := range r.dirs {
+		if isChild(dir, sp, false) {
+			dis = append(dis, modules.DirectoryInfo{SiaPath: dir, MostRecentModTime: modTime, DirMode: os.ModeDir | modules.DefaultDirPerm})
+		}
+	}
+	return dis, nil
+}
+
+func (r *fakeRenter) File(sp modules.SiaPath) (modules.FileInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	if !exists {
+		return modules.FileInfo{}, errors.AddContext(filesystem.ErrNotExist, "unable to get the fileinfo from the filesystem")
+	}
+	return f.info(sp), nil
+}
+
+func (r *fakeRenter) FileList(sp modules.SiaPath, recursive, _ bool, flf modules.FileListFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	for file, f := range r.files {
+		if isChild(file, sp, recursive) {
+			flf(f.info(file))
+		}
+	}
+	return nil
+}
+
+func (r *fakeRenter) RenameDir(sp, newSiaPath modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.dirs[sp]; !exists {
+		return filesystem.ErrNotExist
+	}
+	if _, exists := r.dirs[newSiaPath]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(newSiaPath)
+	for dir, modTime := range r.dirs {
+		if dir.Equals(sp) || isChild(dir, sp, true) {
+			rebased, err := dir.Rebase(sp, newSiaPath)
+			if err != nil {
+				return err
+			}
+			delete(r.dirs, dir)
+			r.dirs[rebased] = modTime
+		}
+	}
+	for file, f := range r.files {
+		if isChild(file, sp, true) {
+			rebased, err := file.Rebase(sp, newSiaPath)
+			if err != nil {
+				return err
+			}
+			delete(r.files, file)
+			r.files[rebased] = f
+		}
+	}
+	return nil
+}
+
+func (r *fakeRenter) RenameFile(sp, newSiaPath modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	if _, exists := r.files[newSiaPath]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(newSiaPath)
+	delete(r.files, sp)
+	r.files[newSiaPath] = f
+	return nil
+}
+
+func (r *fakeRenter) ReplaceFile(sp, srcSiaPath modules.SiaPath) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[srcSiaPath]
+	if !exists {
+		return filesystem.ErrNotExist
+	}
+	r.createDirs(sp)
+	delete(r.files, srcSiaPath)
+	r.files[sp] = f
+	return nil
+}
+
+func (r *fakeRenter) Streamer(sp modules.SiaPath, _ bool) (string, modules.Streamer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	if !exists {
+		return "", nil, filesystem.ErrNotExist
+	}
+	return sp.Name(), nopCloser{bytes.NewReader(f.data)}, nil
+}
+
+func (r *fakeRenter) UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.AddContext(err, "unable to stream an upload from a reader")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.files[up.SiaPath]; exists {
+		return filesystem.ErrExists
+	}
+	r.createDirs(up.SiaPath)
+	r.files[up.SiaPath] = fakeFile{
+		data:    data,
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+// info returns the file info of the file.
+func (f fakeFile) info(sp modules.SiaPath) modules.FileInfo {
+	return modules.FileInfo{
+		Filesize:         uint64(len(f.data)),
+		FileMode:         modules.DefaultFilePerm,
+		ModificationTime: f.modTime,
+		SiaPath:          sp,
+	}
+}
+
+// data returns the contents of the file at the path relative to the user
+// folder.
+func (r *fakeRenter) data(path string) ([]byte, bool) {
+	sp, err := modules.UserFolder.Join(path)
+	if err != nil {
+		panic(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, exists := r.files[sp]
+	return f.data, exists
+}
+
+// tmpFiles returns the number of files within the tmp folder.
+func (r *fakeRenter) tmpFiles() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for file := range r.files {
+		if isChild(file, tmpFolder, true) {
+			n++
+		}
+	}
+	return n
+}
+
+// newServerTester creates a server for a fakeRenter using the provided
+// password.
+func newServerTester(t *testing.T, password string) *serverTester {
+	r := newFakeRenter()
+	return &serverTester{
+		staticRenter: r,
+		staticServer: New(r, password),
+		t:            t,
+	}
+}
+
+// request sends a request to the server and returns the response.
+func (st *serverTester) request(method, target string, body io.Reader, header http.Header) *http.Response {
+	req := httptest.NewRequest(method, target, body)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	st.staticServer.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+// checkStatus sends a request and checks the status code of its response.
+func (st *serverTester) checkStatus(method, target string, body []byte, header http.Header, status int) []byte {
+	st.t.Helper()
+	resp := st.request(method, target, bytes.NewReader(body), header)
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		st.t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		st.t.Fatalf("%v %v: expected status %v but got %v: %s", method, target, status, resp.StatusCode, respBody)
+	}
+	return respBody
+}
+
+// TestAuthentication tests that requests need to provide the password.
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+	st := newServerTester(t, "password")
+
+	// Requests without the password are rejected.
+	resp := st.request("PROPFIND", "/", nil, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("expected 401 but got", resp.StatusCode)
+	}
+	if resp.Header.Get("WWW-Authenticate") == "" {
+		t.Fatal("missing WWW-Authenticate header")
+	}
+	req := httptest.NewRequest("PROPFIND", "/", nil)
+	req.SetBasicAuth("", "wrong")
+	rec := httptest.NewRecorder()
+	st.staticServer.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatal("expected 401 but got", rec.Code)
+	}
+
+	// The username is ignored.
+	req = httptest.NewRequest("PROPFIND", "/", nil)
+	req.SetBasicAuth("user", "password")
+	rec = httptest.NewRecorder()
+	st.staticServer.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatal("expected 207 but got", rec.Code)
+	}
+}
+
+// TestFiles tests creating, reading, moving and deleting files and dirs.
+func TestFiles(t *testing.T) {
+	t.Parallel()
+	st := newServerTester(t, "")
+
+	// Create a dir.
+	st.checkStatus("MKCOL", "/dir", nil, nil, http.StatusCreated)
+	st.checkStatus("MKCOL", "/dir", nil, nil, http.StatusMethodNotAllowed)
+	st.checkStatus("MKCOL", "/missing/dir", nil, nil, http.StatusConflict)
+
+	// Upload and replace a file.
+	st.checkStatus(http.MethodPut, "/dir/file.txt", []byte("hello"), nil, http.StatusCreated)
+	st.checkStatus(http.MethodPut, "/dir/file.txt", []byte("hello world"), nil, http.StatusCreated)
+	st.checkStatus(http.MethodPut, "/missing/file.txt", []byte("hello"), nil, http.StatusNotFound)
+	if data, _ := st.staticRenter.data("dir/file.txt"); string(data) != "hello world" {
+		t.Fatalf("wrong data %q", data)
+	}
+	if n := st.staticRenter.tmpFiles(); n != 0 {
+		t.Fatal("tmp files weren't moved", n)
+	}
+
+	// Download the file.
+	if data := st.checkStatus(http.MethodGet, "/dir/file.txt", nil, nil, http.StatusOK); string(data) != "hello world" {
+		t.Fatalf("wrong data %q", data)
+	}
+	header := http.Header{"Range": []string{"bytes=6-"}}
+	if data := st.checkStatus(http.MethodGet, "/dir/file.txt", nil, header, http.StatusPartialContent); string(data) != "world" {
+		t.Fatalf("wrong data %q", data)
+	}
+	st.checkStatus(http.MethodGet, "/dir/missing.txt", nil, nil, http.StatusNotFound)
+
+	// List the dir.
+	header = http.Header{"Depth": []string{"1"}}
+	listing := string(st.checkStatus("PROPFIND", "/dir", nil, header, http.StatusMultiStatus))
+	for _, s := range []string{"/dir/file.txt", "<D:getcontentlength>11</D:getcontentlength>", "text/plain"} {
+		if !strings.Contains(listing, s) {
+			t.Fatalf("listing doesn't contain %q: %v", s, listing)
+		}
+	}
+
+	// Copy and move the file.
+	header = http.Header{"Destination": []string{"/dir/copy.txt"}}
+	st.checkStatus("COPY", "/dir/file.txt", nil, header, http.StatusCreated)
+	header = http.Header{"Destination": []string{"/other/moved.txt"}}
+	st.checkStatus("MOVE", "/dir/file.txt", nil, header, http.StatusForbidden)
+	header = http.Header{"Destination": []string{"/dir/moved.txt"}}
+	st.checkStatus("MOVE", "/dir/file.txt", nil, header, http.StatusCreated)
+	for _, path := range []string{"dir/copy.txt", "dir/moved.txt"} {
+		if data, _ := st.staticRenter.data(path); string(data) != "hello world" {
+			t.Fatalf("wrong data %q for %v", data, path)
+		}
+	}
+	if _, exists := st.staticRenter.data("dir/file.txt"); exists {
+		t.Fatal("moved file still exists")
+	}
+
+	// Move the dir.
+	header = http.Header{"Destination": []string{"/other"}}
+	st.checkStatus("MOVE", "/dir", nil, header, http.StatusCreated)
+	if data, _ := st.staticRenter.data("other/moved.txt"); string(data) != "hello world" {
+		t.Fatalf("wrong data %q", data)
+	}
+
+	// Delete a file and the dir.
+	st.checkStatus(http.MethodDelete, "/other/copy.txt", nil, nil, http.StatusNoContent)
+	st.checkStatus(http.MethodDelete, "/other/copy.txt", nil, nil, http.StatusNotFound)
+	st.checkStatus(http.MethodDelete, "/other", nil, nil, http.StatusNoContent)
+	st.checkStatus("PROPFIND", "/other", nil, nil, http.StatusNotFound)
+	st.checkStatus(http.MethodDelete, "/", nil, nil, http.StatusMethodNotAllowed)
+	if _, exists := st.staticRenter.dirs[modules.UserFolder]; !exists {
+		t.Fatal("user folder was deleted")
+	}
+}
+
+// TestFailedUpload tests that an upload which fails to read the request body
+// doesn't replace an existing file.
+func TestFailedUpload(t *testing.T) {
+	t.Parallel()
+	st := newServerTester(t, "")
+	st.checkStatus(http.MethodPut, "/file", []byte("data"), nil, http.StatusCreated)
+
+	body := failingReader{strings.NewReader("partial")}
+	resp := st.request(http.MethodPut, "/file", body, nil)
+	if resp.StatusCode < 400 {
+		t.Fatal("failed upload succeeded with status", resp.StatusCode)
+	}
+	if data, _ := st.staticRenter.data("file"); string(data) != "data" {
+		t.Fatalf("file was replaced with %q", data)
+	}
+	if n := st.staticRenter.tmpFiles(); n != 0 {
+		t.Fatal("tmp files weren't deleted", n)
+	}
+}
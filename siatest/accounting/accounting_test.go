@@ -0,0 +1,79 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/siatest"
+)
+
+// TestAccountingAPI tests the /accounting endpoint.
+func TestAccountingAPI(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a node with the accounting module.
+	n, err := siatest.NewNode(node.Accounting(accountingTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The current accounting information should be returned.
+	ag, err := n.AccountingGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ag) != 1 {
+		t.Fatalf("expected 1 entry, got %v", len(ag))
+	}
+	wg, err := n.WalletGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ag[0].Wallet.ConfirmedSiacoinBalance.Equals(wg.ConfirmedSiacoinBalance) {
+		t.Errorf("siacoin balance mismatch: %v vs %v", ag[0].Wallet.ConfirmedSiacoinBalance, wg.ConfirmedSiacoinBalance)
+	}
+
+	// The background loop should persist accounting information that can be
+	// queried by range.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		ag, err = n.AccountingRangeGet(0, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		if len(ag) == 0 {
+			return errors.New("no persisted accounting information")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A range before any entry was persisted should be empty.
+	ag, err = n.AccountingRangeGet(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ag) != 0 {
+		t.Fatalf("expected 0 entries, got %v", len(ag))
+	}
+
+	// An invalid range should return an error.
+	_, err = n.AccountingRangeGet(1, 0)
+	if err == nil {
+		t.Fatal("expected an error for an invalid range")
+	}
+}
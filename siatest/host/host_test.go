@@ -3,16 +3,22 @@ package host
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/host"
 	"go.sia.tech/siad/modules/host/contractmanager"
+	"go.sia.tech/siad/modules/host/registry"
+	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/node/api/client"
@@ -698,3 +704,304 @@ func TestHostGetPriceTable(t *testing.T) {
 		t.Fatal("wrong subscription notification cost")
 	}
 }
+
+// TestHostRegistryAdmin tests the host's registry administration endpoints.
+func TestHostRegistryAdmin(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a renter to update the registry of the hosts.
+	groupParams := siatest.GroupParams{
+		Hosts:   renter.MinUpdateRegistrySuccesses,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(hostTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+	h := tg.Hosts()[0]
+
+	// Update a registry entry. Retry until the renter's workers are ready.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	srv := modules.NewRegistryValue(tweak, fastrand.Bytes(modules.RegistryDataSize), 1, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		return r.RenterRegistryPost(spk, srv)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid := modules.DeriveRegistryEntryID(spk, tweak)
+
+	// The host should list the entry.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		hrg, err := h.HostRegistryGet()
+		if err != nil {
+			return err
+		}
+		if hrg.Entries != 1 || len(hrg.Keys) != 1 || !hrg.Keys[0].PublicKey.Equals(spk) {
+			return fmt.Errorf("wrong stats %v %v", hrg.Entries, hrg.Keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Look up the entry.
+	hreg, err := h.HostRegistryEntryGet(sid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hreg.Entry.Revision != srv.Revision || !bytes.Equal(hreg.Entry.Data, srv.Data) {
+		t.Fatal("wrong entry", hreg.Entry)
+	}
+
+	// Delete it.
+	if err := h.HostRegistryDeletePost(sid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.HostRegistryEntryGet(sid); err == nil || !strings.Contains(err.Error(), registry.ErrEntryNotFound.Error()) {
+		t.Fatal("expected entry to be deleted", err)
+	}
+	if err := h.HostRegistryDeletePost(sid); err == nil {
+		t.Fatal("deleting a missing entry should fail")
+	}
+
+	// Truncate the registry.
+	if err := h.HostRegistryTruncatePost(64 * modules.RegistryEntrySize); err != nil {
+		t.Fatal(err)
+	}
+	hrg, err := h.HostRegistryGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hrg.Capacity != 64 || hrg.Entries != 0 {
+		t.Fatal("wrong stats after truncate", hrg.Capacity, hrg.Entries)
+	}
+	hg, err := h.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hg.InternalSettings.RegistrySize != 64*modules.RegistryEntrySize {
+		t.Fatal("registry size wasn't updated", hg.InternalSettings.RegistrySize)
+	}
+}
+
+// TestHostPricing tests configuring the host's dynamic pricing through the API.
+func TestHostPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create Host
+	testDir := hostTestDir(t.Name())
+	hostParams := node.Host(testDir)
+	host, err := siatest.NewCleanNode(hostParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := host.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Dynamic pricing is disabled by default.
+	hpg, err := host.HostPricingGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hg, err := host.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hpg.Enabled || !hpg.Current.StoragePrice.Equals(hg.InternalSettings.MinStoragePrice) {
+		t.Fatal("unexpected pricing", hpg.Enabled, hpg.Current)
+	}
+
+	// Target prices require an exchange rate.
+	err = host.HostModifySettingPost(client.HostParamPricingTargetStoragePrice, 10)
+	if err == nil {
+		t.Fatal("setting a target price without an exchange rate should fail")
+	}
+
+	// Do a dry run with a higher minimum storage price.
+	price := hg.InternalSettings.MinStoragePrice.Mul64(2)
+	hpg, err = host.HostPricingDryRunGet(client.HostParamMinStoragePrice, price)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hpg.DryRun.StoragePrice.Cmp(price) < 0 || !hpg.Current.StoragePrice.Equals(hg.InternalSettings.MinStoragePrice) {
+		t.Fatal("unexpected dry run", hpg.Current, hpg.DryRun)
+	}
+
+	// Enable dynamic pricing with a target price.
+	err = host.HostModifySettingPost(client.HostParamPricingExchangeRate, "0.5usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = host.HostModifySettingPost(client.HostParamPricingTargetStoragePrice, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = host.HostModifySettingPost(client.HostParamPricingEnabled, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := types.SiacoinPrecision.Mul64(20).Div(modules.BlockBytesPerMonthTerabyte)
+	hg, err = host.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hg.InternalSettings.PricingPolicy.Enabled || hg.ExternalSettings.StoragePrice.Cmp(target) < 0 {
+		t.Fatal("host doesn't use the dynamic price", hg.ExternalSettings.StoragePrice, target)
+	}
+}
+
+// TestHostMaintenance tests putting the host into maintenance mode through
+// the API.
+func TestHostMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create Host
+	testDir := hostTestDir(t.Name())
+	hostParams := node.Host(testDir)
+	host, err := siatest.NewCleanNode(hostParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := host.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The host isn't in maintenance mode by default.
+	hmg, err := host.HostMaintenanceGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hmg.MaintenanceMode || hmg.SafeToShutDown || len(hmg.PendingObligations) != 0 {
+		t.Fatal("unexpected maintenance status", hmg)
+	}
+
+	// A maintenance window can't end before it starts.
+	err = host.HostModifySettingPost(client.HostParamMaintenanceWindowStart, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = host.HostModifySettingPost(client.HostParamMaintenanceWindowEnd, 50)
+	if err == nil {
+		t.Fatal("setting an invalid maintenance window should fail")
+	}
+	err = host.HostModifySettingPost(client.HostParamMaintenanceWindowEnd, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Enable maintenance mode. The host doesn't have any contracts so it is
+	// drained right away.
+	err = host.HostModifySettingPost(client.HostParamAcceptingContracts, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = host.HostModifySettingPost(client.HostParamMaintenanceMode, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hg, err := host.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hg.ExternalSettings.AcceptingContracts || !hg.InternalSettings.MaintenanceMode {
+		t.Fatal("host in maintenance mode shouldn't accept contracts")
+	}
+	if hg.WorkingStatus != modules.HostWorkingStatusDrained {
+		t.Fatal("unexpected working status", hg.WorkingStatus)
+	}
+	hmg, err = host.HostMaintenanceGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hmg.MaintenanceMode || !hmg.SafeToShutDown || hmg.WindowStart != 100 || hmg.WindowEnd != 200 {
+		t.Fatal("unexpected maintenance status", hmg)
+	}
+}
+
+// TestHostStorageFolderMigration tests migrating a storage folder through the
+// API.
+func TestHostStorageFolderMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create Host
+	testDir := hostTestDir(t.Name())
+	hostParams := node.Host(testDir)
+	host, err := siatest.NewCleanNode(hostParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := host.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add two storage folders.
+	source := filepath.Join(testDir, "source")
+	destination := filepath.Join(testDir, "destination")
+	for _, path := range []string{source, destination} {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := host.HostStorageFoldersAddPost(path, 1<<24); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Migrating to an unknown folder should fail.
+	err = host.HostStorageFoldersMigratePost(source, filepath.Join(testDir, "unknown"), 0, 0)
+	if err == nil {
+		t.Fatal("migrating to an unknown folder should fail")
+	}
+
+	// Migrate the source. It should be removed afterwards.
+	err = host.HostStorageFoldersMigratePost(source, destination, 1<<20, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sg, err := host.HostStorageGet()
+		if err != nil {
+			return err
+		}
+		if len(sg.Migrations) != 0 {
+			return errors.New("migration is still in progress")
+		}
+		if len(sg.Folders) != 1 || sg.Folders[0].Path != destination {
+			return fmt.Errorf("unexpected folders %v", sg.Folders)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
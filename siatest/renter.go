@@ -314,8 +314,40 @@ func (tn *TestNode) StreamWithDiskFetch(rf *RemoteFile, disableLocalFetch bool)
 
 // Upload uses the node to upload the file with the option to overwrite if exists.
 func (tn *TestNode) Upload(lf *LocalFile, siapath modules.SiaPath, dataPieces, parityPieces uint64, force bool) (*RemoteFile, error) {
+	return tn.UploadWithErasureCoder(lf, siapath, dataPieces, parityPieces, "", force)
+}
+
+// UploadWithErasureCoder uses the node to upload the file using the specified
+// erasure coder.
+func (tn *TestNode) UploadWithErasureCoder(lf *LocalFile, siapath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder string, force bool) (*RemoteFile, error) {
+	return tn.UploadCustom(lf, siapath, dataPieces, parityPieces, erasureCoder, "", force)
+}
+
+// UploadCustom uses the node to upload the file using the specified erasure
+// coder and cipher type.
+func (tn *TestNode) UploadCustom(lf *LocalFile, siapath modules.SiaPath, dataPieces, parityPieces uint64, erasureCoder, cipherType string, force bool) (*RemoteFile, error) {
+	// Upload file
+	err := tn.RenterUploadCustomPost(lf.path, siapath, dataPieces, parityPieces, erasureCoder, cipherType, force)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to upload from "+lf.path+" to "+siapath.String())
+	}
+	// Create remote file object
+	rf := &RemoteFile{
+		siaPath:  siapath,
+		checksum: lf.checksum,
+	}
+	// Make sure renter tracks file
+	_, err = tn.File(rf)
+	if err != nil {
+		return rf, ErrFileNotTracked
+	}
+	return rf, nil
+}
+
+// UploadDedup uses the node to upload the file in dedup mode.
+func (tn *TestNode) UploadDedup(lf *LocalFile, siapath modules.SiaPath, dataPieces, parityPieces uint64) (*RemoteFile, error) {
 	// Upload file
-	err := tn.RenterUploadForcePost(lf.path, siapath, dataPieces, parityPieces, force)
+	err := tn.RenterUploadDedupPost(lf.path, siapath, dataPieces, parityPieces, "", "", false)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to upload from "+lf.path+" to "+siapath.String())
 	}
@@ -332,6 +364,38 @@ func (tn *TestNode) Upload(lf *LocalFile, siapath modules.SiaPath, dataPieces, p
 	return rf, nil
 }
 
+// UploadPacked uses the node to upload multiple small files which are packed
+// into shared chunks.
+func (tn *TestNode) UploadPacked(lfs []*LocalFile, dataPieces, parityPieces uint64) ([]*RemoteFile, error) {
+	// Upload files
+	files := make([]modules.PackedUploadFile, 0, len(lfs))
+	for _, lf := range lfs {
+		files = append(files, modules.PackedUploadFile{
+			Source:  lf.path,
+			SiaPath: tn.SiaPath(lf.path),
+		})
+	}
+	err := tn.RenterUploadPackedPost(files, dataPieces, parityPieces, "", "", false)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to upload packed files")
+	}
+	// Create remote file objects
+	rfs := make([]*RemoteFile, 0, len(lfs))
+	for i, lf := range lfs {
+		rf := &RemoteFile{
+			siaPath:  files[i].SiaPath,
+			checksum: lf.checksum,
+		}
+		// Make sure renter tracks file
+		_, err = tn.File(rf)
+		if err != nil {
+			return rfs, ErrFileNotTracked
+		}
+		rfs = append(rfs, rf)
+	}
+	return rfs, nil
+}
+
 // UploadDirectory uses the node to upload a directory
 func (tn *TestNode) UploadDirectory(ld *LocalDir) (*RemoteDir, error) {
 	// Check for edge cases.
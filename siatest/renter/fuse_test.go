@@ -733,9 +733,150 @@ func TestFuse(t *testing.T) {
 		t.Fatal("should not be able to make a directory in a read-only fuse system")
 	}
 
-	// TODO: When read-write fuse is supported, switch over to read-write mode
-	// here and begin testing the write features. Extend the concurrency test to
-	// probe write features as well, probably by adding more phases.
+	// Mount the root read-write and probe the write features.
+	rwMount := filepath.Join(testDir, "rwMount")
+	err = os.MkdirAll(rwMount, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwOpts := modules.MountOptions{ReadOnly: false}
+	err = r.RenterFuseMount(rwMount, modules.RootSiaPath(), rwOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwDirSiaPath, err := modules.NewSiaPath("rw-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwDirPath, err := siaPathToFusePath(rwDirSiaPath, modules.RootSiaPath(), rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(rwDirPath, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterDirGet(rwDirSiaPath)
+	if err != nil {
+		t.Fatal("dir created through fuse doesn't exist:", err)
+	}
+
+	// Create a file, write to it and check that it was uploaded once it is
+	// closed.
+	rwFileSiaPath, err := rwDirSiaPath.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwFilePath := filepath.Join(rwDirPath, "file")
+	rwData := fastrand.Bytes(100)
+	err = ioutil.WriteFile(rwFilePath, rwData, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(rwFileSiaPath)
+	if err != nil {
+		t.Fatal("file written through fuse doesn't exist:", err)
+	}
+	if rf.File.Filesize != uint64(len(rwData)) {
+		t.Fatal("wrong filesize", rf.File.Filesize, len(rwData))
+	}
+	readData, err := ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, rwData) {
+		t.Fatal("data mismatch after writing through fuse")
+	}
+
+	// Append to the file.
+	rwFile, err := os.OpenFile(rwFilePath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendData := fastrand.Bytes(50)
+	_, err = rwFile.Write(appendData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rwFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData, err = ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, append(rwData, appendData...)) {
+		t.Fatal("data mismatch after appending through fuse")
+	}
+	// The file's inode should report the size of the uploaded file.
+	rwInfo, err := os.Stat(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rwInfo.Size() != int64(len(rwData)+len(appendData)) {
+		t.Fatal("wrong size after appending through fuse", rwInfo.Size())
+	}
+
+	// Truncate the file.
+	err = os.Truncate(rwFilePath, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData, err = ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, rwData[:10]) {
+		t.Fatal("data mismatch after truncating through fuse")
+	}
+	rwInfo, err = os.Stat(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rwInfo.Size() != 10 {
+		t.Fatal("wrong size after truncating through fuse", rwInfo.Size())
+	}
+
+	// Rename the file and the dir.
+	rwRenamedFilePath := filepath.Join(rwDirPath, "renamed")
+	err = os.Rename(rwFilePath, rwRenamedFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwRenamedDirPath := rwDirPath + "-renamed"
+	err = os.Rename(rwDirPath, rwRenamedDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData, err = ioutil.ReadFile(filepath.Join(rwRenamedDirPath, "renamed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, rwData[:10]) {
+		t.Fatal("data mismatch after renaming through fuse")
+	}
+
+	// Non-empty dirs can't be removed. Unlink the file and remove the dir.
+	err = syscall.Rmdir(rwRenamedDirPath)
+	if err != syscall.ENOTEMPTY {
+		t.Fatal("expected ENOTEMPTY but got", err)
+	}
+	err = os.Remove(filepath.Join(rwRenamedDirPath, "renamed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Remove(rwRenamedDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(rwRenamedDirPath); !os.IsNotExist(err) {
+		t.Fatal("dir still exists after removing it through fuse:", err)
+	}
+	err = r.RenterFuseUnmount(rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Inode check. Mount the root siafile to a special inode mountpoint then
 	// open several files and directoriesk. Grab their inodes. Keep the folder
@@ -61,6 +61,12 @@ func TestRenterOne(t *testing.T) {
 		{Name: "TestPriceTablesUpdated", Test: testPriceTablesUpdated},
 		{Name: "TestFileAvailableAndRecoverable", Test: testFileAvailableAndRecoverable},
 		{Name: "TestReceivedFieldEqualsFileSize", Test: testReceivedFieldEqualsFileSize},
+		{Name: "TestFileSpending", Test: testFileSpending},
+		{Name: "TestLeopardUploadDownload", Test: testLeopardUploadDownload},
+		{Name: "TestRekeyFile", Test: testRekeyFile},
+		{Name: "TestUploadPacked", Test: testUploadPacked},
+		{Name: "TestUploadDedup", Test: testUploadDedup},
+		{Name: "TestRenterRegistry", Test: testRenterRegistry},
 	}
 
 	// Run tests
@@ -310,6 +316,473 @@ func testAllowanceDefaultSet(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testLeopardUploadDownload tests uploading and downloading a file using the
+// leopard erasure coder.
+func testLeopardUploadDownload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file that spans multiple chunks.
+	dataPieces := uint64(2)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	lf, err := r.FilesDir().NewFile(int(2*modules.SectorSize*dataPieces) + siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.UploadWithErasureCoder(lf, r.SiaPath(lf.Path()), dataPieces, parityPieces, api.ErasureCoderLeopard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitForUploadHealth(rf); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Redundancy != float64(dataPieces+parityPieces)/float64(dataPieces) {
+		t.Fatal("wrong redundancy", fi.Redundancy)
+	}
+
+	// Download the whole file and a part of it.
+	if _, _, err := r.DownloadByStream(rf); err != nil {
+		t.Fatal(err)
+	}
+	from := fastrand.Uint64n(fi.Filesize / 2)
+	to := from + fastrand.Uint64n(fi.Filesize/2) + 1
+	if _, err := r.StreamPartial(rf, lf, from, to); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRekeyFile tests uploading a file using XChaCha20 and re-encrypting it
+// under a new key.
+func testRekeyFile(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file using XChaCha20.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	lf, err := r.FilesDir().NewFile(int(modules.SectorSize) + siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	xchacha := crypto.TypeXChaCha20.String()
+	rf, err := r.UploadCustom(lf, r.SiaPath(lf.Path()), dataPieces, parityPieces, "", xchacha, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WaitForUploadHealth(rf); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.CipherType != xchacha {
+		t.Fatalf("expected cipher type %v but was %v", xchacha, fi.CipherType)
+	}
+
+	// waitForRekey is a helper that waits for the file to be re-encrypted
+	// using the provided cipher type.
+	waitForRekey := func(cipherType string) {
+		err := build.Retry(100, 100*time.Millisecond, func() error {
+			rrg, err := r.RenterRekeyGet()
+			if err != nil {
+				return err
+			}
+			for _, info := range rrg.Rekeys {
+				if !info.SiaPath.Equals(rf.SiaPath()) || info.CipherType != cipherType {
+					continue
+				}
+				if !info.Finished {
+					return errors.New("file is still being re-encrypted")
+				}
+				if info.Error != "" {
+					t.Fatal("re-encryption failed:", info.Error)
+				}
+				if info.Processed != info.Size || info.Size != uint64(fi.Filesize) {
+					t.Fatalf("expected %v processed bytes but got %v", fi.Filesize, info.Processed)
+				}
+				return nil
+			}
+			return errors.New("re-encryption not found")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rekey the file without specifying a cipher type. The cipher type should
+	// stay the same.
+	if err := r.RenterFileRekeyPost(rf.SiaPath(), ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForRekey(xchacha)
+	fi, err = r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.CipherType != xchacha {
+		t.Fatalf("expected cipher type %v but was %v", xchacha, fi.CipherType)
+	}
+
+	// Rekey the file using threefish.
+	threefish := crypto.TypeThreefish.String()
+	if err := r.RenterFileRekeyPost(rf.SiaPath(), threefish); err != nil {
+		t.Fatal(err)
+	}
+	waitForRekey(threefish)
+	fi, err = r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.CipherType != threefish {
+		t.Fatalf("expected cipher type %v but was %v", threefish, fi.CipherType)
+	}
+	if fi.LocalPath != lf.Path() {
+		t.Fatalf("expected local path %v but was %v", lf.Path(), fi.LocalPath)
+	}
+
+	// The file should be repaired and still be downloadable.
+	if err := r.WaitForUploadHealth(rf); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.DownloadByStream(rf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rekeying with an invalid cipher should fail.
+	if err := r.RenterFileRekeyPost(rf.SiaPath(), "plaintext"); err == nil {
+		t.Fatal("expected rekeying with plaintext to fail")
+	}
+}
+
+// testUploadPacked tests uploading small files which are packed into shared
+// chunks, downloading them and deleting them again.
+func testUploadPacked(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create some small files.
+	var lfs []*siatest.LocalFile
+	for _, size := range []int{1, 100 + siatest.Fuzz(), int(modules.SectorSize / 2), int(modules.SectorSize)} {
+		lf, err := r.FilesDir().NewFile(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lfs = append(lfs, lf)
+	}
+
+	// Upload them packed.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	rfs, err := r.UploadPacked(lfs, dataPieces, parityPieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rf := range rfs {
+		if err := r.WaitForUploadHealth(rf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// There should be fewer packs than files.
+	packedSiaPath := modules.NewGlobalSiaPath("/var/packed")
+	rd, err := r.RenterDirRootGet(packedSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) == 0 || len(rd.Files) >= len(rfs) {
+		t.Fatalf("expected fewer packs than files but got %v packs", len(rd.Files))
+	}
+
+	// Download the files from the network and from disk.
+	for _, rf := range rfs {
+		if _, _, err := r.DownloadByStreamWithDiskFetch(rf, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := r.DownloadToDiskWithDiskFetch(rf, false, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := r.DownloadToDiskWithDiskFetch(rf, false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Download a range of each file.
+	for i, rf := range rfs {
+		size := uint64(lfs[i].Size())
+		offset := size / 2
+		length := size - offset
+		if _, _, err := r.DownloadToDiskPartial(rf, lfs[i], false, offset, length); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.StreamPartial(rf, lfs[i], offset, size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replace the files with new data using force.
+	files := make([]modules.PackedUploadFile, 0, len(rfs))
+	newLfs := make([]*siatest.LocalFile, 0, len(rfs))
+	for i, rf := range rfs {
+		lf, err := r.FilesDir().NewFile(lfs[i].Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, modules.PackedUploadFile{Source: lf.Path(), SiaPath: rf.SiaPath()})
+		newLfs = append(newLfs, lf)
+	}
+	if err := r.RenterUploadPackedPost(files, dataPieces, parityPieces, "", "", true); err != nil {
+		t.Fatal(err)
+	}
+	for i, rf := range rfs {
+		data, err := r.RenterStreamGet(rf.SiaPath(), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := newLfs[i].Equal(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Delete the files. The packs should be deleted as well.
+	for _, rf := range rfs {
+		if err := r.RenterFileDeletePost(rf.SiaPath()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rd, err = r.RenterDirRootGet(packedSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) != 0 {
+		t.Fatalf("expected all packs to be deleted but got %v", len(rd.Files))
+	}
+}
+
+// testUploadDedup tests uploading the same data twice in dedup mode,
+// downloading it and deleting the files again.
+func testUploadDedup(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a file with a few chunks.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter)
+	lf, err := r.FilesDir().NewFile(int(3 * chunkSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload it in dedup mode twice.
+	var rfs []*siatest.RemoteFile
+	for i := 0; i < 2; i++ {
+		rf, err := r.UploadDedup(lf, modules.RandomSiaPath(), dataPieces, parityPieces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.WaitForUploadHealth(rf); err != nil {
+			t.Fatal(err)
+		}
+		rfs = append(rfs, rf)
+	}
+
+	// The first upload had nothing to deduplicate, the second one should have
+	// reused the first upload's pieces.
+	maxSavings := 3 * (dataPieces + parityPieces) * modules.SectorSize
+	var savings uint64
+	for i, rf := range rfs {
+		fi, err := r.File(rf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && fi.DedupSavings != 0 {
+			t.Fatal("first upload shouldn't have savings", fi.DedupSavings)
+		}
+		if i == 1 && (fi.DedupSavings == 0 || fi.DedupSavings > maxSavings) {
+			t.Fatalf("expected up to %v savings but got %v", maxSavings, fi.DedupSavings)
+		}
+		savings += fi.DedupSavings
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if err := r.RenterBubblePost(modules.RootSiaPath(), true, true); err != nil {
+			return err
+		}
+		rd, err := r.RenterDirRootGet(modules.RootSiaPath())
+		if err != nil {
+			return err
+		}
+		if rd.Directories[0].AggregateDedupSavings < savings {
+			return fmt.Errorf("expected at least %v aggregate savings but got %v", savings, rd.Directories[0].AggregateDedupSavings)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the first file. The second one should still be downloadable.
+	if err := r.RenterFileDeletePost(rfs[0].SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.DownloadByStream(rfs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterFileDeletePost(rfs[1].SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRenterRegistry tests reading, updating and watching a registry entry
+// through the renter's registry endpoints.
+func testRenterRegistry(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+
+	// The entry doesn't exist yet.
+	_, err := r.RenterRegistryGet(spk, dataKey, time.Second)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrRegistryEntryNotFound.Error()) {
+		t.Fatal("expected entry not to be found", err)
+	}
+
+	// Set the entry and read it again.
+	srv := modules.NewRegistryValue(dataKey, fastrand.Bytes(modules.RegistryDataSize), 1, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	if err := r.RenterRegistryPost(spk, srv); err != nil {
+		t.Fatal(err)
+	}
+	readSRV, err := r.RenterRegistryGet(spk, dataKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(readSRV, srv) {
+		t.Fatal("wrong value", readSRV, srv)
+	}
+
+	// Setting a lower revision should fail.
+	lower := modules.NewRegistryValue(dataKey, fastrand.Bytes(modules.RegistryDataSize), 0, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	if err := r.RenterRegistryPost(spk, lower); err == nil {
+		t.Fatal("expected update with lower revision to fail")
+	}
+
+	// An invalid signature should be rejected.
+	invalid := srv
+	invalid.Revision++
+	if err := r.RenterRegistryPost(spk, invalid); err == nil {
+		t.Fatal("expected update with invalid signature to fail")
+	}
+
+	// Watching the current revision should return immediately.
+	watchSRV, err := r.RenterRegistryWatchGet(spk, dataKey, srv.Revision, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(watchSRV, srv) {
+		t.Fatal("wrong value", watchSRV, srv)
+	}
+
+	// Watching a revision that doesn't exist yet should time out.
+	_, err = r.RenterRegistryWatchGet(spk, dataKey, srv.Revision+1, time.Second)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrRegistryWatchTimeout.Error()) {
+		t.Fatal("expected watch to time out", err)
+	}
+
+	// Start watching for the next revision and update the entry.
+	nextRevision := srv.Revision + 1
+	watchChan := make(chan modules.SignedRegistryValue)
+	errChan := make(chan error)
+	go func() {
+		srv, err := r.RenterRegistryWatchGet(spk, dataKey, nextRevision, 0)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		watchChan <- srv
+	}()
+	srv = modules.NewRegistryValue(dataKey, fastrand.Bytes(modules.RegistryDataSize), nextRevision, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	if err := r.RenterRegistryPost(spk, srv); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case watchSRV = <-watchChan:
+	case err := <-errChan:
+		t.Fatal(err)
+	case <-time.After(renter.MaxRegistryWatchTimeout + 5*time.Second):
+		t.Fatal("watch didn't return")
+	}
+	if !reflect.DeepEqual(watchSRV, srv) {
+		t.Fatal("wrong value", watchSRV, srv)
+	}
+}
+
+// testFileSpending tests that the money spent on uploading and downloading a
+// file is attributed to the file and bubbled to its directory.
+func testFileSpending(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file. The upload and storage spending should be attributed to
+	// the file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Spending.UploadSpending.IsZero() || fi.Spending.StorageSpending.IsZero() {
+		t.Fatal("upload spending wasn't attributed to the file", fi.Spending)
+	}
+	if !fi.Spending.DownloadSpending.IsZero() {
+		t.Fatal("file shouldn't have download spending yet", fi.Spending)
+	}
+
+	// Download the file. The download spending should be attributed to the
+	// file.
+	_, _, err = r.DownloadByStream(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err = r.File(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Spending.DownloadSpending.IsZero() {
+		t.Fatal("download spending wasn't attributed to the file", fi.Spending)
+	}
+
+	// The spending should be bubbled to the file's directory.
+	dirSiaPath, err := rf.SiaPath().Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		err := r.RenterBubblePost(dirSiaPath, true, false)
+		if err != nil {
+			return err
+		}
+		rd, err := r.RenterDirGet(dirSiaPath)
+		if err != nil {
+			return err
+		}
+		di := rd.Directories[0]
+		if di.Spending.Total().Cmp(fi.Spending.Total()) < 0 {
+			return fmt.Errorf("directory spending %v is less than file spending %v", di.Spending, fi.Spending)
+		}
+		if di.AggregateSpending.Total().Cmp(di.Spending.Total()) < 0 {
+			return fmt.Errorf("aggregate spending %v is less than spending %v", di.AggregateSpending, di.Spending)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testReceivedFieldEqualsFileSize tests that the bug that caused finished
 // downloads to stall in the UI and siac is gone.
 func testReceivedFieldEqualsFileSize(t *testing.T, tg *siatest.TestGroup) {
@@ -72,12 +72,12 @@ func TestSession(t *testing.T) {
 
 	// upload a sector
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, root, err := s.Append(sector)
+	_, root, _, err := s.Append(sector)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// upload another sector, to test Merkle proofs
-	_, _, err = s.Append(sector)
+	_, _, _, err = s.Append(sector)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -260,7 +260,7 @@ func TestHostLockTimeout(t *testing.T) {
 		errCh <- nil
 	}()
 	time.Sleep(3 * time.Second) // wait for goroutine to start acquiring lock
-	contract, _, err = s2.Append(make([]byte, modules.SectorSize))
+	contract, _, _, err = s2.Append(make([]byte, modules.SectorSize))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -330,7 +330,7 @@ func TestHostBaseRPCPrice(t *testing.T) {
 
 	// Upload a sector.
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, _, err = s.Append(sector)
+	_, _, _, err = s.Append(sector)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -349,7 +349,7 @@ func TestHostBaseRPCPrice(t *testing.T) {
 	}
 
 	// Attempt to upload another sector.
-	_, _, err = s.Append(sector)
+	_, _, _, err = s.Append(sector)
 	if err == nil || !strings.Contains(err.Error(), "rejected for high paying renter valid output") {
 		t.Fatal("expected underpayment error, got", err)
 	}
@@ -400,7 +400,7 @@ func TestMultiRead(t *testing.T) {
 
 	// upload a sector
 	sector := fastrand.Bytes(int(modules.SectorSize))
-	_, root, err := s.Append(sector)
+	_, root, _, err := s.Append(sector)
 	if err != nil {
 		t.Fatal(err)
 	}
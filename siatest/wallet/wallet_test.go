@@ -14,6 +14,7 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
@@ -969,3 +970,516 @@ func TestWalletVerifyPassword(t *testing.T) {
 		t.Error("Password should not be valid")
 	}
 }
+
+// TestWalletBumpTransaction tests bumping the fee of an unconfirmed
+// transaction through the API.
+func TestWalletBumpTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// unconfirmed is a helper that returns the ids of the unconfirmed
+	// transactions of the wallet.
+	unconfirmed := func() map[types.TransactionID]struct{} {
+		wtg, err := testNode.WalletTransactionsGet(0, math.MaxUint64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[types.TransactionID]struct{})
+		for _, txn := range wtg.UnconfirmedTransactions {
+			ids[txn.TransactionID] = struct{}{}
+		}
+		return ids
+	}
+
+	// Send some coins and bump the fee of the transaction.
+	outputs := []types.SiacoinOutput{{Value: types.SiacoinPrecision}}
+	wsp, err := testNode.WalletSiacoinsWithSelectionPost(outputs, modules.CoinSelection{Replaceable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	_, err = testNode.WalletTransactionBumpPost(txid, "foo", types.ZeroCurrency)
+	if err == nil || !strings.Contains(err.Error(), "strategy must be") {
+		t.Fatal("expected an error for an unknown strategy", err)
+	}
+	rbf, err := testNode.WalletTransactionBumpPost(txid, modules.FeeBumpRBF, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbfID := rbf.TransactionIDs[len(rbf.TransactionIDs)-1]
+	ids := unconfirmed()
+	if _, exists := ids[txid]; exists {
+		t.Fatal("replaced transaction is still unconfirmed")
+	}
+	if _, exists := ids[rbfID]; !exists {
+		t.Fatal("replacement isn't unconfirmed")
+	}
+
+	// Attach a child to the replacement and mine the set.
+	cpfp, err := testNode.WalletTransactionBumpPost(rbfID, modules.FeeBumpCPFP, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range cpfp.TransactionIDs {
+		if _, err := testNode.WalletTransactionGet(id); err != nil {
+			t.Fatal("transaction wasn't confirmed", err)
+		}
+	}
+	if len(unconfirmed()) != 0 {
+		t.Fatal("expected no unconfirmed transactions")
+	}
+}
+
+// TestWalletCoinControl tests sending coins from selected outputs and freezing
+// outputs through the API.
+func TestWalletCoinControl(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Get two siacoin outputs of the wallet.
+	wug, err := testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []types.OutputID
+	for _, uo := range wug.Outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput && uo.Value.Cmp(types.SiacoinPrecision) > 0 {
+			ids = append(ids, uo.ID)
+		}
+	}
+	if len(ids) < 2 {
+		t.Fatal("expected at least two siacoin outputs", len(ids))
+	}
+
+	// Freeze the first output.
+	if err := testNode.WalletFreezePost(ids[:1]); err != nil {
+		t.Fatal(err)
+	}
+	wfg, err := testNode.WalletFrozenGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wfg.Outputs) != 1 || wfg.Outputs[0] != ids[0] {
+		t.Fatal("unexpected frozen outputs", wfg.Outputs)
+	}
+	wug, err = testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range wug.Outputs {
+		if uo.IsFrozen != (uo.ID == ids[0]) {
+			t.Fatal("wrong frozen flag for output", uo.ID)
+		}
+	}
+
+	// Spending the frozen output fails, spending the other one works.
+	out := []types.SiacoinOutput{{Value: types.SiacoinPrecision}}
+	_, err = testNode.WalletSiacoinsWithSelectionPost(out, modules.CoinSelection{Outputs: ids[:1]})
+	if err == nil || !strings.Contains(err.Error(), "output is frozen") {
+		t.Fatal("expected an error for a frozen output", err)
+	}
+	_, err = testNode.WalletSiacoinsWithSelectionPost(out, modules.CoinSelection{Strategy: "foo"})
+	if err == nil || !strings.Contains(err.Error(), "unknown coin selection strategy") {
+		t.Fatal("expected an error for an unknown strategy", err)
+	}
+	wsp, err := testNode.WalletSiacoinsWithSelectionPost(out, modules.CoinSelection{Outputs: ids[1:2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs := wsp.Transactions[0].SiacoinInputs; len(inputs) != 1 || types.OutputID(inputs[0].ParentID) != ids[1] {
+		t.Fatal("transaction doesn't spend the selected output", inputs)
+	}
+
+	// Unfreeze the output again.
+	if err := testNode.WalletUnfreezePost(ids[:1]); err != nil {
+		t.Fatal(err)
+	}
+	wfg, err = testNode.WalletFrozenGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wfg.Outputs) != 0 {
+		t.Fatal("expected no frozen outputs", wfg.Outputs)
+	}
+	_, err = testNode.WalletSiacoinsWithSelectionPost(out, modules.CoinSelection{Strategy: modules.CoinSelectionSmallestFirst})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWalletMultisig tests spending a 2-of-2 multisig output of two wallets by
+// passing a partially signed transaction between them.
+func TestWalletMultisig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with two co-signers.
+	groupParams := siatest.GroupParams{
+		Miners: 2,
+	}
+	tg, err := siatest.NewGroupFromTemplate(walletTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cosigners := tg.Miners()
+
+	// Create a multisig address from a key of each co-signer and let both of
+	// them track it.
+	var uc types.UnlockConditions
+	for _, cosigner := range cosigners {
+		wag, err := cosigner.WalletAddressGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wucg, err := cosigner.WalletUnlockConditionsGet(wag.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uc.PublicKeys = append(uc.PublicKeys, wucg.UnlockConditions.PublicKeys...)
+	}
+	uc.SignaturesRequired = uint64(len(cosigners))
+	addr := uc.UnlockHash()
+	for _, cosigner := range cosigners {
+		if err := cosigner.WalletUnlockConditionsPost(uc); err != nil {
+			t.Fatal(err)
+		}
+		if err := cosigner.WalletWatchAddPost([]types.UnlockHash{addr}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Fund the multisig address.
+	value := types.SiacoinPrecision.Mul64(100)
+	_, err = cosigners[0].WalletSiacoinsPost(value, addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cosigners[0].MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	wug, err := cosigners[1].WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parentID types.SiacoinOutputID
+	for _, uo := range wug.Outputs {
+		if uo.UnlockHash == addr {
+			parentID = types.SiacoinOutputID(uo.ID)
+		}
+	}
+	if parentID == (types.SiacoinOutputID{}) {
+		t.Fatal("multisig output not found")
+	}
+
+	// Create the partially signed transaction.
+	fee := types.SiacoinPrecision
+	txn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: parentID}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value.Sub(fee)}},
+		MinerFees:      []types.Currency{fee},
+	}
+	wmp, err := cosigners[0].WalletMultisigCreatePost(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pst := wmp.PartiallySignedTransaction
+
+	// Both co-signers sign the transaction independently. Neither copy can be
+	// finalized on its own.
+	var signed []modules.PartiallySignedTransaction
+	for _, cosigner := range cosigners {
+		wmp, err := cosigner.WalletMultisigSignPost(pst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary, err := cosigner.WalletMultisigInspectPost(wmp.PartiallySignedTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if summary.Complete || len(summary.Inputs) != 1 || len(summary.Inputs[0].SignedBy) != 1 {
+			t.Fatal("wrong summary", summary)
+		}
+		_, err = cosigner.WalletMultisigFinalizePost(wmp.PartiallySignedTransaction)
+		if err == nil || !strings.Contains(err.Error(), "missing signatures") {
+			t.Fatal("expected an error for missing signatures", err)
+		}
+		signed = append(signed, wmp.PartiallySignedTransaction)
+	}
+
+	// Combine and finalize the transaction.
+	wmp, err = cosigners[1].WalletMultisigCombinePost(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := cosigners[1].WalletMultisigInspectPost(wmp.PartiallySignedTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Complete || !summary.SiacoinInputs.Equals(value) || !summary.MinerFees.Equals(fee) {
+		t.Fatal("wrong summary", summary)
+	}
+	wmfp, err := cosigners[1].WalletMultisigFinalizePost(wmp.PartiallySignedTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wmfp.Transaction.ID() != summary.TransactionID {
+		t.Fatal("finalized transaction has the wrong id")
+	}
+
+	// Broadcast and mine the transaction.
+	if err := cosigners[1].TransactionPoolRawPost(wmfp.Transaction, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cosigners[1].MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	wtg, err := cosigners[0].WalletTransactionGet(wmfp.Transaction.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wtg.Transaction.ConfirmationHeight == types.BlockHeight(math.MaxUint64) {
+		t.Fatal("transaction wasn't confirmed")
+	}
+}
+
+// TestWalletOfflineSigning tests spending the outputs of a seed which is never
+// loaded into a node. The node watches the addresses of the seed and the
+// transaction is signed offline.
+func TestWalletOfflineSigning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Watch the addresses of an offline seed.
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	ucs := wallet.SeedUnlockConditions(seed, 0, 10)
+	var addrs []types.UnlockHash
+	for _, uc := range ucs {
+		if err := testNode.WalletUnlockConditionsPost(uc); err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, uc.UnlockHash())
+	}
+	if err := testNode.WalletWatchAddPost(addrs, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund one of the addresses.
+	value := types.SiacoinPrecision.Mul64(100)
+	_, err = testNode.WalletSiacoinsPost(value, addrs[3], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	wug, err := testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parentID types.SiacoinOutputID
+	for _, uo := range wug.Outputs {
+		if uo.UnlockHash == addrs[3] {
+			if !uo.IsWatchOnly {
+				t.Fatal("output should be watch-only")
+			}
+			parentID = types.SiacoinOutputID(uo.ID)
+		}
+	}
+	if parentID == (types.SiacoinOutputID{}) {
+		t.Fatal("output of the offline seed not found")
+	}
+
+	// Create the transaction on the node and sign it offline.
+	fee := types.SiacoinPrecision
+	txn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: parentID}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value.Sub(fee)}},
+		MinerFees:      []types.Currency{fee},
+	}
+	wmp, err := testNode.WalletMultisigCreatePost(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := wallet.SignPartiallySignedTransactionWithSeed(wmp.PartiallySignedTransaction, seed, cg.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Finalize and broadcast the transaction on the node.
+	wmfp, err := testNode.WalletMultisigFinalizePost(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.TransactionPoolRawPost(wmfp.Transaction, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	wug, err = testNode.WalletUnspentGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range wug.Outputs {
+		if uo.ID == types.OutputID(parentID) {
+			t.Fatal("output of the offline seed wasn't spent")
+		}
+	}
+}
+
+// TestWalletPaymentSubscriptions tests streaming the events of a payment
+// subscription.
+func TestWalletPaymentSubscriptions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(walletTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Subscribe to payments to a new address of the wallet.
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := wag.Address
+	wsp, err := testNode.WalletSubscriptionsAddPost(modules.PaymentSubscription{
+		Addresses: []types.UnlockHash{addr},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := wsp.Subscription
+	if sub.Confirmations != 1 || sub.Secret == "" {
+		t.Fatal("wrong subscription", sub)
+	}
+	wsg, err := testNode.WalletSubscriptionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wsg.Subscriptions) != 1 || wsg.Subscriptions[0].ID != sub.ID {
+		t.Fatal("wrong subscriptions", wsg.Subscriptions)
+	}
+	if _, _, err := testNode.WalletSubscriptionsEventsGet("foo"); err == nil {
+		t.Fatal("streaming the events of an unknown subscription should fail")
+	}
+	events, closeStream, err := testNode.WalletSubscriptionsEventsGet(sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := closeStream(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	nextEvent := func() modules.PaymentEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Minute):
+			t.Fatal("no payment event received")
+		}
+		return modules.PaymentEvent{}
+	}
+
+	// Send a payment to the address. It is reported once it is in the
+	// transaction pool and again once it is confirmed.
+	value := types.SiacoinPrecision.Mul64(10)
+	wsp2, err := testNode.WalletSiacoinsPost(value, addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp2.TransactionIDs[len(wsp2.TransactionIDs)-1]
+	event := nextEvent()
+	if event.Type != modules.PaymentEventUnconfirmed || event.SubscriptionID != sub.ID || event.TransactionID != txid || !event.Value.Equals(value) {
+		t.Fatal("wrong event", event)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	event = nextEvent()
+	if event.Type != modules.PaymentEventConfirmed || event.TransactionID != txid || event.Confirmations != 1 {
+		t.Fatal("wrong event", event)
+	}
+
+	// Remove the subscription.
+	if err := testNode.WalletSubscriptionsRemovePost(sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	wsg, err = testNode.WalletSubscriptionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wsg.Subscriptions) != 0 {
+		t.Fatal("expected no subscriptions", wsg.Subscriptions)
+	}
+}
@@ -92,3 +92,19 @@ func (r *ExchangeRate) ApplyAndFormat(c Currency) string {
 	result = fmt.Sprintf("~ %s %s", result, r.staticSymbol)
 	return result
 }
+
+// Unapply converts an amount in the currency of the exchange rate to
+// siacoins. It is the inverse of applying the exchange rate. Negative, NaN and
+// infinite amounts result in zero.
+func (r *ExchangeRate) Unapply(amount float64) Currency {
+	amountRat := new(big.Rat)
+	if amountRat.SetFloat64(amount) == nil || amountRat.Sign() <= 0 {
+		return ZeroCurrency
+	}
+	asRatio, _ := r.staticValue.Rat(nil)
+	precisionRat := new(big.Rat).SetInt(SiacoinPrecision.Big())
+
+	// calculate (amountRat * precisionRat) / asRatio
+	resultRat := new(big.Rat).Quo(new(big.Rat).Mul(amountRat, precisionRat), asRatio)
+	return NewCurrency(new(big.Int).Quo(resultRat.Num(), resultRat.Denom()))
+}
@@ -122,3 +122,35 @@ func TestApplyAndFormat(t *testing.T) {
 		}
 	}
 }
+
+// TestUnapply checks that amounts are correctly converted to siacoins.
+func TestUnapply(t *testing.T) {
+	mustParse := func(s string) *ExchangeRate {
+		rate, err := ParseExchangeRate(s)
+		if err != nil {
+			t.Fatalf("test case uses invalid exchange rate: %v", err)
+		}
+
+		return rate
+	}
+	tests := []struct {
+		rate   *ExchangeRate
+		amount float64
+		result Currency
+	}{
+		{mustParse("1 USD"), 1, SiacoinPrecision},
+		{mustParse("1 USD"), 0.5, SiacoinPrecision.Div64(2)},
+		{mustParse("0.5 USD"), 1, SiacoinPrecision.Mul64(2)},
+		{mustParse("0.25 USD"), 2, SiacoinPrecision.Mul64(8)},
+		{mustParse("1 USD"), 0, ZeroCurrency},
+		{mustParse("1 USD"), -1, ZeroCurrency},
+	}
+	for _, test := range tests {
+		result := test.rate.Unapply(test.amount)
+
+		if !test.result.Equals(result) {
+			t.Errorf("TestUnapply with %v %v and %v: expected %v, got %v",
+				test.rate.staticValue, test.rate.staticSymbol, test.amount, test.result, result)
+		}
+	}
+}